@@ -1,16 +1,66 @@
 package main
 
 import (
+	"flag"
 	"log"
+	// timezones users pick don't depend on the host having them installed
+	_ "time/tzdata"
 
 	"forum/internal/app"
 	"forum/internal/config"
 )
 
 func main() {
+	migrate := flag.String("migrate", "", "run migrations and exit: "+
+		app.MigrateUp+", "+app.MigrateDown+", "+app.MigrateStatus+" or a target version")
+	recount := flag.Bool("recount", false, "rebuild post, comment and reaction counters and exit")
+	backup := flag.Bool("backup", false, "write a snapshot of the sqlite database into the backup directory and exit")
+	restore := flag.String("restore", "", "replace the sqlite database with the given snapshot file and exit")
+	export := flag.String("export", "", "export the forum into the given archive file and exit")
+	exportPasswords := flag.Bool("export-passwords", false, "include password hashes in the export")
+	importPath := flag.String("import", "", "import the forum from the given archive file and exit")
+	flag.Parse()
+
 	cfg, err := config.LoadConfig("config.json")
 	if err != nil {
 		log.Fatal(err)
 	}
+
+	if *migrate != "" {
+		if err := app.Migrate(cfg, *migrate); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if *backup {
+		if err := app.Backup(cfg); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if *restore != "" {
+		if err := app.Restore(cfg, *restore); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if *export != "" {
+		if err := app.Export(cfg, *export, *exportPasswords); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if *importPath != "" {
+		if err := app.Import(cfg, *importPath); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if *recount {
+		if err := app.Recount(cfg); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 	app.Run(cfg)
 }
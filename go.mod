@@ -8,3 +8,7 @@ require (
 )
 
 require github.com/gofrs/uuid v4.3.1+incompatible
+
+require github.com/lib/pq v1.10.9
+
+require github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
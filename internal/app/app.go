@@ -1,55 +1,135 @@
 package app
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strconv"
 	"syscall"
+	"time"
 
 	"forum/internal/config"
 	v1 "forum/internal/controller/http/v1"
 	"forum/internal/repository"
+	"forum/internal/repository/memory"
+	pgrepo "forum/internal/repository/postgres"
 	"forum/internal/repository/sqlite"
 	"forum/internal/usecase"
 	"forum/pkg/auth"
 	"forum/pkg/hasher"
 	"forum/pkg/httpserver"
 	"forum/pkg/logger"
+	"forum/pkg/mailer"
+	"forum/pkg/migrate"
+	"forum/pkg/postgres"
+	"forum/pkg/signer"
 	"forum/pkg/sqlite3"
 )
 
+// imageDir keeps uploaded images, relative to the working directory.
+const imageDir = "templates/img/storage"
+
+const (
+	DriverSqlite   = "sqlite3"
+	DriverPostgres = "postgres"
+	DriverMemory   = "memory"
+)
+
+const (
+	MigrateUp     = "up"
+	MigrateDown   = "down"
+	MigrateStatus = "status"
+)
+
 func Run(cfg config.Config) {
 	// Logger
 	l := logger.New()
 
-	// Sqlite
-	sq, err := sqlite3.New("database/forum.db")
+	// Database
+	repo, migrator, closeDB, err := openDatabase(cfg)
 	if err != nil {
-		l.WriteLog(fmt.Errorf("app - Run - sqlite3.New: %w", err))
+		l.WriteLog(fmt.Errorf("app - Run - %w", err))
 		return
 	}
-	defer sq.Close()
+	defer closeDB()
 
-	// Repository
-	repo := repository.NewRepositories(sq)
-	err = sqlite.CreateDB(sq)
-	if err != nil {
-		l.WriteLog(fmt.Errorf("app - Run - NewRepositories: %w", err))
-		return
+	// Migrations
+	if migrator != nil {
+		err = migrator.Check()
+		if err != nil {
+			l.WriteLog(fmt.Errorf("app - Run - migrator.Check: %w", err))
+			log.Printf("refusing to start: %s\n", err.Error())
+			return
+		}
+		if cfg.Database.AutoMigrate {
+			err = migrator.Up()
+			if err != nil {
+				l.WriteLog(fmt.Errorf("app - Run - migrator.Up: %w", err))
+				return
+			}
+		} else {
+			pending, err := migrator.Pending()
+			if err != nil {
+				l.WriteLog(fmt.Errorf("app - Run - migrator.Pending: %w", err))
+				return
+			}
+			if len(pending) != 0 {
+				log.Printf("refusing to start: %d pending migrations, run with -migrate %s\n",
+					len(pending), MigrateUp)
+				return
+			}
+		}
 	}
 
 	// Dependencies
 	hasher := hasher.NewBcryptHasher()
 	tokenManager := auth.NewManager(cfg)
+	mailer := newMailer(cfg)
+	signer, err := newSigner()
+	if err != nil {
+		l.WriteLog(fmt.Errorf("app - Run - %w", err))
+		return
+	}
 
 	// Usecases
-	postsUseCase := usecase.NewPostsUseCase(repo.Posts, repo.Users, repo.Comments)
-	usersUseCase := usecase.NewUsersUseCase(repo.Users, hasher, tokenManager, repo.Posts, repo.Comments)
-	commentsUseCase := usecase.NewCommentsUseCase(repo.Comments, repo.Posts, repo.Users)
-	useCases := usecase.NewUseCases(postsUseCase, usersUseCase, commentsUseCase)
+	postsUseCase := usecase.NewPostsUseCase(repo.Posts, repo.Users, repo.Comments, repo.Reactions, repo.Revisions,
+		repo.UnitOfWork, cfg.Reactions)
+	categoriesUseCase := usecase.NewCategoriesUseCase(repo.Categories, repo.UnitOfWork)
+	usersUseCase := usecase.NewUsersUseCase(repo.Users, hasher, tokenManager, repo.Posts, repo.Comments,
+		repo.Reactions, repo.Sessions, repo.Roles, repo.UnitOfWork, cfg.Reactions)
+	rolesUseCase := usecase.NewRolesUseCase(repo.Roles, repo.UnitOfWork)
+	resetsUseCase := usecase.NewPasswordResetsUseCase(repo.Resets, repo.Users, hasher, tokenManager, mailer,
+		repo.UnitOfWork, cfg.Mail.SiteURL, time.Duration(cfg.PasswordReset.TokenTTL)*time.Second)
+	verificationUseCase := usecase.NewVerificationUseCase(repo.Users, signer, mailer, cfg.Mail.SiteURL,
+		time.Duration(cfg.Verification.TokenTTL)*time.Second, time.Duration(cfg.Verification.ResendInterval)*time.Second)
+	twoFactorUseCase := usecase.NewTwoFactorUseCase(repo.TwoFactor, repo.Challenges, repo.Users, tokenManager,
+		repo.UnitOfWork, cfg.TwoFactor.Issuer, time.Duration(cfg.TwoFactor.ChallengeTTL)*time.Second)
+	commentsUseCase := usecase.NewCommentsUseCase(repo.Comments, repo.Posts, repo.Users, repo.Reactions,
+		repo.Revisions, repo.UnitOfWork, cfg.Reactions, cfg.Comments.MaxDepth)
+	backupsUseCase := usecase.NewBackupsUseCase(repo.Backups, cfg.Backup.Dir, cfg.Backup.Keep)
+	images, err := filepath.Abs(imageDir)
+	if err != nil {
+		l.WriteLog(fmt.Errorf("app - Run - Abs: %w", err))
+		return
+	}
+	archiveUseCase := usecase.NewArchiveUseCase(repo.UnitOfWork, images)
+	useCases := usecase.NewUseCases(postsUseCase, categoriesUseCase, usersUseCase, rolesUseCase, resetsUseCase,
+		verificationUseCase, twoFactorUseCase, commentsUseCase, backupsUseCase, archiveUseCase)
+
+	// Trash
+	stopPurge := startPurge(cfg, useCases, l)
+	defer stopPurge()
+
+	// Backups
+	if repo.Backups != nil {
+		stopBackups := startBackups(cfg, useCases, l)
+		defer stopBackups()
+	}
 
 	// Http
 	handler := v1.NewHandler(useCases, cfg, l)
@@ -72,3 +152,308 @@ func Run(cfg config.Config) {
 		l.WriteLog(fmt.Errorf("app - Run - httpServer.Shutdown: %w", err))
 	}
 }
+
+// newMailer sends letters the way the config asks, the SMTP password is
+// taken from the environment.
+func newMailer(cfg config.Config) mailer.Mailer {
+	if cfg.Mail.Driver == config.MailSMTP {
+		return mailer.NewSMTPMailer(cfg.Mail.SMTP.Host, cfg.Mail.SMTP.Port, cfg.Mail.SMTP.Username,
+			os.Getenv("SMTP_PASSWORD"), cfg.Mail.From)
+	}
+	return mailer.NewFileMailer(cfg.Mail.File, cfg.Mail.From)
+}
+
+// newSigner signs verification links with the VERIFICATION_SECRET variable.
+// Without it a random key is used, links mailed before a restart stop
+// working then.
+func newSigner() (signer.Signer, error) {
+	if secret := os.Getenv("VERIFICATION_SECRET"); secret != "" {
+		return signer.NewHMACSigner([]byte(secret)), nil
+	}
+	key, err := signer.RandomKey()
+	if err != nil {
+		return nil, fmt.Errorf("newSigner - %w", err)
+	}
+	log.Println("VERIFICATION_SECRET is not set, verification links won't survive a restart")
+	return signer.NewHMACSigner(key), nil
+}
+
+// Migrate runs migrations on demand. Command is one of MigrateUp,
+// MigrateDown, MigrateStatus or a target version number.
+func Migrate(cfg config.Config, command string) error {
+	_, migrator, closeDB, err := openDatabase(cfg)
+	if err != nil {
+		return fmt.Errorf("app - Migrate - %w", err)
+	}
+	defer closeDB()
+	if migrator == nil {
+		return fmt.Errorf("app - Migrate - driver %q has no schema", cfg.Database.Driver)
+	}
+
+	switch command {
+	case MigrateUp:
+		err = migrator.Up()
+	case MigrateDown:
+		err = migrator.Down()
+	case MigrateStatus:
+	default:
+		target, convErr := strconv.Atoi(command)
+		if convErr != nil {
+			return fmt.Errorf("app - Migrate - Atoi: %w", convErr)
+		}
+		err = migrator.To(target)
+	}
+	if err != nil {
+		return fmt.Errorf("app - Migrate - %s: %w", command, err)
+	}
+
+	version, err := migrator.Version()
+	if err != nil {
+		return fmt.Errorf("app - Migrate - Version: %w", err)
+	}
+	pending, err := migrator.Pending()
+	if err != nil {
+		return fmt.Errorf("app - Migrate - Pending: %w", err)
+	}
+	fmt.Printf("Schema version: %d, latest: %d, pending: %d\n",
+		version, migrator.Latest(), len(pending))
+	return nil
+}
+
+// Recount rebuilds the denormalized counters from the rows they count.
+func Recount(cfg config.Config) error {
+	repo, migrator, closeDB, err := openDatabase(cfg)
+	if err != nil {
+		return fmt.Errorf("app - Recount - %w", err)
+	}
+	defer closeDB()
+	if migrator != nil {
+		if err = migrator.Check(); err != nil {
+			return fmt.Errorf("app - Recount - %w", err)
+		}
+	}
+
+	if err = repo.Counters.Recount(context.Background()); err != nil {
+		return fmt.Errorf("app - Recount - %w", err)
+	}
+	fmt.Println("Counters are rebuilt")
+	return nil
+}
+
+// Backup writes a snapshot of the database into the backup directory.
+func Backup(cfg config.Config) error {
+	repo, _, closeDB, err := openDatabase(cfg)
+	if err != nil {
+		return fmt.Errorf("app - Backup - %w", err)
+	}
+	defer closeDB()
+
+	backup, err := usecase.NewBackupsUseCase(repo.Backups, cfg.Backup.Dir, cfg.Backup.Keep).CreateBackup(context.Background())
+	if err != nil {
+		return fmt.Errorf("app - Backup - %w", err)
+	}
+	fmt.Printf("Backup %s is written to %s\n", backup.Name, cfg.Backup.Dir)
+	return nil
+}
+
+// Restore replaces the database with the snapshot file at path, snapshots
+// of a schema newer than the application knows are refused.
+func Restore(cfg config.Config, path string) error {
+	repo, _, closeDB, err := openDatabase(cfg)
+	if err != nil {
+		return fmt.Errorf("app - Restore - %w", err)
+	}
+	defer closeDB()
+
+	err = usecase.NewBackupsUseCase(repo.Backups, cfg.Backup.Dir, cfg.Backup.Keep).RestoreBackup(context.Background(), path)
+	if err != nil {
+		return fmt.Errorf("app - Restore - %w", err)
+	}
+	fmt.Printf("Database is restored from %s\n", path)
+	return nil
+}
+
+// Export writes the forum into the archive file at path, password hashes
+// are included when withPasswords is set.
+func Export(cfg config.Config, path string, withPasswords bool) error {
+	repo, migrator, closeDB, err := openDatabase(cfg)
+	if err != nil {
+		return fmt.Errorf("app - Export - %w", err)
+	}
+	defer closeDB()
+	if migrator != nil {
+		if err = migrator.Check(); err != nil {
+			return fmt.Errorf("app - Export - %w", err)
+		}
+	}
+	images, err := filepath.Abs(imageDir)
+	if err != nil {
+		return fmt.Errorf("app - Export - Abs: %w", err)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("app - Export - Create: %w", err)
+	}
+	err = usecase.NewArchiveUseCase(repo.UnitOfWork, images).Export(context.Background(), file, withPasswords)
+	if err != nil {
+		file.Close()
+		os.Remove(path)
+		return fmt.Errorf("app - Export - %w", err)
+	}
+	if err = file.Close(); err != nil {
+		return fmt.Errorf("app - Export - Close: %w", err)
+	}
+	fmt.Printf("Forum is exported to %s\n", path)
+	return nil
+}
+
+// Import recreates the forum exported to the archive file at path.
+func Import(cfg config.Config, path string) error {
+	repo, migrator, closeDB, err := openDatabase(cfg)
+	if err != nil {
+		return fmt.Errorf("app - Import - %w", err)
+	}
+	defer closeDB()
+	if migrator != nil {
+		if err = migrator.Check(); err != nil {
+			return fmt.Errorf("app - Import - %w", err)
+		}
+		if err = migrator.Up(); err != nil {
+			return fmt.Errorf("app - Import - %w", err)
+		}
+	}
+	images, err := filepath.Abs(imageDir)
+	if err != nil {
+		return fmt.Errorf("app - Import - Abs: %w", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("app - Import - Open: %w", err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("app - Import - Stat: %w", err)
+	}
+
+	summary, err := usecase.NewArchiveUseCase(repo.UnitOfWork, images).Import(context.Background(), file, info.Size())
+	if err != nil {
+		return fmt.Errorf("app - Import - %w", err)
+	}
+	fmt.Printf("Imported users: %d, posts: %d, comments: %d, reactions: %d, images: %d\n",
+		summary.Users, summary.Posts, summary.Comments, summary.Reactions, summary.Images)
+	return nil
+}
+
+// openDatabase opens the configured backend and returns its repositories
+// together with the migrator of the same backend. The memory backend has
+// no schema, its migrator is nil.
+func openDatabase(cfg config.Config) (*repository.Repositories, *migrate.Migrator, func(), error) {
+	switch cfg.Database.Driver {
+	case DriverSqlite, "":
+		opts := cfg.Database.Sqlite
+		sq, err := sqlite3.New(opts.Path, sqlite3.Options{
+			JournalMode:     opts.JournalMode,
+			Synchronous:     opts.Synchronous,
+			BusyTimeout:     time.Duration(opts.BusyTimeout) * time.Millisecond,
+			ForeignKeys:     opts.ForeignKeys,
+			MaxOpenConns:    opts.MaxOpenConns,
+			MaxIdleConns:    opts.MaxIdleConns,
+			ConnMaxLifetime: time.Duration(opts.ConnMaxLifetime) * time.Second,
+		})
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("openDatabase - sqlite3.New: %w", err)
+		}
+		return repository.NewRepositories(sq), sqlite.NewMigrator(sq), sq.Close, nil
+	case DriverPostgres:
+		dsn := cfg.Database.DSN
+		if env := os.Getenv("POSTGRES_DSN"); env != "" {
+			dsn = env
+		}
+		pg, err := postgres.New(dsn)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("openDatabase - postgres.New: %w", err)
+		}
+		return repository.NewPostgresRepositories(pg), pgrepo.NewMigrator(pg), pg.Close, nil
+	case DriverMemory:
+		db := memory.New()
+		return repository.NewMemoryRepositories(db), nil, db.Close, nil
+	}
+	return nil, nil, nil, fmt.Errorf("openDatabase - unknown driver %q", cfg.Database.Driver)
+}
+
+// startPurge removes posts and comments that stayed in the trash longer
+// than the retention period, once at start and then every purge interval.
+// The returned function stops it, canceling a running purge, and waits
+// for it to return.
+func startPurge(cfg config.Config, useCases *usecase.UseCases, l *logger.Logger) func() {
+	if cfg.Trash.RetentionDays <= 0 {
+		return func() {}
+	}
+	retention := time.Duration(cfg.Trash.RetentionDays) * 24 * time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	purge := func() {
+		if _, err := useCases.Posts.PurgeDeleted(ctx, retention); err != nil {
+			l.WriteLog(fmt.Errorf("app - purge - Posts: %w", err))
+		}
+		if _, err := useCases.Comments.PurgeDeleted(ctx, retention); err != nil {
+			l.WriteLog(fmt.Errorf("app - purge - Comments: %w", err))
+		}
+	}
+
+	ticker := time.NewTicker(time.Duration(cfg.Trash.PurgeInterval) * time.Second)
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		defer ticker.Stop()
+		purge()
+		for {
+			select {
+			case <-ticker.C:
+				purge()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-stopped
+	}
+}
+
+// startBackups snapshots the database every backup interval, the first
+// snapshot is taken one interval after start. The returned function stops
+// it, canceling a running snapshot, and waits for it to return.
+func startBackups(cfg config.Config, useCases *usecase.UseCases, l *logger.Logger) func() {
+	if cfg.Backup.Interval <= 0 {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ticker := time.NewTicker(time.Duration(cfg.Backup.Interval) * time.Second)
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := useCases.Backups.CreateBackup(ctx); err != nil {
+					l.WriteLog(fmt.Errorf("app - backup - %w", err))
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-stopped
+	}
+}
@@ -9,6 +9,9 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
+
+	"forum/internal/entity"
 )
 
 type Config struct {
@@ -23,8 +26,113 @@ type Config struct {
 		SessionExpiringTime int    `json:"session_expiring_time"`
 		TokenName           string `json:"token_name"`
 	} `json:"token_manager"`
+	Database struct {
+		Driver      string `json:"driver"`
+		DSN         string `json:"dsn"`
+		AutoMigrate bool   `json:"auto_migrate"`
+		// Sqlite is applied to every connection of the sqlite3 driver.
+		// BusyTimeout is in milliseconds, ConnMaxLifetime in seconds, zero
+		// pool limits keep the defaults of database/sql.
+		Sqlite struct {
+			Path            string `json:"path"`
+			JournalMode     string `json:"journal_mode"`
+			Synchronous     string `json:"synchronous"`
+			BusyTimeout     int    `json:"busy_timeout"`
+			ForeignKeys     bool   `json:"foreign_keys"`
+			MaxOpenConns    int    `json:"max_open_conns"`
+			MaxIdleConns    int    `json:"max_idle_conns"`
+			ConnMaxLifetime int    `json:"conn_max_lifetime"`
+		} `json:"sqlite"`
+	} `json:"database"`
+	// Reactions lists the reaction kinds offered on posts and comments,
+	// entity.DefaultReactionKinds is used when it is empty.
+	Reactions entity.ReactionKinds `json:"reactions"`
+	// Trash keeps deleted posts and comments for RetentionDays before they
+	// are purged, purge runs every PurgeInterval seconds. Zero
+	// RetentionDays keeps them forever.
+	Trash struct {
+		RetentionDays int `json:"retention_days"`
+		PurgeInterval int `json:"purge_interval"`
+	} `json:"trash"`
+	// Comments nests replies MaxDepth levels below top level comments,
+	// deeper replies are shown at the last level.
+	Comments struct {
+		MaxDepth int `json:"max_depth"`
+	} `json:"comments"`
+	// Backup snapshots the sqlite database into Dir every Interval seconds
+	// and keeps the Keep latest snapshots. Zero Interval turns scheduled
+	// snapshots off, zero Keep never removes them.
+	Backup struct {
+		Dir      string `json:"dir"`
+		Interval int    `json:"interval"`
+		Keep     int    `json:"keep"`
+	} `json:"backup"`
+	// Mail sends letters through SMTP when Driver is "smtp" or appends
+	// them to File when it is "file". Links in letters start with SiteURL,
+	// the SMTP password is read from the SMTP_PASSWORD variable.
+	Mail struct {
+		Driver  string `json:"driver"`
+		From    string `json:"from"`
+		File    string `json:"file"`
+		SiteURL string `json:"site_url"`
+		SMTP    struct {
+			Host     string `json:"host"`
+			Port     int    `json:"port"`
+			Username string `json:"username"`
+		} `json:"smtp"`
+	} `json:"mail"`
+	// PasswordReset links are valid for TokenTTL seconds.
+	PasswordReset struct {
+		TokenTTL int `json:"token_ttl"`
+	} `json:"password_reset"`
+	// Verification links are valid for TokenTTL seconds, a new one can be
+	// asked for every ResendInterval seconds. Links are signed with the
+	// VERIFICATION_SECRET variable.
+	Verification struct {
+		TokenTTL       int `json:"token_ttl"`
+		ResendInterval int `json:"resend_interval"`
+	} `json:"verification"`
+	// TwoFactor codes are added to authenticator apps under Issuer, the
+	// code has to be typed within ChallengeTTL seconds of the password.
+	TwoFactor struct {
+		Issuer       string `json:"issuer"`
+		ChallengeTTL int    `json:"challenge_ttl"`
+	} `json:"two_factor"`
+	// Timezone is the IANA name of the timezone dates are shown in to
+	// guests and users who haven't picked their own, empty means UTC.
+	Timezone string `json:"timezone"`
 }
 
+const (
+	defaultPurgeInterval    = 3600
+	defaultCommentsMaxDepth = 5
+	defaultBackupDir        = "database/backups"
+	defaultSqlitePath       = "database/forum.db"
+	defaultJournalMode      = "WAL"
+	defaultSynchronous      = "NORMAL"
+	defaultBusyTimeout      = 5000
+	defaultMailDriver       = MailFile
+	defaultMailFile         = "database/mail.log"
+	defaultMailFrom         = "forum@localhost"
+	defaultSMTPPort         = 587
+	defaultResetTokenTTL    = 3600
+	defaultVerifyTokenTTL   = 86400
+	defaultResendInterval   = 60
+	defaultTOTPIssuer       = "Forum"
+	defaultChallengeTTL     = 300
+)
+
+// Mail drivers.
+const (
+	MailSMTP = "smtp"
+	MailFile = "file"
+)
+
+var (
+	journalModes = []string{"DELETE", "TRUNCATE", "PERSIST", "MEMORY", "WAL", "OFF"}
+	syncModes    = []string{"OFF", "NORMAL", "FULL", "EXTRA"}
+)
+
 func LoadConfig(filename string) (Config, error) {
 	// loading config file
 	config := Config{}
@@ -43,6 +151,42 @@ func LoadConfig(filename string) (Config, error) {
 	if err != nil {
 		return config, err
 	}
+	if len(config.Reactions) == 0 {
+		config.Reactions = entity.DefaultReactionKinds
+	}
+	if config.Trash.PurgeInterval <= 0 {
+		config.Trash.PurgeInterval = defaultPurgeInterval
+	}
+	if config.Comments.MaxDepth <= 0 {
+		config.Comments.MaxDepth = defaultCommentsMaxDepth
+	}
+	if config.Backup.Dir == "" {
+		config.Backup.Dir = defaultBackupDir
+	}
+	if err = setSqliteDefaults(&config); err != nil {
+		return config, err
+	}
+	if err = setMailDefaults(&config); err != nil {
+		return config, err
+	}
+	if config.PasswordReset.TokenTTL <= 0 {
+		config.PasswordReset.TokenTTL = defaultResetTokenTTL
+	}
+	if config.Verification.TokenTTL <= 0 {
+		config.Verification.TokenTTL = defaultVerifyTokenTTL
+	}
+	if config.Verification.ResendInterval <= 0 {
+		config.Verification.ResendInterval = defaultResendInterval
+	}
+	if config.TwoFactor.Issuer == "" {
+		config.TwoFactor.Issuer = defaultTOTPIssuer
+	}
+	if config.TwoFactor.ChallengeTTL <= 0 {
+		config.TwoFactor.ChallengeTTL = defaultChallengeTTL
+	}
+	if _, err = time.LoadLocation(config.Timezone); err != nil {
+		return config, fmt.Errorf("LoadConfig - timezone: %w", err)
+	}
 
 	// seting env variables
 	if err = setEnv(); err != nil {
@@ -51,6 +195,64 @@ func LoadConfig(filename string) (Config, error) {
 	return config, nil
 }
 
+func setSqliteDefaults(config *Config) error {
+	sq := &config.Database.Sqlite
+	if sq.Path == "" {
+		sq.Path = defaultSqlitePath
+	}
+	if sq.JournalMode == "" {
+		sq.JournalMode = defaultJournalMode
+	}
+	if sq.Synchronous == "" {
+		sq.Synchronous = defaultSynchronous
+	}
+	if sq.BusyTimeout <= 0 {
+		sq.BusyTimeout = defaultBusyTimeout
+	}
+	sq.JournalMode = strings.ToUpper(sq.JournalMode)
+	if !contains(journalModes, sq.JournalMode) {
+		return fmt.Errorf("LoadConfig - sqlite journal_mode: unknown mode %q", sq.JournalMode)
+	}
+	sq.Synchronous = strings.ToUpper(sq.Synchronous)
+	if !contains(syncModes, sq.Synchronous) {
+		return fmt.Errorf("LoadConfig - sqlite synchronous: unknown mode %q", sq.Synchronous)
+	}
+	return nil
+}
+
+func setMailDefaults(config *Config) error {
+	mail := &config.Mail
+	if mail.Driver == "" {
+		mail.Driver = defaultMailDriver
+	}
+	if mail.Driver != MailSMTP && mail.Driver != MailFile {
+		return fmt.Errorf("LoadConfig - mail driver: unknown driver %q", mail.Driver)
+	}
+	if mail.File == "" {
+		mail.File = defaultMailFile
+	}
+	if mail.From == "" {
+		mail.From = defaultMailFrom
+	}
+	if mail.SMTP.Port <= 0 {
+		mail.SMTP.Port = defaultSMTPPort
+	}
+	if mail.SiteURL == "" {
+		mail.SiteURL = "http://" + config.Server.Host + config.Server.Port
+	}
+	mail.SiteURL = strings.TrimSuffix(mail.SiteURL, "/")
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
 func setEnv() error {
 	root := getRootPath()
 	file, err := os.Open(root + ".env.example")
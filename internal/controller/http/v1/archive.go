@@ -0,0 +1,97 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"forum/internal/entity"
+)
+
+func (h *Handler) ExportForumHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.Errors(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	content, ok := r.Context().Value(Key("content")).(Content)
+	if !ok {
+		h.l.WriteLog(fmt.Errorf("v1 - ExportForumHandler - TypeAssertion:"+
+			"got data of type %T but wanted v1.Content", content))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	if !content.Can(entity.PermManageBackups) {
+		h.Errors(w, http.StatusForbidden)
+		return
+	}
+
+	name := "forum-export-" + time.Now().Format("20060102-150405") + ".zip"
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	w.Header().Set("Content-Type", "application/zip")
+	// the archive is streamed, an error past this point can only be logged
+	err := h.Usecases.Archive.Export(r.Context(), w, r.FormValue("passwords") != "")
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - ExportForumHandler - Export: %w", err))
+	}
+}
+
+func (h *Handler) ImportForumHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.Errors(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	content, ok := r.Context().Value(Key("content")).(Content)
+	if !ok {
+		h.l.WriteLog(fmt.Errorf("v1 - ImportForumHandler - TypeAssertion:"+
+			"got data of type %T but wanted v1.Content", content))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	if !content.Can(entity.PermManageBackups) {
+		h.Errors(w, http.StatusForbidden)
+		return
+	}
+
+	err := r.ParseMultipartForm(ImageSizeInt << 20)
+	if err != nil {
+		h.Errors(w, http.StatusBadRequest)
+		return
+	}
+	file, header, err := r.FormFile("archive")
+	if err != nil {
+		h.Errors(w, http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	summary, err := h.Usecases.Archive.Import(r.Context(), file, header.Size)
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - ImportForumHandler - Import: %w", err))
+		if errors.Is(err, entity.ErrArchiveInvalid) || errors.Is(err, entity.ErrArchiveVersion) {
+			h.Errors(w, http.StatusBadRequest)
+			return
+		}
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	backups, err := h.Usecases.Backups.GetBackups()
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - ImportForumHandler - GetBackups: %w", err))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+	content.Backups = backups
+	content.Message = fmt.Sprintf(ForumImported, summary.Users, summary.Posts, summary.Comments,
+		summary.Reactions, summary.Images)
+
+	err = h.ParseAndExecute(w, content, "templates/backups.html")
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - ImportForumHandler - ParseAndExecute - %w", err))
+	}
+}
@@ -0,0 +1,115 @@
+package v1_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"forum/internal/entity"
+)
+
+func TestExportForumHandler(t *testing.T) {
+	ctx := context.Background()
+	handler := setup()
+
+	t.Run("OK", func(t *testing.T) {
+		if err := handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/export_forum?passwords=on", nil)
+		cookie := &http.Cookie{
+			Name: "session_token",
+		}
+		req.AddCookie(cookie)
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("want: %v, got: %v", http.StatusOK, rec.Code)
+		}
+		if got := rec.Header().Get("Content-Type"); got != "application/zip" {
+			t.Fatalf("want: %v, got: %v", "application/zip", got)
+		}
+	})
+
+	t.Run("err low access level", func(t *testing.T) {
+		if err := handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/export_forum", nil)
+		cookie := &http.Cookie{
+			Name: "session_token",
+		}
+		req.AddCookie(cookie)
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusForbidden {
+			t.Fatalf("want: %v, got: %v", http.StatusForbidden, rec.Code)
+		}
+	})
+}
+
+func TestImportForumHandler(t *testing.T) {
+	ctx := context.Background()
+	handler := setup()
+
+	t.Run("OK", func(t *testing.T) {
+		if err := handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
+			t.Fatal(err)
+		}
+		body, mw := CreateMultipartForm(t, "../../../../config.json", "", "archive")
+		mw.Close()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/import_forum", body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		cookie := &http.Cookie{
+			Name: "session_token",
+		}
+		req.AddCookie(cookie)
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("want: %v, got: %v", http.StatusOK, rec.Code)
+		}
+	})
+
+	t.Run("err bad request", func(t *testing.T) {
+		body, mw := CreateMultipartForm(t, "", "", "")
+		mw.Close()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/import_forum", body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		cookie := &http.Cookie{
+			Name: "session_token",
+		}
+		req.AddCookie(cookie)
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("want: %v, got: %v", http.StatusBadRequest, rec.Code)
+		}
+	})
+
+	t.Run("err method not allowed", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/import_forum", nil)
+		cookie := &http.Cookie{
+			Name: "session_token",
+		}
+		req.AddCookie(cookie)
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Fatalf("want: %v, got: %v", http.StatusMethodNotAllowed, rec.Code)
+		}
+	})
+}
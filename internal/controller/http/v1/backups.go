@@ -0,0 +1,125 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"forum/internal/entity"
+)
+
+func (h *Handler) BackupsPageHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.Errors(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	content, ok := r.Context().Value(Key("content")).(Content)
+	if !ok {
+		h.l.WriteLog(fmt.Errorf("v1 - BackupsPageHandler - TypeAssertion:"+
+			"got data of type %T but wanted v1.Content", content))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	if !content.Can(entity.PermManageBackups) {
+		h.Errors(w, http.StatusForbidden)
+		return
+	}
+
+	backups, err := h.Usecases.Backups.GetBackups()
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - BackupsPageHandler - GetBackups: %w", err))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+	content.Backups = backups
+	if h.Cfg.Backup.Keep > 0 {
+		content.Message = fmt.Sprintf(BackupsKept, h.Cfg.Backup.Keep)
+	}
+
+	err = h.ParseAndExecute(w, content, "templates/backups.html")
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - BackupsPageHandler - ParseAndExecute - %w", err))
+	}
+}
+
+func (h *Handler) CreateBackupHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.Errors(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	content, ok := r.Context().Value(Key("content")).(Content)
+	if !ok {
+		h.l.WriteLog(fmt.Errorf("v1 - CreateBackupHandler - TypeAssertion:"+
+			"got data of type %T but wanted v1.Content", content))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	if !content.Can(entity.PermManageBackups) {
+		h.Errors(w, http.StatusForbidden)
+		return
+	}
+
+	_, err := h.Usecases.Backups.CreateBackup(r.Context())
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - CreateBackupHandler - CreateBackup: %w", err))
+		if errors.Is(err, entity.ErrBackupUnsupported) {
+			h.Errors(w, http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, entity.ErrBackupExists) {
+			h.errorPage(w, ErrMessage{Code: http.StatusConflict, Message: BackupExists})
+			return
+		}
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/backups", http.StatusFound)
+}
+
+func (h *Handler) DownloadBackupHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.Errors(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := strings.Split(r.URL.Path, "/")
+	name := path[len(path)-1]
+	if r.URL.Path != "/backups/"+name || name == "" {
+		h.Errors(w, http.StatusNotFound)
+		return
+	}
+
+	content, ok := r.Context().Value(Key("content")).(Content)
+	if !ok {
+		h.l.WriteLog(fmt.Errorf("v1 - DownloadBackupHandler - TypeAssertion:"+
+			"got data of type %T but wanted v1.Content", content))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	if !content.Can(entity.PermManageBackups) {
+		h.Errors(w, http.StatusForbidden)
+		return
+	}
+
+	file, err := h.Usecases.Backups.GetBackupPath(name)
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - DownloadBackupHandler - GetBackupPath: %w", err))
+		if errors.Is(err, entity.ErrBackupNotFound) {
+			h.Errors(w, http.StatusNotFound)
+			return
+		}
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	w.Header().Set("Content-Type", "application/vnd.sqlite3")
+	http.ServeFile(w, r, file)
+}
@@ -0,0 +1,155 @@
+package v1_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"forum/internal/entity"
+)
+
+func TestBackupsPageHandler(t *testing.T) {
+	ctx := context.Background()
+	handler := setup()
+
+	t.Run("OK", func(t *testing.T) {
+		if err := handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/backups", nil)
+		cookie := &http.Cookie{
+			Name: "session_token",
+		}
+		req.AddCookie(cookie)
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("want: %v, got: %v", http.StatusOK, rec.Code)
+		}
+	})
+
+	t.Run("err not authorized", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/backups", nil)
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusUnauthorized {
+			t.Fatalf("want: %v, got: %v", http.StatusUnauthorized, rec.Code)
+		}
+	})
+
+	t.Run("err low access level", func(t *testing.T) {
+		if err := handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/backups", nil)
+		cookie := &http.Cookie{
+			Name: "session_token",
+		}
+		req.AddCookie(cookie)
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusForbidden {
+			t.Fatalf("want: %v, got: %v", http.StatusForbidden, rec.Code)
+		}
+	})
+}
+
+func TestCreateBackupHandler(t *testing.T) {
+	ctx := context.Background()
+	handler := setup()
+
+	t.Run("OK", func(t *testing.T) {
+		if err := handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/create_backup", nil)
+		cookie := &http.Cookie{
+			Name: "session_token",
+		}
+		req.AddCookie(cookie)
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusFound {
+			t.Fatalf("want: %v, got: %v", http.StatusFound, rec.Code)
+		}
+	})
+
+	t.Run("err exists", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/create_backup", nil)
+		cookie := &http.Cookie{
+			Name: "session_token",
+		}
+		req.AddCookie(cookie)
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusConflict {
+			t.Fatalf("want: %v, got: %v", http.StatusConflict, rec.Code)
+		}
+	})
+
+	t.Run("err method not allowed", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/create_backup", nil)
+		cookie := &http.Cookie{
+			Name: "session_token",
+		}
+		req.AddCookie(cookie)
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Fatalf("want: %v, got: %v", http.StatusMethodNotAllowed, rec.Code)
+		}
+	})
+}
+
+func TestDownloadBackupHandler(t *testing.T) {
+	ctx := context.Background()
+	handler := setup()
+
+	t.Run("err not found", func(t *testing.T) {
+		if err := handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/backups/forum-20221019-120000.000.db", nil)
+		cookie := &http.Cookie{
+			Name: "session_token",
+		}
+		req.AddCookie(cookie)
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusNotFound {
+			t.Fatalf("want: %v, got: %v", http.StatusNotFound, rec.Code)
+		}
+	})
+
+	t.Run("err low access level", func(t *testing.T) {
+		if err := handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/backups/forum-20221019-120000.000.db", nil)
+		cookie := &http.Cookie{
+			Name: "session_token",
+		}
+		req.AddCookie(cookie)
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusForbidden {
+			t.Fatalf("want: %v, got: %v", http.StatusForbidden, rec.Code)
+		}
+	})
+}
@@ -0,0 +1,258 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"forum/internal/entity"
+)
+
+func (h *Handler) ManageCategoriesPageHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.Errors(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	content, ok := r.Context().Value(Key("content")).(Content)
+	if !ok {
+		h.l.WriteLog(fmt.Errorf("v1 - ManageCategoriesPageHandler - TypeAssertion:"+
+			"got data of type %T but wanted v1.Content", content))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	if !content.Can(entity.PermManageCategories) {
+		h.Errors(w, http.StatusForbidden)
+		return
+	}
+
+	categories, err := h.Usecases.Categories.GetCategories(r.Context())
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - ManageCategoriesPageHandler - GetCategories: %w", err))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+	content.Categories = categories
+
+	err = h.ParseAndExecute(w, content, "templates/manage_categories.html")
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - ManageCategoriesPageHandler - ParseAndExecute - %w", err))
+	}
+}
+
+func (h *Handler) RenameCategoryHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.Errors(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := strings.Split(r.URL.Path, "/")
+	id, err := strconv.ParseInt(path[len(path)-1], 10, 64)
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - RenameCategoryHandler - ParseInt: %w", err))
+	}
+	if r.URL.Path != "/rename_category/"+path[len(path)-1] || err != nil || id <= 0 {
+		h.Errors(w, http.StatusNotFound)
+		return
+	}
+
+	content, ok := r.Context().Value(Key("content")).(Content)
+	if !ok {
+		h.l.WriteLog(fmt.Errorf("v1 - RenameCategoryHandler - TypeAssertion:"+
+			"got data of type %T but wanted v1.Content", content))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	if !content.Can(entity.PermManageCategories) {
+		h.Errors(w, http.StatusForbidden)
+		return
+	}
+
+	err = h.Usecases.Categories.RenameCategory(r.Context(), id, r.FormValue("name"))
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - RenameCategoryHandler - RenameCategory: %w", err))
+		switch {
+		case errors.Is(err, entity.ErrCategoryNotFound):
+			h.Errors(w, http.StatusNotFound)
+		case errors.Is(err, entity.ErrCategoryExists), errors.Is(err, entity.ErrCategoryNameEmpty):
+			h.Errors(w, http.StatusBadRequest)
+		default:
+			h.Errors(w, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	http.Redirect(w, r, "/manage_categories", http.StatusFound)
+}
+
+func (h *Handler) MergeCategoryHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.Errors(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := strings.Split(r.URL.Path, "/")
+	id, err := strconv.ParseInt(path[len(path)-1], 10, 64)
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - MergeCategoryHandler - ParseInt: %w", err))
+	}
+	if r.URL.Path != "/merge_category/"+path[len(path)-1] || err != nil || id <= 0 {
+		h.Errors(w, http.StatusNotFound)
+		return
+	}
+
+	content, ok := r.Context().Value(Key("content")).(Content)
+	if !ok {
+		h.l.WriteLog(fmt.Errorf("v1 - MergeCategoryHandler - TypeAssertion:"+
+			"got data of type %T but wanted v1.Content", content))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	if !content.Can(entity.PermManageCategories) {
+		h.Errors(w, http.StatusForbidden)
+		return
+	}
+
+	intoId, err := strconv.ParseInt(r.FormValue("into"), 10, 64)
+	if err != nil || intoId <= 0 {
+		h.Errors(w, http.StatusBadRequest)
+		return
+	}
+
+	err = h.Usecases.Categories.MergeCategories(r.Context(), id, intoId)
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - MergeCategoryHandler - MergeCategories: %w", err))
+		switch {
+		case errors.Is(err, entity.ErrCategoryNotFound):
+			h.Errors(w, http.StatusNotFound)
+		case errors.Is(err, entity.ErrCategoryMergeSelf):
+			h.Errors(w, http.StatusBadRequest)
+		default:
+			h.Errors(w, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	http.Redirect(w, r, "/manage_categories", http.StatusFound)
+}
+
+// DeleteCategoryHandler deletes the category, its posts move to the
+// category chosen as the target or lose the category when there is none.
+func (h *Handler) DeleteCategoryHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.Errors(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := strings.Split(r.URL.Path, "/")
+	id, err := strconv.ParseInt(path[len(path)-1], 10, 64)
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - DeleteCategoryHandler - ParseInt: %w", err))
+	}
+	if r.URL.Path != "/delete_category/"+path[len(path)-1] || err != nil || id <= 0 {
+		h.Errors(w, http.StatusNotFound)
+		return
+	}
+
+	content, ok := r.Context().Value(Key("content")).(Content)
+	if !ok {
+		h.l.WriteLog(fmt.Errorf("v1 - DeleteCategoryHandler - TypeAssertion:"+
+			"got data of type %T but wanted v1.Content", content))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	if !content.Can(entity.PermManageCategories) {
+		h.Errors(w, http.StatusForbidden)
+		return
+	}
+
+	var targetId int64
+	if value := r.FormValue("target"); value != "" {
+		targetId, err = strconv.ParseInt(value, 10, 64)
+		if err != nil || targetId < 0 {
+			h.Errors(w, http.StatusBadRequest)
+			return
+		}
+	}
+
+	err = h.Usecases.Categories.DeleteCategory(r.Context(), id, targetId)
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - DeleteCategoryHandler - DeleteCategory: %w", err))
+		switch {
+		case errors.Is(err, entity.ErrCategoryNotFound):
+			h.Errors(w, http.StatusNotFound)
+		case errors.Is(err, entity.ErrCategoryMergeSelf):
+			h.Errors(w, http.StatusBadRequest)
+		default:
+			h.Errors(w, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	http.Redirect(w, r, "/manage_categories", http.StatusFound)
+}
+
+// ReorderCategoriesHandler takes the ids of the categories with their new
+// positions as two lists of the same length.
+func (h *Handler) ReorderCategoriesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.Errors(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		h.Errors(w, http.StatusBadRequest)
+		return
+	}
+
+	content, ok := r.Context().Value(Key("content")).(Content)
+	if !ok {
+		h.l.WriteLog(fmt.Errorf("v1 - ReorderCategoriesHandler - TypeAssertion:"+
+			"got data of type %T but wanted v1.Content", content))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	if !content.Can(entity.PermManageCategories) {
+		h.Errors(w, http.StatusForbidden)
+		return
+	}
+
+	ids, values := r.Form["id"], r.Form["position"]
+	if len(ids) != len(values) {
+		h.Errors(w, http.StatusBadRequest)
+		return
+	}
+	positions := make(map[int64]int, len(ids))
+	for i := range ids {
+		id, err := strconv.ParseInt(ids[i], 10, 64)
+		if err != nil {
+			h.Errors(w, http.StatusBadRequest)
+			return
+		}
+		positions[id], err = strconv.Atoi(values[i])
+		if err != nil {
+			h.Errors(w, http.StatusBadRequest)
+			return
+		}
+	}
+
+	err := h.Usecases.Categories.ReorderCategories(r.Context(), positions)
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - ReorderCategoriesHandler - ReorderCategories: %w", err))
+		if errors.Is(err, entity.ErrCategoryNotFound) {
+			h.Errors(w, http.StatusBadRequest)
+			return
+		}
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/manage_categories", http.StatusFound)
+}
@@ -0,0 +1,300 @@
+package v1_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"forum/internal/entity"
+)
+
+func TestManageCategoriesPageHandler(t *testing.T) {
+	ctx := context.Background()
+	handler := setup()
+
+	t.Run("OK", func(t *testing.T) {
+		if err := handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
+			t.Fatal(err)
+		}
+		err := handler.Usecases.Categories.CreateCategories(ctx, []entity.Category{{Name: "cars"}, {Name: "sports"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/manage_categories", nil)
+		cookie := &http.Cookie{
+			Name: "session_token",
+		}
+		req.AddCookie(cookie)
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("want: %v, got: %v", http.StatusOK, rec.Code)
+		}
+		if body := rec.Body.String(); !strings.Contains(body, `action="/rename_category/2"`) ||
+			!strings.Contains(body, `action="/delete_category/1"`) {
+			t.Fatalf("want categories listed, got: %s", body)
+		}
+	})
+
+	t.Run("err not authorized", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/manage_categories", nil)
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusUnauthorized {
+			t.Fatalf("want: %v, got: %v", http.StatusUnauthorized, rec.Code)
+		}
+	})
+
+	t.Run("err low access level", func(t *testing.T) {
+		if err := handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/manage_categories", nil)
+		cookie := &http.Cookie{
+			Name: "session_token",
+		}
+		req.AddCookie(cookie)
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusForbidden {
+			t.Fatalf("want: %v, got: %v", http.StatusForbidden, rec.Code)
+		}
+	})
+}
+
+func TestRenameCategoryHandler(t *testing.T) {
+	ctx := context.Background()
+	handler := setup()
+	if err := handler.Usecases.Categories.CreateCategories(ctx, []entity.Category{{Name: "cars"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		path   string
+		form   string
+		status int
+	}{
+		{"OK", "/rename_category/1", "autos", http.StatusFound},
+		{"err empty name", "/rename_category/1", "", http.StatusBadRequest},
+		{"err not found", "/rename_category/5", "autos", http.StatusNotFound},
+		{"err wrong path", "/rename_category/abc", "autos", http.StatusNotFound},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, tc.path, nil)
+			cookie := &http.Cookie{
+				Name: "session_token",
+			}
+			req.AddCookie(cookie)
+			req.PostForm = url.Values{"name": {tc.form}}
+
+			handler.Mux.ServeHTTP(rec, req)
+
+			if rec.Code != tc.status {
+				t.Fatalf("want: %v, got: %v", tc.status, rec.Code)
+			}
+		})
+	}
+
+	if _, err := handler.Usecases.Categories.GetBySlug(ctx, "autos"); err != nil {
+		t.Fatalf("want category renamed, got: %v", err)
+	}
+}
+
+func TestMergeCategoryHandler(t *testing.T) {
+	ctx := context.Background()
+	handler := setup()
+	err := handler.Usecases.Categories.CreateCategories(ctx, []entity.Category{{Name: "cars"}, {Name: "autos"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		path   string
+		into   string
+		status int
+	}{
+		{"err no target", "/merge_category/2", "", http.StatusBadRequest},
+		{"err merge self", "/merge_category/2", "2", http.StatusBadRequest},
+		{"err not found", "/merge_category/2", "5", http.StatusNotFound},
+		{"OK", "/merge_category/2", "1", http.StatusFound},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, tc.path, nil)
+			cookie := &http.Cookie{
+				Name: "session_token",
+			}
+			req.AddCookie(cookie)
+			req.PostForm = url.Values{"into": {tc.into}}
+
+			handler.Mux.ServeHTTP(rec, req)
+
+			if rec.Code != tc.status {
+				t.Fatalf("want: %v, got: %v", tc.status, rec.Code)
+			}
+		})
+	}
+
+	if _, err := handler.Usecases.Categories.GetBySlug(ctx, "autos"); err == nil {
+		t.Fatal("want merged category deleted")
+	}
+}
+
+func TestDeleteCategoryHandler(t *testing.T) {
+	ctx := context.Background()
+	handler := setup()
+	err := handler.Usecases.Categories.CreateCategories(ctx, []entity.Category{{Name: "cars"}, {Name: "autos"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		if err := handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/delete_category/2", nil)
+		cookie := &http.Cookie{
+			Name: "session_token",
+		}
+		req.AddCookie(cookie)
+		req.PostForm = url.Values{"target": {""}}
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusFound {
+			t.Fatalf("want: %v, got: %v", http.StatusFound, rec.Code)
+		}
+		if categories, err := handler.Usecases.Categories.GetCategories(ctx); err != nil {
+			t.Fatal(err)
+		} else if len(categories) != 1 {
+			t.Fatalf("want one category left, got: %+v", categories)
+		}
+	})
+
+	t.Run("err bad target", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/delete_category/1", nil)
+		cookie := &http.Cookie{
+			Name: "session_token",
+		}
+		req.AddCookie(cookie)
+		req.PostForm = url.Values{"target": {"abc"}}
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("want: %v, got: %v", http.StatusBadRequest, rec.Code)
+		}
+	})
+
+	t.Run("err not found", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/delete_category/2", nil)
+		cookie := &http.Cookie{
+			Name: "session_token",
+		}
+		req.AddCookie(cookie)
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusNotFound {
+			t.Fatalf("want: %v, got: %v", http.StatusNotFound, rec.Code)
+		}
+	})
+
+	t.Run("err wrong method", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/delete_category/1", nil)
+		cookie := &http.Cookie{
+			Name: "session_token",
+		}
+		req.AddCookie(cookie)
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Fatalf("want: %v, got: %v", http.StatusMethodNotAllowed, rec.Code)
+		}
+	})
+
+	t.Run("err low access level", func(t *testing.T) {
+		if err := handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/delete_category/1", nil)
+		cookie := &http.Cookie{
+			Name: "session_token",
+		}
+		req.AddCookie(cookie)
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusForbidden {
+			t.Fatalf("want: %v, got: %v", http.StatusForbidden, rec.Code)
+		}
+	})
+}
+
+func TestReorderCategoriesHandler(t *testing.T) {
+	ctx := context.Background()
+	handler := setup()
+	err := handler.Usecases.Categories.CreateCategories(ctx, []entity.Category{{Name: "cars"}, {Name: "sports"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		form   url.Values
+		status int
+	}{
+		{"OK", url.Values{"id": {"1", "2"}, "position": {"2", "1"}}, http.StatusFound},
+		{"err lengths", url.Values{"id": {"1", "2"}, "position": {"2"}}, http.StatusBadRequest},
+		{"err bad position", url.Values{"id": {"1"}, "position": {"first"}}, http.StatusBadRequest},
+		{"err not found", url.Values{"id": {"5"}, "position": {"1"}}, http.StatusBadRequest},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/reorder_categories", nil)
+			cookie := &http.Cookie{
+				Name: "session_token",
+			}
+			req.AddCookie(cookie)
+			req.PostForm = tc.form
+
+			handler.Mux.ServeHTTP(rec, req)
+
+			if rec.Code != tc.status {
+				t.Fatalf("want: %v, got: %v", tc.status, rec.Code)
+			}
+		})
+	}
+
+	if cars, err := handler.Usecases.Categories.GetBySlug(ctx, "cars"); err != nil {
+		t.Fatal(err)
+	} else if cars.Position != 2 {
+		t.Fatalf("want: %v, got: %v", 2, cars.Position)
+	}
+}
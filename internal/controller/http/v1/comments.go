@@ -1,12 +1,12 @@
 package v1
 
 import (
+	"errors"
 	"fmt"
 	_ "image/gif"
 	_ "image/jpeg"
 	_ "image/png"
 	"net/http"
-	"os"
 	"strconv"
 	"strings"
 
@@ -37,6 +37,23 @@ func (h *Handler) CreateCommentPageHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	if replyTo := r.URL.Query().Get("reply_to"); replyTo != "" {
+		parentId, err := strconv.Atoi(replyTo)
+		if err != nil || parentId <= 0 {
+			h.l.WriteLog(fmt.Errorf("v1 - CreateCommentPageHandler - Atoi: %w", err))
+			h.Errors(w, http.StatusBadRequest)
+			return
+		}
+		parent, err := h.Usecases.Comments.GetById(r.Context(), int64(parentId))
+		if err != nil || parent.PostId != int64(id) || parent.IsDeleted() {
+			h.l.WriteLog(fmt.Errorf("v1 - CreateCommentPageHandler - GetById: %w", err))
+			h.Errors(w, http.StatusNotFound)
+			return
+		}
+		parent.ContentWeb = strings.Split(parent.Content, "\\n")
+		content.Comment = parent
+	}
+
 	content.Post.Id = int64(id)
 	content.Uri = strconv.Itoa(id)
 
@@ -75,7 +92,7 @@ func (h *Handler) CreateCommentHandler(w http.ResponseWriter, r *http.Request) {
 
 	content.Uri = strconv.Itoa(id)
 
-	imagePath, err := h.GetImage(w, r)
+	images, err := h.GetImages(w, r)
 	if err != nil {
 		if strings.Contains(err.Error(), imageTypeForbidden) ||
 			strings.Contains(err.Error(), imageTooLarge) {
@@ -86,7 +103,7 @@ func (h *Handler) CreateCommentHandler(w http.ResponseWriter, r *http.Request) {
 				h.l.WriteLog(fmt.Errorf("v1 - CreateCommentHandler - ParseAndExecute #1: %w", err))
 			}
 		} else {
-			h.l.WriteLog(fmt.Errorf("v1 - CreateCommentHandler - GetImage: %w", err))
+			h.l.WriteLog(fmt.Errorf("v1 - CreateCommentHandler - GetImages: %w", err))
 			h.Errors(w, http.StatusInternalServerError)
 		}
 		return
@@ -97,6 +114,16 @@ func (h *Handler) CreateCommentHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var parentId int
+	if values := r.MultipartForm.Value["parent_id"]; len(values) != 0 && values[0] != "" {
+		parentId, err = strconv.Atoi(values[0])
+		if err != nil || parentId <= 0 {
+			h.l.WriteLog(fmt.Errorf("v1 - CreateCommentHandler - Atoi: %w", err))
+			h.Errors(w, http.StatusBadRequest)
+			return
+		}
+	}
+
 	content, ok = r.Context().Value(Key("content")).(Content)
 	if !ok {
 		h.l.WriteLog(fmt.Errorf("v1 - CreateCommentHandler - TypeAssertion:"+
@@ -110,74 +137,41 @@ func (h *Handler) CreateCommentHandler(w http.ResponseWriter, r *http.Request) {
 	newComment.Content = strings.ReplaceAll(commentContent, "\r\n", "\\n")
 	newComment.User = content.User
 	newComment.PostId = int64(id)
-	newComment.ImagePath = "/" + imagePath
+	newComment.ParentId = int64(parentId)
+	newComment.Images = imagePaths(images)
 
-	err = h.Usecases.Comments.WriteComment(newComment)
-	if err != nil {
-		err = os.Remove(imagePath)
-		if err != nil {
-			h.l.WriteLog(fmt.Errorf("v1 - CreateCommentHandler - Remove: %w", err))
-			h.Errors(w, http.StatusInternalServerError)
-			return
-		} else {
-			h.l.WriteLog(fmt.Errorf("v1 - CreateCommentHandler - WriteComment: %w", err))
-			h.Errors(w, http.StatusBadRequest)
-			return
-		}
-	}
-	http.Redirect(w, r, "/posts/"+strconv.Itoa(id), http.StatusFound)
-}
-
-func (h *Handler) CommentPutLikeHandler(w http.ResponseWriter, r *http.Request) {
-	path := strings.Split(r.URL.Path, "/")
-	id, err := strconv.Atoi(path[len(path)-1])
-	if err != nil {
-		h.l.WriteLog(fmt.Errorf("v1 - CommentPutLikeHandler - Atoi: %w", err))
-	}
-	if r.URL.Path != "/put_comment_like/"+path[len(path)-1] || err != nil || id <= 0 {
-		h.l.WriteLog(fmt.Errorf("v1 - CommentPutLikeHandler - URL.Path: %w", err))
+	err = h.Usecases.Comments.WriteComment(r.Context(), newComment)
+	if errors.Is(err, entity.ErrCommentNotFound) {
+		h.l.WriteLog(fmt.Errorf("v1 - CreateCommentHandler - WriteComment: %w", err))
+		h.removeImages(newComment.Images)
 		h.Errors(w, http.StatusNotFound)
 		return
 	}
-
-	content, ok := r.Context().Value(Key("content")).(Content)
-	if !ok {
-		h.l.WriteLog(fmt.Errorf("v1 - CommentPutLikeHandler - TypeAssertion:"+
-			"got data of type %T but wanted v1.Content", content))
-		h.Errors(w, http.StatusInternalServerError)
-		return
-	}
-
-	comment := entity.Comment{
-		Id: int64(id),
-	}
-	comment.User.Id = content.User.Id
-
-	err = h.Usecases.Comments.MakeReaction(comment, CommandPutLike)
 	if err != nil {
-		h.l.WriteLog(fmt.Errorf("v1 - CommentPutLikeHandler - MakeReaction: %w", err))
-		h.Errors(w, http.StatusNotFound)
+		h.l.WriteLog(fmt.Errorf("v1 - CreateCommentHandler - WriteComment: %w", err))
+		h.removeImages(newComment.Images)
+		h.Errors(w, http.StatusBadRequest)
 		return
 	}
-
-	http.Redirect(w, r, r.Header.Get("Referer")+"#"+strconv.Itoa(int(comment.Id)), http.StatusFound)
+	http.Redirect(w, r, "/posts/"+strconv.Itoa(id), http.StatusFound)
 }
 
-func (h *Handler) CommentPutDislikeHandler(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) CommentPutReactionHandler(w http.ResponseWriter, r *http.Request) {
 	path := strings.Split(r.URL.Path, "/")
+	kind := path[len(path)-2]
 	id, err := strconv.Atoi(path[len(path)-1])
 	if err != nil {
-		h.l.WriteLog(fmt.Errorf("v1 - CommentPutDislikeHandler - Atoi: %w", err))
+		h.l.WriteLog(fmt.Errorf("v1 - CommentPutReactionHandler - Atoi: %w", err))
 	}
-	if r.URL.Path != "/put_comment_dislike/"+path[len(path)-1] || err != nil || id <= 0 {
-		h.l.WriteLog(fmt.Errorf("v1 - CommentPutDislikeHandler - URL.Path: %w", err))
+	if r.URL.Path != "/put_comment_reaction/"+kind+"/"+path[len(path)-1] || err != nil || id <= 0 {
+		h.l.WriteLog(fmt.Errorf("v1 - CommentPutReactionHandler - URL.Path: %w", err))
 		h.Errors(w, http.StatusNotFound)
 		return
 	}
 
 	content, ok := r.Context().Value(Key("content")).(Content)
 	if !ok {
-		h.l.WriteLog(fmt.Errorf("v1 - CommentPutDislikeHandler - TypeAssertion:"+
+		h.l.WriteLog(fmt.Errorf("v1 - CommentPutReactionHandler - TypeAssertion:"+
 			"got data of type %T but wanted v1.Content", content))
 		h.Errors(w, http.StatusInternalServerError)
 		return
@@ -188,11 +182,16 @@ func (h *Handler) CommentPutDislikeHandler(w http.ResponseWriter, r *http.Reques
 	}
 	comment.User.Id = content.User.Id
 
-	err = h.Usecases.Comments.MakeReaction(comment, CommandPutDislike)
+	err = h.Usecases.Comments.MakeReaction(r.Context(), comment, kind)
 	if err != nil {
-		h.l.WriteLog(fmt.Errorf("v1 - CommentPutDislikeHandler - MakeReaction: %w", err))
+		h.l.WriteLog(fmt.Errorf("v1 - CommentPutReactionHandler - MakeReaction: %w", err))
+		if errors.Is(err, entity.ErrUnknownReaction) {
+			h.Errors(w, http.StatusBadRequest)
+			return
+		}
 		h.Errors(w, http.StatusNotFound)
 		return
 	}
+
 	http.Redirect(w, r, r.Header.Get("Referer")+"#"+strconv.Itoa(int(comment.Id)), http.StatusFound)
 }
@@ -1,6 +1,7 @@
 package v1_test
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -10,8 +11,9 @@ import (
 )
 
 func TestCreateCommentPageHandler(t *testing.T) {
+	ctx := context.Background()
 	handler := setup()
-	if err := handler.Usecases.Users.SignUp(entity.User{}); err != nil {
+	if err := handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
 		t.Fatal(err)
 	}
 
@@ -55,6 +57,50 @@ func TestCreateCommentPageHandler(t *testing.T) {
 
 		handler.Mux.ServeHTTP(rec, req)
 
+		if rec.Code != http.StatusNotFound {
+			t.Fatalf("want: %v, got: %v", http.StatusNotFound, rec.Code)
+		}
+	})
+	t.Run("OK reply", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/create_comment_page/1?reply_to=2", nil)
+		cookie := &http.Cookie{
+			Name: "session_token",
+		}
+		req.AddCookie(cookie)
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("want: %v, got: %v", http.StatusOK, rec.Code)
+		}
+	})
+
+	t.Run("err wrong reply", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/create_comment_page/1?reply_to=abc", nil)
+		cookie := &http.Cookie{
+			Name: "session_token",
+		}
+		req.AddCookie(cookie)
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("want: %v, got: %v", http.StatusBadRequest, rec.Code)
+		}
+	})
+
+	t.Run("err reply to other post", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/create_comment_page/3?reply_to=2", nil)
+		cookie := &http.Cookie{
+			Name: "session_token",
+		}
+		req.AddCookie(cookie)
+
+		handler.Mux.ServeHTTP(rec, req)
+
 		if rec.Code != http.StatusNotFound {
 			t.Fatalf("want: %v, got: %v", http.StatusNotFound, rec.Code)
 		}
@@ -62,8 +108,9 @@ func TestCreateCommentPageHandler(t *testing.T) {
 }
 
 func TestCreateCommentHandler(t *testing.T) {
+	ctx := context.Background()
 	handler := setup()
-	if err := handler.Usecases.Users.SignUp(entity.User{}); err != nil {
+	if err := handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
 		t.Fatal(err)
 	}
 
@@ -119,6 +166,27 @@ func TestCreateCommentHandler(t *testing.T) {
 		}
 	})
 
+	t.Run("err wrong parent", func(t *testing.T) {
+		body, mw := CreateMultipartForm(t, "", "Lorem ipsum dolor sit amet.", "image")
+		if err := mw.WriteField("parent_id", "abc"); err != nil {
+			t.Fatal(err)
+		}
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/create_comment/2", body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		cookie := &http.Cookie{
+			Name: "session_token",
+		}
+		req.AddCookie(cookie)
+		mw.Close()
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("want: %v, got: %v", http.StatusBadRequest, rec.Code)
+		}
+	})
+
 	t.Run("err wrong path", func(t *testing.T) {
 		rec := httptest.NewRecorder()
 		req := httptest.NewRequest(http.MethodPost, "/create_comment/dfg", nil)
@@ -139,15 +207,16 @@ func TestCreateCommentHandler(t *testing.T) {
 	})
 }
 
-func TestCommentPutLikeHandler(t *testing.T) {
+func TestCommentPutReactionHandler(t *testing.T) {
+	ctx := context.Background()
 	handler := setup()
-	if err := handler.Usecases.Users.SignUp(entity.User{}); err != nil {
+	if err := handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
 		t.Fatal(err)
 	}
 
 	t.Run("OK", func(t *testing.T) {
 		rec := httptest.NewRecorder()
-		req := httptest.NewRequest(http.MethodGet, "/put_comment_like/2", nil)
+		req := httptest.NewRequest(http.MethodGet, "/put_comment_reaction/like/2", nil)
 		cookie := &http.Cookie{
 			Name: "session_token",
 		}
@@ -162,7 +231,7 @@ func TestCommentPutLikeHandler(t *testing.T) {
 
 	t.Run("err wrong path", func(t *testing.T) {
 		rec := httptest.NewRecorder()
-		req := httptest.NewRequest(http.MethodPut, "/put_comment_like/wert", nil)
+		req := httptest.NewRequest(http.MethodPut, "/put_comment_reaction/like/wert", nil)
 		cookie := &http.Cookie{
 			Name: "session_token",
 		}
@@ -174,17 +243,10 @@ func TestCommentPutLikeHandler(t *testing.T) {
 			t.Fatalf("want: %v, got: %v", http.StatusNotFound, rec.Code)
 		}
 	})
-}
-
-func TestCommentPutDislikeHandler(t *testing.T) {
-	handler := setup()
-	if err := handler.Usecases.Users.SignUp(entity.User{}); err != nil {
-		t.Fatal(err)
-	}
 
-	t.Run("OK", func(t *testing.T) {
+	t.Run("err unknown kind", func(t *testing.T) {
 		rec := httptest.NewRecorder()
-		req := httptest.NewRequest(http.MethodGet, "/put_comment_dislike/2", nil)
+		req := httptest.NewRequest(http.MethodGet, "/put_comment_reaction/angry/2", nil)
 		cookie := &http.Cookie{
 			Name: "session_token",
 		}
@@ -192,23 +254,8 @@ func TestCommentPutDislikeHandler(t *testing.T) {
 
 		handler.Mux.ServeHTTP(rec, req)
 
-		if rec.Code != http.StatusFound {
-			t.Fatalf("want: %v, got: %v", http.StatusFound, rec.Code)
-		}
-	})
-
-	t.Run("err wrong path", func(t *testing.T) {
-		rec := httptest.NewRecorder()
-		req := httptest.NewRequest(http.MethodPut, "/put_comment_dislike/wert", nil)
-		cookie := &http.Cookie{
-			Name: "session_token",
-		}
-		req.AddCookie(cookie)
-
-		handler.Mux.ServeHTTP(rec, req)
-
-		if rec.Code != http.StatusNotFound {
-			t.Fatalf("want: %v, got: %v", http.StatusNotFound, rec.Code)
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("want: %v, got: %v", http.StatusBadRequest, rec.Code)
 		}
 	})
 }
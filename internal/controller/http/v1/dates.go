@@ -0,0 +1,67 @@
+package v1
+
+import (
+	"fmt"
+	"time"
+)
+
+// Layouts dates are shown in.
+const (
+	TimeLayout = "02.01.2006 15:04"
+	DateLayout = "02.01.2006"
+)
+
+// Time renders t in the timezone of the viewer, the zero time is empty.
+func (c Content) Time(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.In(c.location()).Format(TimeLayout)
+}
+
+// Date renders the day of t in the timezone of the viewer.
+func (c Content) Date(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.In(c.location()).Format(DateLayout)
+}
+
+// Ago renders how long ago t was, e.g. "3 часа назад". Times older than a
+// week are rendered as by Time.
+func (c Content) Ago(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	passed := time.Since(t)
+	switch {
+	case passed < time.Minute:
+		return "только что"
+	case passed < time.Hour:
+		return ago(int64(passed/time.Minute), "минуту", "минуты", "минут")
+	case passed < 24*time.Hour:
+		return ago(int64(passed/time.Hour), "час", "часа", "часов")
+	case passed < 7*24*time.Hour:
+		return ago(int64(passed/(24*time.Hour)), "день", "дня", "дней")
+	}
+	return c.Time(t)
+}
+
+func (c Content) location() *time.Location {
+	if c.Location == nil {
+		return time.UTC
+	}
+	return c.Location
+}
+
+// ago picks the russian plural form of the unit for n.
+func ago(n int64, one, few, many string) string {
+	unit := many
+	switch {
+	case n%10 == 1 && n%100 != 11:
+		unit = one
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 10 || n%100 >= 20):
+		unit = few
+	}
+	return fmt.Sprintf("%d %s назад", n, unit)
+}
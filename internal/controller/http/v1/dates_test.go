@@ -0,0 +1,63 @@
+package v1_test
+
+import (
+	"testing"
+	"time"
+
+	v1 "forum/internal/controller/http/v1"
+)
+
+func TestContentTime(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	date := time.Date(2022, 10, 1, 22, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		content v1.Content
+		time    string
+		date    string
+	}{
+		{"OK UTC", v1.Content{}, "01.10.2022 22:30", "01.10.2022"},
+		{"OK location", v1.Content{Location: moscow}, "02.10.2022 01:30", "02.10.2022"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.content.Time(date); got != tt.time {
+				t.Fatalf("want: %v, got: %v", tt.time, got)
+			}
+			if got := tt.content.Date(date); got != tt.date {
+				t.Fatalf("want: %v, got: %v", tt.date, got)
+			}
+			if got := tt.content.Time(time.Time{}); got != "" {
+				t.Fatalf("want empty, got: %v", got)
+			}
+		})
+	}
+}
+
+func TestContentAgo(t *testing.T) {
+	content := v1.Content{}
+	old := time.Date(2022, 10, 1, 22, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		time time.Time
+		want string
+	}{
+		{"OK now", time.Now(), "только что"},
+		{"OK minutes", time.Now().Add(-21 * time.Minute), "21 минуту назад"},
+		{"OK hours", time.Now().Add(-3*time.Hour - time.Minute), "3 часа назад"},
+		{"OK days", time.Now().Add(-5*24*time.Hour - time.Minute), "5 дней назад"},
+		{"OK old", old, "01.10.2022 22:30"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := content.Ago(tt.time); got != tt.want {
+				t.Fatalf("want: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
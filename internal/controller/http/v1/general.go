@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"image"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 
 	"forum/internal/entity"
@@ -31,13 +33,26 @@ func (h *Handler) IndexHandler(w http.ResponseWriter, r *http.Request) {
 		h.Errors(w, http.StatusInternalServerError)
 		return
 	}
-	posts, err := h.Usecases.Posts.GetAllPosts()
+	number, cursor, byCursor, err := pageParams(r)
 	if err != nil {
-		h.l.WriteLog(fmt.Errorf("v1 - IndexHandler - GetAllPosts: %w", err))
+		h.l.WriteLog(fmt.Errorf("v1 - IndexHandler - %w", err))
+		h.Errors(w, http.StatusBadRequest)
+		return
+	}
+	var posts []entity.Post
+	var page entity.Page
+	if byCursor {
+		posts, page, err = h.Usecases.Posts.GetPostsAfter(r.Context(), cursor)
+	} else {
+		posts, page, err = h.Usecases.Posts.GetPostsPage(r.Context(), number)
+	}
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - IndexHandler - GetPosts: %w", err))
 		h.Errors(w, http.StatusInternalServerError)
 		return
 	}
 	content.Posts = posts
+	content.Page = page
 	err = h.ParseAndExecute(w, content, "templates/index.html")
 	if err != nil {
 		h.l.WriteLog(fmt.Errorf("v1 - IndexHandler - ParseAndExecute - %w", err))
@@ -79,15 +94,14 @@ func (h *Handler) SearchHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	searchRequest := strings.ToLower(r.Form["search"][0])
-	posts, err := h.Usecases.Posts.GetAllPosts()
+	searchRequest := r.Form["search"][0]
+	results, err := h.Usecases.Posts.Search(r.Context(), searchRequest)
 	if err != nil {
-		h.l.WriteLog(fmt.Errorf("v1 - SearchHandler - GetAllPosts: %w", err))
-		h.Errors(w, http.StatusBadRequest)
+		h.l.WriteLog(fmt.Errorf("v1 - SearchHandler - Search: %w", err))
+		h.Errors(w, http.StatusInternalServerError)
 		return
 	}
 
-	filtered := h.filterPosts(posts, searchRequest)
 	content, ok := r.Context().Value(Key("content")).(Content)
 	if !ok {
 		h.l.WriteLog(fmt.Errorf("v1 - SearchHandler - TypeAssertion:"+
@@ -96,14 +110,43 @@ func (h *Handler) SearchHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	content.Posts = filtered
+	content.Query = searchRequest
+	content.SearchResults = results
 
-	err = h.ParseAndExecute(w, content, "templates/index.html")
+	err = h.ParseAndExecute(w, content, "templates/search.html")
 	if err != nil {
 		h.l.WriteLog(fmt.Errorf("v1 - SearchHandler - ParseAndExecute - %w", err))
 	}
 }
 
+func (h *Handler) CategoriesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.Errors(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	content, ok := r.Context().Value(Key("content")).(Content)
+	if !ok {
+		h.l.WriteLog(fmt.Errorf("v1 - CategoriesHandler - TypeAssertion:"+
+			"got data of type %T but wanted v1.Content", content))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	categories, err := h.Usecases.Categories.GetCategories(r.Context())
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - CategoriesHandler - GetCategories: %w", err))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+	content.Categories = categories
+
+	err = h.ParseAndExecute(w, content, "templates/categories.html")
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - CategoriesHandler - ParseAndExecute - %w", err))
+	}
+}
+
 func (h *Handler) CreateCategoryPageHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		h.Errors(w, http.StatusMethodNotAllowed)
@@ -118,17 +161,27 @@ func (h *Handler) CreateCategoryPageHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	if !content.Admin {
+	if !content.Can(entity.PermManageCategories) {
 		h.Errors(w, http.StatusForbidden)
 		return
 	}
 
-	err := h.ParseAndExecute(w, content, "templates/create_category.html")
+	categories, err := h.Usecases.Categories.GetCategories(r.Context())
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - CreateCategoryPageHandler - GetCategories: %w", err))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+	content.Categories = categories
+
+	err = h.ParseAndExecute(w, content, "templates/create_category.html")
 	if err != nil {
 		h.l.WriteLog(fmt.Errorf("v1 - CreateCategoryPageHandler - ParseAndExecute - %w", err))
 	}
 }
 
+// CreateCategoryHandler adds a category for every line of the form, the
+// parent, description, position and color are shared by all of them.
 func (h *Handler) CreateCategoryHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		h.Errors(w, http.StatusMethodNotAllowed)
@@ -139,20 +192,82 @@ func (h *Handler) CreateCategoryHandler(w http.ResponseWriter, r *http.Request)
 		h.Errors(w, http.StatusInternalServerError)
 	}
 
+	content, ok := r.Context().Value(Key("content")).(Content)
+	if !ok {
+		h.l.WriteLog(fmt.Errorf("v1 - CreateCategoryHandler - TypeAssertion:"+
+			"got data of type %T but wanted v1.Content", content))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	if !content.Can(entity.PermManageCategories) {
+		h.Errors(w, http.StatusForbidden)
+		return
+	}
+
 	if len(r.Form["category"]) == 0 || len(r.Form["category"][0]) == 0 {
 		h.Errors(w, http.StatusBadRequest)
 		return
 	}
-	data := r.Form["category"][0]
-	categories := strings.Split(data, "\r\n")
+	template, err := categoryForm(r)
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - CreateCategoryHandler - %w", err))
+		h.Errors(w, http.StatusBadRequest)
+		return
+	}
 
-	err := h.Usecases.Posts.CreateCategories(categories)
+	var categories []entity.Category
+	for _, name := range strings.Split(r.Form["category"][0], "\r\n") {
+		category := template
+		category.Name = name
+		categories = append(categories, category)
+	}
+
+	err = h.Usecases.Categories.CreateCategories(r.Context(), categories)
 	if err != nil {
 		h.l.WriteLog(fmt.Errorf("v1 - CreateCategoryHandler - CreateCategories: %w", err))
+		if errors.Is(err, entity.ErrCategoryNotFound) {
+			h.Errors(w, http.StatusBadRequest)
+			return
+		}
 		h.Errors(w, http.StatusInternalServerError)
 		return
 	}
-	http.Redirect(w, r, "/", http.StatusFound)
+	http.Redirect(w, r, "/categories", http.StatusFound)
+}
+
+// categoryForm reads the optional settings of new categories, the color
+// has to be written as #rrggbb.
+func categoryForm(r *http.Request) (entity.Category, error) {
+	var category entity.Category
+	var err error
+	if value := r.FormValue("parent"); value != "" {
+		category.ParentId, err = strconv.ParseInt(value, 10, 64)
+		if err != nil || category.ParentId < 0 {
+			return category, fmt.Errorf("categoryForm - parent %q", value)
+		}
+	}
+	if value := r.FormValue("position"); value != "" {
+		category.Position, err = strconv.Atoi(value)
+		if err != nil {
+			return category, fmt.Errorf("categoryForm - position %q", value)
+		}
+	}
+	category.Color = strings.TrimSpace(r.FormValue("color"))
+	if category.Color != "" && !isColor(category.Color) {
+		return category, fmt.Errorf("categoryForm - color %q", category.Color)
+	}
+	category.Description = strings.TrimSpace(r.FormValue("description"))
+	return category, nil
+}
+
+// isColor tells whether s is a color written as #rrggbb.
+func isColor(s string) bool {
+	if len(s) != 7 || s[0] != '#' {
+		return false
+	}
+	_, err := strconv.ParseUint(s[1:], 16, 32)
+	return err == nil
 }
 
 func (h *Handler) SearchByCategoryHandler(w http.ResponseWriter, r *http.Request) {
@@ -162,8 +277,8 @@ func (h *Handler) SearchByCategoryHandler(w http.ResponseWriter, r *http.Request
 	}
 
 	path := strings.Split(r.URL.Path, "/")
-	category := path[len(path)-1]
-	if r.URL.Path != "/categories/"+category {
+	slug := path[len(path)-1]
+	if r.URL.Path != "/categories/"+slug {
 		h.Errors(w, http.StatusNotFound)
 		return
 	}
@@ -176,17 +291,40 @@ func (h *Handler) SearchByCategoryHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	posts, err := h.Usecases.Posts.GetAllByCategory(category)
+	number, cursor, byCursor, err := pageParams(r)
 	if err != nil {
-		h.l.WriteLog(fmt.Errorf("v1 - SearchByCategoryHandler - GetAllByCategory: %w", err))
-		if strings.Contains(err.Error(), entity.ErrPostNotFound.Error()) {
-			h.Errors(w, http.StatusBadRequest)
+		h.l.WriteLog(fmt.Errorf("v1 - SearchByCategoryHandler - %w", err))
+		h.Errors(w, http.StatusBadRequest)
+		return
+	}
+
+	category, err := h.Usecases.Categories.GetBySlug(r.Context(), slug)
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - SearchByCategoryHandler - GetBySlug: %w", err))
+		if errors.Is(err, entity.ErrCategoryNotFound) {
+			h.Errors(w, http.StatusNotFound)
 			return
 		}
 		h.Errors(w, http.StatusInternalServerError)
 		return
 	}
+
+	// a category without posts is shown empty
+	var posts []entity.Post
+	var page entity.Page
+	if byCursor {
+		posts, page, err = h.Usecases.Posts.GetByCategoryAfter(r.Context(), category.Id, cursor)
+	} else {
+		posts, page, err = h.Usecases.Posts.GetByCategoryPage(r.Context(), category.Id, number)
+	}
+	if err != nil && !errors.Is(err, entity.ErrPostNotFound) {
+		h.l.WriteLog(fmt.Errorf("v1 - SearchByCategoryHandler - GetPosts: %w", err))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+	content.Category = category
 	content.Posts = posts
+	content.Page = page
 
 	err = h.ParseAndExecute(w, content, "templates/index.html")
 	if err != nil {
@@ -194,51 +332,51 @@ func (h *Handler) SearchByCategoryHandler(w http.ResponseWriter, r *http.Request
 	}
 }
 
-func (h *Handler) filterPosts(posts []entity.Post, request string) []entity.Post {
-	var filtered []entity.Post
-	if len(posts) == 0 {
-		return filtered
-	}
-	for _, post := range posts {
-		found := false
-		if strings.Contains(strings.ToLower(post.Title), request) {
-			filtered = append(filtered, post)
-			continue
-		} else if strings.Contains(strings.ToLower(post.Content), request) {
-			filtered = append(filtered, post)
-			continue
-		} else if strings.Contains(strings.ToLower(post.User.Name), request) {
-			filtered = append(filtered, post)
-			continue
-		}
-		for _, val := range post.Categories {
-			if strings.Contains(strings.ToLower(val), request) {
-				filtered = append(filtered, post)
-				found = true
-				break
-			}
-		}
-		if !found {
-			for _, val := range post.Comments {
-				if strings.Contains(strings.ToLower(val.Content), request) {
-					filtered = append(filtered, post)
-					break
-				}
+func (h *Handler) GetImage(w http.ResponseWriter, r *http.Request) (string, error) {
+	file, header, err := r.FormFile("image")
+	if err != nil {
+		return "", nil
+	}
+	file.Close()
+
+	return h.saveImage(header)
+}
+
+// GetImages saves every image of the form in upload order. Images saved
+// before one is refused are removed again.
+func (h *Handler) GetImages(w http.ResponseWriter, r *http.Request) ([]string, error) {
+	if r.MultipartForm == nil {
+		return nil, nil
+	}
+
+	var paths []string
+	for _, header := range r.MultipartForm.File["image"] {
+		path, err := h.saveImage(header)
+		if err != nil {
+			for _, saved := range paths {
+				os.Remove(saved)
 			}
+			return nil, err
 		}
+		paths = append(paths, path)
 	}
-	return filtered
+	return paths, nil
 }
 
-func (h *Handler) GetImage(w http.ResponseWriter, r *http.Request) (string, error) {
-	file, header, err := r.FormFile("image")
+// saveImage copies an uploaded image into the storage and returns its
+// path.
+func (h *Handler) saveImage(header *multipart.FileHeader) (string, error) {
+	file, err := header.Open()
 	if err != nil {
-		return "", nil
+		return "", fmt.Errorf("open: %w", err)
 	}
 	defer file.Close()
 
 	mimeType := header.Header.Get("Content-Type")
 	typeSl := strings.Split(mimeType, "/")
+	if len(typeSl) != 2 {
+		return "", errors.New(imageTypeForbidden)
+	}
 	imageType := typeSl[1]
 	if imageType != "jpeg" && imageType != "png" &&
 		imageType != "gif" && imageType != "octet-stream" {
@@ -279,6 +417,47 @@ func (h *Handler) GetImage(w http.ResponseWriter, r *http.Request) (string, erro
 	return path, nil
 }
 
+// removeImages deletes images by the paths posts and comments keep them
+// with.
+func (h *Handler) removeImages(paths []string) {
+	for _, path := range paths {
+		if err := os.Remove(strings.TrimPrefix(path, "/")); err != nil {
+			h.l.WriteLog(fmt.Errorf("v1 - removeImages - Remove: %w", err))
+		}
+	}
+}
+
+// imagePaths prefixes stored image paths the way posts and comments keep
+// them.
+func imagePaths(stored []string) []string {
+	var paths []string
+	for _, path := range stored {
+		paths = append(paths, "/"+path)
+	}
+	return paths
+}
+
+// splitImages parts the attached images into the kept ones and those
+// checked for removal. Without removals kept is nil, which keeps all.
+func splitImages(attached, removed []string) (kept, dropped []string) {
+	if len(removed) == 0 {
+		return nil, nil
+	}
+	checked := make(map[string]bool, len(removed))
+	for _, path := range removed {
+		checked[path] = true
+	}
+	kept = make([]string, 0, len(attached))
+	for _, path := range attached {
+		if checked[path] {
+			dropped = append(dropped, path)
+		} else {
+			kept = append(kept, path)
+		}
+	}
+	return kept, dropped
+}
+
 func (h *Handler) CheckSizeExceeded(path string) (bool, error) {
 	if path == "" {
 		return false, nil
@@ -298,3 +477,24 @@ func (h *Handler) CheckSizeExceeded(path string) (bool, error) {
 	}
 	return false, nil
 }
+
+// pageParams reads the listing position from the query string. A "cursor"
+// parameter selects keyset paging and wins over "page", which defaults to 1.
+func pageParams(r *http.Request) (page int, cursor int64, byCursor bool, err error) {
+	query := r.URL.Query()
+	if value := query.Get("cursor"); value != "" {
+		cursor, err = strconv.ParseInt(value, 10, 64)
+		if err != nil || cursor < 0 {
+			return 0, 0, false, fmt.Errorf("pageParams - cursor %q", value)
+		}
+		return 0, cursor, true, nil
+	}
+	page = 1
+	if value := query.Get("page"); value != "" {
+		page, err = strconv.Atoi(value)
+		if err != nil || page < 1 {
+			return 0, 0, false, fmt.Errorf("pageParams - page %q", value)
+		}
+	}
+	return page, 0, false, nil
+}
@@ -2,6 +2,7 @@ package v1_test
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"log"
 	"mime/multipart"
@@ -9,6 +10,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"strings"
 	"testing"
 
 	"forum/internal/config"
@@ -26,9 +28,18 @@ func setup() *v1.Handler {
 	}
 	l := logger.New()
 	mockUsersUseCase := mu.NewUsersMockUseCase()
+	mockRolesUseCase := mu.NewRolesMockUseCase()
+	mockResetsUseCase := mu.NewPasswordResetsMockUseCase()
+	mockVerificationUseCase := mu.NewVerificationMockUseCase()
+	mockTwoFactorUseCase := mu.NewTwoFactorMockUseCase()
 	mockPostsUseCase := mu.NewPostsMockUseCase()
+	mockCategoriesUseCase := mu.NewCategoriesMockUseCase()
 	mockCommentsUseCase := mu.NewCommentsMockUseCase()
-	usecases := usecase.NewUseCases(mockPostsUseCase, mockUsersUseCase, mockCommentsUseCase)
+	mockBackupsUseCase := mu.NewBackupsMockUseCase()
+	mockArchiveUseCase := mu.NewArchiveMockUseCase()
+	usecases := usecase.NewUseCases(mockPostsUseCase, mockCategoriesUseCase, mockUsersUseCase, mockRolesUseCase,
+		mockResetsUseCase, mockVerificationUseCase, mockTwoFactorUseCase, mockCommentsUseCase, mockBackupsUseCase,
+		mockArchiveUseCase)
 	handler := v1.NewHandler(usecases, cfg, l)
 	handler.RegisterRoutes(handler.Mux)
 
@@ -86,6 +97,46 @@ func TestIndexHandler(t *testing.T) {
 		}
 	})
 
+	t.Run("OK page", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/?page=2", nil)
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("want: %v, got: %v", http.StatusOK, rec.Code)
+		}
+	})
+
+	t.Run("OK cursor", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/?cursor=10", nil)
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("want: %v, got: %v", http.StatusOK, rec.Code)
+		}
+	})
+
+	t.Run("err bad page", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/?page=abc", nil)
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("want: %v, got: %v", http.StatusBadRequest, rec.Code)
+		}
+	})
+
+	t.Run("err bad cursor", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/?cursor=-1", nil)
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("want: %v, got: %v", http.StatusBadRequest, rec.Code)
+		}
+	})
+
 	t.Run("err wrong method", func(t *testing.T) {
 		rec := httptest.NewRecorder()
 		req := httptest.NewRequest(http.MethodPut, "/", nil)
@@ -122,10 +173,11 @@ func TestSearchPageHandler(t *testing.T) {
 }
 
 func TestSearchHandler(t *testing.T) {
+	ctx := context.Background()
 	handler := setup()
 
 	t.Run("OK", func(t *testing.T) {
-		handler.Usecases.Posts.CreatePost(entity.Post{Title: "sdf"})
+		handler.Usecases.Posts.CreatePost(ctx, entity.Post{Title: "sdf"})
 		rec := httptest.NewRecorder()
 		req := httptest.NewRequest(http.MethodPost, "/search", nil)
 
@@ -163,11 +215,48 @@ func TestSearchHandler(t *testing.T) {
 	})
 }
 
+func TestCategoriesHandler(t *testing.T) {
+	ctx := context.Background()
+	handler := setup()
+
+	t.Run("OK", func(t *testing.T) {
+		err := handler.Usecases.Categories.CreateCategories(ctx, []entity.Category{
+			{Name: "cars", Description: "Everything on wheels.", Color: "#ff0000"},
+			{Name: "audi", ParentId: 1},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/categories", nil)
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("want: %v, got: %v", http.StatusOK, rec.Code)
+		}
+		if body := rec.Body.String(); !strings.Contains(body, `href="/categories/audi"`) ||
+			!strings.Contains(body, "Everything on wheels.") {
+			t.Fatalf("want categories listed, got: %s", body)
+		}
+	})
+
+	t.Run("err wrong method", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/categories", nil)
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Fatalf("want: %v, got: %v", http.StatusMethodNotAllowed, rec.Code)
+		}
+	})
+}
+
 func TestCreateCategoryPageHandler(t *testing.T) {
+	ctx := context.Background()
 	handler := setup()
 
 	t.Run("OK", func(t *testing.T) {
-		if err := handler.Usecases.Users.SignUp(entity.User{}); err != nil {
+		if err := handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
 			t.Fatal(err)
 		}
 		rec := httptest.NewRecorder()
@@ -195,7 +284,7 @@ func TestCreateCategoryPageHandler(t *testing.T) {
 	})
 
 	t.Run("err method not allowed", func(t *testing.T) {
-		if err := handler.Usecases.Users.SignUp(entity.User{}); err != nil {
+		if err := handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
 			t.Fatal(err)
 		}
 		rec := httptest.NewRecorder()
@@ -215,7 +304,7 @@ func TestCreateCategoryPageHandler(t *testing.T) {
 	t.Run("err low access level", func(t *testing.T) {
 		// if there is one user, he becomes admin and can create categories
 		// if more, he behaves as simple user
-		if err := handler.Usecases.Users.SignUp(entity.User{}); err != nil {
+		if err := handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
 			t.Fatal(err)
 		}
 
@@ -235,10 +324,11 @@ func TestCreateCategoryPageHandler(t *testing.T) {
 }
 
 func TestCreateCategoryHandler(t *testing.T) {
+	ctx := context.Background()
 	handler := setup()
 
 	t.Run("OK", func(t *testing.T) {
-		if err := handler.Usecases.Users.SignUp(entity.User{}); err != nil {
+		if err := handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
 			t.Fatal(err)
 		}
 		rec := httptest.NewRecorder()
@@ -276,6 +366,27 @@ func TestCreateCategoryHandler(t *testing.T) {
 		}
 	})
 
+	t.Run("err bad color", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/create_category", nil)
+
+		cookie := &http.Cookie{
+			Name: "session_token",
+		}
+		req.AddCookie(cookie)
+
+		form := url.Values{}
+		form.Add("category", "movies")
+		form.Add("color", "red; background: url(x)")
+		req.PostForm = form
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("want: %v, got: %v", http.StatusBadRequest, rec.Code)
+		}
+	})
+
 	t.Run("err empty request", func(t *testing.T) {
 		rec := httptest.NewRecorder()
 		req := httptest.NewRequest(http.MethodPost, "/create_category", nil)
@@ -294,12 +405,13 @@ func TestCreateCategoryHandler(t *testing.T) {
 }
 
 func TestSearchByCategoryHandler(t *testing.T) {
+	ctx := context.Background()
 	handler := setup()
 
 	t.Run("OK", func(t *testing.T) {
 		rec := httptest.NewRecorder()
 		req := httptest.NewRequest(http.MethodGet, "/categories/cars", nil)
-		if err := handler.Usecases.Posts.CreateCategories([]string{"cars"}); err != nil {
+		if err := handler.Usecases.Categories.CreateCategories(ctx, []entity.Category{{Name: "cars"}}); err != nil {
 			t.Fatal(err)
 		}
 		handler.Mux.ServeHTTP(rec, req)
@@ -320,16 +432,36 @@ func TestSearchByCategoryHandler(t *testing.T) {
 		}
 	})
 
+	t.Run("OK cursor", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/categories/cars?cursor=0", nil)
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("want: %v, got: %v", http.StatusOK, rec.Code)
+		}
+	})
+
+	t.Run("err cursor", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/categories/cars?cursor=x", nil)
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("want: %v, got: %v", http.StatusBadRequest, rec.Code)
+		}
+	})
+
 	t.Run("err category not found", func(t *testing.T) {
 		rec := httptest.NewRecorder()
 		req := httptest.NewRequest(http.MethodGet, "/categories/qwerty", nil)
-		if err := handler.Usecases.Posts.CreateCategories([]string{"cars"}); err != nil {
+		if err := handler.Usecases.Categories.CreateCategories(ctx, []entity.Category{{Name: "cars"}}); err != nil {
 			t.Fatal(err)
 		}
 		handler.Mux.ServeHTTP(rec, req)
 
-		if rec.Code != http.StatusBadRequest {
-			t.Fatalf("want: %v, got: %v", http.StatusBadRequest, rec.Code)
+		if rec.Code != http.StatusNotFound {
+			t.Fatalf("want: %v, got: %v", http.StatusNotFound, rec.Code)
 		}
 	})
 }
@@ -48,8 +48,6 @@ func (h *Handler) ParseAndExecute(w http.ResponseWriter, content Content, path s
 }
 
 func (h *Handler) Errors(w http.ResponseWriter, status int) {
-	root := getRootPath()
-
 	errors := ErrMessage{}
 	switch status {
 	case http.StatusBadRequest:
@@ -74,9 +72,17 @@ func (h *Handler) Errors(w http.ResponseWriter, status int) {
 		errors.Code = http.StatusInternalServerError
 		errors.Message = InternalServerErr
 	}
+	h.errorPage(w, errors)
+}
+
+// errorPage answers with the error page of the message, for errors that
+// need more words than Errors has for their status.
+func (h *Handler) errorPage(w http.ResponseWriter, errors ErrMessage) {
+	root := getRootPath()
+
 	html, err := template.ParseFiles(root + "templates/errors.html")
 	if err != nil {
-		h.l.WriteLog(fmt.Errorf("v1 - Errors - ParseFiles: %w", err))
+		h.l.WriteLog(fmt.Errorf("v1 - errorPage - ParseFiles: %w", err))
 		http.Error(w, InternalServerErr, http.StatusInternalServerError)
 		return
 	}
@@ -98,9 +104,24 @@ func (h *Handler) RegisterRoutes(router *http.ServeMux) {
 	router.Handle("/signup_page", h.AssignStatus(http.HandlerFunc(h.SignUpPageHandler)))
 	router.Handle("/signin", h.AssignStatus(http.HandlerFunc(h.SignInHandler)))
 	router.Handle("/signup", h.AssignStatus(http.HandlerFunc(h.SignUpHandler)))
+	router.Handle("/forgot_password_page", h.AssignStatus(http.HandlerFunc(h.ForgotPasswordPageHandler)))
+	router.Handle("/forgot_password", h.AssignStatus(http.HandlerFunc(h.ForgotPasswordHandler)))
+	router.Handle("/reset_password_page", h.AssignStatus(http.HandlerFunc(h.ResetPasswordPageHandler)))
+	router.Handle("/reset_password", h.AssignStatus(http.HandlerFunc(h.ResetPasswordHandler)))
 	router.Handle("/signout", h.CheckAuth(http.HandlerFunc(h.SignOutHandler)))
 	router.Handle("/edit_profile_page/", h.CheckAuth(http.HandlerFunc(h.EditProfilePageHandler)))
 	router.Handle("/edit_profile/", h.CheckAuth(http.HandlerFunc(h.EditProfileHandler)))
+	router.Handle("/sessions", h.CheckAuth(http.HandlerFunc(h.SessionsPageHandler)))
+	router.Handle("/revoke_session/", h.CheckAuth(http.HandlerFunc(h.RevokeSessionHandler)))
+	router.Handle("/sign_out_everywhere", h.CheckAuth(http.HandlerFunc(h.SignOutEverywhereHandler)))
+	router.Handle("/verify_email", h.AssignStatus(http.HandlerFunc(h.VerifyEmailHandler)))
+	router.Handle("/resend_verification", h.CheckAuth(http.HandlerFunc(h.ResendVerificationHandler)))
+	router.Handle("/change_email", h.CheckAuth(http.HandlerFunc(h.ChangeEmailHandler)))
+	router.Handle("/signin_2fa", h.AssignStatus(http.HandlerFunc(h.SignInTwoFactorHandler)))
+	router.Handle("/two_factor", h.CheckAuth(http.HandlerFunc(h.TwoFactorPageHandler)))
+	router.Handle("/enable_two_factor", h.CheckAuth(http.HandlerFunc(h.EnableTwoFactorHandler)))
+	router.Handle("/disable_two_factor", h.CheckAuth(http.HandlerFunc(h.DisableTwoFactorHandler)))
+	router.Handle("/reset_two_factor/", h.CheckAuth(http.HandlerFunc(h.ResetTwoFactorHandler)))
 	router.Handle("/users/", h.AssignStatus(http.HandlerFunc(h.UserPageHandler)))
 	router.Handle("/all_users_page", h.AssignStatus(http.HandlerFunc(h.AllUsersPageHandler)))
 	router.Handle("/find_reacted_users/", h.CheckAuth(http.HandlerFunc(h.FindReactedUsersHandler)))
@@ -112,21 +133,56 @@ func (h *Handler) RegisterRoutes(router *http.ServeMux) {
 	router.HandleFunc("/oauth2_signin/", h.OauthSigninHandler)
 
 	// posts routes
-	router.Handle("/create_category_page", h.CheckAuth(http.HandlerFunc(h.CreateCategoryPageHandler)))
-	router.Handle("/create_category", h.CheckAuth(http.HandlerFunc(h.CreateCategoryHandler)))
+	router.Handle("/create_category_page", h.CheckAuth(h.CheckVerified(http.HandlerFunc(h.CreateCategoryPageHandler))))
+	router.Handle("/create_category", h.CheckAuth(h.CheckVerified(http.HandlerFunc(h.CreateCategoryHandler))))
+	router.Handle("/categories", h.AssignStatus(http.HandlerFunc(h.CategoriesHandler)))
 	router.Handle("/categories/", h.AssignStatus(http.HandlerFunc(h.SearchByCategoryHandler)))
+	router.Handle("/manage_categories", h.CheckAuth(http.HandlerFunc(h.ManageCategoriesPageHandler)))
+	router.Handle("/rename_category/", h.CheckAuth(http.HandlerFunc(h.RenameCategoryHandler)))
+	router.Handle("/merge_category/", h.CheckAuth(http.HandlerFunc(h.MergeCategoryHandler)))
+	router.Handle("/delete_category/", h.CheckAuth(http.HandlerFunc(h.DeleteCategoryHandler)))
+	router.Handle("/reorder_categories", h.CheckAuth(http.HandlerFunc(h.ReorderCategoriesHandler)))
 	router.Handle("/posts/", h.AssignStatus(http.HandlerFunc(h.PostPageHandler)))
-	router.Handle("/create_post_page", h.CheckAuth(http.HandlerFunc(h.CreatePostPageHandler)))
-	router.Handle("/create_post", h.CheckAuth(http.HandlerFunc(h.CreatePostHandler)))
+	router.Handle("/create_post_page", h.CheckAuth(h.CheckVerified(http.HandlerFunc(h.CreatePostPageHandler))))
+	router.Handle("/create_post", h.CheckAuth(h.CheckVerified(http.HandlerFunc(h.CreatePostHandler))))
 	router.Handle("/find_posts/", h.CheckAuth(http.HandlerFunc(h.FindPostsHandler)))
-	router.Handle("/put_post_like/", h.CheckAuth(http.HandlerFunc(h.PostPutLikeHandler)))
-	router.Handle("/put_post_dislike/", h.CheckAuth(http.HandlerFunc(h.PostPutDislikeHandler)))
+	router.Handle("/put_post_reaction/", h.CheckAuth(h.CheckVerified(http.HandlerFunc(h.PostPutReactionHandler))))
 
 	// comments routes
-	router.Handle("/create_comment_page/", h.CheckAuth(http.HandlerFunc(h.CreateCommentPageHandler)))
-	router.Handle("/create_comment/", h.CheckAuth(http.HandlerFunc(h.CreateCommentHandler)))
-	router.Handle("/put_comment_like/", h.CheckAuth(http.HandlerFunc(h.CommentPutLikeHandler)))
-	router.Handle("/put_comment_dislike/", h.CheckAuth(http.HandlerFunc(h.CommentPutDislikeHandler)))
+	router.Handle("/create_comment_page/", h.CheckAuth(h.CheckVerified(http.HandlerFunc(h.CreateCommentPageHandler))))
+	router.Handle("/create_comment/", h.CheckAuth(h.CheckVerified(http.HandlerFunc(h.CreateCommentHandler))))
+	router.Handle("/put_comment_reaction/", h.CheckAuth(h.CheckVerified(http.HandlerFunc(h.CommentPutReactionHandler))))
+
+	// revision routes
+	router.Handle("/edit_post_page/", h.CheckAuth(http.HandlerFunc(h.EditPostPageHandler)))
+	router.Handle("/edit_post/", h.CheckAuth(http.HandlerFunc(h.EditPostHandler)))
+	router.Handle("/edit_comment_page/", h.CheckAuth(http.HandlerFunc(h.EditCommentPageHandler)))
+	router.Handle("/edit_comment/", h.CheckAuth(http.HandlerFunc(h.EditCommentHandler)))
+	router.Handle("/post_history/", h.AssignStatus(http.HandlerFunc(h.PostHistoryHandler)))
+	router.Handle("/comment_history/", h.AssignStatus(http.HandlerFunc(h.CommentHistoryHandler)))
+
+	// trash routes
+	router.Handle("/trash", h.CheckAuth(http.HandlerFunc(h.TrashPageHandler)))
+	router.Handle("/delete_post/", h.CheckAuth(http.HandlerFunc(h.DeletePostHandler)))
+	router.Handle("/delete_comment/", h.CheckAuth(http.HandlerFunc(h.DeleteCommentHandler)))
+	router.Handle("/restore_post/", h.CheckAuth(http.HandlerFunc(h.RestorePostHandler)))
+	router.Handle("/restore_comment/", h.CheckAuth(http.HandlerFunc(h.RestoreCommentHandler)))
+
+	// backup routes
+	router.Handle("/backups", h.CheckAuth(http.HandlerFunc(h.BackupsPageHandler)))
+	router.Handle("/backups/", h.CheckAuth(http.HandlerFunc(h.DownloadBackupHandler)))
+	router.Handle("/create_backup", h.CheckAuth(http.HandlerFunc(h.CreateBackupHandler)))
+	router.Handle("/export_forum", h.CheckAuth(http.HandlerFunc(h.ExportForumHandler)))
+	router.Handle("/import_forum", h.CheckAuth(http.HandlerFunc(h.ImportForumHandler)))
+
+	// roles routes
+	router.Handle("/roles", h.CheckAuth(http.HandlerFunc(h.RolesPageHandler)))
+	router.Handle("/create_role", h.CheckAuth(http.HandlerFunc(h.CreateRoleHandler)))
+	router.Handle("/update_role/", h.CheckAuth(http.HandlerFunc(h.UpdateRoleHandler)))
+	router.Handle("/delete_role/", h.CheckAuth(http.HandlerFunc(h.DeleteRoleHandler)))
+	router.Handle("/assign_role/", h.CheckAuth(http.HandlerFunc(h.AssignRoleHandler)))
+	router.Handle("/ban_user/", h.CheckAuth(http.HandlerFunc(h.BanUserHandler)))
+	router.Handle("/unban_user/", h.CheckAuth(http.HandlerFunc(h.UnbanUserHandler)))
 
 	// fileserver
 	router.Handle("/templates/css/", http.StripPrefix("/templates/css/", http.FileServer(http.Dir("templates/css"))))
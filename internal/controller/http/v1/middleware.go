@@ -4,16 +4,19 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"time"
+
+	"forum/internal/entity"
 )
 
 func (h *Handler) CheckAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		foundUser := h.GetExistedSession(w, r)
-		if foundUser.Id == 0 {
+		session, found := h.GetExistedSession(w, r)
+		if !found {
 			h.Errors(w, http.StatusUnauthorized)
 			return
 		}
-		isAuthorized, err := h.Usecases.Users.CheckSession(foundUser)
+		session, isAuthorized, err := h.Usecases.Users.CheckSession(r.Context(), session)
 		if err != nil {
 			h.l.WriteLog(fmt.Errorf("v1 - CheckAuth - CheckSession: %w", err))
 			h.Errors(w, http.StatusInternalServerError)
@@ -23,56 +26,73 @@ func (h *Handler) CheckAuth(next http.Handler) http.Handler {
 			h.Errors(w, http.StatusUnauthorized)
 			return
 		}
-		err = h.Usecases.Users.UpdateSession(foundUser)
+		err = h.Usecases.Users.UpdateSession(r.Context(), session)
 		if err != nil {
 			h.l.WriteLog(fmt.Errorf("v1 - CheckAuth - UpdateSession: %w", err))
 			h.Errors(w, http.StatusInternalServerError)
 			return
 		}
 
-		content := Content{}
-		if foundUser.Id == 1 {
-			content.Admin = true
-		}
-		content.User.Id = foundUser.Id
-		content.Authorized = isAuthorized
-		content.Unauthorized = !isAuthorized
-		ctx := context.Background()
-		key := Key("content")
-
-		ctx = context.WithValue(ctx, key, content)
+		content := h.sessionContent(session, isAuthorized)
+		ctx := context.WithValue(r.Context(), Key("content"), content)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
 func (h *Handler) AssignStatus(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		foundUser := h.GetExistedSession(w, r)
-		isAuthorized, err := h.Usecases.Users.CheckSession(foundUser)
-		if err != nil {
-			h.l.WriteLog(fmt.Errorf("v1 - AssignStatus - CheckSession: %w", err))
-			h.Errors(w, http.StatusInternalServerError)
-			return
+		session, found := h.GetExistedSession(w, r)
+		isAuthorized := false
+		if found {
+			var err error
+			session, isAuthorized, err = h.Usecases.Users.CheckSession(r.Context(), session)
+			if err != nil {
+				h.l.WriteLog(fmt.Errorf("v1 - AssignStatus - CheckSession: %w", err))
+				h.Errors(w, http.StatusInternalServerError)
+				return
+			}
 		}
 		if isAuthorized {
-			err = h.Usecases.Users.UpdateSession(foundUser)
+			err := h.Usecases.Users.UpdateSession(r.Context(), session)
 			if err != nil {
 				h.l.WriteLog(fmt.Errorf("v1 - AssignStatus - UpdateSession: %w", err))
 				h.Errors(w, http.StatusInternalServerError)
 				return
 			}
 		}
-		content := Content{}
-		if foundUser.Id == 1 {
-			content.Admin = true
-		}
-		content.User.Id = foundUser.Id
-		content.Authorized = isAuthorized
-		content.Unauthorized = !isAuthorized
-		ctx := context.Background()
-		key := Key("content")
-
-		ctx = context.WithValue(ctx, key, content)
+		content := h.sessionContent(session, isAuthorized)
+		ctx := context.WithValue(r.Context(), Key("content"), content)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// sessionContent is the content every page starts with, the user of an
+// expired session is a guest.
+func (h *Handler) sessionContent(session entity.Session, authorized bool) Content {
+	content := Content{}
+	if authorized {
+		content.Session = session
+		content.User.Id = session.User.Id
+		content.Role = session.User.Role
+		content.Verified = session.User.EmailVerified
+	}
+	content.Authorized = authorized
+	content.Unauthorized = !authorized
+	content.Location = h.location(session.User, authorized)
+	return content
+}
+
+// location is the timezone dates are shown in to the user: the one they
+// picked, or the forum default for guests and those who picked none.
+func (h *Handler) location(user entity.User, authorized bool) *time.Location {
+	name := h.Cfg.Timezone
+	if authorized && user.Timezone != "" {
+		name = user.Timezone
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - location - LoadLocation: %w", err))
+		return time.UTC
+	}
+	return location
+}
@@ -1,6 +1,7 @@
 package v1_test
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -19,10 +20,10 @@ func getMockHandlerOne(t *testing.T, key string) http.HandlerFunc {
 		if !content.Authorized {
 			t.Fatalf("want: true, got: false")
 		}
-		if content.User.Id == 1 && !content.Admin {
+		if content.User.Id == 1 && !content.Can(entity.PermManageRoles) {
 			t.Fatalf("want: true, got: false")
 		}
-		if !content.Admin {
+		if !content.Can(entity.PermManageRoles) {
 			w.WriteHeader(http.StatusForbidden)
 		} else {
 			w.WriteHeader(http.StatusOK)
@@ -41,10 +42,10 @@ func getMockHandlerTwo(t *testing.T, key string) http.HandlerFunc {
 		if content.Authorized && content.Unauthorized || !content.Authorized && !content.Unauthorized {
 			t.Fatalf("should not both be true/false")
 		}
-		if content.User.Id == 1 && !content.Admin {
+		if content.User.Id == 1 && !content.Can(entity.PermManageRoles) {
 			t.Fatalf("want: true, got: false")
 		}
-		if content.Admin && !content.Authorized {
+		if content.Role.Id != 0 && !content.Authorized {
 			t.Fatalf("want: true, got: false")
 		}
 		w.WriteHeader(http.StatusOK)
@@ -53,10 +54,11 @@ func getMockHandlerTwo(t *testing.T, key string) http.HandlerFunc {
 }
 
 func TestCheckAuth(t *testing.T) {
+	ctx := context.Background()
 	handler := setup()
 
 	t.Run("OK", func(t *testing.T) {
-		if err := handler.Usecases.Users.SignUp(entity.User{}); err != nil {
+		if err := handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
 			t.Fatal(err)
 		}
 
@@ -80,7 +82,7 @@ func TestCheckAuth(t *testing.T) {
 
 	t.Run("err user is not admin (low access level)", func(t *testing.T) {
 		// creating another user and id will become not 1
-		if err := handler.Usecases.Users.SignUp(entity.User{}); err != nil {
+		if err := handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
 			t.Fatal(err)
 		}
 
@@ -139,10 +141,11 @@ func TestCheckAuth(t *testing.T) {
 }
 
 func TestAssignStatus(t *testing.T) {
+	ctx := context.Background()
 	handler := setup()
 
 	t.Run("OK", func(t *testing.T) {
-		if err := handler.Usecases.Users.SignUp(entity.User{}); err != nil {
+		if err := handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
 			t.Fatal(err)
 		}
 
@@ -179,6 +182,28 @@ func TestAssignStatus(t *testing.T) {
 		}
 	})
 
+	t.Run("OK request context kept", func(t *testing.T) {
+		type requestKey string
+		handlerToTest := handler.AssignStatus(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Context().Value(requestKey("id")) != "42" || r.Context().Err() == nil {
+				w.WriteHeader(http.StatusNotAcceptable)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		reqCtx, cancel := context.WithCancel(context.WithValue(ctx, requestKey("id"), "42"))
+		cancel()
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "http://testing", nil).WithContext(reqCtx)
+
+		handlerToTest.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("want: %v, got: %v", http.StatusOK, rec.Code)
+		}
+	})
+
 	t.Run("err wrong context", func(t *testing.T) {
 		mockHandler := getMockHandlerTwo(t, "wrongKey")
 
@@ -130,21 +130,56 @@ func (h *Handler) OauthSignIn(w http.ResponseWriter, r *http.Request, oauthParam
 
 	// different apis give response in a different way
 	// and parsing information gets a bit messy
-	if oauthContent.Email != "" {
-		user.Email = oauthContent.Email
-	} else if oauthContents[0].Email != "" {
-		user.Email = strings.ToLower(oauthContents[0].Email)
+	if oauthContent.Email == "" && len(oauthContents) > 0 {
+		// github lists every email of the account, the primary one is used
+		oauthContent = oauthContents[0]
+		for _, listed := range oauthContents {
+			if listed.Primary {
+				oauthContent = listed
+				break
+			}
+		}
+		oauthContent.Email = strings.ToLower(oauthContent.Email)
+	}
+	if oauthContent.Email == "" {
+		h.l.WriteLog(fmt.Errorf("v1 - OauthSignIn %v - no email", oauthParams.ApiName))
+		http.Redirect(w, r, "/", http.StatusTemporaryRedirect)
+		return
 	}
+	user.Email = oauthContent.Email
 
 	// if there is no user with such email in db, register it
-	id, err := h.Usecases.Users.GetIdBy(user)
+	id, err := h.Usecases.Users.GetIdBy(r.Context(), user)
 	if err != nil && !errors.Is(err, entity.ErrUserNotFound) {
 		h.l.WriteLog(fmt.Errorf("v1 - OauthSignIn GetIdBy: %w", err))
 		h.Errors(w, http.StatusInternalServerError)
 		return
 	}
 
+	// an unverified account may have been registered by someone else with
+	// this email, the provider's word is not enough to hand it over. Nor is
+	// an email the provider hasn't checked itself enough to sign in to any
+	// account.
+	if err == nil {
+		if !oauthContent.verified() {
+			h.errorPage(w, ErrMessage{Code: http.StatusForbidden, Message: OauthProviderUnverified})
+			return
+		}
+		existUser, err := h.Usecases.Users.GetById(r.Context(), id)
+		if err != nil {
+			h.l.WriteLog(fmt.Errorf("v1 - OauthSignIn GetById: %w", err))
+			h.Errors(w, http.StatusInternalServerError)
+			return
+		}
+		if !existUser.EmailVerified {
+			h.errorPage(w, ErrMessage{Code: http.StatusForbidden, Message: OauthEmailNotVerified})
+			return
+		}
+	}
+
 	if errors.Is(err, entity.ErrUserNotFound) {
+		// an email the provider has checked needs no link of ours
+		user.EmailVerified = oauthContent.verified()
 		if oauthContent.Name != "" {
 			user.Name = oauthContent.Name
 		} else {
@@ -152,36 +187,46 @@ func (h *Handler) OauthSignIn(w http.ResponseWriter, r *http.Request, oauthParam
 			// name will be chars before '@' from email
 			user.Name = getNameFromEmail(user.Email)
 		}
-		if err := h.Usecases.Users.SignUp(user); err == entity.ErrUserNameAlreadyExists {
+		if err := h.Usecases.Users.SignUp(r.Context(), user); err == entity.ErrUserNameAlreadyExists {
 			suffix := 0
 			// if there is already user with that name
 			// add incrementing integer suffix to it, until register is ok
 			for err == entity.ErrUserNameAlreadyExists {
 				suffix++
 				user.Name = user.Name + strconv.Itoa(suffix)
-				err = h.Usecases.Users.SignUp(user)
+				err = h.Usecases.Users.SignUp(r.Context(), user)
 			}
 		}
 
-		// getting new registered user's id
-		id, err = h.Usecases.Users.GetIdBy(user)
+		// checking the user got registered
+		id, err = h.Usecases.Users.GetIdBy(r.Context(), user)
 		if err != nil {
 			h.l.WriteLog(fmt.Errorf("v1 - OauthSignIn %v - exchageCode: %w", oauthParams.ApiName, err))
 			h.Errors(w, http.StatusInternalServerError)
 			return
 		}
+
+		// the account is there even when the letter fails, it can be sent
+		// again from the profile
+		if !user.EmailVerified {
+			err = h.Usecases.Verification.SendVerification(r.Context(), id)
+			if err != nil {
+				h.l.WriteLog(fmt.Errorf("v1 - OauthSignIn %v - SendVerification: %w", oauthParams.ApiName, err))
+			}
+		}
 	}
 
-	// generating session token
-	err = h.Usecases.Users.SignIn(user)
-	if err != nil {
-		h.l.WriteLog(fmt.Errorf("v1 - OauthSignIn %v - exchageCode: %w", oauthParams.ApiName, err))
-		h.Errors(w, http.StatusInternalServerError)
+	// opening a session for this browser
+	session, _ := h.GetExistedSession(w, r)
+	session, err = h.Usecases.Users.SignIn(r.Context(), user, session)
+	if errors.Is(err, entity.ErrTwoFactorRequired) {
+		h.askSecondFactor(w, r, session.User.Id)
+		return
+	}
+	if errors.Is(err, entity.ErrUserBanned) {
+		h.errorPage(w, ErrMessage{Code: http.StatusForbidden, Message: UserBanned})
 		return
 	}
-
-	// getting generated token from db for saving in cookie
-	userWithSession, err := h.Usecases.Users.GetSession(id)
 	if err != nil {
 		h.l.WriteLog(fmt.Errorf("v1 - OauthSignIn %v - exchageCode: %w", oauthParams.ApiName, err))
 		h.Errors(w, http.StatusInternalServerError)
@@ -191,8 +236,8 @@ func (h *Handler) OauthSignIn(w http.ResponseWriter, r *http.Request, oauthParam
 	// saving session token in cookie
 	http.SetCookie(w, &http.Cookie{
 		Name:    "session_token",
-		Value:   userWithSession.SessionToken,
-		Expires: userWithSession.SessionTTL,
+		Value:   session.Token,
+		Expires: session.ExpiresAt,
 		Path:    "/",
 		Domain:  h.Cfg.Server.Host,
 	})
@@ -0,0 +1,103 @@
+package v1_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	v1 "forum/internal/controller/http/v1"
+	"forum/internal/entity"
+	mu "forum/internal/usecase/mock"
+)
+
+// fakeProvider answers the token and user info requests of an OAuth
+// provider, user info is the given JSON.
+func fakeProvider(t *testing.T, userInfo string) v1.OauthURLs {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"access_token": "token"}`))
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(userInfo))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return v1.OauthURLs{Token: srv.URL + "/token", Access: srv.URL + "/userinfo?access_token"}
+}
+
+func TestOauthSignIn(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("GOOGLE_CLIENT_ID", "id")
+	t.Setenv("GOOGLE_CLIENT_SECRET", "secret")
+	t.Setenv("GITHUB_CLIENT_ID", "id")
+	t.Setenv("GITHUB_CLIENT_SECRET", "secret")
+	google, github := v1.GoogleOauthURLs, v1.GithubOauthURLs
+	defer func() {
+		v1.GoogleOauthURLs, v1.GithubOauthURLs = google, github
+	}()
+
+	callback := func(handler *v1.Handler, api string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/oauth2_callback_"+api+"?code=code&state="+v1.OauthState, nil)
+		handler.Mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	tests := []struct {
+		name     string
+		api      string
+		userInfo string
+		existing bool
+		code     int
+		verified bool
+		email    string
+	}{
+		{name: "OK verified sign up", api: "google", code: http.StatusFound, verified: true, email: "riddle@mail.ru",
+			userInfo: `{"email": "riddle@mail.ru", "name": "Riddle", "verified_email": true}`},
+		{name: "OK unverified sign up", api: "google", code: http.StatusFound, email: "riddle@mail.ru",
+			userInfo: `{"email": "riddle@mail.ru", "name": "Riddle"}`},
+		{name: "OK primary email", api: "github", code: http.StatusFound, email: "riddle@mail.ru",
+			userInfo: `[{"email": "old@mail.ru", "verified": true}, {"email": "Riddle@mail.ru", "primary": true}]`},
+		{name: "OK verified account", api: "google", existing: true, code: http.StatusFound,
+			userInfo: `{"email": "riddle@mail.ru", "verified_email": true}`},
+		{name: "err unverified email of an account", api: "google", existing: true, code: http.StatusForbidden,
+			userInfo: `{"email": "riddle@mail.ru", "verified_email": false}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := setup()
+			users := handler.Usecases.Users.(*mu.UsersMockUseCase)
+			verification := handler.Usecases.Verification.(*mu.VerificationMockUseCase)
+			if tt.existing {
+				if err := users.SignUp(ctx, entity.User{Email: "riddle@mail.ru", EmailVerified: true}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			urls := fakeProvider(t, tt.userInfo)
+			v1.GoogleOauthURLs, v1.GithubOauthURLs = urls, urls
+
+			rec := callback(handler, tt.api)
+
+			if rec.Code != tt.code {
+				t.Fatalf("want: %v, got: %v", tt.code, rec.Code)
+			}
+			if tt.existing {
+				if len(users.Users) != 1 {
+					t.Fatalf("want no sign up, got users: %v", users.Users)
+				}
+				return
+			}
+			if len(users.Users) != 1 {
+				t.Fatalf("want a sign up, got users: %v", users.Users)
+			}
+			if signedUp := users.Users[0]; signedUp.Email != tt.email || signedUp.EmailVerified != tt.verified {
+				t.Fatalf("want email %s verified %v, got user: %+v", tt.email, tt.verified, signedUp)
+			}
+			if sent := len(verification.Sent) == 1; sent == tt.verified {
+				t.Fatalf("want a verification link sent = %v, got sent: %v", !tt.verified, verification.Sent)
+			}
+		})
+	}
+}
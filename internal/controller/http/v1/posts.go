@@ -1,9 +1,9 @@
 package v1
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
-	"os"
 	"strconv"
 	"strings"
 
@@ -26,7 +26,7 @@ func (h *Handler) PostPageHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	post, err := h.Usecases.Posts.GetById(int64(id))
+	post, err := h.Usecases.Posts.GetById(r.Context(), int64(id))
 	if err != nil {
 		h.l.WriteLog(fmt.Errorf("v1 - PostPageHandler - GetById: %w", err))
 		h.Errors(w, http.StatusNotFound)
@@ -41,6 +41,23 @@ func (h *Handler) PostPageHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	number, cursor, byCursor, err := pageParams(r)
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - PostPageHandler - %w", err))
+		h.Errors(w, http.StatusBadRequest)
+		return
+	}
+	if byCursor {
+		post.Comments, content.Page, err = h.Usecases.Comments.GetCommentsAfter(r.Context(), post.Id, cursor)
+	} else {
+		post.Comments, content.Page, err = h.Usecases.Comments.GetCommentsPage(r.Context(), post.Id, number)
+	}
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - PostPageHandler - GetComments: %w", err))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
 	post.ContentWeb = strings.Split(post.Content, "\\n")
 	content.Post = post
 
@@ -64,13 +81,13 @@ func (h *Handler) CreatePostPageHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	categories, err := h.Usecases.Posts.GetAllCategories()
+	categories, err := h.Usecases.Categories.GetCategories(r.Context())
 	if err != nil {
-		h.l.WriteLog(fmt.Errorf("v1 - CreatePostPageHandler - GetAllCategories: %w", err))
+		h.l.WriteLog(fmt.Errorf("v1 - CreatePostPageHandler - GetCategories: %w", err))
 		h.Errors(w, http.StatusInternalServerError)
 		return
 	}
-	content.Post.Categories = categories
+	content.Categories = categories
 
 	err = h.ParseAndExecute(w, content, "templates/create_post.html")
 	if err != nil {
@@ -90,7 +107,7 @@ func (h *Handler) CreatePostHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	imagePath, err := h.GetImage(w, r)
+	images, err := h.GetImages(w, r)
 	if err != nil {
 		content := Content{}
 		if strings.Contains(err.Error(), imageTypeForbidden) ||
@@ -102,7 +119,7 @@ func (h *Handler) CreatePostHandler(w http.ResponseWriter, r *http.Request) {
 				h.l.WriteLog(fmt.Errorf("v1 - CreatePostHandler - ParseAndExecute #1: %w", err))
 			}
 		} else {
-			h.l.WriteLog(fmt.Errorf("v1 - CreatePostHandler - GetImage: %w", err))
+			h.l.WriteLog(fmt.Errorf("v1 - CreatePostHandler - GetImages: %w", err))
 			h.Errors(w, http.StatusInternalServerError)
 		}
 		return
@@ -125,7 +142,15 @@ func (h *Handler) CreatePostHandler(w http.ResponseWriter, r *http.Request) {
 	valid := true
 	postTitle := r.Form["title"][0]
 	postContent := r.Form["content"][0]
-	categories := r.Form["categories"]
+	var categories []entity.Category
+	for _, value := range r.Form["categories"] {
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			h.Errors(w, http.StatusBadRequest)
+			return
+		}
+		categories = append(categories, entity.Category{Id: id})
+	}
 
 	if len(categories) == 0 {
 		content.ErrorMsg.Message = PostCategoryRequired
@@ -137,54 +162,55 @@ func (h *Handler) CreatePostHandler(w http.ResponseWriter, r *http.Request) {
 	newPost.Content = strings.ReplaceAll(postContent, "\r\n", "\\n")
 	newPost.Categories = categories
 	newPost.User = content.User
-	newPost.ImagePath = "/" + imagePath
+	newPost.Images = imagePaths(images)
 	content.Post = newPost
 
 	if !valid {
+		h.removeImages(newPost.Images)
 		w.WriteHeader(http.StatusBadRequest)
-		categories, err := h.Usecases.Posts.GetAllCategories()
+		categories, err := h.Usecases.Categories.GetCategories(r.Context())
 		if err != nil {
-			h.l.WriteLog(fmt.Errorf("v1 - CreatePostHandler - GetAllCategories: %w", err))
+			h.l.WriteLog(fmt.Errorf("v1 - CreatePostHandler - GetCategories: %w", err))
 			h.Errors(w, http.StatusInternalServerError)
 			return
 		}
-		content.Post.Categories = categories
+		content.Categories = categories
 		err = h.ParseAndExecute(w, content, "templates/create_post.html")
 		if err != nil {
 			h.l.WriteLog(fmt.Errorf("v1 - CreatePostHandler - ParseAndExecute - %w", err))
 		}
 	} else {
-		err := h.Usecases.Posts.CreatePost(newPost)
+		err := h.Usecases.Posts.CreatePost(r.Context(), newPost)
 		if err != nil {
-			err = os.Remove(imagePath)
-			if err != nil {
-				h.l.WriteLog(fmt.Errorf("v1 - CreatePostHandler - Remove: %w", err))
-				h.Errors(w, http.StatusInternalServerError)
-				return
-			} else {
-				h.l.WriteLog(fmt.Errorf("v1 - CreatePostHandler - CreatePost: %w", err))
-				h.Errors(w, http.StatusInternalServerError)
+			h.l.WriteLog(fmt.Errorf("v1 - CreatePostHandler - CreatePost: %w", err))
+			h.removeImages(newPost.Images)
+			// the form can offer a category removed since
+			if errors.Is(err, entity.ErrCategoryNotFound) {
+				h.Errors(w, http.StatusBadRequest)
 				return
 			}
+			h.Errors(w, http.StatusInternalServerError)
+			return
 		}
 		http.Redirect(w, r, "/", http.StatusFound)
 	}
 }
 
-func (h *Handler) PostPutLikeHandler(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) PostPutReactionHandler(w http.ResponseWriter, r *http.Request) {
 	path := strings.Split(r.URL.Path, "/")
+	kind := path[len(path)-2]
 	id, err := strconv.Atoi(path[len(path)-1])
 	if err != nil {
-		h.l.WriteLog(fmt.Errorf("v1 - PostPutLikeHandler - Atoi: %w", err))
+		h.l.WriteLog(fmt.Errorf("v1 - PostPutReactionHandler - Atoi: %w", err))
 	}
-	if r.URL.Path != "/put_post_like/"+path[len(path)-1] || err != nil || id <= 0 {
+	if r.URL.Path != "/put_post_reaction/"+kind+"/"+path[len(path)-1] || err != nil || id <= 0 {
 		h.Errors(w, http.StatusNotFound)
 		return
 	}
 
 	content, ok := r.Context().Value(Key("content")).(Content)
 	if !ok {
-		h.l.WriteLog(fmt.Errorf("v1 - PostPutLikeHandler - TypeAssertion:"+
+		h.l.WriteLog(fmt.Errorf("v1 - PostPutReactionHandler - TypeAssertion:"+
 			"got data of type %T but wanted v1.Content", content))
 		h.Errors(w, http.StatusInternalServerError)
 		return
@@ -195,43 +221,13 @@ func (h *Handler) PostPutLikeHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	post.User.Id = content.User.Id
 
-	err = h.Usecases.Posts.MakeReaction(post, CommandPutLike)
+	err = h.Usecases.Posts.MakeReaction(r.Context(), post, kind)
 	if err != nil {
-		h.l.WriteLog(fmt.Errorf("v1 - PostPutLikeHandler - MakeReaction: %w", err))
-		h.Errors(w, http.StatusNotFound)
-		return
-	}
-
-	http.Redirect(w, r, r.Header.Get("Referer"), http.StatusFound)
-}
-
-func (h *Handler) PostPutDislikeHandler(w http.ResponseWriter, r *http.Request) {
-	path := strings.Split(r.URL.Path, "/")
-	id, err := strconv.Atoi(path[len(path)-1])
-	if err != nil {
-		h.l.WriteLog(fmt.Errorf("v1 - PostPutDislikeHandler - Atoi: %w", err))
-	}
-	if r.URL.Path != "/put_post_dislike/"+path[len(path)-1] || err != nil || id <= 0 {
-		h.Errors(w, http.StatusNotFound)
-		return
-	}
-
-	content, ok := r.Context().Value(Key("content")).(Content)
-	if !ok {
-		h.l.WriteLog(fmt.Errorf("v1 - PostPutDislikeHandler - TypeAssertion:"+
-			"got data of type %T but wanted v1.Content", content))
-		h.Errors(w, http.StatusInternalServerError)
-		return
-	}
-
-	post := entity.Post{
-		Id: int64(id),
-	}
-	post.User.Id = content.User.Id
-
-	err = h.Usecases.Posts.MakeReaction(post, CommandPutDislike)
-	if err != nil {
-		h.l.WriteLog(fmt.Errorf("v1 - PostPutDislikeHandler - MakeReaction: %w", err))
+		h.l.WriteLog(fmt.Errorf("v1 - PostPutReactionHandler - MakeReaction: %w", err))
+		if errors.Is(err, entity.ErrUnknownReaction) {
+			h.Errors(w, http.StatusBadRequest)
+			return
+		}
 		h.Errors(w, http.StatusNotFound)
 		return
 	}
@@ -268,7 +264,7 @@ func (h *Handler) FindPostsHandler(w http.ResponseWriter, r *http.Request) {
 
 	user := entity.User{Id: int64(userId)}
 
-	posts, err := h.Usecases.Posts.GetPostsByQuery(user, query)
+	posts, err := h.Usecases.Posts.GetPostsByQuery(r.Context(), user, query)
 	if err != nil {
 		h.l.WriteLog(fmt.Errorf("v1 - FindPostsHandler - GetPostsByQuery: %w", err))
 		h.Errors(w, http.StatusNotFound)
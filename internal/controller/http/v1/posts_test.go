@@ -1,10 +1,19 @@
 package v1_test
 
 import (
+	"bytes"
+	"context"
 	"forum/internal/entity"
+	mu "forum/internal/usecase/mock"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/textproto"
 	"net/url"
+	"os"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -21,6 +30,26 @@ func TestPostPageHandler(t *testing.T) {
 		}
 	})
 
+	t.Run("OK page", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/posts/2?page=2", nil)
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("want: %v, got: %v", http.StatusOK, rec.Code)
+		}
+	})
+
+	t.Run("err bad page", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/posts/2?page=0", nil)
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("want: %v, got: %v", http.StatusBadRequest, rec.Code)
+		}
+	})
+
 	t.Run("err wrong method", func(t *testing.T) {
 		rec := httptest.NewRecorder()
 		req := httptest.NewRequest(http.MethodPut, "/posts/2", nil)
@@ -43,8 +72,9 @@ func TestPostPageHandler(t *testing.T) {
 }
 
 func TestCreatePostPageHandler(t *testing.T) {
+	ctx := context.Background()
 	handler := setup()
-	if err := handler.Usecases.Users.SignUp(entity.User{}); err != nil {
+	if err := handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
 		t.Fatal(err)
 	}
 
@@ -80,8 +110,9 @@ func TestCreatePostPageHandler(t *testing.T) {
 }
 
 func TestCreatePostHandler(t *testing.T) {
+	ctx := context.Background()
 	handler := setup()
-	if err := handler.Usecases.Users.SignUp(entity.User{}); err != nil {
+	if err := handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
 		t.Fatal(err)
 	}
 
@@ -98,7 +129,7 @@ func TestCreatePostHandler(t *testing.T) {
 		req.AddCookie(cookie)
 		form := url.Values{}
 		form.Add("title", "BMW")
-		form.Add("categories", "cars")
+		form.Add("categories", "1")
 		req.PostForm = form
 		mw.Close()
 		handler.Mux.ServeHTTP(rec, req)
@@ -108,6 +139,68 @@ func TestCreatePostHandler(t *testing.T) {
 		}
 	})
 
+	t.Run("OK images", func(t *testing.T) {
+		body, mw := imagesForm(t, map[string]string{
+			"../../../../templates/img/github_auth_icon.jpg": "image/jpeg",
+			"../../../../templates/img/buttons/home.png":     "image/png",
+		}, "../../../../templates/img/github_auth_icon.jpg", "../../../../templates/img/buttons/home.png")
+		for field, value := range map[string]string{"title": "BMW", "content": "Lorem ipsum.", "categories": "1"} {
+			if err := mw.WriteField(field, value); err != nil {
+				t.Fatal(err)
+			}
+		}
+		mw.Close()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/create_post", body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		cookie := &http.Cookie{
+			Name: "session_token",
+		}
+		req.AddCookie(cookie)
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusFound {
+			t.Fatalf("want: %v, got: %v", http.StatusFound, rec.Code)
+		}
+		posts := handler.Usecases.Posts.(*mu.PostsMockUseCase).Posts
+		images := posts[len(posts)-1].Images
+		for _, image := range images {
+			if err := os.Remove(strings.TrimPrefix(image, "/")); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if len(images) != 2 || !strings.HasSuffix(images[0], ".jpeg") || !strings.HasSuffix(images[1], ".png") {
+			t.Fatalf("want both images in upload order, got: %v", images)
+		}
+	})
+
+	t.Run("err image type forbidden", func(t *testing.T) {
+		stored, _ := os.ReadDir("../../../../templates/img/storage")
+		body, mw := imagesForm(t, map[string]string{
+			"../../../../templates/img/buttons/home.png": "image/png",
+			"../../../../config.json":                    "text/plain",
+		}, "../../../../templates/img/buttons/home.png", "../../../../config.json")
+		mw.Close()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/create_post", body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		cookie := &http.Cookie{
+			Name: "session_token",
+		}
+		req.AddCookie(cookie)
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("want: %v, got: %v", http.StatusBadRequest, rec.Code)
+		}
+		left, _ := os.ReadDir("../../../../templates/img/storage")
+		if !reflect.DeepEqual(names(left), names(stored)) {
+			t.Fatalf("want the saved image removed, got: %v", names(left))
+		}
+	})
+
 	t.Run("err wrong method", func(t *testing.T) {
 		rec := httptest.NewRecorder()
 		req := httptest.NewRequest(http.MethodPatch, "/create_post", nil)
@@ -166,15 +259,16 @@ func TestCreatePostHandler(t *testing.T) {
 	})
 }
 
-func TestPostPutLikeHandler(t *testing.T) {
+func TestPostPutReactionHandler(t *testing.T) {
+	ctx := context.Background()
 	handler := setup()
-	if err := handler.Usecases.Users.SignUp(entity.User{}); err != nil {
+	if err := handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
 		t.Fatal(err)
 	}
 
 	t.Run("OK", func(t *testing.T) {
 		rec := httptest.NewRecorder()
-		req := httptest.NewRequest(http.MethodPost, "/put_post_like/1", nil)
+		req := httptest.NewRequest(http.MethodPost, "/put_post_reaction/like/1", nil)
 		cookie := &http.Cookie{
 			Name: "session_token",
 		}
@@ -189,7 +283,7 @@ func TestPostPutLikeHandler(t *testing.T) {
 
 	t.Run("err wrong path", func(t *testing.T) {
 		rec := httptest.NewRecorder()
-		req := httptest.NewRequest(http.MethodPost, "/put_post_like/wewe", nil)
+		req := httptest.NewRequest(http.MethodPost, "/put_post_reaction/like/wewe", nil)
 		cookie := &http.Cookie{
 			Name: "session_token",
 		}
@@ -201,32 +295,10 @@ func TestPostPutLikeHandler(t *testing.T) {
 			t.Fatalf("want: %v, got: %v", http.StatusNotFound, rec.Code)
 		}
 	})
-}
-
-func TestPostPutDislikeHandler(t *testing.T) {
-	handler := setup()
-	if err := handler.Usecases.Users.SignUp(entity.User{}); err != nil {
-		t.Fatal(err)
-	}
-
-	t.Run("OK", func(t *testing.T) {
-		rec := httptest.NewRecorder()
-		req := httptest.NewRequest(http.MethodPost, "/put_post_dislike/2", nil)
-		cookie := &http.Cookie{
-			Name: "session_token",
-		}
-		req.AddCookie(cookie)
-
-		handler.Mux.ServeHTTP(rec, req)
-
-		if rec.Code != http.StatusFound {
-			t.Fatalf("want: %v, got: %v", http.StatusFound, rec.Code)
-		}
-	})
 
-	t.Run("err wrong path", func(t *testing.T) {
+	t.Run("err unknown kind", func(t *testing.T) {
 		rec := httptest.NewRecorder()
-		req := httptest.NewRequest(http.MethodPost, "/put_post_dislike/12/e", nil)
+		req := httptest.NewRequest(http.MethodPost, "/put_post_reaction/angry/1", nil)
 		cookie := &http.Cookie{
 			Name: "session_token",
 		}
@@ -234,21 +306,22 @@ func TestPostPutDislikeHandler(t *testing.T) {
 
 		handler.Mux.ServeHTTP(rec, req)
 
-		if rec.Code != http.StatusNotFound {
-			t.Fatalf("want: %v, got: %v", http.StatusNotFound, rec.Code)
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("want: %v, got: %v", http.StatusBadRequest, rec.Code)
 		}
 	})
 }
 
 func TestFindPostsHandler(t *testing.T) {
+	ctx := context.Background()
 	handler := setup()
-	if err := handler.Usecases.Users.SignUp(entity.User{}); err != nil {
+	if err := handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
 		t.Fatal(err)
 	}
 
 	t.Run("OK", func(t *testing.T) {
 		rec := httptest.NewRecorder()
-		req := httptest.NewRequest(http.MethodGet, "/find_posts/liked/1", nil)
+		req := httptest.NewRequest(http.MethodGet, "/find_posts/like/1", nil)
 		cookie := &http.Cookie{
 			Name: "session_token",
 		}
@@ -291,3 +364,37 @@ func TestFindPostsHandler(t *testing.T) {
 		}
 	})
 }
+
+// imagesForm writes the files as images of a multipart form in the given
+// order, each with its content type.
+func imagesForm(t *testing.T, types map[string]string, paths ...string) (*bytes.Buffer, *multipart.Writer) {
+	body := new(bytes.Buffer)
+	mw := multipart.NewWriter(body)
+	for _, path := range paths {
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", `form-data; name="image"; filename="`+path+`"`)
+		header.Set("Content-Type", types[path])
+		part, err := mw.CreatePart(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = io.Copy(part, file)
+		file.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return body, mw
+}
+
+func names(entries []os.DirEntry) []string {
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
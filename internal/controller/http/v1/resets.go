@@ -0,0 +1,145 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"forum/internal/entity"
+)
+
+func (h *Handler) ForgotPasswordPageHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.Errors(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	err := h.ParseAndExecute(w, Content{}, "templates/forgot_password.html")
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - ForgotPasswordPageHandler - ParseAndExecute - %w", err))
+	}
+}
+
+// ForgotPasswordHandler mails a reset link. The answer is the same whether
+// the email is registered or not.
+func (h *Handler) ForgotPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.Errors(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		h.Errors(w, http.StatusBadRequest)
+		return
+	}
+	if len(r.Form["email"]) == 0 {
+		h.Errors(w, http.StatusBadRequest)
+		return
+	}
+
+	content := Content{}
+	email := r.Form["email"][0]
+	if !checkEmail(email) {
+		w.WriteHeader(http.StatusBadRequest)
+		content.ErrorMsg.Message = EmailFormatWrong
+		err := h.ParseAndExecute(w, content, "templates/forgot_password.html")
+		if err != nil {
+			h.l.WriteLog(fmt.Errorf("v1 - ForgotPasswordHandler - ParseAndExecute #1 - %w", err))
+		}
+		return
+	}
+
+	err := h.Usecases.Resets.RequestReset(r.Context(), strings.ToLower(email))
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - ForgotPasswordHandler - RequestReset: %w", err))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	content.Message = ResetLinkSent
+	err = h.ParseAndExecute(w, content, "templates/forgot_password.html")
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - ForgotPasswordHandler - ParseAndExecute #2 - %w", err))
+	}
+}
+
+// ResetPasswordPageHandler asks for the new password when the mailed link
+// is still valid.
+func (h *Handler) ResetPasswordPageHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.Errors(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	content := Content{}
+	token := r.URL.Query().Get("token")
+	err := h.Usecases.Resets.CheckToken(r.Context(), token)
+	if err != nil {
+		if !errors.Is(err, entity.ErrResetTokenInvalid) {
+			h.l.WriteLog(fmt.Errorf("v1 - ResetPasswordPageHandler - CheckToken: %w", err))
+			h.Errors(w, http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		content.ErrorMsg.Message = ResetLinkInvalid
+	} else {
+		content.Query = token
+	}
+
+	err = h.ParseAndExecute(w, content, "templates/reset_password.html")
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - ResetPasswordPageHandler - ParseAndExecute - %w", err))
+	}
+}
+
+// ResetPasswordHandler sets the new password and sends the user to sign in
+// again, on every device.
+func (h *Handler) ResetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.Errors(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		h.Errors(w, http.StatusBadRequest)
+		return
+	}
+	if len(r.Form["token"]) == 0 || len(r.Form["password"]) == 0 ||
+		len(r.Form["confirm_password"]) == 0 || r.Form["password"][0] == "" {
+		h.Errors(w, http.StatusBadRequest)
+		return
+	}
+
+	content := Content{}
+	token := r.Form["token"][0]
+	password := r.Form["password"][0]
+	if password != r.Form["confirm_password"][0] {
+		w.WriteHeader(http.StatusBadRequest)
+		content.Query = token
+		content.ErrorMsg.Message = PasswordsNotSame
+		err := h.ParseAndExecute(w, content, "templates/reset_password.html")
+		if err != nil {
+			h.l.WriteLog(fmt.Errorf("v1 - ResetPasswordHandler - ParseAndExecute #1 - %w", err))
+		}
+		return
+	}
+
+	err := h.Usecases.Resets.ResetPassword(r.Context(), token, password)
+	if err != nil {
+		if !errors.Is(err, entity.ErrResetTokenInvalid) {
+			h.l.WriteLog(fmt.Errorf("v1 - ResetPasswordHandler - ResetPassword: %w", err))
+			h.Errors(w, http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		content.ErrorMsg.Message = ResetLinkInvalid
+		err := h.ParseAndExecute(w, content, "templates/reset_password.html")
+		if err != nil {
+			h.l.WriteLog(fmt.Errorf("v1 - ResetPasswordHandler - ParseAndExecute #2 - %w", err))
+		}
+		return
+	}
+
+	http.Redirect(w, r, "/signin_page", http.StatusFound)
+}
@@ -0,0 +1,148 @@
+package v1_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	mu "forum/internal/usecase/mock"
+)
+
+func TestForgotPasswordHandler(t *testing.T) {
+	handler := setup()
+	resets := handler.Usecases.Resets.(*mu.PasswordResetsMockUseCase)
+
+	t.Run("OK", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/forgot_password", nil)
+
+		form := url.Values{}
+		form.Add("email", "Riddle@mail.ru")
+		req.PostForm = form
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("want: %v, got: %v", http.StatusOK, rec.Code)
+		}
+		if want := []string{"riddle@mail.ru"}; !reflect.DeepEqual(resets.Emails, want) {
+			t.Fatalf("want: %v, got: %v", want, resets.Emails)
+		}
+	})
+
+	t.Run("err wrong email", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/forgot_password", nil)
+
+		form := url.Values{}
+		form.Add("email", "Riddle")
+		req.PostForm = form
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("want: %v, got: %v", http.StatusBadRequest, rec.Code)
+		}
+	})
+
+	t.Run("err wrong method", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/forgot_password", nil)
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Fatalf("want: %v, got: %v", http.StatusMethodNotAllowed, rec.Code)
+		}
+	})
+}
+
+func TestResetPasswordPageHandler(t *testing.T) {
+	handler := setup()
+
+	t.Run("OK", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/reset_password_page?token="+mu.ValidToken, nil)
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("want: %v, got: %v", http.StatusOK, rec.Code)
+		}
+	})
+
+	t.Run("err invalid token", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/reset_password_page?token=expired", nil)
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("want: %v, got: %v", http.StatusBadRequest, rec.Code)
+		}
+	})
+}
+
+func TestResetPasswordHandler(t *testing.T) {
+	handler := setup()
+	resets := handler.Usecases.Resets.(*mu.PasswordResetsMockUseCase)
+
+	t.Run("err passwords not same", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/reset_password", nil)
+
+		form := url.Values{}
+		form.Add("token", mu.ValidToken)
+		form.Add("password", "Vivse")
+		form.Add("confirm_password", "Vivs")
+		req.PostForm = form
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("want: %v, got: %v", http.StatusBadRequest, rec.Code)
+		}
+	})
+
+	t.Run("err invalid token", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/reset_password", nil)
+
+		form := url.Values{}
+		form.Add("token", "expired")
+		form.Add("password", "Vivse")
+		form.Add("confirm_password", "Vivse")
+		req.PostForm = form
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("want: %v, got: %v", http.StatusBadRequest, rec.Code)
+		}
+		if resets.Reset {
+			t.Fatal("want password kept")
+		}
+	})
+
+	t.Run("OK", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/reset_password", nil)
+
+		form := url.Values{}
+		form.Add("token", mu.ValidToken)
+		form.Add("password", "Vivse")
+		form.Add("confirm_password", "Vivse")
+		req.PostForm = form
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusFound {
+			t.Fatalf("want: %v, got: %v", http.StatusFound, rec.Code)
+		}
+		if !resets.Reset {
+			t.Fatal("want password reset")
+		}
+	})
+}
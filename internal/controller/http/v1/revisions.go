@@ -0,0 +1,389 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"forum/internal/entity"
+)
+
+func (h *Handler) EditPostPageHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.Errors(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := strings.Split(r.URL.Path, "/")
+	id, err := strconv.Atoi(path[len(path)-1])
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - EditPostPageHandler - Atoi: %w", err))
+	}
+	if r.URL.Path != "/edit_post_page/"+path[len(path)-1] || err != nil || id <= 0 {
+		h.Errors(w, http.StatusNotFound)
+		return
+	}
+
+	content, ok := r.Context().Value(Key("content")).(Content)
+	if !ok {
+		h.l.WriteLog(fmt.Errorf("v1 - EditPostPageHandler - TypeAssertion:"+
+			"got data of type %T but wanted v1.Content", content))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	post, err := h.Usecases.Posts.GetById(r.Context(), int64(id))
+	if err != nil || post.IsDeleted() {
+		h.l.WriteLog(fmt.Errorf("v1 - EditPostPageHandler - GetById: %w", err))
+		h.Errors(w, http.StatusNotFound)
+		return
+	}
+
+	if post.User.Id != content.User.Id && !content.Can(entity.PermEditAnyPost) {
+		h.Errors(w, http.StatusForbidden)
+		return
+	}
+
+	post.Content = strings.ReplaceAll(post.Content, "\\n", "\n")
+	content.Post = post
+
+	err = h.ParseAndExecute(w, content, "templates/edit_post.html")
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - EditPostPageHandler - ParseAndExecute - %w", err))
+	}
+}
+
+func (h *Handler) EditPostHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.Errors(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := strings.Split(r.URL.Path, "/")
+	id, err := strconv.Atoi(path[len(path)-1])
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - EditPostHandler - Atoi: %w", err))
+	}
+	if r.URL.Path != "/edit_post/"+path[len(path)-1] || err != nil || id <= 0 {
+		h.Errors(w, http.StatusNotFound)
+		return
+	}
+
+	postTitle := r.FormValue("title")
+	postContent := r.FormValue("content")
+	if len(postTitle) == 0 || len(postContent) == 0 {
+		h.Errors(w, http.StatusBadRequest)
+		return
+	}
+
+	content, ok := r.Context().Value(Key("content")).(Content)
+	if !ok {
+		h.l.WriteLog(fmt.Errorf("v1 - EditPostHandler - TypeAssertion:"+
+			"got data of type %T but wanted v1.Content", content))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	post, err := h.Usecases.Posts.GetById(r.Context(), int64(id))
+	if err != nil || post.IsDeleted() {
+		h.l.WriteLog(fmt.Errorf("v1 - EditPostHandler - GetById: %w", err))
+		h.Errors(w, http.StatusNotFound)
+		return
+	}
+
+	if post.User.Id != content.User.Id && !content.Can(entity.PermEditAnyPost) {
+		h.Errors(w, http.StatusForbidden)
+		return
+	}
+
+	kept, dropped := splitImages(post.Images, r.Form["remove_image"])
+	edited := entity.Post{
+		Id:      post.Id,
+		User:    content.User,
+		Title:   postTitle,
+		Content: strings.ReplaceAll(postContent, "\r\n", "\\n"),
+		Images:  kept,
+	}
+	err = h.Usecases.Posts.UpdatePost(r.Context(), edited)
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - EditPostHandler - UpdatePost: %w", err))
+		if errors.Is(err, entity.ErrPostNotFound) {
+			h.Errors(w, http.StatusNotFound)
+			return
+		}
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+	h.removeImages(dropped)
+
+	http.Redirect(w, r, "/posts/"+path[len(path)-1], http.StatusFound)
+}
+
+func (h *Handler) EditCommentPageHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.Errors(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := strings.Split(r.URL.Path, "/")
+	id, err := strconv.Atoi(path[len(path)-1])
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - EditCommentPageHandler - Atoi: %w", err))
+	}
+	if r.URL.Path != "/edit_comment_page/"+path[len(path)-1] || err != nil || id <= 0 {
+		h.Errors(w, http.StatusNotFound)
+		return
+	}
+
+	content, ok := r.Context().Value(Key("content")).(Content)
+	if !ok {
+		h.l.WriteLog(fmt.Errorf("v1 - EditCommentPageHandler - TypeAssertion:"+
+			"got data of type %T but wanted v1.Content", content))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	comment, err := h.Usecases.Comments.GetById(r.Context(), int64(id))
+	if err != nil || comment.IsDeleted() {
+		h.l.WriteLog(fmt.Errorf("v1 - EditCommentPageHandler - GetById: %w", err))
+		h.Errors(w, http.StatusNotFound)
+		return
+	}
+
+	if comment.User.Id != content.User.Id && !content.Can(entity.PermEditAnyPost) {
+		h.Errors(w, http.StatusForbidden)
+		return
+	}
+
+	comment.Content = strings.ReplaceAll(comment.Content, "\\n", "\n")
+	content.Comment = comment
+
+	err = h.ParseAndExecute(w, content, "templates/edit_comment.html")
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - EditCommentPageHandler - ParseAndExecute - %w", err))
+	}
+}
+
+func (h *Handler) EditCommentHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.Errors(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := strings.Split(r.URL.Path, "/")
+	id, err := strconv.Atoi(path[len(path)-1])
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - EditCommentHandler - Atoi: %w", err))
+	}
+	if r.URL.Path != "/edit_comment/"+path[len(path)-1] || err != nil || id <= 0 {
+		h.Errors(w, http.StatusNotFound)
+		return
+	}
+
+	commentContent := r.FormValue("content")
+	if len(commentContent) == 0 {
+		h.Errors(w, http.StatusBadRequest)
+		return
+	}
+
+	content, ok := r.Context().Value(Key("content")).(Content)
+	if !ok {
+		h.l.WriteLog(fmt.Errorf("v1 - EditCommentHandler - TypeAssertion:"+
+			"got data of type %T but wanted v1.Content", content))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	comment, err := h.Usecases.Comments.GetById(r.Context(), int64(id))
+	if err != nil || comment.IsDeleted() {
+		h.l.WriteLog(fmt.Errorf("v1 - EditCommentHandler - GetById: %w", err))
+		h.Errors(w, http.StatusNotFound)
+		return
+	}
+
+	if comment.User.Id != content.User.Id && !content.Can(entity.PermEditAnyPost) {
+		h.Errors(w, http.StatusForbidden)
+		return
+	}
+
+	kept, dropped := splitImages(comment.Images, r.Form["remove_image"])
+	edited := entity.Comment{
+		Id:      comment.Id,
+		User:    content.User,
+		Content: strings.ReplaceAll(commentContent, "\r\n", "\\n"),
+		Images:  kept,
+	}
+	err = h.Usecases.Comments.UpdateComment(r.Context(), edited)
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - EditCommentHandler - UpdateComment: %w", err))
+		if errors.Is(err, entity.ErrCommentNotFound) {
+			h.Errors(w, http.StatusNotFound)
+			return
+		}
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+	h.removeImages(dropped)
+
+	http.Redirect(w, r, "/posts/"+strconv.Itoa(int(comment.PostId))+"#"+path[len(path)-1], http.StatusFound)
+}
+
+// PostHistoryHandler lists revisions of a post and shows the diff between
+// revisions ?from= and ?to=, the last two by default.
+func (h *Handler) PostHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.Errors(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := strings.Split(r.URL.Path, "/")
+	id, err := strconv.Atoi(path[len(path)-1])
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - PostHistoryHandler - Atoi: %w", err))
+	}
+	if r.URL.Path != "/post_history/"+path[len(path)-1] || err != nil || id <= 0 {
+		h.Errors(w, http.StatusNotFound)
+		return
+	}
+
+	content, ok := r.Context().Value(Key("content")).(Content)
+	if !ok {
+		h.l.WriteLog(fmt.Errorf("v1 - PostHistoryHandler - TypeAssertion:"+
+			"got data of type %T but wanted v1.Content", content))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	post, err := h.Usecases.Posts.GetById(r.Context(), int64(id))
+	if err != nil || post.IsDeleted() && !content.Can(entity.PermDeleteAnyPost) {
+		h.l.WriteLog(fmt.Errorf("v1 - PostHistoryHandler - GetById: %w", err))
+		h.Errors(w, http.StatusNotFound)
+		return
+	}
+	content.Post = post
+
+	content.Revisions, err = h.Usecases.Posts.GetRevisions(r.Context(), post.Id)
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - PostHistoryHandler - GetRevisions: %w", err))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	from, to, err := diffParams(r, len(content.Revisions))
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - PostHistoryHandler - %w", err))
+		h.Errors(w, http.StatusBadRequest)
+		return
+	}
+	if from != 0 {
+		content.Diff, err = h.Usecases.Posts.DiffRevisions(r.Context(), post.Id, from, to)
+		if err != nil {
+			h.l.WriteLog(fmt.Errorf("v1 - PostHistoryHandler - DiffRevisions: %w", err))
+			if errors.Is(err, entity.ErrRevisionNotFound) {
+				h.Errors(w, http.StatusNotFound)
+				return
+			}
+			h.Errors(w, http.StatusInternalServerError)
+			return
+		}
+	}
+	content.Uri = r.URL.Path
+
+	err = h.ParseAndExecute(w, content, "templates/history.html")
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - PostHistoryHandler - ParseAndExecute - %w", err))
+	}
+}
+
+// CommentHistoryHandler lists revisions of a comment and shows the diff
+// between revisions ?from= and ?to=, the last two by default.
+func (h *Handler) CommentHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.Errors(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := strings.Split(r.URL.Path, "/")
+	id, err := strconv.Atoi(path[len(path)-1])
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - CommentHistoryHandler - Atoi: %w", err))
+	}
+	if r.URL.Path != "/comment_history/"+path[len(path)-1] || err != nil || id <= 0 {
+		h.Errors(w, http.StatusNotFound)
+		return
+	}
+
+	content, ok := r.Context().Value(Key("content")).(Content)
+	if !ok {
+		h.l.WriteLog(fmt.Errorf("v1 - CommentHistoryHandler - TypeAssertion:"+
+			"got data of type %T but wanted v1.Content", content))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	comment, err := h.Usecases.Comments.GetById(r.Context(), int64(id))
+	if err != nil || comment.IsDeleted() && !content.Can(entity.PermDeleteAnyPost) {
+		h.l.WriteLog(fmt.Errorf("v1 - CommentHistoryHandler - GetById: %w", err))
+		h.Errors(w, http.StatusNotFound)
+		return
+	}
+	content.Comment = comment
+	content.Post.Id = comment.PostId
+
+	content.Revisions, err = h.Usecases.Comments.GetRevisions(r.Context(), comment.Id)
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - CommentHistoryHandler - GetRevisions: %w", err))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	from, to, err := diffParams(r, len(content.Revisions))
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - CommentHistoryHandler - %w", err))
+		h.Errors(w, http.StatusBadRequest)
+		return
+	}
+	if from != 0 {
+		content.Diff, err = h.Usecases.Comments.DiffRevisions(r.Context(), comment.Id, from, to)
+		if err != nil {
+			h.l.WriteLog(fmt.Errorf("v1 - CommentHistoryHandler - DiffRevisions: %w", err))
+			if errors.Is(err, entity.ErrRevisionNotFound) {
+				h.Errors(w, http.StatusNotFound)
+				return
+			}
+			h.Errors(w, http.StatusInternalServerError)
+			return
+		}
+	}
+	content.Uri = r.URL.Path
+
+	err = h.ParseAndExecute(w, content, "templates/history.html")
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - CommentHistoryHandler - ParseAndExecute - %w", err))
+	}
+}
+
+// diffParams reads the revision numbers to compare from "from" and "to".
+// Without them the last two of total revisions are compared, from is 0
+// when there is nothing to compare.
+func diffParams(r *http.Request, total int) (from, to int, err error) {
+	query := r.URL.Query()
+	if query.Get("from") == "" && query.Get("to") == "" {
+		if total < 2 {
+			return 0, 0, nil
+		}
+		return total - 1, total, nil
+	}
+
+	from, err = strconv.Atoi(query.Get("from"))
+	if err != nil || from < 1 {
+		return 0, 0, fmt.Errorf("diffParams - from %q", query.Get("from"))
+	}
+	to, err = strconv.Atoi(query.Get("to"))
+	if err != nil || to < 1 {
+		return 0, 0, fmt.Errorf("diffParams - to %q", query.Get("to"))
+	}
+	return from, to, nil
+}
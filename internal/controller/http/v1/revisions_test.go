@@ -0,0 +1,177 @@
+package v1_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"forum/internal/entity"
+)
+
+func TestEditPostHandler(t *testing.T) {
+	ctx := context.Background()
+	handler := setup()
+
+	t.Run("OK", func(t *testing.T) {
+		if err := handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/edit_post/1", nil)
+		cookie := &http.Cookie{
+			Name: "session_token",
+		}
+		req.AddCookie(cookie)
+
+		form := url.Values{}
+		form.Add("title", "title")
+		form.Add("content", "content")
+		req.PostForm = form
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusFound {
+			t.Fatalf("want: %v, got: %v", http.StatusFound, rec.Code)
+		}
+
+		revisions, err := handler.Usecases.Posts.GetRevisions(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(revisions) != 1 || revisions[0].Title != "title" {
+			t.Fatalf("want one revision with the new title, got: %v", revisions)
+		}
+	})
+
+	t.Run("err empty form", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/edit_post/1", nil)
+		cookie := &http.Cookie{
+			Name: "session_token",
+		}
+		req.AddCookie(cookie)
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("want: %v, got: %v", http.StatusBadRequest, rec.Code)
+		}
+	})
+
+	t.Run("err wrong method", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/edit_post/1", nil)
+		cookie := &http.Cookie{
+			Name: "session_token",
+		}
+		req.AddCookie(cookie)
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Fatalf("want: %v, got: %v", http.StatusMethodNotAllowed, rec.Code)
+		}
+	})
+
+	t.Run("err not authorized", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/edit_post/1", nil)
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusUnauthorized {
+			t.Fatalf("want: %v, got: %v", http.StatusUnauthorized, rec.Code)
+		}
+	})
+
+	t.Run("err not the author", func(t *testing.T) {
+		if err := handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/edit_post/1", nil)
+		cookie := &http.Cookie{
+			Name: "session_token",
+		}
+		req.AddCookie(cookie)
+
+		form := url.Values{}
+		form.Add("title", "title")
+		form.Add("content", "content")
+		req.PostForm = form
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusForbidden {
+			t.Fatalf("want: %v, got: %v", http.StatusForbidden, rec.Code)
+		}
+	})
+}
+
+func TestPostHistoryHandler(t *testing.T) {
+	ctx := context.Background()
+	handler := setup()
+
+	for _, title := range []string{"first", "second"} {
+		if err := handler.Usecases.Posts.UpdatePost(ctx, entity.Post{Id: 1, Title: title}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/post_history/1", nil)
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("want: %v, got: %v", http.StatusOK, rec.Code)
+		}
+	})
+
+	t.Run("OK chosen revisions", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/post_history/1?from=2&to=1", nil)
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("want: %v, got: %v", http.StatusOK, rec.Code)
+		}
+	})
+
+	t.Run("err bad revision number", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/post_history/1?from=abc&to=1", nil)
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("want: %v, got: %v", http.StatusBadRequest, rec.Code)
+		}
+	})
+
+	t.Run("err revision not found", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/post_history/1?from=1&to=3", nil)
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusNotFound {
+			t.Fatalf("want: %v, got: %v", http.StatusNotFound, rec.Code)
+		}
+	})
+
+	t.Run("err wrong path", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/post_history/abc", nil)
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusNotFound {
+			t.Fatalf("want: %v, got: %v", http.StatusNotFound, rec.Code)
+		}
+	})
+}
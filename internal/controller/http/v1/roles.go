@@ -0,0 +1,327 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"forum/internal/entity"
+)
+
+func (h *Handler) RolesPageHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.Errors(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	content, ok := r.Context().Value(Key("content")).(Content)
+	if !ok {
+		h.l.WriteLog(fmt.Errorf("v1 - RolesPageHandler - TypeAssertion:"+
+			"got data of type %T but wanted v1.Content", content))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	if !content.Can(entity.PermManageRoles) {
+		h.Errors(w, http.StatusForbidden)
+		return
+	}
+
+	roles, err := h.Usecases.Roles.GetRoles(r.Context())
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - RolesPageHandler - GetRoles: %w", err))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+	content.Roles = roles
+
+	err = h.ParseAndExecute(w, content, "templates/roles.html")
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - RolesPageHandler - ParseAndExecute - %w", err))
+	}
+}
+
+// CreateRoleHandler adds a role with the name and the checked permissions
+// of the form.
+func (h *Handler) CreateRoleHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.Errors(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		h.Errors(w, http.StatusBadRequest)
+		return
+	}
+
+	content, ok := r.Context().Value(Key("content")).(Content)
+	if !ok {
+		h.l.WriteLog(fmt.Errorf("v1 - CreateRoleHandler - TypeAssertion:"+
+			"got data of type %T but wanted v1.Content", content))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	if !content.Can(entity.PermManageRoles) {
+		h.Errors(w, http.StatusForbidden)
+		return
+	}
+
+	err := h.Usecases.Roles.CreateRole(r.Context(), roleForm(r))
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - CreateRoleHandler - CreateRole: %w", err))
+		h.roleError(w, err)
+		return
+	}
+
+	http.Redirect(w, r, "/roles", http.StatusFound)
+}
+
+// UpdateRoleHandler renames the role and gives it the checked permissions,
+// users of the role get them with their next request.
+func (h *Handler) UpdateRoleHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.Errors(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := strings.Split(r.URL.Path, "/")
+	id, err := strconv.ParseInt(path[len(path)-1], 10, 64)
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - UpdateRoleHandler - ParseInt: %w", err))
+	}
+	if r.URL.Path != "/update_role/"+path[len(path)-1] || err != nil || id <= 0 {
+		h.Errors(w, http.StatusNotFound)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		h.Errors(w, http.StatusBadRequest)
+		return
+	}
+
+	content, ok := r.Context().Value(Key("content")).(Content)
+	if !ok {
+		h.l.WriteLog(fmt.Errorf("v1 - UpdateRoleHandler - TypeAssertion:"+
+			"got data of type %T but wanted v1.Content", content))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	if !content.Can(entity.PermManageRoles) {
+		h.Errors(w, http.StatusForbidden)
+		return
+	}
+
+	role := roleForm(r)
+	role.Id = id
+	err = h.Usecases.Roles.UpdateRole(r.Context(), role)
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - UpdateRoleHandler - UpdateRole: %w", err))
+		h.roleError(w, err)
+		return
+	}
+
+	http.Redirect(w, r, "/roles", http.StatusFound)
+}
+
+// DeleteRoleHandler deletes the role, its users become plain users.
+func (h *Handler) DeleteRoleHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.Errors(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := strings.Split(r.URL.Path, "/")
+	id, err := strconv.ParseInt(path[len(path)-1], 10, 64)
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - DeleteRoleHandler - ParseInt: %w", err))
+	}
+	if r.URL.Path != "/delete_role/"+path[len(path)-1] || err != nil || id <= 0 {
+		h.Errors(w, http.StatusNotFound)
+		return
+	}
+
+	content, ok := r.Context().Value(Key("content")).(Content)
+	if !ok {
+		h.l.WriteLog(fmt.Errorf("v1 - DeleteRoleHandler - TypeAssertion:"+
+			"got data of type %T but wanted v1.Content", content))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	if !content.Can(entity.PermManageRoles) {
+		h.Errors(w, http.StatusForbidden)
+		return
+	}
+
+	err = h.Usecases.Roles.DeleteRole(r.Context(), id)
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - DeleteRoleHandler - DeleteRole: %w", err))
+		h.roleError(w, err)
+		return
+	}
+
+	http.Redirect(w, r, "/roles", http.StatusFound)
+}
+
+// AssignRoleHandler gives the user of the path the role chosen in the form.
+func (h *Handler) AssignRoleHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.Errors(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := strings.Split(r.URL.Path, "/")
+	id, err := strconv.ParseInt(path[len(path)-1], 10, 64)
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - AssignRoleHandler - ParseInt: %w", err))
+	}
+	if r.URL.Path != "/assign_role/"+path[len(path)-1] || err != nil || id <= 0 {
+		h.Errors(w, http.StatusNotFound)
+		return
+	}
+
+	content, ok := r.Context().Value(Key("content")).(Content)
+	if !ok {
+		h.l.WriteLog(fmt.Errorf("v1 - AssignRoleHandler - TypeAssertion:"+
+			"got data of type %T but wanted v1.Content", content))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	if !content.Can(entity.PermManageRoles) {
+		h.Errors(w, http.StatusForbidden)
+		return
+	}
+
+	roleId, err := strconv.ParseInt(r.FormValue("role_id"), 10, 64)
+	if err != nil || roleId <= 0 {
+		h.Errors(w, http.StatusBadRequest)
+		return
+	}
+
+	err = h.Usecases.Roles.AssignRole(r.Context(), id, roleId)
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - AssignRoleHandler - AssignRole: %w", err))
+		h.roleError(w, err)
+		return
+	}
+
+	http.Redirect(w, r, "/users/"+strconv.FormatInt(id, 10), http.StatusFound)
+}
+
+// BanUserHandler bans the user of the path, they are signed out at once
+// and can't sign in until they are unbanned.
+func (h *Handler) BanUserHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.Errors(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := strings.Split(r.URL.Path, "/")
+	id, err := strconv.ParseInt(path[len(path)-1], 10, 64)
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - BanUserHandler - ParseInt: %w", err))
+	}
+	if r.URL.Path != "/ban_user/"+path[len(path)-1] || err != nil || id <= 0 {
+		h.Errors(w, http.StatusNotFound)
+		return
+	}
+
+	content, ok := r.Context().Value(Key("content")).(Content)
+	if !ok {
+		h.l.WriteLog(fmt.Errorf("v1 - BanUserHandler - TypeAssertion:"+
+			"got data of type %T but wanted v1.Content", content))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	if !content.Can(entity.PermBanUsers) {
+		h.Errors(w, http.StatusForbidden)
+		return
+	}
+
+	err = h.Usecases.Users.BanUser(r.Context(), id)
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - BanUserHandler - BanUser: %w", err))
+		h.roleError(w, err)
+		return
+	}
+
+	http.Redirect(w, r, "/users/"+strconv.FormatInt(id, 10), http.StatusFound)
+}
+
+func (h *Handler) UnbanUserHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.Errors(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := strings.Split(r.URL.Path, "/")
+	id, err := strconv.ParseInt(path[len(path)-1], 10, 64)
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - UnbanUserHandler - ParseInt: %w", err))
+	}
+	if r.URL.Path != "/unban_user/"+path[len(path)-1] || err != nil || id <= 0 {
+		h.Errors(w, http.StatusNotFound)
+		return
+	}
+
+	content, ok := r.Context().Value(Key("content")).(Content)
+	if !ok {
+		h.l.WriteLog(fmt.Errorf("v1 - UnbanUserHandler - TypeAssertion:"+
+			"got data of type %T but wanted v1.Content", content))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	if !content.Can(entity.PermBanUsers) {
+		h.Errors(w, http.StatusForbidden)
+		return
+	}
+
+	err = h.Usecases.Users.UnbanUser(r.Context(), id)
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - UnbanUserHandler - UnbanUser: %w", err))
+		h.roleError(w, err)
+		return
+	}
+
+	http.Redirect(w, r, "/users/"+strconv.FormatInt(id, 10), http.StatusFound)
+}
+
+// roleForm is the role described by the form: its name and the checked
+// permissions.
+func roleForm(r *http.Request) entity.Role {
+	role := entity.Role{Name: r.FormValue("name")}
+	for _, permission := range r.Form["permission"] {
+		role.Permissions = append(role.Permissions, entity.Permission(permission))
+	}
+	return role
+}
+
+// roleError answers with the error page for an error of the roles and bans
+// usecases.
+func (h *Handler) roleError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, entity.ErrRoleNotFound), errors.Is(err, entity.ErrUserNotFound):
+		h.Errors(w, http.StatusNotFound)
+	case errors.Is(err, entity.ErrUnknownPermission):
+		h.Errors(w, http.StatusBadRequest)
+	case errors.Is(err, entity.ErrRoleExists):
+		h.errorPage(w, ErrMessage{Code: http.StatusBadRequest, Message: RoleExists})
+	case errors.Is(err, entity.ErrRoleNameEmpty):
+		h.errorPage(w, ErrMessage{Code: http.StatusBadRequest, Message: RoleNameEmpty})
+	case errors.Is(err, entity.ErrRoleBuiltin):
+		h.errorPage(w, ErrMessage{Code: http.StatusBadRequest, Message: RoleBuiltin})
+	case errors.Is(err, entity.ErrLastAdmin):
+		h.errorPage(w, ErrMessage{Code: http.StatusBadRequest, Message: LastAdmin})
+	case errors.Is(err, entity.ErrBanNotAllowed):
+		h.errorPage(w, ErrMessage{Code: http.StatusForbidden, Message: BanNotAllowed})
+	default:
+		h.Errors(w, http.StatusInternalServerError)
+	}
+}
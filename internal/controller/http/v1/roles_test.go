@@ -0,0 +1,272 @@
+package v1_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"forum/internal/entity"
+	mu "forum/internal/usecase/mock"
+)
+
+func TestRolesPageHandler(t *testing.T) {
+	ctx := context.Background()
+	handler := setup()
+
+	t.Run("err not authorized", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/roles", nil)
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusUnauthorized {
+			t.Fatalf("want: %v, got: %v", http.StatusUnauthorized, rec.Code)
+		}
+	})
+
+	t.Run("OK", func(t *testing.T) {
+		if err := handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/roles", nil)
+		req.AddCookie(&http.Cookie{Name: "session_token"})
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("want: %v, got: %v", http.StatusOK, rec.Code)
+		}
+	})
+
+	t.Run("err wrong method", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/roles", nil)
+		req.AddCookie(&http.Cookie{Name: "session_token"})
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Fatalf("want: %v, got: %v", http.StatusMethodNotAllowed, rec.Code)
+		}
+	})
+
+	t.Run("err low access level", func(t *testing.T) {
+		if err := handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/roles", nil)
+		req.AddCookie(&http.Cookie{Name: "session_token"})
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusForbidden {
+			t.Fatalf("want: %v, got: %v", http.StatusForbidden, rec.Code)
+		}
+	})
+}
+
+func TestCreateRoleHandler(t *testing.T) {
+	handler := setup()
+	if err := handler.Usecases.Users.SignUp(context.Background(), entity.User{}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		form   url.Values
+		status int
+	}{
+		{"OK", url.Values{"name": {"Куратор"}, "permission": {"manage_categories"}}, http.StatusFound},
+		{"err exists", url.Values{"name": {"Куратор"}}, http.StatusBadRequest},
+		{"err empty name", url.Values{"name": {" "}}, http.StatusBadRequest},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/create_role", nil)
+			req.AddCookie(&http.Cookie{Name: "session_token"})
+			req.PostForm = tc.form
+
+			handler.Mux.ServeHTTP(rec, req)
+
+			if rec.Code != tc.status {
+				t.Fatalf("want: %v, got: %v", tc.status, rec.Code)
+			}
+		})
+	}
+
+	roles := handler.Usecases.Roles.(*mu.RolesMockUseCase).Roles
+	curator := roles[len(roles)-1]
+	if curator.Name != "Куратор" || !curator.Can(entity.PermManageCategories) {
+		t.Fatalf("want the curator role, got: %v", curator)
+	}
+}
+
+func TestUpdateRoleHandler(t *testing.T) {
+	handler := setup()
+	if err := handler.Usecases.Users.SignUp(context.Background(), entity.User{}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		path   string
+		status int
+	}{
+		{"err builtin admin", "/update_role/3", http.StatusBadRequest},
+		{"err not found", "/update_role/9", http.StatusNotFound},
+		{"err wrong path", "/update_role/2/1", http.StatusNotFound},
+		{"OK", "/update_role/2", http.StatusFound},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, tc.path, nil)
+			req.AddCookie(&http.Cookie{Name: "session_token"})
+			req.PostForm = url.Values{"name": {"Смотритель"}, "permission": {"ban_users"}}
+
+			handler.Mux.ServeHTTP(rec, req)
+
+			if rec.Code != tc.status {
+				t.Fatalf("want: %v, got: %v", tc.status, rec.Code)
+			}
+		})
+	}
+
+	role, err := handler.Usecases.Roles.GetRole(context.Background(), entity.RoleModeratorId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if role.Name != "Смотритель" || role.Can(entity.PermDeleteAnyPost) {
+		t.Fatalf("want the moderator role updated, got: %v", role)
+	}
+}
+
+func TestDeleteRoleHandler(t *testing.T) {
+	ctx := context.Background()
+	handler := setup()
+	if err := handler.Usecases.Roles.CreateRole(ctx, entity.Role{Name: "Куратор"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		path   string
+		status int
+	}{
+		{"err builtin", "/delete_role/2", http.StatusBadRequest},
+		{"OK", "/delete_role/4", http.StatusFound},
+		{"err not found", "/delete_role/4", http.StatusNotFound},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, tc.path, nil)
+			req.AddCookie(&http.Cookie{Name: "session_token"})
+
+			handler.Mux.ServeHTTP(rec, req)
+
+			if rec.Code != tc.status {
+				t.Fatalf("want: %v, got: %v", tc.status, rec.Code)
+			}
+		})
+	}
+}
+
+func TestAssignRoleHandler(t *testing.T) {
+	handler := setup()
+	if err := handler.Usecases.Users.SignUp(context.Background(), entity.User{}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		path   string
+		roleId string
+		status int
+	}{
+		{"err no role", "/assign_role/5", "", http.StatusBadRequest},
+		{"err role not found", "/assign_role/5", "9", http.StatusNotFound},
+		{"err last admin", "/assign_role/1", "1", http.StatusBadRequest},
+		{"OK", "/assign_role/5", "2", http.StatusFound},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, tc.path, nil)
+			req.AddCookie(&http.Cookie{Name: "session_token"})
+			req.PostForm = url.Values{"role_id": {tc.roleId}}
+
+			handler.Mux.ServeHTTP(rec, req)
+
+			if rec.Code != tc.status {
+				t.Fatalf("want: %v, got: %v", tc.status, rec.Code)
+			}
+		})
+	}
+
+	if roleId := handler.Usecases.Roles.(*mu.RolesMockUseCase).Assigned[5]; roleId != entity.RoleModeratorId {
+		t.Fatalf("want: %v, got: %v", entity.RoleModeratorId, roleId)
+	}
+}
+
+func TestBanUserHandler(t *testing.T) {
+	ctx := context.Background()
+	handler := setup()
+	users := handler.Usecases.Users.(*mu.UsersMockUseCase)
+	if err := users.SignUp(ctx, entity.User{}); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/ban_user/5", nil)
+		req.AddCookie(&http.Cookie{Name: "session_token"})
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusFound {
+			t.Fatalf("want: %v, got: %v", http.StatusFound, rec.Code)
+		}
+		if len(users.Banned) != 1 || users.Banned[0] != 5 {
+			t.Fatalf("want user 5 banned, got: %v", users.Banned)
+		}
+	})
+
+	t.Run("err not allowed", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/ban_user/1", nil)
+		req.AddCookie(&http.Cookie{Name: "session_token"})
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusForbidden {
+			t.Fatalf("want: %v, got: %v", http.StatusForbidden, rec.Code)
+		}
+	})
+
+	t.Run("OK unban", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/unban_user/5", nil)
+		req.AddCookie(&http.Cookie{Name: "session_token"})
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusFound {
+			t.Fatalf("want: %v, got: %v", http.StatusFound, rec.Code)
+		}
+		if len(users.Banned) != 0 {
+			t.Fatalf("want no banned users, got: %v", users.Banned)
+		}
+	})
+
+	t.Run("err low access level", func(t *testing.T) {
+		if err := users.SignUp(ctx, entity.User{}); err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/ban_user/5", nil)
+		req.AddCookie(&http.Cookie{Name: "session_token"})
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusForbidden {
+			t.Fatalf("want: %v, got: %v", http.StatusForbidden, rec.Code)
+		}
+	})
+}
@@ -0,0 +1,110 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"forum/internal/entity"
+)
+
+// SessionsPageHandler lists the devices the user is signed in on.
+func (h *Handler) SessionsPageHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.Errors(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	content, ok := r.Context().Value(Key("content")).(Content)
+	if !ok {
+		h.l.WriteLog(fmt.Errorf("v1 - SessionsPageHandler - TypeAssertion:"+
+			"got data of type %T but wanted v1.Content", content))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	sessions, err := h.Usecases.Users.GetSessions(r.Context(), content.Session)
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - SessionsPageHandler - GetSessions: %w", err))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+	content.Sessions = sessions
+
+	err = h.ParseAndExecute(w, content, "templates/sessions.html")
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - SessionsPageHandler - ParseAndExecute - %w", err))
+	}
+}
+
+// RevokeSessionHandler signs one of the devices of the user out, revoking
+// the current session is signing out.
+func (h *Handler) RevokeSessionHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.Errors(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := strings.Split(r.URL.Path, "/")
+	id, err := strconv.ParseInt(path[len(path)-1], 10, 64)
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - RevokeSessionHandler - ParseInt: %w", err))
+	}
+	if r.URL.Path != "/revoke_session/"+path[len(path)-1] || err != nil || id <= 0 {
+		h.Errors(w, http.StatusNotFound)
+		return
+	}
+
+	content, ok := r.Context().Value(Key("content")).(Content)
+	if !ok {
+		h.l.WriteLog(fmt.Errorf("v1 - RevokeSessionHandler - TypeAssertion:"+
+			"got data of type %T but wanted v1.Content", content))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	err = h.Usecases.Users.RevokeSession(r.Context(), content.User.Id, id)
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - RevokeSessionHandler - RevokeSession: %w", err))
+		if errors.Is(err, entity.ErrSessionNotFound) {
+			h.Errors(w, http.StatusNotFound)
+		} else {
+			h.Errors(w, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if id == content.Session.Id {
+		http.Redirect(w, r, "/", http.StatusFound)
+		return
+	}
+	http.Redirect(w, r, "/sessions", http.StatusFound)
+}
+
+// SignOutEverywhereHandler signs the user out on every device, this one
+// included.
+func (h *Handler) SignOutEverywhereHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.Errors(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	content, ok := r.Context().Value(Key("content")).(Content)
+	if !ok {
+		h.l.WriteLog(fmt.Errorf("v1 - SignOutEverywhereHandler - TypeAssertion:"+
+			"got data of type %T but wanted v1.Content", content))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	err := h.Usecases.Users.RevokeAllSessions(r.Context(), content.User.Id)
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - SignOutEverywhereHandler - RevokeAllSessions: %w", err))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/", http.StatusFound)
+}
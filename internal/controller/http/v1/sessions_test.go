@@ -0,0 +1,182 @@
+package v1_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	v1 "forum/internal/controller/http/v1"
+	"forum/internal/entity"
+	mu "forum/internal/usecase/mock"
+)
+
+// storeSessions signs the mock user 1 in on a laptop and a phone and user 5
+// on a tablet.
+func storeSessions(handler *v1.Handler) *mu.UsersMockUseCase {
+	users := handler.Usecases.Users.(*mu.UsersMockUseCase)
+	users.Sessions = []entity.Session{
+		{Id: 1, User: entity.User{Id: 1}, UserAgent: "Firefox"},
+		{Id: 2, User: entity.User{Id: 1}, UserAgent: "Safari"},
+		{Id: 3, User: entity.User{Id: 5}, UserAgent: "Chrome"},
+	}
+	return users
+}
+
+func TestSessionsPageHandler(t *testing.T) {
+	ctx := context.Background()
+	handler := setup()
+
+	t.Run("err not authorized", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/sessions", nil)
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusUnauthorized {
+			t.Fatalf("want: %v, got: %v", http.StatusUnauthorized, rec.Code)
+		}
+	})
+
+	t.Run("OK", func(t *testing.T) {
+		if err := handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
+			t.Fatal(err)
+		}
+		storeSessions(handler)
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/sessions", nil)
+		cookie := &http.Cookie{
+			Name: "session_token",
+		}
+		req.AddCookie(cookie)
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("want: %v, got: %v", http.StatusOK, rec.Code)
+		}
+	})
+
+	t.Run("err method not allowed", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/sessions", nil)
+		cookie := &http.Cookie{
+			Name: "session_token",
+		}
+		req.AddCookie(cookie)
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Fatalf("want: %v, got: %v", http.StatusMethodNotAllowed, rec.Code)
+		}
+	})
+}
+
+func TestRevokeSessionHandler(t *testing.T) {
+	ctx := context.Background()
+	handler := setup()
+	if err := handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
+		t.Fatal(err)
+	}
+	users := storeSessions(handler)
+
+	t.Run("OK", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/revoke_session/2", nil)
+		cookie := &http.Cookie{
+			Name: "session_token",
+		}
+		req.AddCookie(cookie)
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusFound {
+			t.Fatalf("want: %v, got: %v", http.StatusFound, rec.Code)
+		}
+		if len(users.Sessions) != 2 {
+			t.Fatalf("want: %d, got: %d", 2, len(users.Sessions))
+		}
+	})
+
+	t.Run("err session of other user", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/revoke_session/3", nil)
+		cookie := &http.Cookie{
+			Name: "session_token",
+		}
+		req.AddCookie(cookie)
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusNotFound {
+			t.Fatalf("want: %v, got: %v", http.StatusNotFound, rec.Code)
+		}
+	})
+
+	t.Run("err wrong id", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/revoke_session/abc", nil)
+		cookie := &http.Cookie{
+			Name: "session_token",
+		}
+		req.AddCookie(cookie)
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusNotFound {
+			t.Fatalf("want: %v, got: %v", http.StatusNotFound, rec.Code)
+		}
+	})
+
+	t.Run("err method not allowed", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/revoke_session/1", nil)
+		cookie := &http.Cookie{
+			Name: "session_token",
+		}
+		req.AddCookie(cookie)
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Fatalf("want: %v, got: %v", http.StatusMethodNotAllowed, rec.Code)
+		}
+	})
+}
+
+func TestSignOutEverywhereHandler(t *testing.T) {
+	ctx := context.Background()
+	handler := setup()
+
+	t.Run("err not authorized", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/sign_out_everywhere", nil)
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusUnauthorized {
+			t.Fatalf("want: %v, got: %v", http.StatusUnauthorized, rec.Code)
+		}
+	})
+
+	t.Run("OK", func(t *testing.T) {
+		if err := handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
+			t.Fatal(err)
+		}
+		users := storeSessions(handler)
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/sign_out_everywhere", nil)
+		cookie := &http.Cookie{
+			Name: "session_token",
+		}
+		req.AddCookie(cookie)
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusFound {
+			t.Fatalf("want: %v, got: %v", http.StatusFound, rec.Code)
+		}
+		if len(users.Sessions) != 1 || users.Sessions[0].User.Id != 5 {
+			t.Fatalf("want only the session of user 5 left, got: %+v", users.Sessions)
+		}
+	})
+}
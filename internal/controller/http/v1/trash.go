@@ -0,0 +1,236 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"forum/internal/entity"
+)
+
+func (h *Handler) TrashPageHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.Errors(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	content, ok := r.Context().Value(Key("content")).(Content)
+	if !ok {
+		h.l.WriteLog(fmt.Errorf("v1 - TrashPageHandler - TypeAssertion:"+
+			"got data of type %T but wanted v1.Content", content))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	if !content.Can(entity.PermDeleteAnyPost) {
+		h.Errors(w, http.StatusForbidden)
+		return
+	}
+
+	posts, err := h.Usecases.Posts.GetDeletedPosts(r.Context())
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - TrashPageHandler - GetDeletedPosts: %w", err))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+	comments, err := h.Usecases.Comments.GetDeletedComments(r.Context())
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - TrashPageHandler - GetDeletedComments: %w", err))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+	content.Posts = posts
+	content.Comments = comments
+	if h.Cfg.Trash.RetentionDays > 0 {
+		content.Message = fmt.Sprintf(TrashRetention, h.Cfg.Trash.RetentionDays)
+	}
+
+	err = h.ParseAndExecute(w, content, "templates/trash.html")
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - TrashPageHandler - ParseAndExecute - %w", err))
+	}
+}
+
+func (h *Handler) DeletePostHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.Errors(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := strings.Split(r.URL.Path, "/")
+	id, err := strconv.Atoi(path[len(path)-1])
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - DeletePostHandler - Atoi: %w", err))
+	}
+	if r.URL.Path != "/delete_post/"+path[len(path)-1] || err != nil || id <= 0 {
+		h.Errors(w, http.StatusNotFound)
+		return
+	}
+
+	content, ok := r.Context().Value(Key("content")).(Content)
+	if !ok {
+		h.l.WriteLog(fmt.Errorf("v1 - DeletePostHandler - TypeAssertion:"+
+			"got data of type %T but wanted v1.Content", content))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	if !content.Can(entity.PermDeleteAnyPost) {
+		h.Errors(w, http.StatusForbidden)
+		return
+	}
+
+	post := entity.Post{
+		Id:           int64(id),
+		DeletedBy:    content.User.Id,
+		DeleteReason: strings.TrimSpace(r.FormValue("reason")),
+	}
+	err = h.Usecases.Posts.DeletePost(r.Context(), post)
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - DeletePostHandler - DeletePost: %w", err))
+		if errors.Is(err, entity.ErrPostNotFound) {
+			h.Errors(w, http.StatusNotFound)
+			return
+		}
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/posts/"+path[len(path)-1], http.StatusFound)
+}
+
+func (h *Handler) DeleteCommentHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.Errors(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := strings.Split(r.URL.Path, "/")
+	id, err := strconv.Atoi(path[len(path)-1])
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - DeleteCommentHandler - Atoi: %w", err))
+	}
+	if r.URL.Path != "/delete_comment/"+path[len(path)-1] || err != nil || id <= 0 {
+		h.Errors(w, http.StatusNotFound)
+		return
+	}
+
+	content, ok := r.Context().Value(Key("content")).(Content)
+	if !ok {
+		h.l.WriteLog(fmt.Errorf("v1 - DeleteCommentHandler - TypeAssertion:"+
+			"got data of type %T but wanted v1.Content", content))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	if !content.Can(entity.PermDeleteAnyPost) {
+		h.Errors(w, http.StatusForbidden)
+		return
+	}
+
+	comment := entity.Comment{
+		Id:           int64(id),
+		DeletedBy:    content.User.Id,
+		DeleteReason: strings.TrimSpace(r.FormValue("reason")),
+	}
+	err = h.Usecases.Comments.DeleteComment(r.Context(), comment)
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - DeleteCommentHandler - DeleteComment: %w", err))
+		if errors.Is(err, entity.ErrCommentNotFound) {
+			h.Errors(w, http.StatusNotFound)
+			return
+		}
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, r.Header.Get("Referer"), http.StatusFound)
+}
+
+func (h *Handler) RestorePostHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.Errors(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := strings.Split(r.URL.Path, "/")
+	id, err := strconv.Atoi(path[len(path)-1])
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - RestorePostHandler - Atoi: %w", err))
+	}
+	if r.URL.Path != "/restore_post/"+path[len(path)-1] || err != nil || id <= 0 {
+		h.Errors(w, http.StatusNotFound)
+		return
+	}
+
+	content, ok := r.Context().Value(Key("content")).(Content)
+	if !ok {
+		h.l.WriteLog(fmt.Errorf("v1 - RestorePostHandler - TypeAssertion:"+
+			"got data of type %T but wanted v1.Content", content))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	if !content.Can(entity.PermDeleteAnyPost) {
+		h.Errors(w, http.StatusForbidden)
+		return
+	}
+
+	err = h.Usecases.Posts.RestorePost(r.Context(), int64(id))
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - RestorePostHandler - RestorePost: %w", err))
+		if errors.Is(err, entity.ErrPostNotFound) {
+			h.Errors(w, http.StatusNotFound)
+			return
+		}
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/trash", http.StatusFound)
+}
+
+func (h *Handler) RestoreCommentHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.Errors(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := strings.Split(r.URL.Path, "/")
+	id, err := strconv.Atoi(path[len(path)-1])
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - RestoreCommentHandler - Atoi: %w", err))
+	}
+	if r.URL.Path != "/restore_comment/"+path[len(path)-1] || err != nil || id <= 0 {
+		h.Errors(w, http.StatusNotFound)
+		return
+	}
+
+	content, ok := r.Context().Value(Key("content")).(Content)
+	if !ok {
+		h.l.WriteLog(fmt.Errorf("v1 - RestoreCommentHandler - TypeAssertion:"+
+			"got data of type %T but wanted v1.Content", content))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	if !content.Can(entity.PermDeleteAnyPost) {
+		h.Errors(w, http.StatusForbidden)
+		return
+	}
+
+	err = h.Usecases.Comments.RestoreComment(r.Context(), int64(id))
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - RestoreCommentHandler - RestoreComment: %w", err))
+		if errors.Is(err, entity.ErrCommentNotFound) {
+			h.Errors(w, http.StatusNotFound)
+			return
+		}
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/trash", http.StatusFound)
+}
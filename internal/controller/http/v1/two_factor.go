@@ -0,0 +1,295 @@
+package v1
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"forum/internal/entity"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// qrCodeSize is the side of the enrollment QR code in pixels.
+const qrCodeSize = 256
+
+// askSecondFactor answers a sign-in whose password was right with the
+// code form, the challenge token goes in the form.
+func (h *Handler) askSecondFactor(w http.ResponseWriter, r *http.Request, userId int64) {
+	token, err := h.Usecases.TwoFactor.Challenge(r.Context(), userId)
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - askSecondFactor - Challenge: %w", err))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	err = h.ParseAndExecute(w, Content{Unauthorized: true, Query: token}, "templates/two_factor_signin.html")
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - askSecondFactor - ParseAndExecute - %w", err))
+	}
+}
+
+// SignInTwoFactorHandler takes the code of the second sign-in step and
+// sets the session cookie once it is right.
+func (h *Handler) SignInTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.Errors(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		h.Errors(w, http.StatusBadRequest)
+		return
+	}
+	if len(r.Form["token"]) == 0 || len(r.Form["code"]) == 0 {
+		h.Errors(w, http.StatusBadRequest)
+		return
+	}
+	token := r.Form["token"][0]
+
+	session, _ := h.GetExistedSession(w, r)
+	session, err := h.Usecases.TwoFactor.CompleteSignIn(r.Context(), token, r.Form["code"][0], session)
+	switch {
+	case err == nil:
+	case errors.Is(err, entity.ErrTwoFactorCodeInvalid):
+		content := Content{Unauthorized: true, Query: token}
+		content.ErrorMsg.Message = TwoFactorCodeInvalid
+		w.WriteHeader(http.StatusUnauthorized)
+		err = h.ParseAndExecute(w, content, "templates/two_factor_signin.html")
+		if err != nil {
+			h.l.WriteLog(fmt.Errorf("v1 - SignInTwoFactorHandler - ParseAndExecute - %w", err))
+		}
+		return
+	case errors.Is(err, entity.ErrChallengeInvalid):
+		content := Content{}
+		content.ErrorMsg.Message = TwoFactorExpired
+		w.WriteHeader(http.StatusUnauthorized)
+		err = h.ParseAndExecute(w, content, "templates/login.html")
+		if err != nil {
+			h.l.WriteLog(fmt.Errorf("v1 - SignInTwoFactorHandler - ParseAndExecute - %w", err))
+		}
+		return
+	default:
+		h.l.WriteLog(fmt.Errorf("v1 - SignInTwoFactorHandler - CompleteSignIn: %w", err))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:    "session_token",
+		Value:   session.Token,
+		Expires: session.ExpiresAt,
+		Path:    "/",
+		Domain:  h.Cfg.Server.Host,
+	})
+	http.Redirect(w, r, "/", http.StatusFound)
+}
+
+// TwoFactorPageHandler shows whether two-factor is on, and the QR code
+// to scan while it is off.
+func (h *Handler) TwoFactorPageHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.Errors(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	content, ok := r.Context().Value(Key("content")).(Content)
+	if !ok {
+		h.l.WriteLog(fmt.Errorf("v1 - TwoFactorPageHandler - TypeAssertion:"+
+			"got data of type %T but wanted v1.Content", content))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	if !h.twoFactorContent(w, r, &content) {
+		return
+	}
+
+	err := h.ParseAndExecute(w, content, "templates/two_factor.html")
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - TwoFactorPageHandler - ParseAndExecute - %w", err))
+	}
+}
+
+// EnableTwoFactorHandler turns two-factor on with the first code from the
+// app and shows the recovery codes.
+func (h *Handler) EnableTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.Errors(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		h.Errors(w, http.StatusBadRequest)
+		return
+	}
+	if len(r.Form["code"]) == 0 {
+		h.Errors(w, http.StatusBadRequest)
+		return
+	}
+
+	content, ok := r.Context().Value(Key("content")).(Content)
+	if !ok {
+		h.l.WriteLog(fmt.Errorf("v1 - EnableTwoFactorHandler - TypeAssertion:"+
+			"got data of type %T but wanted v1.Content", content))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	status := http.StatusOK
+	codes, err := h.Usecases.TwoFactor.Enable(r.Context(), content.User.Id, r.Form["code"][0])
+	switch {
+	case err == nil:
+		content.Message = TwoFactorEnabled
+		content.RecoveryCodes = codes
+	case errors.Is(err, entity.ErrTwoFactorCodeInvalid):
+		status = http.StatusBadRequest
+		content.ErrorMsg.Message = TwoFactorCodeInvalid
+	case errors.Is(err, entity.ErrTwoFactorEnabled):
+	default:
+		h.l.WriteLog(fmt.Errorf("v1 - EnableTwoFactorHandler - Enable: %w", err))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	if !h.twoFactorContent(w, r, &content) {
+		return
+	}
+
+	w.WriteHeader(status)
+	err = h.ParseAndExecute(w, content, "templates/two_factor.html")
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - EnableTwoFactorHandler - ParseAndExecute - %w", err))
+	}
+}
+
+// DisableTwoFactorHandler turns two-factor off with a code from the app or
+// a recovery code.
+func (h *Handler) DisableTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.Errors(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		h.Errors(w, http.StatusBadRequest)
+		return
+	}
+	if len(r.Form["code"]) == 0 {
+		h.Errors(w, http.StatusBadRequest)
+		return
+	}
+
+	content, ok := r.Context().Value(Key("content")).(Content)
+	if !ok {
+		h.l.WriteLog(fmt.Errorf("v1 - DisableTwoFactorHandler - TypeAssertion:"+
+			"got data of type %T but wanted v1.Content", content))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	status := http.StatusOK
+	err := h.Usecases.TwoFactor.Disable(r.Context(), content.User.Id, r.Form["code"][0])
+	switch {
+	case err == nil:
+		content.Message = TwoFactorDisabled
+	case errors.Is(err, entity.ErrTwoFactorCodeInvalid):
+		status = http.StatusBadRequest
+		content.ErrorMsg.Message = TwoFactorCodeInvalid
+	case errors.Is(err, entity.ErrTwoFactorDisabled):
+	default:
+		h.l.WriteLog(fmt.Errorf("v1 - DisableTwoFactorHandler - Disable: %w", err))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	if !h.twoFactorContent(w, r, &content) {
+		return
+	}
+
+	w.WriteHeader(status)
+	err = h.ParseAndExecute(w, content, "templates/two_factor.html")
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - DisableTwoFactorHandler - ParseAndExecute - %w", err))
+	}
+}
+
+// ResetTwoFactorHandler lets admins turn two-factor off for users who
+// lost both the app and the recovery codes.
+func (h *Handler) ResetTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.Errors(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := strings.Split(r.URL.Path, "/")
+	id, err := strconv.ParseInt(path[len(path)-1], 10, 64)
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - ResetTwoFactorHandler - ParseInt: %w", err))
+	}
+	if r.URL.Path != "/reset_two_factor/"+path[len(path)-1] || err != nil || id <= 0 {
+		h.Errors(w, http.StatusNotFound)
+		return
+	}
+
+	content, ok := r.Context().Value(Key("content")).(Content)
+	if !ok {
+		h.l.WriteLog(fmt.Errorf("v1 - ResetTwoFactorHandler - TypeAssertion:"+
+			"got data of type %T but wanted v1.Content", content))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	if !content.Can(entity.PermManageUsers) {
+		h.Errors(w, http.StatusForbidden)
+		return
+	}
+
+	err = h.Usecases.TwoFactor.Reset(r.Context(), id)
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - ResetTwoFactorHandler - Reset: %w", err))
+		if errors.Is(err, entity.ErrUserNotFound) {
+			h.Errors(w, http.StatusNotFound)
+		} else {
+			h.Errors(w, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	http.Redirect(w, r, "/users/"+strconv.FormatInt(id, 10), http.StatusFound)
+}
+
+// twoFactorContent puts the two-factor state of the user into the content,
+// with the enrollment and its QR code while two-factor is off. It answers
+// with an error and returns false when that fails.
+func (h *Handler) twoFactorContent(w http.ResponseWriter, r *http.Request, content *Content) bool {
+	var err error
+	content.TwoFactor, err = h.Usecases.TwoFactor.Status(r.Context(), content.User.Id)
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - twoFactorContent - Status: %w", err))
+		h.Errors(w, http.StatusInternalServerError)
+		return false
+	}
+	if content.TwoFactor.Enabled {
+		return true
+	}
+
+	content.Enrollment, err = h.Usecases.TwoFactor.BeginEnrollment(r.Context(), content.User.Id)
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - twoFactorContent - BeginEnrollment: %w", err))
+		h.Errors(w, http.StatusInternalServerError)
+		return false
+	}
+	png, err := qrcode.Encode(content.Enrollment.URI, qrcode.Medium, qrCodeSize)
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - twoFactorContent - Encode: %w", err))
+		h.Errors(w, http.StatusInternalServerError)
+		return false
+	}
+	content.QRCode = template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(png))
+	return true
+}
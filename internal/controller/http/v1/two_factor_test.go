@@ -0,0 +1,227 @@
+package v1_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"forum/internal/entity"
+	mu "forum/internal/usecase/mock"
+)
+
+func TestSignInTwoFactor(t *testing.T) {
+	handler := setup()
+	handler.Usecases.Users.(*mu.UsersMockUseCase).TwoFactor = true
+
+	t.Run("OK code asked", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/signin", nil)
+		req.PostForm = url.Values{"user": {"Riddle"}, "password": {"Vivse"}}
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("want: %v, got: %v", http.StatusOK, rec.Code)
+		}
+		if len(rec.Result().Cookies()) != 0 {
+			t.Fatal("want no session before the code")
+		}
+		if !strings.Contains(rec.Body.String(), `value="`+mu.ValidToken+`"`) {
+			t.Fatal("want challenge token in the form")
+		}
+	})
+
+	t.Run("OK", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/signin_2fa", nil)
+		req.PostForm = url.Values{"token": {mu.ValidToken}, "code": {mu.ValidCode}}
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusFound {
+			t.Fatalf("want: %v, got: %v", http.StatusFound, rec.Code)
+		}
+		if cookies := rec.Result().Cookies(); len(cookies) != 1 || cookies[0].Value != "token" {
+			t.Fatalf("want session cookie, got: %v", cookies)
+		}
+	})
+
+	for name, form := range map[string]url.Values{
+		"err wrong code":      {"token": {mu.ValidToken}, "code": {"000000"}},
+		"err wrong challenge": {"token": {"forged"}, "code": {mu.ValidCode}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/signin_2fa", nil)
+			req.PostForm = form
+
+			handler.Mux.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusUnauthorized {
+				t.Fatalf("want: %v, got: %v", http.StatusUnauthorized, rec.Code)
+			}
+			if len(rec.Result().Cookies()) != 0 {
+				t.Fatal("want no session")
+			}
+		})
+	}
+
+	t.Run("err empty code", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/signin_2fa", nil)
+		req.PostForm = url.Values{"token": {mu.ValidToken}}
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("want: %v, got: %v", http.StatusBadRequest, rec.Code)
+		}
+	})
+}
+
+func TestTwoFactorPage(t *testing.T) {
+	handler := setup()
+	if err := handler.Usecases.Users.SignUp(context.Background(), entity.User{}); err != nil {
+		t.Fatal(err)
+	}
+	twoFactor := handler.Usecases.TwoFactor.(*mu.TwoFactorMockUseCase)
+
+	t.Run("OK enrollment", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/two_factor", nil)
+		req.AddCookie(&http.Cookie{Name: "session_token"})
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("want: %v, got: %v", http.StatusOK, rec.Code)
+		}
+		if !strings.Contains(rec.Body.String(), "data:image/png;base64,") {
+			t.Fatal("want QR code on the page")
+		}
+	})
+
+	t.Run("err wrong code", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/enable_two_factor", nil)
+		req.AddCookie(&http.Cookie{Name: "session_token"})
+		req.PostForm = url.Values{"code": {"000000"}}
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("want: %v, got: %v", http.StatusBadRequest, rec.Code)
+		}
+		if twoFactor.Enabled {
+			t.Fatal("want two-factor disabled")
+		}
+	})
+
+	t.Run("OK enable", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/enable_two_factor", nil)
+		req.AddCookie(&http.Cookie{Name: "session_token"})
+		req.PostForm = url.Values{"code": {mu.ValidCode}}
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("want: %v, got: %v", http.StatusOK, rec.Code)
+		}
+		if !twoFactor.Enabled || !strings.Contains(rec.Body.String(), "aaaaa-bbbbb") {
+			t.Fatal("want two-factor enabled and recovery codes shown")
+		}
+	})
+
+	t.Run("OK disable", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/disable_two_factor", nil)
+		req.AddCookie(&http.Cookie{Name: "session_token"})
+		req.PostForm = url.Values{"code": {mu.ValidCode}}
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("want: %v, got: %v", http.StatusOK, rec.Code)
+		}
+		if twoFactor.Enabled {
+			t.Fatal("want two-factor disabled")
+		}
+	})
+
+	t.Run("err unauthorized", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/two_factor", nil)
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusUnauthorized {
+			t.Fatalf("want: %v, got: %v", http.StatusUnauthorized, rec.Code)
+		}
+	})
+}
+
+func TestResetTwoFactorHandler(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		handler := setup()
+		if err := handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
+			t.Fatal(err)
+		}
+		twoFactor := handler.Usecases.TwoFactor.(*mu.TwoFactorMockUseCase)
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/reset_two_factor/3", nil)
+		req.AddCookie(&http.Cookie{Name: "session_token"})
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusFound {
+			t.Fatalf("want: %v, got: %v", http.StatusFound, rec.Code)
+		}
+		if !reflect.DeepEqual(twoFactor.Resets, []int64{3}) {
+			t.Fatalf("want user 3 reset, got: %v", twoFactor.Resets)
+		}
+	})
+
+	t.Run("err not admin", func(t *testing.T) {
+		handler := setup()
+		for i := 0; i < 2; i++ {
+			if err := handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/reset_two_factor/3", nil)
+		req.AddCookie(&http.Cookie{Name: "session_token"})
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusForbidden {
+			t.Fatalf("want: %v, got: %v", http.StatusForbidden, rec.Code)
+		}
+	})
+
+	t.Run("err wrong method", func(t *testing.T) {
+		handler := setup()
+		if err := handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
+			t.Fatal(err)
+		}
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/reset_two_factor/3", nil)
+		req.AddCookie(&http.Cookie{Name: "session_token"})
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Fatalf("want: %v, got: %v", http.StatusMethodNotAllowed, rec.Code)
+		}
+	})
+}
@@ -3,6 +3,7 @@ package v1
 import (
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"net/mail"
 	"os"
@@ -44,7 +45,7 @@ func (h *Handler) UserPageHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	content.OwnerId = content.User.Id
 
-	user, err := h.Usecases.Users.GetById(int64(id))
+	user, err := h.Usecases.Users.GetById(r.Context(), int64(id))
 	if err != nil {
 		h.l.WriteLog(fmt.Errorf("v1 - UserPageHandler - GetById: %w", err))
 		if errors.Is(err, entity.ErrUserNotFound) {
@@ -55,11 +56,20 @@ func (h *Handler) UserPageHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if content.User.Id == int64(id) && content.Authorized || content.Admin {
+	if content.User.Id == int64(id) && content.Authorized || content.Can(entity.PermManageUsers) {
 		user.Owner = true
 	}
 	content.User = user
 
+	if content.Can(entity.PermManageRoles) {
+		content.Roles, err = h.Usecases.Roles.GetRoles(r.Context())
+		if err != nil {
+			h.l.WriteLog(fmt.Errorf("v1 - UserPageHandler - GetRoles: %w", err))
+			h.Errors(w, http.StatusInternalServerError)
+			return
+		}
+	}
+
 	err = h.ParseAndExecute(w, content, "templates/user.html")
 	if err != nil {
 		h.l.WriteLog(fmt.Errorf("v1 - UserPageHandler - ParseAndExecute - %w", err))
@@ -80,10 +90,19 @@ func (h *Handler) AllUsersPageHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	users, err := h.Usecases.Users.GetAllUsers()
-	content.Users = users
+	number, cursor, byCursor, err := pageParams(r)
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - AllUsersPageHandler - %w", err))
+		h.Errors(w, http.StatusBadRequest)
+		return
+	}
+	if byCursor {
+		content.Users, content.Page, err = h.Usecases.Users.GetUsersAfter(r.Context(), cursor)
+	} else {
+		content.Users, content.Page, err = h.Usecases.Users.GetUsersPage(r.Context(), number)
+	}
 	if err != nil {
-		h.l.WriteLog(fmt.Errorf("v1 - AllUsersPageHandler - GetAllUsers: %w", err))
+		h.l.WriteLog(fmt.Errorf("v1 - AllUsersPageHandler - GetUsers: %w", err))
 		h.Errors(w, http.StatusInternalServerError)
 		return
 	}
@@ -192,7 +211,7 @@ func (h *Handler) SignUpHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.Usecases.Users.SignUp(user)
+	err = h.Usecases.Users.SignUp(r.Context(), user)
 	if err != nil {
 		if err == entity.ErrUserEmailAlreadyExists {
 			content.ErrorMsg.Message = UserEmailAlreadyExist
@@ -213,9 +232,19 @@ func (h *Handler) SignUpHandler(w http.ResponseWriter, r *http.Request) {
 		if err != nil {
 			h.l.WriteLog(fmt.Errorf("v1 - SignUpHandler - ParseAndExecute #2 - %w", err))
 		}
-	} else {
-		http.Redirect(w, r, "/signin_page", http.StatusFound)
+		return
 	}
+
+	// the account is there even when the letter fails, it can be sent
+	// again from the profile
+	id, err := h.Usecases.Users.GetIdBy(r.Context(), entity.User{Name: user.Name})
+	if err == nil {
+		err = h.Usecases.Verification.SendVerification(r.Context(), id)
+	}
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - SignUpHandler - SendVerification: %w", err))
+	}
+	http.Redirect(w, r, "/signin_page", http.StatusFound)
 }
 
 func (h *Handler) SignInPageHandler(w http.ResponseWriter, r *http.Request) {
@@ -236,11 +265,11 @@ func (h *Handler) SignInPageHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) checkIfAuthrized(w http.ResponseWriter, r *http.Request) bool {
-	foundUser := h.GetExistedSession(w, r)
-	if foundUser.Id == 0 {
+	session, found := h.GetExistedSession(w, r)
+	if !found {
 		return false
 	}
-	isAuthorized, err := h.Usecases.Users.CheckSession(foundUser)
+	_, isAuthorized, err := h.Usecases.Users.CheckSession(r.Context(), session)
 	if err != nil {
 		h.l.WriteLog(fmt.Errorf("checkIfAuthrized: %w", err))
 		return false
@@ -276,9 +305,15 @@ func (h *Handler) SignInHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	valid := true
+	status := http.StatusUnauthorized
 	content := Content{}
 
-	err := h.Usecases.Users.SignIn(user)
+	session, _ := h.GetExistedSession(w, r)
+	session, err := h.Usecases.Users.SignIn(r.Context(), user, session)
+	if errors.Is(err, entity.ErrTwoFactorRequired) {
+		h.askSecondFactor(w, r, session.User.Id)
+		return
+	}
 
 	if err != nil && !strings.Contains(err.Error(), NoRowsInResult) {
 		h.l.WriteLog(fmt.Errorf("v1 - SignInHandler - SignIn: %w", err))
@@ -289,10 +324,14 @@ func (h *Handler) SignInHandler(w http.ResponseWriter, r *http.Request) {
 	} else if err == entity.ErrUserPasswordIncorrect {
 		content.ErrorMsg.Message = UserPassWrong
 		valid = false
+	} else if err == entity.ErrUserBanned {
+		content.ErrorMsg.Message = UserBanned
+		status = http.StatusForbidden
+		valid = false
 	}
 
 	if !valid {
-		w.WriteHeader(http.StatusUnauthorized)
+		w.WriteHeader(status)
 
 		err := h.ParseAndExecute(w, content, "templates/login.html")
 		if err != nil {
@@ -300,24 +339,15 @@ func (h *Handler) SignInHandler(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	} else {
-		id, err := h.Usecases.Users.GetIdBy(user)
 		if err != nil {
-			h.l.WriteLog(fmt.Errorf("v1 - SignInHandler - GetIdBy: %w", err))
-			h.Errors(w, http.StatusBadRequest)
-			return
-		}
-
-		userWithSession, err := h.Usecases.Users.GetSession(id)
-		if err != nil {
-			h.l.WriteLog(fmt.Errorf("v1 - SignInHandler - GetSession: %w", err))
 			h.Errors(w, http.StatusInternalServerError)
 			return
 		}
 
 		http.SetCookie(w, &http.Cookie{
 			Name:    "session_token",
-			Value:   userWithSession.SessionToken,
-			Expires: userWithSession.SessionTTL,
+			Value:   session.Token,
+			Expires: session.ExpiresAt,
 			Path:    "/",
 			Domain:  h.Cfg.Server.Host,
 		})
@@ -349,7 +379,7 @@ func (h *Handler) EditProfilePageHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if content.User.Id != 1 && content.User.Id != int64(id) {
+	if !content.Can(entity.PermManageUsers) && content.User.Id != int64(id) {
 		h.Errors(w, http.StatusForbidden)
 		return
 	}
@@ -437,7 +467,7 @@ func (h *Handler) EditProfileHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	existUser, err := h.Usecases.Users.GetById(content.User.Id)
+	existUser, err := h.Usecases.Users.GetById(r.Context(), content.User.Id)
 	if err != nil {
 		h.l.WriteLog(fmt.Errorf("v1 - EditProfileHandler - GetById: %w", err))
 		h.Errors(w, http.StatusNotFound)
@@ -457,13 +487,27 @@ func (h *Handler) EditProfileHandler(w http.ResponseWriter, r *http.Request) {
 	if len(r.MultipartForm.Value["sign"]) != 0 && r.MultipartForm.Value["sign"][0] != "" {
 		existUser.Sign = r.MultipartForm.Value["sign"][0]
 	}
-	if len(r.MultipartForm.Value["role"]) != 0 && r.MultipartForm.Value["role"][0] != "" {
-		existUser.Role = r.MultipartForm.Value["role"][0]
+	if len(r.MultipartForm.Value["timezone"]) != 0 && r.MultipartForm.Value["timezone"][0] != "" {
+		existUser.Timezone = r.MultipartForm.Value["timezone"][0]
 	}
 	if imagePath != "" {
 		existUser.AvatarPath = "/" + imagePath
 	}
-	err = h.Usecases.Users.UpdateUserInfo(existUser, UpdateQueryInfo)
+	err = h.Usecases.Users.UpdateUserInfo(r.Context(), existUser, UpdateQueryInfo)
+	if errors.Is(err, entity.ErrUnknownTimezone) {
+		if imagePath != "" {
+			if err = os.Remove(imagePath); err != nil {
+				h.l.WriteLog(fmt.Errorf("v1 - EditProfileHandler - Remove: %w", err))
+			}
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		content.ErrorMsg.Message = TimezoneUnknown
+		err = h.ParseAndExecute(w, content, "templates/edit_profile.html")
+		if err != nil {
+			h.l.WriteLog(fmt.Errorf("v1 - EditProfileHandler - ParseAndExecute #3: %w", err))
+		}
+		return
+	}
 	if err != nil {
 		err = os.Remove(imagePath)
 		if err != nil {
@@ -489,7 +533,7 @@ func (h *Handler) SignOutHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.Usecases.Users.DeleteSession(content.User)
+	err := h.Usecases.Users.DeleteSession(r.Context(), content.Session)
 	if err != nil {
 		h.l.WriteLog(fmt.Errorf("v1 - SignOutHandler - DeleteSession: %w", err))
 		h.Errors(w, http.StatusInternalServerError)
@@ -506,19 +550,19 @@ func (h *Handler) FindReactedUsersHandler(w http.ResponseWriter, r *http.Request
 
 	path := strings.Split(r.URL.Path, "/")
 	query := path[len(path)-3]
-	reaction := path[len(path)-2]
+	kind := path[len(path)-2]
 	id, err := strconv.Atoi(path[len(path)-1])
 	if err != nil {
 		h.l.WriteLog(fmt.Errorf("v1 - FindReactedUsersHandler - Atoi: %w", err))
 	}
 
-	if (query != QueryPost && query != QueryComment) ||
-		(reaction != QueryLiked && reaction != QueryDisliked) {
+	reaction, found := h.Cfg.Reactions.Find(kind)
+	if (query != QueryPost && query != QueryComment) || !found {
 		h.Errors(w, http.StatusNotFound)
 		return
 	}
 
-	if r.URL.Path != "/find_reacted_users/"+query+"/"+reaction+"/"+path[len(path)-1] ||
+	if r.URL.Path != "/find_reacted_users/"+query+"/"+kind+"/"+path[len(path)-1] ||
 		err != nil || id <= 0 {
 		h.Errors(w, http.StatusNotFound)
 		return
@@ -534,42 +578,21 @@ func (h *Handler) FindReactedUsersHandler(w http.ResponseWriter, r *http.Request
 
 	switch query {
 	case QueryPost:
-		if reaction == QueryLiked {
-			content.Users, err = h.Usecases.Posts.GetReactions(int64(id), QueryLiked)
-			if err != nil {
-				h.l.WriteLog(fmt.Errorf("v1 - FindReactedUsersHandler - GetReactions #1: %w", err))
-				h.Errors(w, http.StatusNotFound)
-				return
-			}
-			content.Message = ReactionMessageLike
-		} else if reaction == QueryDisliked {
-			content.Users, err = h.Usecases.Posts.GetReactions(int64(id), QueryDisliked)
-			if err != nil {
-				h.l.WriteLog(fmt.Errorf("v1 - FindReactedUsersHandler - GetReactions #2: %w", err))
-				h.Errors(w, http.StatusNotFound)
-				return
-			}
-			content.Message = ReactionMessageDislike
+		content.Users, err = h.Usecases.Posts.GetReactions(r.Context(), int64(id), kind)
+		if err != nil {
+			h.l.WriteLog(fmt.Errorf("v1 - FindReactedUsersHandler - GetReactions #1: %w", err))
+			h.Errors(w, http.StatusNotFound)
+			return
 		}
 	case QueryComment:
-		if reaction == QueryLiked {
-			content.Users, err = h.Usecases.Comments.GetReactions(int64(id), QueryLiked)
-			if err != nil {
-				h.l.WriteLog(fmt.Errorf("v1 - FindReactedUsersHandler - GetReactions #3: %w", err))
-				h.Errors(w, http.StatusNotFound)
-				return
-			}
-			content.Message = ReactionMessageLike
-		} else if reaction == QueryDisliked {
-			content.Users, err = h.Usecases.Comments.GetReactions(int64(id), QueryDisliked)
-			if err != nil {
-				h.l.WriteLog(fmt.Errorf("v1 - FindReactedUsersHandler - GetReactions #4: %w", err))
-				h.Errors(w, http.StatusBadRequest)
-				return
-			}
-			content.Message = ReactionMessageDislike
+		content.Users, err = h.Usecases.Comments.GetReactions(r.Context(), int64(id), kind)
+		if err != nil {
+			h.l.WriteLog(fmt.Errorf("v1 - FindReactedUsersHandler - GetReactions #2: %w", err))
+			h.Errors(w, http.StatusBadRequest)
+			return
 		}
 	}
+	content.Message = reaction.Emoji
 
 	err = h.ParseAndExecute(w, content, "templates/reacted_users.html")
 	if err != nil {
@@ -577,32 +600,28 @@ func (h *Handler) FindReactedUsersHandler(w http.ResponseWriter, r *http.Request
 	}
 }
 
-func (h *Handler) GetExistedSession(w http.ResponseWriter, r *http.Request) entity.User {
-	foundUser := entity.User{}
+// GetExistedSession describes the browser of the request by its session
+// cookie, user agent and address. It reports whether there is a cookie at
+// all, the session itself is checked by CheckSession.
+func (h *Handler) GetExistedSession(w http.ResponseWriter, r *http.Request) (entity.Session, bool) {
+	session := entity.Session{
+		UserAgent: r.UserAgent(),
+		IP:        r.RemoteAddr,
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		session.IP = host
+	}
 	cookie, err := r.Cookie(h.Cfg.TokenManager.TokenName)
 	if err != nil {
 		if err == http.ErrNoCookie {
-			return foundUser
+			return session, false
 		}
 		w.WriteHeader(http.StatusBadRequest)
-		return foundUser
+		return session, false
 	}
-	token := cookie.Value
-	user := entity.User{
-		SessionToken: token,
-	}
-	id, err := h.Usecases.Users.GetIdBy(user)
-	if err != nil {
-		if !strings.Contains(err.Error(), NoRowsInResult) {
-			h.l.WriteLog(fmt.Errorf("v1 - GetExistedSession - GetIdBy: %w", err))
-		}
-		return foundUser
-	}
-
-	foundUser.Id = id
-	foundUser.SessionToken = token
+	session.Token = cookie.Value
 
-	return foundUser
+	return session, true
 }
 
 func checkEmail(address string) bool {
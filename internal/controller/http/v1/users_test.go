@@ -1,11 +1,15 @@
 package v1_test
 
 import (
+	"context"
 	"forum/internal/entity"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
+
+	mu "forum/internal/usecase/mock"
 )
 
 func TestUserPageHandler(t *testing.T) {
@@ -40,6 +44,23 @@ func TestUserPageHandler(t *testing.T) {
 			t.Fatalf("want: %v, got: %v", http.StatusNotFound, rec.Code)
 		}
 	})
+
+	t.Run("OK with roles", func(t *testing.T) {
+		if err := handler.Usecases.Users.SignUp(context.Background(), entity.User{}); err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/users/3", nil)
+		req.AddCookie(&http.Cookie{Name: "session_token"})
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("want: %v, got: %v", http.StatusOK, rec.Code)
+		}
+		if !strings.Contains(rec.Body.String(), "/assign_role/") {
+			t.Fatal("want the role form for the admin")
+		}
+	})
 }
 
 func TestAllUsersPageHandler(t *testing.T) {
@@ -55,6 +76,26 @@ func TestAllUsersPageHandler(t *testing.T) {
 		}
 	})
 
+	t.Run("OK cursor", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/all_users_page?cursor=3", nil)
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("want: %v, got: %v", http.StatusOK, rec.Code)
+		}
+	})
+
+	t.Run("err bad page", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/all_users_page?page=x", nil)
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("want: %v, got: %v", http.StatusBadRequest, rec.Code)
+		}
+	})
+
 	t.Run("err wrong method", func(t *testing.T) {
 		rec := httptest.NewRecorder()
 		req := httptest.NewRequest(http.MethodHead, "/all_users_page", nil)
@@ -109,6 +150,10 @@ func TestSignUpHandler(t *testing.T) {
 		if rec.Code != http.StatusFound {
 			t.Fatalf("want: %v, got: %v", http.StatusFound, rec.Code)
 		}
+		verification := handler.Usecases.Verification.(*mu.VerificationMockUseCase)
+		if len(verification.Sent) != 1 {
+			t.Fatalf("want a verification letter, got: %v", verification.Sent)
+		}
 	})
 
 	t.Run("err wrong method", func(t *testing.T) {
@@ -311,11 +356,33 @@ func TestSignInHandler(t *testing.T) {
 			t.Fatalf("want: %v, got: %v", http.StatusBadRequest, rec.Code)
 		}
 	})
+
+	t.Run("err banned", func(t *testing.T) {
+		users := handler.Usecases.Users.(*mu.UsersMockUseCase)
+		if err := users.SignUp(context.Background(), entity.User{}); err != nil {
+			t.Fatal(err)
+		}
+		users.Banned = []int64{1}
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/signin", nil)
+
+		form := url.Values{}
+		form.Add("user", "Riddle")
+		form.Add("password", "Vivse")
+		req.PostForm = form
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusForbidden {
+			t.Fatalf("want: %v, got: %v", http.StatusForbidden, rec.Code)
+		}
+	})
 }
 
 func TestEditProfilePageHandler(t *testing.T) {
+	ctx := context.Background()
 	handler := setup()
-	if err := handler.Usecases.Users.SignUp(entity.User{}); err != nil {
+	if err := handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
 		t.Fatal(err)
 	}
 
@@ -366,8 +433,9 @@ func TestEditProfilePageHandler(t *testing.T) {
 }
 
 func TestEditProfileHandler(t *testing.T) {
+	ctx := context.Background()
 	handler := setup()
-	if err := handler.Usecases.Users.SignUp(entity.User{}); err != nil {
+	if err := handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
 		t.Fatal(err)
 	}
 
@@ -432,8 +500,9 @@ func TestEditProfileHandler(t *testing.T) {
 }
 
 func TestSignOutHandler(t *testing.T) {
+	ctx := context.Background()
 	handler := setup()
-	if err := handler.Usecases.Users.SignUp(entity.User{}); err != nil {
+	if err := handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
 		t.Fatal(err)
 	}
 
@@ -454,14 +523,15 @@ func TestSignOutHandler(t *testing.T) {
 }
 
 func TestFindReactedUsersHandler(t *testing.T) {
+	ctx := context.Background()
 	handler := setup()
-	if err := handler.Usecases.Users.SignUp(entity.User{}); err != nil {
+	if err := handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
 		t.Fatal(err)
 	}
 
-	t.Run("OK liked post", func(t *testing.T) {
+	t.Run("OK like post", func(t *testing.T) {
 		rec := httptest.NewRecorder()
-		req := httptest.NewRequest(http.MethodGet, "/find_reacted_users/post/liked/4", nil)
+		req := httptest.NewRequest(http.MethodGet, "/find_reacted_users/post/like/4", nil)
 		cookie := &http.Cookie{
 			Name: "session_token",
 		}
@@ -474,9 +544,9 @@ func TestFindReactedUsersHandler(t *testing.T) {
 		}
 	})
 
-	t.Run("OK disliked comment", func(t *testing.T) {
+	t.Run("OK dislike comment", func(t *testing.T) {
 		rec := httptest.NewRecorder()
-		req := httptest.NewRequest(http.MethodGet, "/find_reacted_users/comment/disliked/3", nil)
+		req := httptest.NewRequest(http.MethodGet, "/find_reacted_users/comment/dislike/3", nil)
 		cookie := &http.Cookie{
 			Name: "session_token",
 		}
@@ -491,7 +561,7 @@ func TestFindReactedUsersHandler(t *testing.T) {
 
 	t.Run("err wrong method", func(t *testing.T) {
 		rec := httptest.NewRecorder()
-		req := httptest.NewRequest(http.MethodPut, "/find_reacted_users/comment/disliked/3", nil)
+		req := httptest.NewRequest(http.MethodPut, "/find_reacted_users/comment/dislike/3", nil)
 		cookie := &http.Cookie{
 			Name: "session_token",
 		}
@@ -1,19 +1,89 @@
 package v1
 
-import "forum/internal/entity"
+import (
+	"html/template"
+	"time"
+
+	"forum/internal/entity"
+)
 
 type Content struct {
 	Authorized   bool
 	Unauthorized bool
-	Admin        bool
-	User         entity.User
-	Post         entity.Post
-	Posts        []entity.Post
-	Users        []entity.User
-	Message      string
-	OwnerId      int64
-	ErrorMsg     ErrMessage
-	Uri          string
+	// Role is the role of the signed in user.
+	Role entity.Role
+	// Verified is set for signed in users with a verified email.
+	Verified      bool
+	User          entity.User
+	Session       entity.Session
+	Sessions      []entity.Session
+	Post          entity.Post
+	Posts         []entity.Post
+	Category      entity.Category
+	Categories    []entity.Category
+	Comment       entity.Comment
+	Comments      []entity.Comment
+	Revisions     []entity.Revision
+	Diff          entity.Diff
+	Users         []entity.User
+	Roles         []entity.Role
+	Message       string
+	OwnerId       int64
+	ErrorMsg      ErrMessage
+	Uri           string
+	Query         string
+	SearchResults []entity.SearchResult
+	Page          entity.Page
+	Backups       []entity.Backup
+	TwoFactor     entity.TwoFactor
+	Enrollment    entity.TwoFactorEnrollment
+	// QRCode is the enrollment URI as a PNG data URI.
+	QRCode template.URL
+	// RecoveryCodes are shown once, right after two-factor is enabled.
+	RecoveryCodes []string
+	// Location is the timezone dates are shown in, UTC when nil.
+	Location *time.Location
+}
+
+// Thread is a comment rendered with the page it belongs to, post.html
+// passes it down while it walks the replies.
+type Thread struct {
+	Comment entity.Comment
+	Content Content
+}
+
+// Thread pairs a comment of the page with the page content.
+func (c Content) Thread(comment entity.Comment) Thread {
+	return Thread{Comment: comment, Content: c}
+}
+
+// Can reports whether the signed in user has the permission, templates
+// call it as {{if .Can "manage_categories"}}.
+func (c Content) Can(permission entity.Permission) bool {
+	return c.Authorized && c.Role.Can(permission)
+}
+
+// Permissions lists every permission a role may have, for the roles page.
+func (c Content) Permissions() []entity.Permission {
+	return entity.Permissions
+}
+
+// PermissionName is the permission as the roles page shows it.
+func (c Content) PermissionName(permission entity.Permission) string {
+	if name, ok := permissionNames[permission]; ok {
+		return name
+	}
+	return string(permission)
+}
+
+var permissionNames = map[entity.Permission]string{
+	entity.PermManageCategories: "Управление разделами",
+	entity.PermEditAnyPost:      "Редактирование чужих постов",
+	entity.PermDeleteAnyPost:    "Удаление и восстановление постов",
+	entity.PermBanUsers:         "Блокировка пользователей",
+	entity.PermManageUsers:      "Управление пользователями",
+	entity.PermManageBackups:    "Резервные копии",
+	entity.PermManageRoles:      "Управление ролями",
 }
 
 type ErrMessage struct {
@@ -33,13 +103,37 @@ const (
 )
 
 const (
-	UserNotExist          = "Такого пользователя не существует"
-	UserPassWrong         = "Неверный пароль, попробуйте ещё раз"
-	PasswordsNotSame      = "Пароли не совпадают"
-	EmailFormatWrong      = "Неправильный формат почты"
-	UserEmailAlreadyExist = "Пользователь с такой почтой уже существует"
-	UserNameAlreadyExist  = "Пользователь с таким именем уже существует"
-	PostCategoryRequired  = "Выберите хотя бы одну тему"
+	UserNotExist            = "Такого пользователя не существует"
+	UserPassWrong           = "Неверный пароль, попробуйте ещё раз"
+	PasswordsNotSame        = "Пароли не совпадают"
+	EmailFormatWrong        = "Неправильный формат почты"
+	UserEmailAlreadyExist   = "Пользователь с такой почтой уже существует"
+	UserNameAlreadyExist    = "Пользователь с таким именем уже существует"
+	PostCategoryRequired    = "Выберите хотя бы одну тему"
+	TrashRetention          = "Записи стираются навсегда через %d дн. после удаления"
+	BackupsKept             = "Хранятся %d последних копий"
+	BackupExists            = "Копия с таким именем уже есть, попробуйте ещё раз"
+	ForumImported           = "Импортировано пользователей: %d, постов: %d, комментариев: %d, реакций: %d, изображений: %d"
+	TimezoneUnknown         = "Неизвестный часовой пояс"
+	ResetLinkSent           = "Если эта почта зарегистрирована, на неё отправлена ссылка для смены пароля"
+	ResetLinkInvalid        = "Ссылка для смены пароля недействительна или устарела"
+	EmailNotVerified        = "Подтвердите почту, чтобы писать посты, комментарии и ставить реакции"
+	EmailVerified           = "Почта подтверждена"
+	VerificationSent        = "На почту отправлена ссылка для подтверждения"
+	VerificationThrottled   = "Письмо уже отправлено, попробуйте чуть позже"
+	VerificationLinkInvalid = "Ссылка для подтверждения почты недействительна или устарела"
+	OauthEmailNotVerified   = "Аккаунт с этой почтой не подтверждён, войдите с паролем и подтвердите почту"
+	OauthProviderUnverified = "Сервис входа не подтвердил эту почту, войдите с паролем"
+	TwoFactorCodeInvalid    = "Неверный код, попробуйте ещё раз"
+	TwoFactorExpired        = "Время на ввод кода истекло, войдите ещё раз"
+	TwoFactorEnabled        = "Двухфакторная аутентификация включена"
+	TwoFactorDisabled       = "Двухфакторная аутентификация отключена"
+	UserBanned              = "Пользователь заблокирован"
+	BanNotAllowed           = "Нельзя заблокировать пользователя, который сам может блокировать"
+	RoleExists              = "Роль с таким названием уже существует"
+	RoleNameEmpty           = "Введите название роли"
+	RoleBuiltin             = "Встроенную роль нельзя изменить или удалить"
+	LastAdmin               = "Нельзя лишить прав последнего администратора"
 )
 
 const (
@@ -61,15 +155,9 @@ var (
 )
 
 const (
-	QueryPost              = "post"
-	QueryComment           = "comment"
-	QueryLiked             = "liked"
-	QueryDisliked          = "disliked"
-	CommandPutLike         = "like"
-	CommandPutDislike      = "dislike"
-	UpdateQueryInfo        = "info"
-	ReactionMessageLike    = "\"лайк\""
-	ReactionMessageDislike = "\"дизлайк\""
+	QueryPost       = "post"
+	QueryComment    = "comment"
+	UpdateQueryInfo = "info"
 )
 
 const OauthState = "pseudo-random-fs3f#ds38A@f"
@@ -77,6 +165,19 @@ const OauthState = "pseudo-random-fs3f#ds38A@f"
 type OauthContent struct {
 	Email string `json:"email"`
 	Name  string `json:"name"`
+	// providers report that they checked the email under different names:
+	// Google as verified_email, OpenID Connect as email_verified, GitHub
+	// as verified next to primary in the list of emails
+	VerifiedEmail bool `json:"verified_email"`
+	EmailVerified bool `json:"email_verified"`
+	Verified      bool `json:"verified"`
+	Primary       bool `json:"primary"`
+}
+
+// verified tells whether the provider says it checked the email, an
+// email without the claim is not trusted.
+func (c OauthContent) verified() bool {
+	return c.VerifiedEmail || c.EmailVerified || c.Verified
 }
 
 type OauthParams struct {
@@ -0,0 +1,158 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"forum/internal/entity"
+)
+
+// CheckVerified lets only users with a verified email through, it goes
+// after CheckAuth.
+func (h *Handler) CheckVerified(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := r.Context().Value(Key("content")).(Content)
+		if !ok {
+			h.l.WriteLog(fmt.Errorf("v1 - CheckVerified - TypeAssertion:"+
+				"got data of type %T but wanted v1.Content", content))
+			h.Errors(w, http.StatusInternalServerError)
+			return
+		}
+		if !content.Verified {
+			h.errorPage(w, ErrMessage{Code: http.StatusForbidden, Message: EmailNotVerified})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// VerifyEmailHandler follows the link of a verification letter, it works
+// without signing in.
+func (h *Handler) VerifyEmailHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.Errors(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	content, ok := r.Context().Value(Key("content")).(Content)
+	if !ok {
+		h.l.WriteLog(fmt.Errorf("v1 - VerifyEmailHandler - TypeAssertion:"+
+			"got data of type %T but wanted v1.Content", content))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	err := h.Usecases.Verification.Verify(r.Context(), r.URL.Query().Get("token"))
+	switch {
+	case err == nil:
+		content.Message = EmailVerified
+	case errors.Is(err, entity.ErrVerificationInvalid):
+		w.WriteHeader(http.StatusBadRequest)
+		content.ErrorMsg.Message = VerificationLinkInvalid
+	case errors.Is(err, entity.ErrUserEmailAlreadyExists):
+		w.WriteHeader(http.StatusBadRequest)
+		content.ErrorMsg.Message = UserEmailAlreadyExist
+	default:
+		h.l.WriteLog(fmt.Errorf("v1 - VerifyEmailHandler - Verify: %w", err))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	err = h.ParseAndExecute(w, content, "templates/verify_email.html")
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - VerifyEmailHandler - ParseAndExecute - %w", err))
+	}
+}
+
+// ResendVerificationHandler mails the verification link again.
+func (h *Handler) ResendVerificationHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.Errors(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	content, ok := r.Context().Value(Key("content")).(Content)
+	if !ok {
+		h.l.WriteLog(fmt.Errorf("v1 - ResendVerificationHandler - TypeAssertion:"+
+			"got data of type %T but wanted v1.Content", content))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	err := h.Usecases.Verification.SendVerification(r.Context(), content.User.Id)
+	if !h.verificationSent(w, err, &content) {
+		return
+	}
+
+	err = h.ParseAndExecute(w, content, "templates/verify_email.html")
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - ResendVerificationHandler - ParseAndExecute - %w", err))
+	}
+}
+
+// ChangeEmailHandler mails a verification link to the new email, it
+// replaces the current one once the link is followed.
+func (h *Handler) ChangeEmailHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.Errors(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		h.Errors(w, http.StatusBadRequest)
+		return
+	}
+	if len(r.Form["email"]) == 0 {
+		h.Errors(w, http.StatusBadRequest)
+		return
+	}
+
+	content, ok := r.Context().Value(Key("content")).(Content)
+	if !ok {
+		h.l.WriteLog(fmt.Errorf("v1 - ChangeEmailHandler - TypeAssertion:"+
+			"got data of type %T but wanted v1.Content", content))
+		h.Errors(w, http.StatusInternalServerError)
+		return
+	}
+
+	email := r.Form["email"][0]
+	var err error
+	if !checkEmail(email) {
+		w.WriteHeader(http.StatusBadRequest)
+		content.ErrorMsg.Message = EmailFormatWrong
+	} else {
+		err = h.Usecases.Verification.ChangeEmail(r.Context(), content.User.Id, strings.ToLower(email))
+		if errors.Is(err, entity.ErrUserEmailAlreadyExists) {
+			w.WriteHeader(http.StatusBadRequest)
+			content.ErrorMsg.Message = UserEmailAlreadyExist
+		} else if !h.verificationSent(w, err, &content) {
+			return
+		}
+	}
+
+	err = h.ParseAndExecute(w, content, "templates/verify_email.html")
+	if err != nil {
+		h.l.WriteLog(fmt.Errorf("v1 - ChangeEmailHandler - ParseAndExecute - %w", err))
+	}
+}
+
+// verificationSent puts the outcome of sending a verification letter into
+// content, it answers unexpected errors itself and returns false then.
+func (h *Handler) verificationSent(w http.ResponseWriter, err error, content *Content) bool {
+	switch {
+	case err == nil:
+		content.Message = VerificationSent
+	case errors.Is(err, entity.ErrVerificationThrottled):
+		w.WriteHeader(http.StatusTooManyRequests)
+		content.ErrorMsg.Message = VerificationThrottled
+	case errors.Is(err, entity.ErrEmailAlreadyVerified):
+		content.Message = EmailVerified
+	default:
+		h.l.WriteLog(fmt.Errorf("v1 - verificationSent: %w", err))
+		h.Errors(w, http.StatusInternalServerError)
+		return false
+	}
+	return true
+}
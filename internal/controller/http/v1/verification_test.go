@@ -0,0 +1,185 @@
+package v1_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"forum/internal/entity"
+	mu "forum/internal/usecase/mock"
+)
+
+func TestCheckVerified(t *testing.T) {
+	ctx := context.Background()
+	handler := setup()
+	users := handler.Usecases.Users.(*mu.UsersMockUseCase)
+	if err := users.SignUp(ctx, entity.User{}); err != nil {
+		t.Fatal(err)
+	}
+	users.Unverified = true
+
+	for _, path := range []string{"/create_post_page", "/create_comment_page/1", "/create_category_page"} {
+		t.Run("err unverified "+path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			req.AddCookie(&http.Cookie{Name: "session_token"})
+
+			handler.Mux.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusForbidden {
+				t.Fatalf("want: %v, got: %v", http.StatusForbidden, rec.Code)
+			}
+		})
+	}
+
+	t.Run("OK profile", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/sessions", nil)
+		req.AddCookie(&http.Cookie{Name: "session_token"})
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("want: %v, got: %v", http.StatusOK, rec.Code)
+		}
+	})
+}
+
+func TestVerifyEmailHandler(t *testing.T) {
+	handler := setup()
+	verification := handler.Usecases.Verification.(*mu.VerificationMockUseCase)
+
+	t.Run("err invalid token", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/verify_email?token=forged", nil)
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("want: %v, got: %v", http.StatusBadRequest, rec.Code)
+		}
+		if verification.Verified {
+			t.Fatal("want email unverified")
+		}
+	})
+
+	t.Run("OK", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/verify_email?token="+mu.ValidToken, nil)
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("want: %v, got: %v", http.StatusOK, rec.Code)
+		}
+		if !verification.Verified {
+			t.Fatal("want email verified")
+		}
+	})
+}
+
+func TestResendVerificationHandler(t *testing.T) {
+	ctx := context.Background()
+	handler := setup()
+	verification := handler.Usecases.Verification.(*mu.VerificationMockUseCase)
+
+	t.Run("err not authorized", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/resend_verification", nil)
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusUnauthorized {
+			t.Fatalf("want: %v, got: %v", http.StatusUnauthorized, rec.Code)
+		}
+	})
+
+	if err := handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/resend_verification", nil)
+		req.AddCookie(&http.Cookie{Name: "session_token"})
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("want: %v, got: %v", http.StatusOK, rec.Code)
+		}
+		if want := []int64{1}; !reflect.DeepEqual(verification.Sent, want) {
+			t.Fatalf("want: %v, got: %v", want, verification.Sent)
+		}
+	})
+
+	t.Run("err throttled", func(t *testing.T) {
+		verification.Throttled = true
+		defer func() { verification.Throttled = false }()
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/resend_verification", nil)
+		req.AddCookie(&http.Cookie{Name: "session_token"})
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusTooManyRequests {
+			t.Fatalf("want: %v, got: %v", http.StatusTooManyRequests, rec.Code)
+		}
+	})
+
+	t.Run("err wrong method", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/resend_verification", nil)
+		req.AddCookie(&http.Cookie{Name: "session_token"})
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Fatalf("want: %v, got: %v", http.StatusMethodNotAllowed, rec.Code)
+		}
+	})
+}
+
+func TestChangeEmailHandler(t *testing.T) {
+	ctx := context.Background()
+	handler := setup()
+	verification := handler.Usecases.Verification.(*mu.VerificationMockUseCase)
+	if err := handler.Usecases.Users.SignUp(ctx, entity.User{}); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/change_email", nil)
+		req.AddCookie(&http.Cookie{Name: "session_token"})
+		req.PostForm = url.Values{"email": {"New@mail.ru"}}
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("want: %v, got: %v", http.StatusOK, rec.Code)
+		}
+		if want := []string{"new@mail.ru"}; !reflect.DeepEqual(verification.Emails, want) {
+			t.Fatalf("want: %v, got: %v", want, verification.Emails)
+		}
+	})
+
+	t.Run("err wrong email", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/change_email", nil)
+		req.AddCookie(&http.Cookie{Name: "session_token"})
+		req.PostForm = url.Values{"email": {"New"}}
+
+		handler.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("want: %v, got: %v", http.StatusBadRequest, rec.Code)
+		}
+		if len(verification.Emails) != 1 {
+			t.Fatalf("want no letter, got: %v", verification.Emails)
+		}
+	})
+}
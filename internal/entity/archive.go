@@ -0,0 +1,98 @@
+package entity
+
+import "time"
+
+// ArchiveVersion is the version of the archive format written by export,
+// import refuses archives of other versions. Times are in RFC 3339.
+const ArchiveVersion = 5
+
+// Archive is the portable form of a whole forum kept in forum.json of an
+// export. Ids are those of the exporting forum, import gives every record
+// a new one. Image fields name files in the images directory of the
+// export, images of posts and comments are listed in upload order.
+type Archive struct {
+	Version    int                `json:"version"`
+	ExportedAt time.Time          `json:"exported_at"`
+	Roles      []ArchivedRole     `json:"roles"`
+	Users      []ArchivedUser     `json:"users"`
+	Categories []ArchivedCategory `json:"categories"`
+	Posts      []ArchivedPost     `json:"posts"`
+	Comments   []ArchivedComment  `json:"comments"`
+	Reactions  []Reaction         `json:"reactions"`
+}
+
+// ArchivedRole is taken over by name on import, roles the forum has
+// already keep their permissions.
+type ArchivedRole struct {
+	Name        string       `json:"name"`
+	Permissions []Permission `json:"permissions,omitempty"`
+}
+
+// ArchivedUser leaves Password empty unless password hashes are exported.
+// Role is the name of one of the archived roles.
+type ArchivedUser struct {
+	Id          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Email       string    `json:"email"`
+	Verified    bool      `json:"email_verified,omitempty"`
+	Password    string    `json:"password,omitempty"`
+	RegDate     time.Time `json:"reg_date"`
+	DateOfBirth string    `json:"date_of_birth,omitempty"`
+	City        string    `json:"city,omitempty"`
+	Gender      string    `json:"gender,omitempty"`
+	Role        string    `json:"role,omitempty"`
+	Banned      bool      `json:"banned,omitempty"`
+	Sign        string    `json:"sign,omitempty"`
+	Timezone    string    `json:"timezone,omitempty"`
+	Avatar      string    `json:"avatar,omitempty"`
+}
+
+// ArchivedCategory lists parents before their children.
+type ArchivedCategory struct {
+	Id          int64  `json:"id"`
+	ParentId    int64  `json:"parent_id,omitempty"`
+	Name        string `json:"name"`
+	Slug        string `json:"slug"`
+	Description string `json:"description,omitempty"`
+	Position    int    `json:"position,omitempty"`
+	Color       string `json:"color,omitempty"`
+}
+
+type ArchivedPost struct {
+	Id           int64      `json:"id"`
+	UserId       int64      `json:"user_id"`
+	Date         time.Time  `json:"date"`
+	Title        string     `json:"title"`
+	Content      string     `json:"content"`
+	Images       []string   `json:"images,omitempty"`
+	Categories   []int64    `json:"categories"`
+	EditedAt     *time.Time `json:"edited_at,omitempty"`
+	DeletedAt    *time.Time `json:"deleted_at,omitempty"`
+	DeletedBy    int64      `json:"deleted_by,omitempty"`
+	DeleteReason string     `json:"delete_reason,omitempty"`
+}
+
+// ArchivedComment lists replies after their parents.
+type ArchivedComment struct {
+	Id           int64      `json:"id"`
+	PostId       int64      `json:"post_id"`
+	ParentId     int64      `json:"parent_id,omitempty"`
+	UserId       int64      `json:"user_id"`
+	Date         time.Time  `json:"date"`
+	Content      string     `json:"content"`
+	Images       []string   `json:"images,omitempty"`
+	EditedAt     *time.Time `json:"edited_at,omitempty"`
+	DeletedAt    *time.Time `json:"deleted_at,omitempty"`
+	DeletedBy    int64      `json:"deleted_by,omitempty"`
+	DeleteReason string     `json:"delete_reason,omitempty"`
+}
+
+// ImportSummary counts the records an import created. Users already on
+// the forum, matched by email, are taken over and not counted.
+type ImportSummary struct {
+	Users     int
+	Posts     int
+	Comments  int
+	Reactions int
+	Images    int
+}
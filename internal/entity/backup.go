@@ -0,0 +1,11 @@
+package entity
+
+import "time"
+
+// Backup is a snapshot of the database kept in the backup directory, Name
+// is its file name there.
+type Backup struct {
+	Name string
+	Date time.Time
+	Size int64
+}
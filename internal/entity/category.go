@@ -0,0 +1,24 @@
+package entity
+
+import "time"
+
+// Category groups posts. Categories nest under the category of ParentId,
+// zero for top level ones, and siblings are ordered by Position and then
+// by Name. Slug names the category in links, Color is a CSS color of its
+// badge.
+type Category struct {
+	Id          int64
+	ParentId    int64
+	Name        string
+	Slug        string
+	Description string
+	Position    int
+	Color       string
+	// Depth counts the ancestors of the category in a listed tree.
+	Depth int
+	// Posts and Comments count live posts of the category and their
+	// comments, LastActivity is the date of the latest of them.
+	Posts        int64
+	Comments     int64
+	LastActivity time.Time
+}
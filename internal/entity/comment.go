@@ -1,15 +1,35 @@
 package entity
 
+import "time"
+
+// Comment is a comment on a post. Replies have ParentId set, top level
+// comments leave it 0. Listings nest replies under their parents in
+// Replies, Depth counts the levels from the top. Images are paths of the
+// attached images in upload order.
 type Comment struct {
-	Id            int64
-	PostId        int64
-	User          User
-	Date          string
-	Content       string
-	ImagePath     string
-	ContentWeb    []string
-	Likes         []Reaction
-	TotalLikes    int64
-	Dislikes      []Reaction
-	TotalDislikes int64
+	Id           int64
+	PostId       int64
+	ParentId     int64
+	User         User
+	Date         time.Time
+	Content      string
+	Images       []string
+	ContentWeb   []string
+	Reactions    []ReactionCount
+	EditedAt     time.Time
+	DeletedAt    time.Time
+	DeletedBy    int64
+	DeleteReason string
+	Replies      []Comment
+	Depth        int
+}
+
+// IsEdited reports whether the comment was changed after it was written.
+func (c Comment) IsEdited() bool {
+	return !c.EditedAt.IsZero()
+}
+
+// IsDeleted reports whether the comment is in the trash.
+func (c Comment) IsDeleted() bool {
+	return !c.DeletedAt.IsZero()
 }
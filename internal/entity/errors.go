@@ -5,8 +5,40 @@ import "errors"
 var (
 	ErrUserNotFound           = errors.New("user doesn't exist")
 	ErrPostNotFound           = errors.New("posts wasn't found")
+	ErrCommentNotFound        = errors.New("comment wasn't found")
+	ErrRevisionNotFound       = errors.New("revision wasn't found")
+	ErrSessionNotFound        = errors.New("session wasn't found")
+	ErrCategoryNotFound       = errors.New("category wasn't found")
+	ErrCategoryExists         = errors.New("category with such name already exists")
+	ErrCategoryNameEmpty      = errors.New("category name is empty")
+	ErrCategoryMergeSelf      = errors.New("category can't be merged into itself")
 	ErrUserEmailAlreadyExists = errors.New("user with such email already exists")
 	ErrUserNameAlreadyExists  = errors.New("user with such name already exists")
 	ErrUserPasswordIncorrect  = errors.New("password is incorrect")
 	ErrUserEmailIncorrect     = errors.New("email is incorrect")
+	ErrUnknownReaction        = errors.New("unknown reaction kind")
+	ErrUnknownTimezone        = errors.New("unknown timezone")
+	ErrBackupNotFound         = errors.New("backup wasn't found")
+	ErrBackupInvalid          = errors.New("backup isn't a forum database")
+	ErrBackupUnsupported      = errors.New("database driver doesn't support backups")
+	ErrBackupExists           = errors.New("backup with such name already exists")
+	ErrArchiveInvalid         = errors.New("file isn't a forum export")
+	ErrArchiveVersion         = errors.New("unsupported forum export version")
+	ErrResetTokenInvalid      = errors.New("password reset link is invalid or expired")
+	ErrVerificationInvalid    = errors.New("verification link is invalid or expired")
+	ErrVerificationThrottled  = errors.New("verification letter was sent too recently")
+	ErrEmailAlreadyVerified   = errors.New("email is already verified")
+	ErrTwoFactorRequired      = errors.New("second factor is required")
+	ErrTwoFactorCodeInvalid   = errors.New("two-factor code is invalid")
+	ErrTwoFactorEnabled       = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorDisabled      = errors.New("two-factor authentication is not enabled")
+	ErrChallengeInvalid       = errors.New("sign-in challenge is invalid or expired")
+	ErrRoleNotFound           = errors.New("role wasn't found")
+	ErrRoleExists             = errors.New("role with such name already exists")
+	ErrRoleNameEmpty          = errors.New("role name is empty")
+	ErrRoleBuiltin            = errors.New("built-in role can't be changed this way")
+	ErrUnknownPermission      = errors.New("unknown permission")
+	ErrLastAdmin              = errors.New("the last admin can't lose the role")
+	ErrUserBanned             = errors.New("user is banned")
+	ErrBanNotAllowed          = errors.New("user can't be banned")
 )
@@ -0,0 +1,37 @@
+package entity
+
+// Page describes one page of a listing. Number is 1-based and is 0 when
+// the listing was requested by cursor. NextCursor is the id to continue
+// a keyset listing after, 0 when there is nothing left.
+type Page struct {
+	Number     int
+	Size       int
+	Total      int64
+	NextCursor int64
+}
+
+func (p Page) Pages() int {
+	if p.Size <= 0 || p.Total == 0 {
+		return 1
+	}
+	return int((p.Total + int64(p.Size) - 1) / int64(p.Size))
+}
+
+func (p Page) HasPrev() bool {
+	return p.Number > 1
+}
+
+func (p Page) HasNext() bool {
+	if p.Number == 0 {
+		return p.NextCursor != 0
+	}
+	return p.Number < p.Pages()
+}
+
+func (p Page) Prev() int {
+	return p.Number - 1
+}
+
+func (p Page) Next() int {
+	return p.Number + 1
+}
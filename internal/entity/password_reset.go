@@ -0,0 +1,13 @@
+package entity
+
+import "time"
+
+// PasswordReset lets a user who forgot their password set a new one. Only
+// the hash of its token is stored, the token itself is mailed.
+type PasswordReset struct {
+	Id        int64
+	User      User
+	TokenHash string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
@@ -1,25 +1,35 @@
 package entity
 
+import "time"
+
+// Post is a post of a user. Images are paths of its attached images in
+// upload order.
 type Post struct {
 	Id               int64
 	User             User
-	Date             string
+	Date             time.Time
 	Title            string
 	Content          string
-	ImagePath        string
+	Images           []string
 	ContentWeb       []string
-	Categories       []string
+	Categories       []Category
 	Comments         []Comment
 	LastComment      Comment
 	LastCommentExist bool
 	TotalComments    int64
-	Likes            []Reaction
-	TotalLikes       int64
-	Dislikes         []Reaction
-	TotalDislikes    int64
+	Reactions        []ReactionCount
+	EditedAt         time.Time
+	DeletedAt        time.Time
+	DeletedBy        int64
+	DeleteReason     string
+}
+
+// IsEdited reports whether the post was changed after it was written.
+func (p Post) IsEdited() bool {
+	return !p.EditedAt.IsZero()
 }
 
-type Reaction struct {
-	UserId int64  `json:"user_id,omitempty"`
-	Date   string `json:"reaction_date,omitempty"`
+// IsDeleted reports whether the post is in the trash.
+func (p Post) IsDeleted() bool {
+	return !p.DeletedAt.IsZero()
 }
@@ -0,0 +1,75 @@
+package entity
+
+import "time"
+
+// Targets a reaction can be left on.
+const (
+	ReactionTargetPost    = "post"
+	ReactionTargetComment = "comment"
+)
+
+// Reaction is one user's reaction of one kind on a post or a comment.
+type Reaction struct {
+	Target   string    `json:"target,omitempty"`
+	TargetId int64     `json:"target_id,omitempty"`
+	UserId   int64     `json:"user_id,omitempty"`
+	Kind     string    `json:"kind,omitempty"`
+	Date     time.Time `json:"reaction_date"`
+}
+
+// ReactionKind describes a reaction users can leave. Kinds sharing a
+// non-empty Group exclude each other: putting one removes the others.
+type ReactionKind struct {
+	Name  string `json:"name"`
+	Emoji string `json:"emoji"`
+	Group string `json:"group,omitempty"`
+}
+
+// ReactionKinds is the ordered set of kinds offered on the forum.
+type ReactionKinds []ReactionKind
+
+var DefaultReactionKinds = ReactionKinds{
+	{Name: "like", Emoji: "👍", Group: "vote"},
+	{Name: "dislike", Emoji: "👎", Group: "vote"},
+	{Name: "heart", Emoji: "❤️"},
+	{Name: "laugh", Emoji: "😂"},
+	{Name: "wow", Emoji: "😮"},
+	{Name: "sad", Emoji: "😢"},
+}
+
+func (ks ReactionKinds) Find(name string) (ReactionKind, bool) {
+	for _, kind := range ks {
+		if kind.Name == name {
+			return kind, true
+		}
+	}
+	return ReactionKind{}, false
+}
+
+// Excluded returns the names of the kinds removed when kind is put.
+func (ks ReactionKinds) Excluded(kind ReactionKind) []string {
+	var names []string
+	if kind.Group == "" {
+		return names
+	}
+	for _, other := range ks {
+		if other.Group == kind.Group && other.Name != kind.Name {
+			names = append(names, other.Name)
+		}
+	}
+	return names
+}
+
+// Counts lists every kind in order with its count taken from byKind.
+func (ks ReactionKinds) Counts(byKind map[string]int64) []ReactionCount {
+	counts := make([]ReactionCount, 0, len(ks))
+	for _, kind := range ks {
+		counts = append(counts, ReactionCount{Kind: kind, Count: byKind[kind.Name]})
+	}
+	return counts
+}
+
+type ReactionCount struct {
+	Kind  ReactionKind
+	Count int64
+}
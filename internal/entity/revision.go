@@ -0,0 +1,53 @@
+package entity
+
+import "time"
+
+// Targets a revision can belong to.
+const (
+	RevisionTargetPost    = "post"
+	RevisionTargetComment = "comment"
+)
+
+// Revision is one version of a post or a comment. User wrote the version,
+// Number counts versions of the target from 1, the original text. Comments
+// leave Title and Categories empty.
+type Revision struct {
+	Id         int64
+	Target     string
+	TargetId   int64
+	Number     int
+	User       User
+	Date       time.Time
+	Title      string
+	Content    string
+	Categories []string
+}
+
+// Diff ops of a DiffLine.
+const (
+	DiffSame    = ' '
+	DiffAdded   = '+'
+	DiffRemoved = '-'
+)
+
+type DiffLine struct {
+	Op   rune
+	Text string
+}
+
+func (l DiffLine) Added() bool {
+	return l.Op == DiffAdded
+}
+
+func (l DiffLine) Removed() bool {
+	return l.Op == DiffRemoved
+}
+
+// Diff holds the line changes turning From into To. TooLarge tells that
+// the versions differ in too many lines to diff, Lines is empty then.
+type Diff struct {
+	From     Revision
+	To       Revision
+	Lines    []DiffLine
+	TooLarge bool
+}
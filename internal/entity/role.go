@@ -0,0 +1,79 @@
+package entity
+
+// Permission names an action not every user may take.
+type Permission string
+
+const (
+	// PermManageCategories is creating, renaming, merging, deleting and
+	// reordering categories.
+	PermManageCategories Permission = "manage_categories"
+	// PermEditAnyPost is editing posts and comments of other users.
+	PermEditAnyPost Permission = "edit_any_post"
+	// PermDeleteAnyPost is deleting and restoring posts and comments of
+	// any user and looking into the trash.
+	PermDeleteAnyPost Permission = "delete_any_post"
+	// PermBanUsers is banning users who can't ban themselves.
+	PermBanUsers Permission = "ban_users"
+	// PermManageUsers is editing profiles of other users and resetting
+	// their two-factor authentication.
+	PermManageUsers Permission = "manage_users"
+	// PermManageBackups is making and downloading backups, exporting and
+	// importing the forum.
+	PermManageBackups Permission = "manage_backups"
+	// PermManageRoles is creating roles and giving them to users.
+	PermManageRoles Permission = "manage_roles"
+)
+
+// Permissions lists every permission in the order they are shown.
+var Permissions = []Permission{
+	PermManageCategories,
+	PermEditAnyPost,
+	PermDeleteAnyPost,
+	PermBanUsers,
+	PermManageUsers,
+	PermManageBackups,
+	PermManageRoles,
+}
+
+// Built-in roles. New users get RoleUserId, the admin role has every
+// permission and can't be changed, so the forum always has someone to
+// manage it.
+const (
+	RoleUserId      int64 = 1
+	RoleModeratorId int64 = 2
+	RoleAdminId     int64 = 3
+)
+
+// Role is a named set of permissions, every user has one. Permissions is
+// only filled where they are checked or shown, elsewhere a role is just
+// its Id and Name.
+type Role struct {
+	Id          int64
+	Name        string
+	Permissions []Permission
+}
+
+// Can tells whether the role has the permission.
+func (r Role) Can(permission Permission) bool {
+	for _, p := range r.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Builtin roles can't be deleted.
+func (r Role) Builtin() bool {
+	return r.Id == RoleUserId || r.Id == RoleModeratorId || r.Id == RoleAdminId
+}
+
+// KnownPermission tells whether the permission is one of Permissions.
+func KnownPermission(permission Permission) bool {
+	for _, p := range Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
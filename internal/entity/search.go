@@ -0,0 +1,47 @@
+package entity
+
+import "strings"
+
+// Snippet markers wrap matched terms inside SearchResult.Snippet. Control
+// characters never appear in user content, so they can't be forged.
+const (
+	MatchStart = "\x02"
+	MatchEnd   = "\x03"
+)
+
+type SearchResult struct {
+	Post    Post
+	Rank    float64
+	Snippet string
+}
+
+type SnippetPart struct {
+	Text  string
+	Match bool
+}
+
+// Highlighted splits the snippet into plain and matched parts, so templates
+// can wrap matches in <mark> and still escape the text.
+func (sr SearchResult) Highlighted() []SnippetPart {
+	var parts []SnippetPart
+	rest := sr.Snippet
+	for rest != "" {
+		start := strings.Index(rest, MatchStart)
+		if start < 0 {
+			parts = append(parts, SnippetPart{Text: rest})
+			break
+		}
+		if start > 0 {
+			parts = append(parts, SnippetPart{Text: rest[:start]})
+		}
+		rest = rest[start+len(MatchStart):]
+
+		end := strings.Index(rest, MatchEnd)
+		if end < 0 {
+			end = len(rest)
+		}
+		parts = append(parts, SnippetPart{Text: rest[:end], Match: true})
+		rest = strings.TrimPrefix(rest[end:], MatchEnd)
+	}
+	return parts
+}
@@ -0,0 +1,18 @@
+package entity
+
+import "time"
+
+// Session is a signed in browser of a user, a user has one for every
+// device they signed in from. LastSeen and ExpiresAt move on with every
+// request, Current marks the session a listing was asked from.
+type Session struct {
+	Id        int64
+	User      User
+	Token     string
+	UserAgent string
+	IP        string
+	CreatedAt time.Time
+	LastSeen  time.Time
+	ExpiresAt time.Time
+	Current   bool
+}
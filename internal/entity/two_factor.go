@@ -0,0 +1,33 @@
+package entity
+
+import "time"
+
+// TwoFactor is the TOTP state of a user. Secret is set when enrollment
+// starts, codes are asked for at sign-in only once Enabled. LastStep is the
+// time step of the last code taken, a code is never taken twice.
+type TwoFactor struct {
+	UserId        int64
+	Secret        string
+	Enabled       bool
+	LastStep      int64
+	RecoveryCodes int
+}
+
+// LoginChallenge is a sign-in that passed the password and waits for the
+// second factor. Only the hash of its token is stored, the token itself is
+// in the code form.
+type LoginChallenge struct {
+	Id        int64
+	User      User
+	TokenHash string
+	Attempts  int
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// TwoFactorEnrollment is what an authenticator app needs, URI is shown as a
+// QR code and Secret is for typing in by hand.
+type TwoFactorEnrollment struct {
+	Secret string
+	URI    string
+}
@@ -2,27 +2,32 @@ package entity
 
 import "time"
 
+// User is verified once they followed the link mailed to Email. A new
+// email waits in PendingEmail until its own link is followed. Banned users
+// can't sign in.
 type User struct {
-	Id              int64
-	Name            string
-	Email           string
-	Password        string
-	RegDate         string
-	DateOfBirth     string
-	City            string
-	Owner           bool
-	Gender          string
-	Male            bool
-	Female          bool
-	Role            string
-	AvatarPath      string
-	Sign            string
-	SessionToken    string
-	SessionTTL      time.Time
-	Posts           int64
-	Comments        int64
-	PostLikes       int64
-	PostDislikes    int64
-	CommentLikes    int64
-	CommentDislikes int64
+	Id                 int64
+	Name               string
+	Email              string
+	EmailVerified      bool
+	PendingEmail       string
+	VerificationSentAt time.Time
+	TwoFactor          bool
+	Banned             bool
+	Password           string
+	RegDate            time.Time
+	DateOfBirth        string
+	City               string
+	Owner              bool
+	Gender             string
+	Male               bool
+	Female             bool
+	Role               Role
+	AvatarPath         string
+	Sign               string
+	Timezone           string
+	Posts              int64
+	Comments           int64
+	PostReactions      []ReactionCount
+	CommentReactions   []ReactionCount
 }
@@ -0,0 +1,213 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"forum/internal/entity"
+)
+
+type CategoriesRepo struct {
+	*DB
+}
+
+func NewCategoriesRepo(db *DB) *CategoriesRepo {
+	return &CategoriesRepo{db}
+}
+
+func (cr *CategoriesRepo) Store(ctx context.Context, category *entity.Category) error {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	for _, existed := range cr.categories {
+		if existed.name == category.Name {
+			return fmt.Errorf("CategoriesRepo - Store - %w", uniqueErr("topics", "name"))
+		}
+		if existed.slug == category.Slug {
+			return fmt.Errorf("CategoriesRepo - Store - %w", uniqueErr("topics", "slug"))
+		}
+	}
+
+	cr.lastCategoryId++
+	category.Id = cr.lastCategoryId
+	cr.categories = append(cr.categories, categoryRow{
+		id:          category.Id,
+		parentId:    category.ParentId,
+		name:        category.Name,
+		slug:        category.Slug,
+		description: category.Description,
+		position:    category.Position,
+		color:       category.Color,
+	})
+
+	return nil
+}
+
+// Fetch lists every category with the counts of its live posts and their
+// comments and the date of the latest of them.
+func (cr *CategoriesRepo) Fetch(ctx context.Context) ([]entity.Category, error) {
+	cr.mu.RLock()
+	defer cr.mu.RUnlock()
+
+	var categories []entity.Category
+	for _, row := range cr.sortedCategories() {
+		category := row.toEntity()
+		for _, ref := range cr.topicRefs {
+			i := cr.findPost(ref.postId)
+			if ref.categoryId != row.id || i < 0 || !cr.posts[i].deletedAt.IsZero() {
+				continue
+			}
+			category.Posts++
+			if cr.posts[i].date.After(category.LastActivity) {
+				category.LastActivity = cr.posts[i].date
+			}
+			for _, comment := range cr.comments {
+				if comment.postId != ref.postId || !comment.deletedAt.IsZero() {
+					continue
+				}
+				category.Comments++
+				if comment.date.After(category.LastActivity) {
+					category.LastActivity = comment.date
+				}
+			}
+		}
+		categories = append(categories, category)
+	}
+
+	return categories, nil
+}
+
+func (cr *CategoriesRepo) GetById(ctx context.Context, id int64) (entity.Category, error) {
+	cr.mu.RLock()
+	defer cr.mu.RUnlock()
+
+	i := cr.findCategory(id)
+	if i < 0 {
+		return entity.Category{}, fmt.Errorf("CategoriesRepo - GetById - %w", errNoRows)
+	}
+	return cr.categories[i].toEntity(), nil
+}
+
+func (cr *CategoriesRepo) GetBySlug(ctx context.Context, slug string) (entity.Category, error) {
+	cr.mu.RLock()
+	defer cr.mu.RUnlock()
+
+	for _, row := range cr.categories {
+		if row.slug == slug {
+			return row.toEntity(), nil
+		}
+	}
+	return entity.Category{}, fmt.Errorf("CategoriesRepo - GetBySlug - %w", errNoRows)
+}
+
+// Update overwrites every field of the category but its Id.
+func (cr *CategoriesRepo) Update(ctx context.Context, category entity.Category) error {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	i := cr.findCategory(category.Id)
+	if i < 0 {
+		return fmt.Errorf("CategoriesRepo - Update - %w", errNoRows)
+	}
+	for _, existed := range cr.categories {
+		if existed.id == category.Id {
+			continue
+		}
+		if existed.name == category.Name {
+			return fmt.Errorf("CategoriesRepo - Update - %w", uniqueErr("topics", "name"))
+		}
+		if existed.slug == category.Slug {
+			return fmt.Errorf("CategoriesRepo - Update - %w", uniqueErr("topics", "slug"))
+		}
+	}
+
+	cr.categories[i] = categoryRow{
+		id:          category.Id,
+		parentId:    category.ParentId,
+		name:        category.Name,
+		slug:        category.Slug,
+		description: category.Description,
+		position:    category.Position,
+		color:       category.Color,
+	}
+
+	return nil
+}
+
+// MoveReferences moves the posts of the category fromId to the category
+// toId, posts already there are referenced once. The references are
+// dropped when toId is zero.
+func (cr *CategoriesRepo) MoveReferences(ctx context.Context, fromId, toId int64) error {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	referenced := make(map[int64]bool)
+	for _, ref := range cr.topicRefs {
+		if ref.categoryId == toId {
+			referenced[ref.postId] = true
+		}
+	}
+	refs := cr.topicRefs[:0]
+	for _, ref := range cr.topicRefs {
+		if ref.categoryId == fromId {
+			if toId == 0 || referenced[ref.postId] {
+				continue
+			}
+			ref.categoryId = toId
+			referenced[ref.postId] = true
+		}
+		refs = append(refs, ref)
+	}
+	cr.topicRefs = refs
+
+	return nil
+}
+
+// Delete removes the category with its post references. Its children
+// have to be moved beforehand.
+func (cr *CategoriesRepo) Delete(ctx context.Context, id int64) error {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	i := cr.findCategory(id)
+	if i < 0 {
+		return fmt.Errorf("CategoriesRepo - Delete - %w", errNoRows)
+	}
+	cr.categories = append(cr.categories[:i], cr.categories[i+1:]...)
+
+	refs := cr.topicRefs[:0]
+	for _, ref := range cr.topicRefs {
+		if ref.categoryId != id {
+			refs = append(refs, ref)
+		}
+	}
+	cr.topicRefs = refs
+
+	return nil
+}
+
+// sortedCategories orders categories by position and name as the sql
+// backends do.
+func (db *DB) sortedCategories() []categoryRow {
+	rows := append([]categoryRow(nil), db.categories...)
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].position != rows[j].position {
+			return rows[i].position < rows[j].position
+		}
+		return rows[i].name < rows[j].name
+	})
+	return rows
+}
+
+func (row categoryRow) toEntity() entity.Category {
+	return entity.Category{
+		Id:          row.id,
+		ParentId:    row.parentId,
+		Name:        row.name,
+		Slug:        row.slug,
+		Description: row.description,
+		Position:    row.position,
+		Color:       row.color,
+	}
+}
@@ -0,0 +1,243 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"forum/internal/entity"
+)
+
+type CommentsRepo struct {
+	*DB
+}
+
+func NewCommentsRepo(db *DB) *CommentsRepo {
+	return &CommentsRepo{db}
+}
+
+func (cr *CommentsRepo) Store(ctx context.Context, comment *entity.Comment) error {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	cr.lastCommentId++
+	cr.comments = append(cr.comments, commentRow{
+		id:       cr.lastCommentId,
+		postId:   comment.PostId,
+		parentId: comment.ParentId,
+		userId:   comment.User.Id,
+		date:     storedTime(comment.Date),
+		content:  comment.Content,
+	})
+
+	comment.Id = cr.lastCommentId
+
+	for _, path := range comment.Images {
+		cr.images = append(cr.images, imageRow{commentId: comment.Id, path: path})
+	}
+
+	return nil
+}
+
+// Fetch lists every comment of the post, replies included, oldest first.
+func (cr *CommentsRepo) Fetch(ctx context.Context, postId int64) ([]entity.Comment, error) {
+	cr.mu.RLock()
+	defer cr.mu.RUnlock()
+
+	var comments []entity.Comment
+	for _, row := range cr.postComments(postId, false) {
+		comments = append(comments, cr.toCommentWithImages(row))
+	}
+
+	return comments, nil
+}
+
+func (cr *CommentsRepo) FetchByPosts(ctx context.Context, postIds []int64) (map[int64][]entity.Comment, error) {
+	cr.mu.RLock()
+	defer cr.mu.RUnlock()
+
+	byPost := make(map[int64][]entity.Comment, len(postIds))
+	for _, id := range postIds {
+		for _, row := range cr.postComments(id, false) {
+			byPost[id] = append(byPost[id], cr.toCommentWithImages(row))
+		}
+	}
+
+	return byPost, nil
+}
+
+// FetchPage lists top level comments of the post, replies are left to
+// FetchReplies.
+func (cr *CommentsRepo) FetchPage(ctx context.Context, postId int64, limit, offset int) ([]entity.Comment, error) {
+	cr.mu.RLock()
+	defer cr.mu.RUnlock()
+
+	var comments []entity.Comment
+	rows := cr.postComments(postId, true)
+	from, to := window(len(rows), limit, offset)
+	for _, row := range rows[from:to] {
+		comments = append(comments, cr.toCommentWithImages(row))
+	}
+
+	return comments, nil
+}
+
+// FetchAfter lists top level comments of the post with ids above cursor.
+func (cr *CommentsRepo) FetchAfter(ctx context.Context, postId, cursor int64, limit int) ([]entity.Comment, error) {
+	cr.mu.RLock()
+	defer cr.mu.RUnlock()
+
+	var comments []entity.Comment
+	for _, row := range cr.postComments(postId, true) {
+		if len(comments) == limit {
+			break
+		}
+		if row.id > cursor {
+			comments = append(comments, cr.toCommentWithImages(row))
+		}
+	}
+
+	return comments, nil
+}
+
+// Count counts top level comments of the post.
+func (cr *CommentsRepo) Count(ctx context.Context, postId int64) (int64, error) {
+	cr.mu.RLock()
+	defer cr.mu.RUnlock()
+
+	return int64(len(cr.postComments(postId, true))), nil
+}
+
+// postComments lists rows of the post, only the top level ones when
+// topLevel is set.
+// FetchReplies lists the replies under the comments at any depth, oldest
+// first.
+func (cr *CommentsRepo) FetchReplies(ctx context.Context, parentIds []int64) ([]entity.Comment, error) {
+	cr.mu.RLock()
+	defer cr.mu.RUnlock()
+
+	inThread := make(map[int64]bool, len(parentIds))
+	for _, id := range parentIds {
+		inThread[id] = true
+	}
+	var rows []commentRow
+	// replies are written after their parents, one pass in id order finds
+	// the whole threads
+	sorted := append([]commentRow(nil), cr.comments...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].id < sorted[j].id })
+	for _, row := range sorted {
+		if row.parentId != 0 && inThread[row.parentId] {
+			inThread[row.id] = true
+			rows = append(rows, row)
+		}
+	}
+
+	var comments []entity.Comment
+	for _, row := range rows {
+		comments = append(comments, cr.toCommentWithImages(row))
+	}
+	return comments, nil
+}
+
+func (cr *CommentsRepo) postComments(postId int64, topLevel bool) []commentRow {
+	var rows []commentRow
+	for _, row := range cr.comments {
+		if row.postId == postId && (!topLevel || row.parentId == 0) {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+func (cr *CommentsRepo) toCommentWithImages(row commentRow) entity.Comment {
+	comment := cr.toEntity(row)
+	comment.Images = cr.imagePaths(func(image imageRow) bool {
+		return image.commentId == row.id
+	})
+	return comment
+}
+
+func (cr *CommentsRepo) GetById(ctx context.Context, commentId int64) (entity.Comment, error) {
+	cr.mu.RLock()
+	defer cr.mu.RUnlock()
+
+	i := cr.findComment(commentId)
+	if i < 0 {
+		return entity.Comment{}, fmt.Errorf("CommentsRepo - GetById - %w", errNoRows)
+	}
+
+	return cr.toCommentWithImages(cr.comments[i]), nil
+}
+
+// Update overwrites the content of the comment and marks it edited at
+// comment.EditedAt. Images are replaced unless they are nil.
+func (cr *CommentsRepo) Update(ctx context.Context, comment entity.Comment) error {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	i := cr.findComment(comment.Id)
+	if i < 0 {
+		return fmt.Errorf("CommentsRepo - Update - %w", errNoRows)
+	}
+	cr.comments[i].content = comment.Content
+	cr.comments[i].editedAt = storedTime(comment.EditedAt)
+	if comment.Images != nil {
+		rows := make([]imageRow, 0, len(comment.Images))
+		for _, path := range comment.Images {
+			rows = append(rows, imageRow{commentId: comment.Id, path: path})
+		}
+		cr.replaceImages(func(image imageRow) bool { return image.commentId == comment.Id }, rows)
+	}
+
+	return nil
+}
+
+func (cr *CommentsRepo) GetPostIds(ctx context.Context, user entity.User) ([]int64, error) {
+	cr.mu.RLock()
+	defer cr.mu.RUnlock()
+
+	var postIds []int64
+	seen := make(map[int64]bool)
+	for _, row := range cr.comments {
+		if row.userId == user.Id && row.deletedAt.IsZero() && !seen[row.postId] {
+			seen[row.postId] = true
+			postIds = append(postIds, row.postId)
+		}
+	}
+
+	return postIds, nil
+}
+
+// Delete moves the comment to the trash, the deletion mark is taken from
+// the comment. Comments already in the trash are not found.
+func (cr *CommentsRepo) Delete(ctx context.Context, comment entity.Comment) error {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	i := cr.findComment(comment.Id)
+	if i < 0 || !cr.comments[i].deletedAt.IsZero() {
+		return fmt.Errorf("CommentsRepo - Delete - %w", errNoRows)
+	}
+	cr.comments[i].deletion = deletion{
+		deletedAt:    storedTime(comment.DeletedAt),
+		deletedBy:    comment.DeletedBy,
+		deleteReason: comment.DeleteReason,
+	}
+
+	return nil
+}
+
+func (cr *CommentsRepo) toEntity(row commentRow) entity.Comment {
+	var comment entity.Comment
+	comment.Id = row.id
+	comment.PostId = row.postId
+	comment.ParentId = row.parentId
+	comment.User.Id = row.userId
+	comment.Date = row.date
+	comment.Content = row.content
+	comment.EditedAt = row.editedAt
+	comment.DeletedAt = row.deletedAt
+	comment.DeletedBy = row.deletedBy
+	comment.DeleteReason = row.deleteReason
+	return comment
+}
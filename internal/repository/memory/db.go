@@ -0,0 +1,389 @@
+// Package memory implements repositories over plain Go slices guarded by a
+// mutex. It keeps nothing on disk and is meant for development and tests.
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"forum/internal/entity"
+)
+
+// Errors mirror the messages of the sql backends, usecases match on them.
+var errNoRows = sql.ErrNoRows
+
+func uniqueErr(table string, columns ...string) error {
+	for i := range columns {
+		columns[i] = table + "." + columns[i]
+	}
+	return fmt.Errorf("UNIQUE constraint failed: %s", strings.Join(columns, ", "))
+}
+
+type userRow struct {
+	id                 int64
+	name               string
+	email              string
+	emailVerified      bool
+	pendingEmail       string
+	verificationSentAt time.Time
+	totpSecret         string
+	totpEnabled        bool
+	totpLastStep       int64
+	password           string
+	regDate            time.Time
+	dateOfBirth        string
+	city               string
+	gender             string
+	roleId             int64
+	banned             bool
+	sign               string
+	timezone           string
+}
+
+type roleRow struct {
+	id          int64
+	name        string
+	permissions []entity.Permission
+}
+
+type postRow struct {
+	id       int64
+	userId   int64
+	date     time.Time
+	title    string
+	content  string
+	editedAt time.Time
+	deletion
+}
+
+type commentRow struct {
+	id       int64
+	postId   int64
+	parentId int64
+	userId   int64
+	date     time.Time
+	content  string
+	editedAt time.Time
+	deletion
+}
+
+// deletion is the soft delete mark of posts and comments, rows with an
+// zero deletedAt are not deleted.
+type deletion struct {
+	deletedAt    time.Time
+	deletedBy    int64
+	deleteReason string
+}
+
+type reactionRow struct {
+	target   string
+	targetId int64
+	userId   int64
+	kind     string
+	date     time.Time
+}
+
+type revisionRow struct {
+	id         int64
+	target     string
+	targetId   int64
+	userId     int64
+	date       time.Time
+	title      string
+	content    string
+	categories []string
+}
+
+type sessionRow struct {
+	id        int64
+	userId    int64
+	token     string
+	userAgent string
+	ip        string
+	createdAt time.Time
+	lastSeen  time.Time
+	expiresAt time.Time
+}
+
+type resetRow struct {
+	id        int64
+	userId    int64
+	tokenHash string
+	createdAt time.Time
+	expiresAt time.Time
+}
+
+type recoveryCodeRow struct {
+	id       int64
+	userId   int64
+	codeHash string
+}
+
+type challengeRow struct {
+	id        int64
+	userId    int64
+	tokenHash string
+	attempts  int
+	createdAt time.Time
+	expiresAt time.Time
+}
+
+type categoryRow struct {
+	id          int64
+	parentId    int64
+	name        string
+	slug        string
+	description string
+	position    int
+	color       string
+}
+
+type topicRefRow struct {
+	postId     int64
+	categoryId int64
+}
+
+type imageRow struct {
+	postId    int64
+	commentId int64
+	userId    int64
+	path      string
+}
+
+// DB is the in-memory storage shared by the repositories. Every table is
+// a slice kept in insertion order, which matches the order sql backends
+// return rows in.
+type DB struct {
+	mu lock
+
+	tables
+}
+
+// lock guards the tables. Like the write lock of SQLite, writers holds
+// one writer at a time: a running unit of work or a single write outside
+// of one. Reads don't wait for it.
+type lock struct {
+	sync.RWMutex
+	writers sync.Mutex
+	// inTx is set on the copy a unit of work writes to, which holds
+	// writers of its DB already.
+	inTx bool
+}
+
+func (l *lock) Lock() {
+	if !l.inTx {
+		l.writers.Lock()
+	}
+	l.RWMutex.Lock()
+}
+
+func (l *lock) Unlock() {
+	l.RWMutex.Unlock()
+	if !l.inTx {
+		l.writers.Unlock()
+	}
+}
+
+type tables struct {
+	users      []userRow
+	roles      []roleRow
+	posts      []postRow
+	comments   []commentRow
+	reactions  []reactionRow
+	categories []categoryRow
+	topicRefs  []topicRefRow
+	images     []imageRow
+	revisions  []revisionRow
+	sessions   []sessionRow
+	resets     []resetRow
+	codes      []recoveryCodeRow
+	challenges []challengeRow
+
+	lastUserId      int64
+	lastRoleId      int64
+	lastPostId      int64
+	lastCommentId   int64
+	lastRevisionId  int64
+	lastCategoryId  int64
+	lastSessionId   int64
+	lastResetId     int64
+	lastCodeId      int64
+	lastChallengeId int64
+}
+
+// New returns an empty DB with the built-in roles, which the sql backends
+// get from their migrations.
+func New() *DB {
+	db := &DB{}
+	db.roles = []roleRow{
+		{id: entity.RoleUserId, name: "Пользователь"},
+		{id: entity.RoleModeratorId, name: "Модератор", permissions: []entity.Permission{
+			entity.PermBanUsers, entity.PermDeleteAnyPost, entity.PermEditAnyPost,
+		}},
+		{id: entity.RoleAdminId, name: "Администратор", permissions: sortedPermissions(entity.Permissions)},
+	}
+	db.lastRoleId = entity.RoleAdminId
+	return db
+}
+
+// Close exists for symmetry with the sql backends.
+func (db *DB) Close() {}
+
+// RunInTx runs fn on a copy of the tables, which replaces them once fn
+// returns nil and ctx is not done by then. Otherwise the copy is dropped,
+// as a rolled back sql transaction would be. Writes outside of fn wait
+// until it is over, reads see the tables as they were before it.
+func (db *DB) RunInTx(ctx context.Context, fn func(tx *DB) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	db.mu.writers.Lock()
+	defer db.mu.writers.Unlock()
+
+	db.mu.RLock()
+	tx := &DB{tables: db.tables.clone()}
+	db.mu.RUnlock()
+	tx.mu.inTx = true
+
+	err := fn(tx)
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		return err
+	}
+	db.mu.RWMutex.Lock()
+	db.tables = tx.tables
+	db.mu.RWMutex.Unlock()
+	return nil
+}
+
+func (t tables) clone() tables {
+	t.users = append([]userRow(nil), t.users...)
+	t.roles = append([]roleRow(nil), t.roles...)
+	t.posts = append([]postRow(nil), t.posts...)
+	t.comments = append([]commentRow(nil), t.comments...)
+	t.reactions = append([]reactionRow(nil), t.reactions...)
+	t.categories = append([]categoryRow(nil), t.categories...)
+	t.topicRefs = append([]topicRefRow(nil), t.topicRefs...)
+	t.images = append([]imageRow(nil), t.images...)
+	t.revisions = append([]revisionRow(nil), t.revisions...)
+	t.sessions = append([]sessionRow(nil), t.sessions...)
+	t.resets = append([]resetRow(nil), t.resets...)
+	t.codes = append([]recoveryCodeRow(nil), t.codes...)
+	t.challenges = append([]challengeRow(nil), t.challenges...)
+	return t
+}
+
+func (db *DB) findUser(id int64) int {
+	for i := range db.users {
+		if db.users[i].id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (db *DB) findRole(id int64) int {
+	for i := range db.roles {
+		if db.roles[i].id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (db *DB) roleName(id int64) string {
+	if i := db.findRole(id); i >= 0 {
+		return db.roles[i].name
+	}
+	return ""
+}
+
+func (db *DB) findPost(id int64) int {
+	for i := range db.posts {
+		if db.posts[i].id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (db *DB) findComment(id int64) int {
+	for i := range db.comments {
+		if db.comments[i].id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (db *DB) findCategory(id int64) int {
+	for i := range db.categories {
+		if db.categories[i].id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (db *DB) userName(id int64) string {
+	if i := db.findUser(id); i >= 0 {
+		return db.users[i].name
+	}
+	return ""
+}
+
+func (db *DB) imagePath(match func(imageRow) bool) string {
+	for _, image := range db.images {
+		if match(image) {
+			return image.path
+		}
+	}
+	return ""
+}
+
+// imagePaths lists the paths of the images matching in upload order.
+func (db *DB) imagePaths(match func(imageRow) bool) []string {
+	var paths []string
+	for _, image := range db.images {
+		if match(image) {
+			paths = append(paths, image.path)
+		}
+	}
+	return paths
+}
+
+// replaceImages drops the images matching and appends rows instead. The
+// caller holds the write lock.
+func (db *DB) replaceImages(match func(imageRow) bool, rows []imageRow) {
+	images := db.images[:0]
+	for _, image := range db.images {
+		if !match(image) {
+			images = append(images, image)
+		}
+	}
+	db.images = append(images, rows...)
+}
+
+// window clamps limit and offset to a slice of n rows. Rows are kept in
+// insertion order, which is also id order, so a window is a page.
+func window(n, limit, offset int) (int, int) {
+	if offset > n {
+		offset = n
+	}
+	if limit < 0 || offset+limit > n {
+		return offset, n
+	}
+	return offset, offset + limit
+}
+
+// storedTime keeps t the way the sql backends store timestamps: in UTC and
+// to the second.
+func storedTime(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Second)
+}
@@ -0,0 +1,279 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"forum/internal/entity"
+)
+
+type PostsRepo struct {
+	*DB
+}
+
+func NewPostsRepo(db *DB) *PostsRepo {
+	return &PostsRepo{db}
+}
+
+func (pr *PostsRepo) Store(ctx context.Context, post *entity.Post) error {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	pr.lastPostId++
+	post.Id = pr.lastPostId
+	pr.posts = append(pr.posts, postRow{
+		id:      post.Id,
+		userId:  post.User.Id,
+		date:    storedTime(post.Date),
+		title:   post.Title,
+		content: post.Content,
+	})
+
+	for _, path := range post.Images {
+		pr.images = append(pr.images, imageRow{postId: post.Id, path: path})
+	}
+
+	return nil
+}
+
+func (pr *PostsRepo) StoreTopicReference(ctx context.Context, post entity.Post) error {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	stored := append([]topicRefRow{}, pr.topicRefs...)
+	for _, category := range post.Categories {
+		ref := topicRefRow{postId: post.Id, categoryId: category.Id}
+		for _, existed := range stored {
+			if existed == ref {
+				return fmt.Errorf("PostsRepo - StoreTopicReference - %w",
+					uniqueErr("reference_topic", "post_id", "topic_id"))
+			}
+		}
+		stored = append(stored, ref)
+	}
+	pr.topicRefs = stored
+
+	return nil
+}
+
+func (pr *PostsRepo) Fetch(ctx context.Context) ([]entity.Post, error) {
+	pr.mu.RLock()
+	defer pr.mu.RUnlock()
+
+	var posts []entity.Post
+	for _, row := range pr.livePosts() {
+		posts = append(posts, pr.toEntity(row))
+	}
+
+	return posts, nil
+}
+
+func (pr *PostsRepo) FetchPage(ctx context.Context, limit, offset int) ([]entity.Post, error) {
+	pr.mu.RLock()
+	defer pr.mu.RUnlock()
+
+	var posts []entity.Post
+	rows := pr.livePosts()
+	from, to := window(len(rows), limit, offset)
+	for _, row := range rows[from:to] {
+		posts = append(posts, pr.toEntity(row))
+	}
+
+	return posts, nil
+}
+
+func (pr *PostsRepo) FetchAfter(ctx context.Context, cursor int64, limit int) ([]entity.Post, error) {
+	pr.mu.RLock()
+	defer pr.mu.RUnlock()
+
+	var posts []entity.Post
+	for _, row := range pr.livePosts() {
+		if len(posts) == limit {
+			break
+		}
+		if row.id > cursor {
+			posts = append(posts, pr.toEntity(row))
+		}
+	}
+
+	return posts, nil
+}
+
+func (pr *PostsRepo) Count(ctx context.Context) (int64, error) {
+	pr.mu.RLock()
+	defer pr.mu.RUnlock()
+
+	return int64(len(pr.livePosts())), nil
+}
+
+// livePosts returns posts that are not in the trash.
+func (pr *PostsRepo) livePosts() []postRow {
+	var rows []postRow
+	for _, row := range pr.posts {
+		if row.deletedAt.IsZero() {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+func (pr *PostsRepo) FetchByAuthor(ctx context.Context, user entity.User) ([]entity.Post, error) {
+	pr.mu.RLock()
+	defer pr.mu.RUnlock()
+
+	var posts []entity.Post
+	for _, row := range pr.livePosts() {
+		if row.userId == user.Id {
+			posts = append(posts, pr.toEntity(row))
+		}
+	}
+
+	return posts, nil
+}
+
+func (pr *PostsRepo) GetById(ctx context.Context, id int64) (entity.Post, error) {
+	pr.mu.RLock()
+	defer pr.mu.RUnlock()
+
+	i := pr.findPost(id)
+	if i < 0 {
+		return entity.Post{}, fmt.Errorf("PostsRepo - GetById - %w", errNoRows)
+	}
+
+	row := pr.posts[i]
+	post := pr.toEntity(row)
+	post.User.AvatarPath = pr.imagePath(func(image imageRow) bool {
+		return image.userId == row.userId
+	})
+	post.Images = pr.imagePaths(func(image imageRow) bool {
+		return image.postId == row.id
+	})
+
+	return post, nil
+}
+
+func (pr *PostsRepo) GetIdsByCategory(ctx context.Context, categoryId int64) ([]int64, error) {
+	pr.mu.RLock()
+	defer pr.mu.RUnlock()
+
+	var ids []int64
+	for _, ref := range pr.topicRefs {
+		if i := pr.findPost(ref.postId); ref.categoryId == categoryId && i >= 0 && pr.posts[i].deletedAt.IsZero() {
+			ids = append(ids, ref.postId)
+		}
+	}
+
+	return ids, nil
+}
+
+func (pr *PostsRepo) GetRelatedCategories(ctx context.Context, post entity.Post) ([]entity.Category, error) {
+	pr.mu.RLock()
+	defer pr.mu.RUnlock()
+
+	return pr.postCategories(post.Id), nil
+}
+
+func (pr *PostsRepo) FetchCategories(ctx context.Context, postIds []int64) (map[int64][]entity.Category, error) {
+	pr.mu.RLock()
+	defer pr.mu.RUnlock()
+
+	categories := make(map[int64][]entity.Category, len(postIds))
+	for _, id := range postIds {
+		if found := pr.postCategories(id); len(found) != 0 {
+			categories[id] = found
+		}
+	}
+
+	return categories, nil
+}
+
+// postCategories lists categories of the post ordered as sortedCategories
+// does.
+func (pr *PostsRepo) postCategories(postId int64) []entity.Category {
+	categories := []entity.Category{}
+	for _, row := range pr.sortedCategories() {
+		for _, ref := range pr.topicRefs {
+			if ref.postId == postId && ref.categoryId == row.id {
+				categories = append(categories, row.toEntity())
+			}
+		}
+	}
+	return categories
+}
+
+// Update overwrites the title and the content of the post and marks it
+// edited at post.EditedAt. Categories and images are replaced unless they
+// are nil.
+func (pr *PostsRepo) Update(ctx context.Context, post entity.Post) error {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	i := pr.findPost(post.Id)
+	if i < 0 {
+		return fmt.Errorf("PostsRepo - Update - %w", errNoRows)
+	}
+	pr.posts[i].title = post.Title
+	pr.posts[i].content = post.Content
+	pr.posts[i].editedAt = storedTime(post.EditedAt)
+
+	if post.Categories != nil {
+		refs := pr.topicRefs[:0]
+		for _, ref := range pr.topicRefs {
+			if ref.postId != post.Id {
+				refs = append(refs, ref)
+			}
+		}
+		for _, category := range post.Categories {
+			refs = append(refs, topicRefRow{postId: post.Id, categoryId: category.Id})
+		}
+		pr.topicRefs = refs
+	}
+	if post.Images != nil {
+		rows := make([]imageRow, 0, len(post.Images))
+		for _, path := range post.Images {
+			rows = append(rows, imageRow{postId: post.Id, path: path})
+		}
+		pr.replaceImages(func(image imageRow) bool { return image.postId == post.Id }, rows)
+	}
+
+	return nil
+}
+
+// Delete moves the post to the trash, the deletion mark is taken from the
+// post. Posts already in the trash are not found.
+func (pr *PostsRepo) Delete(ctx context.Context, post entity.Post) error {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	i := pr.findPost(post.Id)
+	if i < 0 || !pr.posts[i].deletedAt.IsZero() {
+		return fmt.Errorf("PostsRepo - Delete - %w", errNoRows)
+	}
+	pr.posts[i].deletion = deletion{
+		deletedAt:    storedTime(post.DeletedAt),
+		deletedBy:    post.DeletedBy,
+		deleteReason: post.DeleteReason,
+	}
+
+	return nil
+}
+
+func (pr *PostsRepo) toEntity(row postRow) entity.Post {
+	var post entity.Post
+	post.Id = row.id
+	post.User.Id = row.userId
+	post.User.Name = pr.userName(row.userId)
+	post.Date = row.date
+	post.Title = row.title
+	post.Content = row.content
+	post.EditedAt = row.editedAt
+	post.DeletedAt = row.deletedAt
+	post.DeletedBy = row.deletedBy
+	post.DeleteReason = row.deleteReason
+	for _, comment := range pr.comments {
+		if comment.postId == row.id && comment.deletedAt.IsZero() {
+			post.TotalComments++
+		}
+	}
+	return post
+}
@@ -0,0 +1,195 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"forum/internal/entity"
+)
+
+type ReactionsRepo struct {
+	*DB
+}
+
+func NewReactionsRepo(db *DB) *ReactionsRepo {
+	return &ReactionsRepo{db}
+}
+
+func (rr *ReactionsRepo) Store(ctx context.Context, reaction entity.Reaction) error {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	for _, row := range rr.reactions {
+		if row.matches(reaction) {
+			return fmt.Errorf("ReactionsRepo - Store - %w",
+				uniqueErr("reactions", "target", "target_id", "user_id", "kind"))
+		}
+	}
+	rr.reactions = append(rr.reactions, reactionRow{
+		target:   reaction.Target,
+		targetId: reaction.TargetId,
+		userId:   reaction.UserId,
+		kind:     reaction.Kind,
+		date:     storedTime(reaction.Date),
+	})
+
+	return nil
+}
+
+func (rr *ReactionsRepo) Delete(ctx context.Context, reaction entity.Reaction) error {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	kept := rr.reactions[:0]
+	for _, row := range rr.reactions {
+		if !row.matches(reaction) {
+			kept = append(kept, row)
+		}
+	}
+	rr.reactions = kept
+
+	return nil
+}
+
+// Has reports whether the user of the reaction has put it on the target.
+func (rr *ReactionsRepo) Has(ctx context.Context, reaction entity.Reaction) (bool, error) {
+	rr.mu.RLock()
+	defer rr.mu.RUnlock()
+
+	for _, row := range rr.reactions {
+		if row.matches(reaction) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// DeleteByUser takes back every reaction of the user.
+func (rr *ReactionsRepo) DeleteByUser(ctx context.Context, userId int64) error {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	kept := rr.reactions[:0]
+	for _, row := range rr.reactions {
+		if row.userId != userId {
+			kept = append(kept, row)
+		}
+	}
+	rr.reactions = kept
+
+	return nil
+}
+
+func (rr *ReactionsRepo) Fetch(ctx context.Context, target string, targetId int64,
+	kind string) ([]entity.Reaction, error) {
+	rr.mu.RLock()
+	defer rr.mu.RUnlock()
+
+	var reactions []entity.Reaction
+	for _, row := range rr.reactions {
+		if row.target == target && row.targetId == targetId && row.kind == kind {
+			reactions = append(reactions, row.toEntity())
+		}
+	}
+	sort.SliceStable(reactions, func(i, j int) bool {
+		if !reactions[i].Date.Equal(reactions[j].Date) {
+			return reactions[i].Date.Before(reactions[j].Date)
+		}
+		return reactions[i].UserId < reactions[j].UserId
+	})
+
+	return reactions, nil
+}
+
+func (rr *ReactionsRepo) FetchAll(ctx context.Context, target string) ([]entity.Reaction, error) {
+	rr.mu.RLock()
+	defer rr.mu.RUnlock()
+
+	var reactions []entity.Reaction
+	for _, row := range rr.reactions {
+		if row.target == target {
+			reactions = append(reactions, row.toEntity())
+		}
+	}
+	sort.SliceStable(reactions, func(i, j int) bool {
+		if reactions[i].TargetId != reactions[j].TargetId {
+			return reactions[i].TargetId < reactions[j].TargetId
+		}
+		if !reactions[i].Date.Equal(reactions[j].Date) {
+			return reactions[i].Date.Before(reactions[j].Date)
+		}
+		return reactions[i].UserId < reactions[j].UserId
+	})
+
+	return reactions, nil
+}
+
+func (rr *ReactionsRepo) Count(ctx context.Context, target string,
+	targetIds []int64) (map[int64]map[string]int64, error) {
+	rr.mu.RLock()
+	defer rr.mu.RUnlock()
+
+	wanted := make(map[int64]bool, len(targetIds))
+	for _, id := range targetIds {
+		wanted[id] = true
+	}
+
+	counts := make(map[int64]map[string]int64, len(targetIds))
+	for _, row := range rr.reactions {
+		if row.target != target || !wanted[row.targetId] {
+			continue
+		}
+		if counts[row.targetId] == nil {
+			counts[row.targetId] = make(map[string]int64)
+		}
+		counts[row.targetId][row.kind]++
+	}
+
+	return counts, nil
+}
+
+func (rr *ReactionsRepo) CountByUser(ctx context.Context, target string, userId int64) (map[string]int64, error) {
+	rr.mu.RLock()
+	defer rr.mu.RUnlock()
+
+	counts := make(map[string]int64)
+	for _, row := range rr.reactions {
+		if row.target == target && row.userId == userId {
+			counts[row.kind]++
+		}
+	}
+
+	return counts, nil
+}
+
+func (rr *ReactionsRepo) FetchTargetIds(ctx context.Context, target string, userId int64,
+	kind string) ([]int64, error) {
+	rr.mu.RLock()
+	defer rr.mu.RUnlock()
+
+	var ids []int64
+	for _, row := range rr.reactions {
+		if row.target == target && row.userId == userId && row.kind == kind {
+			ids = append(ids, row.targetId)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	return ids, nil
+}
+
+func (row reactionRow) matches(reaction entity.Reaction) bool {
+	return row.target == reaction.Target && row.targetId == reaction.TargetId &&
+		row.userId == reaction.UserId && row.kind == reaction.Kind
+}
+
+func (row reactionRow) toEntity() entity.Reaction {
+	return entity.Reaction{
+		Target:   row.target,
+		TargetId: row.targetId,
+		UserId:   row.userId,
+		Kind:     row.kind,
+		Date:     row.date,
+	}
+}
@@ -0,0 +1,197 @@
+package memory_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"forum/internal/entity"
+	"forum/internal/repository"
+	"forum/internal/repository/memory"
+	"forum/internal/repository/repotest"
+)
+
+func openRepos(tb testing.TB) (*repository.Repositories, func()) {
+	db := memory.New()
+	return repository.NewMemoryRepositories(db), db.Close
+}
+
+func TestPostsRepo(t *testing.T) {
+	repotest.RunPostsTests(t, openRepos)
+}
+
+func TestCategoriesRepo(t *testing.T) {
+	repotest.RunCategoriesTests(t, openRepos)
+}
+
+func TestUsersRepo(t *testing.T) {
+	repotest.RunUsersTests(t, openRepos)
+}
+
+func TestRolesRepo(t *testing.T) {
+	repotest.RunRolesTests(t, openRepos)
+}
+
+func TestSessionsRepo(t *testing.T) {
+	repotest.RunSessionsTests(t, openRepos)
+}
+
+func TestPasswordResetsRepo(t *testing.T) {
+	repotest.RunPasswordResetsTests(t, openRepos)
+}
+
+func TestTwoFactorRepo(t *testing.T) {
+	repotest.RunTwoFactorTests(t, openRepos)
+}
+
+func TestLoginChallengesRepo(t *testing.T) {
+	repotest.RunLoginChallengesTests(t, openRepos)
+}
+
+func TestCommentsRepo(t *testing.T) {
+	repotest.RunCommentsTests(t, openRepos)
+}
+
+func TestReactionsRepo(t *testing.T) {
+	repotest.RunReactionsTests(t, openRepos)
+}
+
+func TestRevisionsRepo(t *testing.T) {
+	repotest.RunRevisionsTests(t, openRepos)
+}
+
+func TestUnitOfWork(t *testing.T) {
+	repotest.RunUnitOfWorkTests(t, openRepos)
+}
+
+func TestCounters(t *testing.T) {
+	repotest.RunCountersTests(t, openRepos)
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	ctx := context.Background()
+	repos, closeDB := openRepos(t)
+	defer closeDB()
+
+	const workers = 50
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			user := entity.User{
+				Name:  fmt.Sprintf("user%d", i),
+				Email: fmt.Sprintf("user%d@mail.ru", i),
+			}
+			if err := repos.Users.Store(ctx, user); err != nil {
+				errs <- err
+				return
+			}
+			id, err := repos.Users.GetId(ctx, user)
+			if err != nil {
+				errs <- err
+				return
+			}
+			post := entity.Post{User: entity.User{Id: id}, Title: user.Name, Content: user.Name}
+			if err = repos.Posts.Store(ctx, &post); err != nil {
+				errs <- err
+				return
+			}
+			like := entity.Reaction{Target: entity.ReactionTargetPost, TargetId: 1, UserId: id, Kind: "like"}
+			if err = repos.Reactions.Store(ctx, like); err != nil {
+				errs <- err
+				return
+			}
+			if _, err = repos.Posts.Fetch(ctx); err != nil {
+				errs <- err
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	posts, err := repos.Posts.Fetch(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != workers {
+		t.Fatalf("want: %d, got: %d", workers, len(posts))
+	}
+
+	counts, err := repos.Reactions.Count(ctx, entity.ReactionTargetPost, []int64{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts[1]["like"] != workers {
+		t.Fatalf("want: %d, got: %d", workers, counts[1]["like"])
+	}
+}
+
+// TestUnitOfWorkIsolation checks that a unit of work rolled back doesn't
+// take writes made outside of it along, those wait for it instead.
+func TestUnitOfWorkIsolation(t *testing.T) {
+	ctx := context.Background()
+	repos, closeDB := openRepos(t)
+	defer closeDB()
+
+	riddle := entity.User{Name: "Riddle", Email: "riddle@mail.ru"}
+	subi := entity.User{Name: "Subi", Email: "subi@mail.ru"}
+	tom := entity.User{Name: "Tom", Email: "tom@mail.ru"}
+	if err := repos.Users.Store(ctx, riddle); err != nil {
+		t.Fatal(err)
+	}
+
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- repos.UnitOfWork.Do(ctx, func(repos *repository.Repositories) error {
+			if err := repos.Users.Store(ctx, subi); err != nil {
+				return err
+			}
+			close(started)
+			<-release
+			return errors.New("rolled back")
+		})
+	}()
+	<-started
+
+	if _, err := repos.Users.GetId(ctx, subi); err == nil {
+		t.Fatal("want the write of a running unit of work unseen")
+	}
+	stored := make(chan error, 1)
+	go func() {
+		stored <- repos.Users.Store(ctx, tom)
+	}()
+	select {
+	case err := <-stored:
+		t.Fatalf("want the write to wait for the unit of work, got: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+
+	if err := <-done; err == nil {
+		t.Fatal("want the unit of work rolled back")
+	}
+	if err := <-stored; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repos.Users.GetId(ctx, tom); err != nil {
+		t.Fatalf("want the write outside of the unit of work kept, got: %v", err)
+	}
+	if _, err := repos.Users.GetId(ctx, subi); err == nil {
+		t.Fatal("want the write of the unit of work rolled back")
+	}
+}
+
+func TestSearchRepo(t *testing.T) {
+	repotest.RunSearchTests(t, openRepos)
+}
@@ -0,0 +1,93 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"forum/internal/entity"
+)
+
+type PasswordResetsRepo struct {
+	*DB
+}
+
+func NewPasswordResetsRepo(db *DB) *PasswordResetsRepo {
+	return &PasswordResetsRepo{db}
+}
+
+func (rr *PasswordResetsRepo) Store(ctx context.Context, reset *entity.PasswordReset) error {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	for _, existed := range rr.resets {
+		if existed.tokenHash == reset.TokenHash {
+			return fmt.Errorf("PasswordResetsRepo - Store - %w", uniqueErr("password_resets", "token_hash"))
+		}
+	}
+
+	rr.lastResetId++
+	reset.Id = rr.lastResetId
+	rr.resets = append(rr.resets, resetRow{
+		id:        reset.Id,
+		userId:    reset.User.Id,
+		tokenHash: reset.TokenHash,
+		createdAt: storedTime(reset.CreatedAt),
+		expiresAt: storedTime(reset.ExpiresAt),
+	})
+
+	return nil
+}
+
+func (rr *PasswordResetsRepo) GetByHash(ctx context.Context, tokenHash string) (entity.PasswordReset, error) {
+	rr.mu.RLock()
+	defer rr.mu.RUnlock()
+
+	for _, row := range rr.resets {
+		if row.tokenHash == tokenHash {
+			return entity.PasswordReset{
+				Id:        row.id,
+				User:      entity.User{Id: row.userId},
+				TokenHash: row.tokenHash,
+				CreatedAt: row.createdAt,
+				ExpiresAt: row.expiresAt,
+			}, nil
+		}
+	}
+
+	return entity.PasswordReset{}, fmt.Errorf("PasswordResetsRepo - GetByHash - %w", errNoRows)
+}
+
+func (rr *PasswordResetsRepo) DeleteByUser(ctx context.Context, userId int64) error {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	rr.deleteResets(func(row resetRow) bool { return row.userId == userId })
+	return nil
+}
+
+// DeleteExpired removes the resets expired before the given time and
+// returns how many there were.
+func (rr *PasswordResetsRepo) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	before = storedTime(before)
+	return rr.deleteResets(func(row resetRow) bool { return row.expiresAt.Before(before) }), nil
+}
+
+// deleteResets drops the resets matching and returns how many there were.
+// The caller holds the write lock.
+func (rr *PasswordResetsRepo) deleteResets(match func(resetRow) bool) int64 {
+	var deleted int64
+	resets := rr.resets[:0]
+	for _, row := range rr.resets {
+		if match(row) {
+			deleted++
+			continue
+		}
+		resets = append(resets, row)
+	}
+	rr.resets = resets
+	return deleted
+}
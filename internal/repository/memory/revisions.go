@@ -0,0 +1,73 @@
+package memory
+
+import (
+	"context"
+	"forum/internal/entity"
+)
+
+type RevisionsRepo struct {
+	*DB
+}
+
+func NewRevisionsRepo(db *DB) *RevisionsRepo {
+	return &RevisionsRepo{db}
+}
+
+func (rr *RevisionsRepo) Store(ctx context.Context, revision entity.Revision) error {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	rr.lastRevisionId++
+	rr.revisions = append(rr.revisions, revisionRow{
+		id:         rr.lastRevisionId,
+		target:     revision.Target,
+		targetId:   revision.TargetId,
+		userId:     revision.User.Id,
+		date:       storedTime(revision.Date),
+		title:      revision.Title,
+		content:    revision.Content,
+		categories: append([]string(nil), revision.Categories...),
+	})
+
+	return nil
+}
+
+func (rr *RevisionsRepo) Fetch(ctx context.Context, target string, targetId int64) ([]entity.Revision, error) {
+	rr.mu.RLock()
+	defer rr.mu.RUnlock()
+
+	var revisions []entity.Revision
+	for _, row := range rr.revisions {
+		if row.target != target || row.targetId != targetId {
+			continue
+		}
+		revisions = append(revisions, entity.Revision{
+			Id:         row.id,
+			Target:     row.target,
+			TargetId:   row.targetId,
+			Number:     len(revisions) + 1,
+			User:       entity.User{Id: row.userId, Name: rr.userName(row.userId)},
+			Date:       row.date,
+			Title:      row.title,
+			Content:    row.content,
+			Categories: append([]string(nil), row.categories...),
+		})
+	}
+
+	return revisions, nil
+}
+
+func (rr *RevisionsRepo) DeleteByUser(ctx context.Context, userId int64) error {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	kept := rr.revisions[:0]
+	for _, row := range rr.revisions {
+		if row.userId != userId {
+			kept = append(kept, row)
+		}
+	}
+	rr.revisions = kept
+
+	return nil
+}
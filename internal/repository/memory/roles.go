@@ -0,0 +1,135 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"forum/internal/entity"
+)
+
+type RolesRepo struct {
+	*DB
+}
+
+func NewRolesRepo(db *DB) *RolesRepo {
+	return &RolesRepo{db}
+}
+
+// sortedPermissions copies permissions in the order the sql backends
+// return them. Rows never share their slices, so a cloned table is not
+// changed through them.
+func sortedPermissions(permissions []entity.Permission) []entity.Permission {
+	sorted := append([]entity.Permission(nil), permissions...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted
+}
+
+func (row roleRow) toEntity() entity.Role {
+	return entity.Role{Id: row.id, Name: row.name, Permissions: sortedPermissions(row.permissions)}
+}
+
+func (rr *RolesRepo) Store(ctx context.Context, role *entity.Role) error {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	for _, existed := range rr.roles {
+		if existed.name == role.Name {
+			return fmt.Errorf("RolesRepo - Store - %w", uniqueErr("roles", "name"))
+		}
+	}
+
+	rr.lastRoleId++
+	role.Id = rr.lastRoleId
+	rr.roles = append(rr.roles, roleRow{
+		id:          role.Id,
+		name:        role.Name,
+		permissions: sortedPermissions(role.Permissions),
+	})
+
+	return nil
+}
+
+// Fetch lists every role with its permissions by id.
+func (rr *RolesRepo) Fetch(ctx context.Context) ([]entity.Role, error) {
+	rr.mu.RLock()
+	defer rr.mu.RUnlock()
+
+	var roles []entity.Role
+	for _, row := range rr.roles {
+		roles = append(roles, row.toEntity())
+	}
+	return roles, nil
+}
+
+func (rr *RolesRepo) GetById(ctx context.Context, id int64) (entity.Role, error) {
+	rr.mu.RLock()
+	defer rr.mu.RUnlock()
+
+	i := rr.findRole(id)
+	if i < 0 {
+		return entity.Role{}, fmt.Errorf("RolesRepo - GetById - %w", errNoRows)
+	}
+	return rr.roles[i].toEntity(), nil
+}
+
+// Update overwrites the name and the permissions of the role.
+func (rr *RolesRepo) Update(ctx context.Context, role entity.Role) error {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	i := rr.findRole(role.Id)
+	if i < 0 {
+		return fmt.Errorf("RolesRepo - Update - %w", errNoRows)
+	}
+	for _, existed := range rr.roles {
+		if existed.id != role.Id && existed.name == role.Name {
+			return fmt.Errorf("RolesRepo - Update - %w", uniqueErr("roles", "name"))
+		}
+	}
+	rr.roles[i].name = role.Name
+	rr.roles[i].permissions = sortedPermissions(role.Permissions)
+
+	return nil
+}
+
+// Delete removes the role, its users have to be moved beforehand.
+func (rr *RolesRepo) Delete(ctx context.Context, id int64) error {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	i := rr.findRole(id)
+	if i < 0 {
+		return fmt.Errorf("RolesRepo - Delete - %w", errNoRows)
+	}
+	rr.roles = append(rr.roles[:i], rr.roles[i+1:]...)
+
+	return nil
+}
+
+// CountUsers tells how many users have the role.
+func (rr *RolesRepo) CountUsers(ctx context.Context, id int64) (int64, error) {
+	rr.mu.RLock()
+	defer rr.mu.RUnlock()
+
+	var count int64
+	for _, user := range rr.users {
+		if user.roleId == id {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// MoveUsers gives the users of the role fromId the role toId.
+func (rr *RolesRepo) MoveUsers(ctx context.Context, fromId, toId int64) error {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	for i := range rr.users {
+		if rr.users[i].roleId == fromId {
+			rr.users[i].roleId = toId
+		}
+	}
+	return nil
+}
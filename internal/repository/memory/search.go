@@ -0,0 +1,159 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"unicode"
+
+	"forum/internal/entity"
+)
+
+const snippetWords = 12
+
+// searchField is a piece of a document with the weight of matches in it.
+type searchField struct {
+	text   string
+	weight float64
+}
+
+// Search looks up terms in posts and comments. A post can appear several
+// times, once for itself and once per matching comment.
+func (pr *PostsRepo) Search(ctx context.Context, terms []string, limit int) ([]entity.SearchResult, error) {
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	pr.mu.RLock()
+	defer pr.mu.RUnlock()
+
+	var results []entity.SearchResult
+	for _, row := range pr.livePosts() {
+		result, ok := searchDocument(terms, []searchField{
+			{text: row.title, weight: 10},
+			{text: row.content, weight: 1},
+			{text: pr.userName(row.userId), weight: 2},
+		})
+		if ok {
+			result.Post.Id = row.id
+			results = append(results, result)
+		}
+	}
+	results = topResults(results, limit)
+
+	var comments []entity.SearchResult
+	for _, row := range pr.comments {
+		if i := pr.findPost(row.postId); !row.deletedAt.IsZero() || i >= 0 && !pr.posts[i].deletedAt.IsZero() {
+			continue
+		}
+		result, ok := searchDocument(terms, []searchField{
+			{text: row.content, weight: 1},
+			{text: pr.userName(row.userId), weight: 2},
+		})
+		if ok {
+			result.Post.Id = row.postId
+			comments = append(comments, result)
+		}
+	}
+
+	return append(results, topResults(comments, limit)...), nil
+}
+
+// searchDocument ranks a document by weighted count of words starting with
+// any term. Every term has to match somewhere in the document.
+func searchDocument(terms []string, fields []searchField) (entity.SearchResult, bool) {
+	var result entity.SearchResult
+	found := make([]bool, len(terms))
+	best := -1.0
+
+	for _, field := range fields {
+		words := splitWords(field.text)
+		var hits float64
+		for _, word := range words {
+			for i, term := range terms {
+				if strings.HasPrefix(strings.ToLower(word), term) {
+					found[i] = true
+					hits++
+					break
+				}
+			}
+		}
+		result.Rank += hits * field.weight
+		if hits*field.weight > best {
+			best = hits * field.weight
+			result.Snippet = snippet(words, terms)
+		}
+	}
+
+	for _, ok := range found {
+		if !ok {
+			return result, false
+		}
+	}
+	return result, true
+}
+
+// snippet cuts a window of words around the first match and marks matches.
+func snippet(words, terms []string) string {
+	first := 0
+	for i, word := range words {
+		if matchesAny(word, terms) {
+			first = i
+			break
+		}
+	}
+
+	start := first - snippetWords/2
+	if start < 0 {
+		start = 0
+	}
+	end := start + snippetWords
+	if end > len(words) {
+		end = len(words)
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	for i := start; i < end; i++ {
+		if i > start {
+			b.WriteString(" ")
+		}
+		if matchesAny(words[i], terms) {
+			b.WriteString(entity.MatchStart + words[i] + entity.MatchEnd)
+		} else {
+			b.WriteString(words[i])
+		}
+	}
+	if end < len(words) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
+
+func matchesAny(word string, terms []string) bool {
+	word = strings.ToLower(word)
+	for _, term := range terms {
+		if strings.HasPrefix(word, term) {
+			return true
+		}
+	}
+	return false
+}
+
+func splitWords(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func topResults(results []entity.SearchResult, limit int) []entity.SearchResult {
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Rank > results[j].Rank
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
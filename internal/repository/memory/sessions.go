@@ -0,0 +1,162 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"forum/internal/entity"
+)
+
+type SessionsRepo struct {
+	*DB
+}
+
+func NewSessionsRepo(db *DB) *SessionsRepo {
+	return &SessionsRepo{db}
+}
+
+func (sr *SessionsRepo) Store(ctx context.Context, session *entity.Session) error {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	for _, existed := range sr.sessions {
+		if existed.token == session.Token {
+			return fmt.Errorf("SessionsRepo - Store - %w", uniqueErr("sessions", "token"))
+		}
+	}
+
+	sr.lastSessionId++
+	session.Id = sr.lastSessionId
+	sr.sessions = append(sr.sessions, sessionRow{
+		id:        session.Id,
+		userId:    session.User.Id,
+		token:     session.Token,
+		userAgent: session.UserAgent,
+		ip:        session.IP,
+		createdAt: storedTime(session.CreatedAt),
+		lastSeen:  storedTime(session.LastSeen),
+		expiresAt: storedTime(session.ExpiresAt),
+	})
+
+	return nil
+}
+
+// GetByToken returns the session with the Id, the Timezone,
+// EmailVerified, Banned and the Role.Id of its user.
+func (sr *SessionsRepo) GetByToken(ctx context.Context, token string) (entity.Session, error) {
+	sr.mu.RLock()
+	defer sr.mu.RUnlock()
+
+	for _, row := range sr.sessions {
+		if row.token != token {
+			continue
+		}
+		i := sr.findUser(row.userId)
+		if i < 0 {
+			break
+		}
+		session := toSession(row)
+		session.User.Timezone = sr.users[i].timezone
+		session.User.EmailVerified = sr.users[i].emailVerified
+		session.User.Banned = sr.users[i].banned
+		session.User.Role.Id = sr.users[i].roleId
+		return session, nil
+	}
+
+	return entity.Session{}, fmt.Errorf("SessionsRepo - GetByToken - %w", errNoRows)
+}
+
+// FetchByUser lists the sessions of the user, the last seen first.
+func (sr *SessionsRepo) FetchByUser(ctx context.Context, userId int64) ([]entity.Session, error) {
+	sr.mu.RLock()
+	defer sr.mu.RUnlock()
+
+	var sessions []entity.Session
+	for _, row := range sr.sessions {
+		if row.userId == userId {
+			sessions = append(sessions, toSession(row))
+		}
+	}
+	sort.SliceStable(sessions, func(i, j int) bool {
+		if !sessions[i].LastSeen.Equal(sessions[j].LastSeen) {
+			return sessions[i].LastSeen.After(sessions[j].LastSeen)
+		}
+		return sessions[i].Id > sessions[j].Id
+	})
+
+	return sessions, nil
+}
+
+// Update overwrites the address, LastSeen and ExpiresAt of the session.
+func (sr *SessionsRepo) Update(ctx context.Context, session entity.Session) error {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	for i := range sr.sessions {
+		if sr.sessions[i].id == session.Id {
+			sr.sessions[i].ip = session.IP
+			sr.sessions[i].lastSeen = storedTime(session.LastSeen)
+			sr.sessions[i].expiresAt = storedTime(session.ExpiresAt)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("SessionsRepo - Update - %w", errNoRows)
+}
+
+func (sr *SessionsRepo) Delete(ctx context.Context, id int64) error {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	sr.deleteSessions(func(row sessionRow) bool { return row.id == id })
+	return nil
+}
+
+func (sr *SessionsRepo) DeleteByUser(ctx context.Context, userId int64) error {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	sr.deleteSessions(func(row sessionRow) bool { return row.userId == userId })
+	return nil
+}
+
+// DeleteExpired removes the sessions expired before the given time and
+// returns how many there were.
+func (sr *SessionsRepo) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	before = storedTime(before)
+	return sr.deleteSessions(func(row sessionRow) bool { return row.expiresAt.Before(before) }), nil
+}
+
+// deleteSessions drops the sessions matching and returns how many there
+// were. The caller holds the write lock.
+func (sr *SessionsRepo) deleteSessions(match func(sessionRow) bool) int64 {
+	var deleted int64
+	sessions := sr.sessions[:0]
+	for _, row := range sr.sessions {
+		if match(row) {
+			deleted++
+			continue
+		}
+		sessions = append(sessions, row)
+	}
+	sr.sessions = sessions
+	return deleted
+}
+
+func toSession(row sessionRow) entity.Session {
+	return entity.Session{
+		Id:        row.id,
+		User:      entity.User{Id: row.userId},
+		Token:     row.token,
+		UserAgent: row.userAgent,
+		IP:        row.ip,
+		CreatedAt: row.createdAt,
+		LastSeen:  row.lastSeen,
+		ExpiresAt: row.expiresAt,
+	}
+}
@@ -0,0 +1,216 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"forum/internal/entity"
+)
+
+func (pr *PostsRepo) Restore(ctx context.Context, id int64) error {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	i := pr.findPost(id)
+	if i < 0 || pr.posts[i].deletedAt.IsZero() {
+		return fmt.Errorf("PostsRepo - Restore - %w", errNoRows)
+	}
+	pr.posts[i].deletion = deletion{}
+
+	return nil
+}
+
+func (pr *PostsRepo) FetchDeleted(ctx context.Context) ([]entity.Post, error) {
+	pr.mu.RLock()
+	defer pr.mu.RUnlock()
+
+	var posts []entity.Post
+	for _, row := range pr.posts {
+		if !row.deletedAt.IsZero() {
+			posts = append(posts, pr.toEntity(row))
+		}
+	}
+	sort.SliceStable(posts, func(i, j int) bool {
+		return posts[i].DeletedAt.After(posts[j].DeletedAt)
+	})
+
+	return posts, nil
+}
+
+// Purge removes posts deleted before the given time for good, along with
+// their comments, categories, images, reactions and revisions.
+func (pr *PostsRepo) Purge(ctx context.Context, before time.Time) (int64, error) {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	purged := pr.removePosts(func(row postRow) bool {
+		return !row.deletedAt.IsZero() && row.deletedAt.Before(before)
+	})
+
+	return int64(len(purged)), nil
+}
+
+// PurgeByUser removes the posts of the user for good the way Purge does
+// and forgets that the user moved other posts to the trash.
+func (pr *PostsRepo) PurgeByUser(ctx context.Context, userId int64) error {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	pr.removePosts(func(row postRow) bool { return row.userId == userId })
+	for i := range pr.posts {
+		if pr.posts[i].deletedBy == userId {
+			pr.posts[i].deletedBy = 0
+		}
+	}
+
+	return nil
+}
+
+// removePosts drops posts matching purge with their comments, categories,
+// images, reactions and revisions and returns their ids. The caller holds
+// the write lock.
+func (db *DB) removePosts(purge func(postRow) bool) map[int64]bool {
+	purged := make(map[int64]bool)
+	kept := db.posts[:0]
+	for _, row := range db.posts {
+		if purge(row) {
+			purged[row.id] = true
+			continue
+		}
+		kept = append(kept, row)
+	}
+	db.posts = kept
+
+	db.removeComments(func(row commentRow) bool { return purged[row.postId] })
+
+	refs := db.topicRefs[:0]
+	for _, ref := range db.topicRefs {
+		if !purged[ref.postId] {
+			refs = append(refs, ref)
+		}
+	}
+	db.topicRefs = refs
+
+	db.removeTargets(entity.ReactionTargetPost, purged,
+		func(image imageRow) bool { return purged[image.postId] })
+
+	return purged
+}
+
+func (cr *CommentsRepo) Restore(ctx context.Context, id int64) error {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	i := cr.findComment(id)
+	if i < 0 || cr.comments[i].deletedAt.IsZero() {
+		return fmt.Errorf("CommentsRepo - Restore - %w", errNoRows)
+	}
+	cr.comments[i].deletion = deletion{}
+
+	return nil
+}
+
+func (cr *CommentsRepo) FetchDeleted(ctx context.Context) ([]entity.Comment, error) {
+	cr.mu.RLock()
+	defer cr.mu.RUnlock()
+
+	var comments []entity.Comment
+	for _, row := range cr.comments {
+		if !row.deletedAt.IsZero() {
+			comments = append(comments, cr.toCommentWithImages(row))
+		}
+	}
+	sort.SliceStable(comments, func(i, j int) bool {
+		return comments[i].DeletedAt.After(comments[j].DeletedAt)
+	})
+
+	return comments, nil
+}
+
+// Purge removes comments deleted before the given time for good, along
+// with their images, reactions and revisions. Deleted comments with
+// replies are kept.
+func (cr *CommentsRepo) Purge(ctx context.Context, before time.Time) (int64, error) {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	parents := make(map[int64]bool)
+	for _, row := range cr.comments {
+		parents[row.parentId] = true
+	}
+	purged := cr.removeComments(func(row commentRow) bool {
+		return !row.deletedAt.IsZero() && row.deletedAt.Before(before) && !parents[row.id]
+	})
+
+	return int64(len(purged)), nil
+}
+
+// PurgeByUser removes the comments of the user for good the way Purge
+// does, replies to them move to the top of their post. It forgets that the
+// user moved other comments to the trash.
+func (cr *CommentsRepo) PurgeByUser(ctx context.Context, userId int64) error {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	purged := cr.removeComments(func(row commentRow) bool { return row.userId == userId })
+	for i := range cr.comments {
+		if purged[cr.comments[i].parentId] {
+			cr.comments[i].parentId = 0
+		}
+		if cr.comments[i].deletedBy == userId {
+			cr.comments[i].deletedBy = 0
+		}
+	}
+
+	return nil
+}
+
+// removeComments drops comments matching purge with their images,
+// reactions and revisions and returns their ids. The caller holds the write lock.
+func (db *DB) removeComments(purge func(commentRow) bool) map[int64]bool {
+	purged := make(map[int64]bool)
+	kept := db.comments[:0]
+	for _, row := range db.comments {
+		if purge(row) {
+			purged[row.id] = true
+			continue
+		}
+		kept = append(kept, row)
+	}
+	db.comments = kept
+
+	db.removeTargets(entity.ReactionTargetComment, purged,
+		func(image imageRow) bool { return purged[image.commentId] })
+
+	return purged
+}
+
+// removeTargets drops reactions and revisions of the given targets and
+// images matching the filter. The caller holds the write lock.
+func (db *DB) removeTargets(target string, ids map[int64]bool, image func(imageRow) bool) {
+	reactions := db.reactions[:0]
+	for _, row := range db.reactions {
+		if row.target != target || !ids[row.targetId] {
+			reactions = append(reactions, row)
+		}
+	}
+	db.reactions = reactions
+
+	revisions := db.revisions[:0]
+	for _, row := range db.revisions {
+		if row.target != target || !ids[row.targetId] {
+			revisions = append(revisions, row)
+		}
+	}
+	db.revisions = revisions
+
+	images := db.images[:0]
+	for _, row := range db.images {
+		if !image(row) {
+			images = append(images, row)
+		}
+	}
+	db.images = images
+}
@@ -0,0 +1,226 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"forum/internal/entity"
+)
+
+type TwoFactorRepo struct {
+	*DB
+}
+
+func NewTwoFactorRepo(db *DB) *TwoFactorRepo {
+	return &TwoFactorRepo{db}
+}
+
+// Get returns the state of the user with the number of recovery codes
+// left.
+func (tr *TwoFactorRepo) Get(ctx context.Context, userId int64) (entity.TwoFactor, error) {
+	tr.mu.RLock()
+	defer tr.mu.RUnlock()
+
+	i := tr.findUser(userId)
+	if i < 0 {
+		return entity.TwoFactor{}, fmt.Errorf("TwoFactorRepo - Get - %w", errNoRows)
+	}
+	tf := entity.TwoFactor{
+		UserId:   userId,
+		Secret:   tr.users[i].totpSecret,
+		Enabled:  tr.users[i].totpEnabled,
+		LastStep: tr.users[i].totpLastStep,
+	}
+	for _, row := range tr.codes {
+		if row.userId == userId {
+			tf.RecoveryCodes++
+		}
+	}
+	return tf, nil
+}
+
+// Update overwrites Secret, Enabled and LastStep.
+func (tr *TwoFactorRepo) Update(ctx context.Context, tf entity.TwoFactor) error {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	i := tr.findUser(tf.UserId)
+	if i < 0 {
+		return fmt.Errorf("TwoFactorRepo - Update - %w", errNoRows)
+	}
+	tr.users[i].totpSecret = tf.Secret
+	tr.users[i].totpEnabled = tf.Enabled
+	tr.users[i].totpLastStep = tf.LastStep
+	return nil
+}
+
+// UseStep moves LastStep to step when it is later, it returns false for
+// steps taken already.
+func (tr *TwoFactorRepo) UseStep(ctx context.Context, userId, step int64) (bool, error) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	i := tr.findUser(userId)
+	if i < 0 || tr.users[i].totpLastStep >= step {
+		return false, nil
+	}
+	tr.users[i].totpLastStep = step
+	return true, nil
+}
+
+// StoreRecoveryCodes replaces the recovery codes of the user.
+func (tr *TwoFactorRepo) StoreRecoveryCodes(ctx context.Context, userId int64, codeHashes []string) error {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	tr.deleteCodes(func(row recoveryCodeRow) bool { return row.userId == userId })
+	for _, codeHash := range codeHashes {
+		tr.lastCodeId++
+		tr.codes = append(tr.codes, recoveryCodeRow{id: tr.lastCodeId, userId: userId, codeHash: codeHash})
+	}
+	return nil
+}
+
+// UseRecoveryCode removes the code of the user and returns false when
+// there was no such code.
+func (tr *TwoFactorRepo) UseRecoveryCode(ctx context.Context, userId int64, codeHash string) (bool, error) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	deleted := tr.deleteCodes(func(row recoveryCodeRow) bool {
+		return row.userId == userId && row.codeHash == codeHash
+	})
+	return deleted > 0, nil
+}
+
+func (tr *TwoFactorRepo) DeleteRecoveryCodes(ctx context.Context, userId int64) error {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	tr.deleteCodes(func(row recoveryCodeRow) bool { return row.userId == userId })
+	return nil
+}
+
+// deleteCodes drops the codes matching and returns how many there were.
+// The caller holds the write lock.
+func (tr *TwoFactorRepo) deleteCodes(match func(recoveryCodeRow) bool) int64 {
+	var deleted int64
+	codes := tr.codes[:0]
+	for _, row := range tr.codes {
+		if match(row) {
+			deleted++
+			continue
+		}
+		codes = append(codes, row)
+	}
+	tr.codes = codes
+	return deleted
+}
+
+type LoginChallengesRepo struct {
+	*DB
+}
+
+func NewLoginChallengesRepo(db *DB) *LoginChallengesRepo {
+	return &LoginChallengesRepo{db}
+}
+
+func (cr *LoginChallengesRepo) Store(ctx context.Context, challenge *entity.LoginChallenge) error {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	for _, existed := range cr.challenges {
+		if existed.tokenHash == challenge.TokenHash {
+			return fmt.Errorf("LoginChallengesRepo - Store - %w", uniqueErr("login_challenges", "token_hash"))
+		}
+	}
+
+	cr.lastChallengeId++
+	challenge.Id = cr.lastChallengeId
+	cr.challenges = append(cr.challenges, challengeRow{
+		id:        challenge.Id,
+		userId:    challenge.User.Id,
+		tokenHash: challenge.TokenHash,
+		attempts:  challenge.Attempts,
+		createdAt: storedTime(challenge.CreatedAt),
+		expiresAt: storedTime(challenge.ExpiresAt),
+	})
+
+	return nil
+}
+
+func (cr *LoginChallengesRepo) GetByHash(ctx context.Context, tokenHash string) (entity.LoginChallenge, error) {
+	cr.mu.RLock()
+	defer cr.mu.RUnlock()
+
+	for _, row := range cr.challenges {
+		if row.tokenHash == tokenHash {
+			return entity.LoginChallenge{
+				Id:        row.id,
+				User:      entity.User{Id: row.userId},
+				TokenHash: row.tokenHash,
+				Attempts:  row.attempts,
+				CreatedAt: row.createdAt,
+				ExpiresAt: row.expiresAt,
+			}, nil
+		}
+	}
+
+	return entity.LoginChallenge{}, fmt.Errorf("LoginChallengesRepo - GetByHash - %w", errNoRows)
+}
+
+func (cr *LoginChallengesRepo) AddAttempt(ctx context.Context, id int64, max int) (bool, error) {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	for i := range cr.challenges {
+		if cr.challenges[i].id == id && cr.challenges[i].attempts < max {
+			cr.challenges[i].attempts++
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (cr *LoginChallengesRepo) Delete(ctx context.Context, id int64) error {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	cr.deleteChallenges(func(row challengeRow) bool { return row.id == id })
+	return nil
+}
+
+func (cr *LoginChallengesRepo) DeleteByUser(ctx context.Context, userId int64) error {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	cr.deleteChallenges(func(row challengeRow) bool { return row.userId == userId })
+	return nil
+}
+
+// DeleteExpired removes the challenges expired before the given time and
+// returns how many there were.
+func (cr *LoginChallengesRepo) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	before = storedTime(before)
+	return cr.deleteChallenges(func(row challengeRow) bool { return row.expiresAt.Before(before) }), nil
+}
+
+// deleteChallenges drops the challenges matching and returns how many
+// there were. The caller holds the write lock.
+func (cr *LoginChallengesRepo) deleteChallenges(match func(challengeRow) bool) int64 {
+	var deleted int64
+	challenges := cr.challenges[:0]
+	for _, row := range cr.challenges {
+		if match(row) {
+			deleted++
+			continue
+		}
+		challenges = append(challenges, row)
+	}
+	cr.challenges = challenges
+	return deleted
+}
@@ -0,0 +1,325 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"forum/internal/entity"
+)
+
+type UsersRepo struct {
+	*DB
+}
+
+func NewUsersRepo(db *DB) *UsersRepo {
+	return &UsersRepo{db}
+}
+
+func (ur *UsersRepo) Store(ctx context.Context, user entity.User) error {
+	ur.mu.Lock()
+	defer ur.mu.Unlock()
+
+	for _, row := range ur.users {
+		if row.name == user.Name {
+			return fmt.Errorf("UsersRepo - Store - %w", uniqueErr("users", "name"))
+		}
+		if row.email == user.Email {
+			return fmt.Errorf("UsersRepo - Store - %w", uniqueErr("users", "email"))
+		}
+	}
+
+	ur.lastUserId++
+	ur.users = append(ur.users, userRow{
+		id:            ur.lastUserId,
+		name:          user.Name,
+		email:         user.Email,
+		emailVerified: user.EmailVerified,
+		password:      user.Password,
+		regDate:       storedTime(user.RegDate),
+		dateOfBirth:   user.DateOfBirth,
+		city:          user.City,
+		gender:        user.Gender,
+		roleId:        entity.RoleUserId,
+		sign:          " ",
+	})
+
+	return nil
+}
+
+func (ur *UsersRepo) Fetch(ctx context.Context) ([]entity.User, error) {
+	ur.mu.RLock()
+	defer ur.mu.RUnlock()
+
+	var users []entity.User
+	for _, row := range ur.users {
+		users = append(users, ur.toListed(row))
+	}
+
+	return users, nil
+}
+
+func (ur *UsersRepo) FetchByIds(ctx context.Context, ids []int64) ([]entity.User, error) {
+	ur.mu.RLock()
+	defer ur.mu.RUnlock()
+
+	wanted := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	var users []entity.User
+	for _, row := range ur.users {
+		if wanted[row.id] {
+			users = append(users, ur.toListed(row))
+		}
+	}
+
+	return users, nil
+}
+
+func (ur *UsersRepo) FetchPage(ctx context.Context, limit, offset int) ([]entity.User, error) {
+	ur.mu.RLock()
+	defer ur.mu.RUnlock()
+
+	var users []entity.User
+	from, to := window(len(ur.users), limit, offset)
+	for _, row := range ur.users[from:to] {
+		users = append(users, ur.toListed(row))
+	}
+
+	return users, nil
+}
+
+func (ur *UsersRepo) FetchAfter(ctx context.Context, cursor int64, limit int) ([]entity.User, error) {
+	ur.mu.RLock()
+	defer ur.mu.RUnlock()
+
+	var users []entity.User
+	for _, row := range ur.users {
+		if len(users) == limit {
+			break
+		}
+		if row.id > cursor {
+			users = append(users, ur.toListed(row))
+		}
+	}
+
+	return users, nil
+}
+
+func (ur *UsersRepo) Count(ctx context.Context) (int64, error) {
+	ur.mu.RLock()
+	defer ur.mu.RUnlock()
+
+	return int64(len(ur.users)), nil
+}
+
+// toListed converts a row for user listings, which never carry secrets.
+func (ur *UsersRepo) toListed(row userRow) entity.User {
+	user := ur.toEntity(row)
+	user.Password = ""
+	user.Sign = ""
+	return user
+}
+
+func (ur *UsersRepo) GetId(ctx context.Context, user entity.User) (int64, error) {
+	ur.mu.RLock()
+	defer ur.mu.RUnlock()
+
+	var match func(userRow) bool
+	var field string
+	switch {
+	case user.Name != "":
+		match = func(row userRow) bool { return row.name == user.Name }
+		field = "Name"
+	case user.Email != "":
+		match = func(row userRow) bool { return row.email == user.Email }
+		field = "Email"
+	default:
+		return 0, nil
+	}
+
+	for _, row := range ur.users {
+		if match(row) {
+			return row.id, nil
+		}
+	}
+
+	return 0, fmt.Errorf("UsersRepo - GetId - case %s - %w", field, errNoRows)
+}
+
+func (ur *UsersRepo) GetById(ctx context.Context, id int64) (entity.User, error) {
+	ur.mu.RLock()
+	defer ur.mu.RUnlock()
+
+	i := ur.findUser(id)
+	if i < 0 {
+		return entity.User{}, fmt.Errorf("UsersRepo - GetById - %w", errNoRows)
+	}
+
+	user := ur.toEntity(ur.users[i])
+	user.EmailVerified = ur.users[i].emailVerified
+	user.PendingEmail = ur.users[i].pendingEmail
+	user.VerificationSentAt = ur.users[i].verificationSentAt
+	user.TwoFactor = ur.users[i].totpEnabled
+	user.Banned = ur.users[i].banned
+	user.AvatarPath = ur.imagePath(func(image imageRow) bool {
+		return image.userId == id
+	})
+	if user.DateOfBirth == "0001-01-01" {
+		user.DateOfBirth = ""
+	}
+
+	return user, nil
+}
+
+func (ur *UsersRepo) UpdateInfo(ctx context.Context, user entity.User) error {
+	ur.mu.Lock()
+	defer ur.mu.Unlock()
+
+	i := ur.findUser(user.Id)
+	if i < 0 {
+		return fmt.Errorf("UsersRepo - UpdateInfo - %w", errNoRows)
+	}
+	ur.users[i].dateOfBirth = user.DateOfBirth
+	ur.users[i].city = user.City
+	ur.users[i].gender = user.Gender
+	ur.users[i].sign = user.Sign
+	ur.users[i].timezone = user.Timezone
+
+	for j := range ur.images {
+		if ur.images[j].userId == user.Id {
+			ur.images[j].path = user.AvatarPath
+			return nil
+		}
+	}
+	ur.images = append(ur.images, imageRow{userId: user.Id, path: user.AvatarPath})
+
+	return nil
+}
+
+func (ur *UsersRepo) UpdatePassword(ctx context.Context, user entity.User) error {
+	ur.mu.Lock()
+	defer ur.mu.Unlock()
+
+	i := ur.findUser(user.Id)
+	if i < 0 {
+		return fmt.Errorf("UsersRepo - UpdatePassword - %w", errNoRows)
+	}
+	ur.users[i].password = user.Password
+
+	return nil
+}
+
+// UpdateVerification overwrites PendingEmail and VerificationSentAt.
+func (ur *UsersRepo) UpdateVerification(ctx context.Context, user entity.User) error {
+	ur.mu.Lock()
+	defer ur.mu.Unlock()
+
+	i := ur.findUser(user.Id)
+	if i < 0 {
+		return fmt.Errorf("UsersRepo - UpdateVerification - %w", errNoRows)
+	}
+	ur.users[i].pendingEmail = user.PendingEmail
+	ur.users[i].verificationSentAt = storedTime(user.VerificationSentAt)
+
+	return nil
+}
+
+// VerifyEmail makes email the verified email of the user and clears the
+// pending one.
+func (ur *UsersRepo) VerifyEmail(ctx context.Context, id int64, email string) error {
+	ur.mu.Lock()
+	defer ur.mu.Unlock()
+
+	i := ur.findUser(id)
+	if i < 0 {
+		return fmt.Errorf("UsersRepo - VerifyEmail - %w", errNoRows)
+	}
+	for j, row := range ur.users {
+		if j != i && row.email == email {
+			return fmt.Errorf("UsersRepo - VerifyEmail - %w", uniqueErr("users", "email"))
+		}
+	}
+	ur.users[i].email = email
+	ur.users[i].emailVerified = true
+	ur.users[i].pendingEmail = ""
+
+	return nil
+}
+
+// SetRole gives the user the role roleId.
+func (ur *UsersRepo) SetRole(ctx context.Context, id, roleId int64) error {
+	ur.mu.Lock()
+	defer ur.mu.Unlock()
+
+	i := ur.findUser(id)
+	if i < 0 {
+		return fmt.Errorf("UsersRepo - SetRole - %w", errNoRows)
+	}
+	ur.users[i].roleId = roleId
+
+	return nil
+}
+
+// SetBanned bans the user or lifts the ban.
+func (ur *UsersRepo) SetBanned(ctx context.Context, id int64, banned bool) error {
+	ur.mu.Lock()
+	defer ur.mu.Unlock()
+
+	i := ur.findUser(id)
+	if i < 0 {
+		return fmt.Errorf("UsersRepo - SetBanned - %w", errNoRows)
+	}
+	ur.users[i].banned = banned
+
+	return nil
+}
+
+func (ur *UsersRepo) Delete(ctx context.Context, user entity.User) error {
+	ur.mu.Lock()
+	defer ur.mu.Unlock()
+
+	i := ur.findUser(user.Id)
+	if i < 0 {
+		return fmt.Errorf("UsersRepo - Delete - %w", errNoRows)
+	}
+	ur.users = append(ur.users[:i], ur.users[i+1:]...)
+
+	images := ur.images[:0]
+	for _, row := range ur.images {
+		if row.userId != user.Id {
+			images = append(images, row)
+		}
+	}
+	ur.images = images
+
+	return nil
+}
+
+func (ur *UsersRepo) toEntity(row userRow) entity.User {
+	var user entity.User
+	user.Id = row.id
+	user.Name = row.name
+	user.Email = row.email
+	user.Password = row.password
+	user.RegDate = row.regDate
+	user.DateOfBirth = row.dateOfBirth
+	user.City = row.city
+	user.Gender = row.gender
+	user.Role.Id = row.roleId
+	user.Role.Name = ur.roleName(row.roleId)
+	user.Sign = row.sign
+	user.Timezone = row.timezone
+
+	for _, post := range ur.posts {
+		if post.userId == row.id && post.deletedAt.IsZero() {
+			user.Posts++
+		}
+	}
+	for _, comment := range ur.comments {
+		if comment.userId == row.id && comment.deletedAt.IsZero() {
+			user.Comments++
+		}
+	}
+	return user
+}
@@ -0,0 +1,195 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"forum/internal/entity"
+	"forum/pkg/postgres"
+)
+
+type CategoriesRepo struct {
+	*postgres.Postgres
+}
+
+func NewCategoriesRepo(pg *postgres.Postgres) *CategoriesRepo {
+	return &CategoriesRepo{pg}
+}
+
+// categoryColumns are scanned by scanCategory, topics has to be in the
+// query under its own name.
+const categoryColumns = `
+	topics.id, topics.parent_id, topics.name, topics.slug, topics.description, topics.position, topics.color`
+
+// scanCategory scans categoryColumns followed by dest.
+func scanCategory(scan func(dest ...interface{}) error, dest ...interface{}) (entity.Category, error) {
+	var category entity.Category
+	var parentId sql.NullInt64
+	err := scan(append([]interface{}{&category.Id, &parentId, &category.Name, &category.Slug,
+		&category.Description, &category.Position, &category.Color}, dest...)...)
+	category.ParentId = parentId.Int64
+	return category, err
+}
+
+// nullId stores the zero id as NULL.
+func nullId(id int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: id, Valid: id != 0}
+}
+
+func (cr *CategoriesRepo) Store(ctx context.Context, category *entity.Category) error {
+	err := cr.Conn.QueryRowContext(ctx, `
+	INSERT INTO topics(parent_id, name, slug, description, position, color)
+		VALUES($1, $2, $3, $4, $5, $6)
+	RETURNING id
+	`, nullId(category.ParentId), category.Name, category.Slug, category.Description, category.Position,
+		category.Color).Scan(&category.Id)
+	if err != nil {
+		return fmt.Errorf("CategoriesRepo - Store - Scan: %w", wrapErr(err))
+	}
+	return nil
+}
+
+// Fetch lists every category with the counts of its live posts and their
+// comments and the date of the latest of them.
+func (cr *CategoriesRepo) Fetch(ctx context.Context) ([]entity.Category, error) {
+	var categories []entity.Category
+
+	rows, err := cr.Conn.QueryContext(ctx, `
+	SELECT`+categoryColumns+`,
+		COUNT(posts.id), COALESCE(SUM(posts.comment_count), 0), MAX(posts.date),
+		(SELECT MAX(comments.date)
+		FROM comments
+		JOIN reference_topic AS ref ON ref.post_id = comments.post_id
+		JOIN posts AS commented ON commented.id = comments.post_id
+		WHERE ref.topic_id = topics.id AND comments.deleted_at IS NULL AND commented.deleted_at IS NULL)
+	FROM topics
+	LEFT JOIN reference_topic ON reference_topic.topic_id = topics.id
+	LEFT JOIN posts ON posts.id = reference_topic.post_id AND posts.deleted_at IS NULL
+	GROUP BY topics.id
+	ORDER BY topics.position, topics.name, topics.id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("CategoriesRepo - Fetch - Query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var lastPost, lastComment sql.NullString
+		var posts, comments int64
+		category, err := scanCategory(rows.Scan, &posts, &comments, &lastPost, &lastComment)
+		if err != nil {
+			return nil, fmt.Errorf("CategoriesRepo - Fetch - Scan: %w", err)
+		}
+		category.Posts, category.Comments = posts, comments
+		category.LastActivity = parseTime(lastPost)
+		if commented := parseTime(lastComment); commented.After(category.LastActivity) {
+			category.LastActivity = commented
+		}
+		categories = append(categories, category)
+	}
+	return categories, nil
+}
+
+func (cr *CategoriesRepo) GetById(ctx context.Context, id int64) (entity.Category, error) {
+	row := cr.Conn.QueryRowContext(ctx, `SELECT`+categoryColumns+` FROM topics WHERE id = $1`, id)
+	category, err := scanCategory(row.Scan)
+	if err != nil {
+		return category, fmt.Errorf("CategoriesRepo - GetById - Scan: %w", err)
+	}
+	return category, nil
+}
+
+func (cr *CategoriesRepo) GetBySlug(ctx context.Context, slug string) (entity.Category, error) {
+	row := cr.Conn.QueryRowContext(ctx, `SELECT`+categoryColumns+` FROM topics WHERE slug = $1`, slug)
+	category, err := scanCategory(row.Scan)
+	if err != nil {
+		return category, fmt.Errorf("CategoriesRepo - GetBySlug - Scan: %w", err)
+	}
+	return category, nil
+}
+
+// Update overwrites every field of the category but its Id.
+func (cr *CategoriesRepo) Update(ctx context.Context, category entity.Category) error {
+	res, err := cr.Conn.ExecContext(ctx, `
+	UPDATE topics
+	SET parent_id = $1, name = $2, slug = $3, description = $4, position = $5, color = $6
+	WHERE id = $7
+	`, nullId(category.ParentId), category.Name, category.Slug, category.Description, category.Position,
+		category.Color, category.Id)
+	if err != nil {
+		return fmt.Errorf("CategoriesRepo - Update - Exec: %w", wrapErr(err))
+	}
+
+	affected, err := res.RowsAffected()
+	if affected != 1 || err != nil {
+		return fmt.Errorf("CategoriesRepo - Update - RowsAffected: %w", err)
+	}
+	return nil
+}
+
+// MoveReferences moves the posts of the category fromId to the category
+// toId, posts already there are referenced once. The references are
+// dropped when toId is zero.
+func (cr *CategoriesRepo) MoveReferences(ctx context.Context, fromId, toId int64) error {
+	tx, err := cr.Conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("CategoriesRepo - MoveReferences - Begin: %w", err)
+	}
+	defer func() {
+		err = tx.Rollback()
+	}()
+
+	if toId != 0 {
+		_, err = tx.ExecContext(ctx, `
+		INSERT INTO reference_topic(post_id, topic_id)
+			SELECT post_id, $1::bigint FROM reference_topic WHERE topic_id = $2
+		ON CONFLICT DO NOTHING
+		`, toId, fromId)
+		if err != nil {
+			return fmt.Errorf("CategoriesRepo - MoveReferences - Exec #1: %w", err)
+		}
+	}
+	_, err = tx.ExecContext(ctx, `DELETE FROM reference_topic WHERE topic_id = $1`, fromId)
+	if err != nil {
+		return fmt.Errorf("CategoriesRepo - MoveReferences - Exec #2: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("CategoriesRepo - MoveReferences - Commit: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the category with its post references. Its children
+// have to be moved beforehand.
+func (cr *CategoriesRepo) Delete(ctx context.Context, id int64) error {
+	tx, err := cr.Conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("CategoriesRepo - Delete - Begin: %w", err)
+	}
+	defer func() {
+		err = tx.Rollback()
+	}()
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM reference_topic WHERE topic_id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("CategoriesRepo - Delete - Exec #1: %w", err)
+	}
+	res, err := tx.ExecContext(ctx, `DELETE FROM topics WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("CategoriesRepo - Delete - Exec #2: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if affected != 1 || err != nil {
+		return fmt.Errorf("CategoriesRepo - Delete - RowsAffected: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("CategoriesRepo - Delete - Commit: %w", err)
+	}
+	return nil
+}
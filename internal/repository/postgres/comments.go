@@ -0,0 +1,333 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"forum/internal/entity"
+	"forum/pkg/postgres"
+
+	"github.com/lib/pq"
+)
+
+type CommentsRepo struct {
+	*postgres.Postgres
+}
+
+func NewCommentsRepo(pg *postgres.Postgres) *CommentsRepo {
+	return &CommentsRepo{pg}
+}
+
+func (cr *CommentsRepo) Store(ctx context.Context, comment *entity.Comment) error {
+	tx, err := cr.Conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("CommentsRepo - Store - Begin: %w", err)
+	}
+	defer func() {
+		err = tx.Rollback()
+	}()
+
+	var id int64
+	parentId := sql.NullInt64{Int64: comment.ParentId, Valid: comment.ParentId != 0}
+	err = tx.QueryRowContext(ctx, `
+	INSERT INTO comments(post_id, parent_id, user_id, date, content)
+		values($1, $2, $3, $4, $5)
+	RETURNING id
+	`, comment.PostId, parentId, comment.User.Id, formatTime(comment.Date), comment.Content).Scan(&id)
+	if err != nil {
+		return fmt.Errorf("CommentsRepo - Store - Scan: %w", err)
+	}
+	comment.Id = id
+
+	err = storeImages(ctx, tx, "comment_id", id, comment.Images)
+	if err != nil {
+		return fmt.Errorf("CommentsRepo - Store - %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("CommentsRepo - Store - Commit: %w", err)
+	}
+
+	return nil
+}
+
+// selectComments lists comments with their images and deletion marks,
+// callers append conditions, ordering and limits. Deleted comments stay
+// in the listings of a post so replies keep their place.
+const selectComments = `
+	SELECT
+		id, post_id, parent_id, user_id, date, content,
+		` + commentImages + `,
+		deleted_at, deleted_by, delete_reason, edited_at
+	FROM comments
+	`
+
+// Fetch lists every comment of the post, replies included, oldest first.
+func (cr *CommentsRepo) Fetch(ctx context.Context, postId int64) ([]entity.Comment, error) {
+	rows, err := cr.Conn.QueryContext(ctx, selectComments+`
+	WHERE post_id = $1
+	ORDER BY id
+	`, postId)
+	if err != nil {
+		return nil, fmt.Errorf("CommentsRepo - Fetch - Query: %w", err)
+	}
+	defer rows.Close()
+
+	comments, err := scanComments(rows)
+	if err != nil {
+		return nil, fmt.Errorf("CommentsRepo - Fetch - %w", err)
+	}
+	return comments, nil
+}
+
+func (cr *CommentsRepo) FetchByPosts(ctx context.Context, postIds []int64) (map[int64][]entity.Comment, error) {
+	byPost := make(map[int64][]entity.Comment, len(postIds))
+	if len(postIds) == 0 {
+		return byPost, nil
+	}
+
+	rows, err := cr.Conn.QueryContext(ctx, selectComments+`
+	WHERE post_id = ANY($1)
+	ORDER BY id
+	`, pq.Array(postIds))
+	if err != nil {
+		return nil, fmt.Errorf("CommentsRepo - FetchByPosts - Query: %w", err)
+	}
+	defer rows.Close()
+
+	comments, err := scanComments(rows)
+	if err != nil {
+		return nil, fmt.Errorf("CommentsRepo - FetchByPosts - %w", err)
+	}
+	for _, comment := range comments {
+		byPost[comment.PostId] = append(byPost[comment.PostId], comment)
+	}
+	return byPost, nil
+}
+
+// FetchReplies lists the replies under the comments at any depth, oldest
+// first. Only the threads of the comments are read, not the whole post.
+func (cr *CommentsRepo) FetchReplies(ctx context.Context, parentIds []int64) ([]entity.Comment, error) {
+	if len(parentIds) == 0 {
+		return nil, nil
+	}
+
+	rows, err := cr.Conn.QueryContext(ctx, `
+	WITH RECURSIVE thread(id) AS (
+		SELECT id FROM comments WHERE parent_id = ANY($1)
+		UNION
+		SELECT comments.id FROM comments JOIN thread ON comments.parent_id = thread.id
+	)`+selectComments+`
+	WHERE id IN (SELECT id FROM thread)
+	ORDER BY id
+	`, pq.Array(parentIds))
+	if err != nil {
+		return nil, fmt.Errorf("CommentsRepo - FetchReplies - Query: %w", err)
+	}
+	defer rows.Close()
+
+	comments, err := scanComments(rows)
+	if err != nil {
+		return nil, fmt.Errorf("CommentsRepo - FetchReplies - %w", err)
+	}
+	return comments, nil
+}
+
+// FetchPage lists top level comments of the post, replies are left to
+// FetchReplies.
+func (cr *CommentsRepo) FetchPage(ctx context.Context, postId int64, limit, offset int) ([]entity.Comment, error) {
+	rows, err := cr.Conn.QueryContext(ctx, selectComments+`
+	WHERE post_id = $1 AND parent_id IS NULL
+	ORDER BY id
+	LIMIT $2 OFFSET $3
+	`, postId, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("CommentsRepo - FetchPage - Query: %w", err)
+	}
+	defer rows.Close()
+
+	comments, err := scanComments(rows)
+	if err != nil {
+		return nil, fmt.Errorf("CommentsRepo - FetchPage - %w", err)
+	}
+	return comments, nil
+}
+
+// FetchAfter lists top level comments of the post with ids above cursor.
+func (cr *CommentsRepo) FetchAfter(ctx context.Context, postId, cursor int64, limit int) ([]entity.Comment, error) {
+	rows, err := cr.Conn.QueryContext(ctx, selectComments+`
+	WHERE post_id = $1 AND parent_id IS NULL AND id > $2
+	ORDER BY id
+	LIMIT $3
+	`, postId, cursor, limit)
+	if err != nil {
+		return nil, fmt.Errorf("CommentsRepo - FetchAfter - Query: %w", err)
+	}
+	defer rows.Close()
+
+	comments, err := scanComments(rows)
+	if err != nil {
+		return nil, fmt.Errorf("CommentsRepo - FetchAfter - %w", err)
+	}
+	return comments, nil
+}
+
+// Count counts top level comments of the post.
+func (cr *CommentsRepo) Count(ctx context.Context, postId int64) (int64, error) {
+	var count int64
+	err := cr.Conn.QueryRowContext(ctx, `
+	SELECT COUNT(*)
+	FROM comments
+	WHERE post_id = $1 AND parent_id IS NULL
+	`, postId).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("CommentsRepo - Count - Scan: %w", err)
+	}
+
+	return count, nil
+}
+
+func scanComments(rows *sql.Rows) ([]entity.Comment, error) {
+	var comments []entity.Comment
+
+	for rows.Next() {
+		var comment entity.Comment
+		var images sql.NullString
+		var date, deletedAt, deleteReason, editedAt sql.NullString
+		var deletedBy, parentId sql.NullInt64
+
+		err := rows.Scan(&comment.Id, &comment.PostId, &parentId, &comment.User.Id, &date, &comment.Content,
+			&images, &deletedAt, &deletedBy, &deleteReason, &editedAt)
+		if err != nil {
+			return nil, fmt.Errorf("Scan: %w", err)
+		}
+
+		comment.ParentId = parentId.Int64
+		comment.Images = splitImages(images)
+		comment.Date = parseTime(date)
+		comment.DeletedAt = parseTime(deletedAt)
+		comment.DeletedBy = deletedBy.Int64
+		comment.DeleteReason = deleteReason.String
+		comment.EditedAt = parseTime(editedAt)
+		comments = append(comments, comment)
+	}
+	return comments, rows.Err()
+}
+
+func (cr *CommentsRepo) GetById(ctx context.Context, commentId int64) (entity.Comment, error) {
+	var comment entity.Comment
+	var images sql.NullString
+	var date, deletedAt, deleteReason, editedAt sql.NullString
+	var deletedBy, parentId sql.NullInt64
+
+	err := cr.Conn.QueryRowContext(ctx, `
+	SELECT
+		id, post_id, parent_id, user_id, date, content,
+		`+commentImages+`,
+		deleted_at, deleted_by, delete_reason, edited_at
+	FROM comments
+	WHERE id = $1
+	`, commentId).Scan(&comment.Id, &comment.PostId, &parentId, &comment.User.Id, &date, &comment.Content,
+		&images, &deletedAt, &deletedBy, &deleteReason, &editedAt)
+	if err != nil {
+		return comment, fmt.Errorf("CommentsRepo - GetById - Scan: %w", err)
+	}
+	comment.ParentId = parentId.Int64
+	comment.Images = splitImages(images)
+	comment.Date = parseTime(date)
+	comment.DeletedAt = parseTime(deletedAt)
+	comment.DeletedBy = deletedBy.Int64
+	comment.DeleteReason = deleteReason.String
+	comment.EditedAt = parseTime(editedAt)
+
+	return comment, nil
+}
+
+// Update overwrites the content of the comment and marks it edited at
+// comment.EditedAt. Images are replaced unless they are nil.
+func (cr *CommentsRepo) Update(ctx context.Context, comment entity.Comment) error {
+	tx, err := cr.Conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("CommentsRepo - Update - Begin: %w", err)
+	}
+	defer func() {
+		err = tx.Rollback()
+	}()
+
+	res, err := tx.ExecContext(ctx, `
+	UPDATE comments
+	SET content = $1, edited_at = $2
+	WHERE id = $3
+	`, comment.Content, nullTime(comment.EditedAt), comment.Id)
+	if err != nil {
+		return fmt.Errorf("CommentsRepo - Update - Exec: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if affected != 1 || err != nil {
+		return fmt.Errorf("CommentsRepo - Update - RowsAffected: %w", err)
+	}
+	if comment.Images != nil {
+		err = replaceImages(ctx, tx, "comment_id", comment.Id, comment.Images)
+		if err != nil {
+			return fmt.Errorf("CommentsRepo - Update - %w", err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("CommentsRepo - Update - Commit: %w", err)
+	}
+
+	return nil
+}
+
+func (cr *CommentsRepo) GetPostIds(ctx context.Context, user entity.User) ([]int64, error) {
+	var postIds []int64
+
+	rows, err := cr.Conn.QueryContext(ctx, `
+	SELECT DISTINCT post_id
+	FROM comments
+	WHERE user_id = $1 AND deleted_at IS NULL
+	`, user.Id)
+	if err != nil {
+		return nil, fmt.Errorf("CommentsRepo - GetPostIds - Query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var postId sql.NullInt64
+		err = rows.Scan(&postId)
+		if err != nil {
+			return postIds, fmt.Errorf("CommentsRepo - GetPostIds - Scan: %w", err)
+		}
+		postIds = append(postIds, postId.Int64)
+	}
+
+	return postIds, nil
+}
+
+// Delete moves the comment to the trash, the deletion mark is taken from
+// the comment. Comments already in the trash are not found.
+func (cr *CommentsRepo) Delete(ctx context.Context, comment entity.Comment) error {
+	res, err := cr.Conn.ExecContext(ctx, `
+	UPDATE comments
+	SET deleted_at = $1, deleted_by = $2, delete_reason = $3
+	WHERE id = $4 AND deleted_at IS NULL
+	`, nullTime(comment.DeletedAt), nullId(comment.DeletedBy), comment.DeleteReason, comment.Id)
+	if err != nil {
+		return fmt.Errorf("CommentsRepo - Delete - Exec: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("CommentsRepo - Delete - RowsAffected: %w", err)
+	}
+	if affected != 1 {
+		return fmt.Errorf("CommentsRepo - Delete - RowsAffected: %w", sql.ErrNoRows)
+	}
+
+	return nil
+}
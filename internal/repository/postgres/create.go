@@ -0,0 +1,36 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"forum/pkg/postgres"
+
+	"github.com/lib/pq"
+)
+
+// CreateDB brings the database schema up to the latest known version.
+func CreateDB(p *postgres.Postgres) error {
+	err := NewMigrator(p).Up()
+	if err != nil {
+		return fmt.Errorf("CreateDB - %w", err)
+	}
+	return nil
+}
+
+// wrapErr adds sqlite3 wording to unique violations, usecases rely on it
+// to tell duplicates from other failures regardless of the backend.
+func wrapErr(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != uniqueViolation {
+		return err
+	}
+
+	column := strings.TrimPrefix(pqErr.Constraint, pqErr.Table+"_")
+	column = strings.TrimSuffix(column, "_key")
+	if column == "pkey" {
+		return fmt.Errorf("%w: %s: %s", err, uniqueErr, pqErr.Table)
+	}
+	return fmt.Errorf("%w: %s: %s.%s", err, uniqueErr, pqErr.Table, column)
+}
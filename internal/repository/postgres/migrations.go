@@ -0,0 +1,703 @@
+package postgres
+
+import (
+	"forum/pkg/migrate"
+	"forum/pkg/postgres"
+)
+
+// Migrations mirrors sqlite.Migrations version by version, so both
+// backends always agree on what a schema version means, foreign keys
+// included.
+var Migrations = []migrate.Migration{
+	{
+		Version: 1,
+		Name:    "initial_schema",
+		Up: `
+		CREATE TABLE IF NOT EXISTS users (
+			id BIGSERIAL PRIMARY KEY,
+			name TEXT NOT NULL UNIQUE,
+			email TEXT NOT NULL UNIQUE,
+			password TEXT,
+			reg_date TEXT,
+			date_of_birth TEXT,
+			city TEXT,
+			sex TEXT,
+			role TEXT,
+			sign TEXT,
+			session_token TEXT,
+			session_ttl TEXT
+			);
+
+		CREATE TABLE IF NOT EXISTS posts (
+			id BIGSERIAL PRIMARY KEY,
+			user_id BIGINT REFERENCES users(id),
+			date TEXT NOT NULL,
+			title TEXT NOT NULL,
+			content TEXT NOT NULL
+			);
+
+		CREATE TABLE IF NOT EXISTS comments (
+			id BIGSERIAL PRIMARY KEY,
+			post_id BIGINT REFERENCES posts(id),
+			user_id BIGINT REFERENCES users(id),
+			date TEXT NOT NULL,
+			content TEXT NOT NULL
+			);
+
+		CREATE TABLE IF NOT EXISTS post_likes (
+			post_id BIGINT REFERENCES posts(id),
+			user_id BIGINT REFERENCES users(id),
+			date TEXT,
+			PRIMARY KEY(post_id, user_id)
+			);
+
+		CREATE TABLE IF NOT EXISTS post_dislikes (
+			post_id BIGINT REFERENCES posts(id),
+			user_id BIGINT REFERENCES users(id),
+			date TEXT,
+			PRIMARY KEY(post_id, user_id)
+			);
+
+		CREATE TABLE IF NOT EXISTS comment_likes (
+			comment_id BIGINT REFERENCES comments(id),
+			user_id BIGINT REFERENCES users(id),
+			date TEXT,
+			PRIMARY KEY(comment_id, user_id)
+			);
+
+		CREATE TABLE IF NOT EXISTS comment_dislikes (
+			comment_id BIGINT REFERENCES comments(id),
+			user_id BIGINT REFERENCES users(id),
+			date TEXT,
+			PRIMARY KEY(comment_id, user_id)
+			);
+
+		CREATE TABLE IF NOT EXISTS topics (
+			id BIGSERIAL PRIMARY KEY,
+			name TEXT UNIQUE
+			);
+
+		CREATE TABLE IF NOT EXISTS reference_topic (
+			post_id BIGINT REFERENCES posts(id),
+			topic TEXT,
+			PRIMARY KEY (post_id, topic)
+			);
+
+		CREATE TABLE IF NOT EXISTS images (
+			id BIGSERIAL PRIMARY KEY,
+			post_id BIGINT REFERENCES posts(id),
+			comment_id BIGINT REFERENCES comments(id),
+			user_id BIGINT REFERENCES users(id),
+			path TEXT
+			);
+		`,
+		Down: `
+		DROP TABLE IF EXISTS images;
+		DROP TABLE IF EXISTS reference_topic;
+		DROP TABLE IF EXISTS topics;
+		DROP TABLE IF EXISTS comment_dislikes;
+		DROP TABLE IF EXISTS comment_likes;
+		DROP TABLE IF EXISTS post_dislikes;
+		DROP TABLE IF EXISTS post_likes;
+		DROP TABLE IF EXISTS comments;
+		DROP TABLE IF EXISTS posts;
+		DROP TABLE IF EXISTS users;
+		`,
+	},
+	{
+		Version: 2,
+		Name:    "search_index",
+		Up: `
+		ALTER TABLE posts ADD COLUMN search tsvector;
+		ALTER TABLE comments ADD COLUMN search tsvector;
+
+		CREATE FUNCTION posts_search_update() RETURNS trigger AS $$
+		BEGIN
+			NEW.search :=
+				setweight(to_tsvector('simple', coalesce(NEW.title, '')), 'A') ||
+				setweight(to_tsvector('simple', coalesce((SELECT name FROM users WHERE id = NEW.user_id), '')), 'B') ||
+				setweight(to_tsvector('simple', coalesce(NEW.content, '')), 'D');
+			RETURN NEW;
+		END
+		$$ LANGUAGE plpgsql;
+
+		CREATE FUNCTION comments_search_update() RETURNS trigger AS $$
+		BEGIN
+			NEW.search :=
+				setweight(to_tsvector('simple', coalesce((SELECT name FROM users WHERE id = NEW.user_id), '')), 'B') ||
+				setweight(to_tsvector('simple', coalesce(NEW.content, '')), 'D');
+			RETURN NEW;
+		END
+		$$ LANGUAGE plpgsql;
+
+		CREATE TRIGGER posts_search_update BEFORE INSERT OR UPDATE OF title, content ON posts
+			FOR EACH ROW EXECUTE PROCEDURE posts_search_update();
+		CREATE TRIGGER comments_search_update BEFORE INSERT OR UPDATE OF content ON comments
+			FOR EACH ROW EXECUTE PROCEDURE comments_search_update();
+
+		UPDATE posts SET title = title;
+		UPDATE comments SET content = content;
+
+		CREATE INDEX posts_search_idx ON posts USING GIN (search);
+		CREATE INDEX comments_search_idx ON comments USING GIN (search);
+		`,
+		Down: `
+		DROP INDEX IF EXISTS comments_search_idx;
+		DROP INDEX IF EXISTS posts_search_idx;
+		DROP TRIGGER IF EXISTS comments_search_update ON comments;
+		DROP TRIGGER IF EXISTS posts_search_update ON posts;
+		DROP FUNCTION IF EXISTS comments_search_update();
+		DROP FUNCTION IF EXISTS posts_search_update();
+		ALTER TABLE comments DROP COLUMN IF EXISTS search;
+		ALTER TABLE posts DROP COLUMN IF EXISTS search;
+		`,
+	},
+	{
+		Version: 3,
+		Name:    "reactions",
+		Up: `
+		CREATE TABLE IF NOT EXISTS reactions (
+			target TEXT NOT NULL,
+			target_id BIGINT NOT NULL,
+			user_id BIGINT NOT NULL REFERENCES users(id),
+			kind TEXT NOT NULL,
+			date TEXT,
+			PRIMARY KEY(target, target_id, user_id, kind)
+			);
+
+		CREATE INDEX IF NOT EXISTS reactions_user ON reactions(user_id, target, kind);
+
+		INSERT INTO reactions(target, target_id, user_id, kind, date)
+			SELECT 'post', post_id, user_id, 'like', date FROM post_likes
+			WHERE post_id IS NOT NULL AND user_id IS NOT NULL;
+		INSERT INTO reactions(target, target_id, user_id, kind, date)
+			SELECT 'post', post_id, user_id, 'dislike', date FROM post_dislikes
+			WHERE post_id IS NOT NULL AND user_id IS NOT NULL;
+		INSERT INTO reactions(target, target_id, user_id, kind, date)
+			SELECT 'comment', comment_id, user_id, 'like', date FROM comment_likes
+			WHERE comment_id IS NOT NULL AND user_id IS NOT NULL;
+		INSERT INTO reactions(target, target_id, user_id, kind, date)
+			SELECT 'comment', comment_id, user_id, 'dislike', date FROM comment_dislikes
+			WHERE comment_id IS NOT NULL AND user_id IS NOT NULL;
+
+		DROP TABLE post_likes;
+		DROP TABLE post_dislikes;
+		DROP TABLE comment_likes;
+		DROP TABLE comment_dislikes;
+		`,
+		// Only likes and dislikes fit the old tables, other kinds are lost.
+		Down: `
+		CREATE TABLE post_likes (
+			post_id BIGINT REFERENCES posts(id),
+			user_id BIGINT REFERENCES users(id),
+			date TEXT,
+			PRIMARY KEY(post_id, user_id)
+			);
+
+		CREATE TABLE post_dislikes (
+			post_id BIGINT REFERENCES posts(id),
+			user_id BIGINT REFERENCES users(id),
+			date TEXT,
+			PRIMARY KEY(post_id, user_id)
+			);
+
+		CREATE TABLE comment_likes (
+			comment_id BIGINT REFERENCES comments(id),
+			user_id BIGINT REFERENCES users(id),
+			date TEXT,
+			PRIMARY KEY(comment_id, user_id)
+			);
+
+		CREATE TABLE comment_dislikes (
+			comment_id BIGINT REFERENCES comments(id),
+			user_id BIGINT REFERENCES users(id),
+			date TEXT,
+			PRIMARY KEY(comment_id, user_id)
+			);
+
+		INSERT INTO post_likes(post_id, user_id, date)
+			SELECT target_id, user_id, date FROM reactions WHERE target = 'post' AND kind = 'like';
+		INSERT INTO post_dislikes(post_id, user_id, date)
+			SELECT target_id, user_id, date FROM reactions WHERE target = 'post' AND kind = 'dislike';
+		INSERT INTO comment_likes(comment_id, user_id, date)
+			SELECT target_id, user_id, date FROM reactions WHERE target = 'comment' AND kind = 'like';
+		INSERT INTO comment_dislikes(comment_id, user_id, date)
+			SELECT target_id, user_id, date FROM reactions WHERE target = 'comment' AND kind = 'dislike';
+
+		DROP TABLE reactions;
+		`,
+	},
+	{
+		Version: 4,
+		Name:    "soft_delete",
+		Up: `
+		ALTER TABLE posts
+			ADD COLUMN deleted_at TEXT,
+			ADD COLUMN deleted_by BIGINT REFERENCES users(id),
+			ADD COLUMN delete_reason TEXT;
+
+		ALTER TABLE comments
+			ADD COLUMN deleted_at TEXT,
+			ADD COLUMN deleted_by BIGINT REFERENCES users(id),
+			ADD COLUMN delete_reason TEXT;
+
+		CREATE INDEX IF NOT EXISTS posts_deleted ON posts(deleted_at);
+		CREATE INDEX IF NOT EXISTS comments_deleted ON comments(deleted_at);
+		`,
+		// Rows still in the trash come back as ordinary ones.
+		Down: `
+		DROP INDEX IF EXISTS comments_deleted;
+		DROP INDEX IF EXISTS posts_deleted;
+
+		ALTER TABLE comments
+			DROP COLUMN IF EXISTS delete_reason,
+			DROP COLUMN IF EXISTS deleted_by,
+			DROP COLUMN IF EXISTS deleted_at;
+
+		ALTER TABLE posts
+			DROP COLUMN IF EXISTS delete_reason,
+			DROP COLUMN IF EXISTS deleted_by,
+			DROP COLUMN IF EXISTS deleted_at;
+		`,
+	},
+	{
+		Version: 5,
+		Name:    "revisions",
+		Up: `
+		CREATE TABLE IF NOT EXISTS revisions (
+			id BIGSERIAL PRIMARY KEY,
+			target TEXT NOT NULL,
+			target_id BIGINT NOT NULL,
+			user_id BIGINT NOT NULL REFERENCES users(id),
+			date TEXT NOT NULL,
+			title TEXT NOT NULL DEFAULT '',
+			content TEXT NOT NULL,
+			categories TEXT NOT NULL DEFAULT ''
+			);
+
+		CREATE INDEX IF NOT EXISTS revisions_target ON revisions(target, target_id);
+
+		ALTER TABLE posts ADD COLUMN edited_at TEXT;
+		ALTER TABLE comments ADD COLUMN edited_at TEXT;
+		`,
+		Down: `
+		ALTER TABLE comments DROP COLUMN IF EXISTS edited_at;
+		ALTER TABLE posts DROP COLUMN IF EXISTS edited_at;
+
+		DROP TABLE IF EXISTS revisions;
+		`,
+	},
+	{
+		Version: 6,
+		Name:    "comment_threads",
+		Up: `
+		ALTER TABLE comments ADD COLUMN parent_id BIGINT REFERENCES comments(id);
+
+		CREATE INDEX IF NOT EXISTS comments_parent ON comments(parent_id);
+		`,
+		// Replies become top level comments.
+		Down: `
+		DROP INDEX IF EXISTS comments_parent;
+
+		ALTER TABLE comments DROP COLUMN IF EXISTS parent_id;
+		`,
+	},
+	{
+		Version: 7,
+		Name:    "counters",
+		Up: `
+		ALTER TABLE posts ADD COLUMN comment_count BIGINT NOT NULL DEFAULT 0;
+		ALTER TABLE users ADD COLUMN post_count BIGINT NOT NULL DEFAULT 0;
+		ALTER TABLE users ADD COLUMN comment_count BIGINT NOT NULL DEFAULT 0;
+
+		CREATE TABLE IF NOT EXISTS reaction_counts (
+			target TEXT NOT NULL,
+			target_id BIGINT NOT NULL,
+			kind TEXT NOT NULL,
+			count BIGINT NOT NULL DEFAULT 0,
+			PRIMARY KEY(target, target_id, kind)
+			);
+
+		CREATE TABLE IF NOT EXISTS user_reaction_counts (
+			user_id BIGINT NOT NULL,
+			target TEXT NOT NULL,
+			kind TEXT NOT NULL,
+			count BIGINT NOT NULL DEFAULT 0,
+			PRIMARY KEY(user_id, target, kind)
+			);
+
+		CREATE FUNCTION reactions_count() RETURNS trigger AS $$
+		BEGIN
+			IF TG_OP = 'INSERT' THEN
+				INSERT INTO reaction_counts(target, target_id, kind, count) VALUES (NEW.target, NEW.target_id, NEW.kind, 1)
+					ON CONFLICT(target, target_id, kind) DO UPDATE SET count = reaction_counts.count + 1;
+				INSERT INTO user_reaction_counts(user_id, target, kind, count) VALUES (NEW.user_id, NEW.target, NEW.kind, 1)
+					ON CONFLICT(user_id, target, kind) DO UPDATE SET count = user_reaction_counts.count + 1;
+			ELSE
+				UPDATE reaction_counts SET count = count - 1
+				WHERE target = OLD.target AND target_id = OLD.target_id AND kind = OLD.kind;
+				UPDATE user_reaction_counts SET count = count - 1
+				WHERE user_id = OLD.user_id AND target = OLD.target AND kind = OLD.kind;
+			END IF;
+			RETURN NULL;
+		END
+		$$ LANGUAGE plpgsql;
+
+		-- delta tells how a change of the row moves the counters: +1 when a
+		-- live row appears, -1 when it goes away or into the trash.
+		CREATE FUNCTION comments_count() RETURNS trigger AS $$
+		DECLARE
+			delta BIGINT := 0;
+			changed comments%ROWTYPE;
+		BEGIN
+			IF TG_OP = 'INSERT' THEN
+				changed := NEW;
+				IF NEW.deleted_at IS NULL THEN delta := 1; END IF;
+			ELSIF TG_OP = 'DELETE' THEN
+				changed := OLD;
+				IF OLD.deleted_at IS NULL THEN delta := -1; END IF;
+			ELSE
+				changed := NEW;
+				IF OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN delta := -1; END IF;
+				IF OLD.deleted_at IS NOT NULL AND NEW.deleted_at IS NULL THEN delta := 1; END IF;
+			END IF;
+			IF delta <> 0 THEN
+				UPDATE posts SET comment_count = comment_count + delta WHERE id = changed.post_id;
+				UPDATE users SET comment_count = comment_count + delta WHERE id = changed.user_id;
+			END IF;
+			RETURN NULL;
+		END
+		$$ LANGUAGE plpgsql;
+
+		CREATE FUNCTION posts_count() RETURNS trigger AS $$
+		DECLARE
+			delta BIGINT := 0;
+			changed posts%ROWTYPE;
+		BEGIN
+			IF TG_OP = 'INSERT' THEN
+				changed := NEW;
+				IF NEW.deleted_at IS NULL THEN delta := 1; END IF;
+			ELSIF TG_OP = 'DELETE' THEN
+				changed := OLD;
+				IF OLD.deleted_at IS NULL THEN delta := -1; END IF;
+			ELSE
+				changed := NEW;
+				IF OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN delta := -1; END IF;
+				IF OLD.deleted_at IS NOT NULL AND NEW.deleted_at IS NULL THEN delta := 1; END IF;
+			END IF;
+			IF delta <> 0 THEN
+				UPDATE users SET post_count = post_count + delta WHERE id = changed.user_id;
+			END IF;
+			RETURN NULL;
+		END
+		$$ LANGUAGE plpgsql;
+
+		CREATE TRIGGER reactions_count AFTER INSERT OR DELETE ON reactions
+			FOR EACH ROW EXECUTE PROCEDURE reactions_count();
+		CREATE TRIGGER comments_count AFTER INSERT OR DELETE OR UPDATE OF deleted_at ON comments
+			FOR EACH ROW EXECUTE PROCEDURE comments_count();
+		CREATE TRIGGER posts_count AFTER INSERT OR DELETE OR UPDATE OF deleted_at ON posts
+			FOR EACH ROW EXECUTE PROCEDURE posts_count();
+
+		INSERT INTO reaction_counts(target, target_id, kind, count)
+			SELECT target, target_id, kind, COUNT(*) FROM reactions GROUP BY target, target_id, kind;
+		INSERT INTO user_reaction_counts(user_id, target, kind, count)
+			SELECT user_id, target, kind, COUNT(*) FROM reactions GROUP BY user_id, target, kind;
+		UPDATE posts SET comment_count = (SELECT COUNT(*) FROM comments
+			WHERE comments.post_id = posts.id AND comments.deleted_at IS NULL);
+		UPDATE users SET
+			post_count = (SELECT COUNT(*) FROM posts WHERE posts.user_id = users.id AND posts.deleted_at IS NULL),
+			comment_count = (SELECT COUNT(*) FROM comments WHERE comments.user_id = users.id AND comments.deleted_at IS NULL);
+		`,
+		Down: `
+		DROP TRIGGER IF EXISTS posts_count ON posts;
+		DROP TRIGGER IF EXISTS comments_count ON comments;
+		DROP TRIGGER IF EXISTS reactions_count ON reactions;
+		DROP FUNCTION IF EXISTS posts_count();
+		DROP FUNCTION IF EXISTS comments_count();
+		DROP FUNCTION IF EXISTS reactions_count();
+
+		DROP TABLE IF EXISTS user_reaction_counts;
+		DROP TABLE IF EXISTS reaction_counts;
+
+		ALTER TABLE users DROP COLUMN IF EXISTS comment_count;
+		ALTER TABLE users DROP COLUMN IF EXISTS post_count;
+		ALTER TABLE posts DROP COLUMN IF EXISTS comment_count;
+		`,
+	},
+	{
+		Version: 8,
+		Name:    "utc_timestamps",
+		// Timestamps were written in the local time of the server, they
+		// become UTC in TimeFormat. Values postgres cannot read are kept.
+		Up: `
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS timezone TEXT;
+
+		CREATE FUNCTION utc_timestamp(value TEXT) RETURNS TEXT AS $$
+		BEGIN
+			RETURN to_char(value::timestamp::timestamptz AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"');
+		EXCEPTION WHEN others THEN
+			RETURN value;
+		END;
+		$$ LANGUAGE plpgsql;
+
+		UPDATE users SET reg_date = utc_timestamp(reg_date), session_ttl = utc_timestamp(session_ttl);
+		UPDATE posts SET date = utc_timestamp(date), edited_at = utc_timestamp(edited_at),
+			deleted_at = utc_timestamp(deleted_at);
+		UPDATE comments SET date = utc_timestamp(date), edited_at = utc_timestamp(edited_at),
+			deleted_at = utc_timestamp(deleted_at);
+		UPDATE reactions SET date = utc_timestamp(date);
+		UPDATE revisions SET date = utc_timestamp(date);
+
+		DROP FUNCTION utc_timestamp(TEXT);
+		`,
+		Down: `
+		CREATE FUNCTION local_timestamp(value TEXT, layout TEXT) RETURNS TEXT AS $$
+		BEGIN
+			RETURN to_char(value::timestamptz, layout);
+		EXCEPTION WHEN others THEN
+			RETURN value;
+		END;
+		$$ LANGUAGE plpgsql;
+
+		UPDATE revisions SET date = local_timestamp(date, 'YYYY-MM-DD HH24:MI:SS');
+		UPDATE reactions SET date = local_timestamp(date, 'YYYY-MM-DD');
+		UPDATE comments SET date = local_timestamp(date, 'YYYY-MM-DD HH24:MI:SS'),
+			edited_at = local_timestamp(edited_at, 'YYYY-MM-DD HH24:MI:SS'),
+			deleted_at = local_timestamp(deleted_at, 'YYYY-MM-DD HH24:MI:SS');
+		UPDATE posts SET date = local_timestamp(date, 'YYYY-MM-DD HH24:MI:SS'),
+			edited_at = local_timestamp(edited_at, 'YYYY-MM-DD HH24:MI:SS'),
+			deleted_at = local_timestamp(deleted_at, 'YYYY-MM-DD HH24:MI:SS');
+		UPDATE users SET reg_date = local_timestamp(reg_date, 'YYYY-MM-DD'),
+			session_ttl = local_timestamp(session_ttl, 'YYYY-MM-DD HH24:MI:SS');
+
+		DROP FUNCTION local_timestamp(TEXT, TEXT);
+
+		ALTER TABLE users DROP COLUMN IF EXISTS timezone;
+		`,
+	},
+	{
+		Version: 9,
+		Name:    "category_tree",
+		// Posts referenced categories by name, names no category was
+		// stored for become categories. Slugs are made of the names.
+		Up: `
+		ALTER TABLE topics ADD COLUMN parent_id BIGINT REFERENCES topics(id);
+		ALTER TABLE topics ADD COLUMN slug TEXT;
+		ALTER TABLE topics ADD COLUMN description TEXT NOT NULL DEFAULT '';
+		ALTER TABLE topics ADD COLUMN position INTEGER NOT NULL DEFAULT 0;
+		ALTER TABLE topics ADD COLUMN color TEXT NOT NULL DEFAULT '';
+
+		INSERT INTO topics(name)
+			SELECT DISTINCT topic FROM reference_topic WHERE topic NOT IN (SELECT name FROM topics);
+		UPDATE topics SET slug = lower(regexp_replace(trim(name), '[\s/?#]+', '-', 'g'));
+		UPDATE topics SET slug = slug || '-' || id
+			WHERE slug = '' OR EXISTS (SELECT 1 FROM topics AS other WHERE other.slug = topics.slug AND other.id < topics.id);
+		CREATE UNIQUE INDEX IF NOT EXISTS topics_slug ON topics(slug);
+		CREATE INDEX IF NOT EXISTS topics_parent ON topics(parent_id);
+
+		ALTER TABLE reference_topic ADD COLUMN topic_id BIGINT REFERENCES topics(id);
+		UPDATE reference_topic SET topic_id = topics.id FROM topics WHERE topics.name = reference_topic.topic;
+		DELETE FROM reference_topic WHERE topic_id IS NULL;
+		ALTER TABLE reference_topic DROP COLUMN topic;
+		ALTER TABLE reference_topic ADD PRIMARY KEY (post_id, topic_id);
+		CREATE INDEX IF NOT EXISTS reference_topic_topic ON reference_topic(topic_id);
+		`,
+		// Subcategories become top level ones.
+		Down: `
+		DROP INDEX IF EXISTS reference_topic_topic;
+		ALTER TABLE reference_topic ADD COLUMN topic TEXT;
+		UPDATE reference_topic SET topic = topics.name FROM topics WHERE topics.id = reference_topic.topic_id;
+		ALTER TABLE reference_topic DROP COLUMN topic_id;
+		ALTER TABLE reference_topic ADD PRIMARY KEY (post_id, topic);
+
+		DROP INDEX IF EXISTS topics_parent;
+		DROP INDEX IF EXISTS topics_slug;
+		ALTER TABLE topics
+			DROP COLUMN IF EXISTS color,
+			DROP COLUMN IF EXISTS position,
+			DROP COLUMN IF EXISTS description,
+			DROP COLUMN IF EXISTS slug,
+			DROP COLUMN IF EXISTS parent_id;
+		`,
+	},
+	{
+		Version: 10,
+		Name:    "sessions",
+		// A user had one session kept with the user, signed in browsers
+		// get a row each. Creation times of moved sessions are unknown,
+		// they read as the time of the migration.
+		Up: `
+		CREATE TABLE IF NOT EXISTS sessions (
+			id BIGSERIAL PRIMARY KEY,
+			user_id BIGINT NOT NULL REFERENCES users(id),
+			token TEXT NOT NULL UNIQUE,
+			user_agent TEXT NOT NULL DEFAULT '',
+			ip TEXT NOT NULL DEFAULT '',
+			created_at TEXT NOT NULL,
+			last_seen TEXT NOT NULL,
+			expires_at TEXT NOT NULL
+			);
+		CREATE INDEX IF NOT EXISTS sessions_user ON sessions(user_id);
+
+		INSERT INTO sessions(user_id, token, created_at, last_seen, expires_at)
+			SELECT id, session_token, to_char(now() AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+				to_char(now() AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"'), session_ttl
+			FROM users
+			WHERE session_token IS NOT NULL AND session_token != '' AND session_ttl IS NOT NULL
+			ON CONFLICT DO NOTHING;
+		ALTER TABLE users
+			DROP COLUMN IF EXISTS session_ttl,
+			DROP COLUMN IF EXISTS session_token;
+		`,
+		// Users keep the session they were seen with last.
+		Down: `
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS session_token TEXT;
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS session_ttl TEXT;
+		UPDATE users SET session_token = latest.token, session_ttl = latest.expires_at
+			FROM (SELECT DISTINCT ON (user_id) user_id, token, expires_at FROM sessions
+				ORDER BY user_id, last_seen DESC, id DESC) AS latest
+			WHERE latest.user_id = users.id;
+
+		DROP INDEX IF EXISTS sessions_user;
+		DROP TABLE IF EXISTS sessions;
+		`,
+	},
+	{
+		Version: 11,
+		Name:    "password_resets",
+		// Only the hash of a reset token is kept, the token itself is
+		// mailed to the user.
+		Up: `
+		CREATE TABLE IF NOT EXISTS password_resets (
+			id BIGSERIAL PRIMARY KEY,
+			user_id BIGINT NOT NULL REFERENCES users(id),
+			token_hash TEXT NOT NULL UNIQUE,
+			created_at TEXT NOT NULL,
+			expires_at TEXT NOT NULL
+			);
+		CREATE INDEX IF NOT EXISTS password_resets_user ON password_resets(user_id);
+		`,
+		Down: `
+		DROP INDEX IF EXISTS password_resets_user;
+		DROP TABLE password_resets;
+		`,
+	},
+	{
+		Version: 12,
+		Name:    "email_verification",
+		// Users registered before verification existed are taken as
+		// verified. A changed email waits in pending_email until the link
+		// mailed to it is followed.
+		Up: `
+		ALTER TABLE users
+			ADD COLUMN IF NOT EXISTS email_verified BOOLEAN NOT NULL DEFAULT FALSE,
+			ADD COLUMN IF NOT EXISTS pending_email TEXT NOT NULL DEFAULT '',
+			ADD COLUMN IF NOT EXISTS verification_sent_at TEXT;
+		UPDATE users SET email_verified = TRUE;
+		`,
+		Down: `
+		ALTER TABLE users
+			DROP COLUMN IF EXISTS verification_sent_at,
+			DROP COLUMN IF EXISTS pending_email,
+			DROP COLUMN IF EXISTS email_verified;
+		`,
+	},
+	{
+		Version: 13,
+		Name:    "two_factor",
+		// Recovery codes and sign-in challenges are kept as hashes, like
+		// password resets.
+		Up: `
+		ALTER TABLE users
+			ADD COLUMN IF NOT EXISTS totp_secret TEXT NOT NULL DEFAULT '',
+			ADD COLUMN IF NOT EXISTS totp_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+			ADD COLUMN IF NOT EXISTS totp_last_step BIGINT NOT NULL DEFAULT 0;
+		CREATE TABLE IF NOT EXISTS recovery_codes (
+			id BIGSERIAL PRIMARY KEY,
+			user_id BIGINT NOT NULL REFERENCES users(id),
+			code_hash TEXT NOT NULL
+			);
+		CREATE INDEX IF NOT EXISTS recovery_codes_user ON recovery_codes(user_id);
+		CREATE TABLE IF NOT EXISTS login_challenges (
+			id BIGSERIAL PRIMARY KEY,
+			user_id BIGINT NOT NULL REFERENCES users(id),
+			token_hash TEXT NOT NULL UNIQUE,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			created_at TEXT NOT NULL,
+			expires_at TEXT NOT NULL
+			);
+		CREATE INDEX IF NOT EXISTS login_challenges_user ON login_challenges(user_id);
+		`,
+		Down: `
+		DROP INDEX IF EXISTS login_challenges_user;
+		DROP TABLE login_challenges;
+		DROP INDEX IF EXISTS recovery_codes_user;
+		DROP TABLE recovery_codes;
+		ALTER TABLE users
+			DROP COLUMN IF EXISTS totp_last_step,
+			DROP COLUMN IF EXISTS totp_enabled,
+			DROP COLUMN IF EXISTS totp_secret;
+		`,
+	},
+	{
+		Version: 14,
+		Name:    "roles",
+		// Free-text roles of users become roles without permissions, so the
+		// titles shown stay. Anyone could write any role into the profile,
+		// so a title naming a built-in role with permissions gives the plain
+		// user role. The first user, the admin so far, gets the admin role.
+		Up: `
+		CREATE TABLE IF NOT EXISTS roles (
+			id BIGSERIAL PRIMARY KEY,
+			name TEXT NOT NULL UNIQUE
+			);
+		CREATE TABLE IF NOT EXISTS role_permissions (
+			role_id BIGINT NOT NULL REFERENCES roles(id),
+			permission TEXT NOT NULL,
+			PRIMARY KEY (role_id, permission)
+			);
+		INSERT INTO roles(id, name) VALUES
+			(1, 'Пользователь'),
+			(2, 'Модератор'),
+			(3, 'Администратор');
+		SELECT setval(pg_get_serial_sequence('roles', 'id'), 3);
+		INSERT INTO role_permissions(role_id, permission) VALUES
+			(2, 'edit_any_post'),
+			(2, 'delete_any_post'),
+			(2, 'ban_users'),
+			(3, 'manage_categories'),
+			(3, 'edit_any_post'),
+			(3, 'delete_any_post'),
+			(3, 'ban_users'),
+			(3, 'manage_users'),
+			(3, 'manage_backups'),
+			(3, 'manage_roles');
+		INSERT INTO roles(name)
+			SELECT DISTINCT TRIM(role) FROM users
+			WHERE TRIM(COALESCE(role, '')) NOT IN ('', 'Пользователь', 'Модератор', 'Администратор');
+		ALTER TABLE users
+			ADD COLUMN IF NOT EXISTS role_id BIGINT NOT NULL DEFAULT 1 REFERENCES roles(id),
+			ADD COLUMN IF NOT EXISTS banned BOOLEAN NOT NULL DEFAULT FALSE;
+		UPDATE users SET role_id = COALESCE((SELECT id FROM roles WHERE roles.name = TRIM(users.role)
+			AND NOT EXISTS (SELECT 1 FROM role_permissions WHERE role_permissions.role_id = roles.id)), 1);
+		UPDATE users SET role_id = 3 WHERE id = 1;
+		ALTER TABLE users DROP COLUMN IF EXISTS role;
+		CREATE INDEX IF NOT EXISTS users_role ON users(role_id);
+		`,
+		Down: `
+		DROP INDEX IF EXISTS users_role;
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS role TEXT;
+		UPDATE users SET role = (SELECT name FROM roles WHERE roles.id = users.role_id);
+		ALTER TABLE users
+			DROP COLUMN IF EXISTS banned,
+			DROP COLUMN IF EXISTS role_id;
+		DROP TABLE role_permissions;
+		DROP TABLE roles;
+		`,
+	},
+}
+
+func NewMigrator(p *postgres.Postgres) *migrate.Migrator {
+	return migrate.New(p.DB, Migrations)
+}
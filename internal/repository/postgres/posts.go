@@ -0,0 +1,412 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"forum/internal/entity"
+	"forum/pkg/postgres"
+
+	"github.com/lib/pq"
+)
+
+type PostsRepo struct {
+	*postgres.Postgres
+}
+
+func NewPostsRepo(pg *postgres.Postgres) *PostsRepo {
+	return &PostsRepo{pg}
+}
+
+func (pr *PostsRepo) Store(ctx context.Context, post *entity.Post) error {
+	tx, err := pr.Conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("PostsRepo - Store - Begin: %w", err)
+	}
+	defer func() {
+		err = tx.Rollback()
+	}()
+
+	var postId int64
+	err = tx.QueryRowContext(ctx, `
+	INSERT INTO posts(user_id, date, title, content)
+		values($1, $2, $3, $4)
+	RETURNING id
+	`, post.User.Id, formatTime(post.Date), post.Title, post.Content).Scan(&postId)
+	if err != nil {
+		return fmt.Errorf("PostsRepo - Store - Scan: %w", err)
+	}
+	post.Id = postId
+
+	err = storeImages(ctx, tx, "post_id", postId, post.Images)
+	if err != nil {
+		return fmt.Errorf("PostsRepo - Store - %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("PostsRepo - Store - Commit: %w", err)
+	}
+
+	return nil
+}
+
+func (pr *PostsRepo) StoreTopicReference(ctx context.Context, post entity.Post) error {
+	tx, err := pr.Conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("PostsRepo - StoreTopicReference - Begin: %w", err)
+	}
+	defer func() {
+		err = tx.Rollback()
+	}()
+
+	stmt, err := tx.PrepareContext(ctx, `
+	INSERT INTO reference_topic(post_id, topic_id)
+		values($1, $2)
+	`)
+	if err != nil {
+		return fmt.Errorf("PostsRepo - StoreTopicReference - Prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	for i := 0; i < len(post.Categories); i++ {
+		res, err := stmt.ExecContext(ctx, post.Id, post.Categories[i].Id)
+		if err != nil {
+			return fmt.Errorf("PostsRepo - StoreTopicReference - Exec: %w", wrapErr(err))
+		}
+		affected, err := res.RowsAffected()
+		if affected != 1 || err != nil {
+			return fmt.Errorf("PostsRepo - StoreTopicReference - RowsAffected: %w", err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("PostsRepo - StoreTopicReference - Commit: %w", err)
+	}
+
+	return nil
+}
+
+// selectPosts lists posts with author names and comment counters, callers
+// append conditions and ordering.
+const selectPosts = `
+	SELECT
+		id, user_id, date, title, content,
+		(SELECT name FROM users WHERE users.id = posts.user_id) AS user_name,
+		deleted_at, deleted_by, delete_reason, edited_at, comment_count
+	FROM posts
+	`
+
+func (pr *PostsRepo) Fetch(ctx context.Context) ([]entity.Post, error) {
+	rows, err := pr.Conn.QueryContext(ctx, selectPosts+`
+	WHERE deleted_at IS NULL
+	ORDER BY id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("PostsRepo - Fetch - Query: %w", err)
+	}
+	defer rows.Close()
+
+	posts, err := scanPosts(rows)
+	if err != nil {
+		return posts, fmt.Errorf("PostsRepo - Fetch - %w", err)
+	}
+
+	return posts, nil
+}
+
+func (pr *PostsRepo) FetchPage(ctx context.Context, limit, offset int) ([]entity.Post, error) {
+	rows, err := pr.Conn.QueryContext(ctx, selectPosts+`
+	WHERE deleted_at IS NULL
+	ORDER BY id
+	LIMIT $1 OFFSET $2
+	`, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("PostsRepo - FetchPage - Query: %w", err)
+	}
+	defer rows.Close()
+
+	posts, err := scanPosts(rows)
+	if err != nil {
+		return posts, fmt.Errorf("PostsRepo - FetchPage - %w", err)
+	}
+
+	return posts, nil
+}
+
+func (pr *PostsRepo) FetchAfter(ctx context.Context, cursor int64, limit int) ([]entity.Post, error) {
+	rows, err := pr.Conn.QueryContext(ctx, selectPosts+`
+	WHERE id > $1 AND deleted_at IS NULL
+	ORDER BY id
+	LIMIT $2
+	`, cursor, limit)
+	if err != nil {
+		return nil, fmt.Errorf("PostsRepo - FetchAfter - Query: %w", err)
+	}
+	defer rows.Close()
+
+	posts, err := scanPosts(rows)
+	if err != nil {
+		return posts, fmt.Errorf("PostsRepo - FetchAfter - %w", err)
+	}
+
+	return posts, nil
+}
+
+func (pr *PostsRepo) Count(ctx context.Context) (int64, error) {
+	var count int64
+	err := pr.Conn.QueryRowContext(ctx, `
+	SELECT COUNT(*)
+	FROM posts
+	WHERE deleted_at IS NULL
+	`).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("PostsRepo - Count - Scan: %w", err)
+	}
+
+	return count, nil
+}
+
+func (pr *PostsRepo) FetchByAuthor(ctx context.Context, user entity.User) ([]entity.Post, error) {
+	rows, err := pr.Conn.QueryContext(ctx, selectPosts+`
+	WHERE user_id = $1 AND deleted_at IS NULL
+	ORDER BY id
+	`, user.Id)
+	if err != nil {
+		return nil, fmt.Errorf("PostsRepo - FetchByAuthor - Query: %w", err)
+	}
+	defer rows.Close()
+
+	posts, err := scanPosts(rows)
+	if err != nil {
+		return posts, fmt.Errorf("PostsRepo - FetchByAuthor - %w", err)
+	}
+
+	return posts, nil
+}
+
+func scanPosts(rows *sql.Rows) ([]entity.Post, error) {
+	var posts []entity.Post
+
+	for rows.Next() {
+		var post entity.Post
+		var userName sql.NullString
+		var date, deletedAt, deleteReason, editedAt sql.NullString
+		var deletedBy sql.NullInt64
+
+		err := rows.Scan(&post.Id, &post.User.Id, &date, &post.Title, &post.Content, &userName,
+			&deletedAt, &deletedBy, &deleteReason, &editedAt, &post.TotalComments)
+		if err != nil {
+			return posts, fmt.Errorf("Scan: %w", err)
+		}
+
+		post.User.Name = userName.String
+		post.Date = parseTime(date)
+		post.DeletedAt = parseTime(deletedAt)
+		post.DeletedBy = deletedBy.Int64
+		post.DeleteReason = deleteReason.String
+		post.EditedAt = parseTime(editedAt)
+
+		posts = append(posts, post)
+	}
+
+	return posts, rows.Err()
+}
+
+func (pr *PostsRepo) GetById(ctx context.Context, id int64) (entity.Post, error) {
+	var post entity.Post
+	var userName sql.NullString
+	var images sql.NullString
+	var avatarPath sql.NullString
+	var date, deletedAt, deleteReason, editedAt sql.NullString
+	var deletedBy sql.NullInt64
+
+	err := pr.Conn.QueryRowContext(ctx, `
+	SELECT
+		id, user_id, date, title, content,
+		(SELECT path FROM images WHERE images.user_id = posts.user_id LIMIT 1),
+		(SELECT name FROM users WHERE users.id = posts.user_id) AS user_name,
+		`+postImages+`,
+		deleted_at, deleted_by, delete_reason, edited_at, comment_count
+	FROM posts
+	WHERE id = $1
+	`, id).Scan(&post.Id, &post.User.Id, &date, &post.Title, &post.Content,
+		&avatarPath, &userName, &images, &deletedAt, &deletedBy, &deleteReason, &editedAt,
+		&post.TotalComments)
+	if err != nil {
+		return post, fmt.Errorf("PostsRepo - GetById - Scan: %w", err)
+	}
+
+	post.User.Name = userName.String
+	post.User.AvatarPath = avatarPath.String
+	post.Images = splitImages(images)
+	post.Date = parseTime(date)
+	post.DeletedAt = parseTime(deletedAt)
+	post.DeletedBy = deletedBy.Int64
+	post.DeleteReason = deleteReason.String
+	post.EditedAt = parseTime(editedAt)
+
+	return post, nil
+}
+
+func (pr *PostsRepo) GetIdsByCategory(ctx context.Context, categoryId int64) ([]int64, error) {
+	var ids []int64
+
+	rows, err := pr.Conn.QueryContext(ctx, `
+	SELECT post_id
+	FROM reference_topic
+	JOIN posts ON posts.id = reference_topic.post_id
+	WHERE topic_id = $1 AND posts.deleted_at IS NULL
+	ORDER BY reference_topic.post_id
+	ORDER BY post_id
+	`, categoryId)
+	if err != nil {
+		return nil, fmt.Errorf("PostsRepo - GetIdsByCategory - Query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, fmt.Errorf("PostsRepo - GetIdsByCategory - Scan: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+func (pr *PostsRepo) GetRelatedCategories(ctx context.Context, post entity.Post) ([]entity.Category, error) {
+	categories := []entity.Category{}
+	rows, err := pr.Conn.QueryContext(ctx, `
+	SELECT`+categoryColumns+`
+	FROM reference_topic
+	JOIN topics ON topics.id = reference_topic.topic_id
+	WHERE post_id = $1
+	ORDER BY topics.position, topics.name
+	`, post.Id)
+	if err != nil {
+		return nil, fmt.Errorf("PostsRepo - GetRelatedCategories - Query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		category, err := scanCategory(rows.Scan)
+		if err != nil {
+			return nil, fmt.Errorf("PostsRepo - GetRelatedCategories - Scan: %w", err)
+		}
+		categories = append(categories, category)
+	}
+
+	return categories, nil
+}
+
+func (pr *PostsRepo) FetchCategories(ctx context.Context, postIds []int64) (map[int64][]entity.Category, error) {
+	categories := make(map[int64][]entity.Category, len(postIds))
+	if len(postIds) == 0 {
+		return categories, nil
+	}
+
+	rows, err := pr.Conn.QueryContext(ctx, `
+	SELECT`+categoryColumns+`, reference_topic.post_id
+	FROM reference_topic
+	JOIN topics ON topics.id = reference_topic.topic_id
+	WHERE post_id = ANY($1)
+	ORDER BY reference_topic.post_id, topics.position, topics.name
+	`, pq.Array(postIds))
+	if err != nil {
+		return nil, fmt.Errorf("PostsRepo - FetchCategories - Query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var postId int64
+		category, err := scanCategory(rows.Scan, &postId)
+		if err != nil {
+			return nil, fmt.Errorf("PostsRepo - FetchCategories - Scan: %w", err)
+		}
+		categories[postId] = append(categories[postId], category)
+	}
+
+	return categories, nil
+}
+
+// Update overwrites the title and the content of the post and marks it
+// edited at post.EditedAt. Categories and images are replaced unless they
+// are nil.
+func (pr *PostsRepo) Update(ctx context.Context, post entity.Post) error {
+	tx, err := pr.Conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("PostsRepo - Update - Begin: %w", err)
+	}
+	defer func() {
+		err = tx.Rollback()
+	}()
+
+	res, err := tx.ExecContext(ctx, `
+	UPDATE posts
+	SET title = $1, content = $2, edited_at = $3
+	WHERE id = $4
+	`, post.Title, post.Content, nullTime(post.EditedAt), post.Id)
+	if err != nil {
+		return fmt.Errorf("PostsRepo - Update - Exec #1: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if affected != 1 || err != nil {
+		return fmt.Errorf("PostsRepo - Update - RowsAffected: %w", err)
+	}
+
+	if post.Categories != nil {
+		_, err = tx.ExecContext(ctx, `DELETE FROM reference_topic WHERE post_id = $1`, post.Id)
+		if err != nil {
+			return fmt.Errorf("PostsRepo - Update - Exec #2: %w", err)
+		}
+		for _, category := range post.Categories {
+			_, err = tx.ExecContext(ctx, `INSERT INTO reference_topic(post_id, topic_id) VALUES($1, $2)`, post.Id,
+				category.Id)
+			if err != nil {
+				return fmt.Errorf("PostsRepo - Update - Exec #3: %w", wrapErr(err))
+			}
+		}
+	}
+	if post.Images != nil {
+		err = replaceImages(ctx, tx, "post_id", post.Id, post.Images)
+		if err != nil {
+			return fmt.Errorf("PostsRepo - Update - %w", err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("PostsRepo - Update - Commit: %w", err)
+	}
+
+	return nil
+}
+
+// Delete moves the post to the trash, the deletion mark is taken from the
+// post. Posts already in the trash are not found.
+func (pr *PostsRepo) Delete(ctx context.Context, post entity.Post) error {
+	res, err := pr.Conn.ExecContext(ctx, `
+	UPDATE posts
+	SET deleted_at = $1, deleted_by = $2, delete_reason = $3
+	WHERE id = $4 AND deleted_at IS NULL
+	`, nullTime(post.DeletedAt), nullId(post.DeletedBy), post.DeleteReason, post.Id)
+	if err != nil {
+		return fmt.Errorf("PostsRepo - Delete - Exec: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("PostsRepo - Delete - RowsAffected: %w", err)
+	}
+	if affected != 1 {
+		return fmt.Errorf("PostsRepo - Delete - RowsAffected: %w", sql.ErrNoRows)
+	}
+
+	return nil
+}
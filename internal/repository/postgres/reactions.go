@@ -0,0 +1,212 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"forum/internal/entity"
+	"forum/pkg/postgres"
+
+	"github.com/lib/pq"
+)
+
+type ReactionsRepo struct {
+	*postgres.Postgres
+}
+
+func NewReactionsRepo(pg *postgres.Postgres) *ReactionsRepo {
+	return &ReactionsRepo{pg}
+}
+
+func (rr *ReactionsRepo) Store(ctx context.Context, reaction entity.Reaction) error {
+	res, err := rr.Conn.ExecContext(ctx, `
+	INSERT INTO reactions(target, target_id, user_id, kind, date)
+		VALUES($1, $2, $3, $4, $5)
+	`, reaction.Target, reaction.TargetId, reaction.UserId, reaction.Kind, formatTime(reaction.Date))
+	if err != nil {
+		return fmt.Errorf("ReactionsRepo - Store - Exec: %w", wrapErr(err))
+	}
+
+	affected, err := res.RowsAffected()
+	if affected != 1 || err != nil {
+		return fmt.Errorf("ReactionsRepo - Store - RowsAffected: %w", err)
+	}
+	return nil
+}
+
+func (rr *ReactionsRepo) Delete(ctx context.Context, reaction entity.Reaction) error {
+	_, err := rr.Conn.ExecContext(ctx, `
+	DELETE FROM reactions
+	WHERE target = $1 AND target_id = $2 AND user_id = $3 AND kind = $4
+	`, reaction.Target, reaction.TargetId, reaction.UserId, reaction.Kind)
+	if err != nil {
+		return fmt.Errorf("ReactionsRepo - Delete - Exec: %w", err)
+	}
+	return nil
+}
+
+// Has reports whether the user of the reaction has put it on the target.
+func (rr *ReactionsRepo) Has(ctx context.Context, reaction entity.Reaction) (bool, error) {
+	var has bool
+	err := rr.Conn.QueryRowContext(ctx, `
+	SELECT EXISTS(
+		SELECT 1 FROM reactions
+		WHERE target = $1 AND target_id = $2 AND user_id = $3 AND kind = $4
+	)
+	`, reaction.Target, reaction.TargetId, reaction.UserId, reaction.Kind).Scan(&has)
+	if err != nil {
+		return false, fmt.Errorf("ReactionsRepo - Has - Scan: %w", err)
+	}
+	return has, nil
+}
+
+// DeleteByUser takes back every reaction of the user.
+func (rr *ReactionsRepo) DeleteByUser(ctx context.Context, userId int64) error {
+	_, err := rr.Conn.ExecContext(ctx, `
+	DELETE FROM reactions
+	WHERE user_id = $1
+	`, userId)
+	if err != nil {
+		return fmt.Errorf("ReactionsRepo - DeleteByUser - Exec: %w", err)
+	}
+	return nil
+}
+
+func (rr *ReactionsRepo) Fetch(ctx context.Context, target string, targetId int64,
+	kind string) ([]entity.Reaction, error) {
+	var reactions []entity.Reaction
+
+	rows, err := rr.Conn.QueryContext(ctx, `
+	SELECT target, target_id, user_id, kind, date
+	FROM reactions
+	WHERE target = $1 AND target_id = $2 AND kind = $3
+	ORDER BY date, user_id
+	`, target, targetId, kind)
+	if err != nil {
+		return nil, fmt.Errorf("ReactionsRepo - Fetch - Query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var reaction entity.Reaction
+		var date sql.NullString
+		err = rows.Scan(&reaction.Target, &reaction.TargetId, &reaction.UserId, &reaction.Kind, &date)
+		if err != nil {
+			return nil, fmt.Errorf("ReactionsRepo - Fetch - Scan: %w", err)
+		}
+		reaction.Date = parseTime(date)
+		reactions = append(reactions, reaction)
+	}
+	return reactions, nil
+}
+
+func (rr *ReactionsRepo) FetchAll(ctx context.Context, target string) ([]entity.Reaction, error) {
+	var reactions []entity.Reaction
+
+	rows, err := rr.Conn.QueryContext(ctx, `
+	SELECT target, target_id, user_id, kind, date
+	FROM reactions
+	WHERE target = $1
+	ORDER BY target_id, date, user_id
+	`, target)
+	if err != nil {
+		return nil, fmt.Errorf("ReactionsRepo - FetchAll - Query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var reaction entity.Reaction
+		var date sql.NullString
+		err = rows.Scan(&reaction.Target, &reaction.TargetId, &reaction.UserId, &reaction.Kind, &date)
+		if err != nil {
+			return nil, fmt.Errorf("ReactionsRepo - FetchAll - Scan: %w", err)
+		}
+		reaction.Date = parseTime(date)
+		reactions = append(reactions, reaction)
+	}
+	return reactions, nil
+}
+
+func (rr *ReactionsRepo) Count(ctx context.Context, target string,
+	targetIds []int64) (map[int64]map[string]int64, error) {
+	counts := make(map[int64]map[string]int64, len(targetIds))
+	if len(targetIds) == 0 {
+		return counts, nil
+	}
+
+	rows, err := rr.Conn.QueryContext(ctx, `
+	SELECT target_id, kind, count
+	FROM reaction_counts
+	WHERE target = $1 AND target_id = ANY($2) AND count > 0
+	`, target, pq.Array(targetIds))
+	if err != nil {
+		return nil, fmt.Errorf("ReactionsRepo - Count - Query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var targetId, count int64
+		var kind string
+		err = rows.Scan(&targetId, &kind, &count)
+		if err != nil {
+			return nil, fmt.Errorf("ReactionsRepo - Count - Scan: %w", err)
+		}
+		if counts[targetId] == nil {
+			counts[targetId] = make(map[string]int64)
+		}
+		counts[targetId][kind] = count
+	}
+	return counts, nil
+}
+
+func (rr *ReactionsRepo) CountByUser(ctx context.Context, target string, userId int64) (map[string]int64, error) {
+	counts := make(map[string]int64)
+
+	rows, err := rr.Conn.QueryContext(ctx, `
+	SELECT kind, count
+	FROM user_reaction_counts
+	WHERE target = $1 AND user_id = $2 AND count > 0
+	`, target, userId)
+	if err != nil {
+		return nil, fmt.Errorf("ReactionsRepo - CountByUser - Query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var kind string
+		var count int64
+		err = rows.Scan(&kind, &count)
+		if err != nil {
+			return nil, fmt.Errorf("ReactionsRepo - CountByUser - Scan: %w", err)
+		}
+		counts[kind] = count
+	}
+	return counts, nil
+}
+
+func (rr *ReactionsRepo) FetchTargetIds(ctx context.Context, target string, userId int64,
+	kind string) ([]int64, error) {
+	var ids []int64
+
+	rows, err := rr.Conn.QueryContext(ctx, `
+	SELECT target_id
+	FROM reactions
+	WHERE target = $1 AND user_id = $2 AND kind = $3
+	ORDER BY target_id
+	`, target, userId, kind)
+	if err != nil {
+		return nil, fmt.Errorf("ReactionsRepo - FetchTargetIds - Query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, fmt.Errorf("ReactionsRepo - FetchTargetIds - Scan: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"forum/internal/entity"
+	"forum/pkg/postgres"
+)
+
+type PasswordResetsRepo struct {
+	*postgres.Postgres
+}
+
+func NewPasswordResetsRepo(pg *postgres.Postgres) *PasswordResetsRepo {
+	return &PasswordResetsRepo{pg}
+}
+
+func (rr *PasswordResetsRepo) Store(ctx context.Context, reset *entity.PasswordReset) error {
+	err := rr.Conn.QueryRowContext(ctx, `
+	INSERT INTO password_resets(user_id, token_hash, created_at, expires_at)
+		VALUES($1, $2, $3, $4)
+	RETURNING id
+	`, reset.User.Id, reset.TokenHash, formatTime(reset.CreatedAt), formatTime(reset.ExpiresAt)).Scan(&reset.Id)
+	if err != nil {
+		return fmt.Errorf("PasswordResetsRepo - Store - Scan: %w", wrapErr(err))
+	}
+	return nil
+}
+
+func (rr *PasswordResetsRepo) GetByHash(ctx context.Context, tokenHash string) (entity.PasswordReset, error) {
+	var reset entity.PasswordReset
+	var createdAt, expiresAt sql.NullString
+	err := rr.Conn.QueryRowContext(ctx, `
+	SELECT id, user_id, token_hash, created_at, expires_at
+	FROM password_resets
+	WHERE token_hash = $1
+	`, tokenHash).Scan(&reset.Id, &reset.User.Id, &reset.TokenHash, &createdAt, &expiresAt)
+	if err != nil {
+		return reset, fmt.Errorf("PasswordResetsRepo - GetByHash - Scan: %w", err)
+	}
+	reset.CreatedAt = parseTime(createdAt)
+	reset.ExpiresAt = parseTime(expiresAt)
+	return reset, nil
+}
+
+func (rr *PasswordResetsRepo) DeleteByUser(ctx context.Context, userId int64) error {
+	_, err := rr.Conn.ExecContext(ctx, `DELETE FROM password_resets WHERE user_id = $1`, userId)
+	if err != nil {
+		return fmt.Errorf("PasswordResetsRepo - DeleteByUser - Exec: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired removes the resets expired before the given time and
+// returns how many there were.
+func (rr *PasswordResetsRepo) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	res, err := rr.Conn.ExecContext(ctx, `DELETE FROM password_resets WHERE expires_at < $1`, formatTime(before))
+	if err != nil {
+		return 0, fmt.Errorf("PasswordResetsRepo - DeleteExpired - Exec: %w", err)
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("PasswordResetsRepo - DeleteExpired - RowsAffected: %w", err)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,201 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"forum/internal/entity"
+	"forum/pkg/postgres"
+)
+
+type RolesRepo struct {
+	*postgres.Postgres
+}
+
+func NewRolesRepo(pg *postgres.Postgres) *RolesRepo {
+	return &RolesRepo{pg}
+}
+
+func (rr *RolesRepo) Store(ctx context.Context, role *entity.Role) error {
+	tx, err := rr.Conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("RolesRepo - Store - Begin: %w", err)
+	}
+	defer func() {
+		err = tx.Rollback()
+	}()
+
+	err = tx.QueryRowContext(ctx, `INSERT INTO roles(name) VALUES($1) RETURNING id`, role.Name).Scan(&role.Id)
+	if err != nil {
+		return fmt.Errorf("RolesRepo - Store - Scan: %w", wrapErr(err))
+	}
+	for _, permission := range role.Permissions {
+		_, err = tx.ExecContext(ctx, `
+		INSERT INTO role_permissions(role_id, permission) VALUES($1, $2)
+		`, role.Id, permission)
+		if err != nil {
+			return fmt.Errorf("RolesRepo - Store - Exec: %w", err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("RolesRepo - Store - Commit: %w", err)
+	}
+	return nil
+}
+
+// Fetch lists every role with its permissions by id.
+func (rr *RolesRepo) Fetch(ctx context.Context) ([]entity.Role, error) {
+	var roles []entity.Role
+
+	rows, err := rr.Conn.QueryContext(ctx, `SELECT id, name FROM roles ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("RolesRepo - Fetch - Query #1: %w", err)
+	}
+	defer rows.Close()
+
+	index := make(map[int64]int)
+	for rows.Next() {
+		var role entity.Role
+		if err := rows.Scan(&role.Id, &role.Name); err != nil {
+			return nil, fmt.Errorf("RolesRepo - Fetch - Scan #1: %w", err)
+		}
+		index[role.Id] = len(roles)
+		roles = append(roles, role)
+	}
+
+	rows, err = rr.Conn.QueryContext(ctx, `
+	SELECT role_id, permission FROM role_permissions ORDER BY role_id, permission
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("RolesRepo - Fetch - Query #2: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var roleId int64
+		var permission entity.Permission
+		if err := rows.Scan(&roleId, &permission); err != nil {
+			return nil, fmt.Errorf("RolesRepo - Fetch - Scan #2: %w", err)
+		}
+		if i, ok := index[roleId]; ok {
+			roles[i].Permissions = append(roles[i].Permissions, permission)
+		}
+	}
+	return roles, nil
+}
+
+func (rr *RolesRepo) GetById(ctx context.Context, id int64) (entity.Role, error) {
+	var role entity.Role
+	err := rr.Conn.QueryRowContext(ctx, `SELECT id, name FROM roles WHERE id = $1`, id).Scan(&role.Id, &role.Name)
+	if err != nil {
+		return role, fmt.Errorf("RolesRepo - GetById - Scan #1: %w", err)
+	}
+
+	rows, err := rr.Conn.QueryContext(ctx, `
+	SELECT permission FROM role_permissions WHERE role_id = $1 ORDER BY permission
+	`, id)
+	if err != nil {
+		return role, fmt.Errorf("RolesRepo - GetById - Query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var permission entity.Permission
+		if err := rows.Scan(&permission); err != nil {
+			return role, fmt.Errorf("RolesRepo - GetById - Scan #2: %w", err)
+		}
+		role.Permissions = append(role.Permissions, permission)
+	}
+	return role, nil
+}
+
+// Update overwrites the name and the permissions of the role.
+func (rr *RolesRepo) Update(ctx context.Context, role entity.Role) error {
+	tx, err := rr.Conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("RolesRepo - Update - Begin: %w", err)
+	}
+	defer func() {
+		err = tx.Rollback()
+	}()
+
+	res, err := tx.ExecContext(ctx, `UPDATE roles SET name = $1 WHERE id = $2`, role.Name, role.Id)
+	if err != nil {
+		return fmt.Errorf("RolesRepo - Update - Exec #1: %w", wrapErr(err))
+	}
+	affected, err := res.RowsAffected()
+	if affected != 1 || err != nil {
+		return fmt.Errorf("RolesRepo - Update - RowsAffected: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM role_permissions WHERE role_id = $1`, role.Id)
+	if err != nil {
+		return fmt.Errorf("RolesRepo - Update - Exec #2: %w", err)
+	}
+	for _, permission := range role.Permissions {
+		_, err = tx.ExecContext(ctx, `
+		INSERT INTO role_permissions(role_id, permission) VALUES($1, $2)
+		`, role.Id, permission)
+		if err != nil {
+			return fmt.Errorf("RolesRepo - Update - Exec #3: %w", err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("RolesRepo - Update - Commit: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the role, its users have to be moved beforehand.
+func (rr *RolesRepo) Delete(ctx context.Context, id int64) error {
+	tx, err := rr.Conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("RolesRepo - Delete - Begin: %w", err)
+	}
+	defer func() {
+		err = tx.Rollback()
+	}()
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM role_permissions WHERE role_id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("RolesRepo - Delete - Exec #1: %w", err)
+	}
+	res, err := tx.ExecContext(ctx, `DELETE FROM roles WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("RolesRepo - Delete - Exec #2: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if affected != 1 || err != nil {
+		return fmt.Errorf("RolesRepo - Delete - RowsAffected: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("RolesRepo - Delete - Commit: %w", err)
+	}
+	return nil
+}
+
+// CountUsers tells how many users have the role.
+func (rr *RolesRepo) CountUsers(ctx context.Context, id int64) (int64, error) {
+	var count int64
+	err := rr.Conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM users WHERE role_id = $1`, id).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("RolesRepo - CountUsers - Scan: %w", err)
+	}
+	return count, nil
+}
+
+// MoveUsers gives the users of the role fromId the role toId.
+func (rr *RolesRepo) MoveUsers(ctx context.Context, fromId, toId int64) error {
+	_, err := rr.Conn.ExecContext(ctx, `UPDATE users SET role_id = $1 WHERE role_id = $2`, toId, fromId)
+	if err != nil {
+		return fmt.Errorf("RolesRepo - MoveUsers - Exec: %w", err)
+	}
+	return nil
+}
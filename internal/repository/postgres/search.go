@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"forum/internal/entity"
+)
+
+// headlineOptions makes ts_headline wrap matches in the entity markers.
+var headlineOptions = fmt.Sprintf("StartSel=%s, StopSel=%s, MinWords=6, MaxWords=14",
+	entity.MatchStart, entity.MatchEnd)
+
+// Search looks up terms in posts and comments. A post can appear several
+// times, once for itself and once per matching comment.
+func (pr *PostsRepo) Search(ctx context.Context, terms []string, limit int) ([]entity.SearchResult, error) {
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	query := tsQuery(terms)
+
+	results, err := pr.searchIndex(ctx, `
+	SELECT
+		id,
+		ts_rank('{0.1, 0.2, 0.4, 1.0}', search, query) AS rank,
+		ts_headline('simple', title || ' ' || content, query, $2)
+	FROM posts, to_tsquery('simple', $1) query
+	WHERE search @@ query AND deleted_at IS NULL
+	ORDER BY rank DESC
+	LIMIT $3
+	`, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("PostsRepo - Search - posts - %w", err)
+	}
+
+	comments, err := pr.searchIndex(ctx, `
+	SELECT
+		post_id,
+		ts_rank('{0.1, 0.2, 0.4, 1.0}', search, query) AS rank,
+		ts_headline('simple', content, query, $2)
+	FROM comments, to_tsquery('simple', $1) query
+	WHERE search @@ query AND deleted_at IS NULL
+		AND post_id NOT IN (SELECT id FROM posts WHERE deleted_at IS NOT NULL)
+	ORDER BY rank DESC
+	LIMIT $3
+	`, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("PostsRepo - Search - comments - %w", err)
+	}
+
+	return append(results, comments...), nil
+}
+
+func (pr *PostsRepo) searchIndex(ctx context.Context, query, tsquery string,
+	limit int) ([]entity.SearchResult, error) {
+	var results []entity.SearchResult
+
+	rows, err := pr.Conn.QueryContext(ctx, query, tsquery, headlineOptions, limit)
+	if err != nil {
+		return nil, fmt.Errorf("Query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var result entity.SearchResult
+		err = rows.Scan(&result.Post.Id, &result.Rank, &result.Snippet)
+		if err != nil {
+			return nil, fmt.Errorf("Scan: %w", err)
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// tsQuery matches every term as a prefix, all terms are required.
+func tsQuery(terms []string) string {
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = "'" + strings.ReplaceAll(term, "'", "''") + "':*"
+	}
+	return strings.Join(quoted, " & ")
+}
@@ -0,0 +1,272 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"forum/internal/entity"
+	"forum/pkg/sqlconn"
+)
+
+// purgedPosts selects posts deleted before the given time.
+const purgedPosts = `SELECT id FROM posts WHERE deleted_at < $1`
+
+// purgedComments selects comments deleted before the given time that have
+// no replies left, a deleted comment with replies stays until they are
+// gone so the thread keeps its place.
+const purgedComments = `SELECT id FROM comments WHERE deleted_at < $1
+	AND id NOT IN (SELECT parent_id FROM comments WHERE parent_id IS NOT NULL)`
+
+func (pr *PostsRepo) Restore(ctx context.Context, id int64) error {
+	res, err := pr.Conn.ExecContext(ctx, `
+	UPDATE posts
+	SET deleted_at = NULL, deleted_by = NULL, delete_reason = NULL
+	WHERE id = $1 AND deleted_at IS NOT NULL
+	`, id)
+	if err != nil {
+		return fmt.Errorf("PostsRepo - Restore - Exec: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("PostsRepo - Restore - RowsAffected: %w", err)
+	}
+	if affected != 1 {
+		return fmt.Errorf("PostsRepo - Restore - RowsAffected: %w", sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+func (pr *PostsRepo) FetchDeleted(ctx context.Context) ([]entity.Post, error) {
+	rows, err := pr.Conn.QueryContext(ctx, selectPosts+`
+	WHERE deleted_at IS NOT NULL
+	ORDER BY deleted_at DESC, id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("PostsRepo - FetchDeleted - Query: %w", err)
+	}
+	defer rows.Close()
+
+	posts, err := scanPosts(rows)
+	if err != nil {
+		return posts, fmt.Errorf("PostsRepo - FetchDeleted - %w", err)
+	}
+
+	return posts, nil
+}
+
+// Purge removes posts deleted before the given time for good, along with
+// their comments, categories, images, reactions and revisions.
+func (pr *PostsRepo) Purge(ctx context.Context, before time.Time) (int64, error) {
+	tx, err := pr.Conn.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("PostsRepo - Purge - Begin: %w", err)
+	}
+	defer func() {
+		err = tx.Rollback()
+	}()
+
+	purged, err := purgePosts(ctx, tx, purgedPosts, formatTime(before))
+	if err != nil {
+		return 0, fmt.Errorf("PostsRepo - Purge - %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, fmt.Errorf("PostsRepo - Purge - Commit: %w", err)
+	}
+
+	return purged, nil
+}
+
+// PurgeByUser removes the posts of the user for good the way Purge does
+// and forgets that the user moved other posts to the trash.
+func (pr *PostsRepo) PurgeByUser(ctx context.Context, userId int64) error {
+	tx, err := pr.Conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("PostsRepo - PurgeByUser - Begin: %w", err)
+	}
+	defer func() {
+		err = tx.Rollback()
+	}()
+
+	_, err = purgePosts(ctx, tx, `SELECT id FROM posts WHERE user_id = $1`, userId)
+	if err != nil {
+		return fmt.Errorf("PostsRepo - PurgeByUser - %w", err)
+	}
+	_, err = tx.ExecContext(ctx, `UPDATE posts SET deleted_by = NULL WHERE deleted_by = $1`, userId)
+	if err != nil {
+		return fmt.Errorf("PostsRepo - PurgeByUser - Exec: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("PostsRepo - PurgeByUser - Commit: %w", err)
+	}
+
+	return nil
+}
+
+// purgePosts removes the posts selected by the picked query along with
+// their comments, categories, images, reactions and revisions and returns
+// how many posts there were.
+func purgePosts(ctx context.Context, tx sqlconn.Tx, picked string, arg any) (int64, error) {
+	cleanups := []string{
+		`DELETE FROM reactions WHERE target = 'comment' AND target_id IN (
+			SELECT id FROM comments WHERE post_id IN (` + picked + `))`,
+		`DELETE FROM images WHERE comment_id IN (
+			SELECT id FROM comments WHERE post_id IN (` + picked + `))`,
+		`DELETE FROM revisions WHERE target = 'comment' AND target_id IN (
+			SELECT id FROM comments WHERE post_id IN (` + picked + `))`,
+		`DELETE FROM comments WHERE post_id IN (` + picked + `)`,
+		`DELETE FROM reactions WHERE target = 'post' AND target_id IN (` + picked + `)`,
+		`DELETE FROM images WHERE post_id IN (` + picked + `)`,
+		`DELETE FROM reference_topic WHERE post_id IN (` + picked + `)`,
+		`DELETE FROM revisions WHERE target = 'post' AND target_id IN (` + picked + `)`,
+	}
+	for i, query := range cleanups {
+		_, err := tx.ExecContext(ctx, query, arg)
+		if err != nil {
+			return 0, fmt.Errorf("Exec #%d: %w", i+1, err)
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM posts WHERE id IN (`+picked+`)`, arg)
+	if err != nil {
+		return 0, fmt.Errorf("Exec: %w", err)
+	}
+	purged, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("RowsAffected: %w", err)
+	}
+
+	return purged, nil
+}
+
+func (cr *CommentsRepo) Restore(ctx context.Context, id int64) error {
+	res, err := cr.Conn.ExecContext(ctx, `
+	UPDATE comments
+	SET deleted_at = NULL, deleted_by = NULL, delete_reason = NULL
+	WHERE id = $1 AND deleted_at IS NOT NULL
+	`, id)
+	if err != nil {
+		return fmt.Errorf("CommentsRepo - Restore - Exec: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("CommentsRepo - Restore - RowsAffected: %w", err)
+	}
+	if affected != 1 {
+		return fmt.Errorf("CommentsRepo - Restore - RowsAffected: %w", sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+func (cr *CommentsRepo) FetchDeleted(ctx context.Context) ([]entity.Comment, error) {
+	rows, err := cr.Conn.QueryContext(ctx, selectComments+`
+	WHERE deleted_at IS NOT NULL
+	ORDER BY deleted_at DESC, id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("CommentsRepo - FetchDeleted - Query: %w", err)
+	}
+	defer rows.Close()
+
+	comments, err := scanComments(rows)
+	if err != nil {
+		return nil, fmt.Errorf("CommentsRepo - FetchDeleted - %w", err)
+	}
+	return comments, nil
+}
+
+// Purge removes comments deleted before the given time for good, along
+// with their images, reactions and revisions. Deleted comments with
+// replies are kept.
+func (cr *CommentsRepo) Purge(ctx context.Context, before time.Time) (int64, error) {
+	tx, err := cr.Conn.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("CommentsRepo - Purge - Begin: %w", err)
+	}
+	defer func() {
+		err = tx.Rollback()
+	}()
+
+	purged, err := purgeComments(ctx, tx, purgedComments, formatTime(before))
+	if err != nil {
+		return 0, fmt.Errorf("CommentsRepo - Purge - %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, fmt.Errorf("CommentsRepo - Purge - Commit: %w", err)
+	}
+
+	return purged, nil
+}
+
+// PurgeByUser removes the comments of the user for good the way Purge
+// does, replies to them move to the top of their post. It forgets that the
+// user moved other comments to the trash.
+func (cr *CommentsRepo) PurgeByUser(ctx context.Context, userId int64) error {
+	tx, err := cr.Conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("CommentsRepo - PurgeByUser - Begin: %w", err)
+	}
+	defer func() {
+		err = tx.Rollback()
+	}()
+
+	const picked = `SELECT id FROM comments WHERE user_id = $1`
+	_, err = tx.ExecContext(ctx, `UPDATE comments SET parent_id = NULL WHERE parent_id IN (`+picked+`)`, userId)
+	if err != nil {
+		return fmt.Errorf("CommentsRepo - PurgeByUser - Exec #1: %w", err)
+	}
+	_, err = purgeComments(ctx, tx, picked, userId)
+	if err != nil {
+		return fmt.Errorf("CommentsRepo - PurgeByUser - %w", err)
+	}
+	_, err = tx.ExecContext(ctx, `UPDATE comments SET deleted_by = NULL WHERE deleted_by = $1`, userId)
+	if err != nil {
+		return fmt.Errorf("CommentsRepo - PurgeByUser - Exec #2: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("CommentsRepo - PurgeByUser - Commit: %w", err)
+	}
+
+	return nil
+}
+
+// purgeComments removes the comments selected by the picked query along
+// with their images, reactions and revisions and returns how many comments
+// there were.
+func purgeComments(ctx context.Context, tx sqlconn.Tx, picked string, arg any) (int64, error) {
+	cleanups := []string{
+		`DELETE FROM reactions WHERE target = 'comment' AND target_id IN (` + picked + `)`,
+		`DELETE FROM images WHERE comment_id IN (` + picked + `)`,
+		`DELETE FROM revisions WHERE target = 'comment' AND target_id IN (` + picked + `)`,
+	}
+	for i, query := range cleanups {
+		_, err := tx.ExecContext(ctx, query, arg)
+		if err != nil {
+			return 0, fmt.Errorf("Exec #%d: %w", i+1, err)
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM comments WHERE id IN (`+picked+`)`, arg)
+	if err != nil {
+		return 0, fmt.Errorf("Exec: %w", err)
+	}
+	purged, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("RowsAffected: %w", err)
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,199 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"forum/internal/entity"
+	"forum/pkg/postgres"
+)
+
+type TwoFactorRepo struct {
+	*postgres.Postgres
+}
+
+func NewTwoFactorRepo(pg *postgres.Postgres) *TwoFactorRepo {
+	return &TwoFactorRepo{pg}
+}
+
+// Get returns the state of the user with the number of recovery codes
+// left.
+func (tr *TwoFactorRepo) Get(ctx context.Context, userId int64) (entity.TwoFactor, error) {
+	tf := entity.TwoFactor{UserId: userId}
+	err := tr.Conn.QueryRowContext(ctx, `
+	SELECT totp_secret, totp_enabled, totp_last_step,
+		(SELECT COUNT(*) FROM recovery_codes WHERE recovery_codes.user_id = users.id)
+	FROM users
+	WHERE id = $1
+	`, userId).Scan(&tf.Secret, &tf.Enabled, &tf.LastStep, &tf.RecoveryCodes)
+	if err != nil {
+		return tf, fmt.Errorf("TwoFactorRepo - Get - Scan: %w", err)
+	}
+	return tf, nil
+}
+
+// Update overwrites Secret, Enabled and LastStep.
+func (tr *TwoFactorRepo) Update(ctx context.Context, tf entity.TwoFactor) error {
+	res, err := tr.Conn.ExecContext(ctx, `
+	UPDATE users
+	SET totp_secret = $1, totp_enabled = $2, totp_last_step = $3
+	WHERE id = $4
+	`, tf.Secret, tf.Enabled, tf.LastStep, tf.UserId)
+	if err != nil {
+		return fmt.Errorf("TwoFactorRepo - Update - Exec: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if affected != 1 || err != nil {
+		return fmt.Errorf("TwoFactorRepo - Update - RowsAffected: %w", err)
+	}
+	return nil
+}
+
+// UseStep moves LastStep to step when it is later, it returns false for
+// steps taken already.
+func (tr *TwoFactorRepo) UseStep(ctx context.Context, userId, step int64) (bool, error) {
+	res, err := tr.Conn.ExecContext(ctx, `
+	UPDATE users
+	SET totp_last_step = $1
+	WHERE id = $2 AND totp_last_step < $3
+	`, step, userId, step)
+	if err != nil {
+		return false, fmt.Errorf("TwoFactorRepo - UseStep - Exec: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("TwoFactorRepo - UseStep - RowsAffected: %w", err)
+	}
+	return affected == 1, nil
+}
+
+// StoreRecoveryCodes replaces the recovery codes of the user.
+func (tr *TwoFactorRepo) StoreRecoveryCodes(ctx context.Context, userId int64, codeHashes []string) error {
+	if err := tr.DeleteRecoveryCodes(ctx, userId); err != nil {
+		return fmt.Errorf("TwoFactorRepo - StoreRecoveryCodes - %w", err)
+	}
+	for _, codeHash := range codeHashes {
+		_, err := tr.Conn.ExecContext(ctx, `
+		INSERT INTO recovery_codes(user_id, code_hash)
+			VALUES($1, $2)
+		`, userId, codeHash)
+		if err != nil {
+			return fmt.Errorf("TwoFactorRepo - StoreRecoveryCodes - Exec: %w", err)
+		}
+	}
+	return nil
+}
+
+// UseRecoveryCode removes the code of the user and returns false when
+// there was no such code.
+func (tr *TwoFactorRepo) UseRecoveryCode(ctx context.Context, userId int64, codeHash string) (bool, error) {
+	res, err := tr.Conn.ExecContext(ctx, `
+	DELETE FROM recovery_codes
+	WHERE user_id = $1 AND code_hash = $2
+	`, userId, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("TwoFactorRepo - UseRecoveryCode - Exec: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("TwoFactorRepo - UseRecoveryCode - RowsAffected: %w", err)
+	}
+	return affected > 0, nil
+}
+
+func (tr *TwoFactorRepo) DeleteRecoveryCodes(ctx context.Context, userId int64) error {
+	_, err := tr.Conn.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, userId)
+	if err != nil {
+		return fmt.Errorf("TwoFactorRepo - DeleteRecoveryCodes - Exec: %w", err)
+	}
+	return nil
+}
+
+type LoginChallengesRepo struct {
+	*postgres.Postgres
+}
+
+func NewLoginChallengesRepo(pg *postgres.Postgres) *LoginChallengesRepo {
+	return &LoginChallengesRepo{pg}
+}
+
+func (cr *LoginChallengesRepo) Store(ctx context.Context, challenge *entity.LoginChallenge) error {
+	err := cr.Conn.QueryRowContext(ctx, `
+	INSERT INTO login_challenges(user_id, token_hash, attempts, created_at, expires_at)
+		VALUES($1, $2, $3, $4, $5)
+	RETURNING id
+	`, challenge.User.Id, challenge.TokenHash, challenge.Attempts, formatTime(challenge.CreatedAt),
+		formatTime(challenge.ExpiresAt)).Scan(&challenge.Id)
+	if err != nil {
+		return fmt.Errorf("LoginChallengesRepo - Store - Scan: %w", wrapErr(err))
+	}
+	return nil
+}
+
+func (cr *LoginChallengesRepo) GetByHash(ctx context.Context, tokenHash string) (entity.LoginChallenge, error) {
+	var challenge entity.LoginChallenge
+	var createdAt, expiresAt sql.NullString
+	err := cr.Conn.QueryRowContext(ctx, `
+	SELECT id, user_id, token_hash, attempts, created_at, expires_at
+	FROM login_challenges
+	WHERE token_hash = $1
+	`, tokenHash).Scan(&challenge.Id, &challenge.User.Id, &challenge.TokenHash, &challenge.Attempts,
+		&createdAt, &expiresAt)
+	if err != nil {
+		return challenge, fmt.Errorf("LoginChallengesRepo - GetByHash - Scan: %w", err)
+	}
+	challenge.CreatedAt = parseTime(createdAt)
+	challenge.ExpiresAt = parseTime(expiresAt)
+	return challenge, nil
+}
+
+func (cr *LoginChallengesRepo) AddAttempt(ctx context.Context, id int64, max int) (bool, error) {
+	res, err := cr.Conn.ExecContext(ctx, `
+	UPDATE login_challenges SET attempts = attempts + 1
+	WHERE id = $1 AND attempts < $2
+	`, id, max)
+	if err != nil {
+		return false, fmt.Errorf("LoginChallengesRepo - AddAttempt - Exec: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("LoginChallengesRepo - AddAttempt - RowsAffected: %w", err)
+	}
+	return affected == 1, nil
+}
+
+func (cr *LoginChallengesRepo) Delete(ctx context.Context, id int64) error {
+	_, err := cr.Conn.ExecContext(ctx, `DELETE FROM login_challenges WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("LoginChallengesRepo - Delete - Exec: %w", err)
+	}
+	return nil
+}
+
+func (cr *LoginChallengesRepo) DeleteByUser(ctx context.Context, userId int64) error {
+	_, err := cr.Conn.ExecContext(ctx, `DELETE FROM login_challenges WHERE user_id = $1`, userId)
+	if err != nil {
+		return fmt.Errorf("LoginChallengesRepo - DeleteByUser - Exec: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired removes the challenges expired before the given time and
+// returns how many there were.
+func (cr *LoginChallengesRepo) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	res, err := cr.Conn.ExecContext(ctx, `DELETE FROM login_challenges WHERE expires_at < $1`, formatTime(before))
+	if err != nil {
+		return 0, fmt.Errorf("LoginChallengesRepo - DeleteExpired - Exec: %w", err)
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("LoginChallengesRepo - DeleteExpired - RowsAffected: %w", err)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,395 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"forum/internal/entity"
+	"forum/pkg/postgres"
+
+	"github.com/lib/pq"
+)
+
+type UsersRepo struct {
+	*postgres.Postgres
+}
+
+func NewUsersRepo(pg *postgres.Postgres) *UsersRepo {
+	return &UsersRepo{pg}
+}
+
+func (ur *UsersRepo) Store(ctx context.Context, user entity.User) error {
+	res, err := ur.Conn.ExecContext(ctx, `
+	INSERT INTO users(name, email, password, reg_date, date_of_birth, city, sex, sign, email_verified)
+		values($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, user.Name, user.Email, user.Password, nullTime(user.RegDate),
+		user.DateOfBirth, user.City, user.Gender, " ", user.EmailVerified)
+	if err != nil {
+		return fmt.Errorf("UsersRepo - Store - Exec: %w", wrapErr(err))
+	}
+
+	affected, err := res.RowsAffected()
+	if affected != 1 || err != nil {
+		return fmt.Errorf("UsersRepo - Store - RowsAffected: %w", err)
+	}
+
+	return nil
+}
+
+// selectUsers lists users with their post and comment counters, callers append
+// conditions and ordering.
+const selectUsers = `
+	SELECT
+		id, name, email, reg_date, date_of_birth, city, sex,
+		role_id, (SELECT name FROM roles WHERE roles.id = users.role_id),
+		post_count, comment_count
+	FROM users
+	`
+
+func (ur *UsersRepo) Fetch(ctx context.Context) ([]entity.User, error) {
+	rows, err := ur.Conn.QueryContext(ctx, selectUsers+`
+	ORDER BY id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("UsersRepo - Fetch - Query: %w", err)
+	}
+	defer rows.Close()
+
+	users, err := scanUsers(rows)
+	if err != nil {
+		return nil, fmt.Errorf("UsersRepo - Fetch - %w", err)
+	}
+	return users, nil
+}
+
+func (ur *UsersRepo) FetchByIds(ctx context.Context, ids []int64) ([]entity.User, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	rows, err := ur.Conn.QueryContext(ctx, selectUsers+`
+	WHERE id = ANY($1)
+	ORDER BY id
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("UsersRepo - FetchByIds - Query: %w", err)
+	}
+	defer rows.Close()
+
+	users, err := scanUsers(rows)
+	if err != nil {
+		return nil, fmt.Errorf("UsersRepo - FetchByIds - %w", err)
+	}
+	return users, nil
+}
+
+func (ur *UsersRepo) FetchPage(ctx context.Context, limit, offset int) ([]entity.User, error) {
+	rows, err := ur.Conn.QueryContext(ctx, selectUsers+`
+	ORDER BY id
+	LIMIT $1 OFFSET $2
+	`, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("UsersRepo - FetchPage - Query: %w", err)
+	}
+	defer rows.Close()
+
+	users, err := scanUsers(rows)
+	if err != nil {
+		return nil, fmt.Errorf("UsersRepo - FetchPage - %w", err)
+	}
+	return users, nil
+}
+
+func (ur *UsersRepo) FetchAfter(ctx context.Context, cursor int64, limit int) ([]entity.User, error) {
+	rows, err := ur.Conn.QueryContext(ctx, selectUsers+`
+	WHERE id > $1
+	ORDER BY id
+	LIMIT $2
+	`, cursor, limit)
+	if err != nil {
+		return nil, fmt.Errorf("UsersRepo - FetchAfter - Query: %w", err)
+	}
+	defer rows.Close()
+
+	users, err := scanUsers(rows)
+	if err != nil {
+		return nil, fmt.Errorf("UsersRepo - FetchAfter - %w", err)
+	}
+	return users, nil
+}
+
+func (ur *UsersRepo) Count(ctx context.Context) (int64, error) {
+	var count int64
+	err := ur.Conn.QueryRowContext(ctx, `
+	SELECT COUNT(*)
+	FROM users
+	`).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("UsersRepo - Count - Scan: %w", err)
+	}
+
+	return count, nil
+}
+
+func scanUsers(rows *sql.Rows) ([]entity.User, error) {
+	var users []entity.User
+
+	for rows.Next() {
+		user := entity.User{}
+		var regDate, dateOfBirth, city, gender sql.NullString
+		var posts sql.NullInt64
+		var comments sql.NullInt64
+
+		err := rows.Scan(&user.Id, &user.Name, &user.Email, &regDate, &dateOfBirth, &city,
+			&gender, &user.Role.Id, &user.Role.Name, &posts, &comments)
+		if err != nil {
+			return nil, fmt.Errorf("Scan: %w", err)
+		}
+
+		user.RegDate = parseTime(regDate)
+		user.DateOfBirth = dateOfBirth.String
+		user.City = city.String
+		user.Gender = gender.String
+		user.Posts = posts.Int64
+		user.Comments = comments.Int64
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+func (ur *UsersRepo) GetId(ctx context.Context, user entity.User) (int64, error) {
+	var id int64
+	switch {
+	case user.Name != "":
+		err := ur.Conn.QueryRowContext(ctx, `
+		SELECT id
+		FROM users
+		WHERE name = $1
+		`, user.Name).Scan(&id)
+		if err != nil {
+			return 0, fmt.Errorf("UsersRepo - GetId - case Name - Scan: %w", err)
+		}
+	case user.Email != "":
+		err := ur.Conn.QueryRowContext(ctx, `
+		SELECT id
+		FROM users
+		WHERE email = $1
+		`, user.Email).Scan(&id)
+		if err != nil {
+			return 0, fmt.Errorf("UsersRepo - GetId - case Email - Scan: %w", err)
+		}
+	}
+
+	return id, nil
+}
+
+func (ur *UsersRepo) GetById(ctx context.Context, id int64) (entity.User, error) {
+	var user entity.User
+	var password, regDate, dateOfBirth, city, gender sql.NullString
+	var posts sql.NullInt64
+	var comments sql.NullInt64
+	var sign, timezone sql.NullString
+	var avatarPath sql.NullString
+	var sentAt sql.NullString
+
+	err := ur.Conn.QueryRowContext(ctx, `
+	SELECT
+		id, name, email, password, reg_date, date_of_birth, city, sex, sign, timezone,
+		role_id, (SELECT name FROM roles WHERE roles.id = users.role_id), banned,
+		email_verified, pending_email, verification_sent_at, totp_enabled,
+		(SELECT path FROM images WHERE images.user_id = $1 LIMIT 1),
+		post_count, comment_count
+	FROM users
+	WHERE id = $1
+	`, id).Scan(&user.Id, &user.Name, &user.Email, &password, &regDate,
+		&dateOfBirth, &city, &gender, &sign, &timezone, &user.Role.Id, &user.Role.Name, &user.Banned,
+		&user.EmailVerified, &user.PendingEmail, &sentAt, &user.TwoFactor, &avatarPath, &posts, &comments)
+	if err != nil {
+		return user, fmt.Errorf("UsersRepo - GetById - Scan: %w", err)
+	}
+
+	user.Password = password.String
+	user.RegDate = parseTime(regDate)
+	user.DateOfBirth = dateOfBirth.String
+	user.City = city.String
+	user.Gender = gender.String
+	user.Posts = posts.Int64
+	user.Comments = comments.Int64
+	user.Sign = sign.String
+	user.Timezone = timezone.String
+	user.AvatarPath = avatarPath.String
+	user.VerificationSentAt = parseTime(sentAt)
+
+	if user.DateOfBirth == "0001-01-01" {
+		user.DateOfBirth = ""
+	}
+
+	return user, nil
+}
+
+func (ur *UsersRepo) UpdateInfo(ctx context.Context, user entity.User) error {
+	tx, err := ur.Conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("UsersRepo - UpdateInfo - Begin: %w", err)
+	}
+	defer func() {
+		err = tx.Rollback()
+	}()
+
+	res, err := tx.ExecContext(ctx, `
+	UPDATE users
+	SET date_of_birth = $1, city = $2, sex = $3, sign = $4, timezone = $5
+	WHERE id = $6
+	`, user.DateOfBirth, user.City, user.Gender, user.Sign, user.Timezone, user.Id)
+	if err != nil {
+		return fmt.Errorf("UsersRepo - UpdateInfo - Exec #1: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if affected != 1 || err != nil {
+		return fmt.Errorf("UsersRepo - UpdateInfo - RowsAffected #1: %w", err)
+	}
+
+	var avatarPath sql.NullString
+	err = tx.QueryRowContext(ctx, `
+		SELECT path
+		FROM images WHERE user_id = $1
+	`, user.Id).Scan(&avatarPath)
+
+	if err == sql.ErrNoRows {
+		res, err = tx.ExecContext(ctx, `
+		INSERT INTO images(user_id, path)
+		VALUES ($1, $2)
+		`, user.Id, user.AvatarPath)
+		if err != nil {
+			return fmt.Errorf("UsersRepo - UpdateInfo - Exec #2: %w", err)
+		}
+	} else {
+		res, err = tx.ExecContext(ctx, `
+		UPDATE images
+		SET path = $1
+		WHERE user_id = $2
+		`, user.AvatarPath, user.Id)
+		if err != nil {
+			return fmt.Errorf("UsersRepo - UpdateInfo - Exec #3: %w", err)
+		}
+	}
+	affected, err = res.RowsAffected()
+	if affected != 1 || err != nil {
+		return fmt.Errorf("UsersRepo - UpdateInfo - RowsAffected #2: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("UsersRepo - UpdateInfo - Commit: %w", err)
+	}
+
+	return nil
+}
+
+func (ur *UsersRepo) UpdatePassword(ctx context.Context, user entity.User) error {
+	res, err := ur.Conn.ExecContext(ctx, `
+	UPDATE users
+	SET password = $1
+	WHERE id = $2
+	`, user.Password, user.Id)
+	if err != nil {
+		return fmt.Errorf("UsersRepo - UpdatePassword - Exec: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if affected != 1 || err != nil {
+		return fmt.Errorf("UsersRepo - UpdatePassword - RowsAffected: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateVerification overwrites PendingEmail and VerificationSentAt.
+func (ur *UsersRepo) UpdateVerification(ctx context.Context, user entity.User) error {
+	res, err := ur.Conn.ExecContext(ctx, `
+	UPDATE users
+	SET pending_email = $1, verification_sent_at = $2
+	WHERE id = $3
+	`, user.PendingEmail, nullTime(user.VerificationSentAt), user.Id)
+	if err != nil {
+		return fmt.Errorf("UsersRepo - UpdateVerification - Exec: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if affected != 1 || err != nil {
+		return fmt.Errorf("UsersRepo - UpdateVerification - RowsAffected: %w", err)
+	}
+	return nil
+}
+
+// VerifyEmail makes email the verified email of the user and clears the
+// pending one.
+func (ur *UsersRepo) VerifyEmail(ctx context.Context, id int64, email string) error {
+	res, err := ur.Conn.ExecContext(ctx, `
+	UPDATE users
+	SET email = $1, email_verified = TRUE, pending_email = ''
+	WHERE id = $2
+	`, email, id)
+	if err != nil {
+		return fmt.Errorf("UsersRepo - VerifyEmail - Exec: %w", wrapErr(err))
+	}
+
+	affected, err := res.RowsAffected()
+	if affected != 1 || err != nil {
+		return fmt.Errorf("UsersRepo - VerifyEmail - RowsAffected: %w", err)
+	}
+	return nil
+}
+
+// SetRole gives the user the role roleId.
+func (ur *UsersRepo) SetRole(ctx context.Context, id, roleId int64) error {
+	res, err := ur.Conn.ExecContext(ctx, `UPDATE users SET role_id = $1 WHERE id = $2`, roleId, id)
+	if err != nil {
+		return fmt.Errorf("UsersRepo - SetRole - Exec: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if affected != 1 || err != nil {
+		return fmt.Errorf("UsersRepo - SetRole - RowsAffected: %w", err)
+	}
+	return nil
+}
+
+// SetBanned bans the user or lifts the ban.
+func (ur *UsersRepo) SetBanned(ctx context.Context, id int64, banned bool) error {
+	res, err := ur.Conn.ExecContext(ctx, `UPDATE users SET banned = $1 WHERE id = $2`, banned, id)
+	if err != nil {
+		return fmt.Errorf("UsersRepo - SetBanned - Exec: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if affected != 1 || err != nil {
+		return fmt.Errorf("UsersRepo - SetBanned - RowsAffected: %w", err)
+	}
+	return nil
+}
+
+func (ur *UsersRepo) Delete(ctx context.Context, user entity.User) error {
+	_, err := ur.Conn.ExecContext(ctx, `DELETE FROM images WHERE user_id = $1`, user.Id)
+	if err != nil {
+		return fmt.Errorf("UsersRepo - Delete - Exec #1: %w", err)
+	}
+
+	res, err := ur.Conn.ExecContext(ctx, `
+	DELETE FROM users
+	WHERE id = $1
+	`, user.Id)
+	if err != nil {
+		return fmt.Errorf("UsersRepo - Delete - Exec #2: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if affected != 1 || err != nil {
+		return fmt.Errorf("UsersRepo - Delete - RowsAffected: %w", err)
+	}
+
+	return nil
+}
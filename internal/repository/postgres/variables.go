@@ -0,0 +1,40 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+)
+
+const (
+	// TimeFormat is the layout timestamps are stored in. They are kept in
+	// UTC, so comparing and ordering them as text follows time.
+	TimeFormat = "2006-01-02T15:04:05Z"
+)
+
+const (
+	uniqueViolation = "23505"
+	uniqueErr       = "UNIQUE constraint failed"
+)
+
+// formatTime turns t into its stored form.
+func formatTime(t time.Time) string {
+	return t.UTC().Format(TimeFormat)
+}
+
+// nullTime is formatTime for nullable columns, the zero time is NULL.
+func nullTime(t time.Time) sql.NullString {
+	if t.IsZero() {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: formatTime(t), Valid: true}
+}
+
+// parseTime reads a stored timestamp, NULL and values not in TimeFormat
+// read as the zero time.
+func parseTime(value sql.NullString) time.Time {
+	t, err := time.Parse(TimeFormat, value.String)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
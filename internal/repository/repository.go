@@ -1,68 +1,387 @@
 package repository
 
 import (
+	"context"
+	"time"
+
 	"forum/internal/entity"
+	"forum/internal/repository/memory"
+	pgrepo "forum/internal/repository/postgres"
 	"forum/internal/repository/sqlite"
+	"forum/pkg/postgres"
+	"forum/pkg/sqlconn"
 	"forum/pkg/sqlite3"
 )
 
 type Posts interface {
-	Store(post *entity.Post) error
-	Fetch() ([]entity.Post, error)
-	FetchByAuthor(user entity.User) ([]entity.Post, error)
-	GetById(id int64) (entity.Post, error)
-	GetIdsByCategory(category string) ([]int64, error)
-	FetchIdsByReaction(user entity.User, reaction string) ([]int64, error)
-	Update(post entity.Post) error
-	Delete(post entity.Post) error
-	StoreLike(post entity.Post) error
-	StoreDislike(post entity.Post) error
-	DeleteLike(post entity.Post) error
-	DeleteDislike(post entity.Post) error
-	StoreTopicReference(post entity.Post) error
-	GetRelatedCategories(post entity.Post) ([]string, error)
-	FetchReactions(id int64) (entity.Post, error)
-	StoreCategories(categories []string) error
-	GetExistedCategories() ([]string, error)
+	Store(ctx context.Context, post *entity.Post) error
+	Fetch(ctx context.Context) ([]entity.Post, error)
+	// FetchPage and FetchAfter list posts ordered by id, FetchAfter starts
+	// past the cursor id and stays stable while new posts are written.
+	FetchPage(ctx context.Context, limit, offset int) ([]entity.Post, error)
+	FetchAfter(ctx context.Context, cursor int64, limit int) ([]entity.Post, error)
+	Count(ctx context.Context) (int64, error)
+	FetchByAuthor(ctx context.Context, user entity.User) ([]entity.Post, error)
+	GetById(ctx context.Context, id int64) (entity.Post, error)
+	GetIdsByCategory(ctx context.Context, categoryId int64) ([]int64, error)
+	// Update overwrites the title and the content and sets EditedAt,
+	// categories are replaced unless they are nil.
+	Update(ctx context.Context, post entity.Post) error
+	// Delete moves a post to the trash, listings and search skip it while
+	// GetById still returns it with the deletion mark.
+	Delete(ctx context.Context, post entity.Post) error
+	Restore(ctx context.Context, id int64) error
+	FetchDeleted(ctx context.Context) ([]entity.Post, error)
+	// Purge removes posts deleted before the given time for good and
+	// returns how many there were.
+	Purge(ctx context.Context, before time.Time) (int64, error)
+	// PurgeByUser removes the posts of the user for good and clears the
+	// user from the deletion marks of other posts.
+	PurgeByUser(ctx context.Context, userId int64) error
+	// StoreTopicReference puts the post in its categories, they are
+	// referenced by id.
+	StoreTopicReference(ctx context.Context, post entity.Post) error
+	GetRelatedCategories(ctx context.Context, post entity.Post) ([]entity.Category, error)
+	// FetchCategories returns categories of many posts in one query, keyed
+	// by post id. Posts without categories are left out.
+	FetchCategories(ctx context.Context, postIds []int64) (map[int64][]entity.Category, error)
+	// Search returns posts matching every term, terms are lowercase words.
+	// A post may be returned once for itself and once per matching comment.
+	Search(ctx context.Context, terms []string, limit int) ([]entity.SearchResult, error)
+}
+
+// Categories keeps the category tree. Categories are listed by position
+// and then by name.
+type Categories interface {
+	// Store writes a new category and sets its Id. Names and slugs are
+	// unique.
+	Store(ctx context.Context, category *entity.Category) error
+	// Fetch lists every category with the counts of its live posts and
+	// their comments and the date of the latest of them.
+	Fetch(ctx context.Context) ([]entity.Category, error)
+	GetById(ctx context.Context, id int64) (entity.Category, error)
+	GetBySlug(ctx context.Context, slug string) (entity.Category, error)
+	// Update overwrites every field of the category but its Id.
+	Update(ctx context.Context, category entity.Category) error
+	// MoveReferences moves the posts of the category fromId to the
+	// category toId, or drops their references when toId is zero.
+	MoveReferences(ctx context.Context, fromId, toId int64) error
+	// Delete removes the category with its post references, its children
+	// have to be moved beforehand.
+	Delete(ctx context.Context, id int64) error
 }
 
 type Users interface {
-	Store(user entity.User) error
-	Fetch() ([]entity.User, error)
-	GetId(user entity.User) (int64, error)
-	GetById(n int64) (entity.User, error)
-	GetSession(n int64) (entity.User, error)
-	UpdateInfo(user entity.User) error
-	UpdatePassword(user entity.User) error
-	NewSession(user entity.User) error
-	UpdateSession(user entity.User) error
-	Delete(user entity.User) error
+	Store(ctx context.Context, user entity.User) error
+	Fetch(ctx context.Context) ([]entity.User, error)
+	FetchPage(ctx context.Context, limit, offset int) ([]entity.User, error)
+	FetchAfter(ctx context.Context, cursor int64, limit int) ([]entity.User, error)
+	Count(ctx context.Context) (int64, error)
+	// FetchByIds lists the users with the given ids as Fetch does, ordered
+	// by id. Unknown ids are skipped.
+	FetchByIds(ctx context.Context, ids []int64) ([]entity.User, error)
+	GetId(ctx context.Context, user entity.User) (int64, error)
+	GetById(ctx context.Context, n int64) (entity.User, error)
+	// UpdateInfo overwrites the profile fields, the role and the ban are
+	// set by SetRole and SetBanned.
+	UpdateInfo(ctx context.Context, user entity.User) error
+	SetRole(ctx context.Context, id, roleId int64) error
+	SetBanned(ctx context.Context, id int64, banned bool) error
+	UpdatePassword(ctx context.Context, user entity.User) error
+	// UpdateVerification overwrites PendingEmail and VerificationSentAt.
+	UpdateVerification(ctx context.Context, user entity.User) error
+	// VerifyEmail makes email the verified email of the user and clears
+	// PendingEmail. An email of another user is a unique violation.
+	VerifyEmail(ctx context.Context, id int64, email string) error
+	// Delete removes the user with their avatar, everything else referring
+	// to them has to be gone beforehand.
+	Delete(ctx context.Context, user entity.User) error
+}
+
+// Roles keeps the roles with their permissions, the built-in ones are
+// made by the migrations. Roles are listed by Id.
+type Roles interface {
+	// Store writes a new role with its permissions and sets its Id. Names
+	// are unique.
+	Store(ctx context.Context, role *entity.Role) error
+	Fetch(ctx context.Context) ([]entity.Role, error)
+	GetById(ctx context.Context, id int64) (entity.Role, error)
+	// Update overwrites the name and the permissions of the role.
+	Update(ctx context.Context, role entity.Role) error
+	// Delete removes the role, its users have to be moved beforehand.
+	Delete(ctx context.Context, id int64) error
+	// CountUsers tells how many users have the role.
+	CountUsers(ctx context.Context, id int64) (int64, error)
+	// MoveUsers gives the users of the role fromId the role toId.
+	MoveUsers(ctx context.Context, fromId, toId int64) error
+}
+
+// Sessions keeps signed in browsers, a user can have any number of them.
+type Sessions interface {
+	// Store writes a new session and sets its Id. Tokens are unique.
+	Store(ctx context.Context, session *entity.Session) error
+	// GetByToken returns the session with the Id, the Timezone,
+	// EmailVerified, Banned and the Role.Id of its user.
+	GetByToken(ctx context.Context, token string) (entity.Session, error)
+	// FetchByUser lists the sessions of the user, the last seen first.
+	FetchByUser(ctx context.Context, userId int64) ([]entity.Session, error)
+	// Update overwrites the address, LastSeen and ExpiresAt of the
+	// session.
+	Update(ctx context.Context, session entity.Session) error
+	Delete(ctx context.Context, id int64) error
+	DeleteByUser(ctx context.Context, userId int64) error
+	// DeleteExpired removes the sessions expired before the given time and
+	// returns how many there were.
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+// PasswordResets keeps the hashes of mailed password reset tokens.
+type PasswordResets interface {
+	// Store writes a new reset and sets its Id. Token hashes are unique.
+	Store(ctx context.Context, reset *entity.PasswordReset) error
+	GetByHash(ctx context.Context, tokenHash string) (entity.PasswordReset, error)
+	DeleteByUser(ctx context.Context, userId int64) error
+	// DeleteExpired removes the resets expired before the given time and
+	// returns how many there were.
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+// TwoFactor keeps the TOTP state of users next to them and the hashes of
+// their recovery codes.
+type TwoFactor interface {
+	// Get returns the state of the user with the number of recovery codes
+	// left.
+	Get(ctx context.Context, userId int64) (entity.TwoFactor, error)
+	// Update overwrites Secret, Enabled and LastStep.
+	Update(ctx context.Context, tf entity.TwoFactor) error
+	// UseStep moves LastStep to step when it is later, it returns false
+	// for steps taken already.
+	UseStep(ctx context.Context, userId, step int64) (bool, error)
+	// StoreRecoveryCodes replaces the recovery codes of the user.
+	StoreRecoveryCodes(ctx context.Context, userId int64, codeHashes []string) error
+	// UseRecoveryCode removes the code of the user and returns false when
+	// there was no such code.
+	UseRecoveryCode(ctx context.Context, userId int64, codeHash string) (bool, error)
+	DeleteRecoveryCodes(ctx context.Context, userId int64) error
+}
+
+// LoginChallenges keeps the hashes of the tokens of sign-ins waiting for
+// the second factor.
+type LoginChallenges interface {
+	// Store writes a new challenge and sets its Id. Token hashes are
+	// unique.
+	Store(ctx context.Context, challenge *entity.LoginChallenge) error
+	GetByHash(ctx context.Context, tokenHash string) (entity.LoginChallenge, error)
+	// AddAttempt counts one more code tried on the challenge unless it had
+	// max attempts already, ok is false then or when there is no such
+	// challenge.
+	AddAttempt(ctx context.Context, id int64, max int) (ok bool, err error)
+	Delete(ctx context.Context, id int64) error
+	DeleteByUser(ctx context.Context, userId int64) error
+	// DeleteExpired removes the challenges expired before the given time
+	// and returns how many there were.
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
 }
 
 type Comments interface {
-	Store(comment entity.Comment) error
-	Fetch(postId int64) ([]entity.Comment, error)
-	GetById(id int64) (entity.Comment, error)
-	GetPostIds(user entity.User) ([]int64, error)
-	Update(comment entity.Comment) error
-	Delete(comment entity.Comment) error
-	StoreLike(comment entity.Comment) error
-	DeleteLike(comment entity.Comment) error
-	StoreDislike(comment entity.Comment) error
-	DeleteDislike(comment entity.Comment) error
-	FetchReactions(id int64) (entity.Comment, error)
+	// Store writes a new comment and sets its Id.
+	Store(ctx context.Context, comment *entity.Comment) error
+	// Fetch lists every comment of the post, replies included, oldest
+	// first.
+	Fetch(ctx context.Context, postId int64) ([]entity.Comment, error)
+	// FetchByPosts does Fetch for many posts in one query, keyed by post
+	// id.
+	FetchByPosts(ctx context.Context, postIds []int64) (map[int64][]entity.Comment, error)
+	// FetchPage, FetchAfter and Count see top level comments only.
+	FetchPage(ctx context.Context, postId int64, limit, offset int) ([]entity.Comment, error)
+	FetchAfter(ctx context.Context, postId, cursor int64, limit int) ([]entity.Comment, error)
+	Count(ctx context.Context, postId int64) (int64, error)
+	// FetchReplies lists the replies under the comments at any depth,
+	// oldest first.
+	FetchReplies(ctx context.Context, parentIds []int64) ([]entity.Comment, error)
+	GetById(ctx context.Context, id int64) (entity.Comment, error)
+	GetPostIds(ctx context.Context, user entity.User) ([]int64, error)
+	// Update overwrites the content and sets EditedAt.
+	Update(ctx context.Context, comment entity.Comment) error
+	// Delete moves a comment to the trash, it stays in the comments of its
+	// post with the deletion mark.
+	Delete(ctx context.Context, comment entity.Comment) error
+	Restore(ctx context.Context, id int64) error
+	FetchDeleted(ctx context.Context) ([]entity.Comment, error)
+	// Purge removes comments deleted before the given time, those with
+	// replies stay until the replies are gone.
+	Purge(ctx context.Context, before time.Time) (int64, error)
+	// PurgeByUser removes the comments of the user for good, their replies
+	// become top level comments. It clears the user from the deletion marks
+	// of other comments.
+	PurgeByUser(ctx context.Context, userId int64) error
+}
+
+// Reactions stores reactions of every kind on posts and comments, target
+// is one of entity.ReactionTargetPost and entity.ReactionTargetComment.
+type Reactions interface {
+	Store(ctx context.Context, reaction entity.Reaction) error
+	Delete(ctx context.Context, reaction entity.Reaction) error
+	// Has reports whether the user of the reaction has put it on the target.
+	Has(ctx context.Context, reaction entity.Reaction) (bool, error)
+	Fetch(ctx context.Context, target string, targetId int64, kind string) ([]entity.Reaction, error)
+	// FetchAll lists every reaction on targets of the kind, ordered by
+	// target id, date and user.
+	FetchAll(ctx context.Context, target string) ([]entity.Reaction, error)
+	// Count returns counts by kind for every target id that has reactions.
+	Count(ctx context.Context, target string, targetIds []int64) (map[int64]map[string]int64, error)
+	CountByUser(ctx context.Context, target string, userId int64) (map[string]int64, error)
+	FetchTargetIds(ctx context.Context, target string, userId int64, kind string) ([]int64, error)
+	// DeleteByUser takes back every reaction of the user.
+	DeleteByUser(ctx context.Context, userId int64) error
+}
+
+// Revisions keeps every version of edited posts and comments, target is
+// one of entity.RevisionTargetPost and entity.RevisionTargetComment.
+type Revisions interface {
+	Store(ctx context.Context, revision entity.Revision) error
+	// Fetch lists revisions of the target oldest first, numbered from 1.
+	Fetch(ctx context.Context, target string, targetId int64) ([]entity.Revision, error)
+	// DeleteByUser removes the revisions the user made.
+	DeleteByUser(ctx context.Context, userId int64) error
+}
+
+// Counters keeps the counts of comments, posts and reactions shown in
+// listings in step with the rows they count.
+type Counters interface {
+	// Recount rebuilds every counter from scratch, repairing any drift.
+	Recount(ctx context.Context) error
+}
+
+// Backups copies the live database into snapshot files and back. Only the
+// sqlite backend has them, Repositories.Backups is nil for the others.
+type Backups interface {
+	// Backup writes a consistent snapshot to dest while the database
+	// stays in use.
+	Backup(ctx context.Context, dest string) error
+	// Restore replaces the database with the snapshot at src once its
+	// schema version is checked.
+	Restore(ctx context.Context, src string) error
+}
+
+// UnitOfWork runs several repository calls atomically. Do hands fn
+// repositories bound to one transaction, which is committed when fn
+// returns nil and rolled back otherwise. Their own UnitOfWork joins the
+// running transaction.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(repos *Repositories) error) error
+}
+
+type unitOfWork func(ctx context.Context, fn func(repos *Repositories) error) error
+
+func (u unitOfWork) Do(ctx context.Context, fn func(repos *Repositories) error) error {
+	return u(ctx, fn)
 }
 
 type Repositories struct {
-	Posts    Posts
-	Users    Users
-	Comments Comments
+	Posts      Posts
+	Categories Categories
+	Users      Users
+	Roles      Roles
+	Sessions   Sessions
+	Resets     PasswordResets
+	TwoFactor  TwoFactor
+	Challenges LoginChallenges
+	Comments   Comments
+	Reactions  Reactions
+	Revisions  Revisions
+	Counters   Counters
+	Backups    Backups
+	UnitOfWork UnitOfWork
 }
 
 func NewRepositories(sq *sqlite3.Sqlite) *Repositories {
+	repos := newSqliteRepositories(sq)
+	repos.UnitOfWork = unitOfWork(func(ctx context.Context, fn func(repos *Repositories) error) error {
+		return sqlconn.RunInTx(ctx, sq.DB, func(conn sqlconn.Conn) error {
+			return fn(joined(newSqliteRepositories(&sqlite3.Sqlite{DB: sq.DB, Conn: conn})))
+		})
+	})
+	repos.Backups = sqlite.NewBackupRepo(sq)
+	return repos
+}
+
+func newSqliteRepositories(sq *sqlite3.Sqlite) *Repositories {
 	return &Repositories{
-		Posts:    sqlite.NewPostsRepo(sq),
-		Users:    sqlite.NewUsersRepo(sq),
-		Comments: sqlite.NewCommentsRepo(sq),
+		Posts:      sqlite.NewPostsRepo(sq),
+		Categories: sqlite.NewCategoriesRepo(sq),
+		Users:      sqlite.NewUsersRepo(sq),
+		Roles:      sqlite.NewRolesRepo(sq),
+		Sessions:   sqlite.NewSessionsRepo(sq),
+		Resets:     sqlite.NewPasswordResetsRepo(sq),
+		TwoFactor:  sqlite.NewTwoFactorRepo(sq),
+		Challenges: sqlite.NewLoginChallengesRepo(sq),
+		Comments:   sqlite.NewCommentsRepo(sq),
+		Reactions:  sqlite.NewReactionsRepo(sq),
+		Revisions:  sqlite.NewRevisionsRepo(sq),
+		Counters:   sqlite.NewCountersRepo(sq),
 	}
 }
+
+func NewPostgresRepositories(pg *postgres.Postgres) *Repositories {
+	repos := newPostgresRepositories(pg)
+	repos.UnitOfWork = unitOfWork(func(ctx context.Context, fn func(repos *Repositories) error) error {
+		return sqlconn.RunInTx(ctx, pg.DB, func(conn sqlconn.Conn) error {
+			return fn(joined(newPostgresRepositories(&postgres.Postgres{DB: pg.DB, Conn: conn})))
+		})
+	})
+	return repos
+}
+
+func newPostgresRepositories(pg *postgres.Postgres) *Repositories {
+	return &Repositories{
+		Posts:      pgrepo.NewPostsRepo(pg),
+		Categories: pgrepo.NewCategoriesRepo(pg),
+		Users:      pgrepo.NewUsersRepo(pg),
+		Roles:      pgrepo.NewRolesRepo(pg),
+		Sessions:   pgrepo.NewSessionsRepo(pg),
+		Resets:     pgrepo.NewPasswordResetsRepo(pg),
+		TwoFactor:  pgrepo.NewTwoFactorRepo(pg),
+		Challenges: pgrepo.NewLoginChallengesRepo(pg),
+		Comments:   pgrepo.NewCommentsRepo(pg),
+		Reactions:  pgrepo.NewReactionsRepo(pg),
+		Revisions:  pgrepo.NewRevisionsRepo(pg),
+		Counters:   pgrepo.NewCountersRepo(pg),
+	}
+}
+
+func NewMemoryRepositories(db *memory.DB) *Repositories {
+	repos := newMemoryRepositories(db)
+	repos.UnitOfWork = unitOfWork(func(ctx context.Context, fn func(repos *Repositories) error) error {
+		return db.RunInTx(ctx, func(tx *memory.DB) error {
+			return fn(joined(newMemoryRepositories(tx)))
+		})
+	})
+	return repos
+}
+
+func newMemoryRepositories(db *memory.DB) *Repositories {
+	return &Repositories{
+		Posts:      memory.NewPostsRepo(db),
+		Categories: memory.NewCategoriesRepo(db),
+		Users:      memory.NewUsersRepo(db),
+		Roles:      memory.NewRolesRepo(db),
+		Sessions:   memory.NewSessionsRepo(db),
+		Resets:     memory.NewPasswordResetsRepo(db),
+		TwoFactor:  memory.NewTwoFactorRepo(db),
+		Challenges: memory.NewLoginChallengesRepo(db),
+		Comments:   memory.NewCommentsRepo(db),
+		Reactions:  memory.NewReactionsRepo(db),
+		Revisions:  memory.NewRevisionsRepo(db),
+		Counters:   memory.NewCountersRepo(db),
+	}
+}
+
+// joined makes Do of repos run fn right away on repos, inside the
+// transaction they are bound to.
+func joined(repos *Repositories) *Repositories {
+	repos.UnitOfWork = unitOfWork(func(ctx context.Context, fn func(repos *Repositories) error) error {
+		return fn(repos)
+	})
+	return repos
+}
@@ -0,0 +1,362 @@
+package repotest
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"forum/internal/entity"
+	"forum/internal/repository"
+)
+
+func RunCategoriesTests(t *testing.T, open Opener) {
+	t.Run("CategoryStore", func(t *testing.T) { testCategoryStore(t, open) })
+	t.Run("CategoryFetch", func(t *testing.T) { testCategoryFetch(t, open) })
+	t.Run("CategoryGetById", func(t *testing.T) { testCategoryGetById(t, open) })
+	t.Run("CategoryGetBySlug", func(t *testing.T) { testCategoryGetBySlug(t, open) })
+	t.Run("CategoryUpdate", func(t *testing.T) { testCategoryUpdate(t, open) })
+	t.Run("CategoryMoveReferences", func(t *testing.T) { testCategoryMoveReferences(t, open) })
+	t.Run("CategoryDelete", func(t *testing.T) { testCategoryDelete(t, open) })
+}
+
+// storeCategories stores categories of the names, their slugs are the
+// names in lower case.
+func storeCategories(t *testing.T, repo repository.Categories, names ...string) []entity.Category {
+	t.Helper()
+	categories := make([]entity.Category, len(names))
+	for i, name := range names {
+		categories[i] = entity.Category{Name: name, Slug: strings.ToLower(name)}
+		if err := repo.Store(context.Background(), &categories[i]); err != nil {
+			t.Fatal("Unable to store category:", err)
+		}
+	}
+	return categories
+}
+
+// storeCategorizedPosts stores a post for every list of categories.
+func storeCategorizedPosts(t *testing.T, repos *repository.Repositories, categories ...[]entity.Category) {
+	t.Helper()
+	ctx := context.Background()
+	for _, categories := range categories {
+		post := entity.Post{User: entity.User{Id: 1}, Date: at("2022-09-01"), Title: "Post", Content: "Lorem ipsum.",
+			Categories: categories}
+		if err := repos.Posts.Store(ctx, &post); err != nil {
+			t.Fatal("Unable to store post:", err)
+		}
+		if err := repos.Posts.StoreTopicReference(ctx, post); err != nil {
+			t.Fatal("Unable to StoreTopicReference:", err)
+		}
+	}
+}
+
+func testCategoryStore(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		repo := repos.Categories
+
+		cars := entity.Category{Name: "Cars", Slug: "cars", Description: "Everything on wheels.", Color: "#ff0000"}
+		if err := repo.Store(ctx, &cars); err != nil {
+			t.Fatal("Unable to store:", err)
+		} else if cars.Id != 1 {
+			t.Fatalf("want id = %d, got id = %d:", 1, cars.Id)
+		}
+
+		audi := entity.Category{ParentId: cars.Id, Name: "Audi", Slug: "audi", Position: 2}
+		if err := repo.Store(ctx, &audi); err != nil {
+			t.Fatal("Unable to store:", err)
+		} else if audi.Id != 2 {
+			t.Fatalf("want id = %d, got id = %d:", 2, audi.Id)
+		}
+	})
+
+	t.Run("err unique", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		repo := repos.Categories
+		storeCategories(t, repo, "Cars")
+
+		expErr := "UNIQUE constraint failed"
+		for _, category := range []entity.Category{
+			{Name: "Cars", Slug: "other"},
+			{Name: "Other", Slug: "cars"},
+		} {
+			if err := repo.Store(ctx, &category); err == nil {
+				t.Fatal("Expected error:")
+			} else if !strings.Contains(err.Error(), expErr) {
+				t.Fatalf("want err = %v, got err = %v:", expErr, err)
+			}
+		}
+	})
+}
+
+func testCategoryFetch(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeUsers(t, repos.Users, "Riddle")
+		repo := repos.Categories
+
+		categories := storeCategories(t, repo, "Sports", "Cars", "Travel")
+		work := entity.Category{Name: "Work", Slug: "work", Position: -1}
+		if err := repo.Store(ctx, &work); err != nil {
+			t.Fatal("Unable to store:", err)
+		}
+
+		for i, date := range []string{"2022-09-01", "2022-09-03", "2022-09-02"} {
+			post := entity.Post{User: entity.User{Id: 1}, Date: at(date), Title: "Post", Content: "Lorem ipsum.",
+				Categories: []entity.Category{categories[1]}}
+			if i == 2 {
+				post.Categories = append(post.Categories, categories[0])
+			}
+			if err := repos.Posts.Store(ctx, &post); err != nil {
+				t.Fatal("Unable to store post:", err)
+			}
+			if err := repos.Posts.StoreTopicReference(ctx, post); err != nil {
+				t.Fatal("Unable to StoreTopicReference:", err)
+			}
+		}
+		// the latest post is in the trash, the comment is later still
+		if err := repos.Posts.Delete(ctx, entity.Post{Id: 2, DeletedAt: at("2022-09-04"), DeletedBy: 1}); err != nil {
+			t.Fatal("Unable to Delete:", err)
+		}
+		comment := entity.Comment{PostId: 3, User: entity.User{Id: 1}, Date: at("2022-09-05"), Content: "Nice."}
+		if err := repos.Comments.Store(ctx, &comment); err != nil {
+			t.Fatal("Unable to store comment:", err)
+		}
+
+		found, err := repo.Fetch(ctx)
+		if err != nil {
+			t.Fatal("Unable to Fetch:", err)
+		}
+		var names []string
+		for _, category := range found {
+			names = append(names, category.Name)
+		}
+		if want := []string{"Work", "Cars", "Sports", "Travel"}; !reflect.DeepEqual(names, want) {
+			t.Fatalf("want = %v, got = %v:", want, names)
+		}
+		if cars := found[1]; cars.Posts != 2 || cars.Comments != 1 || !cars.LastActivity.Equal(at("2022-09-05")) {
+			t.Fatalf("want 2 posts, 1 comment and activity of the comment, got = %+v:", cars)
+		}
+		if sports := found[2]; sports.Posts != 1 || sports.Comments != 1 {
+			t.Fatalf("want 1 post and 1 comment, got = %+v:", sports)
+		}
+		if work := found[0]; work.Posts != 0 || !work.LastActivity.IsZero() {
+			t.Fatalf("want no activity, got = %+v:", work)
+		}
+	})
+}
+
+func testCategoryGetById(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		repo := repos.Categories
+
+		cars := storeCategories(t, repo, "Cars")[0]
+		audi := entity.Category{ParentId: cars.Id, Name: "Audi", Slug: "audi", Description: "Vorsprung.",
+			Position: 3, Color: "#000000"}
+		if err := repo.Store(ctx, &audi); err != nil {
+			t.Fatal("Unable to store:", err)
+		}
+
+		if found, err := repo.GetById(ctx, audi.Id); err != nil {
+			t.Fatal("Unable to GetById:", err)
+		} else if !reflect.DeepEqual(found, audi) {
+			t.Fatalf("want = %+v, got = %+v:", audi, found)
+		}
+		if found, err := repo.GetById(ctx, cars.Id); err != nil {
+			t.Fatal("Unable to GetById:", err)
+		} else if found.ParentId != 0 {
+			t.Fatalf("want top level, got = %+v:", found)
+		}
+
+		expErr := "no rows in result set"
+		if _, err := repo.GetById(ctx, 10); err == nil {
+			t.Fatal("Expected error:")
+		} else if !strings.Contains(err.Error(), expErr) {
+			t.Fatalf("want err = %v, got err = %v:", expErr, err)
+		}
+	})
+}
+
+func testCategoryGetBySlug(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		repo := repos.Categories
+
+		storeCategories(t, repo, "Cars", "Sports")
+
+		if found, err := repo.GetBySlug(ctx, "sports"); err != nil {
+			t.Fatal("Unable to GetBySlug:", err)
+		} else if found.Id != 2 || found.Name != "Sports" {
+			t.Fatalf("want Sports, got = %+v:", found)
+		}
+
+		expErr := "no rows in result set"
+		if _, err := repo.GetBySlug(ctx, "travel"); err == nil {
+			t.Fatal("Expected error:")
+		} else if !strings.Contains(err.Error(), expErr) {
+			t.Fatalf("want err = %v, got err = %v:", expErr, err)
+		}
+	})
+}
+
+func testCategoryUpdate(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		repo := repos.Categories
+
+		categories := storeCategories(t, repo, "Cars", "Audi")
+		audi := entity.Category{Id: categories[1].Id, ParentId: categories[0].Id, Name: "Audi AG", Slug: "audi-ag",
+			Description: "Vorsprung.", Position: 4, Color: "#ffffff"}
+		if err := repo.Update(ctx, audi); err != nil {
+			t.Fatal("Unable to Update:", err)
+		}
+
+		if found, err := repo.GetById(ctx, audi.Id); err != nil {
+			t.Fatal("Unable to GetById:", err)
+		} else if !reflect.DeepEqual(found, audi) {
+			t.Fatalf("want = %+v, got = %+v:", audi, found)
+		}
+	})
+
+	t.Run("err unique", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		repo := repos.Categories
+		categories := storeCategories(t, repo, "Cars", "Audi")
+
+		expErr := "UNIQUE constraint failed"
+		audi := categories[1]
+		audi.Name = "Cars"
+		if err := repo.Update(ctx, audi); err == nil {
+			t.Fatal("Expected error:")
+		} else if !strings.Contains(err.Error(), expErr) {
+			t.Fatalf("want err = %v, got err = %v:", expErr, err)
+		}
+	})
+
+	t.Run("err not found", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+
+		if err := repos.Categories.Update(ctx, entity.Category{Id: 10, Name: "Cars", Slug: "cars"}); err == nil {
+			t.Fatal("Expected error:")
+		}
+	})
+}
+
+func testCategoryMoveReferences(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeUsers(t, repos.Users, "Riddle")
+		repo := repos.Categories
+
+		categories := storeCategories(t, repo, "Cars", "Autos", "Travel")
+		cars, autos, travel := categories[0], categories[1], categories[2]
+		storeCategorizedPosts(t, repos, []entity.Category{cars}, []entity.Category{cars, autos},
+			[]entity.Category{autos, travel})
+
+		if err := repo.MoveReferences(ctx, autos.Id, cars.Id); err != nil {
+			t.Fatal("Unable to MoveReferences:", err)
+		}
+		for _, tc := range []struct {
+			category entity.Category
+			want     []int64
+		}{
+			{cars, []int64{1, 2, 3}},
+			{autos, nil},
+			{travel, []int64{3}},
+		} {
+			ids, err := repos.Posts.GetIdsByCategory(ctx, tc.category.Id)
+			if err != nil {
+				t.Fatal("Unable to GetIdsByCategory:", err)
+			}
+			if len(ids) != len(tc.want) || len(ids) != 0 && !reflect.DeepEqual(ids, tc.want) {
+				t.Fatalf("%s: want = %v, got = %v:", tc.category.Name, tc.want, ids)
+			}
+		}
+	})
+
+	t.Run("OK dropped", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeUsers(t, repos.Users, "Riddle")
+		repo := repos.Categories
+
+		categories := storeCategories(t, repo, "Cars", "Travel")
+		storeCategorizedPosts(t, repos, categories)
+
+		if err := repo.MoveReferences(ctx, categories[0].Id, 0); err != nil {
+			t.Fatal("Unable to MoveReferences:", err)
+		}
+		post, err := repos.Posts.GetById(ctx, 1)
+		if err != nil {
+			t.Fatal("Unable to GetById:", err)
+		}
+		found, err := repos.Posts.GetRelatedCategories(ctx, post)
+		if err != nil {
+			t.Fatal("Unable to GetRelatedCategories:", err)
+		}
+		if len(found) != 1 || found[0].Id != categories[1].Id {
+			t.Fatalf("want Travel only, got = %+v:", found)
+		}
+	})
+}
+
+func testCategoryDelete(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeUsers(t, repos.Users, "Riddle")
+		repo := repos.Categories
+
+		categories := storeCategories(t, repo, "Cars", "Travel")
+		storeCategorizedPosts(t, repos, categories)
+
+		if err := repo.Delete(ctx, categories[0].Id); err != nil {
+			t.Fatal("Unable to Delete:", err)
+		}
+		found, err := repo.Fetch(ctx)
+		if err != nil {
+			t.Fatal("Unable to Fetch:", err)
+		}
+		if len(found) != 1 || found[0].Name != "Travel" || found[0].Posts != 1 {
+			t.Fatalf("want Travel with its post, got = %+v:", found)
+		}
+		if ids, err := repos.Posts.GetIdsByCategory(ctx, categories[0].Id); err != nil {
+			t.Fatal("Unable to GetIdsByCategory:", err)
+		} else if len(ids) != 0 {
+			t.Fatalf("want no references, got = %v:", ids)
+		}
+	})
+
+	t.Run("err not found", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+
+		if err := repos.Categories.Delete(ctx, 10); err == nil {
+			t.Fatal("Expected error:")
+		}
+	})
+}
@@ -0,0 +1,488 @@
+package repotest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+
+	"forum/internal/entity"
+)
+
+func RunCommentsTests(t *testing.T, open Opener) {
+	t.Run("CommentStore", func(t *testing.T) { testCommentStore(t, open) })
+	t.Run("CommentFetch", func(t *testing.T) { testCommentFetch(t, open) })
+	t.Run("CommentFetchByPosts", func(t *testing.T) { testCommentFetchByPosts(t, open) })
+	t.Run("CommentFetchPage", func(t *testing.T) { testCommentFetchPage(t, open) })
+	t.Run("CommentReplies", func(t *testing.T) { testCommentReplies(t, open) })
+	t.Run("CommentGetyId", func(t *testing.T) { testCommentGetyId(t, open) })
+	t.Run("CommentUpdate", func(t *testing.T) { testCommentUpdate(t, open) })
+	t.Run("GetPostIds", func(t *testing.T) { testGetPostIds(t, open) })
+	t.Run("CommentDelete", func(t *testing.T) { testCommentDelete(t, open) })
+	t.Run("CommentRestore", func(t *testing.T) { testCommentRestore(t, open) })
+	t.Run("CommentPurge", func(t *testing.T) { testCommentPurge(t, open) })
+}
+
+func testCommentStore(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storePosts(t, repos, 7)
+		repo := repos.Comments
+
+		comment := entity.Comment{
+			PostId:  1,
+			User:    entity.User{Id: 1},
+			Date:    at("2022-09-01"),
+			Content: "Lorem ipsum dolor sit amet.",
+		}
+
+		if err := repo.Store(ctx, &comment); err != nil {
+			t.Fatal("Unable to store:", err)
+		}
+
+		comment2 := entity.Comment{
+			PostId:  1,
+			User:    entity.User{Id: 2},
+			Date:    at("2022-09-01"),
+			Content: "Lorem ipsum dolor sit amet.",
+		}
+
+		if err := repo.Store(ctx, &comment2); err != nil {
+			t.Fatal("Unable to store:", err)
+		}
+		if comment.Id != 1 || comment2.Id != 2 {
+			t.Fatalf("want ids = 1, 2, got ids = %d, %d", comment.Id, comment2.Id)
+		}
+	})
+}
+
+func testCommentFetch(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storePosts(t, repos, 7)
+		repo := repos.Comments
+
+		comment := entity.Comment{
+			PostId:  1,
+			User:    entity.User{Id: 1},
+			Date:    at("2022-09-01"),
+			Content: "Lorem ipsum dolor sit amet.",
+		}
+
+		if err := repo.Store(ctx, &comment); err != nil {
+			t.Fatal("Unable to store:", err)
+		}
+
+		comment2 := entity.Comment{
+			PostId:  1,
+			User:    entity.User{Id: 2},
+			Date:    at("2022-09-01"),
+			Content: "Lorem ipsum dolor sit amet.",
+		}
+
+		if err := repo.Store(ctx, &comment2); err != nil {
+			t.Fatal("Unable to store:", err)
+		}
+
+		if found, err := repo.Fetch(ctx, 1); err != nil {
+			t.Fatal("Unable to Fetch:", err)
+		} else if len(found) != 2 {
+			t.Fatalf("want len = %d, got len = %d:", 2, len(found))
+		}
+	})
+}
+
+func testCommentFetchByPosts(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storePosts(t, repos, 7)
+		repo := repos.Comments
+
+		for _, postId := range []int64{1, 2, 1, 3} {
+			comment := entity.Comment{
+				PostId:  postId,
+				User:    entity.User{Id: 1},
+				Date:    at("2022-09-01"),
+				Content: "Lorem ipsum dolor sit amet.",
+			}
+			if err := repo.Store(ctx, &comment); err != nil {
+				t.Fatal("Unable to store:", err)
+			}
+		}
+
+		found, err := repo.FetchByPosts(ctx, []int64{1, 2, 4})
+		if err != nil {
+			t.Fatal("Unable to FetchByPosts:", err)
+		}
+		got := map[int64][]int64{}
+		for postId, comments := range found {
+			got[postId] = commentIds(comments)
+		}
+		if want := map[int64][]int64{1: {1, 3}, 2: {2}}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("want ids = %v, got ids = %v:", want, got)
+		}
+	})
+}
+
+func testCommentFetchPage(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	repos, closeDB := open(t)
+	defer closeDB()
+	storePosts(t, repos, 7)
+	repo := repos.Comments
+
+	// Comments 1, 3 and 5 belong to post 1, the others to post 2.
+	for i := 0; i < 5; i++ {
+		comment := entity.Comment{
+			PostId:  int64(i%2 + 1),
+			User:    entity.User{Id: 1},
+			Date:    at("2022-09-01"),
+			Content: "Lorem ipsum dolor sit amet.",
+		}
+		if err := repo.Store(ctx, &comment); err != nil {
+			t.Fatal("Unable to store:", err)
+		}
+	}
+
+	t.Run("Count", func(t *testing.T) {
+		if count, err := repo.Count(ctx, 1); err != nil {
+			t.Fatal("Unable to Count:", err)
+		} else if count != 3 {
+			t.Fatalf("want count = %d, got count = %d", 3, count)
+		}
+	})
+
+	t.Run("Page", func(t *testing.T) {
+		if comments, err := repo.FetchPage(ctx, 1, 2, 0); err != nil {
+			t.Fatal("Unable to FetchPage:", err)
+		} else if got := commentIds(comments); !reflect.DeepEqual(got, []int64{1, 3}) {
+			t.Fatalf("want %v, got %v", []int64{1, 3}, got)
+		}
+
+		if comments, err := repo.FetchPage(ctx, 1, 2, 2); err != nil {
+			t.Fatal("Unable to FetchPage:", err)
+		} else if got := commentIds(comments); !reflect.DeepEqual(got, []int64{5}) {
+			t.Fatalf("want %v, got %v", []int64{5}, got)
+		}
+	})
+
+	t.Run("After", func(t *testing.T) {
+		if comments, err := repo.FetchAfter(ctx, 1, 1, 2); err != nil {
+			t.Fatal("Unable to FetchAfter:", err)
+		} else if got := commentIds(comments); !reflect.DeepEqual(got, []int64{3, 5}) {
+			t.Fatalf("want %v, got %v", []int64{3, 5}, got)
+		}
+
+		if comments, err := repo.FetchAfter(ctx, 2, 2, 2); err != nil {
+			t.Fatal("Unable to FetchAfter:", err)
+		} else if got := commentIds(comments); !reflect.DeepEqual(got, []int64{4}) {
+			t.Fatalf("want %v, got %v", []int64{4}, got)
+		}
+	})
+}
+
+func testCommentReplies(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	repos, closeDB := open(t)
+	defer closeDB()
+	storePosts(t, repos, 7)
+	repo := repos.Comments
+
+	// Comment 2 replies to 1, 4 replies to 2, 3 is top level.
+	for _, parentId := range []int64{0, 1, 0, 2} {
+		comment := entity.Comment{
+			PostId:   1,
+			ParentId: parentId,
+			User:     entity.User{Id: 1},
+			Date:     at("2022-09-01"),
+			Content:  "Lorem ipsum dolor sit amet.",
+		}
+		if err := repo.Store(ctx, &comment); err != nil {
+			t.Fatal("Unable to store:", err)
+		}
+	}
+
+	t.Run("Fetch", func(t *testing.T) {
+		found, err := repo.Fetch(ctx, 1)
+		if err != nil {
+			t.Fatal("Unable to Fetch:", err)
+		}
+		var parents []int64
+		for _, comment := range found {
+			parents = append(parents, comment.ParentId)
+		}
+		if want := []int64{0, 1, 0, 2}; !reflect.DeepEqual(parents, want) {
+			t.Fatalf("want parents %v, got %v", want, parents)
+		}
+	})
+
+	t.Run("GetById", func(t *testing.T) {
+		if found, err := repo.GetById(ctx, 4); err != nil {
+			t.Fatal("Unable to GetById:", err)
+		} else if found.ParentId != 2 {
+			t.Fatalf("want parent = %d, got parent = %d", 2, found.ParentId)
+		}
+	})
+
+	t.Run("FetchReplies", func(t *testing.T) {
+		for _, tt := range []struct {
+			parents []int64
+			want    []int64
+		}{
+			{[]int64{1}, []int64{2, 4}},
+			{[]int64{2}, []int64{4}},
+			{[]int64{1, 3}, []int64{2, 4}},
+			{[]int64{3}, nil},
+			{nil, nil},
+		} {
+			if comments, err := repo.FetchReplies(ctx, tt.parents); err != nil {
+				t.Fatal("Unable to FetchReplies:", err)
+			} else if got := commentIds(comments); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("parents %v: want %v, got %v", tt.parents, tt.want, got)
+			}
+		}
+	})
+
+	t.Run("Top level", func(t *testing.T) {
+		if count, err := repo.Count(ctx, 1); err != nil {
+			t.Fatal("Unable to Count:", err)
+		} else if count != 2 {
+			t.Fatalf("want count = %d, got count = %d", 2, count)
+		}
+
+		if comments, err := repo.FetchPage(ctx, 1, 10, 0); err != nil {
+			t.Fatal("Unable to FetchPage:", err)
+		} else if got := commentIds(comments); !reflect.DeepEqual(got, []int64{1, 3}) {
+			t.Fatalf("want %v, got %v", []int64{1, 3}, got)
+		}
+
+		if comments, err := repo.FetchAfter(ctx, 1, 1, 10); err != nil {
+			t.Fatal("Unable to FetchAfter:", err)
+		} else if got := commentIds(comments); !reflect.DeepEqual(got, []int64{3}) {
+			t.Fatalf("want %v, got %v", []int64{3}, got)
+		}
+	})
+}
+
+func commentIds(comments []entity.Comment) []int64 {
+	var ids []int64
+	for _, comment := range comments {
+		ids = append(ids, comment.Id)
+	}
+	return ids
+}
+
+func testCommentGetyId(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storePosts(t, repos, 7)
+		repo := repos.Comments
+
+		comment := entity.Comment{
+			PostId:  7,
+			User:    entity.User{Id: 1},
+			Date:    at("2022-09-01"),
+			Content: "Lorem ipsum dolor sit amet.",
+		}
+
+		if err := repo.Store(ctx, &comment); err != nil {
+			t.Fatal("Unable to store:", err)
+		}
+
+		comment2 := entity.Comment{
+			PostId:  3,
+			User:    entity.User{Id: 2},
+			Date:    at("2022-09-01"),
+			Content: "Lorem ipsum dolor sit amet.",
+		}
+
+		if err := repo.Store(ctx, &comment2); err != nil {
+			t.Fatal("Unable to Store:", err)
+		}
+
+		if found, err := repo.GetById(ctx, 2); err != nil {
+			t.Fatal("Unable to GetById:", err)
+		} else if found.Id != 2 {
+			t.Fatalf("want len = %d, got len = %d:", 2, found.Id)
+		}
+	})
+}
+
+func testCommentUpdate(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storePosts(t, repos, 7)
+		repo := repos.Comments
+		var err error
+
+		comment := entity.Comment{
+			PostId:  7,
+			User:    entity.User{Id: 1},
+			Date:    at("2022-09-01"),
+			Content: "Lorem ipsum dolor sit amet.",
+		}
+
+		if err := repo.Store(ctx, &comment); err != nil {
+			t.Fatal("Unable to store:", err)
+		}
+
+		newContent := "New Content"
+		comment.Id = 1
+		comment.Content = newContent
+		comment.EditedAt = at("2022-10-02 10:00:00")
+
+		if err = repo.Update(ctx, comment); err != nil {
+			t.Fatal("Unable to Update:", err)
+		}
+
+		if found, err := repo.GetById(ctx, 1); err != nil {
+			t.Fatal("Unable to GetById:", err)
+		} else if found.Content != newContent || !found.IsEdited() {
+			t.Fatalf("want = %v, got = %v:", newContent, found)
+		}
+	})
+
+	t.Run("Images", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storePosts(t, repos, 7)
+		repo := repos.Comments
+
+		comment := entity.Comment{PostId: 7, User: entity.User{Id: 1}, Date: at("2022-09-01"), Content: "Lorem.",
+			Images: []string{"/img/b.png", "/img/a.png"}}
+		if err := repo.Store(ctx, &comment); err != nil {
+			t.Fatal("Unable to store:", err)
+		}
+		reply := entity.Comment{PostId: 7, ParentId: comment.Id, User: entity.User{Id: 2}, Date: at("2022-09-02"),
+			Content: "Ipsum."}
+		if err := repo.Store(ctx, &reply); err != nil {
+			t.Fatal("Unable to store:", err)
+		}
+		if found, err := repo.Fetch(ctx, 7); err != nil {
+			t.Fatal("Unable to Fetch:", err)
+		} else if !reflect.DeepEqual(found[0].Images, comment.Images) || found[1].Images != nil {
+			t.Fatalf("want images of the first comment only, got = %+v:", found)
+		}
+
+		comment.Images = []string{"/img/a.png"}
+		if err := repo.Update(ctx, comment); err != nil {
+			t.Fatal("Unable to Update:", err)
+		}
+		if found, err := repo.GetById(ctx, comment.Id); err != nil {
+			t.Fatal("Unable to GetById:", err)
+		} else if !reflect.DeepEqual(found.Images, comment.Images) {
+			t.Fatalf("want = %v, got = %v:", comment.Images, found.Images)
+		}
+	})
+}
+
+func testGetPostIds(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storePosts(t, repos, 7)
+		repo := repos.Comments
+
+		comment := entity.Comment{
+			PostId:  7,
+			User:    entity.User{Id: 1},
+			Date:    at("2022-09-01"),
+			Content: "Lorem ipsum dolor sit amet.",
+		}
+
+		if err := repo.Store(ctx, &comment); err != nil {
+			t.Fatal("Unable to store:", err)
+		}
+
+		user := entity.User{Id: 1}
+		comment2 := entity.Comment{
+			PostId:  2,
+			User:    user,
+			Date:    at("2022-09-01"),
+			Content: "Lorem ipsum dolor sit amet.",
+		}
+
+		if err := repo.Store(ctx, &comment2); err != nil {
+			t.Fatal("Unable to store:", err)
+		}
+
+		if found, err := repo.GetPostIds(ctx, user); err != nil {
+			t.Fatal("Unable to GetPostIds:", err)
+		} else if len(found) != 2 {
+			t.Fatalf("want = %d, got = %d:", 2, len(found))
+		}
+	})
+}
+
+func testCommentDelete(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storePosts(t, repos, 7)
+		repo := repos.Comments
+
+		comment := entity.Comment{
+			PostId:  7,
+			User:    entity.User{Id: 1},
+			Date:    at("2022-09-01"),
+			Content: "Lorem ipsum dolor sit amet.",
+		}
+
+		if err := repo.Store(ctx, &comment); err != nil {
+			t.Fatal("Unable to store:", err)
+		}
+
+		if _, err := repo.GetById(ctx, 1); err != nil {
+			t.Fatal("Unable to GetById:", err)
+		}
+		comment.Id = 1
+		comment.DeletedAt = at("2022-09-02 10:00:00")
+		comment.DeletedBy = 2
+		comment.DeleteReason = "spam"
+
+		if err := repo.Delete(ctx, comment); err != nil {
+			t.Fatal("Unable to Delete:", err)
+		}
+
+		if found, err := repo.GetById(ctx, 1); err != nil {
+			t.Fatal("Unable to GetById:", err)
+		} else if !found.IsDeleted() || found.DeletedBy != 2 || found.DeleteReason != "spam" {
+			t.Fatalf("want deleted comment, got comment = %v:", found)
+		}
+
+		// Deleted comments keep their place in the post.
+		if found, err := repo.Fetch(ctx, 7); err != nil {
+			t.Fatal("Unable to Fetch:", err)
+		} else if len(found) != 1 || !found[0].IsDeleted() {
+			t.Fatalf("want deleted comment, got comments = %v:", found)
+		}
+
+		if err := repo.Delete(ctx, comment); err == nil {
+			t.Fatal("expected error")
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			t.Fatalf("want err = %v, got err = %v:", sql.ErrNoRows, err)
+		}
+	})
+}
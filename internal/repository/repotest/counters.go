@@ -0,0 +1,116 @@
+package repotest
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"forum/internal/entity"
+	"forum/internal/repository"
+)
+
+func RunCountersTests(t *testing.T, open Opener) {
+	t.Run("CountersFollowWrites", func(t *testing.T) { testCountersFollowWrites(t, open) })
+	t.Run("CountersRecount", func(t *testing.T) { testCountersRecount(t, open) })
+}
+
+// storeCounted stores two users, a post of the first one with two comments
+// on it and their two reactions on the post.
+func storeCounted(t *testing.T, repos *repository.Repositories) {
+	t.Helper()
+	ctx := context.Background()
+	storeUsers(t, repos.Users, "Riddle", "Subi")
+	post := entity.Post{User: entity.User{Id: 1}, Date: at("2022-09-01"), Title: "Cars", Content: "Lorem ipsum."}
+	if err := repos.Posts.Store(ctx, &post); err != nil {
+		t.Fatal("Unable to store post:", err)
+	}
+	for i := 0; i < 2; i++ {
+		comment := entity.Comment{PostId: 1, User: entity.User{Id: 1}, Date: at("2022-09-01"), Content: "Lorem ipsum."}
+		if err := repos.Comments.Store(ctx, &comment); err != nil {
+			t.Fatal("Unable to store comment:", err)
+		}
+	}
+	storeReactions(t, repos.Reactions,
+		entity.Reaction{Target: entity.ReactionTargetPost, TargetId: 1, UserId: 1, Kind: "like", Date: at("2022-09-01")},
+		entity.Reaction{Target: entity.ReactionTargetPost, TargetId: 1, UserId: 2, Kind: "like", Date: at("2022-09-01")},
+	)
+}
+
+// checkCounters compares the counters of post 1 and user 1.
+func checkCounters(t *testing.T, repos *repository.Repositories, comments, userPosts int64, likes int64) {
+	t.Helper()
+	ctx := context.Background()
+	post, err := repos.Posts.GetById(ctx, 1)
+	if err != nil {
+		t.Fatal("Unable to GetById:", err)
+	}
+	if post.TotalComments != comments {
+		t.Fatalf("want post comments = %d, got = %d:", comments, post.TotalComments)
+	}
+
+	user, err := repos.Users.GetById(ctx, 1)
+	if err != nil {
+		t.Fatal("Unable to GetById:", err)
+	}
+	if user.Posts != userPosts || user.Comments != comments {
+		t.Fatalf("want user posts = %d, comments = %d, got posts = %d, comments = %d:",
+			userPosts, comments, user.Posts, user.Comments)
+	}
+
+	want := map[int64]map[string]int64{}
+	if likes != 0 {
+		want[1] = map[string]int64{"like": likes}
+	}
+	if found, err := repos.Reactions.Count(ctx, entity.ReactionTargetPost, []int64{1}); err != nil {
+		t.Fatal("Unable to count:", err)
+	} else if !reflect.DeepEqual(found, want) {
+		t.Fatalf("want counts = %v, got counts = %v:", want, found)
+	}
+}
+
+func testCountersFollowWrites(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+
+		storeCounted(t, repos)
+		checkCounters(t, repos, 2, 1, 2)
+
+		comment := entity.Comment{Id: 1, DeletedAt: at("2022-09-02 10:00:00"), DeletedBy: 1}
+		if err := repos.Comments.Delete(ctx, comment); err != nil {
+			t.Fatal("Unable to Delete:", err)
+		}
+		if err := repos.Reactions.Delete(ctx, entity.Reaction{
+			Target: entity.ReactionTargetPost, TargetId: 1, UserId: 2, Kind: "like",
+		}); err != nil {
+			t.Fatal("Unable to Delete:", err)
+		}
+		checkCounters(t, repos, 1, 1, 1)
+
+		if err := repos.Comments.Restore(ctx, 1); err != nil {
+			t.Fatal("Unable to Restore:", err)
+		}
+		if err := repos.Posts.Delete(ctx, entity.Post{Id: 1, DeletedAt: at("2022-09-02 10:00:00"),
+			DeletedBy: 1}); err != nil {
+			t.Fatal("Unable to Delete:", err)
+		}
+		checkCounters(t, repos, 2, 0, 1)
+	})
+}
+
+func testCountersRecount(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+
+		storeCounted(t, repos)
+		if err := repos.Counters.Recount(ctx); err != nil {
+			t.Fatal("Unable to Recount:", err)
+		}
+		checkCounters(t, repos, 2, 1, 2)
+	})
+}
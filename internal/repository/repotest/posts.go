@@ -0,0 +1,619 @@
+package repotest
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"forum/internal/entity"
+)
+
+func RunPostsTests(t *testing.T, open Opener) {
+	t.Run("PostStore", func(t *testing.T) { testPostStore(t, open) })
+	t.Run("StoreTopicReference", func(t *testing.T) { testStoreTopicReference(t, open) })
+	t.Run("PostFetch", func(t *testing.T) { testPostFetch(t, open) })
+	t.Run("PostFetchPage", func(t *testing.T) { testPostFetchPage(t, open) })
+	t.Run("FetchByAuthor", func(t *testing.T) { testFetchByAuthor(t, open) })
+	t.Run("PostGetById", func(t *testing.T) { testPostGetById(t, open) })
+	t.Run("GetRelatedCategories", func(t *testing.T) { testGetRelatedCategories(t, open) })
+	t.Run("FetchCategories", func(t *testing.T) { testFetchCategories(t, open) })
+	t.Run("PostUpdate", func(t *testing.T) { testPostUpdate(t, open) })
+	t.Run("PostDelete", func(t *testing.T) { testPostDelete(t, open) })
+	t.Run("PostRestore", func(t *testing.T) { testPostRestore(t, open) })
+	t.Run("PostFetchDeleted", func(t *testing.T) { testPostFetchDeleted(t, open) })
+	t.Run("PostPurge", func(t *testing.T) { testPostPurge(t, open) })
+	t.Run("GetIdsByCategory", func(t *testing.T) { testGetIdsByCategory(t, open) })
+}
+
+func testPostStore(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeUsers(t, repos.Users, "Riddle", "Subi")
+		repo := repos.Posts
+
+		post := entity.Post{
+			User:    entity.User{Id: 1},
+			Date:    at("2022-09-01"),
+			Title:   "Cars",
+			Content: "Lorem ipsum dolor sit amet.",
+		}
+
+		if err := repo.Store(ctx, &post); err != nil {
+			t.Fatal("Unable to store:", err)
+		} else if post.Id != 1 {
+			t.Fatalf("want id = %d, got id = %d:", 1, post.Id)
+		}
+
+		post2 := entity.Post{
+			User:    entity.User{Id: 2},
+			Date:    at("2022-09-02"),
+			Title:   "Sports",
+			Content: "Lorem ipsum dolor sit amet.",
+		}
+
+		if err := repo.Store(ctx, &post2); err != nil {
+			t.Fatal("Unable to store:", err)
+		} else if post2.Id != 2 {
+			t.Fatalf("want id = %d, got id = %d:", 2, post2.Id)
+		}
+	})
+}
+
+func testStoreTopicReference(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		repo := repos.Posts
+		storePosts(t, repos, 1)
+
+		categories := storeCategories(t, repos.Categories, "cars", "cinema", "food")
+
+		post := entity.Post{Id: 1, Categories: categories}
+
+		if err := repo.StoreTopicReference(ctx, post); err != nil {
+			t.Fatal("Unable to StoreTopicReference:", err)
+		}
+	})
+}
+
+func testPostFetch(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeUsers(t, repos.Users, "Riddle", "Subi")
+		repo := repos.Posts
+
+		post := entity.Post{
+			User:    entity.User{Id: 1, Name: "Riddle"},
+			Date:    at("2022-09-01"),
+			Title:   "Cars",
+			Content: "Lorem ipsum dolor sit amet.",
+		}
+
+		if err := repo.Store(ctx, &post); err != nil {
+			t.Fatal("Unable to store:", err)
+		} else if post.Id != 1 {
+			t.Fatalf("want id = %d, got id = %d:", 1, post.Id)
+		}
+
+		post2 := entity.Post{
+			User:    entity.User{Id: 2, Name: "Subi"},
+			Date:    at("2022-09-02"),
+			Title:   "Sports",
+			Content: "Lorem ipsum dolor sit amet.",
+		}
+
+		if err := repo.Store(ctx, &post2); err != nil {
+			t.Fatal("Unable to store:", err)
+		} else if post2.Id != 2 {
+			t.Fatalf("want id = %d, got id = %d:", 2, post2.Id)
+		}
+
+		if fetched, err := repo.Fetch(ctx); err != nil {
+			t.Fatal("Unable to Fetch:", err)
+		} else if len(fetched) != 2 {
+			t.Fatalf("want len = %d, got len = %d:", 2, len(fetched))
+		}
+	})
+}
+
+func testPostFetchPage(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	repos, closeDB := open(t)
+	defer closeDB()
+	storeUsers(t, repos.Users, "Riddle", "Subi")
+	repo := repos.Posts
+
+	for i := 0; i < 5; i++ {
+		post := entity.Post{
+			User:    entity.User{Id: 1},
+			Date:    at("2022-09-01"),
+			Title:   fmt.Sprintf("Post %d", i+1),
+			Content: "Lorem ipsum dolor sit amet.",
+		}
+		if err := repo.Store(ctx, &post); err != nil {
+			t.Fatal("Unable to store:", err)
+		}
+	}
+
+	t.Run("Count", func(t *testing.T) {
+		if count, err := repo.Count(ctx); err != nil {
+			t.Fatal("Unable to Count:", err)
+		} else if count != 5 {
+			t.Fatalf("want count = %d, got count = %d", 5, count)
+		}
+	})
+
+	t.Run("Page", func(t *testing.T) {
+		tests := []struct {
+			limit, offset int
+			want          []int64
+		}{
+			{2, 0, []int64{1, 2}},
+			{2, 2, []int64{3, 4}},
+			{2, 4, []int64{5}},
+			{2, 6, nil},
+		}
+		for _, test := range tests {
+			posts, err := repo.FetchPage(ctx, test.limit, test.offset)
+			if err != nil {
+				t.Fatal("Unable to FetchPage:", err)
+			}
+			if got := postIds(posts); !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("limit %d offset %d: want %v, got %v", test.limit, test.offset, test.want, got)
+			}
+		}
+	})
+
+	t.Run("After", func(t *testing.T) {
+		tests := []struct {
+			cursor int64
+			limit  int
+			want   []int64
+		}{
+			{0, 2, []int64{1, 2}},
+			{2, 2, []int64{3, 4}},
+			{4, 2, []int64{5}},
+			{5, 2, nil},
+		}
+		for _, test := range tests {
+			posts, err := repo.FetchAfter(ctx, test.cursor, test.limit)
+			if err != nil {
+				t.Fatal("Unable to FetchAfter:", err)
+			}
+			if got := postIds(posts); !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("cursor %d: want %v, got %v", test.cursor, test.want, got)
+			}
+		}
+	})
+}
+
+func postIds(posts []entity.Post) []int64 {
+	var ids []int64
+	for _, post := range posts {
+		ids = append(ids, post.Id)
+	}
+	return ids
+}
+
+func testFetchByAuthor(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeUsers(t, repos.Users, "Riddle", "Subi")
+		repo := repos.Posts
+
+		post := entity.Post{
+			User:    entity.User{Id: 1, Name: "Riddle"},
+			Date:    at("2022-09-01"),
+			Title:   "Cars",
+			Content: "Lorem ipsum dolor sit amet.",
+		}
+
+		if err := repo.Store(ctx, &post); err != nil {
+			t.Fatal("Unable to store:", err)
+		}
+
+		title := "Travel"
+		post1 := entity.Post{
+			User:    entity.User{Id: 1, Name: "Riddle"},
+			Date:    at("2022-09-01"),
+			Title:   title,
+			Content: "Lorem ipsum dolor sit amet.",
+		}
+
+		if err := repo.Store(ctx, &post1); err != nil {
+			t.Fatal("Unable to store:", err)
+		}
+
+		post2 := entity.Post{
+			User:    entity.User{Id: 2, Name: "Subi"},
+			Date:    at("2022-09-02"),
+			Title:   "Sports",
+			Content: "Lorem ipsum dolor sit amet.",
+		}
+
+		if err := repo.Store(ctx, &post2); err != nil {
+			t.Fatal("Unable to store:", err)
+		}
+
+		if fetched, err := repo.FetchByAuthor(ctx, entity.User{Id: 1}); err != nil {
+			t.Fatal("Unable to FetchByAuthor:", err)
+		} else if len(fetched) != 2 {
+			t.Fatalf("want len = %d, got len = %d:", 2, len(fetched))
+		} else if fetched[1].Title != title {
+			t.Fatalf("want title = %v, got title = %v:", title, fetched[1].Title)
+		}
+	})
+}
+
+func testPostGetById(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeUsers(t, repos.Users, "Riddle", "Subi")
+		repo := repos.Posts
+
+		title := "Travel"
+		post1 := entity.Post{
+			User:    entity.User{Id: 1, Name: "Riddle"},
+			Date:    at("2022-09-01"),
+			Title:   title,
+			Content: "Lorem ipsum dolor sit amet.",
+		}
+
+		if err := repo.Store(ctx, &post1); err != nil {
+			t.Fatal("Unable to store:", err)
+		}
+
+		title2 := "Sports"
+		post2 := entity.Post{
+			User:    entity.User{Id: 2, Name: "Subi"},
+			Date:    at("2022-09-02"),
+			Title:   title2,
+			Content: "Lorem ipsum dolor sit amet.",
+		}
+
+		if err := repo.Store(ctx, &post2); err != nil {
+			t.Fatal("Unable to store:", err)
+		}
+
+		if found, err := repo.GetById(ctx, 1); err != nil {
+			t.Fatal("Unable to GetById:", err)
+		} else if found.Title != title {
+			t.Fatalf("want title = %v, got title = %v:", title, found.Title)
+		}
+
+		if found, err := repo.GetById(ctx, 2); err != nil {
+			t.Fatal("Unable to GetById:", err)
+		} else if found.Title != title2 {
+			t.Fatalf("want title = %v, got title = %v:", title2, found.Title)
+		}
+	})
+}
+
+func testGetRelatedCategories(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeUsers(t, repos.Users, "Riddle", "Subi")
+		repo := repos.Posts
+
+		stored := storeCategories(t, repos.Categories, "Cars", "Cinema", "Games", "Work")
+		categories := stored[:3]
+		post1 := entity.Post{
+			User:       entity.User{Id: 1, Name: "Riddle"},
+			Categories: categories,
+		}
+
+		if err := repo.Store(ctx, &post1); err != nil {
+			t.Fatal("Unable to store:", err)
+		}
+		if err := repo.StoreTopicReference(ctx, post1); err != nil {
+			t.Fatal("Unable to StoreTopicReference:", err)
+		}
+
+		categories2 := stored[2:]
+		post2 := entity.Post{
+			User:       entity.User{Id: 1, Name: "Riddle"},
+			Categories: categories2,
+		}
+
+		if err := repo.Store(ctx, &post2); err != nil {
+			t.Fatal("Unable to store:", err)
+		}
+		if err := repo.StoreTopicReference(ctx, post2); err != nil {
+			t.Fatal("Unable to StoreTopicReference:", err)
+		}
+
+		if found, err := repo.GetRelatedCategories(ctx, entity.Post{Id: 1}); err != nil {
+			t.Fatal("Unable to GetRelatedCategories:", err)
+		} else if !reflect.DeepEqual(found, categories) {
+			t.Fatalf("want = %v, got = %v:", categories, found)
+		}
+
+		if found, err := repo.GetRelatedCategories(ctx, entity.Post{Id: 2}); err != nil {
+			t.Fatal("Unable to GetRelatedCategories:", err)
+		} else if !reflect.DeepEqual(found, categories2) {
+			t.Fatalf("want = %v, got = %v:", categories2, found)
+		}
+	})
+}
+
+func testFetchCategories(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeUsers(t, repos.Users, "Riddle", "Subi")
+		repo := repos.Posts
+
+		stored := storeCategories(t, repos.Categories, "Cars", "Games", "Work")
+		for _, categories := range [][]entity.Category{{stored[1], stored[0]}, nil, {stored[2]}} {
+			post := entity.Post{
+				User:       entity.User{Id: 1, Name: "Riddle"},
+				Categories: categories,
+			}
+			if err := repo.Store(ctx, &post); err != nil {
+				t.Fatal("Unable to store:", err)
+			}
+			if err := repo.StoreTopicReference(ctx, post); err != nil {
+				t.Fatal("Unable to StoreTopicReference:", err)
+			}
+		}
+
+		want := map[int64][]entity.Category{1: stored[:2]}
+		if found, err := repo.FetchCategories(ctx, []int64{1, 2}); err != nil {
+			t.Fatal("Unable to FetchCategories:", err)
+		} else if !reflect.DeepEqual(found, want) {
+			t.Fatalf("want = %v, got = %v:", want, found)
+		}
+
+		if found, err := repo.FetchCategories(ctx, nil); err != nil {
+			t.Fatal("Unable to FetchCategories:", err)
+		} else if len(found) != 0 {
+			t.Fatalf("want len = %d, got len = %d:", 0, len(found))
+		}
+	})
+}
+
+func testPostUpdate(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeUsers(t, repos.Users, "Riddle", "Subi")
+		repo := repos.Posts
+		var err error
+
+		title := "Travel"
+		post1 := entity.Post{
+			User:    entity.User{Id: 1, Name: "Riddle"},
+			Date:    at("2022-09-01"),
+			Title:   title,
+			Content: "Lorem ipsum dolor sit amet.",
+		}
+
+		if err := repo.Store(ctx, &post1); err != nil {
+			t.Fatal("Unable to store:", err)
+		}
+
+		title2 := "Sports"
+		post2 := entity.Post{
+			User:    entity.User{Id: 2, Name: "Subi"},
+			Date:    at("2022-09-02"),
+			Title:   title2,
+			Content: "Lorem ipsum dolor sit amet.",
+		}
+
+		if err := repo.Store(ctx, &post2); err != nil {
+			t.Fatal("Unable to store:", err)
+		}
+
+		newTitle := "NewTitle"
+		editedAt := at("2022-10-02 10:00:00")
+		if err = repo.Update(ctx, entity.Post{Id: 1, Title: newTitle, EditedAt: editedAt}); err != nil {
+			t.Fatal("Unable to Update:", err)
+		}
+
+		if found, err := repo.GetById(ctx, 1); err != nil {
+			t.Fatal("Unable to GetById:", err)
+		} else if found.Title != newTitle || !found.EditedAt.Equal(editedAt) {
+			t.Fatalf("want title = %v, edited = %v, got post = %v:", newTitle, editedAt, found)
+		}
+	})
+
+	t.Run("Categories", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeUsers(t, repos.Users, "Riddle", "Subi")
+		repo := repos.Posts
+
+		post := entity.Post{User: entity.User{Id: 1}, Date: at("2022-10-01"), Title: "Cars", Content: "Lorem ipsum."}
+		if err := repo.Store(ctx, &post); err != nil {
+			t.Fatal("Unable to store:", err)
+		}
+		stored := storeCategories(t, repos.Categories, "cars", "sport", "travel")
+		post.Categories = stored[:2]
+		if err := repo.StoreTopicReference(ctx, post); err != nil {
+			t.Fatal("Unable to store references:", err)
+		}
+
+		post.Categories = nil
+		if err := repo.Update(ctx, post); err != nil {
+			t.Fatal("Unable to Update:", err)
+		}
+		if found, err := repo.GetRelatedCategories(ctx, post); err != nil {
+			t.Fatal("Unable to GetRelatedCategories:", err)
+		} else if want := stored[:2]; !reflect.DeepEqual(found, want) {
+			t.Fatalf("want = %v, got = %v:", want, found)
+		}
+
+		post.Categories = stored[2:]
+		if err := repo.Update(ctx, post); err != nil {
+			t.Fatal("Unable to Update:", err)
+		}
+		if found, err := repo.GetRelatedCategories(ctx, post); err != nil {
+			t.Fatal("Unable to GetRelatedCategories:", err)
+		} else if want := stored[2:]; !reflect.DeepEqual(found, want) {
+			t.Fatalf("want = %v, got = %v:", want, found)
+		}
+	})
+
+	t.Run("Images", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeUsers(t, repos.Users, "Riddle", "Subi")
+		repo := repos.Posts
+
+		post := entity.Post{User: entity.User{Id: 1}, Date: at("2022-10-01"), Title: "Cars", Content: "Lorem ipsum.",
+			Images: []string{"/img/b.png", "/img/a.png", "/img/c.png"}}
+		if err := repo.Store(ctx, &post); err != nil {
+			t.Fatal("Unable to store:", err)
+		}
+		other := entity.Post{User: entity.User{Id: 1}, Date: at("2022-10-02"), Title: "Sport", Content: "Lorem.",
+			Images: []string{"/img/d.png"}}
+		if err := repo.Store(ctx, &other); err != nil {
+			t.Fatal("Unable to store:", err)
+		}
+		if found, err := repo.GetById(ctx, post.Id); err != nil {
+			t.Fatal("Unable to GetById:", err)
+		} else if !reflect.DeepEqual(found.Images, post.Images) {
+			t.Fatalf("want = %v, got = %v:", post.Images, found.Images)
+		}
+
+		for _, tc := range []struct {
+			images []string
+			want   []string
+		}{
+			{nil, []string{"/img/b.png", "/img/a.png", "/img/c.png"}},
+			{[]string{"/img/c.png", "/img/b.png"}, []string{"/img/c.png", "/img/b.png"}},
+			{[]string{}, nil},
+		} {
+			post.Images = tc.images
+			if err := repo.Update(ctx, post); err != nil {
+				t.Fatal("Unable to Update:", err)
+			}
+			if found, err := repo.GetById(ctx, post.Id); err != nil {
+				t.Fatal("Unable to GetById:", err)
+			} else if !reflect.DeepEqual(found.Images, tc.want) {
+				t.Fatalf("want = %v, got = %v:", tc.want, found.Images)
+			}
+		}
+		if found, err := repo.GetById(ctx, other.Id); err != nil {
+			t.Fatal("Unable to GetById:", err)
+		} else if !reflect.DeepEqual(found.Images, other.Images) {
+			t.Fatalf("want = %v, got = %v:", other.Images, found.Images)
+		}
+	})
+}
+
+func testPostDelete(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeUsers(t, repos.Users, "Riddle", "Subi")
+		repo := repos.Posts
+		var err error
+
+		post1 := entity.Post{
+			User:    entity.User{Id: 1, Name: "Riddle"},
+			Date:    at("2022-09-01"),
+			Content: "Lorem ipsum dolor sit amet.",
+		}
+
+		if err := repo.Store(ctx, &post1); err != nil {
+			t.Fatal("Unable to store:", err)
+		}
+
+		if found, err := repo.GetById(ctx, 1); err != nil {
+			t.Fatal("Unable to GetById:", err)
+		} else if found.Id != 1 {
+			t.Fatalf("want id = %d, got id = %d:", 1, found.Id)
+		}
+
+		deleted := entity.Post{Id: 1, DeletedAt: at("2022-09-02 10:00:00"), DeletedBy: 2, DeleteReason: "spam"}
+		if err = repo.Delete(ctx, deleted); err != nil {
+			t.Fatal("Unable to Delete:", err)
+		}
+
+		if found, err := repo.GetById(ctx, 1); err != nil {
+			t.Fatal("Unable to GetById:", err)
+		} else if !found.DeletedAt.Equal(deleted.DeletedAt) || found.DeletedBy != deleted.DeletedBy ||
+			found.DeleteReason != deleted.DeleteReason {
+			t.Fatalf("want deletion = %v, got post = %v:", deleted, found)
+		}
+
+		if found, err := repo.Fetch(ctx); err != nil {
+			t.Fatal("Unable to Fetch:", err)
+		} else if len(found) != 0 {
+			t.Fatalf("want len = %d, got len = %d:", 0, len(found))
+		}
+
+		if count, err := repo.Count(ctx); err != nil {
+			t.Fatal("Unable to Count:", err)
+		} else if count != 0 {
+			t.Fatalf("want count = %d, got count = %d:", 0, count)
+		}
+
+		expErr := "no rows in result set"
+
+		if err := repo.Delete(ctx, deleted); err == nil {
+			t.Fatal("Expected error:")
+		} else if !strings.Contains(err.Error(), expErr) {
+			t.Fatalf("want err = %v, got err = %v:", expErr, err)
+		}
+	})
+}
+
+func testGetIdsByCategory(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeUsers(t, repos.Users, "Riddle", "Subi")
+		repo := repos.Posts
+
+		stored := storeCategories(t, repos.Categories, "Cars", "Sports")
+		for _, categories := range [][]entity.Category{stored, stored[:1], stored[1:], stored[:1]} {
+			post := entity.Post{User: entity.User{Id: 1}, Date: at("2022-09-01"), Title: "Post",
+				Content: "Lorem ipsum.", Categories: categories}
+			if err := repo.Store(ctx, &post); err != nil {
+				t.Fatal("Unable to store:", err)
+			}
+			if err := repo.StoreTopicReference(ctx, post); err != nil {
+				t.Fatal("Unable to StoreTopicReference:", err)
+			}
+		}
+		if err := repo.Delete(ctx, entity.Post{Id: 4, DeletedAt: at("2022-09-02"), DeletedBy: 1}); err != nil {
+			t.Fatal("Unable to Delete:", err)
+		}
+
+		if found, err := repo.GetIdsByCategory(ctx, stored[0].Id); err != nil {
+			t.Fatal("Unable to GetIdsByCategory:", err)
+		} else if want := []int64{1, 2}; !reflect.DeepEqual(found, want) {
+			t.Fatalf("want = %v, got = %v:", want, found)
+		}
+	})
+}
@@ -0,0 +1,317 @@
+package repotest
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"forum/internal/entity"
+	"forum/internal/repository"
+)
+
+func RunReactionsTests(t *testing.T, open Opener) {
+	t.Run("ReactionStore", func(t *testing.T) { testReactionStore(t, open) })
+	t.Run("ReactionDelete", func(t *testing.T) { testReactionDelete(t, open) })
+	t.Run("ReactionDeleteByUser", func(t *testing.T) { testReactionDeleteByUser(t, open) })
+	t.Run("ReactionHas", func(t *testing.T) { testReactionHas(t, open) })
+	t.Run("ReactionFetch", func(t *testing.T) { testReactionFetch(t, open) })
+	t.Run("ReactionFetchAll", func(t *testing.T) { testReactionFetchAll(t, open) })
+	t.Run("ReactionCount", func(t *testing.T) { testReactionCount(t, open) })
+	t.Run("ReactionCountByUser", func(t *testing.T) { testReactionCountByUser(t, open) })
+	t.Run("FetchTargetIds", func(t *testing.T) { testFetchTargetIds(t, open) })
+}
+
+func storeReactions(t *testing.T, repo repository.Reactions, reactions ...entity.Reaction) {
+	t.Helper()
+	ctx := context.Background()
+	for _, reaction := range reactions {
+		if err := repo.Store(ctx, reaction); err != nil {
+			t.Fatal("Unable to store:", err)
+		}
+	}
+}
+
+func testReactionStore(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeUsers(t, repos.Users, "Riddle", "Subi", "Tom", "Ginny")
+		repo := repos.Reactions
+
+		storeReactions(t, repo,
+			entity.Reaction{Target: entity.ReactionTargetPost, TargetId: 1, UserId: 1, Kind: "like", Date: at("2022-09-01")},
+			entity.Reaction{Target: entity.ReactionTargetPost, TargetId: 1, UserId: 1, Kind: "heart", Date: at("2022-09-01")},
+			entity.Reaction{Target: entity.ReactionTargetComment, TargetId: 1, UserId: 1, Kind: "like", Date: at("2022-09-01")},
+		)
+	})
+
+	t.Run("Duplicate", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeUsers(t, repos.Users, "Riddle", "Subi", "Tom", "Ginny")
+		repo := repos.Reactions
+
+		reaction := entity.Reaction{Target: entity.ReactionTargetPost, TargetId: 1, UserId: 1, Kind: "like", Date: at("2022-09-01")}
+		storeReactions(t, repo, reaction)
+
+		expErr := "UNIQUE constraint failed: reactions"
+		if err := repo.Store(ctx, reaction); err == nil {
+			t.Fatal("expected error")
+		} else if !strings.Contains(err.Error(), expErr) {
+			t.Fatalf("want err = %v, got err = %v:", expErr, err)
+		}
+	})
+}
+
+func testReactionDelete(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeUsers(t, repos.Users, "Riddle", "Subi", "Tom", "Ginny")
+		repo := repos.Reactions
+
+		like := entity.Reaction{Target: entity.ReactionTargetPost, TargetId: 1, UserId: 1, Kind: "like", Date: at("2022-09-01")}
+		heart := entity.Reaction{Target: entity.ReactionTargetPost, TargetId: 1, UserId: 1, Kind: "heart", Date: at("2022-09-01")}
+		storeReactions(t, repo, like, heart)
+
+		if err := repo.Delete(ctx, like); err != nil {
+			t.Fatal("Unable to delete:", err)
+		}
+
+		if found, err := repo.Fetch(ctx, entity.ReactionTargetPost, 1, "like"); err != nil {
+			t.Fatal("Unable to fetch:", err)
+		} else if len(found) != 0 {
+			t.Fatalf("want len = %d, got len = %d:", 0, len(found))
+		}
+
+		if found, err := repo.Fetch(ctx, entity.ReactionTargetPost, 1, "heart"); err != nil {
+			t.Fatal("Unable to fetch:", err)
+		} else if len(found) != 1 {
+			t.Fatalf("want len = %d, got len = %d:", 1, len(found))
+		}
+	})
+}
+
+func testReactionDeleteByUser(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeUsers(t, repos.Users, "Riddle", "Subi", "Tom", "Ginny")
+		repo := repos.Reactions
+
+		storeReactions(t, repo,
+			entity.Reaction{Target: entity.ReactionTargetPost, TargetId: 1, UserId: 1, Kind: "like", Date: at("2022-09-01")},
+			entity.Reaction{Target: entity.ReactionTargetComment, TargetId: 1, UserId: 1, Kind: "like", Date: at("2022-09-01")},
+			entity.Reaction{Target: entity.ReactionTargetPost, TargetId: 1, UserId: 2, Kind: "like", Date: at("2022-09-01")},
+		)
+
+		if err := repo.DeleteByUser(ctx, 1); err != nil {
+			t.Fatal("Unable to delete:", err)
+		}
+
+		if found, err := repo.CountByUser(ctx, entity.ReactionTargetPost, 1); err != nil {
+			t.Fatal("Unable to count:", err)
+		} else if len(found) != 0 {
+			t.Fatalf("want len = %d, got len = %d:", 0, len(found))
+		}
+		if found, err := repo.CountByUser(ctx, entity.ReactionTargetComment, 1); err != nil {
+			t.Fatal("Unable to count:", err)
+		} else if len(found) != 0 {
+			t.Fatalf("want len = %d, got len = %d:", 0, len(found))
+		}
+		if found, err := repo.CountByUser(ctx, entity.ReactionTargetPost, 2); err != nil {
+			t.Fatal("Unable to count:", err)
+		} else if want := map[string]int64{"like": 1}; !reflect.DeepEqual(found, want) {
+			t.Fatalf("want counts = %v, got counts = %v:", want, found)
+		}
+	})
+}
+
+func testReactionHas(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeUsers(t, repos.Users, "Riddle", "Subi", "Tom", "Ginny")
+		repo := repos.Reactions
+
+		like := entity.Reaction{Target: entity.ReactionTargetPost, TargetId: 1, UserId: 1, Kind: "like", Date: at("2022-09-01")}
+		storeReactions(t, repo, like,
+			entity.Reaction{Target: entity.ReactionTargetPost, TargetId: 1, UserId: 2, Kind: "heart", Date: at("2022-09-01")},
+		)
+
+		others := []entity.Reaction{
+			{Target: entity.ReactionTargetPost, TargetId: 1, UserId: 2, Kind: "like"},
+			{Target: entity.ReactionTargetPost, TargetId: 1, UserId: 1, Kind: "heart"},
+			{Target: entity.ReactionTargetPost, TargetId: 2, UserId: 1, Kind: "like"},
+			{Target: entity.ReactionTargetComment, TargetId: 1, UserId: 1, Kind: "like"},
+		}
+		if has, err := repo.Has(ctx, like); err != nil {
+			t.Fatal(err)
+		} else if !has {
+			t.Fatalf("want: %v, got: %v", true, has)
+		}
+		for _, other := range others {
+			if has, err := repo.Has(ctx, other); err != nil {
+				t.Fatal(err)
+			} else if has {
+				t.Fatalf("want: %v, got: %v for %+v", false, has, other)
+			}
+		}
+	})
+}
+
+func testReactionFetch(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeUsers(t, repos.Users, "Riddle", "Subi", "Tom", "Ginny")
+		repo := repos.Reactions
+
+		storeReactions(t, repo,
+			entity.Reaction{Target: entity.ReactionTargetComment, TargetId: 1, UserId: 3, Kind: "dislike", Date: at("2022-09-02")},
+			entity.Reaction{Target: entity.ReactionTargetComment, TargetId: 1, UserId: 2, Kind: "dislike", Date: at("2022-09-01")},
+			entity.Reaction{Target: entity.ReactionTargetComment, TargetId: 1, UserId: 1, Kind: "like", Date: at("2022-09-01")},
+			entity.Reaction{Target: entity.ReactionTargetPost, TargetId: 1, UserId: 4, Kind: "dislike", Date: at("2022-09-01")},
+		)
+
+		found, err := repo.Fetch(ctx, entity.ReactionTargetComment, 1, "dislike")
+		if err != nil {
+			t.Fatal("Unable to fetch:", err)
+		}
+		var userIds []int64
+		for _, reaction := range found {
+			userIds = append(userIds, reaction.UserId)
+		}
+		if want := []int64{2, 3}; !reflect.DeepEqual(userIds, want) {
+			t.Fatalf("want users = %v, got users = %v:", want, userIds)
+		}
+	})
+}
+
+func testReactionFetchAll(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeUsers(t, repos.Users, "Riddle", "Subi", "Tom", "Ginny")
+		repo := repos.Reactions
+
+		storeReactions(t, repo,
+			entity.Reaction{Target: entity.ReactionTargetComment, TargetId: 2, UserId: 1, Kind: "like", Date: at("2022-09-01")},
+			entity.Reaction{Target: entity.ReactionTargetComment, TargetId: 1, UserId: 3, Kind: "dislike", Date: at("2022-09-02")},
+			entity.Reaction{Target: entity.ReactionTargetComment, TargetId: 1, UserId: 2, Kind: "heart", Date: at("2022-09-01")},
+			entity.Reaction{Target: entity.ReactionTargetPost, TargetId: 1, UserId: 4, Kind: "dislike", Date: at("2022-09-01")},
+		)
+
+		found, err := repo.FetchAll(ctx, entity.ReactionTargetComment)
+		if err != nil {
+			t.Fatal("Unable to fetch:", err)
+		}
+		var got []string
+		for _, reaction := range found {
+			got = append(got, fmt.Sprintf("%d/%d/%s", reaction.TargetId, reaction.UserId, reaction.Kind))
+		}
+		if want := []string{"1/2/heart", "1/3/dislike", "2/1/like"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("want reactions = %v, got reactions = %v:", want, got)
+		}
+	})
+}
+
+func testReactionCount(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeUsers(t, repos.Users, "Riddle", "Subi", "Tom", "Ginny")
+		repo := repos.Reactions
+
+		storeReactions(t, repo,
+			entity.Reaction{Target: entity.ReactionTargetPost, TargetId: 1, UserId: 1, Kind: "like", Date: at("2022-09-01")},
+			entity.Reaction{Target: entity.ReactionTargetPost, TargetId: 1, UserId: 2, Kind: "like", Date: at("2022-09-01")},
+			entity.Reaction{Target: entity.ReactionTargetPost, TargetId: 1, UserId: 2, Kind: "laugh", Date: at("2022-09-01")},
+			entity.Reaction{Target: entity.ReactionTargetPost, TargetId: 2, UserId: 1, Kind: "dislike", Date: at("2022-09-01")},
+			entity.Reaction{Target: entity.ReactionTargetPost, TargetId: 3, UserId: 1, Kind: "like", Date: at("2022-09-01")},
+			entity.Reaction{Target: entity.ReactionTargetComment, TargetId: 1, UserId: 1, Kind: "like", Date: at("2022-09-01")},
+		)
+
+		want := map[int64]map[string]int64{
+			1: {"like": 2, "laugh": 1},
+			2: {"dislike": 1},
+		}
+		if found, err := repo.Count(ctx, entity.ReactionTargetPost, []int64{1, 2, 4}); err != nil {
+			t.Fatal("Unable to count:", err)
+		} else if !reflect.DeepEqual(found, want) {
+			t.Fatalf("want counts = %v, got counts = %v:", want, found)
+		}
+
+		if found, err := repo.Count(ctx, entity.ReactionTargetPost, nil); err != nil {
+			t.Fatal("Unable to count:", err)
+		} else if len(found) != 0 {
+			t.Fatalf("want len = %d, got len = %d:", 0, len(found))
+		}
+	})
+}
+
+func testReactionCountByUser(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeUsers(t, repos.Users, "Riddle", "Subi", "Tom", "Ginny")
+		repo := repos.Reactions
+
+		storeReactions(t, repo,
+			entity.Reaction{Target: entity.ReactionTargetPost, TargetId: 1, UserId: 1, Kind: "like", Date: at("2022-09-01")},
+			entity.Reaction{Target: entity.ReactionTargetPost, TargetId: 2, UserId: 1, Kind: "like", Date: at("2022-09-01")},
+			entity.Reaction{Target: entity.ReactionTargetPost, TargetId: 2, UserId: 1, Kind: "wow", Date: at("2022-09-01")},
+			entity.Reaction{Target: entity.ReactionTargetPost, TargetId: 2, UserId: 2, Kind: "like", Date: at("2022-09-01")},
+			entity.Reaction{Target: entity.ReactionTargetComment, TargetId: 1, UserId: 1, Kind: "dislike", Date: at("2022-09-01")},
+		)
+
+		want := map[string]int64{"like": 2, "wow": 1}
+		if found, err := repo.CountByUser(ctx, entity.ReactionTargetPost, 1); err != nil {
+			t.Fatal("Unable to count:", err)
+		} else if !reflect.DeepEqual(found, want) {
+			t.Fatalf("want counts = %v, got counts = %v:", want, found)
+		}
+	})
+}
+
+func testFetchTargetIds(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeUsers(t, repos.Users, "Riddle", "Subi", "Tom", "Ginny")
+		repo := repos.Reactions
+
+		storeReactions(t, repo,
+			entity.Reaction{Target: entity.ReactionTargetPost, TargetId: 3, UserId: 1, Kind: "like", Date: at("2022-09-01")},
+			entity.Reaction{Target: entity.ReactionTargetPost, TargetId: 1, UserId: 1, Kind: "like", Date: at("2022-09-01")},
+			entity.Reaction{Target: entity.ReactionTargetPost, TargetId: 2, UserId: 1, Kind: "dislike", Date: at("2022-09-01")},
+			entity.Reaction{Target: entity.ReactionTargetPost, TargetId: 4, UserId: 2, Kind: "like", Date: at("2022-09-01")},
+		)
+
+		if found, err := repo.FetchTargetIds(ctx, entity.ReactionTargetPost, 1, "like"); err != nil {
+			t.Fatal("Unable to FetchTargetIds:", err)
+		} else if want := []int64{1, 3}; !reflect.DeepEqual(found, want) {
+			t.Fatalf("want ids = %v, got ids = %v:", want, found)
+		}
+	})
+}
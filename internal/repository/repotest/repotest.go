@@ -0,0 +1,54 @@
+// Package repotest holds the repository test suite shared by every
+// backend. Each backend runs it against a fresh, empty database.
+package repotest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"forum/internal/entity"
+	"forum/internal/repository"
+)
+
+// Opener returns repositories over an empty migrated database and a
+// function releasing it.
+type Opener func(tb testing.TB) (*repository.Repositories, func())
+
+// at parses a fixture time written as "2006-01-02" or "2006-01-02 15:04:05",
+// in UTC.
+func at(value string) time.Time {
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	panic("repotest: bad fixture time " + value)
+}
+
+// storeUsers stores a user for every name, they get the ids 1, 2, ... in
+// order so that fixtures referring to them satisfy the foreign keys.
+func storeUsers(t *testing.T, repo repository.Users, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		user := entity.User{Name: name, Email: strings.ToLower(name) + "@mail.ru"}
+		if err := repo.Store(context.Background(), user); err != nil {
+			t.Fatal("Unable to store user:", err)
+		}
+	}
+}
+
+// storePosts stores two users and count posts of the first one, the posts
+// get the ids 1, 2, ... for the comments and reactions of a fixture to refer
+// to.
+func storePosts(t *testing.T, repos *repository.Repositories, count int) {
+	t.Helper()
+	storeUsers(t, repos.Users, "Riddle", "Subi")
+	for i := 0; i < count; i++ {
+		post := entity.Post{User: entity.User{Id: 1}, Date: at("2022-09-01"), Title: "Post", Content: "Lorem ipsum."}
+		if err := repos.Posts.Store(context.Background(), &post); err != nil {
+			t.Fatal("Unable to store post:", err)
+		}
+	}
+}
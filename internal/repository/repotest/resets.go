@@ -0,0 +1,123 @@
+package repotest
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"forum/internal/entity"
+	"forum/internal/repository"
+)
+
+func RunPasswordResetsTests(t *testing.T, open Opener) {
+	t.Run("ResetStore", func(t *testing.T) { testResetStore(t, open) })
+	t.Run("ResetDelete", func(t *testing.T) { testResetDelete(t, open) })
+}
+
+// storeResets stores Riddle and Tom, Riddle asked for a reset twice and
+// Tom once.
+func storeResets(t *testing.T, repos *repository.Repositories) []entity.PasswordReset {
+	ctx := context.Background()
+	for _, user := range []entity.User{
+		{Name: "Riddle", Email: "riddle@mail.ru"},
+		{Name: "Tom", Email: "tom@mail.ru"},
+	} {
+		if err := repos.Users.Store(ctx, user); err != nil {
+			t.Fatal("Unable to store user:", err)
+		}
+	}
+
+	resets := []entity.PasswordReset{
+		{User: entity.User{Id: 1}, TokenHash: "first", CreatedAt: at("2022-10-01 10:00:00"),
+			ExpiresAt: at("2022-10-01 11:00:00")},
+		{User: entity.User{Id: 1}, TokenHash: "second", CreatedAt: at("2022-10-02 10:00:00"),
+			ExpiresAt: at("2022-10-02 11:00:00")},
+		{User: entity.User{Id: 2}, TokenHash: "tom", CreatedAt: at("2022-10-01 12:00:00"),
+			ExpiresAt: at("2022-10-01 13:00:00")},
+	}
+	for i := range resets {
+		if err := repos.Resets.Store(ctx, &resets[i]); err != nil {
+			t.Fatal("Unable to store:", err)
+		}
+	}
+	return resets
+}
+
+func testResetStore(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		resets := storeResets(t, repos)
+
+		found, err := repos.Resets.GetByHash(ctx, "second")
+		if err != nil {
+			t.Fatal("Unable to GetByHash:", err)
+		}
+		if resets[1].Id != 2 || !reflect.DeepEqual(found, resets[1]) {
+			t.Fatalf("want reset = %+v, got reset = %+v:", resets[1], found)
+		}
+	})
+
+	t.Run("err hash taken", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeResets(t, repos)
+
+		reset := entity.PasswordReset{User: entity.User{Id: 2}, TokenHash: "first", CreatedAt: at("2022-10-01"),
+			ExpiresAt: at("2022-10-02")}
+		if err := repos.Resets.Store(ctx, &reset); err == nil || !strings.Contains(err.Error(), "UNIQUE") {
+			t.Fatalf("want unique constraint err, got err = %v:", err)
+		}
+	})
+
+	t.Run("err not found", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeResets(t, repos)
+
+		if _, err := repos.Resets.GetByHash(ctx, "third"); err == nil ||
+			!strings.Contains(err.Error(), "no rows in result set") {
+			t.Fatalf("want no rows err, got err = %v:", err)
+		}
+	})
+}
+
+func testResetDelete(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK by user", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeResets(t, repos)
+
+		if err := repos.Resets.DeleteByUser(ctx, 1); err != nil {
+			t.Fatal("Unable to DeleteByUser:", err)
+		}
+		for _, hash := range []string{"first", "second"} {
+			if _, err := repos.Resets.GetByHash(ctx, hash); err == nil {
+				t.Fatalf("want %s deleted, got nil err", hash)
+			}
+		}
+		if _, err := repos.Resets.GetByHash(ctx, "tom"); err != nil {
+			t.Fatal("Unable to GetByHash:", err)
+		}
+	})
+
+	t.Run("OK expired", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeResets(t, repos)
+
+		if deleted, err := repos.Resets.DeleteExpired(ctx, at("2022-10-02")); err != nil {
+			t.Fatal("Unable to DeleteExpired:", err)
+		} else if deleted != 2 {
+			t.Fatalf("want deleted = %d, got deleted = %d:", 2, deleted)
+		}
+		if _, err := repos.Resets.GetByHash(ctx, "second"); err != nil {
+			t.Fatal("Unable to GetByHash:", err)
+		}
+	})
+}
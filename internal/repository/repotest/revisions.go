@@ -0,0 +1,100 @@
+package repotest
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"forum/internal/entity"
+)
+
+func RunRevisionsTests(t *testing.T, open Opener) {
+	t.Run("RevisionStore", func(t *testing.T) { testRevisionStore(t, open) })
+	t.Run("RevisionFetch", func(t *testing.T) { testRevisionFetch(t, open) })
+}
+
+func testRevisionStore(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		repo := repos.Revisions
+
+		if err := repos.Users.Store(ctx, entity.User{Name: "Riddle", Email: "riddle@mail.ru"}); err != nil {
+			t.Fatal("Unable to store user:", err)
+		}
+
+		revision := entity.Revision{
+			Target:     entity.RevisionTargetPost,
+			TargetId:   1,
+			User:       entity.User{Id: 1},
+			Date:       at("2022-10-01 10:00:00"),
+			Title:      "Cars",
+			Content:    "Lorem ipsum.\nDolor sit amet.",
+			Categories: []string{"cars", "sport"},
+		}
+		if err := repo.Store(ctx, revision); err != nil {
+			t.Fatal("Unable to store:", err)
+		}
+
+		found, err := repo.Fetch(ctx, entity.RevisionTargetPost, 1)
+		if err != nil {
+			t.Fatal("Unable to Fetch:", err)
+		}
+		if len(found) != 1 {
+			t.Fatalf("want len = %d, got len = %d:", 1, len(found))
+		}
+
+		revision.Id, revision.Number, revision.User.Name = 1, 1, "Riddle"
+		if !reflect.DeepEqual(found[0], revision) {
+			t.Fatalf("want revision = %v, got revision = %v:", revision, found[0])
+		}
+	})
+}
+
+func testRevisionFetch(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeUsers(t, repos.Users, "Riddle", "Subi")
+		repo := repos.Revisions
+
+		for _, revision := range []entity.Revision{
+			{Target: entity.RevisionTargetPost, TargetId: 1, User: entity.User{Id: 1}, Date: at("2022-10-01"), Content: "one"},
+			{Target: entity.RevisionTargetComment, TargetId: 1, User: entity.User{Id: 1}, Date: at("2022-10-01"), Content: "one"},
+			{Target: entity.RevisionTargetPost, TargetId: 2, User: entity.User{Id: 1}, Date: at("2022-10-01"), Content: "one"},
+			{Target: entity.RevisionTargetPost, TargetId: 1, User: entity.User{Id: 2}, Date: at("2022-10-02"), Content: "two"},
+		} {
+			if err := repo.Store(ctx, revision); err != nil {
+				t.Fatal("Unable to store:", err)
+			}
+		}
+
+		found, err := repo.Fetch(ctx, entity.RevisionTargetPost, 1)
+		if err != nil {
+			t.Fatal("Unable to Fetch:", err)
+		}
+		var contents []string
+		for i, revision := range found {
+			if revision.Number != i+1 {
+				t.Fatalf("want number = %d, got number = %d:", i+1, revision.Number)
+			}
+			if revision.Categories != nil {
+				t.Fatalf("want no categories, got categories = %v:", revision.Categories)
+			}
+			contents = append(contents, revision.Content)
+		}
+		if want := []string{"one", "two"}; !reflect.DeepEqual(contents, want) {
+			t.Fatalf("want contents = %v, got contents = %v:", want, contents)
+		}
+
+		if found, err := repo.Fetch(ctx, entity.RevisionTargetComment, 2); err != nil {
+			t.Fatal("Unable to Fetch:", err)
+		} else if len(found) != 0 {
+			t.Fatalf("want len = %d, got len = %d:", 0, len(found))
+		}
+	})
+}
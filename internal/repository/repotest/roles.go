@@ -0,0 +1,218 @@
+package repotest
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"forum/internal/entity"
+)
+
+func RunRolesTests(t *testing.T, open Opener) {
+	t.Run("RoleBuiltin", func(t *testing.T) { testRoleBuiltin(t, open) })
+	t.Run("RoleStore", func(t *testing.T) { testRoleStore(t, open) })
+	t.Run("RoleUpdate", func(t *testing.T) { testRoleUpdate(t, open) })
+	t.Run("RoleUsers", func(t *testing.T) { testRoleUsers(t, open) })
+	t.Run("RoleDelete", func(t *testing.T) { testRoleDelete(t, open) })
+}
+
+func testRoleBuiltin(t *testing.T, open Opener) {
+	repos, closeDB := open(t)
+	defer closeDB()
+
+	roles, err := repos.Roles.Fetch(context.Background())
+	if err != nil {
+		t.Fatal("Unable to Fetch:", err)
+	}
+	want := []entity.Role{
+		{Id: entity.RoleUserId, Name: "Пользователь"},
+		{Id: entity.RoleModeratorId, Name: "Модератор", Permissions: []entity.Permission{
+			entity.PermBanUsers, entity.PermDeleteAnyPost, entity.PermEditAnyPost,
+		}},
+		{Id: entity.RoleAdminId, Name: "Администратор", Permissions: []entity.Permission{
+			entity.PermBanUsers, entity.PermDeleteAnyPost, entity.PermEditAnyPost, entity.PermManageBackups,
+			entity.PermManageCategories, entity.PermManageRoles, entity.PermManageUsers,
+		}},
+	}
+	if !reflect.DeepEqual(roles, want) {
+		t.Fatalf("want roles = %v, got roles = %v", want, roles)
+	}
+}
+
+func testRoleStore(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+
+		role := entity.Role{Name: "Curator", Permissions: []entity.Permission{
+			entity.PermManageCategories, entity.PermEditAnyPost,
+		}}
+		if err := repos.Roles.Store(ctx, &role); err != nil {
+			t.Fatal("Unable to Store:", err)
+		}
+		if role.Id != 4 {
+			t.Fatalf("want id = 4, got id = %d", role.Id)
+		}
+
+		found, err := repos.Roles.GetById(ctx, role.Id)
+		if err != nil {
+			t.Fatal("Unable to GetById:", err)
+		}
+		want := entity.Role{Id: 4, Name: "Curator", Permissions: []entity.Permission{
+			entity.PermEditAnyPost, entity.PermManageCategories,
+		}}
+		if !reflect.DeepEqual(found, want) {
+			t.Fatalf("want role = %v, got role = %v", want, found)
+		}
+	})
+
+	t.Run("err name taken", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+
+		role := entity.Role{Name: "Модератор"}
+		if err := repos.Roles.Store(ctx, &role); err == nil ||
+			!strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			t.Fatalf("want unique err, got err = %v", err)
+		}
+	})
+
+	t.Run("err not found", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+
+		if _, err := repos.Roles.GetById(ctx, 10); err == nil ||
+			!strings.Contains(err.Error(), "no rows in result set") {
+			t.Fatalf("want no rows err, got err = %v", err)
+		}
+	})
+}
+
+func testRoleUpdate(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+
+		role := entity.Role{Id: entity.RoleModeratorId, Name: "Редактор", Permissions: []entity.Permission{
+			entity.PermEditAnyPost,
+		}}
+		if err := repos.Roles.Update(ctx, role); err != nil {
+			t.Fatal("Unable to Update:", err)
+		}
+
+		found, err := repos.Roles.GetById(ctx, role.Id)
+		if err != nil {
+			t.Fatal("Unable to GetById:", err)
+		}
+		if !reflect.DeepEqual(found, role) {
+			t.Fatalf("want role = %v, got role = %v", role, found)
+		}
+	})
+
+	t.Run("err name taken", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+
+		role := entity.Role{Id: entity.RoleModeratorId, Name: "Администратор"}
+		if err := repos.Roles.Update(ctx, role); err == nil ||
+			!strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			t.Fatalf("want unique err, got err = %v", err)
+		}
+		found, err := repos.Roles.GetById(ctx, entity.RoleModeratorId)
+		if err != nil {
+			t.Fatal("Unable to GetById:", err)
+		}
+		if found.Name != "Модератор" || len(found.Permissions) != 3 {
+			t.Fatalf("failed update changed the role: %v", found)
+		}
+	})
+}
+
+func testRoleUsers(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	repos, closeDB := open(t)
+	defer closeDB()
+
+	for _, user := range []entity.User{
+		{Name: "Riddle", Email: "riddle@mail.ru"},
+		{Name: "Tom", Email: "tom@mail.ru"},
+		{Name: "Bob", Email: "bob@mail.ru"},
+	} {
+		if err := repos.Users.Store(ctx, user); err != nil {
+			t.Fatal("Unable to store user:", err)
+		}
+	}
+	for _, id := range []int64{1, 2} {
+		if err := repos.Users.SetRole(ctx, id, entity.RoleModeratorId); err != nil {
+			t.Fatal("Unable to SetRole:", err)
+		}
+	}
+
+	if count, err := repos.Roles.CountUsers(ctx, entity.RoleModeratorId); err != nil {
+		t.Fatal("Unable to CountUsers:", err)
+	} else if count != 2 {
+		t.Fatalf("want 2 moderators, got %d", count)
+	}
+
+	if err := repos.Roles.MoveUsers(ctx, entity.RoleModeratorId, entity.RoleAdminId); err != nil {
+		t.Fatal("Unable to MoveUsers:", err)
+	}
+	for id, want := range map[int64]int64{1: entity.RoleAdminId, 2: entity.RoleAdminId, 3: entity.RoleUserId} {
+		if user, err := repos.Users.GetById(ctx, id); err != nil {
+			t.Fatal("Unable to GetById:", err)
+		} else if user.Role.Id != want {
+			t.Fatalf("user %d: want role = %d, got role = %d", id, want, user.Role.Id)
+		}
+	}
+	if count, err := repos.Roles.CountUsers(ctx, entity.RoleModeratorId); err != nil {
+		t.Fatal("Unable to CountUsers:", err)
+	} else if count != 0 {
+		t.Fatalf("want no moderators, got %d", count)
+	}
+}
+
+func testRoleDelete(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+
+		role := entity.Role{Name: "Curator", Permissions: []entity.Permission{entity.PermManageCategories}}
+		if err := repos.Roles.Store(ctx, &role); err != nil {
+			t.Fatal("Unable to Store:", err)
+		}
+		if err := repos.Roles.Delete(ctx, role.Id); err != nil {
+			t.Fatal("Unable to Delete:", err)
+		}
+		if _, err := repos.Roles.GetById(ctx, role.Id); err == nil {
+			t.Fatal("want the role deleted, got nil err")
+		}
+
+		// The name is free again and the new role has no permissions left over.
+		role = entity.Role{Name: "Curator"}
+		if err := repos.Roles.Store(ctx, &role); err != nil {
+			t.Fatal("Unable to Store:", err)
+		}
+		if found, err := repos.Roles.GetById(ctx, role.Id); err != nil {
+			t.Fatal("Unable to GetById:", err)
+		} else if len(found.Permissions) != 0 {
+			t.Fatalf("want no permissions, got %v", found.Permissions)
+		}
+	})
+
+	t.Run("err not found", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+
+		if err := repos.Roles.Delete(ctx, 10); err == nil {
+			t.Fatal("want err, got nil")
+		}
+	})
+}
@@ -0,0 +1,173 @@
+package repotest
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"forum/internal/entity"
+)
+
+func RunSearchTests(t *testing.T, open Opener) {
+	t.Run("Search", func(t *testing.T) { testSearch(t, open) })
+	t.Run("SearchSync", func(t *testing.T) { testSearchSync(t, open) })
+}
+
+func searchIds(t *testing.T, repos searchRepo, terms ...string) map[int64]entity.SearchResult {
+	t.Helper()
+	ctx := context.Background()
+	results, err := repos.Search(ctx, terms, 10)
+	if err != nil {
+		t.Fatal("Unable to search:", err)
+	}
+	found := make(map[int64]entity.SearchResult)
+	for _, result := range results {
+		if prev, ok := found[result.Post.Id]; !ok || result.Rank > prev.Rank {
+			found[result.Post.Id] = result
+		}
+	}
+	return found
+}
+
+type searchRepo interface {
+	Search(ctx context.Context, terms []string, limit int) ([]entity.SearchResult, error)
+}
+
+func testSearch(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	repos, closeDB := open(t)
+	defer closeDB()
+
+	if err := repos.Users.Store(ctx, entity.User{Name: "Riddle", Email: "riddle@mail.ru"}); err != nil {
+		t.Fatal("Unable to store user:", err)
+	}
+
+	posts := []entity.Post{
+		{User: entity.User{Id: 1}, Date: at("2022-10-01"), Title: "Engines",
+			Content: "Audi builds a turbocharged engine."},
+		{User: entity.User{Id: 1}, Date: at("2022-10-02"), Title: "Audi review",
+			Content: "A long test drive of the new sedan."},
+		{User: entity.User{Id: 1}, Date: at("2022-10-03"), Title: "Football",
+			Content: "Nothing about cars here."},
+	}
+	for i := range posts {
+		if err := repos.Posts.Store(ctx, &posts[i]); err != nil {
+			t.Fatal("Unable to store post:", err)
+		}
+	}
+
+	comment := entity.Comment{PostId: posts[2].Id, User: entity.User{Id: 1},
+		Date: at("2022-10-04"), Content: "Still waiting for the Audi match"}
+	if err := repos.Comments.Store(ctx, &comment); err != nil {
+		t.Fatal("Unable to store comment:", err)
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		found := searchIds(t, repos.Posts, "audi")
+		if len(found) != 3 {
+			t.Fatalf("want: %d results, got: %d", 3, len(found))
+		}
+		if found[posts[1].Id].Rank <= found[posts[0].Id].Rank {
+			t.Fatalf("title match ranked %v, content match ranked %v",
+				found[posts[1].Id].Rank, found[posts[0].Id].Rank)
+		}
+		if snippet := found[posts[0].Id].Snippet; !strings.Contains(snippet, entity.MatchStart+"Audi"+entity.MatchEnd) {
+			t.Fatalf("match is not highlighted: %q", snippet)
+		}
+	})
+
+	t.Run("prefix and every term", func(t *testing.T) {
+		if found := searchIds(t, repos.Posts, "turbo"); len(found) != 1 {
+			t.Fatalf("want: %d results, got: %d", 1, len(found))
+		}
+		if found := searchIds(t, repos.Posts, "audi", "sedan"); len(found) != 1 {
+			t.Fatalf("want: %d results, got: %d", 1, len(found))
+		} else if _, ok := found[posts[1].Id]; !ok {
+			t.Fatalf("want post %d, got: %v", posts[1].Id, found)
+		}
+	})
+
+	t.Run("author", func(t *testing.T) {
+		if found := searchIds(t, repos.Posts, "riddle"); len(found) != 3 {
+			t.Fatalf("want: %d results, got: %d", 3, len(found))
+		}
+	})
+
+	t.Run("no results", func(t *testing.T) {
+		if found := searchIds(t, repos.Posts, "bicycle"); len(found) != 0 {
+			t.Fatalf("want: %d results, got: %d", 0, len(found))
+		}
+	})
+}
+
+func testSearchSync(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	repos, closeDB := open(t)
+	defer closeDB()
+	storeUsers(t, repos.Users, "Riddle")
+
+	post := entity.Post{User: entity.User{Id: 1}, Date: at("2022-10-01"),
+		Title: "Bicycles", Content: "Road bikes are light."}
+	if err := repos.Posts.Store(ctx, &post); err != nil {
+		t.Fatal("Unable to store post:", err)
+	}
+
+	t.Run("update", func(t *testing.T) {
+		post.Title = "Skates"
+		post.Content = "Inline skates are fast."
+		if err := repos.Posts.Update(ctx, post); err != nil {
+			t.Fatal("Unable to update:", err)
+		}
+		if found := searchIds(t, repos.Posts, "bicycles"); len(found) != 0 {
+			t.Fatalf("want: %d results, got: %d", 0, len(found))
+		}
+		if found := searchIds(t, repos.Posts, "skates"); len(found) != 1 {
+			t.Fatalf("want: %d results, got: %d", 1, len(found))
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		post.DeletedAt = at("2022-10-02 10:00:00")
+		if err := repos.Posts.Delete(ctx, post); err != nil {
+			t.Fatal("Unable to delete:", err)
+		}
+		if found := searchIds(t, repos.Posts, "skates"); len(found) != 0 {
+			t.Fatalf("want: %d results, got: %d", 0, len(found))
+		}
+	})
+
+	t.Run("comment", func(t *testing.T) {
+		other := entity.Post{User: entity.User{Id: 1}, Date: at("2022-10-02"),
+			Title: "Skiing", Content: "Fresh snow."}
+		if err := repos.Posts.Store(ctx, &other); err != nil {
+			t.Fatal("Unable to store post:", err)
+		}
+		comment := entity.Comment{PostId: other.Id, User: entity.User{Id: 1},
+			Date: at("2022-10-02"), Content: "Hockey tonight"}
+		if err := repos.Comments.Store(ctx, &comment); err != nil {
+			t.Fatal("Unable to store comment:", err)
+		}
+		if found := searchIds(t, repos.Posts, "hockey"); len(found) != 1 {
+			t.Fatalf("want: %d results, got: %d", 1, len(found))
+		}
+
+		comment.Id = 1
+		comment.Content = "Curling tonight"
+		if err := repos.Comments.Update(ctx, comment); err != nil {
+			t.Fatal("Unable to update comment:", err)
+		}
+		if found := searchIds(t, repos.Posts, "hockey"); len(found) != 0 {
+			t.Fatalf("want: %d results, got: %d", 0, len(found))
+		}
+
+		comment.DeletedAt = at("2022-10-03 10:00:00")
+		if err := repos.Comments.Delete(ctx, comment); err != nil {
+			t.Fatal("Unable to delete comment:", err)
+		}
+		if found := searchIds(t, repos.Posts, "curling"); len(found) != 0 {
+			t.Fatalf("want: %d results, got: %d", 0, len(found))
+		}
+	})
+}
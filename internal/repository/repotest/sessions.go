@@ -0,0 +1,208 @@
+package repotest
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"forum/internal/entity"
+	"forum/internal/repository"
+)
+
+func RunSessionsTests(t *testing.T, open Opener) {
+	t.Run("SessionStore", func(t *testing.T) { testSessionStore(t, open) })
+	t.Run("SessionFetchByUser", func(t *testing.T) { testSessionFetchByUser(t, open) })
+	t.Run("SessionUpdate", func(t *testing.T) { testSessionUpdate(t, open) })
+	t.Run("SessionDelete", func(t *testing.T) { testSessionDelete(t, open) })
+}
+
+// storeSessions stores Riddle and Tom, Riddle signed in from a laptop and
+// a phone and Tom from a laptop.
+func storeSessions(t *testing.T, repos *repository.Repositories) []entity.Session {
+	ctx := context.Background()
+	for _, user := range []entity.User{
+		{Name: "Riddle", Email: "riddle@mail.ru"},
+		{Name: "Tom", Email: "tom@mail.ru"},
+	} {
+		if err := repos.Users.Store(ctx, user); err != nil {
+			t.Fatal("Unable to store user:", err)
+		}
+	}
+
+	sessions := []entity.Session{
+		{User: entity.User{Id: 1}, Token: "laptop", UserAgent: "Firefox", IP: "10.0.0.1",
+			CreatedAt: at("2022-10-01 10:00:00"), LastSeen: at("2022-10-02 10:00:00"), ExpiresAt: at("2022-10-03")},
+		{User: entity.User{Id: 1}, Token: "phone", UserAgent: "Safari", IP: "10.0.0.2",
+			CreatedAt: at("2022-10-01 12:00:00"), LastSeen: at("2022-10-04 10:00:00"), ExpiresAt: at("2022-10-05")},
+		{User: entity.User{Id: 2}, Token: "tom", UserAgent: "Chrome", IP: "10.0.0.3",
+			CreatedAt: at("2022-10-01 14:00:00"), LastSeen: at("2022-10-01 14:00:00"), ExpiresAt: at("2022-10-02")},
+	}
+	for i := range sessions {
+		if err := repos.Sessions.Store(ctx, &sessions[i]); err != nil {
+			t.Fatal("Unable to store:", err)
+		}
+	}
+	return sessions
+}
+
+func testSessionStore(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		repo := repos.Sessions
+		sessions := storeSessions(t, repos)
+
+		if err := repos.Users.UpdateInfo(ctx, entity.User{Id: 1, Timezone: "Asia/Almaty"}); err != nil {
+			t.Fatal("Unable to UpdateInfo:", err)
+		}
+		if err := repos.Users.SetRole(ctx, 1, entity.RoleModeratorId); err != nil {
+			t.Fatal("Unable to SetRole:", err)
+		}
+		if err := repos.Users.SetBanned(ctx, 1, true); err != nil {
+			t.Fatal("Unable to SetBanned:", err)
+		}
+
+		found, err := repo.GetByToken(ctx, "phone")
+		if err != nil {
+			t.Fatal("Unable to GetByToken:", err)
+		}
+		want := sessions[1]
+		want.User.Timezone = "Asia/Almaty"
+		want.User.Role.Id = entity.RoleModeratorId
+		want.User.Banned = true
+		if want.Id != 2 || !reflect.DeepEqual(found, want) {
+			t.Fatalf("want session = %+v, got session = %+v:", want, found)
+		}
+	})
+
+	t.Run("err token taken", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeSessions(t, repos)
+
+		session := entity.Session{User: entity.User{Id: 2}, Token: "phone", CreatedAt: at("2022-10-01"),
+			LastSeen: at("2022-10-01"), ExpiresAt: at("2022-10-02")}
+		if err := repos.Sessions.Store(ctx, &session); err == nil || !strings.Contains(err.Error(), "UNIQUE") {
+			t.Fatalf("want unique constraint err, got err = %v:", err)
+		}
+	})
+
+	t.Run("err not found", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeSessions(t, repos)
+
+		if _, err := repos.Sessions.GetByToken(ctx, "tablet"); err == nil ||
+			!strings.Contains(err.Error(), "no rows in result set") {
+			t.Fatalf("want no rows err, got err = %v:", err)
+		}
+	})
+}
+
+func testSessionFetchByUser(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeSessions(t, repos)
+
+		found, err := repos.Sessions.FetchByUser(ctx, 1)
+		if err != nil {
+			t.Fatal("Unable to FetchByUser:", err)
+		}
+		var tokens []string
+		for _, session := range found {
+			tokens = append(tokens, session.Token)
+		}
+		if want := []string{"phone", "laptop"}; !reflect.DeepEqual(tokens, want) {
+			t.Fatalf("want tokens = %v, got tokens = %v:", want, tokens)
+		}
+
+		if found, err := repos.Sessions.FetchByUser(ctx, 3); err != nil {
+			t.Fatal("Unable to FetchByUser:", err)
+		} else if len(found) != 0 {
+			t.Fatalf("want len = %d, got len = %d:", 0, len(found))
+		}
+	})
+}
+
+func testSessionUpdate(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		sessions := storeSessions(t, repos)
+
+		laptop := sessions[0]
+		laptop.IP, laptop.LastSeen, laptop.ExpiresAt = "10.0.0.9", at("2022-10-06 10:00:00"), at("2022-10-07")
+		if err := repos.Sessions.Update(ctx, laptop); err != nil {
+			t.Fatal("Unable to Update:", err)
+		}
+
+		found, err := repos.Sessions.FetchByUser(ctx, 1)
+		if err != nil {
+			t.Fatal("Unable to FetchByUser:", err)
+		}
+		if len(found) != 2 || !reflect.DeepEqual(found[0], laptop) || !reflect.DeepEqual(found[1], sessions[1]) {
+			t.Fatalf("want laptop seen last, got sessions = %+v:", found)
+		}
+	})
+}
+
+func testSessionDelete(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeSessions(t, repos)
+
+		if err := repos.Sessions.Delete(ctx, 2); err != nil {
+			t.Fatal("Unable to Delete:", err)
+		}
+		if _, err := repos.Sessions.GetByToken(ctx, "phone"); err == nil {
+			t.Fatal("want phone signed out, got nil err")
+		}
+		if _, err := repos.Sessions.GetByToken(ctx, "laptop"); err != nil {
+			t.Fatal("Unable to GetByToken:", err)
+		}
+	})
+
+	t.Run("OK by user", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeSessions(t, repos)
+
+		if err := repos.Sessions.DeleteByUser(ctx, 1); err != nil {
+			t.Fatal("Unable to DeleteByUser:", err)
+		}
+		if found, err := repos.Sessions.FetchByUser(ctx, 1); err != nil {
+			t.Fatal("Unable to FetchByUser:", err)
+		} else if len(found) != 0 {
+			t.Fatalf("want len = %d, got len = %d:", 0, len(found))
+		}
+		if _, err := repos.Sessions.GetByToken(ctx, "tom"); err != nil {
+			t.Fatal("Unable to GetByToken:", err)
+		}
+	})
+
+	t.Run("OK expired", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeSessions(t, repos)
+
+		if deleted, err := repos.Sessions.DeleteExpired(ctx, at("2022-10-04")); err != nil {
+			t.Fatal("Unable to DeleteExpired:", err)
+		} else if deleted != 2 {
+			t.Fatalf("want deleted = %d, got deleted = %d:", 2, deleted)
+		}
+		if _, err := repos.Sessions.GetByToken(ctx, "phone"); err != nil {
+			t.Fatal("Unable to GetByToken:", err)
+		}
+	})
+}
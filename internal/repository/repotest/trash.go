@@ -0,0 +1,287 @@
+package repotest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"forum/internal/entity"
+	"forum/internal/repository"
+)
+
+func storeDeletedPosts(t *testing.T, repo repository.Posts, deletedAt ...string) {
+	t.Helper()
+	ctx := context.Background()
+	for i, when := range deletedAt {
+		post := entity.Post{User: entity.User{Id: 1}, Date: at("2022-10-01"), Title: "Cars", Content: "Lorem ipsum."}
+		if err := repo.Store(ctx, &post); err != nil {
+			t.Fatal("Unable to store:", err)
+		}
+		if when == "" {
+			continue
+		}
+		post.DeletedAt, post.DeletedBy, post.DeleteReason = at(when), 1, fmt.Sprint("reason ", i+1)
+		if err := repo.Delete(ctx, post); err != nil {
+			t.Fatal("Unable to Delete:", err)
+		}
+	}
+}
+
+func testPostRestore(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeUsers(t, repos.Users, "Riddle", "Subi")
+		repo := repos.Posts
+
+		storeDeletedPosts(t, repo, "2022-10-02 10:00:00")
+
+		if err := repo.Restore(ctx, 1); err != nil {
+			t.Fatal("Unable to Restore:", err)
+		}
+
+		if found, err := repo.GetById(ctx, 1); err != nil {
+			t.Fatal("Unable to GetById:", err)
+		} else if found.IsDeleted() || found.DeletedBy != 0 || found.DeleteReason != "" {
+			t.Fatalf("want restored post, got post = %v:", found)
+		}
+
+		if found, err := repo.Fetch(ctx); err != nil {
+			t.Fatal("Unable to Fetch:", err)
+		} else if len(found) != 1 {
+			t.Fatalf("want len = %d, got len = %d:", 1, len(found))
+		}
+	})
+
+	t.Run("NotDeleted", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeUsers(t, repos.Users, "Riddle", "Subi")
+		repo := repos.Posts
+
+		storeDeletedPosts(t, repo, "")
+
+		if err := repo.Restore(ctx, 1); err == nil {
+			t.Fatal("expected error")
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			t.Fatalf("want err = %v, got err = %v:", sql.ErrNoRows, err)
+		}
+	})
+}
+
+func testPostFetchDeleted(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeUsers(t, repos.Users, "Riddle", "Subi")
+		repo := repos.Posts
+
+		storeDeletedPosts(t, repo, "2022-10-02 10:00:00", "", "2022-10-03 10:00:00")
+
+		found, err := repo.FetchDeleted(ctx)
+		if err != nil {
+			t.Fatal("Unable to FetchDeleted:", err)
+		}
+		var ids []int64
+		for _, post := range found {
+			ids = append(ids, post.Id)
+		}
+		if want := []int64{3, 1}; !reflect.DeepEqual(ids, want) {
+			t.Fatalf("want ids = %v, got ids = %v:", want, ids)
+		}
+		if found[0].DeleteReason != "reason 3" || found[0].DeletedBy != 1 {
+			t.Fatalf("want deletion mark, got post = %v:", found[0])
+		}
+	})
+}
+
+func testPostPurge(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeUsers(t, repos.Users, "Riddle", "Subi")
+		repo := repos.Posts
+
+		storeDeletedPosts(t, repo, "2022-10-01 10:00:00", "2022-10-05 10:00:00", "")
+
+		if err := repos.Comments.Store(ctx, &entity.Comment{PostId: 1, User: entity.User{Id: 1}, Date: at("2022-10-01"),
+			Content: "Lorem ipsum."}); err != nil {
+			t.Fatal("Unable to store comment:", err)
+		}
+		storeReactions(t, repos.Reactions,
+			entity.Reaction{Target: entity.ReactionTargetPost, TargetId: 1, UserId: 1, Kind: "like"},
+			entity.Reaction{Target: entity.ReactionTargetPost, TargetId: 3, UserId: 1, Kind: "like"},
+			entity.Reaction{Target: entity.ReactionTargetComment, TargetId: 1, UserId: 1, Kind: "like"},
+		)
+		if err := repos.Revisions.Store(ctx, entity.Revision{Target: entity.RevisionTargetPost, TargetId: 1,
+			User: entity.User{Id: 1}, Date: at("2022-10-01"), Content: "Lorem ipsum."}); err != nil {
+			t.Fatal("Unable to store revision:", err)
+		}
+
+		if purged, err := repo.Purge(ctx, at("2022-10-03")); err != nil {
+			t.Fatal("Unable to Purge:", err)
+		} else if purged != 1 {
+			t.Fatalf("want purged = %d, got purged = %d:", 1, purged)
+		}
+
+		if _, err := repo.GetById(ctx, 1); !errors.Is(err, sql.ErrNoRows) {
+			t.Fatalf("want err = %v, got err = %v:", sql.ErrNoRows, err)
+		}
+		for _, id := range []int64{2, 3} {
+			if _, err := repo.GetById(ctx, id); err != nil {
+				t.Fatal("Unable to GetById:", err)
+			}
+		}
+
+		if _, err := repos.Comments.GetById(ctx, 1); !errors.Is(err, sql.ErrNoRows) {
+			t.Fatalf("want err = %v, got err = %v:", sql.ErrNoRows, err)
+		}
+
+		want := map[int64]map[string]int64{3: {"like": 1}}
+		if found, err := repos.Reactions.Count(ctx, entity.ReactionTargetPost, []int64{1, 3}); err != nil {
+			t.Fatal("Unable to count:", err)
+		} else if !reflect.DeepEqual(found, want) {
+			t.Fatalf("want counts = %v, got counts = %v:", want, found)
+		}
+		if found, err := repos.Reactions.Count(ctx, entity.ReactionTargetComment, []int64{1}); err != nil {
+			t.Fatal("Unable to count:", err)
+		} else if len(found) != 0 {
+			t.Fatalf("want len = %d, got len = %d:", 0, len(found))
+		}
+		if found, err := repos.Revisions.Fetch(ctx, entity.RevisionTargetPost, 1); err != nil {
+			t.Fatal("Unable to Fetch revisions:", err)
+		} else if len(found) != 0 {
+			t.Fatalf("want len = %d, got len = %d:", 0, len(found))
+		}
+	})
+}
+
+func testCommentRestore(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storePosts(t, repos, 1)
+		repo := repos.Comments
+
+		comment := entity.Comment{PostId: 1, User: entity.User{Id: 1}, Date: at("2022-10-01"), Content: "Lorem ipsum."}
+		if err := repo.Store(ctx, &comment); err != nil {
+			t.Fatal("Unable to store:", err)
+		}
+		comment.Id, comment.DeletedAt, comment.DeletedBy = 1, at("2022-10-02 10:00:00"), 1
+		if err := repo.Delete(ctx, comment); err != nil {
+			t.Fatal("Unable to Delete:", err)
+		}
+
+		if found, err := repo.FetchDeleted(ctx); err != nil {
+			t.Fatal("Unable to FetchDeleted:", err)
+		} else if len(found) != 1 || found[0].Id != 1 {
+			t.Fatalf("want deleted comment, got comments = %v:", found)
+		}
+
+		if err := repo.Restore(ctx, 1); err != nil {
+			t.Fatal("Unable to Restore:", err)
+		}
+
+		if found, err := repo.GetById(ctx, 1); err != nil {
+			t.Fatal("Unable to GetById:", err)
+		} else if found.IsDeleted() {
+			t.Fatalf("want restored comment, got comment = %v:", found)
+		}
+
+		if err := repo.Restore(ctx, 1); !errors.Is(err, sql.ErrNoRows) {
+			t.Fatalf("want err = %v, got err = %v:", sql.ErrNoRows, err)
+		}
+	})
+}
+
+func testCommentPurge(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storePosts(t, repos, 1)
+		repo := repos.Comments
+
+		for i, when := range []string{"2022-10-01 10:00:00", "2022-10-05 10:00:00", ""} {
+			comment := entity.Comment{PostId: 1, User: entity.User{Id: 1}, Date: at("2022-10-01"), Content: "Lorem ipsum."}
+			if err := repo.Store(ctx, &comment); err != nil {
+				t.Fatal("Unable to store:", err)
+			}
+			if when == "" {
+				continue
+			}
+			comment.Id, comment.DeletedAt, comment.DeletedBy = int64(i+1), at(when), 1
+			if err := repo.Delete(ctx, comment); err != nil {
+				t.Fatal("Unable to Delete:", err)
+			}
+		}
+
+		if purged, err := repo.Purge(ctx, at("2022-10-03")); err != nil {
+			t.Fatal("Unable to Purge:", err)
+		} else if purged != 1 {
+			t.Fatalf("want purged = %d, got purged = %d:", 1, purged)
+		}
+
+		found, err := repo.Fetch(ctx, 1)
+		if err != nil {
+			t.Fatal("Unable to Fetch:", err)
+		}
+		var ids []int64
+		for _, comment := range found {
+			ids = append(ids, comment.Id)
+		}
+		if want := []int64{2, 3}; !reflect.DeepEqual(ids, want) {
+			t.Fatalf("want ids = %v, got ids = %v:", want, ids)
+		}
+	})
+
+	t.Run("Replies", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storePosts(t, repos, 1)
+		repo := repos.Comments
+
+		// Comment 2 replies to 1, both get deleted.
+		for _, parentId := range []int64{0, 1} {
+			comment := entity.Comment{PostId: 1, ParentId: parentId, User: entity.User{Id: 1}, Date: at("2022-10-01"),
+				Content: "Lorem ipsum."}
+			if err := repo.Store(ctx, &comment); err != nil {
+				t.Fatal("Unable to store:", err)
+			}
+		}
+		deleted := entity.Comment{Id: 1, DeletedAt: at("2022-10-01 10:00:00"), DeletedBy: 1}
+		if err := repo.Delete(ctx, deleted); err != nil {
+			t.Fatal("Unable to Delete:", err)
+		}
+
+		if purged, err := repo.Purge(ctx, at("2022-10-03")); err != nil {
+			t.Fatal("Unable to Purge:", err)
+		} else if purged != 0 {
+			t.Fatalf("want purged = %d, got purged = %d:", 0, purged)
+		}
+
+		deleted.Id = 2
+		if err := repo.Delete(ctx, deleted); err != nil {
+			t.Fatal("Unable to Delete:", err)
+		}
+		for _, want := range []int64{1, 1, 0} {
+			if purged, err := repo.Purge(ctx, at("2022-10-03")); err != nil {
+				t.Fatal("Unable to Purge:", err)
+			} else if purged != want {
+				t.Fatalf("want purged = %d, got purged = %d:", want, purged)
+			}
+		}
+	})
+}
@@ -0,0 +1,317 @@
+package repotest
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"forum/internal/entity"
+	"forum/internal/repository"
+)
+
+func RunTwoFactorTests(t *testing.T, open Opener) {
+	t.Run("TwoFactorUpdate", func(t *testing.T) { testTwoFactorUpdate(t, open) })
+	t.Run("TwoFactorUseStep", func(t *testing.T) { testTwoFactorUseStep(t, open) })
+	t.Run("RecoveryCodes", func(t *testing.T) { testRecoveryCodes(t, open) })
+}
+
+func RunLoginChallengesTests(t *testing.T, open Opener) {
+	t.Run("ChallengeStore", func(t *testing.T) { testChallengeStore(t, open) })
+	t.Run("ChallengeAddAttempt", func(t *testing.T) { testChallengeAddAttempt(t, open) })
+	t.Run("ChallengeDelete", func(t *testing.T) { testChallengeDelete(t, open) })
+}
+
+// storeTwoFactor stores Riddle with two-factor enabled at step 100 and
+// Tom without it.
+func storeTwoFactor(t *testing.T, repos *repository.Repositories) {
+	ctx := context.Background()
+	for _, user := range []entity.User{
+		{Name: "Riddle", Email: "riddle@mail.ru"},
+		{Name: "Tom", Email: "tom@mail.ru"},
+	} {
+		if err := repos.Users.Store(ctx, user); err != nil {
+			t.Fatal("Unable to store user:", err)
+		}
+	}
+	if err := repos.TwoFactor.Update(ctx, entity.TwoFactor{UserId: 1, Secret: "SECRET", Enabled: true,
+		LastStep: 100}); err != nil {
+		t.Fatal("Unable to Update:", err)
+	}
+}
+
+func testTwoFactorUpdate(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeTwoFactor(t, repos)
+
+		want := entity.TwoFactor{UserId: 1, Secret: "SECRET", Enabled: true, LastStep: 100}
+		got, err := repos.TwoFactor.Get(ctx, 1)
+		if err != nil {
+			t.Fatal("Unable to Get:", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("want state = %+v, got state = %+v:", want, got)
+		}
+
+		user, err := repos.Users.GetById(ctx, 1)
+		if err != nil {
+			t.Fatal("Unable to GetById:", err)
+		}
+		if !user.TwoFactor {
+			t.Fatal("want user with two-factor enabled")
+		}
+		if user, _ = repos.Users.GetById(ctx, 2); user.TwoFactor {
+			t.Fatal("want user without two-factor")
+		}
+	})
+
+	t.Run("err not found", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeTwoFactor(t, repos)
+
+		if _, err := repos.TwoFactor.Get(ctx, 3); err == nil ||
+			!strings.Contains(err.Error(), "no rows in result set") {
+			t.Fatalf("want no rows err, got err = %v:", err)
+		}
+	})
+}
+
+func testTwoFactorUseStep(t *testing.T, open Opener) {
+	ctx := context.Background()
+	repos, closeDB := open(t)
+	defer closeDB()
+	storeTwoFactor(t, repos)
+
+	for _, tc := range []struct {
+		step int64
+		want bool
+	}{
+		{step: 99, want: false},
+		{step: 100, want: false},
+		{step: 101, want: true},
+		{step: 101, want: false},
+	} {
+		used, err := repos.TwoFactor.UseStep(ctx, 1, tc.step)
+		if err != nil {
+			t.Fatal("Unable to UseStep:", err)
+		}
+		if used != tc.want {
+			t.Fatalf("step %d: want used = %v, got used = %v:", tc.step, tc.want, used)
+		}
+	}
+}
+
+func testRecoveryCodes(t *testing.T, open Opener) {
+	ctx := context.Background()
+	repos, closeDB := open(t)
+	defer closeDB()
+	storeTwoFactor(t, repos)
+
+	if err := repos.TwoFactor.StoreRecoveryCodes(ctx, 1, []string{"old"}); err != nil {
+		t.Fatal("Unable to StoreRecoveryCodes:", err)
+	}
+	if err := repos.TwoFactor.StoreRecoveryCodes(ctx, 1, []string{"first", "second"}); err != nil {
+		t.Fatal("Unable to StoreRecoveryCodes:", err)
+	}
+	if err := repos.TwoFactor.StoreRecoveryCodes(ctx, 2, []string{"tom"}); err != nil {
+		t.Fatal("Unable to StoreRecoveryCodes:", err)
+	}
+
+	for _, tc := range []struct {
+		userId int64
+		hash   string
+		want   bool
+	}{
+		{userId: 1, hash: "old", want: false},
+		{userId: 1, hash: "tom", want: false},
+		{userId: 1, hash: "first", want: true},
+		{userId: 1, hash: "first", want: false},
+	} {
+		used, err := repos.TwoFactor.UseRecoveryCode(ctx, tc.userId, tc.hash)
+		if err != nil {
+			t.Fatal("Unable to UseRecoveryCode:", err)
+		}
+		if used != tc.want {
+			t.Fatalf("code %s: want used = %v, got used = %v:", tc.hash, tc.want, used)
+		}
+	}
+	if tf, _ := repos.TwoFactor.Get(ctx, 1); tf.RecoveryCodes != 1 {
+		t.Fatalf("want codes = %d, got codes = %d:", 1, tf.RecoveryCodes)
+	}
+
+	if err := repos.TwoFactor.DeleteRecoveryCodes(ctx, 1); err != nil {
+		t.Fatal("Unable to DeleteRecoveryCodes:", err)
+	}
+	if tf, _ := repos.TwoFactor.Get(ctx, 1); tf.RecoveryCodes != 0 {
+		t.Fatalf("want codes = %d, got codes = %d:", 0, tf.RecoveryCodes)
+	}
+	if tf, _ := repos.TwoFactor.Get(ctx, 2); tf.RecoveryCodes != 1 {
+		t.Fatalf("want codes = %d, got codes = %d:", 1, tf.RecoveryCodes)
+	}
+}
+
+// storeChallenges stores Riddle and Tom, Riddle signed in twice and Tom
+// once.
+func storeChallenges(t *testing.T, repos *repository.Repositories) []entity.LoginChallenge {
+	ctx := context.Background()
+	storeTwoFactor(t, repos)
+
+	challenges := []entity.LoginChallenge{
+		{User: entity.User{Id: 1}, TokenHash: "first", CreatedAt: at("2022-10-01 10:00:00"),
+			ExpiresAt: at("2022-10-01 10:05:00")},
+		{User: entity.User{Id: 1}, TokenHash: "second", CreatedAt: at("2022-10-02 10:00:00"),
+			ExpiresAt: at("2022-10-02 10:05:00")},
+		{User: entity.User{Id: 2}, TokenHash: "tom", CreatedAt: at("2022-10-01 12:00:00"),
+			ExpiresAt: at("2022-10-01 12:05:00")},
+	}
+	for i := range challenges {
+		if err := repos.Challenges.Store(ctx, &challenges[i]); err != nil {
+			t.Fatal("Unable to store:", err)
+		}
+	}
+	return challenges
+}
+
+func testChallengeStore(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		challenges := storeChallenges(t, repos)
+
+		if ok, err := repos.Challenges.AddAttempt(ctx, challenges[1].Id, 5); err != nil || !ok {
+			t.Fatal("Unable to AddAttempt:", ok, err)
+		}
+		challenges[1].Attempts++
+
+		found, err := repos.Challenges.GetByHash(ctx, "second")
+		if err != nil {
+			t.Fatal("Unable to GetByHash:", err)
+		}
+		if challenges[1].Id != 2 || !reflect.DeepEqual(found, challenges[1]) {
+			t.Fatalf("want challenge = %+v, got challenge = %+v:", challenges[1], found)
+		}
+	})
+
+	t.Run("err hash taken", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeChallenges(t, repos)
+
+		challenge := entity.LoginChallenge{User: entity.User{Id: 2}, TokenHash: "first",
+			CreatedAt: at("2022-10-01"), ExpiresAt: at("2022-10-02")}
+		if err := repos.Challenges.Store(ctx, &challenge); err == nil || !strings.Contains(err.Error(), "UNIQUE") {
+			t.Fatalf("want unique constraint err, got err = %v:", err)
+		}
+	})
+
+	t.Run("err not found", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeChallenges(t, repos)
+
+		if _, err := repos.Challenges.GetByHash(ctx, "third"); err == nil ||
+			!strings.Contains(err.Error(), "no rows in result set") {
+			t.Fatalf("want no rows err, got err = %v:", err)
+		}
+	})
+}
+
+func testChallengeAddAttempt(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		challenges := storeChallenges(t, repos)
+
+		for i := 1; i <= 3; i++ {
+			if ok, err := repos.Challenges.AddAttempt(ctx, challenges[0].Id, 3); err != nil || !ok {
+				t.Fatalf("attempt %d: want counted, got: %v, %v", i, ok, err)
+			}
+		}
+		if ok, err := repos.Challenges.AddAttempt(ctx, challenges[0].Id, 3); err != nil || ok {
+			t.Fatalf("want attempt over max refused, got: %v, %v", ok, err)
+		}
+
+		if found, err := repos.Challenges.GetByHash(ctx, "first"); err != nil {
+			t.Fatal("Unable to GetByHash:", err)
+		} else if found.Attempts != 3 {
+			t.Fatalf("want attempts = %d, got attempts = %d:", 3, found.Attempts)
+		}
+		if found, err := repos.Challenges.GetByHash(ctx, "second"); err != nil {
+			t.Fatal("Unable to GetByHash:", err)
+		} else if found.Attempts != 0 {
+			t.Fatalf("want attempts = %d, got attempts = %d:", 0, found.Attempts)
+		}
+	})
+
+	t.Run("err not found", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeChallenges(t, repos)
+
+		if ok, err := repos.Challenges.AddAttempt(ctx, 4, 3); err != nil || ok {
+			t.Fatalf("want missing challenge refused, got: %v, %v", ok, err)
+		}
+	})
+}
+
+func testChallengeDelete(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		challenges := storeChallenges(t, repos)
+
+		if err := repos.Challenges.Delete(ctx, challenges[0].Id); err != nil {
+			t.Fatal("Unable to Delete:", err)
+		}
+		if _, err := repos.Challenges.GetByHash(ctx, "first"); err == nil {
+			t.Fatal("want first deleted, got nil err")
+		}
+		if _, err := repos.Challenges.GetByHash(ctx, "second"); err != nil {
+			t.Fatal("Unable to GetByHash:", err)
+		}
+	})
+
+	t.Run("OK by user", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeChallenges(t, repos)
+
+		if err := repos.Challenges.DeleteByUser(ctx, 1); err != nil {
+			t.Fatal("Unable to DeleteByUser:", err)
+		}
+		for _, hash := range []string{"first", "second"} {
+			if _, err := repos.Challenges.GetByHash(ctx, hash); err == nil {
+				t.Fatalf("want %s deleted, got nil err", hash)
+			}
+		}
+		if _, err := repos.Challenges.GetByHash(ctx, "tom"); err != nil {
+			t.Fatal("Unable to GetByHash:", err)
+		}
+	})
+
+	t.Run("OK expired", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeChallenges(t, repos)
+
+		if deleted, err := repos.Challenges.DeleteExpired(ctx, at("2022-10-02")); err != nil {
+			t.Fatal("Unable to DeleteExpired:", err)
+		} else if deleted != 2 {
+			t.Fatalf("want deleted = %d, got deleted = %d:", 2, deleted)
+		}
+		if _, err := repos.Challenges.GetByHash(ctx, "second"); err != nil {
+			t.Fatal("Unable to GetByHash:", err)
+		}
+	})
+}
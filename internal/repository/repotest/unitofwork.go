@@ -0,0 +1,133 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"forum/internal/entity"
+	"forum/internal/repository"
+)
+
+func RunUnitOfWorkTests(t *testing.T, open Opener) {
+	t.Run("UnitOfWorkCommit", func(t *testing.T) { testUnitOfWorkCommit(t, open) })
+	t.Run("UnitOfWorkRollback", func(t *testing.T) { testUnitOfWorkRollback(t, open) })
+	t.Run("UnitOfWorkNested", func(t *testing.T) { testUnitOfWorkNested(t, open) })
+	t.Run("UnitOfWorkCanceled", func(t *testing.T) { testUnitOfWorkCanceled(t, open) })
+}
+
+var errAbort = errors.New("abort")
+
+func storePostAndComment(repos *repository.Repositories) error {
+	ctx := context.Background()
+	post := entity.Post{User: entity.User{Id: 1}, Date: at("2022-09-01"), Title: "Cars", Content: "Lorem ipsum."}
+	if err := repos.Posts.Store(ctx, &post); err != nil {
+		return err
+	}
+	comment := entity.Comment{PostId: post.Id, User: entity.User{Id: 1}, Date: at("2022-09-01"), Content: "Lorem ipsum."}
+	return repos.Comments.Store(ctx, &comment)
+}
+
+func countPostsAndComments(t *testing.T, repos *repository.Repositories) (int64, int64) {
+	t.Helper()
+	ctx := context.Background()
+	posts, err := repos.Posts.Count(ctx)
+	if err != nil {
+		t.Fatal("Unable to count posts:", err)
+	}
+	comments, err := repos.Comments.Count(ctx, 1)
+	if err != nil {
+		t.Fatal("Unable to count comments:", err)
+	}
+	return posts, comments
+}
+
+func testUnitOfWorkCommit(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeUsers(t, repos.Users, "Riddle")
+
+		if err := repos.UnitOfWork.Do(ctx, storePostAndComment); err != nil {
+			t.Fatal("Unable to do:", err)
+		}
+
+		if posts, comments := countPostsAndComments(t, repos); posts != 1 || comments != 1 {
+			t.Fatalf("want posts = 1, comments = 1, got posts = %d, comments = %d:", posts, comments)
+		}
+	})
+}
+
+func testUnitOfWorkRollback(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeUsers(t, repos.Users, "Riddle")
+
+		err := repos.UnitOfWork.Do(ctx, func(repos *repository.Repositories) error {
+			if err := storePostAndComment(repos); err != nil {
+				return err
+			}
+			return errAbort
+		})
+		if !errors.Is(err, errAbort) {
+			t.Fatalf("want err = %v, got err = %v:", errAbort, err)
+		}
+
+		if posts, comments := countPostsAndComments(t, repos); posts != 0 || comments != 0 {
+			t.Fatalf("want posts = 0, comments = 0, got posts = %d, comments = %d:", posts, comments)
+		}
+	})
+}
+
+func testUnitOfWorkNested(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeUsers(t, repos.Users, "Riddle")
+
+		err := repos.UnitOfWork.Do(ctx, func(repos *repository.Repositories) error {
+			if err := repos.UnitOfWork.Do(ctx, storePostAndComment); err != nil {
+				return err
+			}
+			return errAbort
+		})
+		if !errors.Is(err, errAbort) {
+			t.Fatalf("want err = %v, got err = %v:", errAbort, err)
+		}
+
+		if posts, comments := countPostsAndComments(t, repos); posts != 0 || comments != 0 {
+			t.Fatalf("want posts = 0, comments = 0, got posts = %d, comments = %d:", posts, comments)
+		}
+	})
+}
+
+func testUnitOfWorkCanceled(t *testing.T, open Opener) {
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeUsers(t, repos.Users, "Riddle")
+
+		ctx, cancel := context.WithCancel(context.Background())
+		err := repos.UnitOfWork.Do(ctx, func(repos *repository.Repositories) error {
+			if err := storePostAndComment(repos); err != nil {
+				return err
+			}
+			cancel()
+			return nil
+		})
+		if err == nil {
+			t.Fatal("want err, got nil")
+		}
+
+		if posts, comments := countPostsAndComments(t, repos); posts != 0 || comments != 0 {
+			t.Fatalf("want posts = 0, comments = 0, got posts = %d, comments = %d:", posts, comments)
+		}
+	})
+}
@@ -0,0 +1,555 @@
+package repotest
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"forum/internal/entity"
+)
+
+func RunUsersTests(t *testing.T, open Opener) {
+	t.Run("UserStore", func(t *testing.T) { testUserStore(t, open) })
+	t.Run("UserFetch", func(t *testing.T) { testUserFetch(t, open) })
+	t.Run("UserFetchByIds", func(t *testing.T) { testUserFetchByIds(t, open) })
+	t.Run("UserFetchPage", func(t *testing.T) { testUserFetchPage(t, open) })
+	t.Run("UserGetId", func(t *testing.T) { testUserGetId(t, open) })
+	t.Run("UserGetById", func(t *testing.T) { testUserGetById(t, open) })
+	t.Run("UserUpdateInfo", func(t *testing.T) { testUserUpdateInfo(t, open) })
+	t.Run("UserRoleAndBan", func(t *testing.T) { testUserRoleAndBan(t, open) })
+	t.Run("UpdatePassword", func(t *testing.T) { testUpdatePassword(t, open) })
+	t.Run("UserVerification", func(t *testing.T) { testUserVerification(t, open) })
+	t.Run("UserDelete", func(t *testing.T) { testUserDelete(t, open) })
+}
+
+func testUserStore(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	repos, closeDB := open(t)
+	defer closeDB()
+	repo := repos.Users
+	var err error
+
+	t.Run("OK", func(t *testing.T) {
+		user := entity.User{
+			Id:    1,
+			Name:  "Riddle",
+			Email: "Riddle@mail.ru",
+			City:  "Astana",
+			Role:  entity.Role{Id: entity.RoleUserId, Name: "Пользователь"},
+			Sign:  " ",
+		}
+
+		if err := repo.Store(ctx, user); err != nil {
+			t.Fatal("Unable to store:", err)
+		}
+
+		if id, err := repo.GetId(ctx, user); err != nil {
+			t.Fatal("Unable to GetId:", err)
+		} else if id != int64(1) {
+			t.Fatalf("ID=%v, want %v", 1, id)
+		}
+
+		if found, err := repo.GetById(ctx, 1); err != nil {
+			t.Fatal("Unable to GetById:", err)
+		} else if !reflect.DeepEqual(user, found) {
+			t.Fatalf("mismatch: %#v != %#v", user, found)
+		}
+
+		user2 := entity.User{
+			Name:  "buch",
+			Email: "buch@mak.go",
+		}
+		if err := repo.Store(ctx, user2); err != nil {
+			t.Fatal("Unable to Store:", err)
+		}
+
+		if id2, err := repo.GetId(ctx, user2); err != nil {
+			t.Fatal("Unable to GetId:", err)
+		} else if id2 != int64(2) {
+			t.Fatalf("ID=%v, want %v", 2, id2)
+		}
+	})
+
+	t.Run("ErrNameAlreadyExist", func(t *testing.T) {
+		user2 := entity.User{
+			Name: "Riddle",
+		}
+		if err = repo.Store(ctx, user2); err == nil {
+			t.Fatalf("Error expected")
+		} else if !strings.Contains(err.Error(), "UNIQUE constraint failed: users.name") {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func testUserFetch(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		repo := repos.Users
+		var err error
+
+		user := entity.User{
+			Name:  "Riddle",
+			Email: "Riddle@mail.ru",
+			City:  "Astana",
+		}
+
+		if err := repo.Store(ctx, user); err != nil {
+			t.Fatal("Unable to Store:", err)
+		}
+
+		if err := repo.Store(ctx, entity.User{Name: "adf"}); err != nil {
+			t.Fatal("Unable to Store:", err)
+		}
+
+		users, err := repo.Fetch(ctx)
+		if err != nil {
+			t.Fatal("Unable to Fetch:", err)
+		}
+		if len(users) != 2 {
+			t.Fatalf("want %d length, got %d length", 2, len(users))
+		}
+	})
+}
+
+func testUserFetchByIds(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		repo := repos.Users
+
+		for _, name := range []string{"Riddle", "Subi", "Tom"} {
+			user := entity.User{Name: name, Email: name + "@mail.ru"}
+			if err := repo.Store(ctx, user); err != nil {
+				t.Fatal("Unable to Store:", err)
+			}
+		}
+
+		users, err := repo.FetchByIds(ctx, []int64{3, 1, 7})
+		if err != nil {
+			t.Fatal("Unable to FetchByIds:", err)
+		}
+		var names []string
+		for _, user := range users {
+			names = append(names, user.Name)
+		}
+		if want := []string{"Riddle", "Tom"}; !reflect.DeepEqual(names, want) {
+			t.Fatalf("want names = %v, got names = %v:", want, names)
+		}
+	})
+}
+
+func testUserFetchPage(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	repos, closeDB := open(t)
+	defer closeDB()
+	repo := repos.Users
+
+	for _, name := range []string{"Riddle", "Subi", "Tom"} {
+		user := entity.User{Name: name, Email: name + "@mail.ru"}
+		if err := repo.Store(ctx, user); err != nil {
+			t.Fatal("Unable to Store:", err)
+		}
+	}
+
+	if count, err := repo.Count(ctx); err != nil {
+		t.Fatal("Unable to Count:", err)
+	} else if count != 3 {
+		t.Fatalf("want count = %d, got count = %d", 3, count)
+	}
+
+	if users, err := repo.FetchPage(ctx, 2, 1); err != nil {
+		t.Fatal("Unable to FetchPage:", err)
+	} else if len(users) != 2 || users[0].Name != "Subi" || users[1].Name != "Tom" {
+		t.Fatalf("unexpected page: %#v", users)
+	}
+
+	if users, err := repo.FetchAfter(ctx, 1, 1); err != nil {
+		t.Fatal("Unable to FetchAfter:", err)
+	} else if len(users) != 1 || users[0].Id != 2 {
+		t.Fatalf("unexpected users after cursor: %#v", users)
+	}
+}
+
+func testUserGetId(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		repo := repos.Users
+
+		if err := repo.Store(ctx, entity.User{Name: "qwe", Email: "hthth"}); err != nil {
+			t.Fatal("Unable to Store:", err)
+		}
+		if err := repo.Store(ctx, entity.User{Name: "sdf"}); err != nil {
+			t.Fatal("Unable to Store:", err)
+		}
+
+		user := entity.User{
+			Name:  "Subi",
+			Email: "Subi@mail.ru",
+			City:  "Astana",
+		}
+
+		if err := repo.Store(ctx, user); err != nil {
+			t.Fatal("Unable to Store:", err)
+		}
+		userToFindByName := entity.User{Name: "Subi"}
+		if id, err := repo.GetId(ctx, userToFindByName); err != nil {
+			t.Fatal("Unable to GetId:", err)
+		} else if id != 3 {
+			t.Fatalf("want id = %d, got id = %d", 3, id)
+		}
+
+		userToFindByEmail := entity.User{Email: "Subi@mail.ru"}
+		if id, err := repo.GetId(ctx, userToFindByEmail); err != nil {
+			t.Fatal("Unable to GetId:", err)
+		} else if id != 3 {
+			t.Fatalf("want id = %d, got id = %d", 3, id)
+		}
+	})
+}
+
+func testUserGetById(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		repo := repos.Users
+
+		if err := repo.Store(ctx, entity.User{Name: "Bobik", Email: "hthth"}); err != nil {
+			t.Fatal("Unable to Store:", err)
+		}
+		name := "Tuzik"
+		if err := repo.Store(ctx, entity.User{Name: name}); err != nil {
+			t.Fatal("Unable to Store:", err)
+		}
+
+		if foundUser, err := repo.GetById(ctx, 2); err != nil {
+			t.Fatal("Unable to GetById:", err)
+		} else if foundUser.Name != "Tuzik" {
+			t.Fatalf("want name = %v, got name = %v", name, foundUser.Name)
+		}
+	})
+}
+
+func testUserUpdateInfo(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		repo := repos.Users
+		var err error
+
+		user := entity.User{Name: "Bobik", Email: "hthth@dfg"}
+
+		if err := repo.Store(ctx, user); err != nil {
+			t.Fatal("Unable to Store:", err)
+		}
+
+		user.Id = 1
+		user.City = "Astana"
+		user.Role = entity.Role{Id: entity.RoleAdminId}
+
+		if err != repo.UpdateInfo(ctx, user) {
+			t.Fatal("Unable to UpdateInfo:", err)
+		}
+
+		if foundUser, err := repo.GetById(ctx, 1); err != nil {
+			t.Fatal("Unable to GetById:", err)
+		} else if foundUser.City != user.City {
+			t.Fatalf("want city = %v, got city = %v", user.City, foundUser.City)
+		} else if foundUser.Role.Id != entity.RoleUserId {
+			t.Fatalf("role changed by UpdateInfo: %v", foundUser.Role)
+		}
+	})
+}
+
+func testUserRoleAndBan(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	repos, closeDB := open(t)
+	defer closeDB()
+	repo := repos.Users
+
+	if err := repo.Store(ctx, entity.User{Name: "Bobik", Email: "hthth@dfg"}); err != nil {
+		t.Fatal("Unable to Store:", err)
+	}
+
+	if err := repo.SetRole(ctx, 1, entity.RoleModeratorId); err != nil {
+		t.Fatal("Unable to SetRole:", err)
+	}
+	if err := repo.SetBanned(ctx, 1, true); err != nil {
+		t.Fatal("Unable to SetBanned:", err)
+	}
+
+	found, err := repo.GetById(ctx, 1)
+	if err != nil {
+		t.Fatal("Unable to GetById:", err)
+	}
+	if found.Role.Id != entity.RoleModeratorId || found.Role.Name != "Модератор" {
+		t.Fatalf("role = %v, want the moderator", found.Role)
+	}
+	if !found.Banned {
+		t.Fatal("user is not banned")
+	}
+
+	users, err := repo.Fetch(ctx)
+	if err != nil {
+		t.Fatal("Unable to Fetch:", err)
+	}
+	if len(users) != 1 || users[0].Role.Name != "Модератор" {
+		t.Fatalf("listed users = %v, want the moderator", users)
+	}
+
+	if err := repo.SetBanned(ctx, 1, false); err != nil {
+		t.Fatal("Unable to SetBanned:", err)
+	}
+	if found, err := repo.GetById(ctx, 1); err != nil {
+		t.Fatal("Unable to GetById:", err)
+	} else if found.Banned {
+		t.Fatal("ban is not lifted")
+	}
+
+	if err := repo.SetRole(ctx, 2, entity.RoleModeratorId); err == nil {
+		t.Fatal("SetRole of a missing user: error expected")
+	}
+}
+
+func testUpdatePassword(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		repo := repos.Users
+		var err error
+
+		user := entity.User{Name: "Bobik", Email: "hthth@dfg", Password: "password!@#$%^"}
+
+		if err := repo.Store(ctx, user); err != nil {
+			t.Fatal("Unable to Store:", err)
+		}
+
+		user.Id = 1
+		user.Password = "NewPass!@#"
+
+		if err != repo.UpdatePassword(ctx, user) {
+			t.Fatal("Unable to UpdatePassword:", err)
+		}
+
+		if foundUser, err := repo.GetById(ctx, 1); err != nil {
+			t.Fatal("Unable to GetById:", err)
+		} else if foundUser.Password != user.Password {
+			t.Fatalf("want password = %v, got password = %v", user.Password, foundUser.Password)
+		}
+	})
+}
+
+func testUserVerification(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	repos, closeDB := open(t)
+	defer closeDB()
+	repo := repos.Users
+
+	if err := repo.Store(ctx, entity.User{Name: "Bobik", Email: "bobik@mail.ru"}); err != nil {
+		t.Fatal("Unable to Store:", err)
+	}
+	if err := repo.Store(ctx, entity.User{Name: "Riddle", Email: "riddle@mail.ru", EmailVerified: true}); err != nil {
+		t.Fatal("Unable to Store:", err)
+	}
+
+	t.Run("OK stored", func(t *testing.T) {
+		if found, err := repo.GetById(ctx, 1); err != nil {
+			t.Fatal("Unable to GetById:", err)
+		} else if found.EmailVerified {
+			t.Fatal("want the first user unverified")
+		}
+		if found, err := repo.GetById(ctx, 2); err != nil {
+			t.Fatal("Unable to GetById:", err)
+		} else if !found.EmailVerified {
+			t.Fatal("want the second user verified")
+		}
+	})
+
+	t.Run("OK pending", func(t *testing.T) {
+		sentAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+		user := entity.User{Id: 2, PendingEmail: "new@mail.ru", VerificationSentAt: sentAt}
+		if err := repo.UpdateVerification(ctx, user); err != nil {
+			t.Fatal("Unable to UpdateVerification:", err)
+		}
+
+		found, err := repo.GetById(ctx, 2)
+		if err != nil {
+			t.Fatal("Unable to GetById:", err)
+		}
+		if found.PendingEmail != user.PendingEmail || !found.VerificationSentAt.Equal(sentAt) {
+			t.Fatalf("want: %v %v, got: %v %v", user.PendingEmail, sentAt, found.PendingEmail,
+				found.VerificationSentAt)
+		}
+		if found.Email != "riddle@mail.ru" {
+			t.Fatalf("want email kept, got: %v", found.Email)
+		}
+	})
+
+	t.Run("ErrEmailTaken", func(t *testing.T) {
+		err := repo.VerifyEmail(ctx, 2, "bobik@mail.ru")
+		if err == nil {
+			t.Fatal("Expected error")
+		} else if !strings.Contains(err.Error(), "UNIQUE constraint failed: users.email") {
+			t.Fatalf("want unique error, got: %v", err)
+		}
+	})
+
+	t.Run("OK verify", func(t *testing.T) {
+		if err := repo.VerifyEmail(ctx, 2, "new@mail.ru"); err != nil {
+			t.Fatal("Unable to VerifyEmail:", err)
+		}
+		if err := repo.VerifyEmail(ctx, 1, "bobik@mail.ru"); err != nil {
+			t.Fatal("Unable to VerifyEmail:", err)
+		}
+
+		found, err := repo.GetById(ctx, 2)
+		if err != nil {
+			t.Fatal("Unable to GetById:", err)
+		}
+		if found.Email != "new@mail.ru" || found.PendingEmail != "" || !found.EmailVerified {
+			t.Fatalf("want the new email verified, got: %#v", found)
+		}
+		if found, err := repo.GetById(ctx, 1); err != nil {
+			t.Fatal("Unable to GetById:", err)
+		} else if !found.EmailVerified {
+			t.Fatal("want the first user verified")
+		}
+	})
+
+	t.Run("OK session", func(t *testing.T) {
+		session := entity.Session{User: entity.User{Id: 2}, Token: "verified-token"}
+		if err := repos.Sessions.Store(ctx, &session); err != nil {
+			t.Fatal("Unable to Store session:", err)
+		}
+		found, err := repos.Sessions.GetByToken(ctx, session.Token)
+		if err != nil {
+			t.Fatal("Unable to GetByToken:", err)
+		}
+		if !found.User.EmailVerified {
+			t.Fatal("want the session user verified")
+		}
+	})
+}
+
+func testUserDelete(t *testing.T, open Opener) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		repo := repos.Users
+		var err error
+
+		user := entity.User{Name: "Bobik", Email: "hthth@dfg", Password: "password!@#$%^"}
+
+		if err := repo.Store(ctx, user); err != nil {
+			t.Fatal("Unable to Store:", err)
+		}
+
+		if err = repo.Delete(ctx, entity.User{Id: 1}); err != nil {
+			t.Fatal("Unable to Delete:", err)
+		}
+
+		if _, err := repo.GetById(ctx, 1); err == nil {
+			t.Fatal("Expected error:")
+		}
+	})
+
+	t.Run("OK with content", func(t *testing.T) {
+		repos, closeDB := open(t)
+		defer closeDB()
+		storeUsers(t, repos.Users, "Riddle", "Subi")
+
+		if err := repos.Users.UpdateInfo(ctx, entity.User{Id: 1, AvatarPath: "riddle.png"}); err != nil {
+			t.Fatal("Unable to UpdateInfo:", err)
+		}
+		for _, userId := range []int64{1, 2} {
+			post := entity.Post{User: entity.User{Id: userId}, Date: at("2022-10-01"), Title: "Cars", Content: "Lorem."}
+			if err := repos.Posts.Store(ctx, &post); err != nil {
+				t.Fatal("Unable to store post:", err)
+			}
+		}
+		// The reply of Subi to Riddle on the post of Subi outlives the
+		// comment it replies to.
+		for _, comment := range []entity.Comment{
+			{PostId: 1, User: entity.User{Id: 2}, Date: at("2022-10-01"), Content: "Lorem."},
+			{PostId: 2, User: entity.User{Id: 1}, Date: at("2022-10-01"), Content: "Lorem."},
+			{PostId: 2, ParentId: 2, User: entity.User{Id: 2}, Date: at("2022-10-02"), Content: "Lorem."},
+		} {
+			if err := repos.Comments.Store(ctx, &comment); err != nil {
+				t.Fatal("Unable to store comment:", err)
+			}
+		}
+		if err := repos.Revisions.Store(ctx, entity.Revision{Target: entity.RevisionTargetPost, TargetId: 2,
+			User: entity.User{Id: 1}, Date: at("2022-10-02"), Content: "Lorem."}); err != nil {
+			t.Fatal("Unable to store revision:", err)
+		}
+		storeReactions(t, repos.Reactions,
+			entity.Reaction{Target: entity.ReactionTargetPost, TargetId: 2, UserId: 1, Kind: "like"})
+		if err := repos.Posts.Delete(ctx, entity.Post{Id: 2, DeletedAt: at("2022-10-03"), DeletedBy: 1}); err != nil {
+			t.Fatal("Unable to Delete:", err)
+		}
+		if err := repos.Resets.Store(ctx, &entity.PasswordReset{User: entity.User{Id: 1}, TokenHash: "hash",
+			CreatedAt: at("2022-10-01"), ExpiresAt: at("2022-10-02")}); err != nil {
+			t.Fatal("Unable to store reset:", err)
+		}
+
+		if err := repos.Reactions.DeleteByUser(ctx, 1); err != nil {
+			t.Fatal("Unable to DeleteByUser reactions:", err)
+		}
+		if err := repos.Resets.DeleteByUser(ctx, 1); err != nil {
+			t.Fatal("Unable to DeleteByUser resets:", err)
+		}
+		if err := repos.Posts.PurgeByUser(ctx, 1); err != nil {
+			t.Fatal("Unable to PurgeByUser posts:", err)
+		}
+		if err := repos.Comments.PurgeByUser(ctx, 1); err != nil {
+			t.Fatal("Unable to PurgeByUser comments:", err)
+		}
+		if err := repos.Revisions.DeleteByUser(ctx, 1); err != nil {
+			t.Fatal("Unable to DeleteByUser revisions:", err)
+		}
+		if err := repos.Users.Delete(ctx, entity.User{Id: 1}); err != nil {
+			t.Fatal("Unable to Delete:", err)
+		}
+
+		if _, err := repos.Posts.GetById(ctx, 1); err == nil {
+			t.Fatal("Expected error:")
+		}
+		if post, err := repos.Posts.GetById(ctx, 2); err != nil {
+			t.Fatal("Unable to GetById:", err)
+		} else if post.DeletedBy != 0 {
+			t.Fatalf("want deleted by = %d, got deleted by = %d:", 0, post.DeletedBy)
+		}
+		if found, err := repos.Comments.Fetch(ctx, 2); err != nil {
+			t.Fatal("Unable to Fetch:", err)
+		} else if len(found) != 1 || found[0].Id != 3 || found[0].ParentId != 0 {
+			t.Fatalf("want top level reply 3, got comments = %v:", found)
+		}
+		if found, err := repos.Revisions.Fetch(ctx, entity.RevisionTargetPost, 2); err != nil {
+			t.Fatal("Unable to Fetch revisions:", err)
+		} else if len(found) != 0 {
+			t.Fatalf("want len = %d, got len = %d:", 0, len(found))
+		}
+	})
+}
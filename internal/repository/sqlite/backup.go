@@ -0,0 +1,67 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"forum/internal/entity"
+	"forum/pkg/migrate"
+	"forum/pkg/sqlite3"
+)
+
+type BackupRepo struct {
+	*sqlite3.Sqlite
+}
+
+func NewBackupRepo(sq *sqlite3.Sqlite) *BackupRepo {
+	return &BackupRepo{sq}
+}
+
+func (br *BackupRepo) Backup(ctx context.Context, dest string) error {
+	if err := br.Sqlite.Backup(ctx, dest); err != nil {
+		return fmt.Errorf("BackupRepo - Backup - %w", err)
+	}
+	return nil
+}
+
+// Restore checks that the snapshot at src is an intact forum database the
+// application knows the schema of before it replaces the live one. A
+// snapshot of an older schema is migrated up after it is restored.
+func (br *BackupRepo) Restore(ctx context.Context, src string) error {
+	snapshot, err := sqlite3.New("file:"+src+"?mode=ro", sqlite3.Options{})
+	if err != nil {
+		return fmt.Errorf("BackupRepo - Restore - sqlite3.New: %w", err)
+	}
+	defer snapshot.Close()
+
+	var check string
+	err = snapshot.DB.QueryRowContext(ctx, `PRAGMA integrity_check`).Scan(&check)
+	if err != nil {
+		return fmt.Errorf("BackupRepo - Restore - integrity_check: %v: %w", err, entity.ErrBackupInvalid)
+	}
+	if check != "ok" {
+		return fmt.Errorf("BackupRepo - Restore - integrity_check: %s: %w", check, entity.ErrBackupInvalid)
+	}
+
+	var version int
+	err = snapshot.DB.QueryRowContext(ctx, `SELECT IFNULL(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	if err != nil {
+		return fmt.Errorf("BackupRepo - Restore - Version: %v: %w", err, entity.ErrBackupInvalid)
+	}
+	migrator := NewMigrator(br.Sqlite)
+	if version == 0 {
+		return fmt.Errorf("BackupRepo - Restore - no migrations applied: %w", entity.ErrBackupInvalid)
+	}
+	if version > migrator.Latest() {
+		return fmt.Errorf("BackupRepo - Restore - version %d, latest known %d: %w",
+			version, migrator.Latest(), migrate.ErrSchemaTooNew)
+	}
+
+	if err = br.Sqlite.Restore(ctx, src); err != nil {
+		return fmt.Errorf("BackupRepo - Restore - %w", err)
+	}
+	if err = migrator.Up(); err != nil {
+		return fmt.Errorf("BackupRepo - Restore - %w", err)
+	}
+	return nil
+}
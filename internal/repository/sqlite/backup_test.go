@@ -0,0 +1,117 @@
+package sqlite_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"forum/internal/entity"
+	"forum/internal/repository/sqlite"
+	"forum/pkg/migrate"
+)
+
+func TestBackupRestore(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		db := sqlite.MustOpenDB(t, "file:foobar?mode=memory&cache=shared")
+		defer sqlite.MustCloseDB(t, db)
+		if err := sqlite.CreateDB(db); err != nil {
+			t.Fatal("Unable to migrate:", err)
+		}
+		users := sqlite.NewUsersRepo(db)
+		if err := users.Store(ctx, entity.User{Name: "Riddle", Email: "Riddle@mail.ru"}); err != nil {
+			t.Fatal("Unable to Store:", err)
+		}
+
+		snapshot := filepath.Join(t.TempDir(), "forum.db")
+		if err := sqlite.NewBackupRepo(db).Backup(ctx, snapshot); err != nil {
+			t.Fatal("Unable to Backup:", err)
+		}
+		if err := users.Store(ctx, entity.User{Name: "Tom", Email: "Tom@mail.ru"}); err != nil {
+			t.Fatal("Unable to Store:", err)
+		}
+
+		if err := sqlite.NewBackupRepo(db).Restore(ctx, snapshot); err != nil {
+			t.Fatal("Unable to Restore:", err)
+		}
+		if found, err := users.Fetch(ctx); err != nil {
+			t.Fatal("Unable to Fetch:", err)
+		} else if len(found) != 1 || found[0].Name != "Riddle" {
+			t.Fatalf("want the backed up user only, got: %+v", found)
+		}
+	})
+
+	t.Run("older schema is migrated", func(t *testing.T) {
+		old := sqlite.MustOpenDB(t, "file:old?mode=memory&cache=shared")
+		defer sqlite.MustCloseDB(t, old)
+		if err := sqlite.NewMigrator(old).To(6); err != nil {
+			t.Fatal("Unable to migrate:", err)
+		}
+		snapshot := filepath.Join(t.TempDir(), "forum.db")
+		if err := sqlite.NewBackupRepo(old).Backup(ctx, snapshot); err != nil {
+			t.Fatal("Unable to Backup:", err)
+		}
+
+		db := sqlite.MustOpenDB(t, "file:foobar?mode=memory&cache=shared")
+		defer sqlite.MustCloseDB(t, db)
+		if err := sqlite.CreateDB(db); err != nil {
+			t.Fatal("Unable to migrate:", err)
+		}
+		if err := sqlite.NewBackupRepo(db).Restore(ctx, snapshot); err != nil {
+			t.Fatal("Unable to Restore:", err)
+		}
+		migrator := sqlite.NewMigrator(db)
+		if version, err := migrator.Version(); err != nil {
+			t.Fatal("Unable to get version:", err)
+		} else if version != migrator.Latest() {
+			t.Fatalf("want version = %d, got version = %d:", migrator.Latest(), version)
+		}
+	})
+
+	t.Run("err schema too new", func(t *testing.T) {
+		db := sqlite.MustOpenDB(t, "file:foobar?mode=memory&cache=shared")
+		defer sqlite.MustCloseDB(t, db)
+		if err := sqlite.CreateDB(db); err != nil {
+			t.Fatal("Unable to migrate:", err)
+		}
+
+		snapshot := filepath.Join(t.TempDir(), "forum.db")
+		newer := sqlite.MustOpenDB(t, snapshot)
+		defer sqlite.MustCloseDB(t, newer)
+		if err := sqlite.CreateDB(newer); err != nil {
+			t.Fatal("Unable to migrate:", err)
+		}
+		_, err := newer.DB.Exec(`
+		INSERT INTO schema_migrations(version, name, applied_at)
+			values(?, ?, ?)
+		`, sqlite.NewMigrator(newer).Latest()+1, "from_the_future", "2022-01-01 00:00:00")
+		if err != nil {
+			t.Fatal("Unable to insert:", err)
+		}
+
+		if err := sqlite.NewBackupRepo(db).Restore(ctx, snapshot); !errors.Is(err, migrate.ErrSchemaTooNew) {
+			t.Fatalf("want err = %v, got err = %v:", migrate.ErrSchemaTooNew, err)
+		}
+	})
+
+	t.Run("err not a forum database", func(t *testing.T) {
+		db := sqlite.MustOpenDB(t, "file:foobar?mode=memory&cache=shared")
+		defer sqlite.MustCloseDB(t, db)
+		if err := sqlite.CreateDB(db); err != nil {
+			t.Fatal("Unable to migrate:", err)
+		}
+
+		snapshot := filepath.Join(t.TempDir(), "other.db")
+		other := sqlite.MustOpenDB(t, snapshot)
+		defer sqlite.MustCloseDB(t, other)
+		if _, err := other.DB.Exec(`CREATE TABLE notes(id INTEGER PRIMARY KEY)`); err != nil {
+			t.Fatal("Unable to create:", err)
+		}
+
+		if err := sqlite.NewBackupRepo(db).Restore(ctx, snapshot); !errors.Is(err, entity.ErrBackupInvalid) {
+			t.Fatalf("want err = %v, got err = %v:", entity.ErrBackupInvalid, err)
+		}
+	})
+}
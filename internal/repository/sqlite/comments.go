@@ -1,8 +1,10 @@
 package sqlite
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 
 	"forum/internal/entity"
 	"forum/pkg/sqlite3"
@@ -16,8 +18,8 @@ func NewCommentsRepo(sq *sqlite3.Sqlite) *CommentsRepo {
 	return &CommentsRepo{sq}
 }
 
-func (cr *CommentsRepo) Store(comment entity.Comment) error {
-	tx, err := cr.DB.Begin()
+func (cr *CommentsRepo) Store(ctx context.Context, comment *entity.Comment) error {
+	tx, err := cr.Conn.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("CommentsRepo - Store - Begin: %w", err)
 	}
@@ -25,16 +27,18 @@ func (cr *CommentsRepo) Store(comment entity.Comment) error {
 		err = tx.Rollback()
 	}()
 
-	stmt, err := tx.Prepare(`
-	INSERT INTO comments(post_id, user_id, date, content) 
-		values(?, ?, ?, ?)
+	stmt, err := tx.PrepareContext(ctx, `
+	INSERT INTO comments(post_id, parent_id, user_id, date, content) 
+		values(?, ?, ?, ?, ?)
 	`)
 	if err != nil {
 		return fmt.Errorf("CommentsRepo - Store - Prepare: %w", err)
 	}
 	defer stmt.Close()
 
-	res, err := stmt.Exec(comment.PostId, comment.User.Id, comment.Date, comment.Content)
+	parentId := sql.NullInt64{Int64: comment.ParentId, Valid: comment.ParentId != 0}
+	res, err := stmt.ExecContext(ctx, comment.PostId, parentId, comment.User.Id, formatTime(comment.Date),
+		comment.Content)
 	if err != nil {
 		return fmt.Errorf("CommentsRepo - Store - Exec #1: %w", err)
 	}
@@ -47,19 +51,10 @@ func (cr *CommentsRepo) Store(comment entity.Comment) error {
 	if err != nil {
 		return fmt.Errorf("CommentsRepo - Store - LastInsertId: %w", err)
 	}
-	if comment.ImagePath != "" {
-		res, err = tx.Exec(`
-		INSERT INTO images(comment_id, path)
-			values(?, ?)
-	`, id, comment.ImagePath)
-
-		if err != nil {
-			return fmt.Errorf("CommentsRepo - Store - Exec #2: %w", err)
-		}
-		affected, err = res.RowsAffected()
-		if affected != 1 || err != nil {
-			return fmt.Errorf("CommentsRepo - Store - RowsAffected #2: %w", err)
-		}
+	comment.Id = id
+	err = storeImages(ctx, tx, "comment_id", id, comment.Images)
+	if err != nil {
+		return fmt.Errorf("CommentsRepo - Store - %w", err)
 	}
 
 	err = tx.Commit()
@@ -70,50 +65,187 @@ func (cr *CommentsRepo) Store(comment entity.Comment) error {
 	return nil
 }
 
-func (cr *CommentsRepo) Fetch(postId int64) ([]entity.Comment, error) {
-	var comments []entity.Comment
-
-	rows, err := cr.DB.Query(`
+// selectComments lists comments with their images and deletion marks,
+// callers append conditions, ordering and limits. Deleted comments stay
+// in the listings of a post so replies keep their place.
+const selectComments = `
 	SELECT
-		id, post_id, user_id, date, content,
-		(SELECT COUNT(*) FROM comment_likes WHERE comment_likes.comment_id = comments.id) AS comment_likes,
-		(SELECT COUNT(*) FROM comment_dislikes WHERE comment_dislikes.comment_id = comments.id) AS comment_dislikes,
-		(SELECT path FROM images WHERE images.comment_id = comments.id)
+		id, post_id, parent_id, user_id, date, content,
+		` + commentImages + `,
+		deleted_at, deleted_by, delete_reason, edited_at
 	FROM comments
+	`
+
+// Fetch lists every comment of the post, replies included, oldest first.
+func (cr *CommentsRepo) Fetch(ctx context.Context, postId int64) ([]entity.Comment, error) {
+	rows, err := cr.Conn.QueryContext(ctx, selectComments+`
 	WHERE post_id = ?
+	ORDER BY id
 	`, postId)
 	if err != nil {
 		return nil, fmt.Errorf("CommentsRepo - Fetch - Query: %w", err)
 	}
 	defer rows.Close()
+
+	comments, err := scanComments(rows)
+	if err != nil {
+		return nil, fmt.Errorf("CommentsRepo - Fetch - %w", err)
+	}
+	return comments, nil
+}
+
+func (cr *CommentsRepo) FetchByPosts(ctx context.Context, postIds []int64) (map[int64][]entity.Comment, error) {
+	byPost := make(map[int64][]entity.Comment, len(postIds))
+	if len(postIds) == 0 {
+		return byPost, nil
+	}
+
+	args := make([]interface{}, 0, len(postIds))
+	for _, id := range postIds {
+		args = append(args, id)
+	}
+
+	rows, err := cr.Conn.QueryContext(ctx, selectComments+`
+	WHERE post_id IN (?`+strings.Repeat(", ?", len(postIds)-1)+`)
+	ORDER BY id
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("CommentsRepo - FetchByPosts - Query: %w", err)
+	}
+	defer rows.Close()
+
+	comments, err := scanComments(rows)
+	if err != nil {
+		return nil, fmt.Errorf("CommentsRepo - FetchByPosts - %w", err)
+	}
+	for _, comment := range comments {
+		byPost[comment.PostId] = append(byPost[comment.PostId], comment)
+	}
+	return byPost, nil
+}
+
+// FetchReplies lists the replies under the comments at any depth, oldest
+// first. Only the threads of the comments are read, not the whole post.
+func (cr *CommentsRepo) FetchReplies(ctx context.Context, parentIds []int64) ([]entity.Comment, error) {
+	if len(parentIds) == 0 {
+		return nil, nil
+	}
+
+	args := make([]interface{}, 0, len(parentIds))
+	for _, id := range parentIds {
+		args = append(args, id)
+	}
+
+	rows, err := cr.Conn.QueryContext(ctx, `
+	WITH RECURSIVE thread(id) AS (
+		SELECT id FROM comments WHERE parent_id IN (?`+strings.Repeat(", ?", len(parentIds)-1)+`)
+		UNION
+		SELECT comments.id FROM comments JOIN thread ON comments.parent_id = thread.id
+	)`+selectComments+`
+	WHERE id IN (SELECT id FROM thread)
+	ORDER BY id
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("CommentsRepo - FetchReplies - Query: %w", err)
+	}
+	defer rows.Close()
+
+	comments, err := scanComments(rows)
+	if err != nil {
+		return nil, fmt.Errorf("CommentsRepo - FetchReplies - %w", err)
+	}
+	return comments, nil
+}
+
+// FetchPage lists top level comments of the post, replies are left to
+// FetchReplies.
+func (cr *CommentsRepo) FetchPage(ctx context.Context, postId int64, limit, offset int) ([]entity.Comment, error) {
+	rows, err := cr.Conn.QueryContext(ctx, selectComments+`
+	WHERE post_id = ? AND parent_id IS NULL
+	ORDER BY id
+	LIMIT ? OFFSET ?
+	`, postId, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("CommentsRepo - FetchPage - Query: %w", err)
+	}
+	defer rows.Close()
+
+	comments, err := scanComments(rows)
+	if err != nil {
+		return nil, fmt.Errorf("CommentsRepo - FetchPage - %w", err)
+	}
+	return comments, nil
+}
+
+// FetchAfter lists top level comments of the post with ids above cursor.
+func (cr *CommentsRepo) FetchAfter(ctx context.Context, postId, cursor int64, limit int) ([]entity.Comment, error) {
+	rows, err := cr.Conn.QueryContext(ctx, selectComments+`
+	WHERE post_id = ? AND parent_id IS NULL AND id > ?
+	ORDER BY id
+	LIMIT ?
+	`, postId, cursor, limit)
+	if err != nil {
+		return nil, fmt.Errorf("CommentsRepo - FetchAfter - Query: %w", err)
+	}
+	defer rows.Close()
+
+	comments, err := scanComments(rows)
+	if err != nil {
+		return nil, fmt.Errorf("CommentsRepo - FetchAfter - %w", err)
+	}
+	return comments, nil
+}
+
+// Count counts top level comments of the post.
+func (cr *CommentsRepo) Count(ctx context.Context, postId int64) (int64, error) {
+	var count int64
+	err := cr.Conn.QueryRowContext(ctx, `
+	SELECT COUNT(*)
+	FROM comments
+	WHERE post_id = ? AND parent_id IS NULL
+	`, postId).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("CommentsRepo - Count - Scan: %w", err)
+	}
+
+	return count, nil
+}
+
+func scanComments(rows *sql.Rows) ([]entity.Comment, error) {
+	var comments []entity.Comment
+
 	for rows.Next() {
 		var comment entity.Comment
-		var commentLikes sql.NullInt64
-		var commentDislikes sql.NullInt64
-		var imagePath sql.NullString
+		var images sql.NullString
+		var date, deletedAt, deleteReason, editedAt sql.NullString
+		var deletedBy, parentId sql.NullInt64
 
-		err = rows.Scan(&comment.Id, &comment.PostId, &comment.User.Id, &comment.Date, &comment.Content,
-			&commentLikes, &commentDislikes, &imagePath)
+		err := rows.Scan(&comment.Id, &comment.PostId, &parentId, &comment.User.Id, &date, &comment.Content,
+			&images, &deletedAt, &deletedBy, &deleteReason, &editedAt)
 		if err != nil {
-			return nil, fmt.Errorf("CommentsRepo - Fetch - Scan: %w", err)
+			return nil, fmt.Errorf("Scan: %w", err)
 		}
 
-		comment.TotalLikes = commentLikes.Int64
-		comment.TotalDislikes = commentDislikes.Int64
-		comment.ImagePath = imagePath.String
+		comment.ParentId = parentId.Int64
+		comment.Images = splitImages(images)
+		comment.Date = parseTime(date)
+		comment.DeletedAt = parseTime(deletedAt)
+		comment.DeletedBy = deletedBy.Int64
+		comment.DeleteReason = deleteReason.String
+		comment.EditedAt = parseTime(editedAt)
 		comments = append(comments, comment)
 	}
 	return comments, nil
 }
 
-func (cr *CommentsRepo) GetById(commentId int64) (entity.Comment, error) {
+func (cr *CommentsRepo) GetById(ctx context.Context, commentId int64) (entity.Comment, error) {
 	var comment entity.Comment
 
-	stmt, err := cr.DB.Prepare(`
+	stmt, err := cr.Conn.PrepareContext(ctx, `
 	SELECT
-		id, post_id, user_id, date, content,
-		(SELECT COUNT(*) FROM comment_likes WHERE comment_id = ?) AS comment_likes,
-		(SELECT COUNT(*) FROM comment_dislikes WHERE comment_id = ?) AS comment_dislikes
+		id, post_id, parent_id, user_id, date, content,
+		`+commentImages+`,
+		deleted_at, deleted_by, delete_reason, edited_at
 	FROM comments
 	WHERE id = ?
 	`)
@@ -121,21 +253,29 @@ func (cr *CommentsRepo) GetById(commentId int64) (entity.Comment, error) {
 		return comment, fmt.Errorf("CommentsRepo - GetById - Query: %w", err)
 	}
 	defer stmt.Close()
-	var commentLikes sql.NullInt64
-	var commentDislikes sql.NullInt64
-	err = stmt.QueryRow(commentId, commentId, commentId).Scan(&comment.Id, &comment.PostId, &comment.User.Id, &comment.Date, &comment.Content, &commentLikes, &commentDislikes)
+	var images sql.NullString
+	var date, deletedAt, deleteReason, editedAt sql.NullString
+	var deletedBy, parentId sql.NullInt64
+	err = stmt.QueryRowContext(ctx, commentId).Scan(&comment.Id, &comment.PostId, &parentId, &comment.User.Id,
+		&date, &comment.Content, &images, &deletedAt, &deletedBy, &deleteReason, &editedAt)
 	if err != nil {
 		return comment, fmt.Errorf("CommentsRepo - GetById - Scan: %w", err)
 	}
-
-	comment.TotalLikes = commentLikes.Int64
-	comment.TotalDislikes = commentDislikes.Int64
+	comment.ParentId = parentId.Int64
+	comment.Images = splitImages(images)
+	comment.Date = parseTime(date)
+	comment.DeletedAt = parseTime(deletedAt)
+	comment.DeletedBy = deletedBy.Int64
+	comment.DeleteReason = deleteReason.String
+	comment.EditedAt = parseTime(editedAt)
 
 	return comment, nil
 }
 
-func (cr *CommentsRepo) Update(comment entity.Comment) error {
-	tx, err := cr.DB.Begin()
+// Update overwrites the content of the comment and marks it edited at
+// comment.EditedAt. Images are replaced unless they are nil.
+func (cr *CommentsRepo) Update(ctx context.Context, comment entity.Comment) error {
+	tx, err := cr.Conn.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("CommentsRepo - Update - Begin: %w", err)
 	}
@@ -143,9 +283,9 @@ func (cr *CommentsRepo) Update(comment entity.Comment) error {
 		err = tx.Rollback()
 	}()
 
-	stmt, err := cr.DB.Prepare(`
+	stmt, err := tx.PrepareContext(ctx, `
 	UPDATE comments
-	SET content = ?
+	SET content = ?, edited_at = ?
 	WHERE id = ?
 	`)
 	if err != nil {
@@ -153,7 +293,7 @@ func (cr *CommentsRepo) Update(comment entity.Comment) error {
 	}
 	defer stmt.Close()
 
-	res, err := stmt.Exec(comment.Content, comment.Id)
+	res, err := stmt.ExecContext(ctx, comment.Content, nullTime(comment.EditedAt), comment.Id)
 	if err != nil {
 		return fmt.Errorf("CommentsRepo - Update - Exec: %w", err)
 	}
@@ -162,6 +302,12 @@ func (cr *CommentsRepo) Update(comment entity.Comment) error {
 	if affected != 1 || err != nil {
 		return fmt.Errorf("CommentsRepo - Update - RowsAffected: %w", err)
 	}
+	if comment.Images != nil {
+		err = replaceImages(ctx, tx, "comment_id", comment.Id, comment.Images)
+		if err != nil {
+			return fmt.Errorf("CommentsRepo - Update - %w", err)
+		}
+	}
 
 	err = tx.Commit()
 	if err != nil {
@@ -171,13 +317,13 @@ func (cr *CommentsRepo) Update(comment entity.Comment) error {
 	return nil
 }
 
-func (cr *CommentsRepo) GetPostIds(user entity.User) ([]int64, error) {
+func (cr *CommentsRepo) GetPostIds(ctx context.Context, user entity.User) ([]int64, error) {
 	var postIds []int64
 
-	rows, err := cr.DB.Query(`
+	rows, err := cr.Conn.QueryContext(ctx, `
 	SELECT DISTINCT post_id
 	FROM comments
-	WHERE user_id = ?
+	WHERE user_id = ? AND deleted_at IS NULL
 	`, user.Id)
 	if err != nil {
 		return nil, fmt.Errorf("PostsRepo - GetPostIds - Query: %w", err)
@@ -196,8 +342,10 @@ func (cr *CommentsRepo) GetPostIds(user entity.User) ([]int64, error) {
 	return postIds, nil
 }
 
-func (cr *CommentsRepo) Delete(comment entity.Comment) error {
-	tx, err := cr.DB.Begin()
+// Delete moves the comment to the trash, the deletion mark is taken from
+// the comment. Comments already in the trash are not found.
+func (cr *CommentsRepo) Delete(ctx context.Context, comment entity.Comment) error {
+	tx, err := cr.Conn.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("CommentsRepo - Delete - Begin: %w", err)
 	}
@@ -205,206 +353,34 @@ func (cr *CommentsRepo) Delete(comment entity.Comment) error {
 		err = tx.Rollback()
 	}()
 
-	stmt, err := cr.DB.Prepare(`
-	DELETE FROM comments
-	WHERE id = ?
+	stmt, err := tx.PrepareContext(ctx, `
+	UPDATE comments
+	SET deleted_at = ?, deleted_by = ?, delete_reason = ?
+	WHERE id = ? AND deleted_at IS NULL
 	`)
 	if err != nil {
 		return fmt.Errorf("CommentsRepo - Delete - Prepare: %w", err)
 	}
 	defer stmt.Close()
 
-	res, err := stmt.Exec(comment.Id)
+	res, err := stmt.ExecContext(ctx, nullTime(comment.DeletedAt), nullId(comment.DeletedBy), comment.DeleteReason,
+		comment.Id)
 	if err != nil {
 		return fmt.Errorf("CommentsRepo - Delete - Exec: %w", err)
 	}
 
 	affected, err := res.RowsAffected()
-	if affected != 1 || err != nil {
-		return fmt.Errorf("CommentsRepo - Delete - RowsAffected: %w", err)
-	}
-
-	err = tx.Commit()
-	if err != nil {
-		return fmt.Errorf("CommentsRepo - Delete - Commit: %w", err)
-	}
-
-	return nil
-}
-
-func (pr *CommentsRepo) StoreLike(comment entity.Comment) error {
-	tx, err := pr.DB.Begin()
-	if err != nil {
-		return fmt.Errorf("CommentsRepo - StoreLike - Begin: %w", err)
-	}
-	defer func() {
-		err = tx.Rollback()
-	}()
-
-	stmt, err := tx.Prepare(`
-	INSERT INTO comment_likes(comment_id, user_id, date) 
-	VALUES(?, ?, ?)
-	`)
-	if err != nil {
-		return err
-	}
-	defer stmt.Close()
-
-	res, err := stmt.Exec(comment.Id, comment.User.Id, getRegTime(DateFormat))
-	if err != nil {
-		return fmt.Errorf("CommentsRepo - StoreLike - Exec: %w", err)
-	}
-	affected, err := res.RowsAffected()
-	if affected != 1 || err != nil {
-		return fmt.Errorf("CommentsRepo - StoreLike - RowsAffected: %w", err)
-	}
-
-	err = tx.Commit()
-	if err != nil {
-		return fmt.Errorf("CommentsRepo - StoreLike - Commit: %w", err)
-	}
-	return nil
-}
-
-func (pr *CommentsRepo) DeleteLike(comment entity.Comment) error {
-	tx, err := pr.DB.Begin()
-	if err != nil {
-		return fmt.Errorf("CommentsRepo - DeleteLike - Begin: %w", err)
-	}
-	defer func() {
-		err = tx.Rollback()
-	}()
-
-	stmt, err := pr.DB.Prepare(`
-	DELETE FROM comment_likes
-	WHERE comment_id = ? AND user_id = ?
-	`)
-	if err != nil {
-		return fmt.Errorf("CommentsRepo - DeleteLike - Prepare: %w", err)
-	}
-	defer stmt.Close()
-
-	_, err = stmt.Exec(comment.Id, comment.User.Id)
-	if err != nil {
-		return fmt.Errorf("CommentsRepo - DeleteLike - Exec: %w", err)
-	}
-
-	err = tx.Commit()
-	if err != nil {
-		return fmt.Errorf("CommentsRepo - DeleteLike - Commit: %w", err)
-	}
-
-	return nil
-}
-
-func (pr *CommentsRepo) StoreDislike(comment entity.Comment) error {
-	tx, err := pr.DB.Begin()
-	if err != nil {
-		return fmt.Errorf("CommentsRepo - StoreDislike - Begin: %w", err)
-	}
-	defer func() {
-		err = tx.Rollback()
-	}()
-
-	stmt, err := tx.Prepare(`
-	INSERT INTO comment_dislikes(comment_id, user_id, date) 
-	VALUES(?, ?, ?)
-	`)
-	if err != nil {
-		return err
-	}
-	defer stmt.Close()
-
-	res, err := stmt.Exec(comment.Id, comment.User.Id, getRegTime(DateFormat))
-	if err != nil {
-		return fmt.Errorf("CommentsRepo - StoreDislike - Exec: %w", err)
-	}
-	affected, err := res.RowsAffected()
-	if affected != 1 || err != nil {
-		return fmt.Errorf("CommentsRepo - StoreDislike - RowsAffected: %w", err)
-	}
-
-	err = tx.Commit()
-	if err != nil {
-		return fmt.Errorf("CommentsRepo - StoreDislike - Commit: %w", err)
-	}
-	return nil
-}
-
-func (pr *CommentsRepo) DeleteDislike(comment entity.Comment) error {
-	tx, err := pr.DB.Begin()
-	if err != nil {
-		return fmt.Errorf("CommentsRepo - DeleteDislike - Begin: %w", err)
-	}
-	defer func() {
-		err = tx.Rollback()
-	}()
-
-	stmt, err := pr.DB.Prepare(`
-	DELETE FROM comment_dislikes
-	WHERE comment_id = ? AND user_id = ?
-	`)
 	if err != nil {
-		return fmt.Errorf("CommentsRepo - DeleteDislike - Prepare: %w", err)
+		return fmt.Errorf("CommentsRepo - Delete - RowsAffected: %w", err)
 	}
-	defer stmt.Close()
-
-	_, err = stmt.Exec(comment.Id, comment.User.Id)
-	if err != nil {
-		return fmt.Errorf("CommentsRepo - DeleteDislike - Exec: %w", err)
+	if affected != 1 {
+		return fmt.Errorf("CommentsRepo - Delete - RowsAffected: %w", sql.ErrNoRows)
 	}
 
 	err = tx.Commit()
 	if err != nil {
-		return fmt.Errorf("CommentsRepo - DeleteDislike - Commit: %w", err)
+		return fmt.Errorf("CommentsRepo - Delete - Commit: %w", err)
 	}
 
 	return nil
 }
-
-func (pr *CommentsRepo) FetchReactions(id int64) (entity.Comment, error) {
-	var comment entity.Comment
-	var likes []entity.Reaction
-	var dislikes []entity.Reaction
-
-	rowsLikes, err := pr.DB.Query(`
-		SELECT user_id, date
-		FROM comment_likes
-		WHERE comment_id = ?
-	`, id)
-	if err != nil {
-		return comment, fmt.Errorf("CommentsRepo - FetchReactions - likes - Query: %w", err)
-	}
-	defer rowsLikes.Close()
-
-	for rowsLikes.Next() {
-		var like entity.Reaction
-		err = rowsLikes.Scan(&like.UserId, &like.Date)
-		if err != nil {
-			return comment, fmt.Errorf("CommentsRepo - FetchReactions - likes - Scan: %w", err)
-		}
-		likes = append(likes, like)
-	}
-
-	rowsDislikes, err := pr.DB.Query(`
-		SELECT user_id, date
-		FROM comment_dislikes
-		WHERE comment_id = ?
-	`, id)
-	if err != nil {
-		return comment, fmt.Errorf("CommentsRepo - FetchReactions - dislikes - Query: %w", err)
-	}
-	defer rowsDislikes.Close()
-
-	for rowsDislikes.Next() {
-		var dislike entity.Reaction
-		err = rowsDislikes.Scan(&dislike.UserId, &dislike.Date)
-		if err != nil {
-			return comment, fmt.Errorf("CommentsRepo - FetchReactions - dislikes - Scan: %w", err)
-		}
-		dislikes = append(dislikes, dislike)
-	}
-	comment.Likes = append(comment.Likes, likes...)
-	comment.Dislikes = append(comment.Dislikes, dislikes...)
-	return comment, nil
-}
@@ -0,0 +1,53 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"forum/pkg/sqlite3"
+)
+
+type CountersRepo struct {
+	*sqlite3.Sqlite
+}
+
+func NewCountersRepo(sq *sqlite3.Sqlite) *CountersRepo {
+	return &CountersRepo{sq}
+}
+
+// Recount rebuilds every counter from the rows it counts. Triggers of the
+// counters migration keep them in step afterwards.
+func (cr *CountersRepo) Recount(ctx context.Context) error {
+	tx, err := cr.Conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("CountersRepo - Recount - Begin: %w", err)
+	}
+	defer func() {
+		err = tx.Rollback()
+	}()
+
+	_, err = tx.ExecContext(ctx, `
+	DELETE FROM reaction_counts;
+	INSERT INTO reaction_counts(target, target_id, kind, count)
+		SELECT target, target_id, kind, COUNT(*) FROM reactions GROUP BY target, target_id, kind;
+
+	DELETE FROM user_reaction_counts;
+	INSERT INTO user_reaction_counts(user_id, target, kind, count)
+		SELECT user_id, target, kind, COUNT(*) FROM reactions GROUP BY user_id, target, kind;
+
+	UPDATE posts SET comment_count = (SELECT COUNT(*) FROM comments
+		WHERE comments.post_id = posts.id AND comments.deleted_at IS NULL);
+	UPDATE users SET
+		post_count = (SELECT COUNT(*) FROM posts WHERE posts.user_id = users.id AND posts.deleted_at IS NULL),
+		comment_count = (SELECT COUNT(*) FROM comments WHERE comments.user_id = users.id AND comments.deleted_at IS NULL);
+	`)
+	if err != nil {
+		return fmt.Errorf("CountersRepo - Recount - Exec: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("CountersRepo - Recount - Commit: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"forum/pkg/sqlconn"
+)
+
+// postImages and commentImages select the image paths of a post or a
+// comment joined by newlines in upload order, posts and comments have to
+// be in the query under their own names.
+const (
+	postImages = `(SELECT group_concat(path, char(10)) FROM (
+		SELECT path FROM images WHERE images.post_id = posts.id ORDER BY images.id))`
+	commentImages = `(SELECT group_concat(path, char(10)) FROM (
+		SELECT path FROM images WHERE images.comment_id = comments.id ORDER BY images.id))`
+)
+
+// splitImages reads the paths selected by postImages or commentImages.
+func splitImages(paths sql.NullString) []string {
+	if paths.String == "" {
+		return nil
+	}
+	return strings.Split(paths.String, "\n")
+}
+
+// storeImages attaches the images to the post or the comment in order,
+// column is the images column referencing it.
+func storeImages(ctx context.Context, tx sqlconn.Tx, column string, id int64, paths []string) error {
+	for _, path := range paths {
+		_, err := tx.ExecContext(ctx, `INSERT INTO images(`+column+`, path) VALUES(?, ?)`, id, path)
+		if err != nil {
+			return fmt.Errorf("storeImages: %w", err)
+		}
+	}
+	return nil
+}
+
+// replaceImages drops the images of the post or the comment and attaches
+// paths instead.
+func replaceImages(ctx context.Context, tx sqlconn.Tx, column string, id int64, paths []string) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM images WHERE `+column+` = ?`, id)
+	if err != nil {
+		return fmt.Errorf("replaceImages: %w", err)
+	}
+	return storeImages(ctx, tx, column, id, paths)
+}
@@ -0,0 +1,687 @@
+package sqlite
+
+import (
+	"forum/pkg/migrate"
+	"forum/pkg/sqlite3"
+)
+
+// Migrations holds every schema change of the sqlite database in order.
+// New versions are appended to the end, applied ones are never edited.
+var Migrations = []migrate.Migration{
+	{
+		Version: 1,
+		Name:    "initial_schema",
+		Up: `
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE,
+			email TEXT NOT NULL UNIQUE,
+			password TEXT,
+			reg_date TEXT,
+			date_of_birth TEXT,
+			city TEXT,
+			sex TEXT,
+			role TEXT,
+			sign TEXT,
+			session_token TEXT,
+			session_ttl TEXT
+			);
+
+		CREATE TABLE IF NOT EXISTS posts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER,
+			date TEXT NOT NULL,
+			title TEXT NOT NULL,
+			content TEXT NOT NULL,
+			FOREIGN KEY (user_id) REFERENCES users(id)
+			);
+
+		CREATE TABLE IF NOT EXISTS comments (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			post_id INTEGER,
+			user_id INTEGER,
+			date TEXT NOT NULL,
+			content TEXT NOT NULL,
+			FOREIGN KEY (post_id) REFERENCES posts(id),
+			FOREIGN KEY (user_id) REFERENCES users(id)
+			);
+
+		CREATE TABLE IF NOT EXISTS post_likes (
+			post_id INTEGER,
+			user_id INTEGER,
+			date TEXT,
+			PRIMARY KEY(post_id, user_id),
+			FOREIGN KEY (post_id) REFERENCES posts(id),
+			FOREIGN KEY (user_id) REFERENCES users(id)
+			);
+
+		CREATE TABLE IF NOT EXISTS post_dislikes (
+			post_id INTEGER,
+			user_id INTEGER,
+			date TEXT,
+			PRIMARY KEY(post_id, user_id),
+			FOREIGN KEY (post_id) REFERENCES posts(id),
+			FOREIGN KEY (user_id) REFERENCES users(id)
+			);
+
+		CREATE TABLE IF NOT EXISTS comment_likes (
+			comment_id INTEGER,
+			user_id INTEGER,
+			date TEXT,
+			PRIMARY KEY(comment_id, user_id),
+			FOREIGN KEY (comment_id) REFERENCES comments(id),
+			FOREIGN KEY (user_id) REFERENCES users(id)
+			);
+
+		CREATE TABLE IF NOT EXISTS comment_dislikes (
+			comment_id INTEGER,
+			user_id INTEGER,
+			date TEXT,
+			PRIMARY KEY(comment_id, user_id),
+			FOREIGN KEY (comment_id) REFERENCES comments(id),
+			FOREIGN KEY (user_id) REFERENCES users(id)
+			);
+
+		CREATE TABLE IF NOT EXISTS topics (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT UNIQUE
+			);
+
+		CREATE TABLE IF NOT EXISTS reference_topic (
+			post_id INTEGER,
+			topic TEXT,
+			PRIMARY KEY (post_id, topic),
+			FOREIGN KEY (post_id) REFERENCES posts(id)
+			);
+
+		CREATE TABLE IF NOT EXISTS images (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			post_id INTEGER,
+			comment_id INTEGER,
+			user_id INTEGER,
+			path TEXT,
+			FOREIGN KEY (post_id) REFERENCES posts(id),
+			FOREIGN KEY (comment_id) REFERENCES comments(id),
+			FOREIGN KEY (user_id) REFERENCES users(id)
+			);
+		`,
+		Down: `
+		DROP TABLE IF EXISTS images;
+		DROP TABLE IF EXISTS reference_topic;
+		DROP TABLE IF EXISTS topics;
+		DROP TABLE IF EXISTS comment_dislikes;
+		DROP TABLE IF EXISTS comment_likes;
+		DROP TABLE IF EXISTS post_dislikes;
+		DROP TABLE IF EXISTS post_likes;
+		DROP TABLE IF EXISTS comments;
+		DROP TABLE IF EXISTS posts;
+		DROP TABLE IF EXISTS users;
+		`,
+	},
+	{
+		Version: 2,
+		Name:    "search_index",
+		Up: searchIndexTables + `
+		CREATE TRIGGER posts_search_insert AFTER INSERT ON posts BEGIN
+			INSERT INTO posts_search(rowid, title, content, author)
+				VALUES (new.id, new.title, new.content, (SELECT name FROM users WHERE id = new.user_id));
+		END;
+
+		CREATE TRIGGER posts_search_update AFTER UPDATE OF title, content ON posts BEGIN
+			UPDATE posts_search
+			SET title = new.title, content = new.content
+			WHERE rowid = new.id;
+		END;
+
+		CREATE TRIGGER posts_search_delete AFTER DELETE ON posts BEGIN
+			DELETE FROM posts_search WHERE rowid = old.id;
+		END;
+
+		CREATE TRIGGER comments_search_insert AFTER INSERT ON comments BEGIN
+			INSERT INTO comments_search(rowid, content, author)
+				VALUES (new.id, new.content, (SELECT name FROM users WHERE id = new.user_id));
+		END;
+
+		CREATE TRIGGER comments_search_update AFTER UPDATE OF content ON comments BEGIN
+			UPDATE comments_search
+			SET content = new.content
+			WHERE rowid = new.id;
+		END;
+
+		CREATE TRIGGER comments_search_delete AFTER DELETE ON comments BEGIN
+			DELETE FROM comments_search WHERE rowid = old.id;
+		END;
+		` + searchIndexFill,
+		Down: `
+		DROP TRIGGER IF EXISTS comments_search_delete;
+		DROP TRIGGER IF EXISTS comments_search_update;
+		DROP TRIGGER IF EXISTS comments_search_insert;
+		DROP TRIGGER IF EXISTS posts_search_delete;
+		DROP TRIGGER IF EXISTS posts_search_update;
+		DROP TRIGGER IF EXISTS posts_search_insert;
+		DROP TABLE IF EXISTS comments_search;
+		DROP TABLE IF EXISTS posts_search;
+		`,
+	},
+	{
+		Version: 3,
+		Name:    "reactions",
+		Up: `
+		CREATE TABLE IF NOT EXISTS reactions (
+			target TEXT NOT NULL,
+			target_id INTEGER NOT NULL,
+			user_id INTEGER NOT NULL,
+			kind TEXT NOT NULL,
+			date TEXT,
+			PRIMARY KEY(target, target_id, user_id, kind),
+			FOREIGN KEY (user_id) REFERENCES users(id)
+			);
+
+		CREATE INDEX IF NOT EXISTS reactions_user ON reactions(user_id, target, kind);
+
+		INSERT INTO reactions(target, target_id, user_id, kind, date)
+			SELECT 'post', post_id, user_id, 'like', date FROM post_likes
+			WHERE post_id IS NOT NULL AND user_id IS NOT NULL;
+		INSERT INTO reactions(target, target_id, user_id, kind, date)
+			SELECT 'post', post_id, user_id, 'dislike', date FROM post_dislikes
+			WHERE post_id IS NOT NULL AND user_id IS NOT NULL;
+		INSERT INTO reactions(target, target_id, user_id, kind, date)
+			SELECT 'comment', comment_id, user_id, 'like', date FROM comment_likes
+			WHERE comment_id IS NOT NULL AND user_id IS NOT NULL;
+		INSERT INTO reactions(target, target_id, user_id, kind, date)
+			SELECT 'comment', comment_id, user_id, 'dislike', date FROM comment_dislikes
+			WHERE comment_id IS NOT NULL AND user_id IS NOT NULL;
+
+		DROP TABLE post_likes;
+		DROP TABLE post_dislikes;
+		DROP TABLE comment_likes;
+		DROP TABLE comment_dislikes;
+		`,
+		// Only likes and dislikes fit the old tables, other kinds are lost.
+		Down: `
+		CREATE TABLE post_likes (
+			post_id INTEGER,
+			user_id INTEGER,
+			date TEXT,
+			PRIMARY KEY(post_id, user_id),
+			FOREIGN KEY (post_id) REFERENCES posts(id),
+			FOREIGN KEY (user_id) REFERENCES users(id)
+			);
+
+		CREATE TABLE post_dislikes (
+			post_id INTEGER,
+			user_id INTEGER,
+			date TEXT,
+			PRIMARY KEY(post_id, user_id),
+			FOREIGN KEY (post_id) REFERENCES posts(id),
+			FOREIGN KEY (user_id) REFERENCES users(id)
+			);
+
+		CREATE TABLE comment_likes (
+			comment_id INTEGER,
+			user_id INTEGER,
+			date TEXT,
+			PRIMARY KEY(comment_id, user_id),
+			FOREIGN KEY (comment_id) REFERENCES comments(id),
+			FOREIGN KEY (user_id) REFERENCES users(id)
+			);
+
+		CREATE TABLE comment_dislikes (
+			comment_id INTEGER,
+			user_id INTEGER,
+			date TEXT,
+			PRIMARY KEY(comment_id, user_id),
+			FOREIGN KEY (comment_id) REFERENCES comments(id),
+			FOREIGN KEY (user_id) REFERENCES users(id)
+			);
+
+		INSERT INTO post_likes(post_id, user_id, date)
+			SELECT target_id, user_id, date FROM reactions WHERE target = 'post' AND kind = 'like';
+		INSERT INTO post_dislikes(post_id, user_id, date)
+			SELECT target_id, user_id, date FROM reactions WHERE target = 'post' AND kind = 'dislike';
+		INSERT INTO comment_likes(comment_id, user_id, date)
+			SELECT target_id, user_id, date FROM reactions WHERE target = 'comment' AND kind = 'like';
+		INSERT INTO comment_dislikes(comment_id, user_id, date)
+			SELECT target_id, user_id, date FROM reactions WHERE target = 'comment' AND kind = 'dislike';
+
+		DROP TABLE reactions;
+		`,
+	},
+	{
+		Version: 4,
+		Name:    "soft_delete",
+		Up: `
+		ALTER TABLE posts ADD COLUMN deleted_at TEXT;
+		ALTER TABLE posts ADD COLUMN deleted_by INTEGER REFERENCES users(id);
+		ALTER TABLE posts ADD COLUMN delete_reason TEXT;
+
+		ALTER TABLE comments ADD COLUMN deleted_at TEXT;
+		ALTER TABLE comments ADD COLUMN deleted_by INTEGER REFERENCES users(id);
+		ALTER TABLE comments ADD COLUMN delete_reason TEXT;
+
+		CREATE INDEX IF NOT EXISTS posts_deleted ON posts(deleted_at);
+		CREATE INDEX IF NOT EXISTS comments_deleted ON comments(deleted_at);
+		`,
+		// Rows still in the trash come back as ordinary ones.
+		Down: `
+		DROP INDEX IF EXISTS comments_deleted;
+		DROP INDEX IF EXISTS posts_deleted;
+
+		ALTER TABLE comments DROP COLUMN delete_reason;
+		ALTER TABLE comments DROP COLUMN deleted_by;
+		ALTER TABLE comments DROP COLUMN deleted_at;
+
+		ALTER TABLE posts DROP COLUMN delete_reason;
+		ALTER TABLE posts DROP COLUMN deleted_by;
+		ALTER TABLE posts DROP COLUMN deleted_at;
+		`,
+	},
+	{
+		Version: 5,
+		Name:    "revisions",
+		Up: `
+		CREATE TABLE IF NOT EXISTS revisions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			target TEXT NOT NULL,
+			target_id INTEGER NOT NULL,
+			user_id INTEGER NOT NULL,
+			date TEXT NOT NULL,
+			title TEXT NOT NULL DEFAULT '',
+			content TEXT NOT NULL,
+			categories TEXT NOT NULL DEFAULT '',
+			FOREIGN KEY (user_id) REFERENCES users(id)
+			);
+
+		CREATE INDEX IF NOT EXISTS revisions_target ON revisions(target, target_id);
+
+		ALTER TABLE posts ADD COLUMN edited_at TEXT;
+		ALTER TABLE comments ADD COLUMN edited_at TEXT;
+		`,
+		Down: `
+		ALTER TABLE comments DROP COLUMN edited_at;
+		ALTER TABLE posts DROP COLUMN edited_at;
+
+		DROP TABLE revisions;
+		`,
+	},
+	{
+		Version: 6,
+		Name:    "comment_threads",
+		Up: `
+		ALTER TABLE comments ADD COLUMN parent_id INTEGER REFERENCES comments(id);
+
+		CREATE INDEX IF NOT EXISTS comments_parent ON comments(parent_id);
+		`,
+		// Replies become top level comments.
+		Down: `
+		DROP INDEX IF EXISTS comments_parent;
+
+		ALTER TABLE comments DROP COLUMN parent_id;
+		`,
+	},
+	{
+		Version: 7,
+		Name:    "counters",
+		Up: `
+		ALTER TABLE posts ADD COLUMN comment_count INTEGER NOT NULL DEFAULT 0;
+		ALTER TABLE users ADD COLUMN post_count INTEGER NOT NULL DEFAULT 0;
+		ALTER TABLE users ADD COLUMN comment_count INTEGER NOT NULL DEFAULT 0;
+
+		CREATE TABLE IF NOT EXISTS reaction_counts (
+			target TEXT NOT NULL,
+			target_id INTEGER NOT NULL,
+			kind TEXT NOT NULL,
+			count INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY(target, target_id, kind)
+			);
+
+		CREATE TABLE IF NOT EXISTS user_reaction_counts (
+			user_id INTEGER NOT NULL,
+			target TEXT NOT NULL,
+			kind TEXT NOT NULL,
+			count INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY(user_id, target, kind)
+			);
+
+		CREATE TRIGGER reactions_count_insert AFTER INSERT ON reactions BEGIN
+			INSERT INTO reaction_counts(target, target_id, kind, count) VALUES (NEW.target, NEW.target_id, NEW.kind, 1)
+				ON CONFLICT(target, target_id, kind) DO UPDATE SET count = count + 1;
+			INSERT INTO user_reaction_counts(user_id, target, kind, count) VALUES (NEW.user_id, NEW.target, NEW.kind, 1)
+				ON CONFLICT(user_id, target, kind) DO UPDATE SET count = count + 1;
+		END;
+		CREATE TRIGGER reactions_count_delete AFTER DELETE ON reactions BEGIN
+			UPDATE reaction_counts SET count = count - 1
+			WHERE target = OLD.target AND target_id = OLD.target_id AND kind = OLD.kind;
+			UPDATE user_reaction_counts SET count = count - 1
+			WHERE user_id = OLD.user_id AND target = OLD.target AND kind = OLD.kind;
+		END;
+
+		CREATE TRIGGER comments_count_insert AFTER INSERT ON comments WHEN NEW.deleted_at IS NULL BEGIN
+			UPDATE posts SET comment_count = comment_count + 1 WHERE id = NEW.post_id;
+			UPDATE users SET comment_count = comment_count + 1 WHERE id = NEW.user_id;
+		END;
+		CREATE TRIGGER comments_count_delete AFTER DELETE ON comments WHEN OLD.deleted_at IS NULL BEGIN
+			UPDATE posts SET comment_count = comment_count - 1 WHERE id = OLD.post_id;
+			UPDATE users SET comment_count = comment_count - 1 WHERE id = OLD.user_id;
+		END;
+		CREATE TRIGGER comments_count_trash AFTER UPDATE OF deleted_at ON comments
+			WHEN (OLD.deleted_at IS NULL) <> (NEW.deleted_at IS NULL) BEGIN
+			UPDATE posts SET comment_count = comment_count + (CASE WHEN NEW.deleted_at IS NULL THEN 1 ELSE -1 END)
+			WHERE id = NEW.post_id;
+			UPDATE users SET comment_count = comment_count + (CASE WHEN NEW.deleted_at IS NULL THEN 1 ELSE -1 END)
+			WHERE id = NEW.user_id;
+		END;
+
+		CREATE TRIGGER posts_count_insert AFTER INSERT ON posts WHEN NEW.deleted_at IS NULL BEGIN
+			UPDATE users SET post_count = post_count + 1 WHERE id = NEW.user_id;
+		END;
+		CREATE TRIGGER posts_count_delete AFTER DELETE ON posts WHEN OLD.deleted_at IS NULL BEGIN
+			UPDATE users SET post_count = post_count - 1 WHERE id = OLD.user_id;
+		END;
+		CREATE TRIGGER posts_count_trash AFTER UPDATE OF deleted_at ON posts
+			WHEN (OLD.deleted_at IS NULL) <> (NEW.deleted_at IS NULL) BEGIN
+			UPDATE users SET post_count = post_count + (CASE WHEN NEW.deleted_at IS NULL THEN 1 ELSE -1 END)
+			WHERE id = NEW.user_id;
+		END;
+
+		INSERT INTO reaction_counts(target, target_id, kind, count)
+			SELECT target, target_id, kind, COUNT(*) FROM reactions GROUP BY target, target_id, kind;
+		INSERT INTO user_reaction_counts(user_id, target, kind, count)
+			SELECT user_id, target, kind, COUNT(*) FROM reactions GROUP BY user_id, target, kind;
+		UPDATE posts SET comment_count = (SELECT COUNT(*) FROM comments
+			WHERE comments.post_id = posts.id AND comments.deleted_at IS NULL);
+		UPDATE users SET
+			post_count = (SELECT COUNT(*) FROM posts WHERE posts.user_id = users.id AND posts.deleted_at IS NULL),
+			comment_count = (SELECT COUNT(*) FROM comments WHERE comments.user_id = users.id AND comments.deleted_at IS NULL);
+		`,
+		Down: `
+		DROP TRIGGER IF EXISTS posts_count_trash;
+		DROP TRIGGER IF EXISTS posts_count_delete;
+		DROP TRIGGER IF EXISTS posts_count_insert;
+		DROP TRIGGER IF EXISTS comments_count_trash;
+		DROP TRIGGER IF EXISTS comments_count_delete;
+		DROP TRIGGER IF EXISTS comments_count_insert;
+		DROP TRIGGER IF EXISTS reactions_count_delete;
+		DROP TRIGGER IF EXISTS reactions_count_insert;
+
+		DROP TABLE user_reaction_counts;
+		DROP TABLE reaction_counts;
+
+		ALTER TABLE users DROP COLUMN comment_count;
+		ALTER TABLE users DROP COLUMN post_count;
+		ALTER TABLE posts DROP COLUMN comment_count;
+		`,
+	},
+	{
+		Version: 8,
+		Name:    "utc_timestamps",
+		// Timestamps were written in the local time of the server, they
+		// become UTC in TimeFormat. Values sqlite cannot read are kept.
+		Up: `
+		ALTER TABLE users ADD COLUMN timezone TEXT;
+
+		UPDATE users SET
+			reg_date = COALESCE(strftime('%Y-%m-%dT%H:%M:%SZ', reg_date, 'utc'), reg_date),
+			session_ttl = COALESCE(strftime('%Y-%m-%dT%H:%M:%SZ', session_ttl, 'utc'), session_ttl);
+		UPDATE posts SET
+			date = COALESCE(strftime('%Y-%m-%dT%H:%M:%SZ', date, 'utc'), date),
+			edited_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%SZ', edited_at, 'utc'), edited_at),
+			deleted_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%SZ', deleted_at, 'utc'), deleted_at);
+		UPDATE comments SET
+			date = COALESCE(strftime('%Y-%m-%dT%H:%M:%SZ', date, 'utc'), date),
+			edited_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%SZ', edited_at, 'utc'), edited_at),
+			deleted_at = COALESCE(strftime('%Y-%m-%dT%H:%M:%SZ', deleted_at, 'utc'), deleted_at);
+		UPDATE reactions SET date = COALESCE(strftime('%Y-%m-%dT%H:%M:%SZ', date, 'utc'), date);
+		UPDATE revisions SET date = COALESCE(strftime('%Y-%m-%dT%H:%M:%SZ', date, 'utc'), date);
+		`,
+		Down: `
+		UPDATE revisions SET date = COALESCE(strftime('%Y-%m-%d %H:%M:%S', date, 'localtime'), date);
+		UPDATE reactions SET date = COALESCE(strftime('%Y-%m-%d', date, 'localtime'), date);
+		UPDATE comments SET
+			date = COALESCE(strftime('%Y-%m-%d %H:%M:%S', date, 'localtime'), date),
+			edited_at = COALESCE(strftime('%Y-%m-%d %H:%M:%S', edited_at, 'localtime'), edited_at),
+			deleted_at = COALESCE(strftime('%Y-%m-%d %H:%M:%S', deleted_at, 'localtime'), deleted_at);
+		UPDATE posts SET
+			date = COALESCE(strftime('%Y-%m-%d %H:%M:%S', date, 'localtime'), date),
+			edited_at = COALESCE(strftime('%Y-%m-%d %H:%M:%S', edited_at, 'localtime'), edited_at),
+			deleted_at = COALESCE(strftime('%Y-%m-%d %H:%M:%S', deleted_at, 'localtime'), deleted_at);
+		UPDATE users SET
+			reg_date = COALESCE(strftime('%Y-%m-%d', reg_date, 'localtime'), reg_date),
+			session_ttl = COALESCE(strftime('%Y-%m-%d %H:%M:%S', session_ttl, 'localtime'), session_ttl);
+
+		ALTER TABLE users DROP COLUMN timezone;
+		`,
+	},
+	{
+		Version: 9,
+		Name:    "category_tree",
+		// Posts referenced categories by name, names no category was
+		// stored for become categories. Slugs are made of the names.
+		Up: `
+		ALTER TABLE topics ADD COLUMN parent_id INTEGER REFERENCES topics(id);
+		ALTER TABLE topics ADD COLUMN slug TEXT;
+		ALTER TABLE topics ADD COLUMN description TEXT NOT NULL DEFAULT '';
+		ALTER TABLE topics ADD COLUMN position INTEGER NOT NULL DEFAULT 0;
+		ALTER TABLE topics ADD COLUMN color TEXT NOT NULL DEFAULT '';
+
+		INSERT INTO topics(name)
+			SELECT DISTINCT topic FROM reference_topic WHERE topic NOT IN (SELECT name FROM topics);
+		UPDATE topics SET slug = lower(replace(replace(replace(replace(trim(name), ' ', '-'), '/', '-'), '?', '-'),
+			'#', '-'));
+		UPDATE topics SET slug = slug || '-' || id
+			WHERE slug = '' OR EXISTS (SELECT 1 FROM topics AS other WHERE other.slug = topics.slug AND other.id < topics.id);
+		CREATE UNIQUE INDEX IF NOT EXISTS topics_slug ON topics(slug);
+		CREATE INDEX IF NOT EXISTS topics_parent ON topics(parent_id);
+
+		CREATE TABLE reference_topic_ids (
+			post_id INTEGER,
+			topic_id INTEGER,
+			PRIMARY KEY (post_id, topic_id),
+			FOREIGN KEY (post_id) REFERENCES posts(id),
+			FOREIGN KEY (topic_id) REFERENCES topics(id)
+			);
+		INSERT INTO reference_topic_ids(post_id, topic_id)
+			SELECT post_id, topics.id FROM reference_topic JOIN topics ON topics.name = reference_topic.topic;
+		DROP TABLE reference_topic;
+		ALTER TABLE reference_topic_ids RENAME TO reference_topic;
+		CREATE INDEX IF NOT EXISTS reference_topic_topic ON reference_topic(topic_id);
+		`,
+		// Subcategories become top level ones.
+		Down: `
+		CREATE TABLE reference_topic_names (
+			post_id INTEGER,
+			topic TEXT,
+			PRIMARY KEY (post_id, topic),
+			FOREIGN KEY (post_id) REFERENCES posts(id)
+			);
+		INSERT OR IGNORE INTO reference_topic_names(post_id, topic)
+			SELECT post_id, topics.name FROM reference_topic JOIN topics ON topics.id = reference_topic.topic_id;
+		DROP TABLE reference_topic;
+		ALTER TABLE reference_topic_names RENAME TO reference_topic;
+
+		DROP INDEX IF EXISTS topics_parent;
+		DROP INDEX IF EXISTS topics_slug;
+		ALTER TABLE topics DROP COLUMN color;
+		ALTER TABLE topics DROP COLUMN position;
+		ALTER TABLE topics DROP COLUMN description;
+		ALTER TABLE topics DROP COLUMN slug;
+		ALTER TABLE topics DROP COLUMN parent_id;
+		`,
+	},
+	{
+		Version: 10,
+		Name:    "sessions",
+		// A user had one session kept with the user, signed in browsers
+		// get a row each. Creation times of moved sessions are unknown,
+		// they read as the time of the migration.
+		Up: `
+		CREATE TABLE IF NOT EXISTS sessions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			token TEXT NOT NULL UNIQUE,
+			user_agent TEXT NOT NULL DEFAULT '',
+			ip TEXT NOT NULL DEFAULT '',
+			created_at TEXT NOT NULL,
+			last_seen TEXT NOT NULL,
+			expires_at TEXT NOT NULL,
+			FOREIGN KEY (user_id) REFERENCES users(id)
+			);
+		CREATE INDEX IF NOT EXISTS sessions_user ON sessions(user_id);
+
+		INSERT OR IGNORE INTO sessions(user_id, token, created_at, last_seen, expires_at)
+			SELECT id, session_token, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'), strftime('%Y-%m-%dT%H:%M:%SZ', 'now'),
+				session_ttl
+			FROM users
+			WHERE session_token IS NOT NULL AND session_token != '' AND session_ttl IS NOT NULL;
+		ALTER TABLE users DROP COLUMN session_ttl;
+		ALTER TABLE users DROP COLUMN session_token;
+		`,
+		// Users keep the session they were seen with last.
+		Down: `
+		ALTER TABLE users ADD COLUMN session_token TEXT;
+		ALTER TABLE users ADD COLUMN session_ttl TEXT;
+		UPDATE users SET
+			session_token = (SELECT token FROM sessions WHERE sessions.user_id = users.id
+				ORDER BY last_seen DESC, id DESC LIMIT 1),
+			session_ttl = (SELECT expires_at FROM sessions WHERE sessions.user_id = users.id
+				ORDER BY last_seen DESC, id DESC LIMIT 1);
+
+		DROP INDEX IF EXISTS sessions_user;
+		DROP TABLE sessions;
+		`,
+	},
+	{
+		Version: 11,
+		Name:    "password_resets",
+		// Only the hash of a reset token is kept, the token itself is
+		// mailed to the user.
+		Up: `
+		CREATE TABLE IF NOT EXISTS password_resets (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			token_hash TEXT NOT NULL UNIQUE,
+			created_at TEXT NOT NULL,
+			expires_at TEXT NOT NULL,
+			FOREIGN KEY (user_id) REFERENCES users(id)
+			);
+		CREATE INDEX IF NOT EXISTS password_resets_user ON password_resets(user_id);
+		`,
+		Down: `
+		DROP INDEX IF EXISTS password_resets_user;
+		DROP TABLE password_resets;
+		`,
+	},
+	{
+		Version: 12,
+		Name:    "email_verification",
+		// Users registered before verification existed are taken as
+		// verified. A changed email waits in pending_email until the link
+		// mailed to it is followed.
+		Up: `
+		ALTER TABLE users ADD COLUMN email_verified INTEGER NOT NULL DEFAULT 0;
+		ALTER TABLE users ADD COLUMN pending_email TEXT NOT NULL DEFAULT '';
+		ALTER TABLE users ADD COLUMN verification_sent_at TEXT;
+		UPDATE users SET email_verified = 1;
+		`,
+		Down: `
+		ALTER TABLE users DROP COLUMN verification_sent_at;
+		ALTER TABLE users DROP COLUMN pending_email;
+		ALTER TABLE users DROP COLUMN email_verified;
+		`,
+	},
+	{
+		Version: 13,
+		Name:    "two_factor",
+		// Recovery codes and sign-in challenges are kept as hashes, like
+		// password resets.
+		Up: `
+		ALTER TABLE users ADD COLUMN totp_secret TEXT NOT NULL DEFAULT '';
+		ALTER TABLE users ADD COLUMN totp_enabled INTEGER NOT NULL DEFAULT 0;
+		ALTER TABLE users ADD COLUMN totp_last_step INTEGER NOT NULL DEFAULT 0;
+		CREATE TABLE IF NOT EXISTS recovery_codes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			code_hash TEXT NOT NULL,
+			FOREIGN KEY (user_id) REFERENCES users(id)
+			);
+		CREATE INDEX IF NOT EXISTS recovery_codes_user ON recovery_codes(user_id);
+		CREATE TABLE IF NOT EXISTS login_challenges (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			token_hash TEXT NOT NULL UNIQUE,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			created_at TEXT NOT NULL,
+			expires_at TEXT NOT NULL,
+			FOREIGN KEY (user_id) REFERENCES users(id)
+			);
+		CREATE INDEX IF NOT EXISTS login_challenges_user ON login_challenges(user_id);
+		`,
+		Down: `
+		DROP INDEX IF EXISTS login_challenges_user;
+		DROP TABLE login_challenges;
+		DROP INDEX IF EXISTS recovery_codes_user;
+		DROP TABLE recovery_codes;
+		ALTER TABLE users DROP COLUMN totp_last_step;
+		ALTER TABLE users DROP COLUMN totp_enabled;
+		ALTER TABLE users DROP COLUMN totp_secret;
+		`,
+	},
+	{
+		Version: 14,
+		Name:    "roles",
+		// Free-text roles of users become roles without permissions, so the
+		// titles shown stay. Anyone could write any role into the profile,
+		// so a title naming a built-in role with permissions gives the plain
+		// user role. The first user, the admin so far, gets the admin role.
+		Up: `
+		CREATE TABLE IF NOT EXISTS roles (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE
+			);
+		CREATE TABLE IF NOT EXISTS role_permissions (
+			role_id INTEGER NOT NULL,
+			permission TEXT NOT NULL,
+			PRIMARY KEY (role_id, permission),
+			FOREIGN KEY (role_id) REFERENCES roles(id)
+			);
+		INSERT INTO roles(id, name) VALUES
+			(1, 'Пользователь'),
+			(2, 'Модератор'),
+			(3, 'Администратор');
+		INSERT INTO role_permissions(role_id, permission) VALUES
+			(2, 'edit_any_post'),
+			(2, 'delete_any_post'),
+			(2, 'ban_users'),
+			(3, 'manage_categories'),
+			(3, 'edit_any_post'),
+			(3, 'delete_any_post'),
+			(3, 'ban_users'),
+			(3, 'manage_users'),
+			(3, 'manage_backups'),
+			(3, 'manage_roles');
+		INSERT INTO roles(name)
+			SELECT DISTINCT TRIM(role) FROM users
+			WHERE TRIM(COALESCE(role, '')) NOT IN ('', 'Пользователь', 'Модератор', 'Администратор');
+		ALTER TABLE users ADD COLUMN role_id INTEGER NOT NULL DEFAULT 1;
+		ALTER TABLE users ADD COLUMN banned INTEGER NOT NULL DEFAULT 0;
+		UPDATE users SET role_id = COALESCE((SELECT id FROM roles WHERE roles.name = TRIM(users.role)
+			AND NOT EXISTS (SELECT 1 FROM role_permissions WHERE role_permissions.role_id = roles.id)), 1);
+		UPDATE users SET role_id = 3 WHERE id = 1;
+		ALTER TABLE users DROP COLUMN role;
+		CREATE INDEX IF NOT EXISTS users_role ON users(role_id);
+		`,
+		Down: `
+		DROP INDEX IF EXISTS users_role;
+		ALTER TABLE users ADD COLUMN role TEXT;
+		UPDATE users SET role = (SELECT name FROM roles WHERE roles.id = users.role_id);
+		ALTER TABLE users DROP COLUMN banned;
+		ALTER TABLE users DROP COLUMN role_id;
+		DROP TABLE role_permissions;
+		DROP TABLE roles;
+		`,
+	},
+}
+
+func NewMigrator(s *sqlite3.Sqlite) *migrate.Migrator {
+	return migrate.New(s.DB, Migrations).WithRepair(repairSearchIndex)
+}
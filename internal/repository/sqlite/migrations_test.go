@@ -0,0 +1,484 @@
+package sqlite_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"forum/internal/entity"
+	"forum/internal/repository/sqlite"
+	"forum/pkg/migrate"
+)
+
+func TestMigratorUp(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		db := sqlite.MustOpenDB(t, "file:foobar?mode=memory&cache=shared")
+		defer sqlite.MustCloseDB(t, db)
+		migrator := sqlite.NewMigrator(db)
+
+		if err := migrator.Up(); err != nil {
+			t.Fatal("Unable to migrate:", err)
+		}
+
+		if version, err := migrator.Version(); err != nil {
+			t.Fatal("Unable to get version:", err)
+		} else if version != migrator.Latest() {
+			t.Fatalf("want version = %d, got version = %d:", migrator.Latest(), version)
+		}
+
+		if pending, err := migrator.Pending(); err != nil {
+			t.Fatal("Unable to get pending:", err)
+		} else if len(pending) != 0 {
+			t.Fatalf("want len = %d, got len = %d:", 0, len(pending))
+		}
+	})
+
+	t.Run("existing schema", func(t *testing.T) {
+		db := sqlite.MustOpenDB(t, "file:foobar?mode=memory&cache=shared")
+		defer sqlite.MustCloseDB(t, db)
+
+		if _, err := db.DB.Exec(sqlite.Migrations[0].Up); err != nil {
+			t.Fatal("Unable to create legacy schema:", err)
+		}
+		if err := sqlite.CreateDB(db); err != nil {
+			t.Fatal("Unable to migrate:", err)
+		}
+	})
+}
+
+func TestMigratorDown(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		db := sqlite.MustOpenDB(t, "file:foobar?mode=memory&cache=shared")
+		defer sqlite.MustCloseDB(t, db)
+		migrator := sqlite.NewMigrator(db)
+
+		if err := migrator.Up(); err != nil {
+			t.Fatal("Unable to migrate:", err)
+		}
+		if err := migrator.To(0); err != nil {
+			t.Fatal("Unable to revert:", err)
+		}
+
+		if version, err := migrator.Version(); err != nil {
+			t.Fatal("Unable to get version:", err)
+		} else if version != 0 {
+			t.Fatalf("want version = %d, got version = %d:", 0, version)
+		}
+
+		var count int
+		err := db.DB.QueryRow(`
+		SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'posts'
+		`).Scan(&count)
+		if err != nil {
+			t.Fatal("Unable to query:", err)
+		} else if count != 0 {
+			t.Fatalf("want count = %d, got count = %d:", 0, count)
+		}
+	})
+}
+
+func TestMigrateReactions(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		db := sqlite.MustOpenDB(t, "file:foobar?mode=memory&cache=shared")
+		defer sqlite.MustCloseDB(t, db)
+		migrator := sqlite.NewMigrator(db)
+
+		if err := migrator.To(2); err != nil {
+			t.Fatal("Unable to migrate:", err)
+		}
+		_, err := db.DB.Exec(`
+		INSERT INTO users(name, email) VALUES('Riddle', 'riddle@mail.ru'), ('Tom', 'tom@mail.ru'),
+			('Ginny', 'ginny@mail.ru');
+		INSERT INTO posts(user_id, date, title, content) VALUES(1, '2022-19-01', 'Cars', 'Lorem'),
+			(1, '2022-19-01', 'Sports', 'Lorem');
+		INSERT INTO comments(post_id, user_id, date, content) VALUES(1, 1, '2022-19-01', 'Lorem');
+		INSERT INTO post_likes(post_id, user_id, date) VALUES(1, 1, '2022-19-01'), (1, 2, '2022-19-01');
+		INSERT INTO post_dislikes(post_id, user_id, date) VALUES(2, 1, '2022-19-01');
+		INSERT INTO comment_dislikes(comment_id, user_id, date) VALUES(1, 3, '2022-19-01');
+		`)
+		if err != nil {
+			t.Fatal("Unable to insert:", err)
+		}
+		if err = migrator.Up(); err != nil {
+			t.Fatal("Unable to migrate:", err)
+		}
+
+		repo := sqlite.NewReactionsRepo(db)
+		want := map[int64]map[string]int64{1: {"like": 2}, 2: {"dislike": 1}}
+		if found, err := repo.Count(ctx, entity.ReactionTargetPost, []int64{1, 2}); err != nil {
+			t.Fatal("Unable to count:", err)
+		} else if !reflect.DeepEqual(found, want) {
+			t.Fatalf("want counts = %v, got counts = %v:", want, found)
+		}
+		if found, err := repo.Fetch(ctx, entity.ReactionTargetComment, 1, "dislike"); err != nil {
+			t.Fatal("Unable to fetch:", err)
+		} else if len(found) != 1 || found[0].UserId != 3 {
+			t.Fatalf("want user = %d, got reactions = %v:", 3, found)
+		}
+
+		if err = migrator.To(2); err != nil {
+			t.Fatal("Unable to revert:", err)
+		}
+		var count int
+		if err = db.DB.QueryRow(`SELECT COUNT(*) FROM post_likes`).Scan(&count); err != nil {
+			t.Fatal("Unable to query:", err)
+		} else if count != 2 {
+			t.Fatalf("want count = %d, got count = %d:", 2, count)
+		}
+	})
+}
+
+func TestMigrateCounters(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		db := sqlite.MustOpenDB(t, "file:foobar?mode=memory&cache=shared")
+		defer sqlite.MustCloseDB(t, db)
+		migrator := sqlite.NewMigrator(db)
+
+		if err := migrator.To(6); err != nil {
+			t.Fatal("Unable to migrate:", err)
+		}
+		_, err := db.DB.Exec(`
+		INSERT INTO users(name, email, password, reg_date, date_of_birth, city, sex, role)
+			VALUES('Riddle', 'Riddle@mail.ru', '', '2022-19-01', '', '', '', '');
+		INSERT INTO posts(user_id, date, title, content) VALUES(1, '2022-19-01', 'Cars', 'Lorem'),
+			(1, '2022-19-01', 'Guns', 'Lorem');
+		INSERT INTO comments(post_id, user_id, date, content) VALUES(1, 1, '2022-19-01', 'Lorem'),
+			(1, 1, '2022-19-01', 'Lorem');
+		INSERT INTO comments(post_id, user_id, date, content, deleted_at)
+			VALUES(1, 1, '2022-19-01', 'Lorem', '2022-19-02 10:00:00');
+		`)
+		if err != nil {
+			t.Fatal("Unable to insert:", err)
+		}
+		if err = migrator.Up(); err != nil {
+			t.Fatal("Unable to migrate:", err)
+		}
+
+		check := func() {
+			t.Helper()
+			user, err := sqlite.NewUsersRepo(db).GetById(ctx, 1)
+			if err != nil {
+				t.Fatal("Unable to GetById:", err)
+			} else if user.Posts != 2 || user.Comments != 2 {
+				t.Fatalf("want posts = %d, comments = %d, got posts = %d, comments = %d:", 2, 2, user.Posts, user.Comments)
+			}
+			post, err := sqlite.NewPostsRepo(db).GetById(ctx, 1)
+			if err != nil {
+				t.Fatal("Unable to GetById:", err)
+			} else if post.TotalComments != 2 {
+				t.Fatalf("want comments = %d, got comments = %d:", 2, post.TotalComments)
+			}
+		}
+		check()
+
+		_, err = db.DB.Exec(`
+		UPDATE users SET post_count = 7, comment_count = 0;
+		UPDATE posts SET comment_count = 9;
+		`)
+		if err != nil {
+			t.Fatal("Unable to update:", err)
+		}
+		if err = sqlite.NewCountersRepo(db).Recount(ctx); err != nil {
+			t.Fatal("Unable to Recount:", err)
+		}
+		check()
+	})
+}
+
+func TestMigrateUTCTimestamps(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		db := sqlite.MustOpenDB(t, "file:foobar?mode=memory&cache=shared")
+		defer sqlite.MustCloseDB(t, db)
+		migrator := sqlite.NewMigrator(db)
+
+		if err := migrator.To(7); err != nil {
+			t.Fatal("Unable to migrate:", err)
+		}
+		_, err := db.DB.Exec(`
+		INSERT INTO users(name, email, password, reg_date, date_of_birth, city, sex, role)
+			VALUES('Riddle', 'Riddle@mail.ru', '', '2022-10-01', '', '', '', '');
+		INSERT INTO posts(user_id, date, title, content, edited_at)
+			VALUES(1, '2022-10-01 10:00:00', 'Cars', 'Lorem', '2022-10-02 10:00:00'),
+			(1, '2022-19-01', 'Guns', 'Lorem', NULL);
+		`)
+		if err != nil {
+			t.Fatal("Unable to insert:", err)
+		}
+		if err = migrator.Up(); err != nil {
+			t.Fatal("Unable to migrate:", err)
+		}
+
+		posts := sqlite.NewPostsRepo(db)
+		if post, err := posts.GetById(ctx, 1); err != nil {
+			t.Fatal("Unable to GetById:", err)
+		} else if !post.Date.Equal(time.Date(2022, 10, 1, 10, 0, 0, 0, time.Local)) ||
+			!post.EditedAt.Equal(time.Date(2022, 10, 2, 10, 0, 0, 0, time.Local)) {
+			t.Fatalf("want dates in local time, got date = %v, edited = %v:", post.Date, post.EditedAt)
+		}
+		// values sqlite can't read are kept and read as the zero time
+		if post, err := posts.GetById(ctx, 2); err != nil {
+			t.Fatal("Unable to GetById:", err)
+		} else if !post.Date.IsZero() || post.IsEdited() {
+			t.Fatalf("want zero date, got date = %v, edited = %v:", post.Date, post.EditedAt)
+		}
+		if user, err := sqlite.NewUsersRepo(db).GetById(ctx, 1); err != nil {
+			t.Fatal("Unable to GetById:", err)
+		} else if !user.RegDate.Equal(time.Date(2022, 10, 1, 0, 0, 0, 0, time.Local)) {
+			t.Fatalf("want registration in local time, got %v:", user.RegDate)
+		}
+
+		if err = migrator.To(7); err != nil {
+			t.Fatal("Unable to migrate:", err)
+		}
+		var date, regDate string
+		if err = db.DB.QueryRow(`SELECT date, reg_date FROM posts, users WHERE posts.id = 1`).
+			Scan(&date, &regDate); err != nil {
+			t.Fatal("Unable to select:", err)
+		} else if date != "2022-10-01 10:00:00" || regDate != "2022-10-01" {
+			t.Fatalf("want local dates back, got date = %s, registration = %s:", date, regDate)
+		}
+	})
+}
+
+func TestMigrateCategoryTree(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		db := sqlite.MustOpenDB(t, "file:foobar?mode=memory&cache=shared")
+		defer sqlite.MustCloseDB(t, db)
+		migrator := sqlite.NewMigrator(db)
+
+		if err := migrator.To(8); err != nil {
+			t.Fatal("Unable to migrate:", err)
+		}
+		// Travel was referenced without being stored, both names slug to
+		// "sci-fi"
+		_, err := db.DB.Exec(`
+		INSERT INTO users(name, email) VALUES('Riddle', 'riddle@mail.ru');
+		INSERT INTO posts(user_id, date, title, content) VALUES(1, '2022-10-01T10:00:00Z', 'Cars', 'Lorem');
+		INSERT INTO topics(name) VALUES('Cars'), ('Sci Fi'), ('sci/fi');
+		INSERT INTO reference_topic(post_id, topic) VALUES(1, 'Cars'), (1, 'Travel');
+		`)
+		if err != nil {
+			t.Fatal("Unable to insert:", err)
+		}
+		if err = migrator.Up(); err != nil {
+			t.Fatal("Unable to migrate:", err)
+		}
+
+		categories := sqlite.NewCategoriesRepo(db)
+		for slug, name := range map[string]string{"cars": "Cars", "sci-fi": "Sci Fi", "sci-fi-3": "sci/fi",
+			"travel": "Travel"} {
+			if category, err := categories.GetBySlug(ctx, slug); err != nil {
+				t.Fatal("Unable to GetBySlug:", err)
+			} else if category.Name != name {
+				t.Fatalf("want %s by slug %s, got %+v:", name, slug, category)
+			}
+		}
+		related, err := sqlite.NewPostsRepo(db).GetRelatedCategories(ctx, entity.Post{Id: 1})
+		if err != nil {
+			t.Fatal("Unable to GetRelatedCategories:", err)
+		}
+		if len(related) != 2 || related[0].Name != "Cars" || related[1].Name != "Travel" {
+			t.Fatalf("want Cars and Travel, got %+v:", related)
+		}
+
+		if err = migrator.To(8); err != nil {
+			t.Fatal("Unable to migrate:", err)
+		}
+		var topics []string
+		rows, err := db.DB.Query(`SELECT topic FROM reference_topic WHERE post_id = 1 ORDER BY topic`)
+		if err != nil {
+			t.Fatal("Unable to select:", err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var topic string
+			if err = rows.Scan(&topic); err != nil {
+				t.Fatal("Unable to scan:", err)
+			}
+			topics = append(topics, topic)
+		}
+		if want := []string{"Cars", "Travel"}; !reflect.DeepEqual(topics, want) {
+			t.Fatalf("want = %v, got = %v:", want, topics)
+		}
+	})
+}
+
+func TestMigrateSessions(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		db := sqlite.MustOpenDB(t, "file:foobar?mode=memory&cache=shared")
+		defer sqlite.MustCloseDB(t, db)
+		migrator := sqlite.NewMigrator(db)
+
+		if err := migrator.To(9); err != nil {
+			t.Fatal("Unable to migrate:", err)
+		}
+		// Tom never signed in
+		_, err := db.DB.Exec(`
+		INSERT INTO users(name, email, session_token, session_ttl)
+			VALUES('Riddle', 'riddle@mail.ru', 'laptop', '2022-10-03T10:00:00Z'), ('Tom', 'tom@mail.ru', NULL, NULL);
+		`)
+		if err != nil {
+			t.Fatal("Unable to insert:", err)
+		}
+		if err = migrator.Up(); err != nil {
+			t.Fatal("Unable to migrate:", err)
+		}
+
+		sessions := sqlite.NewSessionsRepo(db)
+		if session, err := sessions.GetByToken(ctx, "laptop"); err != nil {
+			t.Fatal("Unable to GetByToken:", err)
+		} else if session.User.Id != 1 || !session.ExpiresAt.Equal(time.Date(2022, 10, 3, 10, 0, 0, 0, time.UTC)) {
+			t.Fatalf("want session of Riddle, got %+v:", session)
+		}
+		if found, err := sessions.FetchByUser(ctx, 2); err != nil {
+			t.Fatal("Unable to FetchByUser:", err)
+		} else if len(found) != 0 {
+			t.Fatalf("want len = %d, got len = %d:", 0, len(found))
+		}
+
+		phone := entity.Session{User: entity.User{Id: 1}, Token: "phone", LastSeen: time.Now(),
+			ExpiresAt: time.Now().Add(time.Hour)}
+		if err = sessions.Store(ctx, &phone); err != nil {
+			t.Fatal("Unable to Store:", err)
+		}
+		if err = migrator.To(9); err != nil {
+			t.Fatal("Unable to migrate:", err)
+		}
+		var token string
+		if err = db.DB.QueryRow(`SELECT session_token FROM users WHERE id = 1`).Scan(&token); err != nil {
+			t.Fatal("Unable to select:", err)
+		} else if token != "phone" {
+			t.Fatalf("want token = %s, got token = %s:", "phone", token)
+		}
+	})
+}
+
+func TestMigratorCheck(t *testing.T) {
+	t.Run("err schema too new", func(t *testing.T) {
+		db := sqlite.MustOpenDB(t, "file:foobar?mode=memory&cache=shared")
+		defer sqlite.MustCloseDB(t, db)
+		migrator := sqlite.NewMigrator(db)
+
+		if err := migrator.Up(); err != nil {
+			t.Fatal("Unable to migrate:", err)
+		}
+		_, err := db.DB.Exec(`
+		INSERT INTO schema_migrations(version, name, applied_at)
+			values(?, ?, ?)
+		`, migrator.Latest()+1, "from_the_future", "2022-01-01 00:00:00")
+		if err != nil {
+			t.Fatal("Unable to insert:", err)
+		}
+
+		if err := migrator.Check(); !errors.Is(err, migrate.ErrSchemaTooNew) {
+			t.Fatalf("want err = %v, got err = %v:", migrate.ErrSchemaTooNew, err)
+		}
+		if err := migrator.Up(); !errors.Is(err, migrate.ErrSchemaTooNew) {
+			t.Fatalf("want err = %v, got err = %v:", migrate.ErrSchemaTooNew, err)
+		}
+	})
+}
+
+func TestMigrateRoles(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		db := sqlite.MustOpenDB(t, "file:foobar?mode=memory&cache=shared")
+		defer sqlite.MustCloseDB(t, db)
+		migrator := sqlite.NewMigrator(db)
+
+		if err := migrator.To(13); err != nil {
+			t.Fatal("Unable to migrate:", err)
+		}
+		// the roles were typed by the users themselves on the profile page
+		_, err := db.DB.Exec(`
+		INSERT INTO users(name, email, role) VALUES
+			('Riddle', 'riddle@mail.ru', NULL),
+			('Tom', 'tom@mail.ru', 'Администратор'),
+			('Ginny', 'ginny@mail.ru', ' Модератор '),
+			('Harry', 'harry@mail.ru', 'Ловец');
+		`)
+		if err != nil {
+			t.Fatal("Unable to insert:", err)
+		}
+		if err = migrator.Up(); err != nil {
+			t.Fatal("Unable to migrate:", err)
+		}
+
+		// Tom and Ginny named built-in roles with permissions
+		for id, want := range map[int64]int64{1: entity.RoleAdminId, 2: entity.RoleUserId, 3: entity.RoleUserId, 4: 4} {
+			var roleId int64
+			if err = db.DB.QueryRow(`SELECT role_id FROM users WHERE id = ?`, id).Scan(&roleId); err != nil {
+				t.Fatal("Unable to select:", err)
+			} else if roleId != want {
+				t.Fatalf("want role of user %d = %d, got role = %d:", id, want, roleId)
+			}
+		}
+		role, err := sqlite.NewRolesRepo(db).GetById(ctx, 4)
+		if err != nil {
+			t.Fatal("Unable to GetById:", err)
+		}
+		if role.Name != "Ловец" || len(role.Permissions) != 0 {
+			t.Fatalf("want role without permissions, got %+v:", role)
+		}
+	})
+}
+
+func TestRepairSearchIndex(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		db := sqlite.MustOpenDB(t, "file:foobar?mode=memory&cache=shared")
+		defer sqlite.MustCloseDB(t, db)
+		migrator := sqlite.NewMigrator(db)
+
+		if err := migrator.Up(); err != nil {
+			t.Fatal("Unable to migrate:", err)
+		}
+		_, err := db.DB.Exec(`
+		INSERT INTO users(name, email) VALUES('Riddle', 'riddle@mail.ru');
+		INSERT INTO posts(user_id, date, title, content) VALUES(1, '2022-10-01T10:00:00Z', 'Cars', 'Lorem');
+		`)
+		if err != nil {
+			t.Fatal("Unable to insert:", err)
+		}
+		// the database was used by a build with the other search module
+		if err = plantOtherSearchIndex(db); err != nil {
+			t.Fatal("Unable to plant index:", err)
+		}
+
+		if err = migrator.Check(); err != nil {
+			t.Fatal("Unable to check:", err)
+		}
+
+		posts := sqlite.NewPostsRepo(db)
+		if results, err := posts.Search(ctx, []string{"cars"}, 10); err != nil {
+			t.Fatal("Unable to Search:", err)
+		} else if len(results) != 1 || results[0].Post.Id != 1 {
+			t.Fatalf("want post 1 found, got %+v:", results)
+		}
+		_, err = db.DB.Exec(`
+		INSERT INTO posts(user_id, date, title, content) VALUES(1, '2022-10-01T10:00:00Z', 'Guns', 'Lorem');
+		`)
+		if err != nil {
+			t.Fatal("Unable to insert:", err)
+		}
+		if results, err := posts.Search(ctx, []string{"guns"}, 10); err != nil {
+			t.Fatal("Unable to Search:", err)
+		} else if len(results) != 1 || results[0].Post.Id != 2 {
+			t.Fatalf("want post 2 found, got %+v:", results)
+		}
+	})
+}
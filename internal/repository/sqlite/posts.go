@@ -1,8 +1,10 @@
 package sqlite
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 
 	"forum/internal/entity"
 	"forum/pkg/sqlite3"
@@ -16,8 +18,8 @@ func NewPostsRepo(sq *sqlite3.Sqlite) *PostsRepo {
 	return &PostsRepo{sq}
 }
 
-func (pr *PostsRepo) Store(post *entity.Post) error {
-	tx, err := pr.DB.Begin()
+func (pr *PostsRepo) Store(ctx context.Context, post *entity.Post) error {
+	tx, err := pr.Conn.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("PostsRepo - Store - Begin: %w", err)
 	}
@@ -25,7 +27,7 @@ func (pr *PostsRepo) Store(post *entity.Post) error {
 		err = tx.Rollback()
 	}()
 
-	stmt, err := tx.Prepare(`
+	stmt, err := tx.PrepareContext(ctx, `
 	INSERT INTO posts(user_id, date, title, content) 
 		values(?, ?, ?, ?)
 	`)
@@ -34,7 +36,7 @@ func (pr *PostsRepo) Store(post *entity.Post) error {
 	}
 	defer stmt.Close()
 
-	res, err := stmt.Exec(post.User.Id, post.Date, post.Title, post.Content)
+	res, err := stmt.ExecContext(ctx, post.User.Id, formatTime(post.Date), post.Title, post.Content)
 	if err != nil {
 		return fmt.Errorf("PostsRepo - Store - Exec #1: %w", err)
 	}
@@ -49,19 +51,9 @@ func (pr *PostsRepo) Store(post *entity.Post) error {
 	}
 	post.Id = postId
 
-	if post.ImagePath != "" {
-		res, err = tx.Exec(`
-		INSERT INTO images(post_id, path)
-			values(?, ?)
-	`, postId, post.ImagePath)
-
-		if err != nil {
-			return fmt.Errorf("PostsRepo - Store - Exec #2: %w", err)
-		}
-		affected, err = res.RowsAffected()
-		if affected != 1 || err != nil {
-			return fmt.Errorf("PostsRepo - Store - RowsAffected #2: %w", err)
-		}
+	err = storeImages(ctx, tx, "post_id", postId, post.Images)
+	if err != nil {
+		return fmt.Errorf("PostsRepo - Store - %w", err)
 	}
 
 	err = tx.Commit()
@@ -72,8 +64,8 @@ func (pr *PostsRepo) Store(post *entity.Post) error {
 	return nil
 }
 
-func (pr *PostsRepo) StoreTopicReference(post entity.Post) error {
-	tx, err := pr.DB.Begin()
+func (pr *PostsRepo) StoreTopicReference(ctx context.Context, post entity.Post) error {
+	tx, err := pr.Conn.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("PostsRepo - StoreTopicReference - Begin: %w", err)
 	}
@@ -81,8 +73,8 @@ func (pr *PostsRepo) StoreTopicReference(post entity.Post) error {
 		err = tx.Rollback()
 	}()
 
-	stmt, err := tx.Prepare(`
-	INSERT INTO reference_topic(post_id, topic) 
+	stmt, err := tx.PrepareContext(ctx, `
+	INSERT INTO reference_topic(post_id, topic_id)
 		values(?, ?)
 	`)
 	if err != nil {
@@ -91,7 +83,7 @@ func (pr *PostsRepo) StoreTopicReference(post entity.Post) error {
 	defer stmt.Close()
 
 	for i := 0; i < len(post.Categories); i++ {
-		res, err := stmt.Exec(post.Id, post.Categories[i])
+		res, err := stmt.ExecContext(ctx, post.Id, post.Categories[i].Id)
 		if err != nil {
 			return fmt.Errorf("PostsRepo - StoreTopicReference - Exec: %w", err)
 		}
@@ -109,132 +101,140 @@ func (pr *PostsRepo) StoreTopicReference(post entity.Post) error {
 	return nil
 }
 
-func (pr *PostsRepo) Fetch() ([]entity.Post, error) {
-	var posts []entity.Post
-
-	rows, err := pr.DB.Query(`
+// selectPosts lists posts with author names, deletion marks and comment
+// counters, callers append conditions and ordering.
+const selectPosts = `
 	SELECT
 		id, user_id, date, title, content,
 		(SELECT name FROM users WHERE users.id = posts.user_id) AS user_name,
-		(SELECT COUNT(*) FROM post_likes WHERE post_likes.post_id = posts.id) AS post_likes,
-		(SELECT COUNT(*) FROM post_dislikes WHERE post_dislikes.post_id = posts.id) AS post_dislikes
+		deleted_at, deleted_by, delete_reason, edited_at, comment_count
 	FROM posts
+	`
+
+func (pr *PostsRepo) Fetch(ctx context.Context) ([]entity.Post, error) {
+	rows, err := pr.Conn.QueryContext(ctx, selectPosts+`
+	WHERE deleted_at IS NULL
 	`)
 	if err != nil {
 		return nil, fmt.Errorf("PostsRepo - Fetch - Query: %w", err)
 	}
 	defer rows.Close()
 
-	for rows.Next() {
-		var post entity.Post
-		var userName sql.NullString
-		var postsLikes sql.NullInt64
-		var postDislikes sql.NullInt64
+	posts, err := scanPosts(rows)
+	if err != nil {
+		return posts, fmt.Errorf("PostsRepo - Fetch - %w", err)
+	}
 
-		err = rows.Scan(&post.Id, &post.User.Id, &post.Date, &post.Title, &post.Content,
-			&userName, &postsLikes, &postDislikes)
-		if err != nil {
-			return posts, fmt.Errorf("PostsRepo - Fetch - Scan: %w", err)
-		}
+	return posts, nil
+}
 
-		post.TotalLikes = postsLikes.Int64
-		post.TotalDislikes = postDislikes.Int64
-		post.User.Name = userName.String
+func (pr *PostsRepo) FetchPage(ctx context.Context, limit, offset int) ([]entity.Post, error) {
+	rows, err := pr.Conn.QueryContext(ctx, selectPosts+`
+	WHERE deleted_at IS NULL
+	ORDER BY id
+	LIMIT ? OFFSET ?
+	`, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("PostsRepo - FetchPage - Query: %w", err)
+	}
+	defer rows.Close()
 
-		posts = append(posts, post)
+	posts, err := scanPosts(rows)
+	if err != nil {
+		return posts, fmt.Errorf("PostsRepo - FetchPage - %w", err)
 	}
 
 	return posts, nil
 }
 
-func (pr *PostsRepo) FetchByAuthor(user entity.User) ([]entity.Post, error) {
-	var posts []entity.Post
-
-	rows, err := pr.DB.Query(`
-	SELECT
-		id, user_id, date, title, content,
-		(SELECT name FROM users WHERE users.id = posts.user_id) AS user_name,
-		(SELECT COUNT(*) FROM post_likes WHERE post_likes.post_id = posts.id) AS post_likes,
-		(SELECT COUNT(*) FROM post_dislikes WHERE post_dislikes.post_id = posts.id) AS post_dislikes
-	FROM posts
-	WHERE user_id = ?
-	`, user.Id)
+func (pr *PostsRepo) FetchAfter(ctx context.Context, cursor int64, limit int) ([]entity.Post, error) {
+	rows, err := pr.Conn.QueryContext(ctx, selectPosts+`
+	WHERE id > ? AND deleted_at IS NULL
+	ORDER BY id
+	LIMIT ?
+	`, cursor, limit)
 	if err != nil {
-		return nil, fmt.Errorf("PostsRepo - FetchByQuery - Query: %w", err)
+		return nil, fmt.Errorf("PostsRepo - FetchAfter - Query: %w", err)
 	}
 	defer rows.Close()
 
-	for rows.Next() {
-		var post entity.Post
-		var userName sql.NullString
-		var postsLikes sql.NullInt64
-		var postDislikes sql.NullInt64
-
-		err = rows.Scan(&post.Id, &post.User.Id, &post.Date, &post.Title, &post.Content,
-			&userName, &postsLikes, &postDislikes)
-		if err != nil {
-			return posts, fmt.Errorf("PostsRepo - FetchByQuery - Scan: %w", err)
-		}
+	posts, err := scanPosts(rows)
+	if err != nil {
+		return posts, fmt.Errorf("PostsRepo - FetchAfter - %w", err)
+	}
 
-		post.TotalLikes = postsLikes.Int64
-		post.TotalDislikes = postDislikes.Int64
-		post.User.Name = userName.String
+	return posts, nil
+}
 
-		posts = append(posts, post)
+func (pr *PostsRepo) Count(ctx context.Context) (int64, error) {
+	var count int64
+	err := pr.Conn.QueryRowContext(ctx, `
+	SELECT COUNT(*)
+	FROM posts
+	WHERE deleted_at IS NULL
+	`).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("PostsRepo - Count - Scan: %w", err)
 	}
 
-	return posts, nil
+	return count, nil
 }
 
-func (pr *PostsRepo) FetchIdsByReaction(user entity.User, reaction string) ([]int64, error) {
-	var postIds []int64
-	var rows *sql.Rows
-	var err error
-
-	switch reaction {
-	case QueryLiked:
-		rows, err = pr.DB.Query(`
-		SELECT post_id
-		FROM post_likes
-		WHERE user_id = ?
-	`, user.Id)
-	case QueryDislike:
-		rows, err = pr.DB.Query(`
-		SELECT post_id
-		FROM post_dislikes
-		WHERE user_id = ?
+func (pr *PostsRepo) FetchByAuthor(ctx context.Context, user entity.User) ([]entity.Post, error) {
+	rows, err := pr.Conn.QueryContext(ctx, selectPosts+`
+	WHERE user_id = ? AND deleted_at IS NULL
 	`, user.Id)
+	if err != nil {
+		return nil, fmt.Errorf("PostsRepo - FetchByQuery - Query: %w", err)
 	}
+	defer rows.Close()
 
+	posts, err := scanPosts(rows)
 	if err != nil {
-		return nil, fmt.Errorf("PostsRepo - FetchIdsByReaction - Query: %w", err)
+		return posts, fmt.Errorf("PostsRepo - FetchByQuery - %w", err)
 	}
-	defer rows.Close()
+
+	return posts, nil
+}
+
+func scanPosts(rows *sql.Rows) ([]entity.Post, error) {
+	var posts []entity.Post
 
 	for rows.Next() {
-		var postId sql.NullInt64
+		var post entity.Post
+		var userName sql.NullString
+		var date, deletedAt, deleteReason, editedAt sql.NullString
+		var deletedBy sql.NullInt64
 
-		err = rows.Scan(&postId)
+		err := rows.Scan(&post.Id, &post.User.Id, &date, &post.Title, &post.Content, &userName,
+			&deletedAt, &deletedBy, &deleteReason, &editedAt, &post.TotalComments)
 		if err != nil {
-			return postIds, fmt.Errorf("PostsRepo - FetchIdsByReaction - Scan: %w", err)
+			return posts, fmt.Errorf("Scan: %w", err)
 		}
-		postIds = append(postIds, postId.Int64)
+
+		post.User.Name = userName.String
+		post.Date = parseTime(date)
+		post.DeletedAt = parseTime(deletedAt)
+		post.DeletedBy = deletedBy.Int64
+		post.DeleteReason = deleteReason.String
+		post.EditedAt = parseTime(editedAt)
+
+		posts = append(posts, post)
 	}
 
-	return postIds, nil
+	return posts, nil
 }
 
-func (pr *PostsRepo) GetById(id int64) (entity.Post, error) {
+func (pr *PostsRepo) GetById(ctx context.Context, id int64) (entity.Post, error) {
 	var post entity.Post
 
-	stmt, err := pr.DB.Prepare(`
+	stmt, err := pr.Conn.PrepareContext(ctx, `
 	SELECT
 		id, user_id, date, title, content,
 		(SELECT path FROM images WHERE images.user_id = posts.user_id),
 		(SELECT name FROM users WHERE users.id = posts.user_id) AS user_name,
-		(SELECT COUNT(*) FROM post_likes WHERE post_id = ?) AS post_likes,
-		(SELECT COUNT(*) FROM post_dislikes WHERE post_id = ?) AS post_dislikes,
-		(SELECT path FROM images WHERE images.post_id = ?)
+		`+postImages+`,
+		deleted_at, deleted_by, delete_reason, edited_at, comment_count
 	FROM posts
 	WHERE id = ?
 	`)
@@ -242,36 +242,42 @@ func (pr *PostsRepo) GetById(id int64) (entity.Post, error) {
 		return post, fmt.Errorf("PostsRepo - GetById - Prepare: %w", err)
 	}
 	defer stmt.Close()
-	var postsLikes sql.NullInt64
-	var postDislikes sql.NullInt64
 	var userName sql.NullString
-	var imagePath sql.NullString
+	var images sql.NullString
 	var avatarPath sql.NullString
+	var date, deletedAt, deleteReason, editedAt sql.NullString
+	var deletedBy sql.NullInt64
 
-	err = stmt.QueryRow(id, id, id, id).Scan(&post.Id, &post.User.Id, &post.Date, &post.Title, &post.Content,
-		&avatarPath, &userName, &postsLikes, &postDislikes, &imagePath)
+	err = stmt.QueryRowContext(ctx, id).Scan(&post.Id, &post.User.Id, &date, &post.Title, &post.Content,
+		&avatarPath, &userName, &images, &deletedAt, &deletedBy, &deleteReason, &editedAt,
+		&post.TotalComments)
 
 	if err != nil {
 		return post, fmt.Errorf("PostsRepo - GetById - Scan: %w", err)
 	}
 
-	post.TotalLikes = postsLikes.Int64
-	post.TotalDislikes = postDislikes.Int64
 	post.User.Name = userName.String
 	post.User.AvatarPath = avatarPath.String
-	post.ImagePath = imagePath.String
+	post.Images = splitImages(images)
+	post.Date = parseTime(date)
+	post.DeletedAt = parseTime(deletedAt)
+	post.DeletedBy = deletedBy.Int64
+	post.DeleteReason = deleteReason.String
+	post.EditedAt = parseTime(editedAt)
 
 	return post, nil
 }
 
-func (pr *PostsRepo) GetIdsByCategory(category string) ([]int64, error) {
+func (pr *PostsRepo) GetIdsByCategory(ctx context.Context, categoryId int64) ([]int64, error) {
 	var ids []int64
 
-	rows, err := pr.DB.Query(`
+	rows, err := pr.Conn.QueryContext(ctx, `
 	SELECT post_id
 	FROM reference_topic
-	WHERE topic = ?
-	`, category)
+	JOIN posts ON posts.id = reference_topic.post_id
+	WHERE topic_id = ? AND posts.deleted_at IS NULL
+	ORDER BY reference_topic.post_id
+	`, categoryId)
 	if err != nil {
 		return nil, fmt.Errorf("PostsRepo - GetIdsByCategory - Query: %w", err)
 	}
@@ -289,12 +295,14 @@ func (pr *PostsRepo) GetIdsByCategory(category string) ([]int64, error) {
 	return ids, nil
 }
 
-func (pr *PostsRepo) GetRelatedCategories(post entity.Post) ([]string, error) {
-	categories := []string{}
-	rows, err := pr.DB.Query(`
-	SELECT topic
+func (pr *PostsRepo) GetRelatedCategories(ctx context.Context, post entity.Post) ([]entity.Category, error) {
+	categories := []entity.Category{}
+	rows, err := pr.Conn.QueryContext(ctx, `
+	SELECT`+categoryColumns+`
 	FROM reference_topic
+	JOIN topics ON topics.id = reference_topic.topic_id
 	WHERE post_id = ?
+	ORDER BY topics.position, topics.name
 	`, post.Id)
 	if err != nil {
 		return nil, fmt.Errorf("PostsRepo - GetRelatedCategories - Query: %w", err)
@@ -302,8 +310,7 @@ func (pr *PostsRepo) GetRelatedCategories(post entity.Post) ([]string, error) {
 	defer rows.Close()
 
 	for rows.Next() {
-		var category string
-		err = rows.Scan(&category)
+		category, err := scanCategory(rows.Scan)
 		if err != nil {
 			return nil, fmt.Errorf("PostsRepo - GetRelatedCategories - Scan: %w", err)
 		}
@@ -313,313 +320,139 @@ func (pr *PostsRepo) GetRelatedCategories(post entity.Post) ([]string, error) {
 	return categories, nil
 }
 
-func (pr *PostsRepo) Update(post entity.Post) error {
-	tx, err := pr.DB.Begin()
-	if err != nil {
-		return fmt.Errorf("PostsRepo - Update - Begin: %w", err)
+func (pr *PostsRepo) FetchCategories(ctx context.Context, postIds []int64) (map[int64][]entity.Category, error) {
+	categories := make(map[int64][]entity.Category, len(postIds))
+	if len(postIds) == 0 {
+		return categories, nil
 	}
-	defer func() {
-		err = tx.Rollback()
-	}()
 
-	stmt, err := pr.DB.Prepare(`
-	UPDATE posts
-	SET title = ?, content = ?
-	WHERE id = ?
-	`)
-	if err != nil {
-		return fmt.Errorf("PostsRepo - Update - Prepare: %w", err)
+	args := make([]interface{}, 0, len(postIds))
+	for _, id := range postIds {
+		args = append(args, id)
 	}
-	defer stmt.Close()
 
-	res, err := stmt.Exec(post.Title, post.Content, post.Id)
+	rows, err := pr.Conn.QueryContext(ctx, `
+	SELECT`+categoryColumns+`, reference_topic.post_id
+	FROM reference_topic
+	JOIN topics ON topics.id = reference_topic.topic_id
+	WHERE post_id IN (?`+strings.Repeat(", ?", len(postIds)-1)+`)
+	ORDER BY reference_topic.post_id, topics.position, topics.name
+	`, args...)
 	if err != nil {
-		return fmt.Errorf("PostsRepo - Update - Exec: %w", err)
-	}
-
-	affected, err := res.RowsAffected()
-	if affected != 1 || err != nil {
-		return fmt.Errorf("PostsRepo - Update - RowsAffected: %w", err)
+		return nil, fmt.Errorf("PostsRepo - FetchCategories - Query: %w", err)
 	}
+	defer rows.Close()
 
-	err = tx.Commit()
-	if err != nil {
-		return fmt.Errorf("PostsRepo - Update - Commit: %w", err)
+	for rows.Next() {
+		var postId int64
+		category, err := scanCategory(rows.Scan, &postId)
+		if err != nil {
+			return nil, fmt.Errorf("PostsRepo - FetchCategories - Scan: %w", err)
+		}
+		categories[postId] = append(categories[postId], category)
 	}
 
-	return nil
+	return categories, nil
 }
 
-func (pr *PostsRepo) Delete(post entity.Post) error {
-	tx, err := pr.DB.Begin()
+// Update overwrites the title and the content of the post and marks it
+// edited at post.EditedAt. Categories and images are replaced unless they
+// are nil.
+func (pr *PostsRepo) Update(ctx context.Context, post entity.Post) error {
+	tx, err := pr.Conn.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("PostsRepo - Delete - Begin: %w", err)
+		return fmt.Errorf("PostsRepo - Update - Begin: %w", err)
 	}
 	defer func() {
 		err = tx.Rollback()
 	}()
 
-	stmt, err := pr.DB.Prepare(`
-	DELETE FROM posts
+	stmt, err := tx.PrepareContext(ctx, `
+	UPDATE posts
+	SET title = ?, content = ?, edited_at = ?
 	WHERE id = ?
 	`)
 	if err != nil {
-		return fmt.Errorf("PostsRepo - Delete - Prepare: %w", err)
+		return fmt.Errorf("PostsRepo - Update - Prepare: %w", err)
 	}
 	defer stmt.Close()
 
-	res, err := stmt.Exec(post.Id)
-	if err != nil {
-		return fmt.Errorf("PostsRepo - Delete - Exec: %w", err)
-	}
-
-	affected, err := res.RowsAffected()
-	if affected != 1 || err != nil {
-		return fmt.Errorf("PostsRepo - Delete - RowsAffected: %w", err)
-	}
-
-	err = tx.Commit()
-	if err != nil {
-		return fmt.Errorf("PostsRepo - Delete - Commit: %w", err)
-	}
-
-	return nil
-}
-
-func (pr *PostsRepo) StoreLike(post entity.Post) error {
-	tx, err := pr.DB.Begin()
-	if err != nil {
-		return fmt.Errorf("PostsRepo - StoreLike - Begin: %w", err)
-	}
-	defer func() {
-		err = tx.Rollback()
-	}()
-
-	stmt, err := tx.Prepare(`
-	INSERT INTO post_likes(post_id, user_id, date) 
-	VALUES(?, ?, ?)
-	`)
+	res, err := stmt.ExecContext(ctx, post.Title, post.Content, nullTime(post.EditedAt), post.Id)
 	if err != nil {
-		return err
+		return fmt.Errorf("PostsRepo - Update - Exec #1: %w", err)
 	}
-	defer stmt.Close()
 
-	res, err := stmt.Exec(post.Id, post.User.Id, getRegTime(DateFormat))
-	if err != nil {
-		return fmt.Errorf("PostsRepo - StoreLike - Exec: %w", err)
-	}
 	affected, err := res.RowsAffected()
 	if affected != 1 || err != nil {
-		return fmt.Errorf("PostsRepo - StoreLike - RowsAffected: %w", err)
-	}
-
-	err = tx.Commit()
-	if err != nil {
-		return fmt.Errorf("PostsRepo - StoreLike - Commit: %w", err)
-	}
-	return nil
-}
-
-func (pr *PostsRepo) DeleteLike(post entity.Post) error {
-	tx, err := pr.DB.Begin()
-	if err != nil {
-		return fmt.Errorf("PostsRepo - DeleteLike - Begin: %w", err)
+		return fmt.Errorf("PostsRepo - Update - RowsAffected: %w", err)
 	}
-	defer func() {
-		err = tx.Rollback()
-	}()
 
-	stmt, err := pr.DB.Prepare(`
-	DELETE FROM post_likes
-	WHERE post_id = ? AND user_id = ?
-	`)
-	if err != nil {
-		return fmt.Errorf("PostsRepo - DeleteLike - Prepare: %w", err)
+	if post.Categories != nil {
+		_, err = tx.ExecContext(ctx, `DELETE FROM reference_topic WHERE post_id = ?`, post.Id)
+		if err != nil {
+			return fmt.Errorf("PostsRepo - Update - Exec #2: %w", err)
+		}
+		for _, category := range post.Categories {
+			_, err = tx.ExecContext(ctx, `INSERT INTO reference_topic(post_id, topic_id) VALUES(?, ?)`, post.Id,
+				category.Id)
+			if err != nil {
+				return fmt.Errorf("PostsRepo - Update - Exec #3: %w", err)
+			}
+		}
 	}
-	defer stmt.Close()
-
-	_, err = stmt.Exec(post.Id, post.User.Id)
-	if err != nil {
-		return fmt.Errorf("PostsRepo - DeleteLike - Exec: %w", err)
+	if post.Images != nil {
+		err = replaceImages(ctx, tx, "post_id", post.Id, post.Images)
+		if err != nil {
+			return fmt.Errorf("PostsRepo - Update - %w", err)
+		}
 	}
 
 	err = tx.Commit()
 	if err != nil {
-		return fmt.Errorf("PostsRepo - DeleteLike - Commit: %w", err)
-	}
-
-	return nil
-}
-
-func (pr *PostsRepo) StoreDislike(post entity.Post) error {
-	tx, err := pr.DB.Begin()
-	if err != nil {
-		return fmt.Errorf("PostsRepo - StoreDislike - Begin: %w", err)
-	}
-	defer func() {
-		err = tx.Rollback()
-	}()
-
-	stmt, err := tx.Prepare(`
-	INSERT INTO post_dislikes(post_id, user_id, date) 
-	VALUES(?, ?, ?)
-	`)
-	if err != nil {
-		return err
-	}
-	defer stmt.Close()
-
-	res, err := stmt.Exec(post.Id, post.User.Id, getRegTime(DateFormat))
-	if err != nil {
-		return fmt.Errorf("PostsRepo - StoreDislike - Exec: %w", err)
-	}
-	affected, err := res.RowsAffected()
-	if affected != 1 || err != nil {
-		return fmt.Errorf("PostsRepo - StoreDislike - RowsAffected: %w", err)
+		return fmt.Errorf("PostsRepo - Update - Commit: %w", err)
 	}
 
-	err = tx.Commit()
-	if err != nil {
-		return fmt.Errorf("PostsRepo - StoreDislike - Commit: %w", err)
-	}
 	return nil
 }
 
-func (pr *PostsRepo) DeleteDislike(post entity.Post) error {
-	tx, err := pr.DB.Begin()
+// Delete moves the post to the trash, the deletion mark is taken from the
+// post. Posts already in the trash are not found.
+func (pr *PostsRepo) Delete(ctx context.Context, post entity.Post) error {
+	tx, err := pr.Conn.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("PostsRepo - DeleteDislike - Begin: %w", err)
+		return fmt.Errorf("PostsRepo - Delete - Begin: %w", err)
 	}
 	defer func() {
 		err = tx.Rollback()
 	}()
 
-	stmt, err := pr.DB.Prepare(`
-	DELETE FROM post_dislikes
-	WHERE post_id = ? AND user_id = ?
+	stmt, err := tx.PrepareContext(ctx, `
+	UPDATE posts
+	SET deleted_at = ?, deleted_by = ?, delete_reason = ?
+	WHERE id = ? AND deleted_at IS NULL
 	`)
 	if err != nil {
-		return fmt.Errorf("PostsRepo - DeleteDislike - Prepare: %w", err)
+		return fmt.Errorf("PostsRepo - Delete - Prepare: %w", err)
 	}
 	defer stmt.Close()
 
-	_, err = stmt.Exec(post.Id, post.User.Id)
+	res, err := stmt.ExecContext(ctx, nullTime(post.DeletedAt), nullId(post.DeletedBy), post.DeleteReason, post.Id)
 	if err != nil {
-		return fmt.Errorf("PostsRepo - DeleteDislike - Exec: %w", err)
-	}
-
-	err = tx.Commit()
-	if err != nil {
-		return fmt.Errorf("PostsRepo - DeleteDislike - Commit: %w", err)
-	}
-
-	return nil
-}
-
-func (pr *PostsRepo) FetchReactions(id int64) (entity.Post, error) {
-	var post entity.Post
-	var likes []entity.Reaction
-	var dislikes []entity.Reaction
-
-	rowsLikes, err := pr.DB.Query(`
-		SELECT user_id, date
-		FROM post_likes
-		WHERE post_id = ?
-	`, id)
-	if err != nil {
-		return post, fmt.Errorf("PostsRepo - FetchReactions - likes - Query: %w", err)
-	}
-	defer rowsLikes.Close()
-
-	for rowsLikes.Next() {
-		var like entity.Reaction
-		err = rowsLikes.Scan(&like.UserId, &like.Date)
-		if err != nil {
-			return post, fmt.Errorf("PostsRepo - FetchReactions - likes - Scan: %w", err)
-		}
-		likes = append(likes, like)
-	}
-
-	rowsDislikes, err := pr.DB.Query(`
-		SELECT user_id, date
-		FROM post_dislikes
-		WHERE post_id = ?
-	`, id)
-	if err != nil {
-		return post, fmt.Errorf("PostsRepo - FetchReactions - dislikes - Query: %w", err)
-	}
-	defer rowsDislikes.Close()
-
-	for rowsDislikes.Next() {
-		var dislike entity.Reaction
-		err = rowsDislikes.Scan(&dislike.UserId, &dislike.Date)
-		if err != nil {
-			return post, fmt.Errorf("PostsRepo - FetchReactions - dislikes - Scan: %w", err)
-		}
-		dislikes = append(dislikes, dislike)
-	}
-	post.Likes = append(post.Likes, likes...)
-	post.Dislikes = append(post.Dislikes, dislikes...)
-	return post, nil
-}
-
-func (pr *PostsRepo) StoreCategories(categories []string) error {
-	tx, err := pr.DB.Begin()
-	if err != nil {
-		return fmt.Errorf("PostsRepo - StoreCategories - Begin: %w", err)
+		return fmt.Errorf("PostsRepo - Delete - Exec: %w", err)
 	}
-	defer func() {
-		err = tx.Rollback()
-	}()
 
-	stmt, err := tx.Prepare(`
-	INSERT INTO topics(name) 
-		values(?)
-	`)
+	affected, err := res.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("PostsRepo - StoreCategories - Prepare: %w", err)
+		return fmt.Errorf("PostsRepo - Delete - RowsAffected: %w", err)
 	}
-	defer stmt.Close()
-
-	for i := 0; i < len(categories); i++ {
-		res, err := stmt.Exec(categories[i])
-		if err != nil {
-			return fmt.Errorf("PostsRepo - StoreCategories - Exec: %w", err)
-		}
-
-		affected, err := res.RowsAffected()
-		if affected != 1 || err != nil {
-			return fmt.Errorf("PostsRepo - StoreCategories - RowsAffected: %w", err)
-		}
+	if affected != 1 {
+		return fmt.Errorf("PostsRepo - Delete - RowsAffected: %w", sql.ErrNoRows)
 	}
 
 	err = tx.Commit()
 	if err != nil {
-		return fmt.Errorf("PostsRepo - StoreCategories - Commit: %w", err)
+		return fmt.Errorf("PostsRepo - Delete - Commit: %w", err)
 	}
 
 	return nil
 }
-
-func (pr *PostsRepo) GetExistedCategories() ([]string, error) {
-	categories := []string{}
-	rows, err := pr.DB.Query(`
-	SELECT name
-	FROM topics
-	`)
-	if err != nil {
-		return nil, fmt.Errorf("PostsRepo - GetExistedCategories - Query: %w", err)
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var category string
-		err = rows.Scan(&category)
-		if err != nil {
-			return nil, fmt.Errorf("PostsRepo - GetExistedCategories - Scan: %w", err)
-		}
-		categories = append(categories, category)
-	}
-
-	return categories, nil
-}
@@ -0,0 +1,190 @@
+package sqlite_test
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"forum/internal/entity"
+	"forum/internal/repository"
+	"forum/internal/repository/repotest"
+	"forum/internal/repository/sqlite"
+	"forum/pkg/sqlite3"
+)
+
+func openRepos(tb testing.TB) (*repository.Repositories, func()) {
+	db := sqlite.MustOpenDB(tb, "file:foobar?mode=memory&cache=shared")
+	if err := sqlite.CreateDB(db); err != nil {
+		sqlite.MustCloseDB(tb, db)
+		tb.Fatal("Unable to create db:", err)
+	}
+	return repository.NewRepositories(db), func() {
+		sqlite.MustCloseDB(tb, db)
+	}
+}
+
+func TestPostsRepo(t *testing.T) {
+	repotest.RunPostsTests(t, openRepos)
+}
+
+func TestCategoriesRepo(t *testing.T) {
+	repotest.RunCategoriesTests(t, openRepos)
+}
+
+func TestUsersRepo(t *testing.T) {
+	repotest.RunUsersTests(t, openRepos)
+}
+
+func TestRolesRepo(t *testing.T) {
+	repotest.RunRolesTests(t, openRepos)
+}
+
+func TestSessionsRepo(t *testing.T) {
+	repotest.RunSessionsTests(t, openRepos)
+}
+
+func TestPasswordResetsRepo(t *testing.T) {
+	repotest.RunPasswordResetsTests(t, openRepos)
+}
+
+func TestTwoFactorRepo(t *testing.T) {
+	repotest.RunTwoFactorTests(t, openRepos)
+}
+
+func TestLoginChallengesRepo(t *testing.T) {
+	repotest.RunLoginChallengesTests(t, openRepos)
+}
+
+func TestCommentsRepo(t *testing.T) {
+	repotest.RunCommentsTests(t, openRepos)
+}
+
+func TestReactionsRepo(t *testing.T) {
+	repotest.RunReactionsTests(t, openRepos)
+}
+
+func TestRevisionsRepo(t *testing.T) {
+	repotest.RunRevisionsTests(t, openRepos)
+}
+
+func TestUnitOfWork(t *testing.T) {
+	repotest.RunUnitOfWorkTests(t, openRepos)
+}
+
+func TestCounters(t *testing.T) {
+	repotest.RunCountersTests(t, openRepos)
+}
+
+func TestSearchRepo(t *testing.T) {
+	repotest.RunSearchTests(t, openRepos)
+}
+
+func TestConcurrentWrites(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		db, err := sqlite3.New(filepath.Join(t.TempDir(), "forum.db"), sqlite3.Options{
+			JournalMode: "WAL",
+			Synchronous: "NORMAL",
+			BusyTimeout: 5 * time.Second,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer sqlite.MustCloseDB(t, db)
+		if err = sqlite.CreateDB(db); err != nil {
+			t.Fatal("Unable to create db:", err)
+		}
+		repos := repository.NewRepositories(db)
+
+		const writers = 8
+		var wg sync.WaitGroup
+		errs := make(chan error, writers)
+		for i := 0; i < writers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				// reading first would leave the transaction unable to
+				// upgrade its lock if it didn't take the write lock early
+				errs <- repos.UnitOfWork.Do(ctx, func(repos *repository.Repositories) error {
+					if _, err := repos.Posts.Count(ctx); err != nil {
+						return err
+					}
+					post := entity.Post{User: entity.User{Id: 1}, Date: time.Now().UTC(), Title: "Cars",
+						Content: "Lorem ipsum."}
+					return repos.Posts.Store(ctx, &post)
+				})
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			if err != nil {
+				t.Fatal("Unable to do:", err)
+			}
+		}
+		if posts, err := repos.Posts.Count(ctx); err != nil || posts != writers {
+			t.Fatalf("want posts = %d, got: %d, %v", writers, posts, err)
+		}
+	})
+
+	t.Run("OK challenge attempts", func(t *testing.T) {
+		db, err := sqlite3.New(filepath.Join(t.TempDir(), "forum.db"), sqlite3.Options{
+			JournalMode: "WAL",
+			Synchronous: "NORMAL",
+			BusyTimeout: 5 * time.Second,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer sqlite.MustCloseDB(t, db)
+		if err = sqlite.CreateDB(db); err != nil {
+			t.Fatal("Unable to create db:", err)
+		}
+		repos := repository.NewRepositories(db)
+		user := entity.User{Id: 1, Name: "Riddle", Email: "riddle@mail.ru"}
+		if err = repos.Users.Store(ctx, user); err != nil {
+			t.Fatal("Unable to store:", err)
+		}
+		challenge := entity.LoginChallenge{User: user, TokenHash: "first", CreatedAt: time.Now(),
+			ExpiresAt: time.Now().Add(time.Minute)}
+		if err = repos.Challenges.Store(ctx, &challenge); err != nil {
+			t.Fatal("Unable to store:", err)
+		}
+
+		const writers, max = 16, 5
+		var wg sync.WaitGroup
+		added := make(chan bool, writers)
+		errs := make(chan error, writers)
+		for i := 0; i < writers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ok, err := repos.Challenges.AddAttempt(ctx, challenge.Id, max)
+				added <- ok
+				errs <- err
+			}()
+		}
+		wg.Wait()
+		close(added)
+		close(errs)
+
+		for err := range errs {
+			if err != nil {
+				t.Fatal("Unable to AddAttempt:", err)
+			}
+		}
+		count := 0
+		for ok := range added {
+			if ok {
+				count++
+			}
+		}
+		if count != max {
+			t.Fatalf("want attempts = %d, got: %d", max, count)
+		}
+	})
+}
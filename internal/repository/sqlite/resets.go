@@ -0,0 +1,78 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"forum/internal/entity"
+	"forum/pkg/sqlite3"
+)
+
+type PasswordResetsRepo struct {
+	*sqlite3.Sqlite
+}
+
+func NewPasswordResetsRepo(sq *sqlite3.Sqlite) *PasswordResetsRepo {
+	return &PasswordResetsRepo{sq}
+}
+
+func (rr *PasswordResetsRepo) Store(ctx context.Context, reset *entity.PasswordReset) error {
+	res, err := rr.Conn.ExecContext(ctx, `
+	INSERT INTO password_resets(user_id, token_hash, created_at, expires_at)
+		VALUES(?, ?, ?, ?)
+	`, reset.User.Id, reset.TokenHash, formatTime(reset.CreatedAt), formatTime(reset.ExpiresAt))
+	if err != nil {
+		return fmt.Errorf("PasswordResetsRepo - Store - Exec: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if affected != 1 || err != nil {
+		return fmt.Errorf("PasswordResetsRepo - Store - RowsAffected: %w", err)
+	}
+	reset.Id, err = res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("PasswordResetsRepo - Store - LastInsertId: %w", err)
+	}
+	return nil
+}
+
+func (rr *PasswordResetsRepo) GetByHash(ctx context.Context, tokenHash string) (entity.PasswordReset, error) {
+	var reset entity.PasswordReset
+	var createdAt, expiresAt sql.NullString
+	err := rr.Conn.QueryRowContext(ctx, `
+	SELECT id, user_id, token_hash, created_at, expires_at
+	FROM password_resets
+	WHERE token_hash = ?
+	`, tokenHash).Scan(&reset.Id, &reset.User.Id, &reset.TokenHash, &createdAt, &expiresAt)
+	if err != nil {
+		return reset, fmt.Errorf("PasswordResetsRepo - GetByHash - Scan: %w", err)
+	}
+	reset.CreatedAt = parseTime(createdAt)
+	reset.ExpiresAt = parseTime(expiresAt)
+	return reset, nil
+}
+
+func (rr *PasswordResetsRepo) DeleteByUser(ctx context.Context, userId int64) error {
+	_, err := rr.Conn.ExecContext(ctx, `DELETE FROM password_resets WHERE user_id = ?`, userId)
+	if err != nil {
+		return fmt.Errorf("PasswordResetsRepo - DeleteByUser - Exec: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired removes the resets expired before the given time and
+// returns how many there were.
+func (rr *PasswordResetsRepo) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	res, err := rr.Conn.ExecContext(ctx, `DELETE FROM password_resets WHERE expires_at < ?`, formatTime(before))
+	if err != nil {
+		return 0, fmt.Errorf("PasswordResetsRepo - DeleteExpired - Exec: %w", err)
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("PasswordResetsRepo - DeleteExpired - RowsAffected: %w", err)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,83 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"forum/internal/entity"
+	"forum/pkg/sqlite3"
+)
+
+// categorySeparator joins the categories of a revision in one column.
+const categorySeparator = "\n"
+
+type RevisionsRepo struct {
+	*sqlite3.Sqlite
+}
+
+func NewRevisionsRepo(sq *sqlite3.Sqlite) *RevisionsRepo {
+	return &RevisionsRepo{sq}
+}
+
+func (rr *RevisionsRepo) Store(ctx context.Context, revision entity.Revision) error {
+	res, err := rr.Conn.ExecContext(ctx, `
+	INSERT INTO revisions(target, target_id, user_id, date, title, content, categories)
+		VALUES(?, ?, ?, ?, ?, ?, ?)
+	`, revision.Target, revision.TargetId, revision.User.Id, formatTime(revision.Date), revision.Title,
+		revision.Content, strings.Join(revision.Categories, categorySeparator))
+	if err != nil {
+		return fmt.Errorf("RevisionsRepo - Store - Exec: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if affected != 1 || err != nil {
+		return fmt.Errorf("RevisionsRepo - Store - RowsAffected: %w", err)
+	}
+	return nil
+}
+
+func (rr *RevisionsRepo) Fetch(ctx context.Context, target string, targetId int64) ([]entity.Revision, error) {
+	var revisions []entity.Revision
+
+	rows, err := rr.Conn.QueryContext(ctx, `
+	SELECT
+		id, target, target_id, user_id, date, title, content, categories,
+		(SELECT name FROM users WHERE users.id = revisions.user_id)
+	FROM revisions
+	WHERE target = ? AND target_id = ?
+	ORDER BY id
+	`, target, targetId)
+	if err != nil {
+		return nil, fmt.Errorf("RevisionsRepo - Fetch - Query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var revision entity.Revision
+		var categories string
+		var date, userName sql.NullString
+		err = rows.Scan(&revision.Id, &revision.Target, &revision.TargetId, &revision.User.Id, &date,
+			&revision.Title, &revision.Content, &categories, &userName)
+		if err != nil {
+			return nil, fmt.Errorf("RevisionsRepo - Fetch - Scan: %w", err)
+		}
+		revision.Date = parseTime(date)
+		revision.User.Name = userName.String
+		if categories != "" {
+			revision.Categories = strings.Split(categories, categorySeparator)
+		}
+		revision.Number = len(revisions) + 1
+		revisions = append(revisions, revision)
+	}
+	return revisions, nil
+}
+
+func (rr *RevisionsRepo) DeleteByUser(ctx context.Context, userId int64) error {
+	_, err := rr.Conn.ExecContext(ctx, `DELETE FROM revisions WHERE user_id = ?`, userId)
+	if err != nil {
+		return fmt.Errorf("RevisionsRepo - DeleteByUser - Exec: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"forum/internal/entity"
+)
+
+// Column weights of the search tables: title, content and author of posts,
+// content and author of comments.
+var (
+	postsSearchWeights    = []float64{10, 1, 2}
+	commentsSearchWeights = []float64{1, 2}
+)
+
+// searchIndexFill indexes the posts and comments there are.
+const searchIndexFill = `
+INSERT INTO posts_search(rowid, title, content, author)
+	SELECT id, title, content, (SELECT name FROM users WHERE users.id = posts.user_id)
+	FROM posts;
+
+INSERT INTO comments_search(rowid, content, author)
+	SELECT id, content, (SELECT name FROM users WHERE users.id = comments.user_id)
+	FROM comments;
+`
+
+// repairSearchIndex rebuilds the search index when it was made by a build
+// with the other search module, its queries fail on such an index and so
+// do the triggers of builds without FTS5.
+func repairSearchIndex(db *sql.DB) error {
+	var schema string
+	err := db.QueryRow(`
+	SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'posts_search'
+	`).Scan(&schema)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("repairSearchIndex - Scan: %w", err)
+	}
+	if strings.Contains(strings.ToLower(schema), "using "+searchModule) {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("repairSearchIndex - Begin: %w", err)
+	}
+	defer func() {
+		err = tx.Rollback()
+	}()
+
+	if err = dropSearchIndex(tx); err != nil {
+		return fmt.Errorf("repairSearchIndex - %w", err)
+	}
+	if _, err = tx.Exec(searchIndexTables + searchIndexFill); err != nil {
+		return fmt.Errorf("repairSearchIndex - Exec: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("repairSearchIndex - Commit: %w", err)
+	}
+	return nil
+}
+
+// Search looks up terms in posts and comments. A post can appear several
+// times, once for itself and once per matching comment.
+func (pr *PostsRepo) Search(ctx context.Context, terms []string, limit int) ([]entity.SearchResult, error) {
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	match := matchQuery(terms)
+
+	results, err := pr.searchIndex(ctx, searchPostsQuery, match, limit, postsSearchWeights)
+	if err != nil {
+		return nil, fmt.Errorf("PostsRepo - Search - posts - %w", err)
+	}
+
+	comments, err := pr.searchIndex(ctx, searchCommentsQuery, match, limit, commentsSearchWeights)
+	if err != nil {
+		return nil, fmt.Errorf("PostsRepo - Search - comments - %w", err)
+	}
+
+	return append(results, comments...), nil
+}
+
+func (pr *PostsRepo) searchIndex(ctx context.Context, query, match string, limit int,
+	weights []float64) ([]entity.SearchResult, error) {
+	var results []entity.SearchResult
+
+	rows, err := pr.Conn.QueryContext(ctx, query, match, limit)
+	if err != nil {
+		return nil, fmt.Errorf("Query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var result entity.SearchResult
+		var rank string
+		err = rows.Scan(&result.Post.Id, &rank, &result.Snippet)
+		if err != nil {
+			return nil, fmt.Errorf("Scan: %w", err)
+		}
+		result.Rank, err = parseRank(rank, weights)
+		if err != nil {
+			return nil, fmt.Errorf("parseRank: %w", err)
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// matchQuery matches every term as a prefix. Terms are combined with
+// implicit AND.
+func matchQuery(terms []string) string {
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = prefixTerm(strings.ReplaceAll(term, `"`, `""`))
+	}
+	return strings.Join(quoted, " ")
+}
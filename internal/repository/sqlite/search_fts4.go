@@ -0,0 +1,96 @@
+//go:build !sqlite_fts5
+
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FTS4 is compiled into go-sqlite3 by default. It has no builtin ranking,
+// so queries return match offsets and parseRank weights them by column.
+// Rows are preordered by the number of matches before the limit applies.
+const searchModule = "fts4"
+
+const searchIndexTables = `
+CREATE VIRTUAL TABLE posts_search USING fts4(title, content, author, tokenize=unicode61);
+CREATE VIRTUAL TABLE comments_search USING fts4(content, author, tokenize=unicode61);
+`
+
+const searchPostsQuery = `
+SELECT
+	docid,
+	offsets(posts_search),
+	snippet(posts_search, char(2), char(3), '…', -1, 12)
+FROM posts_search
+JOIN posts ON posts.id = posts_search.docid
+WHERE posts_search MATCH ? AND posts.deleted_at IS NULL
+ORDER BY length(offsets(posts_search)) DESC
+LIMIT ?
+`
+
+const searchCommentsQuery = `
+SELECT
+	comments.post_id,
+	offsets(comments_search),
+	snippet(comments_search, char(2), char(3), '…', -1, 12)
+FROM comments_search
+JOIN comments ON comments.id = comments_search.docid
+LEFT JOIN posts ON posts.id = comments.post_id
+WHERE comments_search MATCH ? AND comments.deleted_at IS NULL AND posts.deleted_at IS NULL
+ORDER BY length(offsets(comments_search)) DESC
+LIMIT ?
+`
+
+func prefixTerm(term string) string {
+	return `"` + term + `*"`
+}
+
+// parseRank sums column weights of every matched term. Offsets come in
+// groups of four integers: column, term, byte offset and size.
+func parseRank(raw string, weights []float64) (float64, error) {
+	fields := strings.Fields(raw)
+	var rank float64
+	for i := 0; i+3 < len(fields); i += 4 {
+		column, err := strconv.Atoi(fields[i])
+		if err != nil {
+			return 0, err
+		}
+		if column < len(weights) {
+			rank += weights[column]
+		}
+	}
+	return rank, nil
+}
+
+// dropSearchIndex drops an FTS5 index. SQLite can't drop a table of a
+// module it doesn't have, so the entries of the index are taken out of the
+// schema by hand, with the schema version bumped for every connection to
+// reload it, and the shadow tables are dropped then.
+func dropSearchIndex(tx *sql.Tx) error {
+	var version int
+	if err := tx.QueryRow(`PRAGMA schema_version`).Scan(&version); err != nil {
+		return fmt.Errorf("dropSearchIndex - schema_version: %w", err)
+	}
+
+	_, err := tx.Exec(`
+	PRAGMA writable_schema = ON;
+	DELETE FROM sqlite_master WHERE type = 'table' AND name IN ('posts_search', 'comments_search');
+	PRAGMA writable_schema = OFF;
+	PRAGMA schema_version = ` + strconv.Itoa(version+1) + `;
+	`)
+	if err != nil {
+		return fmt.Errorf("dropSearchIndex - Exec #1: %w", err)
+	}
+
+	for _, table := range []string{"posts_search", "comments_search"} {
+		for _, shadow := range []string{"data", "idx", "content", "docsize", "config"} {
+			if _, err = tx.Exec(`DROP TABLE IF EXISTS ` + table + `_` + shadow); err != nil {
+				return fmt.Errorf("dropSearchIndex - Exec #2: %w", err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+//go:build !sqlite_fts5
+
+package sqlite_test
+
+import (
+	"strconv"
+
+	"forum/pkg/sqlite3"
+)
+
+// plantOtherSearchIndex replaces the search index with the one an FTS5
+// build makes. This build can't create FTS5 tables, so their schema
+// entries are written by hand, the way an FTS5 build leaves them.
+func plantOtherSearchIndex(db *sqlite3.Sqlite) error {
+	var version int
+	if err := db.DB.QueryRow(`PRAGMA schema_version`).Scan(&version); err != nil {
+		return err
+	}
+	_, err := db.DB.Exec(`
+	DROP TABLE posts_search;
+	DROP TABLE comments_search;
+	CREATE TABLE posts_search_data(id INTEGER PRIMARY KEY, block BLOB);
+	CREATE TABLE posts_search_config(k PRIMARY KEY, v) WITHOUT ROWID;
+	PRAGMA writable_schema = ON;
+	INSERT INTO sqlite_master(type, name, tbl_name, rootpage, sql) VALUES
+		('table', 'posts_search', 'posts_search', 0,
+			'CREATE VIRTUAL TABLE posts_search USING fts5(title, content, author, tokenize = ''unicode61'')'),
+		('table', 'comments_search', 'comments_search', 0,
+			'CREATE VIRTUAL TABLE comments_search USING fts5(content, author, tokenize = ''unicode61'')');
+	PRAGMA writable_schema = OFF;
+	PRAGMA schema_version = ` + strconv.Itoa(version+1) + `;
+	`)
+	return err
+}
@@ -0,0 +1,66 @@
+//go:build sqlite_fts5
+
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+)
+
+// Search index is built with FTS5, go-sqlite3 has it only when compiled
+// with the sqlite_fts5 tag. Builds without the tag fall back to FTS4,
+// see search_fts4.go. An index made by the other build is rebuilt by
+// repairSearchIndex.
+const searchModule = "fts5"
+
+const searchIndexTables = `
+CREATE VIRTUAL TABLE posts_search USING fts5(title, content, author, tokenize = 'unicode61');
+CREATE VIRTUAL TABLE comments_search USING fts5(content, author, tokenize = 'unicode61');
+`
+
+const searchPostsQuery = `
+SELECT
+	posts_search.rowid,
+	-bm25(posts_search, 10.0, 1.0, 2.0),
+	snippet(posts_search, -1, char(2), char(3), '…', 12)
+FROM posts_search
+JOIN posts ON posts.id = posts_search.rowid
+WHERE posts_search MATCH ? AND posts.deleted_at IS NULL
+ORDER BY rank
+LIMIT ?
+`
+
+const searchCommentsQuery = `
+SELECT
+	comments.post_id,
+	-bm25(comments_search, 1.0, 2.0),
+	snippet(comments_search, -1, char(2), char(3), '…', 12)
+FROM comments_search
+JOIN comments ON comments.id = comments_search.rowid
+LEFT JOIN posts ON posts.id = comments.post_id
+WHERE comments_search MATCH ? AND comments.deleted_at IS NULL AND posts.deleted_at IS NULL
+ORDER BY rank
+LIMIT ?
+`
+
+func prefixTerm(term string) string {
+	return `"` + term + `"*`
+}
+
+// parseRank reads the bm25 score, weights are applied by the query.
+func parseRank(raw string, weights []float64) (float64, error) {
+	return strconv.ParseFloat(raw, 64)
+}
+
+// dropSearchIndex drops an FTS4 index, FTS4 is compiled in along with FTS5.
+func dropSearchIndex(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+	DROP TABLE IF EXISTS posts_search;
+	DROP TABLE IF EXISTS comments_search;
+	`)
+	if err != nil {
+		return fmt.Errorf("dropSearchIndex - Exec: %w", err)
+	}
+	return nil
+}
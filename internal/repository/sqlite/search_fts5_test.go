@@ -0,0 +1,17 @@
+//go:build sqlite_fts5
+
+package sqlite_test
+
+import "forum/pkg/sqlite3"
+
+// plantOtherSearchIndex replaces the search index with the one a build
+// without FTS5 makes.
+func plantOtherSearchIndex(db *sqlite3.Sqlite) error {
+	_, err := db.DB.Exec(`
+	DROP TABLE posts_search;
+	DROP TABLE comments_search;
+	CREATE VIRTUAL TABLE posts_search USING fts4(title, content, author, tokenize=unicode61);
+	CREATE VIRTUAL TABLE comments_search USING fts4(content, author, tokenize=unicode61);
+	`)
+	return err
+}
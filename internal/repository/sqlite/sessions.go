@@ -0,0 +1,154 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"forum/internal/entity"
+	"forum/pkg/sqlite3"
+)
+
+type SessionsRepo struct {
+	*sqlite3.Sqlite
+}
+
+func NewSessionsRepo(sq *sqlite3.Sqlite) *SessionsRepo {
+	return &SessionsRepo{sq}
+}
+
+// sessionColumns are scanned by scanSession, sessions has to be in the
+// query under its own name.
+const sessionColumns = `
+	sessions.id, sessions.user_id, sessions.token, sessions.user_agent, sessions.ip, sessions.created_at,
+	sessions.last_seen, sessions.expires_at`
+
+// scanSession scans sessionColumns followed by dest.
+func scanSession(scan func(dest ...interface{}) error, dest ...interface{}) (entity.Session, error) {
+	var session entity.Session
+	var createdAt, lastSeen, expiresAt sql.NullString
+	err := scan(append([]interface{}{&session.Id, &session.User.Id, &session.Token, &session.UserAgent,
+		&session.IP, &createdAt, &lastSeen, &expiresAt}, dest...)...)
+	session.CreatedAt = parseTime(createdAt)
+	session.LastSeen = parseTime(lastSeen)
+	session.ExpiresAt = parseTime(expiresAt)
+	return session, err
+}
+
+func (sr *SessionsRepo) Store(ctx context.Context, session *entity.Session) error {
+	res, err := sr.Conn.ExecContext(ctx, `
+	INSERT INTO sessions(user_id, token, user_agent, ip, created_at, last_seen, expires_at)
+		VALUES(?, ?, ?, ?, ?, ?, ?)
+	`, session.User.Id, session.Token, session.UserAgent, session.IP, formatTime(session.CreatedAt),
+		formatTime(session.LastSeen), formatTime(session.ExpiresAt))
+	if err != nil {
+		return fmt.Errorf("SessionsRepo - Store - Exec: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if affected != 1 || err != nil {
+		return fmt.Errorf("SessionsRepo - Store - RowsAffected: %w", err)
+	}
+	session.Id, err = res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("SessionsRepo - Store - LastInsertId: %w", err)
+	}
+	return nil
+}
+
+// GetByToken returns the session with the Id, the Timezone,
+// EmailVerified, Banned and the Role.Id of its user.
+func (sr *SessionsRepo) GetByToken(ctx context.Context, token string) (entity.Session, error) {
+	var timezone sql.NullString
+	var verified, banned bool
+	var roleId int64
+	row := sr.Conn.QueryRowContext(ctx, `SELECT`+sessionColumns+`,
+		users.timezone, users.email_verified, users.banned, users.role_id
+	FROM sessions
+	JOIN users ON users.id = sessions.user_id
+	WHERE sessions.token = ?
+	`, token)
+	session, err := scanSession(row.Scan, &timezone, &verified, &banned, &roleId)
+	if err != nil {
+		return session, fmt.Errorf("SessionsRepo - GetByToken - Scan: %w", err)
+	}
+	session.User.Timezone = timezone.String
+	session.User.EmailVerified = verified
+	session.User.Banned = banned
+	session.User.Role.Id = roleId
+	return session, nil
+}
+
+// FetchByUser lists the sessions of the user, the last seen first.
+func (sr *SessionsRepo) FetchByUser(ctx context.Context, userId int64) ([]entity.Session, error) {
+	var sessions []entity.Session
+
+	rows, err := sr.Conn.QueryContext(ctx, `SELECT`+sessionColumns+`
+	FROM sessions
+	WHERE user_id = ?
+	ORDER BY last_seen DESC, id DESC
+	`, userId)
+	if err != nil {
+		return nil, fmt.Errorf("SessionsRepo - FetchByUser - Query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		session, err := scanSession(rows.Scan)
+		if err != nil {
+			return nil, fmt.Errorf("SessionsRepo - FetchByUser - Scan: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, nil
+}
+
+// Update overwrites the address, LastSeen and ExpiresAt of the session.
+func (sr *SessionsRepo) Update(ctx context.Context, session entity.Session) error {
+	res, err := sr.Conn.ExecContext(ctx, `
+	UPDATE sessions
+	SET ip = ?, last_seen = ?, expires_at = ?
+	WHERE id = ?
+	`, session.IP, formatTime(session.LastSeen), formatTime(session.ExpiresAt), session.Id)
+	if err != nil {
+		return fmt.Errorf("SessionsRepo - Update - Exec: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if affected != 1 || err != nil {
+		return fmt.Errorf("SessionsRepo - Update - RowsAffected: %w", err)
+	}
+	return nil
+}
+
+func (sr *SessionsRepo) Delete(ctx context.Context, id int64) error {
+	_, err := sr.Conn.ExecContext(ctx, `DELETE FROM sessions WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("SessionsRepo - Delete - Exec: %w", err)
+	}
+	return nil
+}
+
+func (sr *SessionsRepo) DeleteByUser(ctx context.Context, userId int64) error {
+	_, err := sr.Conn.ExecContext(ctx, `DELETE FROM sessions WHERE user_id = ?`, userId)
+	if err != nil {
+		return fmt.Errorf("SessionsRepo - DeleteByUser - Exec: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired removes the sessions expired before the given time and
+// returns how many there were.
+func (sr *SessionsRepo) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	res, err := sr.Conn.ExecContext(ctx, `DELETE FROM sessions WHERE expires_at < ?`, formatTime(before))
+	if err != nil {
+		return 0, fmt.Errorf("SessionsRepo - DeleteExpired - Exec: %w", err)
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("SessionsRepo - DeleteExpired - RowsAffected: %w", err)
+	}
+	return deleted, nil
+}
@@ -12,7 +12,7 @@ func TestNew(t *testing.T) {
 }
 
 func MustOpenDB(tb testing.TB, path string) *sqlite3.Sqlite {
-	db, err := sqlite3.New(path)
+	db, err := sqlite3.New(path, sqlite3.Options{ForeignKeys: true})
 	if err != nil {
 		tb.Fatal(err)
 	}
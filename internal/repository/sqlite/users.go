@@ -1,9 +1,10 @@
 package sqlite
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"time"
+	"strings"
 
 	"forum/internal/entity"
 	"forum/pkg/sqlite3"
@@ -17,8 +18,8 @@ func NewUsersRepo(sq *sqlite3.Sqlite) *UsersRepo {
 	return &UsersRepo{sq}
 }
 
-func (ur *UsersRepo) Store(user entity.User) error {
-	tx, err := ur.DB.Begin()
+func (ur *UsersRepo) Store(ctx context.Context, user entity.User) error {
+	tx, err := ur.Conn.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("UsersRepo - Store - Begin: %w", err)
 	}
@@ -26,8 +27,8 @@ func (ur *UsersRepo) Store(user entity.User) error {
 		err = tx.Rollback()
 	}()
 
-	stmt, err := tx.Prepare(`
-	INSERT INTO users(name, email, password, reg_date, date_of_birth, city, sex, role, sign) 
+	stmt, err := tx.PrepareContext(ctx, `
+	INSERT INTO users(name, email, password, reg_date, date_of_birth, city, sex, sign, email_verified) 
 		values(?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`)
 	if err != nil {
@@ -35,8 +36,8 @@ func (ur *UsersRepo) Store(user entity.User) error {
 	}
 	defer stmt.Close()
 
-	res, err := stmt.Exec(user.Name, user.Email, user.Password, user.RegDate,
-		user.DateOfBirth, user.City, user.Gender, RoleUser, " ")
+	res, err := stmt.ExecContext(ctx, user.Name, user.Email, user.Password, nullTime(user.RegDate),
+		user.DateOfBirth, user.City, user.Gender, " ", user.EmailVerified)
 	if err != nil {
 		return fmt.Errorf("UsersRepo - Store - Exec #1: %w", err)
 	}
@@ -54,71 +55,133 @@ func (ur *UsersRepo) Store(user entity.User) error {
 	return nil
 }
 
-func (ur *UsersRepo) Fetch() ([]entity.User, error) {
-	var users []entity.User
-
-	rows, err := ur.DB.Query(`
+// selectUsers lists users with their post and comment counters, callers append
+// conditions and ordering.
+const selectUsers = `
 	SELECT
-		id, name, email, reg_date, date_of_birth, city, sex, role,
-		(SELECT id FROM posts WHERE posts.user_id = users.id) AS posts,
-		(SELECT id FROM comments WHERE comments.user_id = users.id) AS comments,
-		(SELECT post_id FROM post_likes WHERE post_likes.user_id = users.id) AS post_likes,
-		(SELECT post_id FROM post_dislikes WHERE post_dislikes.user_id = users.id) AS post_dislikes,
-		(SELECT comment_id FROM comment_likes WHERE comment_likes.user_id = users.id) AS comment_likes,
-		(SELECT comment_id FROM comment_dislikes WHERE comment_dislikes.user_id = users.id) AS comment_dislikes
+		id, name, email, reg_date, date_of_birth, city, sex,
+		role_id, (SELECT name FROM roles WHERE roles.id = users.role_id),
+		post_count, comment_count
 	FROM users
-	`)
+	`
+
+func (ur *UsersRepo) Fetch(ctx context.Context) ([]entity.User, error) {
+	rows, err := ur.Conn.QueryContext(ctx, selectUsers)
 	if err != nil {
 		return nil, fmt.Errorf("UsersRepo - Fetch - Query: %w", err)
 	}
 	defer rows.Close()
+
+	users, err := scanUsers(rows)
+	if err != nil {
+		return nil, fmt.Errorf("UsersRepo - Fetch - %w", err)
+	}
+	return users, nil
+}
+
+func (ur *UsersRepo) FetchByIds(ctx context.Context, ids []int64) ([]entity.User, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	args := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	rows, err := ur.Conn.QueryContext(ctx, selectUsers+`
+	WHERE id IN (?`+strings.Repeat(", ?", len(ids)-1)+`)
+	ORDER BY id
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("UsersRepo - FetchByIds - Query: %w", err)
+	}
+	defer rows.Close()
+
+	users, err := scanUsers(rows)
+	if err != nil {
+		return nil, fmt.Errorf("UsersRepo - FetchByIds - %w", err)
+	}
+	return users, nil
+}
+
+func (ur *UsersRepo) FetchPage(ctx context.Context, limit, offset int) ([]entity.User, error) {
+	rows, err := ur.Conn.QueryContext(ctx, selectUsers+`
+	ORDER BY id
+	LIMIT ? OFFSET ?
+	`, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("UsersRepo - FetchPage - Query: %w", err)
+	}
+	defer rows.Close()
+
+	users, err := scanUsers(rows)
+	if err != nil {
+		return nil, fmt.Errorf("UsersRepo - FetchPage - %w", err)
+	}
+	return users, nil
+}
+
+func (ur *UsersRepo) FetchAfter(ctx context.Context, cursor int64, limit int) ([]entity.User, error) {
+	rows, err := ur.Conn.QueryContext(ctx, selectUsers+`
+	WHERE id > ?
+	ORDER BY id
+	LIMIT ?
+	`, cursor, limit)
+	if err != nil {
+		return nil, fmt.Errorf("UsersRepo - FetchAfter - Query: %w", err)
+	}
+	defer rows.Close()
+
+	users, err := scanUsers(rows)
+	if err != nil {
+		return nil, fmt.Errorf("UsersRepo - FetchAfter - %w", err)
+	}
+	return users, nil
+}
+
+func (ur *UsersRepo) Count(ctx context.Context) (int64, error) {
+	var count int64
+	err := ur.Conn.QueryRowContext(ctx, `
+	SELECT COUNT(*)
+	FROM users
+	`).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("UsersRepo - Count - Scan: %w", err)
+	}
+
+	return count, nil
+}
+
+func scanUsers(rows *sql.Rows) ([]entity.User, error) {
+	var users []entity.User
+
 	for rows.Next() {
 		user := entity.User{}
+		var regDate sql.NullString
 		var posts sql.NullInt64
 		var comments sql.NullInt64
-		var postLikes sql.NullInt64
-		var postDislikes sql.NullInt64
-		var commentLikes sql.NullInt64
-		var commentDislikes sql.NullInt64
 
-		err = rows.Scan(&user.Id, &user.Name, &user.Email, &user.RegDate, &user.DateOfBirth, &user.City,
-			&user.Gender, &user.Role, &posts, &comments, &postLikes, &postDislikes, &commentLikes, &commentDislikes)
+		err := rows.Scan(&user.Id, &user.Name, &user.Email, &regDate, &user.DateOfBirth, &user.City,
+			&user.Gender, &user.Role.Id, &user.Role.Name, &posts, &comments)
 		if err != nil {
-			return nil, fmt.Errorf("UsersRepo - Fetch - Scan: %w", err)
+			return nil, fmt.Errorf("Scan: %w", err)
 		}
 
+		user.RegDate = parseTime(regDate)
 		user.Posts = posts.Int64
 		user.Comments = comments.Int64
-		user.PostLikes = postLikes.Int64
-		user.PostDislikes = postDislikes.Int64
-		user.CommentLikes = commentLikes.Int64
-		user.CommentDislikes = commentDislikes.Int64
 		users = append(users, user)
 	}
 
 	return users, nil
 }
 
-func (ur *UsersRepo) GetId(user entity.User) (int64, error) {
+func (ur *UsersRepo) GetId(ctx context.Context, user entity.User) (int64, error) {
 	var id int64
 	switch {
-	case user.SessionToken != "":
-		stmt, err := ur.DB.Prepare(`
-		SELECT id
-		FROM users
-		WHERE session_token = ?
-		`)
-		if err != nil {
-			return 0, fmt.Errorf("UsersRepo - GetId - case Session - Query: %w", err)
-		}
-		defer stmt.Close()
-
-		err = stmt.QueryRow(user.SessionToken).Scan(&id)
-		if err != nil {
-			return 0, fmt.Errorf("UsersRepo - GetId - case Session - Scan: %w", err)
-		}
 	case user.Name != "":
-		stmt, err := ur.DB.Prepare(`
+		stmt, err := ur.Conn.PrepareContext(ctx, `
 		SELECT id
 		FROM users
 		WHERE name = ?
@@ -128,12 +191,12 @@ func (ur *UsersRepo) GetId(user entity.User) (int64, error) {
 		}
 		defer stmt.Close()
 
-		err = stmt.QueryRow(user.Name).Scan(&id)
+		err = stmt.QueryRowContext(ctx, user.Name).Scan(&id)
 		if err != nil {
 			return 0, fmt.Errorf("UsersRepo - GetId - case Name - Scan: %w", err)
 		}
 	case user.Email != "":
-		stmt, err := ur.DB.Prepare(`
+		stmt, err := ur.Conn.PrepareContext(ctx, `
 		SELECT id
 		FROM users
 		WHERE email = ?
@@ -143,7 +206,7 @@ func (ur *UsersRepo) GetId(user entity.User) (int64, error) {
 		}
 		defer stmt.Close()
 
-		err = stmt.QueryRow(user.Email).Scan(&id)
+		err = stmt.QueryRowContext(ctx, user.Email).Scan(&id)
 		if err != nil {
 			return 0, fmt.Errorf("UsersRepo - GetId - case Email - Scan: %w", err)
 		}
@@ -152,18 +215,15 @@ func (ur *UsersRepo) GetId(user entity.User) (int64, error) {
 	return id, nil
 }
 
-func (ur *UsersRepo) GetById(id int64) (entity.User, error) {
+func (ur *UsersRepo) GetById(ctx context.Context, id int64) (entity.User, error) {
 	var user entity.User
-	stmt, err := ur.DB.Prepare(`
+	stmt, err := ur.Conn.PrepareContext(ctx, `
 	SELECT
-		id, name, email, password, reg_date, date_of_birth, city, sex, role, sign,
+		id, name, email, password, reg_date, date_of_birth, city, sex, sign, timezone,
+		role_id, (SELECT name FROM roles WHERE roles.id = users.role_id), banned,
+		email_verified, pending_email, verification_sent_at, totp_enabled,
 		(SELECT path FROM images WHERE images.user_id = ?),
-		(SELECT COUNT(*) FROM posts WHERE posts.user_id = users.id) AS posts,
-		(SELECT COUNT(*) FROM comments WHERE comments.user_id = users.id) AS comments,
-		(SELECT COUNT(*) FROM post_likes WHERE post_likes.user_id = users.id) AS post_likes,
-		(SELECT COUNT(*) FROM post_dislikes WHERE post_dislikes.user_id = users.id) AS post_dislikes,
-		(SELECT COUNT(*) FROM comment_likes WHERE comment_likes.user_id = users.id) AS comment_likes,
-		(SELECT COUNT(*) FROM comment_dislikes WHERE comment_dislikes.user_id = users.id) AS comment_dislikes
+		post_count, comment_count
 	FROM users
 	WHERE id = ?
 	`)
@@ -171,30 +231,28 @@ func (ur *UsersRepo) GetById(id int64) (entity.User, error) {
 		return user, fmt.Errorf("UsersRepo - GetById - Query: %w", err)
 	}
 	defer stmt.Close()
+	var regDate sql.NullString
 	var posts sql.NullInt64
 	var comments sql.NullInt64
-	var postLikes sql.NullInt64
-	var postDislikes sql.NullInt64
-	var commentLikes sql.NullInt64
-	var commentDislikes sql.NullInt64
-	var sign sql.NullString
+	var sign, timezone sql.NullString
 	var avatarPath sql.NullString
+	var sentAt sql.NullString
 
-	err = stmt.QueryRow(id, id).Scan(&user.Id, &user.Name, &user.Email, &user.Password, &user.RegDate,
-		&user.DateOfBirth, &user.City, &user.Gender, &user.Role, &sign, &avatarPath, &posts, &comments,
-		&postLikes, &postDislikes, &commentLikes, &commentDislikes)
+	err = stmt.QueryRowContext(ctx, id, id).Scan(&user.Id, &user.Name, &user.Email, &user.Password, &regDate,
+		&user.DateOfBirth, &user.City, &user.Gender, &sign, &timezone, &user.Role.Id, &user.Role.Name,
+		&user.Banned, &user.EmailVerified,
+		&user.PendingEmail, &sentAt, &user.TwoFactor, &avatarPath, &posts, &comments)
 	if err != nil {
 		return user, fmt.Errorf("UsersRepo - GetById - Scan: %w", err)
 	}
 
 	user.Posts = posts.Int64
 	user.Comments = comments.Int64
-	user.PostLikes = postLikes.Int64
-	user.PostDislikes = postDislikes.Int64
-	user.CommentLikes = commentLikes.Int64
-	user.CommentDislikes = commentDislikes.Int64
+	user.RegDate = parseTime(regDate)
 	user.Sign = sign.String
+	user.Timezone = timezone.String
 	user.AvatarPath = avatarPath.String
+	user.VerificationSentAt = parseTime(sentAt)
 
 	if user.DateOfBirth == "0001-01-01" {
 		user.DateOfBirth = ""
@@ -203,38 +261,8 @@ func (ur *UsersRepo) GetById(id int64) (entity.User, error) {
 	return user, nil
 }
 
-func (ur *UsersRepo) GetSession(n int64) (entity.User, error) {
-	var user entity.User
-	stmt, err := ur.DB.Prepare(`
-	SELECT
-		session_token, session_ttl
-	FROM users
-	WHERE id = ?
-	`)
-	if err != nil {
-		return user, fmt.Errorf("UsersRepo - GetSession - Query: %w", err)
-	}
-	defer stmt.Close()
-	var sessionToken sql.NullString
-	var sessionTTL sql.NullString
-	err = stmt.QueryRow(n).Scan(&sessionToken, &sessionTTL)
-	if err != nil {
-		return user, fmt.Errorf("UsersRepo - GetSession - Scan: %w", err)
-	}
-	if sessionTTL.String == "" {
-		return user, entity.ErrUserNotFound
-	}
-	TTLParsed, err := time.Parse(DateAndTimeFormat, sessionTTL.String)
-	if err != nil {
-		return user, fmt.Errorf("UsersRepo - GetSession - Parse TTL: %w", err)
-	}
-	user.SessionTTL = TTLParsed
-	user.SessionToken = sessionToken.String
-	return user, nil
-}
-
-func (ur *UsersRepo) UpdateInfo(user entity.User) error {
-	tx, err := ur.DB.Begin()
+func (ur *UsersRepo) UpdateInfo(ctx context.Context, user entity.User) error {
+	tx, err := ur.Conn.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("UsersRepo - Update - Begin: %w", err)
 	}
@@ -242,9 +270,9 @@ func (ur *UsersRepo) UpdateInfo(user entity.User) error {
 		err = tx.Rollback()
 	}()
 
-	stmt, err := ur.DB.Prepare(`
+	stmt, err := tx.PrepareContext(ctx, `
 	UPDATE users
-	SET date_of_birth = ?, city = ?, sex = ?, sign = ?, role = ?
+	SET date_of_birth = ?, city = ?, sex = ?, sign = ?, timezone = ?
 	WHERE id = ?
 	`)
 	if err != nil {
@@ -252,7 +280,8 @@ func (ur *UsersRepo) UpdateInfo(user entity.User) error {
 	}
 	defer stmt.Close()
 
-	res, err := stmt.Exec(user.DateOfBirth, user.City, user.Gender, user.Sign, user.Role, user.Id)
+	res, err := stmt.ExecContext(ctx, user.DateOfBirth, user.City, user.Gender, user.Sign, user.Timezone,
+		user.Id)
 	if err != nil {
 		return fmt.Errorf("UsersRepo - Update - Exec #1: %w", err)
 	}
@@ -262,7 +291,7 @@ func (ur *UsersRepo) UpdateInfo(user entity.User) error {
 		return fmt.Errorf("UsersRepo - Update - RowsAffected: %w", err)
 	}
 
-	row := tx.QueryRow(`
+	row := tx.QueryRowContext(ctx, `
 		SELECT path 
 		FROM images WHERE user_id = ?
 	`, user.Id)
@@ -270,7 +299,7 @@ func (ur *UsersRepo) UpdateInfo(user entity.User) error {
 	var avatarPath sql.NullString
 
 	if err = row.Scan(&avatarPath); err == sql.ErrNoRows {
-		res, err = tx.Exec(`
+		res, err = tx.ExecContext(ctx, `
 		INSERT INTO images(user_id, path)
 		VALUES (?, ?)
 		`, user.Id, user.AvatarPath)
@@ -282,7 +311,7 @@ func (ur *UsersRepo) UpdateInfo(user entity.User) error {
 			return fmt.Errorf("UsersRepo - Update - RowsAffected #2: %w", err)
 		}
 	} else {
-		res, err = tx.Exec(`
+		res, err = tx.ExecContext(ctx, `
 		UPDATE images
 		SET path = ?
 		WHERE user_id = ?
@@ -304,8 +333,8 @@ func (ur *UsersRepo) UpdateInfo(user entity.User) error {
 	return nil
 }
 
-func (ur *UsersRepo) UpdatePassword(user entity.User) error {
-	tx, err := ur.DB.Begin()
+func (ur *UsersRepo) UpdatePassword(ctx context.Context, user entity.User) error {
+	tx, err := ur.Conn.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("UsersRepo - UpdatePassword - Begin: %w", err)
 	}
@@ -313,7 +342,7 @@ func (ur *UsersRepo) UpdatePassword(user entity.User) error {
 		err = tx.Rollback()
 	}()
 
-	stmt, err := ur.DB.Prepare(`
+	stmt, err := tx.PrepareContext(ctx, `
 	UPDATE users
 	SET password = ?
 	WHERE id = ?
@@ -323,7 +352,7 @@ func (ur *UsersRepo) UpdatePassword(user entity.User) error {
 	}
 	defer stmt.Close()
 
-	res, err := stmt.Exec(user.Password, user.Id)
+	res, err := stmt.ExecContext(ctx, user.Password, user.Id)
 	if err != nil {
 		return fmt.Errorf("UsersRepo - UpdatePassword - Exec: %w", err)
 	}
@@ -341,82 +370,73 @@ func (ur *UsersRepo) UpdatePassword(user entity.User) error {
 	return nil
 }
 
-func (ur *UsersRepo) NewSession(user entity.User) error {
-	tx, err := ur.DB.Begin()
-	if err != nil {
-		return fmt.Errorf("UsersRepo - NewSession - Begin: %w", err)
-	}
-	defer func() {
-		err = tx.Rollback()
-	}()
-
-	stmt, err := ur.DB.Prepare(`
+// UpdateVerification overwrites PendingEmail and VerificationSentAt.
+func (ur *UsersRepo) UpdateVerification(ctx context.Context, user entity.User) error {
+	res, err := ur.Conn.ExecContext(ctx, `
 	UPDATE users
-	SET session_token = ?, session_ttl = ?
+	SET pending_email = ?, verification_sent_at = ?
 	WHERE id = ?
-	`)
+	`, user.PendingEmail, nullTime(user.VerificationSentAt), user.Id)
 	if err != nil {
-		return fmt.Errorf("UsersRepo - NewSession - Prepare: %w", err)
-	}
-	defer stmt.Close()
-	sessionTTL := user.SessionTTL.Format(DateAndTimeFormat)
-	res, err := stmt.Exec(user.SessionToken, sessionTTL, user.Id)
-	if err != nil {
-		return fmt.Errorf("UsersRepo - NewSession - Exec: %w", err)
+		return fmt.Errorf("UsersRepo - UpdateVerification - Exec: %w", err)
 	}
 
 	affected, err := res.RowsAffected()
 	if affected != 1 || err != nil {
-		return fmt.Errorf("UsersRepo - NewSession - RowsAffected: %w", err)
-	}
-
-	err = tx.Commit()
-	if err != nil {
-		return fmt.Errorf("UsersRepo - NewSession - Commit: %w", err)
+		return fmt.Errorf("UsersRepo - UpdateVerification - RowsAffected: %w", err)
 	}
-
 	return nil
 }
 
-func (ur *UsersRepo) UpdateSession(user entity.User) error {
-	tx, err := ur.DB.Begin()
-	if err != nil {
-		return fmt.Errorf("UsersRepo - UpdateSession - Begin: %w", err)
-	}
-	defer func() {
-		err = tx.Rollback()
-	}()
-
-	stmt, err := ur.DB.Prepare(`
+// VerifyEmail makes email the verified email of the user and clears the
+// pending one.
+func (ur *UsersRepo) VerifyEmail(ctx context.Context, id int64, email string) error {
+	res, err := ur.Conn.ExecContext(ctx, `
 	UPDATE users
-	SET session_ttl = ?
+	SET email = ?, email_verified = 1, pending_email = ''
 	WHERE id = ?
-	`)
+	`, email, id)
 	if err != nil {
-		return fmt.Errorf("UsersRepo - UpdateSession - Prepare: %w", err)
+		return fmt.Errorf("UsersRepo - VerifyEmail - Exec: %w", err)
 	}
-	defer stmt.Close()
-	sessionTTL := user.SessionTTL.Format(DateAndTimeFormat)
-	res, err := stmt.Exec(sessionTTL, user.Id)
+
+	affected, err := res.RowsAffected()
+	if affected != 1 || err != nil {
+		return fmt.Errorf("UsersRepo - VerifyEmail - RowsAffected: %w", err)
+	}
+	return nil
+}
+
+// SetRole gives the user the role roleId.
+func (ur *UsersRepo) SetRole(ctx context.Context, id, roleId int64) error {
+	res, err := ur.Conn.ExecContext(ctx, `UPDATE users SET role_id = ? WHERE id = ?`, roleId, id)
 	if err != nil {
-		return fmt.Errorf("UsersRepo - UpdateSession - Exec: %w", err)
+		return fmt.Errorf("UsersRepo - SetRole - Exec: %w", err)
 	}
 
 	affected, err := res.RowsAffected()
 	if affected != 1 || err != nil {
-		return fmt.Errorf("UsersRepo - UpdateSession - RowsAffected: %w", err)
+		return fmt.Errorf("UsersRepo - SetRole - RowsAffected: %w", err)
 	}
+	return nil
+}
 
-	err = tx.Commit()
+// SetBanned bans the user or lifts the ban.
+func (ur *UsersRepo) SetBanned(ctx context.Context, id int64, banned bool) error {
+	res, err := ur.Conn.ExecContext(ctx, `UPDATE users SET banned = ? WHERE id = ?`, banned, id)
 	if err != nil {
-		return fmt.Errorf("UsersRepo - UpdateSession - Commit: %w", err)
+		return fmt.Errorf("UsersRepo - SetBanned - Exec: %w", err)
 	}
 
+	affected, err := res.RowsAffected()
+	if affected != 1 || err != nil {
+		return fmt.Errorf("UsersRepo - SetBanned - RowsAffected: %w", err)
+	}
 	return nil
 }
 
-func (ur *UsersRepo) Delete(user entity.User) error {
-	tx, err := ur.DB.Begin()
+func (ur *UsersRepo) Delete(ctx context.Context, user entity.User) error {
+	tx, err := ur.Conn.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("UsersRepo - Delete - Begin: %w", err)
 	}
@@ -424,7 +444,12 @@ func (ur *UsersRepo) Delete(user entity.User) error {
 		err = tx.Rollback()
 	}()
 
-	stmt, err := ur.DB.Prepare(`
+	_, err = tx.ExecContext(ctx, `DELETE FROM images WHERE user_id = ?`, user.Id)
+	if err != nil {
+		return fmt.Errorf("UsersRepo - Delete - Exec #1: %w", err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
 	DELETE FROM users
 	WHERE id = ?
 	`)
@@ -433,9 +458,9 @@ func (ur *UsersRepo) Delete(user entity.User) error {
 	}
 	defer stmt.Close()
 
-	res, err := stmt.Exec(user.Id)
+	res, err := stmt.ExecContext(ctx, user.Id)
 	if err != nil {
-		return fmt.Errorf("UsersRepo - Delete - Exec: %w", err)
+		return fmt.Errorf("UsersRepo - Delete - Exec #2: %w", err)
 	}
 
 	affected, err := res.RowsAffected()
@@ -0,0 +1,706 @@
+package usecase
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"forum/internal/entity"
+	"forum/internal/repository"
+)
+
+// Entries of an export archive.
+const (
+	archiveManifest = "forum.json"
+	archiveImages   = "images/"
+)
+
+type ArchiveUseCase struct {
+	uow      repository.UnitOfWork
+	imageDir string
+}
+
+// NewArchiveUseCase exports and imports the forum stored in the
+// repositories of uow, uploaded images are kept in imageDir.
+func NewArchiveUseCase(uow repository.UnitOfWork, imageDir string) *ArchiveUseCase {
+	return &ArchiveUseCase{
+		uow:      uow,
+		imageDir: imageDir,
+	}
+}
+
+// Export writes the whole forum to w as a zip archive of forum.json and
+// the images it refers to. Password hashes are left out unless
+// withPasswords is set. Trashed posts and comments are exported with their
+// deletion mark, revisions and sessions are not.
+func (au *ArchiveUseCase) Export(ctx context.Context, w io.Writer, withPasswords bool) error {
+	var archive entity.Archive
+	var images []string
+	// reads share a transaction to see one state of the forum
+	err := au.uow.Do(ctx, func(repos *repository.Repositories) error {
+		var err error
+		archive, images, err = au.collect(ctx, repos, withPasswords)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("ArchiveUseCase - Export #1 - %w", err)
+	}
+
+	zw := zip.NewWriter(w)
+	manifest, err := zw.Create(archiveManifest)
+	if err != nil {
+		return fmt.Errorf("ArchiveUseCase - Export #2 - %w", err)
+	}
+	encoder := json.NewEncoder(manifest)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(archive)
+	if err != nil {
+		return fmt.Errorf("ArchiveUseCase - Export #3 - %w", err)
+	}
+	for _, name := range images {
+		err = au.exportImage(zw, name)
+		if err != nil {
+			return fmt.Errorf("ArchiveUseCase - Export #4 - %w", err)
+		}
+	}
+	err = zw.Close()
+	if err != nil {
+		return fmt.Errorf("ArchiveUseCase - Export #5 - %w", err)
+	}
+	return nil
+}
+
+// collect reads the forum into an archive and returns the file names of
+// the images it refers to.
+func (au *ArchiveUseCase) collect(ctx context.Context, repos *repository.Repositories,
+	withPasswords bool) (entity.Archive, []string, error) {
+	archive := entity.Archive{
+		Version:    entity.ArchiveVersion,
+		ExportedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	var images []string
+	addImage := func(path string) string {
+		name := au.imageName(path)
+		if name != "" {
+			images = append(images, name)
+		}
+		return name
+	}
+	addImages := func(paths []string) []string {
+		var names []string
+		for _, path := range paths {
+			if name := addImage(path); name != "" {
+				names = append(names, name)
+			}
+		}
+		return names
+	}
+
+	roles, err := repos.Roles.Fetch(ctx)
+	if err != nil {
+		return archive, nil, fmt.Errorf("collect #1 - %w", err)
+	}
+	for _, role := range roles {
+		archive.Roles = append(archive.Roles, entity.ArchivedRole{
+			Name:        role.Name,
+			Permissions: role.Permissions,
+		})
+	}
+
+	listed, err := repos.Users.Fetch(ctx)
+	if err != nil {
+		return archive, nil, fmt.Errorf("collect #2 - %w", err)
+	}
+	for _, found := range listed {
+		user, err := repos.Users.GetById(ctx, found.Id)
+		if err != nil {
+			return archive, nil, fmt.Errorf("collect #3 - %w", err)
+		}
+		archived := entity.ArchivedUser{
+			Id:          user.Id,
+			Name:        user.Name,
+			Email:       user.Email,
+			Verified:    user.EmailVerified,
+			RegDate:     user.RegDate,
+			DateOfBirth: user.DateOfBirth,
+			City:        user.City,
+			Gender:      user.Gender,
+			Role:        user.Role.Name,
+			Banned:      user.Banned,
+			Sign:        strings.TrimSpace(user.Sign),
+			Timezone:    user.Timezone,
+			Avatar:      addImage(user.AvatarPath),
+		}
+		if withPasswords {
+			archived.Password = user.Password
+		}
+		archive.Users = append(archive.Users, archived)
+	}
+
+	categories, err := repos.Categories.Fetch(ctx)
+	if err != nil {
+		return archive, nil, fmt.Errorf("collect #4 - %w", err)
+	}
+	for _, category := range categoryTree(categories) {
+		archive.Categories = append(archive.Categories, entity.ArchivedCategory{
+			Id:          category.Id,
+			ParentId:    category.ParentId,
+			Name:        category.Name,
+			Slug:        category.Slug,
+			Description: category.Description,
+			Position:    category.Position,
+			Color:       category.Color,
+		})
+	}
+
+	posts, err := repos.Posts.Fetch(ctx)
+	if err != nil {
+		return archive, nil, fmt.Errorf("collect #5 - %w", err)
+	}
+	deleted, err := repos.Posts.FetchDeleted(ctx)
+	if err != nil {
+		return archive, nil, fmt.Errorf("collect #6 - %w", err)
+	}
+	posts = append(posts, deleted...)
+	sort.Slice(posts, func(i, j int) bool {
+		return posts[i].Id < posts[j].Id
+	})
+	postIds := make([]int64, 0, len(posts))
+	for _, post := range posts {
+		postIds = append(postIds, post.Id)
+	}
+	postCategories, err := repos.Posts.FetchCategories(ctx, postIds)
+	if err != nil {
+		return archive, nil, fmt.Errorf("collect #7 - %w", err)
+	}
+	for _, post := range posts {
+		// listings leave images out
+		full, err := repos.Posts.GetById(ctx, post.Id)
+		if err != nil {
+			return archive, nil, fmt.Errorf("collect #8 - %w", err)
+		}
+		var categoryIds []int64
+		for _, category := range postCategories[post.Id] {
+			categoryIds = append(categoryIds, category.Id)
+		}
+		archive.Posts = append(archive.Posts, entity.ArchivedPost{
+			Id:           post.Id,
+			UserId:       post.User.Id,
+			Date:         post.Date,
+			Title:        post.Title,
+			Content:      post.Content,
+			Images:       addImages(full.Images),
+			Categories:   categoryIds,
+			EditedAt:     optionalTime(post.EditedAt),
+			DeletedAt:    optionalTime(post.DeletedAt),
+			DeletedBy:    post.DeletedBy,
+			DeleteReason: post.DeleteReason,
+		})
+	}
+
+	byPost, err := repos.Comments.FetchByPosts(ctx, postIds)
+	if err != nil {
+		return archive, nil, fmt.Errorf("collect #9 - %w", err)
+	}
+	for _, comments := range byPost {
+		for _, comment := range comments {
+			archive.Comments = append(archive.Comments, entity.ArchivedComment{
+				Id:           comment.Id,
+				PostId:       comment.PostId,
+				ParentId:     comment.ParentId,
+				UserId:       comment.User.Id,
+				Date:         comment.Date,
+				Content:      comment.Content,
+				Images:       addImages(comment.Images),
+				EditedAt:     optionalTime(comment.EditedAt),
+				DeletedAt:    optionalTime(comment.DeletedAt),
+				DeletedBy:    comment.DeletedBy,
+				DeleteReason: comment.DeleteReason,
+			})
+		}
+	}
+	// replies are newer than their parents
+	sort.Slice(archive.Comments, func(i, j int) bool {
+		return archive.Comments[i].Id < archive.Comments[j].Id
+	})
+
+	for _, target := range []string{entity.ReactionTargetPost, entity.ReactionTargetComment} {
+		reactions, err := repos.Reactions.FetchAll(ctx, target)
+		if err != nil {
+			return archive, nil, fmt.Errorf("collect #10 - %w", err)
+		}
+		archive.Reactions = append(archive.Reactions, reactions...)
+	}
+
+	return archive, images, nil
+}
+
+// imageName returns the file name of an uploaded image, or "" when path
+// doesn't point to a file of the image directory.
+func (au *ArchiveUseCase) imageName(path string) string {
+	name := filepath.Base(path)
+	if path == "" || name == "/" || name == "." {
+		return ""
+	}
+	info, err := os.Stat(filepath.Join(au.imageDir, name))
+	if err != nil || !info.Mode().IsRegular() {
+		return ""
+	}
+	return name
+}
+
+func (au *ArchiveUseCase) exportImage(zw *zip.Writer, name string) error {
+	file, err := os.Open(filepath.Join(au.imageDir, name))
+	if err != nil {
+		return fmt.Errorf("exportImage - %w", err)
+	}
+	defer file.Close()
+
+	entry, err := zw.Create(archiveImages + name)
+	if err != nil {
+		return fmt.Errorf("exportImage - %w", err)
+	}
+	_, err = io.Copy(entry, file)
+	if err != nil {
+		return fmt.Errorf("exportImage - %w", err)
+	}
+	return nil
+}
+
+// Import recreates an export read from r on this forum in one
+// transaction. Every record gets a new id, users whose email is already
+// registered are taken over instead. Users exported without passwords
+// can't sign in until they set a new one.
+func (au *ArchiveUseCase) Import(ctx context.Context, r io.ReaderAt, size int64) (entity.ImportSummary, error) {
+	var summary entity.ImportSummary
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return summary, fmt.Errorf("ArchiveUseCase - Import #1 - %v: %w", err, entity.ErrArchiveInvalid)
+	}
+
+	var archive entity.Archive
+	images := make(map[string]*zip.File)
+	found := false
+	for _, file := range zr.File {
+		switch {
+		case file.Name == archiveManifest:
+			err = readManifest(file, &archive)
+			if err != nil {
+				return summary, fmt.Errorf("ArchiveUseCase - Import #2 - %w", err)
+			}
+			found = true
+		case strings.HasPrefix(file.Name, archiveImages):
+			name := strings.TrimPrefix(file.Name, archiveImages)
+			if name != filepath.Base(name) || name == "" || name == "." || name == ".." {
+				return summary, fmt.Errorf("ArchiveUseCase - Import #3 - image %q: %w", file.Name, entity.ErrArchiveInvalid)
+			}
+			images[name] = file
+		}
+	}
+	if !found {
+		return summary, fmt.Errorf("ArchiveUseCase - Import #4 - no %s: %w", archiveManifest, entity.ErrArchiveInvalid)
+	}
+	if archive.Version != entity.ArchiveVersion {
+		return summary, fmt.Errorf("ArchiveUseCase - Import #5 - version %d: %w", archive.Version, entity.ErrArchiveVersion)
+	}
+
+	paths, written, err := au.importImages(images)
+	defer func() {
+		// images are only kept along with the records using them
+		if err != nil {
+			for _, path := range written {
+				os.Remove(path)
+			}
+		}
+	}()
+	if err != nil {
+		return summary, fmt.Errorf("ArchiveUseCase - Import #6 - %w", err)
+	}
+	summary.Images = len(written)
+
+	err = au.uow.Do(ctx, func(repos *repository.Repositories) error {
+		return restore(ctx, repos, archive, paths, &summary)
+	})
+	if err != nil {
+		return entity.ImportSummary{}, fmt.Errorf("ArchiveUseCase - Import #7 - %w", err)
+	}
+	return summary, nil
+}
+
+func readManifest(file *zip.File, archive *entity.Archive) error {
+	manifest, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("readManifest - %v: %w", err, entity.ErrArchiveInvalid)
+	}
+	defer manifest.Close()
+
+	err = json.NewDecoder(manifest).Decode(archive)
+	if err != nil {
+		return fmt.Errorf("readManifest - %v: %w", err, entity.ErrArchiveInvalid)
+	}
+	return nil
+}
+
+// importImages copies the images of an export into the image directory and
+// returns the paths records refer to them by, keyed by file name, and the
+// files it wrote. Files that exist already are kept.
+func (au *ArchiveUseCase) importImages(images map[string]*zip.File) (map[string]string, []string, error) {
+	paths := make(map[string]string, len(images))
+	var written []string
+	if len(images) == 0 {
+		return paths, written, nil
+	}
+	err := os.MkdirAll(au.imageDir, os.ModePerm)
+	if err != nil {
+		return paths, written, fmt.Errorf("importImages - %w", err)
+	}
+
+	for name, file := range images {
+		path := filepath.Join(au.imageDir, name)
+		paths[name] = "/" + path
+		if _, err := os.Stat(path); err == nil {
+			continue
+		}
+		err = copyImage(file, path)
+		if err != nil {
+			return paths, written, fmt.Errorf("importImages - %w", err)
+		}
+		written = append(written, path)
+	}
+	return paths, written, nil
+}
+
+func copyImage(file *zip.File, path string) error {
+	src, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("copyImage - %v: %w", err, entity.ErrArchiveInvalid)
+	}
+	defer src.Close()
+
+	dst, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("copyImage - %w", err)
+	}
+	_, err = io.Copy(dst, src)
+	if err != nil {
+		dst.Close()
+		os.Remove(path)
+		return fmt.Errorf("copyImage - %w", err)
+	}
+	return dst.Close()
+}
+
+// restore stores the records of the archive mapping their ids to the new
+// ones, images refers to image paths by file name.
+func restore(ctx context.Context, repos *repository.Repositories, archive entity.Archive, images map[string]string,
+	summary *entity.ImportSummary,
+) error {
+	roleIds, err := restoreRoles(ctx, repos, archive.Roles)
+	if err != nil {
+		return fmt.Errorf("restore #1 - %w", err)
+	}
+
+	userIds := make(map[int64]int64, len(archive.Users))
+	for _, archived := range archive.Users {
+		id, err := repos.Users.GetId(ctx, entity.User{Email: archived.Email})
+		if err == nil {
+			userIds[archived.Id] = id
+			continue
+		}
+		if !strings.Contains(err.Error(), NoRowsResultErr) {
+			return fmt.Errorf("restore #2 - %w", err)
+		}
+
+		err = repos.Users.Store(ctx, entity.User{
+			Name:          archived.Name,
+			Email:         archived.Email,
+			EmailVerified: archived.Verified,
+			Password:      archived.Password,
+			RegDate:       archived.RegDate,
+			DateOfBirth:   archived.DateOfBirth,
+			City:          archived.City,
+			Gender:        archived.Gender,
+		})
+		if err != nil {
+			return fmt.Errorf("restore #3 - %w", err)
+		}
+		id, err = repos.Users.GetId(ctx, entity.User{Email: archived.Email})
+		if err != nil {
+			return fmt.Errorf("restore #4 - %w", err)
+		}
+		// Store gives every user the default role, the rest of the
+		// profile is set by UpdateInfo
+		user, err := repos.Users.GetById(ctx, id)
+		if err != nil {
+			return fmt.Errorf("restore #5 - %w", err)
+		}
+		if archived.Sign != "" {
+			user.Sign = archived.Sign
+		}
+		user.Timezone = archived.Timezone
+		user.AvatarPath = images[archived.Avatar]
+		err = repos.Users.UpdateInfo(ctx, user)
+		if err != nil {
+			return fmt.Errorf("restore #6 - %w", err)
+		}
+		if archived.Role != "" {
+			roleId, ok := roleIds[archived.Role]
+			if !ok {
+				return fmt.Errorf("restore #7 - user %d has unknown role %q: %w", archived.Id, archived.Role,
+					entity.ErrArchiveInvalid)
+			}
+			err = repos.Users.SetRole(ctx, id, roleId)
+			if err != nil {
+				return fmt.Errorf("restore #8 - %w", err)
+			}
+		}
+		if archived.Banned {
+			err = repos.Users.SetBanned(ctx, id, true)
+			if err != nil {
+				return fmt.Errorf("restore #9 - %w", err)
+			}
+		}
+		userIds[archived.Id] = id
+		summary.Users++
+	}
+	userId := func(id int64, record string) (int64, error) {
+		newId, ok := userIds[id]
+		if !ok {
+			return 0, fmt.Errorf("%s of unknown user %d: %w", record, id, entity.ErrArchiveInvalid)
+		}
+		return newId, nil
+	}
+
+	// categories are taken over by name, slugs are numbered if taken
+	existed, err := repos.Categories.Fetch(ctx)
+	if err != nil {
+		return fmt.Errorf("restore #10 - %w", err)
+	}
+	byName := make(map[string]int64, len(existed))
+	slugs := make(map[string]bool, len(existed))
+	for _, category := range existed {
+		byName[category.Name] = category.Id
+		slugs[category.Slug] = true
+	}
+	categoryIds := make(map[int64]int64, len(archive.Categories))
+	for _, archived := range archive.Categories {
+		if id, ok := byName[archived.Name]; ok {
+			categoryIds[archived.Id] = id
+			continue
+		}
+		parentId, ok := categoryIds[archived.ParentId]
+		if archived.ParentId != 0 && !ok {
+			return fmt.Errorf("restore #11 - category %d nests under unknown category %d: %w", archived.Id,
+				archived.ParentId, entity.ErrArchiveInvalid)
+		}
+		slug := archived.Slug
+		if slug == "" {
+			slug = slugify(archived.Name)
+		}
+		category := entity.Category{
+			ParentId:    parentId,
+			Name:        archived.Name,
+			Slug:        uniqueSlug(slug, slugs),
+			Description: archived.Description,
+			Position:    archived.Position,
+			Color:       archived.Color,
+		}
+		err = repos.Categories.Store(ctx, &category)
+		if err != nil {
+			return fmt.Errorf("restore #12 - %w", err)
+		}
+		byName[category.Name] = category.Id
+		slugs[category.Slug] = true
+		categoryIds[archived.Id] = category.Id
+	}
+
+	postIds := make(map[int64]int64, len(archive.Posts))
+	for _, archived := range archive.Posts {
+		authorId, err := userId(archived.UserId, fmt.Sprint("post ", archived.Id))
+		if err != nil {
+			return fmt.Errorf("restore #13 - %w", err)
+		}
+		post := entity.Post{
+			User:    entity.User{Id: authorId},
+			Date:    archived.Date,
+			Title:   archived.Title,
+			Content: archived.Content,
+			Images:  imagePaths(images, archived.Images),
+		}
+		for _, id := range archived.Categories {
+			categoryId, ok := categoryIds[id]
+			if !ok {
+				return fmt.Errorf("restore #14 - post %d in unknown category %d: %w", archived.Id, id,
+					entity.ErrArchiveInvalid)
+			}
+			post.Categories = append(post.Categories, entity.Category{Id: categoryId})
+		}
+		err = repos.Posts.Store(ctx, &post)
+		if err != nil {
+			return fmt.Errorf("restore #15 - %w", err)
+		}
+		err = repos.Posts.StoreTopicReference(ctx, post)
+		if err != nil {
+			return fmt.Errorf("restore #16 - %w", err)
+		}
+		if archived.EditedAt != nil {
+			post.EditedAt = *archived.EditedAt
+			post.Categories, post.Images = nil, nil
+			err = repos.Posts.Update(ctx, post)
+			if err != nil {
+				return fmt.Errorf("restore #17 - %w", err)
+			}
+		}
+		if archived.DeletedAt != nil {
+			err = repos.Posts.Delete(ctx, entity.Post{
+				Id:           post.Id,
+				DeletedAt:    *archived.DeletedAt,
+				DeletedBy:    userIds[archived.DeletedBy],
+				DeleteReason: archived.DeleteReason,
+			})
+			if err != nil {
+				return fmt.Errorf("restore #18 - %w", err)
+			}
+		}
+		postIds[archived.Id] = post.Id
+		summary.Posts++
+	}
+
+	commentIds := make(map[int64]int64, len(archive.Comments))
+	for _, archived := range archive.Comments {
+		record := fmt.Sprint("comment ", archived.Id)
+		authorId, err := userId(archived.UserId, record)
+		if err != nil {
+			return fmt.Errorf("restore #19 - %w", err)
+		}
+		postId, ok := postIds[archived.PostId]
+		if !ok {
+			return fmt.Errorf("restore #20 - %s of unknown post %d: %w", record, archived.PostId, entity.ErrArchiveInvalid)
+		}
+		parentId, ok := commentIds[archived.ParentId]
+		if archived.ParentId != 0 && !ok {
+			return fmt.Errorf("restore #21 - %s replies to unknown comment %d: %w", record, archived.ParentId,
+				entity.ErrArchiveInvalid)
+		}
+		comment := entity.Comment{
+			PostId:   postId,
+			ParentId: parentId,
+			User:     entity.User{Id: authorId},
+			Date:     archived.Date,
+			Content:  archived.Content,
+			Images:   imagePaths(images, archived.Images),
+		}
+		err = repos.Comments.Store(ctx, &comment)
+		if err != nil {
+			return fmt.Errorf("restore #22 - %w", err)
+		}
+		if archived.EditedAt != nil {
+			comment.EditedAt = *archived.EditedAt
+			comment.Images = nil
+			err = repos.Comments.Update(ctx, comment)
+			if err != nil {
+				return fmt.Errorf("restore #23 - %w", err)
+			}
+		}
+		if archived.DeletedAt != nil {
+			err = repos.Comments.Delete(ctx, entity.Comment{
+				Id:           comment.Id,
+				DeletedAt:    *archived.DeletedAt,
+				DeletedBy:    userIds[archived.DeletedBy],
+				DeleteReason: archived.DeleteReason,
+			})
+			if err != nil {
+				return fmt.Errorf("restore #24 - %w", err)
+			}
+		}
+		commentIds[archived.Id] = comment.Id
+		summary.Comments++
+	}
+
+	targetIds := map[string]map[int64]int64{
+		entity.ReactionTargetPost:    postIds,
+		entity.ReactionTargetComment: commentIds,
+	}
+	for _, reaction := range archive.Reactions {
+		record := fmt.Sprintf("%s reaction on %d", reaction.Kind, reaction.TargetId)
+		targetId, ok := targetIds[reaction.Target][reaction.TargetId]
+		if !ok {
+			return fmt.Errorf("restore #25 - %s of unknown target: %w", record, entity.ErrArchiveInvalid)
+		}
+		reactorId, err := userId(reaction.UserId, record)
+		if err != nil {
+			return fmt.Errorf("restore #26 - %w", err)
+		}
+		reaction.TargetId = targetId
+		reaction.UserId = reactorId
+		err = repos.Reactions.Store(ctx, reaction)
+		if err != nil {
+			return fmt.Errorf("restore #27 - %w", err)
+		}
+		summary.Reactions++
+	}
+	return nil
+}
+
+// restoreRoles stores the roles of the archive the forum has no role of
+// the name for and returns the ids of all roles by name.
+func restoreRoles(ctx context.Context, repos *repository.Repositories, archived []entity.ArchivedRole,
+) (map[string]int64, error) {
+	existed, err := repos.Roles.Fetch(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("restoreRoles - %w", err)
+	}
+	ids := make(map[string]int64, len(existed)+len(archived))
+	for _, role := range existed {
+		ids[role.Name] = role.Id
+	}
+
+	for _, archivedRole := range archived {
+		if _, ok := ids[archivedRole.Name]; ok {
+			continue
+		}
+		if strings.TrimSpace(archivedRole.Name) == "" {
+			return nil, fmt.Errorf("restoreRoles - role without a name: %w", entity.ErrArchiveInvalid)
+		}
+		for _, permission := range archivedRole.Permissions {
+			if !entity.KnownPermission(permission) {
+				return nil, fmt.Errorf("restoreRoles - role %q has unknown permission %q: %w", archivedRole.Name,
+					permission, entity.ErrArchiveInvalid)
+			}
+		}
+		role := entity.Role{Name: archivedRole.Name, Permissions: archivedRole.Permissions}
+		err = repos.Roles.Store(ctx, &role)
+		if err != nil {
+			return nil, fmt.Errorf("restoreRoles - %w", err)
+		}
+		ids[role.Name] = role.Id
+	}
+	return ids, nil
+}
+
+// optionalTime leaves the zero time out of the archive.
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// imagePaths maps image file names of the archive to the paths of the
+// imported images, names the archive has no file for are left out.
+func imagePaths(images map[string]string, names []string) []string {
+	var paths []string
+	for _, name := range names {
+		if path, ok := images[name]; ok {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
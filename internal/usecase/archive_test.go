@@ -0,0 +1,300 @@
+package usecase_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"forum/internal/entity"
+	"forum/internal/repository"
+	"forum/internal/repository/memory"
+	"forum/internal/usecase"
+)
+
+// setupExported fills a forum with two users, the admin Riddle and Tom
+// banned as a curator, a post with an image, a comment with a trashed
+// reply and reactions, and returns its export.
+func setupExported(t *testing.T, withPasswords bool) []byte {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories(memory.New())
+	imageDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(imageDir, "car.png"), []byte("png"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	day := func(month time.Month, day int) time.Time {
+		return time.Date(2022, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	for _, user := range []entity.User{
+		{Name: "Riddle", Email: "riddle@mail.ru", EmailVerified: true, Password: "hash1", RegDate: day(1, 1)},
+		{Name: "Tom", Email: "tom@mail.ru", Password: "hash2", RegDate: day(1, 2)},
+	} {
+		if err := repos.Users.Store(ctx, user); err != nil {
+			t.Fatal(err)
+		}
+	}
+	curator := entity.Role{Name: "Curator", Permissions: []entity.Permission{entity.PermManageCategories}}
+	if err := repos.Roles.Store(ctx, &curator); err != nil {
+		t.Fatal(err)
+	}
+	for id, roleId := range map[int64]int64{1: entity.RoleAdminId, 2: curator.Id} {
+		if err := repos.Users.SetRole(ctx, id, roleId); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repos.Users.SetBanned(ctx, 2, true); err != nil {
+		t.Fatal(err)
+	}
+	cars := entity.Category{Name: "Cars", Slug: "cars"}
+	if err := repos.Categories.Store(ctx, &cars); err != nil {
+		t.Fatal(err)
+	}
+	audi := entity.Category{ParentId: cars.Id, Name: "Audi", Slug: "audi", Description: "Vorsprung."}
+	if err := repos.Categories.Store(ctx, &audi); err != nil {
+		t.Fatal(err)
+	}
+	post := entity.Post{User: entity.User{Id: 1}, Date: day(5, 2), Title: "Audi", Content: "Lorem ipsum.",
+		Images: []string{"/" + filepath.Join(imageDir, "car.png")}, Categories: []entity.Category{audi}}
+	if err := repos.Posts.Store(ctx, &post); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Posts.StoreTopicReference(ctx, post); err != nil {
+		t.Fatal(err)
+	}
+	comment := entity.Comment{PostId: post.Id, User: entity.User{Id: 2}, Date: day(5, 3), Content: "Nice."}
+	if err := repos.Comments.Store(ctx, &comment); err != nil {
+		t.Fatal(err)
+	}
+	reply := entity.Comment{PostId: post.Id, ParentId: comment.Id, User: entity.User{Id: 1}, Date: day(5, 4),
+		Content: "Thanks."}
+	if err := repos.Comments.Store(ctx, &reply); err != nil {
+		t.Fatal(err)
+	}
+	reply.DeletedAt, reply.DeletedBy = time.Date(2022, 5, 5, 10, 0, 0, 0, time.UTC), 1
+	if err := repos.Comments.Delete(ctx, reply); err != nil {
+		t.Fatal(err)
+	}
+	for _, reaction := range []entity.Reaction{
+		{Target: entity.ReactionTargetPost, TargetId: post.Id, UserId: 2, Kind: "like", Date: day(5, 3)},
+		{Target: entity.ReactionTargetComment, TargetId: comment.Id, UserId: 1, Kind: "heart", Date: day(5, 4)},
+	} {
+		if err := repos.Reactions.Store(ctx, reaction); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := usecase.NewArchiveUseCase(repos.UnitOfWork, imageDir).Export(ctx, &buf, withPasswords); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func readArchive(t *testing.T, exported []byte) entity.Archive {
+	zr, err := zip.NewReader(bytes.NewReader(exported), int64(len(exported)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := zr.Open("forum.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer manifest.Close()
+	var archive entity.Archive
+	if err = json.NewDecoder(manifest).Decode(&archive); err != nil {
+		t.Fatal(err)
+	}
+	return archive
+}
+
+func TestExport(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		archive := readArchive(t, setupExported(t, false))
+
+		if archive.Version != entity.ArchiveVersion || len(archive.Users) != 2 || len(archive.Posts) != 1 ||
+			len(archive.Comments) != 2 || len(archive.Reactions) != 2 || len(archive.Categories) != 2 {
+			t.Fatalf("want the whole forum, got: %+v", archive)
+		}
+		if archive.Users[0].Password != "" {
+			t.Fatalf("want no password, got: %q", archive.Users[0].Password)
+		}
+		if !archive.Users[0].Verified || archive.Users[1].Verified {
+			t.Fatalf("want only Riddle verified, got: %+v", archive.Users)
+		}
+		if len(archive.Roles) != 4 || archive.Roles[3].Name != "Curator" || archive.Users[0].Role != "Администратор" ||
+			archive.Users[1].Role != "Curator" || archive.Users[0].Banned || !archive.Users[1].Banned {
+			t.Fatalf("want admin Riddle and banned curator Tom, got: %+v, %+v", archive.Roles, archive.Users)
+		}
+		if !reflect.DeepEqual(archive.Posts[0].Images, []string{"car.png"}) || archive.Comments[1].DeletedAt == nil {
+			t.Fatalf("want image and deletion mark, got: %+v, %+v", archive.Posts[0], archive.Comments[1])
+		}
+		if audi := archive.Categories[1]; audi.ParentId != archive.Categories[0].Id ||
+			!reflect.DeepEqual(archive.Posts[0].Categories, []int64{audi.Id}) {
+			t.Fatalf("want post in the nested category, got: %+v, %+v", archive.Categories, archive.Posts[0])
+		}
+	})
+
+	t.Run("OK with passwords", func(t *testing.T) {
+		archive := readArchive(t, setupExported(t, true))
+
+		if archive.Users[0].Password != "hash1" {
+			t.Fatalf("want: %q, got: %q", "hash1", archive.Users[0].Password)
+		}
+	})
+}
+
+func TestImport(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		exported := setupExported(t, true)
+		repos := repository.NewMemoryRepositories(memory.New())
+		imageDir := t.TempDir()
+		// ids of the importing forum are taken, Tom and Cars are there
+		// already
+		for _, user := range []entity.User{
+			{Name: "Admin", Email: "admin@mail.ru"},
+			{Name: "Tom", Email: "tom@mail.ru"},
+		} {
+			if err := repos.Users.Store(ctx, user); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, category := range []entity.Category{{Name: "Games", Slug: "audi"}, {Name: "Cars", Slug: "autos"}} {
+			if err := repos.Categories.Store(ctx, &category); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		summary, err := usecase.NewArchiveUseCase(repos.UnitOfWork, imageDir).
+			Import(ctx, bytes.NewReader(exported), int64(len(exported)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := entity.ImportSummary{Users: 1, Posts: 1, Comments: 2, Reactions: 2, Images: 1}
+		if summary != want {
+			t.Fatalf("want: %+v, got: %+v", want, summary)
+		}
+
+		riddle, err := repos.Users.GetById(ctx, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if riddle.Name != "Riddle" || riddle.Password != "hash1" || !riddle.EmailVerified ||
+			riddle.Role.Id != entity.RoleAdminId {
+			t.Fatalf("want verified admin Riddle with the password, got: %+v", riddle)
+		}
+		// Tom was taken over untouched, the curator role is added
+		tom, err := repos.Users.GetById(ctx, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tom.Role.Id != entity.RoleUserId || tom.Banned {
+			t.Fatalf("want Tom untouched, got: %+v", tom)
+		}
+		curator, err := repos.Roles.GetById(ctx, 4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if curator.Name != "Curator" || !reflect.DeepEqual(curator.Permissions,
+			[]entity.Permission{entity.PermManageCategories}) {
+			t.Fatalf("want the curator role, got: %+v", curator)
+		}
+		post, err := repos.Posts.GetById(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if post.User.Id != 3 || !reflect.DeepEqual(post.Images, []string{"/" + filepath.Join(imageDir, "car.png")}) ||
+			!post.Date.Equal(time.Date(2022, 5, 2, 0, 0, 0, 0, time.UTC)) {
+			t.Fatalf("want post of Riddle with the image and its date, got: %+v", post)
+		}
+		if _, err = os.Stat(filepath.Join(imageDir, "car.png")); err != nil {
+			t.Fatal(err)
+		}
+		categories, err := repos.Posts.GetRelatedCategories(ctx, post)
+		if err != nil {
+			t.Fatal(err)
+		}
+		audi := entity.Category{Id: 3, ParentId: 2, Name: "Audi", Slug: "audi-2", Description: "Vorsprung."}
+		if len(categories) != 1 || categories[0] != audi {
+			t.Fatalf("want: %+v, got: %+v", audi, categories)
+		}
+		comments, err := repos.Comments.Fetch(ctx, post.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(comments) != 2 || comments[0].User.Id != 2 || comments[1].ParentId != comments[0].Id ||
+			!comments[1].IsDeleted() {
+			t.Fatalf("want comment of Tom with the trashed reply, got: %+v", comments)
+		}
+		counts, err := repos.Reactions.CountByUser(ctx, entity.ReactionTargetPost, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if counts["like"] != 1 {
+			t.Fatalf("want like of Tom, got: %v", counts)
+		}
+	})
+
+	t.Run("err rolled back", func(t *testing.T) {
+		exported := setupExported(t, false)
+		repos := repository.NewMemoryRepositories(memory.New())
+		imageDir := t.TempDir()
+		// another user has the name of an exported one
+		if err := repos.Users.Store(ctx, entity.User{Name: "Riddle", Email: "other@mail.ru"}); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := usecase.NewArchiveUseCase(repos.UnitOfWork, imageDir).
+			Import(ctx, bytes.NewReader(exported), int64(len(exported)))
+		if err == nil {
+			t.Fatal("want err, got nil")
+		}
+		if users, err := repos.Users.Fetch(ctx); err != nil || len(users) != 1 {
+			t.Fatalf("want the only user, got: %v, %v", users, err)
+		}
+		if _, err = os.Stat(filepath.Join(imageDir, "car.png")); !os.IsNotExist(err) {
+			t.Fatalf("want image removed, got: %v", err)
+		}
+	})
+
+	t.Run("err invalid", func(t *testing.T) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		data := []byte("not a zip")
+
+		_, err := usecase.NewArchiveUseCase(repos.UnitOfWork, t.TempDir()).Import(ctx, bytes.NewReader(data),
+			int64(len(data)))
+		if !errors.Is(err, entity.ErrArchiveInvalid) {
+			t.Fatalf("want: %v, got: %v", entity.ErrArchiveInvalid, err)
+		}
+	})
+
+	t.Run("err version", func(t *testing.T) {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		manifest, err := zw.Create("forum.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = manifest.Write([]byte(`{"version": 99}`)); err != nil {
+			t.Fatal(err)
+		}
+		if err = zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		repos := repository.NewMemoryRepositories(memory.New())
+
+		_, err = usecase.NewArchiveUseCase(repos.UnitOfWork, t.TempDir()).
+			Import(ctx, bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		if !errors.Is(err, entity.ErrArchiveVersion) {
+			t.Fatalf("want: %v, got: %v", entity.ErrArchiveVersion, err)
+		}
+	})
+}
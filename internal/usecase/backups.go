@@ -0,0 +1,185 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"forum/internal/entity"
+	"forum/internal/repository"
+)
+
+// Backup file names carry the time of the snapshot to the millisecond.
+// Snapshots of older versions were named to the second, parsing with
+// backupParseFormat reads both.
+const (
+	backupPrefix      = "forum-"
+	backupSuffix      = ".db"
+	backupTimeFormat  = "20060102-150405.000"
+	backupParseFormat = "20060102-150405"
+)
+
+type BackupsUseCase struct {
+	repo repository.Backups
+	dir  string
+	keep int
+}
+
+// NewBackupsUseCase keeps snapshots in dir, the keep latest of them. Zero
+// keep never removes snapshots, nil repo makes every call fail with
+// entity.ErrBackupUnsupported.
+func NewBackupsUseCase(repo repository.Backups, dir string, keep int) *BackupsUseCase {
+	return &BackupsUseCase{
+		repo: repo,
+		dir:  dir,
+		keep: keep,
+	}
+}
+
+// CreateBackup writes a snapshot of the database into the backup directory
+// and removes the snapshots beyond the kept number. It fails with
+// entity.ErrBackupExists rather than overwrite a snapshot of the same name.
+func (bu *BackupsUseCase) CreateBackup(ctx context.Context) (entity.Backup, error) {
+	if bu.repo == nil {
+		return entity.Backup{}, entity.ErrBackupUnsupported
+	}
+	err := os.MkdirAll(bu.dir, 0o755)
+	if err != nil {
+		return entity.Backup{}, fmt.Errorf("BackupsUseCase - CreateBackup #1 - %w", err)
+	}
+
+	name := backupPrefix + time.Now().Format(backupTimeFormat) + backupSuffix
+	path := filepath.Join(bu.dir, name)
+	// the snapshot gets its name once it is complete, listings and
+	// downloads never see a partial file
+	part, err := os.OpenFile(path+".part", os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		if os.IsExist(err) {
+			return entity.Backup{}, entity.ErrBackupExists
+		}
+		return entity.Backup{}, fmt.Errorf("BackupsUseCase - CreateBackup #2 - %w", err)
+	}
+	part.Close()
+	defer os.Remove(path + ".part")
+	err = bu.repo.Backup(ctx, path+".part")
+	if err != nil {
+		return entity.Backup{}, fmt.Errorf("BackupsUseCase - CreateBackup #3 - %w", err)
+	}
+	// unlike a rename, a link never replaces an existing snapshot
+	err = os.Link(path+".part", path)
+	if err != nil {
+		if os.IsExist(err) {
+			return entity.Backup{}, entity.ErrBackupExists
+		}
+		return entity.Backup{}, fmt.Errorf("BackupsUseCase - CreateBackup #4 - %w", err)
+	}
+
+	backups, err := bu.GetBackups()
+	if err != nil {
+		return entity.Backup{}, fmt.Errorf("BackupsUseCase - CreateBackup #5 - %w", err)
+	}
+	if bu.keep > 0 && len(backups) > bu.keep {
+		for _, old := range backups[bu.keep:] {
+			err = os.Remove(filepath.Join(bu.dir, old.Name))
+			if err != nil {
+				return entity.Backup{}, fmt.Errorf("BackupsUseCase - CreateBackup #6 - %w", err)
+			}
+		}
+	}
+	for _, backup := range backups {
+		if backup.Name == name {
+			return backup, nil
+		}
+	}
+	return entity.Backup{Name: name}, nil
+}
+
+// GetBackups lists snapshots of the backup directory, the latest first.
+func (bu *BackupsUseCase) GetBackups() ([]entity.Backup, error) {
+	entries, err := os.ReadDir(bu.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("BackupsUseCase - GetBackups #1 - %w", err)
+	}
+
+	var backups []entity.Backup
+	for _, entry := range entries {
+		created, ok := backupTime(entry.Name())
+		if !ok || entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("BackupsUseCase - GetBackups #2 - %w", err)
+		}
+		backups = append(backups, entity.Backup{
+			Name: entry.Name(),
+			Date: created,
+			Size: info.Size(),
+		})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		if !backups[i].Date.Equal(backups[j].Date) {
+			return backups[i].Date.After(backups[j].Date)
+		}
+		return backups[i].Name > backups[j].Name
+	})
+	return backups, nil
+}
+
+// GetBackupPath returns the path of the named snapshot for download, names
+// other than those GetBackups lists are not found.
+func (bu *BackupsUseCase) GetBackupPath(name string) (string, error) {
+	if _, ok := backupTime(name); !ok {
+		return "", entity.ErrBackupNotFound
+	}
+	path := filepath.Join(bu.dir, name)
+	_, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", entity.ErrBackupNotFound
+		}
+		return "", fmt.Errorf("BackupsUseCase - GetBackupPath - %w", err)
+	}
+	return path, nil
+}
+
+// RestoreBackup replaces the database with the snapshot file at path. The
+// snapshot is refused unless its schema is one the application knows.
+func (bu *BackupsUseCase) RestoreBackup(ctx context.Context, path string) error {
+	if bu.repo == nil {
+		return entity.ErrBackupUnsupported
+	}
+	_, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return entity.ErrBackupNotFound
+		}
+		return fmt.Errorf("BackupsUseCase - RestoreBackup #1 - %w", err)
+	}
+	err = bu.repo.Restore(ctx, path)
+	if err != nil {
+		return fmt.Errorf("BackupsUseCase - RestoreBackup #2 - %w", err)
+	}
+	return nil
+}
+
+// backupTime parses the time of a snapshot name, ok is false for other
+// names.
+func backupTime(name string) (time.Time, bool) {
+	if !strings.HasPrefix(name, backupPrefix) || !strings.HasSuffix(name, backupSuffix) {
+		return time.Time{}, false
+	}
+	stamp := strings.TrimSuffix(strings.TrimPrefix(name, backupPrefix), backupSuffix)
+	created, err := time.ParseInLocation(backupParseFormat, stamp, time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return created, true
+}
@@ -0,0 +1,130 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"forum/internal/entity"
+	"forum/internal/repository"
+	"forum/internal/repository/sqlite"
+	"forum/internal/usecase"
+	"forum/pkg/sqlite3"
+)
+
+func setupBackups(t *testing.T, keep int) (*usecase.BackupsUseCase, string) {
+	db, err := sqlite3.New("file:backups?mode=memory&cache=shared", sqlite3.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(db.Close)
+	if err = sqlite.CreateDB(db); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	repos := repository.NewRepositories(db)
+	return usecase.NewBackupsUseCase(repos.Backups, dir, keep), dir
+}
+
+func TestCreateBackup(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		backupsUseCase, _ := setupBackups(t, 2)
+
+		backup, err := backupsUseCase.CreateBackup(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if backup.Size == 0 {
+			t.Fatalf("want written backup, got: %+v", backup)
+		}
+
+		path, err := backupsUseCase.GetBackupPath(backup.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = backupsUseCase.RestoreBackup(ctx, path); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("OK old backups removed", func(t *testing.T) {
+		backupsUseCase, dir := setupBackups(t, 2)
+		for _, name := range []string{"forum-20221001-120000.db", "forum-20221002-120000.db", "notes.txt"} {
+			if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		backup, err := backupsUseCase.CreateBackup(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		backups, err := backupsUseCase.GetBackups()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(backups) != 2 || backups[0].Name != backup.Name || backups[1].Name != "forum-20221002-120000.db" {
+			t.Fatalf("want the latest two backups, got: %+v", backups)
+		}
+		if _, err = os.Stat(filepath.Join(dir, "notes.txt")); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("OK never overwritten", func(t *testing.T) {
+		backupsUseCase, dir := setupBackups(t, 0)
+
+		created := map[string]bool{}
+		for i := 0; i < 5; i++ {
+			backup, err := backupsUseCase.CreateBackup(ctx)
+			if errors.Is(err, entity.ErrBackupExists) {
+				continue
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if created[backup.Name] {
+				t.Fatalf("want a new name, got: %s twice", backup.Name)
+			}
+			created[backup.Name] = true
+		}
+
+		backups, err := backupsUseCase.GetBackups()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(backups) != len(created) {
+			t.Fatalf("want: %d backups, got: %+v", len(created), backups)
+		}
+		if parts, _ := filepath.Glob(filepath.Join(dir, "*.part")); len(parts) != 0 {
+			t.Fatalf("want no partial files, got: %v", parts)
+		}
+	})
+
+	t.Run("err unsupported", func(t *testing.T) {
+		backupsUseCase := usecase.NewBackupsUseCase(nil, t.TempDir(), 2)
+
+		if _, err := backupsUseCase.CreateBackup(ctx); !errors.Is(err, entity.ErrBackupUnsupported) {
+			t.Fatalf("want: %v, got: %v", entity.ErrBackupUnsupported, err)
+		}
+	})
+}
+
+func TestGetBackupPath(t *testing.T) {
+	backupsUseCase, dir := setupBackups(t, 2)
+	if err := os.WriteFile(filepath.Join(dir, "..", "forum-20221001-120000.db"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"forum-20221002-120000.db", "../forum-20221001-120000.db", "notes.txt"} {
+		if _, err := backupsUseCase.GetBackupPath(name); !errors.Is(err, entity.ErrBackupNotFound) {
+			t.Fatalf("%s: want: %v, got: %v", name, entity.ErrBackupNotFound, err)
+		}
+	}
+}
@@ -0,0 +1,306 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"forum/internal/entity"
+	"forum/internal/repository"
+)
+
+type CategoriesUseCase struct {
+	repo repository.Categories
+	uow  repository.UnitOfWork
+}
+
+func NewCategoriesUseCase(repo repository.Categories, uow repository.UnitOfWork) *CategoriesUseCase {
+	return &CategoriesUseCase{
+		repo: repo,
+		uow:  uow,
+	}
+}
+
+// CreateCategories stores the categories whose names are not taken yet,
+// the others are skipped. Slugs are made of the names, a category can only
+// nest under an existing one.
+func (cu *CategoriesUseCase) CreateCategories(ctx context.Context, categories []entity.Category) error {
+	existed, err := cu.repo.Fetch(ctx)
+	if err != nil {
+		return fmt.Errorf("CategoriesUseCase - CreateCategories #1 - %w", err)
+	}
+	ids := make(map[int64]bool, len(existed))
+	names := make(map[string]bool, len(existed))
+	slugs := make(map[string]bool, len(existed))
+	for _, category := range existed {
+		ids[category.Id] = true
+		names[category.Name] = true
+		slugs[category.Slug] = true
+	}
+
+	for _, category := range categories {
+		category.Name = strings.TrimSpace(category.Name)
+		if category.Name == "" || names[category.Name] {
+			continue
+		}
+		if category.ParentId != 0 && !ids[category.ParentId] {
+			return entity.ErrCategoryNotFound
+		}
+		category.Slug = uniqueSlug(slugify(category.Name), slugs)
+		err = cu.repo.Store(ctx, &category)
+		if err != nil {
+			return fmt.Errorf("CategoriesUseCase - CreateCategories #2 - %w", err)
+		}
+		ids[category.Id] = true
+		names[category.Name] = true
+		slugs[category.Slug] = true
+	}
+	return nil
+}
+
+// GetCategories lists the category tree depth first with the activity of
+// every category, children follow their parent and know their Depth.
+func (cu *CategoriesUseCase) GetCategories(ctx context.Context) ([]entity.Category, error) {
+	categories, err := cu.repo.Fetch(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("CategoriesUseCase - GetCategories - %w", err)
+	}
+	return categoryTree(categories), nil
+}
+
+func (cu *CategoriesUseCase) GetBySlug(ctx context.Context, slug string) (entity.Category, error) {
+	category, err := cu.repo.GetBySlug(ctx, slug)
+	if err != nil {
+		if strings.Contains(err.Error(), NoRowsResultErr) {
+			return category, entity.ErrCategoryNotFound
+		}
+		return category, fmt.Errorf("CategoriesUseCase - GetBySlug - %w", err)
+	}
+	return category, nil
+}
+
+// RenameCategory renames the category and makes its slug of the new name.
+// Posts reference categories by id and keep theirs.
+func (cu *CategoriesUseCase) RenameCategory(ctx context.Context, id int64, name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return entity.ErrCategoryNameEmpty
+	}
+	return cu.uow.Do(ctx, func(repos *repository.Repositories) error {
+		existed, err := repos.Categories.Fetch(ctx)
+		if err != nil {
+			return fmt.Errorf("CategoriesUseCase - RenameCategory #1 - %w", err)
+		}
+		category, ok := findCategory(existed, id)
+		if !ok {
+			return entity.ErrCategoryNotFound
+		}
+		if category.Name == name {
+			return nil
+		}
+		slugs := make(map[string]bool, len(existed))
+		for _, other := range existed {
+			if other.Id == id {
+				continue
+			}
+			if other.Name == name {
+				return entity.ErrCategoryExists
+			}
+			slugs[other.Slug] = true
+		}
+
+		category.Name = name
+		category.Slug = uniqueSlug(slugify(name), slugs)
+		err = repos.Categories.Update(ctx, category)
+		if err != nil {
+			return fmt.Errorf("CategoriesUseCase - RenameCategory #2 - %w", err)
+		}
+		return nil
+	})
+}
+
+// MergeCategories moves the posts and the subcategories of the category
+// fromId into the category intoId and deletes the emptied one.
+func (cu *CategoriesUseCase) MergeCategories(ctx context.Context, fromId, intoId int64) error {
+	if intoId == 0 {
+		return entity.ErrCategoryNotFound
+	}
+	err := cu.DeleteCategory(ctx, fromId, intoId)
+	if err != nil {
+		return fmt.Errorf("CategoriesUseCase - MergeCategories - %w", err)
+	}
+	return nil
+}
+
+// DeleteCategory deletes the category. Its posts and subcategories are
+// moved to the category targetId, without a target the posts lose the
+// category and the subcategories move up a level. A target nested in the
+// deleted category takes its place in the tree.
+func (cu *CategoriesUseCase) DeleteCategory(ctx context.Context, id, targetId int64) error {
+	if id == targetId {
+		return entity.ErrCategoryMergeSelf
+	}
+	return cu.uow.Do(ctx, func(repos *repository.Repositories) error {
+		existed, err := repos.Categories.Fetch(ctx)
+		if err != nil {
+			return fmt.Errorf("CategoriesUseCase - DeleteCategory #1 - %w", err)
+		}
+		deleted, ok := findCategory(existed, id)
+		if !ok {
+			return entity.ErrCategoryNotFound
+		}
+		parentId := deleted.ParentId
+		if targetId != 0 {
+			target, ok := findCategory(existed, targetId)
+			if !ok {
+				return entity.ErrCategoryNotFound
+			}
+			if isDescendant(existed, target, id) {
+				target.ParentId = deleted.ParentId
+				err = repos.Categories.Update(ctx, target)
+				if err != nil {
+					return fmt.Errorf("CategoriesUseCase - DeleteCategory #2 - %w", err)
+				}
+			}
+			parentId = targetId
+		}
+
+		for _, child := range existed {
+			if child.ParentId != id || child.Id == targetId {
+				continue
+			}
+			child.ParentId = parentId
+			err = repos.Categories.Update(ctx, child)
+			if err != nil {
+				return fmt.Errorf("CategoriesUseCase - DeleteCategory #3 - %w", err)
+			}
+		}
+		err = repos.Categories.MoveReferences(ctx, id, targetId)
+		if err != nil {
+			return fmt.Errorf("CategoriesUseCase - DeleteCategory #4 - %w", err)
+		}
+		err = repos.Categories.Delete(ctx, id)
+		if err != nil {
+			return fmt.Errorf("CategoriesUseCase - DeleteCategory #5 - %w", err)
+		}
+		return nil
+	})
+}
+
+// ReorderCategories sets the positions of the categories by their ids,
+// siblings are listed by position.
+func (cu *CategoriesUseCase) ReorderCategories(ctx context.Context, positions map[int64]int) error {
+	ids := make([]int64, 0, len(positions))
+	for id := range positions {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	return cu.uow.Do(ctx, func(repos *repository.Repositories) error {
+		for _, id := range ids {
+			category, err := repos.Categories.GetById(ctx, id)
+			if err != nil {
+				if strings.Contains(err.Error(), NoRowsResultErr) {
+					return entity.ErrCategoryNotFound
+				}
+				return fmt.Errorf("CategoriesUseCase - ReorderCategories #1 - %w", err)
+			}
+			if category.Position == positions[id] {
+				continue
+			}
+			category.Position = positions[id]
+			err = repos.Categories.Update(ctx, category)
+			if err != nil {
+				return fmt.Errorf("CategoriesUseCase - ReorderCategories #2 - %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+func findCategory(categories []entity.Category, id int64) (entity.Category, bool) {
+	for _, category := range categories {
+		if category.Id == id {
+			return category, true
+		}
+	}
+	return entity.Category{}, false
+}
+
+// isDescendant reports whether the category is nested in the category
+// ancestorId at any depth.
+func isDescendant(categories []entity.Category, category entity.Category, ancestorId int64) bool {
+	for seen := 0; category.ParentId != 0 && seen < len(categories); seen++ {
+		if category.ParentId == ancestorId {
+			return true
+		}
+		parent, ok := findCategory(categories, category.ParentId)
+		if !ok {
+			return false
+		}
+		category = parent
+	}
+	return false
+}
+
+// categoryTree orders listed categories depth first keeping the order of
+// siblings. Categories whose parent is not listed are top level.
+func categoryTree(categories []entity.Category) []entity.Category {
+	listed := make(map[int64]bool, len(categories))
+	for _, category := range categories {
+		listed[category.Id] = true
+	}
+	children := make(map[int64][]entity.Category)
+	for _, category := range categories {
+		parentId := category.ParentId
+		if !listed[parentId] {
+			parentId = 0
+		}
+		children[parentId] = append(children[parentId], category)
+	}
+
+	tree := make([]entity.Category, 0, len(categories))
+	var walk func(parentId int64, depth int)
+	walk = func(parentId int64, depth int) {
+		for _, category := range children[parentId] {
+			category.Depth = depth
+			tree = append(tree, category)
+			walk(category.Id, depth+1)
+		}
+	}
+	walk(0, 0)
+	return tree
+}
+
+// slugify lowercases the name and joins its words with dashes.
+func slugify(name string) string {
+	var slug strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			dash = true
+			continue
+		}
+		if dash && slug.Len() != 0 {
+			slug.WriteByte('-')
+		}
+		dash = false
+		slug.WriteRune(r)
+	}
+	if slug.Len() == 0 {
+		return "category"
+	}
+	return slug.String()
+}
+
+// uniqueSlug numbers the slug if it is taken already.
+func uniqueSlug(slug string, taken map[string]bool) string {
+	unique := slug
+	for i := 2; taken[unique]; i++ {
+		unique = slug + "-" + strconv.Itoa(i)
+	}
+	return unique
+}
@@ -0,0 +1,326 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"forum/internal/entity"
+	"forum/internal/repository"
+	"forum/internal/repository/memory"
+	"forum/internal/usecase"
+)
+
+func TestCreateCategories(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		categoryUseCase := usecase.NewCategoriesUseCase(repos.Categories, repos.UnitOfWork)
+
+		if err := categoryUseCase.CreateCategories(ctx, []entity.Category{
+			{Name: "Cars"}, {Name: " Sci-Fi / Fantasy "}, {Name: "Cars"}, {Name: ""},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if err := categoryUseCase.CreateCategories(ctx, []entity.Category{
+			{Name: "Sci-Fi: Fantasy", ParentId: 1, Description: "Dragons."}, {Name: "Машины"},
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		found, err := repos.Categories.Fetch(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var slugs []string
+		for _, category := range found {
+			slugs = append(slugs, category.Slug)
+		}
+		if want := []string{"cars", "sci-fi-fantasy", "sci-fi-fantasy-2", "машины"}; !reflect.DeepEqual(slugs, want) {
+			t.Fatalf("want: %v, got: %v", want, slugs)
+		}
+		if found[2].ParentId != 1 || found[2].Description != "Dragons." {
+			t.Fatalf("want nested category, got: %+v", found[2])
+		}
+	})
+
+	t.Run("err parent not found", func(t *testing.T) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		categoryUseCase := usecase.NewCategoriesUseCase(repos.Categories, repos.UnitOfWork)
+
+		err := categoryUseCase.CreateCategories(ctx, []entity.Category{{Name: "Audi", ParentId: 5}})
+		if !errors.Is(err, entity.ErrCategoryNotFound) {
+			t.Fatalf("want: %v, got: %v", entity.ErrCategoryNotFound, err)
+		}
+	})
+}
+
+func TestGetCategories(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories(memory.New())
+	categoryUseCase := usecase.NewCategoriesUseCase(repos.Categories, repos.UnitOfWork)
+
+	for _, category := range []entity.Category{
+		{Name: "Cars", Slug: "cars", Position: 1},
+		{Name: "Sports", Slug: "sports"},
+		{Name: "BMW", Slug: "bmw", ParentId: 1},
+		{Name: "Audi", Slug: "audi", ParentId: 1},
+		{Name: "A4", Slug: "a4", ParentId: 4},
+	} {
+		if err := repos.Categories.Store(ctx, &category); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		found, err := categoryUseCase.GetCategories(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var tree []string
+		for _, category := range found {
+			tree = append(tree, fmt.Sprint(category.Depth, category.Name))
+		}
+		if want := []string{"0Sports", "0Cars", "1Audi", "2A4", "1BMW"}; !reflect.DeepEqual(tree, want) {
+			t.Fatalf("want: %v, got: %v", want, tree)
+		}
+	})
+}
+
+func TestGetCategoryBySlug(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories(memory.New())
+	categoryUseCase := usecase.NewCategoriesUseCase(repos.Categories, repos.UnitOfWork)
+	storeCategories(t, repos)
+
+	t.Run("OK", func(t *testing.T) {
+		if found, err := categoryUseCase.GetBySlug(ctx, "sports"); err != nil {
+			t.Fatal(err)
+		} else if found != sports {
+			t.Fatalf("want: %+v, got: %+v", sports, found)
+		}
+	})
+
+	t.Run("err not found", func(t *testing.T) {
+		if _, err := categoryUseCase.GetBySlug(ctx, "weather"); !errors.Is(err, entity.ErrCategoryNotFound) {
+			t.Fatalf("want: %v, got: %v", entity.ErrCategoryNotFound, err)
+		}
+	})
+}
+
+// setupCategorized stores the categories and the posts of the fixtures.
+func setupCategorized(t *testing.T) (*usecase.CategoriesUseCase, *repository.Repositories) {
+	t.Helper()
+	repos := repository.NewMemoryRepositories(memory.New())
+	storeCategories(t, repos)
+	for _, post := range []entity.Post{post1, post2, post3, post4} {
+		post.Id = 0
+		if err := repos.Posts.Store(context.Background(), &post); err != nil {
+			t.Fatal(err)
+		}
+		if err := repos.Posts.StoreTopicReference(context.Background(), post); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return usecase.NewCategoriesUseCase(repos.Categories, repos.UnitOfWork), repos
+}
+
+func TestRenameCategory(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		categoryUseCase, repos := setupCategorized(t)
+
+		if err := categoryUseCase.RenameCategory(ctx, guns.Id, " Weapons "); err != nil {
+			t.Fatal(err)
+		}
+		found, err := repos.Categories.GetById(ctx, guns.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found.Name != "Weapons" || found.Slug != "weapons" {
+			t.Fatalf("want renamed category, got: %+v", found)
+		}
+		if ids, err := repos.Posts.GetIdsByCategory(ctx, guns.Id); err != nil {
+			t.Fatal(err)
+		} else if !reflect.DeepEqual(ids, []int64{4}) {
+			t.Fatalf("want: %v, got: %v", []int64{4}, ids)
+		}
+	})
+
+	for _, tc := range []struct {
+		name string
+		id   int64
+		to   string
+		want error
+	}{
+		{"err exists", guns.Id, "Cars", entity.ErrCategoryExists},
+		{"err empty", guns.Id, " ", entity.ErrCategoryNameEmpty},
+		{"err not found", 10, "Weapons", entity.ErrCategoryNotFound},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			categoryUseCase, _ := setupCategorized(t)
+
+			if err := categoryUseCase.RenameCategory(ctx, tc.id, tc.to); !errors.Is(err, tc.want) {
+				t.Fatalf("want: %v, got: %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestMergeCategories(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		categoryUseCase, repos := setupCategorized(t)
+		bmw := entity.Category{ParentId: guns.Id, Name: "BMW", Slug: "bmw"}
+		if err := repos.Categories.Store(ctx, &bmw); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := categoryUseCase.MergeCategories(ctx, guns.Id, cars.Id); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := repos.Categories.GetById(ctx, guns.Id); err == nil {
+			t.Fatal("want merged category deleted")
+		}
+		if ids, err := repos.Posts.GetIdsByCategory(ctx, cars.Id); err != nil {
+			t.Fatal(err)
+		} else if !reflect.DeepEqual(ids, []int64{1, 3, 4}) {
+			t.Fatalf("want: %v, got: %v", []int64{1, 3, 4}, ids)
+		}
+		if found, err := repos.Categories.GetById(ctx, bmw.Id); err != nil {
+			t.Fatal(err)
+		} else if found.ParentId != cars.Id {
+			t.Fatalf("want subcategory moved to Cars, got: %+v", found)
+		}
+	})
+
+	t.Run("err merge self", func(t *testing.T) {
+		categoryUseCase, _ := setupCategorized(t)
+
+		if err := categoryUseCase.MergeCategories(ctx, cars.Id, cars.Id); !errors.Is(err, entity.ErrCategoryMergeSelf) {
+			t.Fatalf("want: %v, got: %v", entity.ErrCategoryMergeSelf, err)
+		}
+	})
+
+	t.Run("err not found", func(t *testing.T) {
+		categoryUseCase, _ := setupCategorized(t)
+
+		for _, into := range []int64{0, 10} {
+			if err := categoryUseCase.MergeCategories(ctx, cars.Id, into); !errors.Is(err, entity.ErrCategoryNotFound) {
+				t.Fatalf("want: %v, got: %v", entity.ErrCategoryNotFound, err)
+			}
+		}
+	})
+}
+
+func TestDeleteCategory(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("OK without target", func(t *testing.T) {
+		categoryUseCase, repos := setupCategorized(t)
+		audi := entity.Category{ParentId: cars.Id, Name: "Audi", Slug: "audi"}
+		if err := repos.Categories.Store(ctx, &audi); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := categoryUseCase.DeleteCategory(ctx, cars.Id, 0); err != nil {
+			t.Fatal(err)
+		}
+		post, err := repos.Posts.GetById(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if categories, err := repos.Posts.GetRelatedCategories(ctx, post); err != nil {
+			t.Fatal(err)
+		} else if len(categories) != 0 {
+			t.Fatalf("want post without categories, got: %+v", categories)
+		}
+		if found, err := repos.Categories.GetById(ctx, audi.Id); err != nil {
+			t.Fatal(err)
+		} else if found.ParentId != 0 {
+			t.Fatalf("want subcategory moved up, got: %+v", found)
+		}
+	})
+
+	t.Run("OK nested target", func(t *testing.T) {
+		categoryUseCase, repos := setupCategorized(t)
+		audi := entity.Category{ParentId: cars.Id, Name: "Audi", Slug: "audi"}
+		if err := repos.Categories.Store(ctx, &audi); err != nil {
+			t.Fatal(err)
+		}
+		bmw := entity.Category{ParentId: cars.Id, Name: "BMW", Slug: "bmw"}
+		if err := repos.Categories.Store(ctx, &bmw); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := categoryUseCase.DeleteCategory(ctx, cars.Id, audi.Id); err != nil {
+			t.Fatal(err)
+		}
+		found, err := categoryUseCase.GetCategories(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var tree []string
+		for _, category := range found {
+			tree = append(tree, fmt.Sprint(category.Depth, category.Name))
+		}
+		if want := []string{"0Audi", "1BMW", "0Computers", "0Guns", "0Sports"}; !reflect.DeepEqual(tree, want) {
+			t.Fatalf("want: %v, got: %v", want, tree)
+		}
+		if found[0].Posts != 2 {
+			t.Fatalf("want posts of Cars in Audi, got: %+v", found[0])
+		}
+	})
+
+	t.Run("err not found", func(t *testing.T) {
+		categoryUseCase, _ := setupCategorized(t)
+
+		for _, ids := range [][2]int64{{10, 0}, {cars.Id, 10}} {
+			if err := categoryUseCase.DeleteCategory(ctx, ids[0], ids[1]); !errors.Is(err, entity.ErrCategoryNotFound) {
+				t.Fatalf("want: %v, got: %v", entity.ErrCategoryNotFound, err)
+			}
+		}
+	})
+}
+
+func TestReorderCategories(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		categoryUseCase, _ := setupCategorized(t)
+
+		if err := categoryUseCase.ReorderCategories(ctx, map[int64]int{sports.Id: 1, guns.Id: 2, cars.Id: 3}); err != nil {
+			t.Fatal(err)
+		}
+		found, err := categoryUseCase.GetCategories(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, category := range found {
+			names = append(names, category.Name)
+		}
+		if want := []string{"Computers", "Sports", "Guns", "Cars"}; !reflect.DeepEqual(names, want) {
+			t.Fatalf("want: %v, got: %v", want, names)
+		}
+	})
+
+	t.Run("err not found", func(t *testing.T) {
+		categoryUseCase, repos := setupCategorized(t)
+
+		err := categoryUseCase.ReorderCategories(ctx, map[int64]int{cars.Id: 5, 10: 1})
+		if !errors.Is(err, entity.ErrCategoryNotFound) {
+			t.Fatalf("want: %v, got: %v", entity.ErrCategoryNotFound, err)
+		}
+		if found, err := repos.Categories.GetById(ctx, cars.Id); err != nil {
+			t.Fatal(err)
+		} else if found.Position != 0 {
+			t.Fatalf("want reorder rolled back, got: %+v", found)
+		}
+	})
+}
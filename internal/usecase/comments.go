@@ -1,8 +1,12 @@
 package usecase
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"forum/internal/entity"
 	"forum/internal/repository"
@@ -11,139 +15,342 @@ import (
 type CommentsUseCase struct {
 	repo repository.Comments
 
-	postRepo repository.Posts
-	userRepo repository.Users
+	postRepo  repository.Posts
+	userRepo  repository.Users
+	reactions reactions
+	revisions revisions
+	uow       repository.UnitOfWork
+	// maxDepth is the deepest level replies are nested to.
+	maxDepth int
 }
 
-func NewCommentsUseCase(repo repository.Comments, postsRepo repository.Posts, usersRepo repository.Users) *CommentsUseCase {
+func NewCommentsUseCase(repo repository.Comments, postsRepo repository.Posts, usersRepo repository.Users,
+	reactionsRepo repository.Reactions, revisionsRepo repository.Revisions, uow repository.UnitOfWork,
+	kinds entity.ReactionKinds, maxDepth int,
+) *CommentsUseCase {
 	return &CommentsUseCase{
-		repo:     repo,
-		postRepo: postsRepo,
-		userRepo: usersRepo,
+		repo:      repo,
+		postRepo:  postsRepo,
+		userRepo:  usersRepo,
+		reactions: reactions{repo: reactionsRepo, kinds: kinds, target: entity.ReactionTargetComment},
+		revisions: revisions{repo: revisionsRepo, target: entity.RevisionTargetComment},
+		uow:       uow,
+		maxDepth:  maxDepth,
 	}
 }
 
-func (cu *CommentsUseCase) WriteComment(comment entity.Comment) error {
-	comment.Date = getRegTime(DateAndTimeFormat)
-	err := cu.repo.Store(comment)
+// WriteComment stores the comment, replies must answer a comment of the
+// same post that is not in the trash.
+func (cu *CommentsUseCase) WriteComment(ctx context.Context, comment entity.Comment) error {
+	return cu.uow.Do(ctx, func(repos *repository.Repositories) error {
+		if comment.ParentId != 0 {
+			parent, err := repos.Comments.GetById(ctx, comment.ParentId)
+			if err != nil {
+				if strings.Contains(err.Error(), NoRowsResultErr) {
+					return entity.ErrCommentNotFound
+				}
+				return fmt.Errorf("CommentsUseCase - WriteComment #1 - %w", err)
+			}
+			if parent.PostId != comment.PostId || parent.IsDeleted() {
+				return entity.ErrCommentNotFound
+			}
+		}
+
+		comment.Date = time.Now()
+		err := repos.Comments.Store(ctx, &comment)
+		if err != nil {
+			return fmt.Errorf("CommentsUseCase - WriteComment #2 - %w", err)
+		}
+		return nil
+	})
+}
+
+// GetAllComments returns top level comments of the post with their
+// replies nested in Replies.
+func (cu *CommentsUseCase) GetAllComments(ctx context.Context, postId int64) ([]entity.Comment, error) {
+	comments, err := cu.repo.Fetch(ctx, postId)
 	if err != nil {
-		return fmt.Errorf("CommentsUseCase - WriteComment - %w", err)
+		return nil, fmt.Errorf("CommentsUseCase - GetAllComments #1 - %w", err)
 	}
 
-	return nil
+	err = cu.fillAuthors(ctx, comments)
+	if err != nil {
+		return nil, fmt.Errorf("CommentsUseCase - GetAllComments #2 - %w", err)
+	}
+	return nestReplies(comments, cu.maxDepth), nil
 }
 
-func (cu *CommentsUseCase) GetAllComments(postId int64) ([]entity.Comment, error) {
-	comments, err := cu.repo.Fetch(postId)
+func (cu *CommentsUseCase) GetCommentsPage(ctx context.Context, postId int64,
+	number int) ([]entity.Comment, entity.Page, error) {
+	total, err := cu.repo.Count(ctx, postId)
 	if err != nil {
-		return nil, fmt.Errorf("CommentsUseCase - GetAllComments #1 - %w", err)
+		return nil, entity.Page{}, fmt.Errorf("CommentsUseCase - GetCommentsPage #1 - %w", err)
 	}
+	page, offset := pageWindow(number, CommentsPerPage, total)
 
-	for i := 0; i < len(comments); i++ {
-		user, err := cu.userRepo.GetById(comments[i].User.Id)
-		if err != nil {
-			return nil, fmt.Errorf("CommentsUseCase - GetAllComments #2 - %w", err)
-		}
-		comments[i].User = user
+	comments, err := cu.repo.FetchPage(ctx, postId, CommentsPerPage, offset)
+	if err != nil {
+		return nil, page, fmt.Errorf("CommentsUseCase - GetCommentsPage #2 - %w", err)
 	}
-	return comments, nil
+
+	err = cu.withReplies(ctx, comments)
+	if err != nil {
+		return nil, page, fmt.Errorf("CommentsUseCase - GetCommentsPage #3 - %w", err)
+	}
+	return comments, page, nil
 }
 
-func (cu *CommentsUseCase) UpdateComment(comment entity.Comment) error {
-	err := cu.repo.Update(comment)
+func (cu *CommentsUseCase) GetCommentsAfter(ctx context.Context,
+	postId, cursor int64) ([]entity.Comment, entity.Page, error) {
+	total, err := cu.repo.Count(ctx, postId)
 	if err != nil {
-		return fmt.Errorf("CommentsUseCase - UpdateComment - %w", err)
+		return nil, entity.Page{}, fmt.Errorf("CommentsUseCase - GetCommentsAfter #1 - %w", err)
 	}
-	return nil
+
+	comments, err := cu.repo.FetchAfter(ctx, postId, cursor, CommentsPerPage+1)
+	if err != nil {
+		return nil, entity.Page{}, fmt.Errorf("CommentsUseCase - GetCommentsAfter #2 - %w", err)
+	}
+	fetched := len(comments)
+	if fetched > CommentsPerPage {
+		comments = comments[:CommentsPerPage]
+	}
+	var lastId int64
+	if len(comments) != 0 {
+		lastId = comments[len(comments)-1].Id
+	}
+	page := cursorPage(CommentsPerPage, fetched, lastId, total)
+
+	err = cu.withReplies(ctx, comments)
+	if err != nil {
+		return nil, page, fmt.Errorf("CommentsUseCase - GetCommentsAfter #3 - %w", err)
+	}
+	return comments, page, nil
 }
 
-func (cu *CommentsUseCase) DeleteComment(comment entity.Comment) error {
-	err := cu.repo.Delete(comment)
+// withReplies replaces the top level comments of a page with their
+// threads, only the replies under them are read.
+func (cu *CommentsUseCase) withReplies(ctx context.Context, comments []entity.Comment) error {
+	ids := make([]int64, len(comments))
+	for i := range comments {
+		ids[i] = comments[i].Id
+	}
+	replies, err := cu.repo.FetchReplies(ctx, ids)
 	if err != nil {
-		return fmt.Errorf("CommentsUseCase - DeleteComment - %w", err)
+		return err
+	}
+	all := append(append(make([]entity.Comment, 0, len(comments)+len(replies)), comments...), replies...)
+	err = cu.fillAuthors(ctx, all)
+	if err != nil {
+		return err
+	}
+
+	threads := make(map[int64]entity.Comment)
+	for _, thread := range nestReplies(all, cu.maxDepth) {
+		threads[thread.Id] = thread
+	}
+	for i := range comments {
+		if thread, ok := threads[comments[i].Id]; ok {
+			comments[i] = thread
+		}
 	}
 	return nil
 }
 
-func (cu *CommentsUseCase) MakeReaction(comment entity.Comment, command string) error {
-	switch command {
-	case ReactionLike:
-		err := cu.repo.StoreLike(comment)
-		if err != nil {
-			if strings.Contains(err.Error(), UniqueReactionErr) {
-				err = cu.repo.DeleteLike(comment)
-				if err != nil {
-					return fmt.Errorf("CommentsUseCase - MakeReaction #1 - %w", err)
-				}
-				return nil
-			}
-			return fmt.Errorf("CommentsUseCase - MakeReaction #2 -  %w", err)
+// nestReplies puts comments under their parents and returns the top
+// level ones. Replies are nested maxDepth levels deep at most, those
+// further down are listed at the last level in the order of writing.
+func nestReplies(comments []entity.Comment, maxDepth int) []entity.Comment {
+	if maxDepth < 1 {
+		maxDepth = 1
+	}
+
+	known := make(map[int64]bool, len(comments))
+	for _, comment := range comments {
+		known[comment.Id] = true
+	}
+	var roots []entity.Comment
+	replies := make(map[int64][]entity.Comment)
+	for _, comment := range comments {
+		// Replies whose parent is gone are kept at the top level.
+		if comment.ParentId == 0 || !known[comment.ParentId] {
+			roots = append(roots, comment)
+			continue
 		}
-		err = cu.repo.DeleteDislike(comment)
-		if err != nil {
-			return fmt.Errorf("CommentsUseCase - MakeReaction #3 -  %w", err)
+		replies[comment.ParentId] = append(replies[comment.ParentId], comment)
+	}
+
+	var below func(id int64) []entity.Comment
+	below = func(id int64) []entity.Comment {
+		var list []entity.Comment
+		for _, reply := range replies[id] {
+			list = append(list, reply)
+			list = append(list, below(reply.Id)...)
 		}
-	case ReactionDislike:
-		err := cu.repo.StoreDislike(comment)
-		if err != nil {
-			if strings.Contains(err.Error(), UniqueReactionErr) {
-				err = cu.repo.DeleteDislike(comment)
-				if err != nil {
-					return fmt.Errorf("CommentsUseCase - MakeReaction #4 - %w", err)
-				}
-				return nil
+		return list
+	}
+
+	var nest func(comment entity.Comment, depth int) entity.Comment
+	nest = func(comment entity.Comment, depth int) entity.Comment {
+		comment.Depth = depth
+		if depth+1 < maxDepth {
+			for _, reply := range replies[comment.Id] {
+				comment.Replies = append(comment.Replies, nest(reply, depth+1))
 			}
-			return fmt.Errorf("CommentsUseCase - MakeReaction #5 - %w", err)
+			return comment
 		}
-		err = cu.repo.DeleteLike(comment)
-		if err != nil {
-			return fmt.Errorf("CommentsUseCase - MakeReaction #6 - %w", err)
+
+		comment.Replies = below(comment.Id)
+		sort.Slice(comment.Replies, func(i, j int) bool {
+			return comment.Replies[i].Id < comment.Replies[j].Id
+		})
+		for i := range comment.Replies {
+			comment.Replies[i].Depth = maxDepth
 		}
+		return comment
 	}
-	return nil
+
+	for i := range roots {
+		roots[i] = nest(roots[i], 0)
+	}
+	return roots
+}
+
+func (cu *CommentsUseCase) fillAuthors(ctx context.Context, comments []entity.Comment) error {
+	ids := make([]int64, len(comments))
+	for i := range comments {
+		ids[i] = comments[i].Id
+	}
+	reactionCounts, err := cu.reactions.counts(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range comments {
+		comments[i].Reactions = reactionCounts[comments[i].Id]
+	}
+	return newLoader(cu.postRepo, cu.repo, cu.userRepo).authors(ctx, comments)
+}
+
+func (cu *CommentsUseCase) GetById(ctx context.Context, id int64) (entity.Comment, error) {
+	comment, err := cu.repo.GetById(ctx, id)
+	if err != nil {
+		if strings.Contains(err.Error(), NoRowsResultErr) {
+			return comment, entity.ErrCommentNotFound
+		}
+		return comment, fmt.Errorf("CommentsUseCase - GetById - %w", err)
+	}
+	return comment, nil
 }
 
-func (cu *CommentsUseCase) DeleteReaction(comment entity.Comment, command string) error {
-	switch command {
-	case ReactionLike:
-		err := cu.repo.DeleteLike(comment)
+// UpdateComment overwrites the comment and keeps both the previous and the
+// new version as revisions, comment.User is the editor. Images list the
+// ones to keep, nil keeps all of them.
+func (cu *CommentsUseCase) UpdateComment(ctx context.Context, comment entity.Comment) error {
+	return cu.uow.Do(ctx, func(repos *repository.Repositories) error {
+		old, err := repos.Comments.GetById(ctx, comment.Id)
 		if err != nil {
-			return fmt.Errorf("CommentsUseCase - DeleteReaction #1 - %w", err)
+			if strings.Contains(err.Error(), NoRowsResultErr) {
+				return entity.ErrCommentNotFound
+			}
+			return fmt.Errorf("CommentsUseCase - UpdateComment #1 - %w", err)
+		}
+		if old.IsDeleted() {
+			return entity.ErrCommentNotFound
 		}
-	case ReactionDislike:
-		err := cu.repo.DeleteDislike(comment)
+
+		comment.Images = keptImages(old.Images, comment.Images)
+		comment.EditedAt = time.Now()
+		err = repos.Comments.Update(ctx, comment)
 		if err != nil {
-			return fmt.Errorf("CommentsUseCase - DeleteReaction #2 - %w", err)
+			return fmt.Errorf("CommentsUseCase - UpdateComment #2 - %w", err)
 		}
+
+		err = cu.revisions.in(repos).record(ctx,
+			entity.Revision{TargetId: old.Id, User: old.User, Date: old.Date, Content: old.Content},
+			entity.Revision{TargetId: comment.Id, User: comment.User, Date: comment.EditedAt, Content: comment.Content},
+		)
+		if err != nil {
+			return fmt.Errorf("CommentsUseCase - UpdateComment #3 - %w", err)
+		}
+		return nil
+	})
+}
+
+// GetRevisions lists versions of the comment oldest first, comments never
+// edited have none.
+func (cu *CommentsUseCase) GetRevisions(ctx context.Context, id int64) ([]entity.Revision, error) {
+	list, err := cu.revisions.list(ctx, id)
+	if err != nil {
+		return list, fmt.Errorf("CommentsUseCase - GetRevisions - %w", err)
 	}
-	return nil
+	return list, nil
 }
 
-func (cu *CommentsUseCase) GetReactions(id int64, query string) ([]entity.User, error) {
-	var users []entity.User
-	comment, err := cu.repo.FetchReactions(id)
+// DiffRevisions compares versions number from and to of the comment.
+func (cu *CommentsUseCase) DiffRevisions(ctx context.Context, id int64, from, to int) (entity.Diff, error) {
+	diff, err := cu.revisions.diff(ctx, id, from, to)
 	if err != nil {
-		return users, fmt.Errorf("PostsUseCase - GetReactions #1 - %w", err)
+		if errors.Is(err, entity.ErrRevisionNotFound) {
+			return diff, err
+		}
+		return diff, fmt.Errorf("CommentsUseCase - DiffRevisions - %w", err)
 	}
+	return diff, nil
+}
 
-	switch query {
-	case PostLikedQuery:
-		for i := 0; i < len(comment.Likes); i++ {
-			user, err := cu.userRepo.GetById(comment.Likes[i].UserId)
-			if err != nil {
-				return users, fmt.Errorf("PostsUseCase - GetReactions #2 - %w", err)
-			}
-			users = append(users, user)
+// DeleteComment moves the comment to the trash, DeletedBy and DeleteReason
+// of the comment tell who deleted it and why.
+func (cu *CommentsUseCase) DeleteComment(ctx context.Context, comment entity.Comment) error {
+	comment.DeletedAt = time.Now()
+	err := cu.repo.Delete(ctx, comment)
+	if err != nil {
+		if strings.Contains(err.Error(), NoRowsResultErr) {
+			return entity.ErrCommentNotFound
 		}
-	case PostDislikedQuery:
-		for i := 0; i < len(comment.Dislikes); i++ {
-			user, err := cu.userRepo.GetById(comment.Dislikes[i].UserId)
-			if err != nil {
-				return users, fmt.Errorf("PostsUseCase - GetReactions #3 - %w", err)
+		return fmt.Errorf("CommentsUseCase - DeleteComment - %w", err)
+	}
+	return nil
+}
+
+// MakeReaction toggles the reaction of comment.User on the comment, it
+// fails with entity.ErrCommentNotFound when the comment is missing or in
+// the trash.
+func (cu *CommentsUseCase) MakeReaction(ctx context.Context, comment entity.Comment, kind string) error {
+	if _, ok := cu.reactions.kinds.Find(kind); !ok {
+		return entity.ErrUnknownReaction
+	}
+	err := cu.uow.Do(ctx, func(repos *repository.Repositories) error {
+		found, err := repos.Comments.GetById(ctx, comment.Id)
+		if err != nil {
+			if strings.Contains(err.Error(), NoRowsResultErr) {
+				return entity.ErrCommentNotFound
 			}
-			users = append(users, user)
+			return fmt.Errorf("GetById - %w", err)
+		}
+		if found.IsDeleted() {
+			return entity.ErrCommentNotFound
 		}
+		return cu.reactions.in(repos).toggle(ctx, comment.Id, comment.User.Id, kind)
+	})
+	if err != nil {
+		return fmt.Errorf("CommentsUseCase - MakeReaction - %w", err)
+	}
+	return nil
+}
+
+func (cu *CommentsUseCase) DeleteReaction(ctx context.Context, comment entity.Comment, kind string) error {
+	err := cu.reactions.remove(ctx, comment.Id, comment.User.Id, kind)
+	if err != nil {
+		return fmt.Errorf("CommentsUseCase - DeleteReaction - %w", err)
 	}
+	return nil
+}
 
+func (cu *CommentsUseCase) GetReactions(ctx context.Context, id int64, kind string) ([]entity.User, error) {
+	users, err := cu.reactions.users(ctx, id, kind, cu.userRepo)
+	if err != nil {
+		return users, fmt.Errorf("CommentsUseCase - GetReactions - %w", err)
+	}
 	return users, nil
 }
@@ -1,85 +1,289 @@
 package usecase_test
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"forum/internal/entity"
-	m "forum/internal/repository/sqlite/mock"
+	"forum/internal/repository"
+	"forum/internal/repository/memory"
 	"forum/internal/usecase"
 )
 
 var (
 	comment1 = entity.Comment{
+		Id:      1,
 		PostId:  1,
 		User:    user1,
-		Date:    "2022-05-04",
+		Date:    time.Date(2022, 5, 4, 0, 0, 0, 0, time.UTC),
 		Content: "Lorem ipsum, dolor sit amet",
 	}
 	comment2 = entity.Comment{
+		Id:      2,
 		PostId:  1,
 		User:    user4,
-		Date:    "2022-05-04",
+		Date:    time.Date(2022, 5, 4, 0, 0, 0, 0, time.UTC),
 		Content: "Lorem sit amet",
 	}
 )
 
+const commentsMaxDepth = 3
+
 func TestWriteComments(t *testing.T) {
+	ctx := context.Background()
+
 	t.Run("OK", func(t *testing.T) {
-		mockRepo := m.NewMockRepos()
-		commentUseCase := usecase.NewCommentsUseCase(mockRepo.Comments, mockRepo.Posts, mockRepo.Users)
-		if err := commentUseCase.WriteComment(comment1); err != nil {
+		repos := repository.NewMemoryRepositories(memory.New())
+		commentUseCase := usecase.NewCommentsUseCase(repos.Comments, repos.Posts, repos.Users, repos.Reactions, repos.Revisions,
+			repos.UnitOfWork, entity.DefaultReactionKinds, commentsMaxDepth)
+		if err := commentUseCase.WriteComment(ctx, comment1); err != nil {
 			t.Fatal(err)
 		}
 	})
+
+	t.Run("err parent not found", func(t *testing.T) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		commentUseCase := usecase.NewCommentsUseCase(repos.Comments, repos.Posts, repos.Users, repos.Reactions, repos.Revisions,
+			repos.UnitOfWork, entity.DefaultReactionKinds, commentsMaxDepth)
+		if err := commentUseCase.WriteComment(ctx, comment1); err != nil {
+			t.Fatal(err)
+		}
+
+		reply := comment2
+		reply.ParentId = 7
+		if err := commentUseCase.WriteComment(ctx, reply); !errors.Is(err, entity.ErrCommentNotFound) {
+			t.Fatalf("want: %v, got: %v", entity.ErrCommentNotFound, err)
+		}
+
+		reply.ParentId, reply.PostId = 1, 2
+		if err := commentUseCase.WriteComment(ctx, reply); !errors.Is(err, entity.ErrCommentNotFound) {
+			t.Fatalf("want: %v, got: %v", entity.ErrCommentNotFound, err)
+		}
+	})
 }
 
 func TestGetAllComments(t *testing.T) {
+	ctx := context.Background()
+
 	t.Run("OK", func(t *testing.T) {
-		mockRepo := m.NewMockRepos()
-		userUseCase := setupUserUseCase(mockRepo)
-		commentUseCase := usecase.NewCommentsUseCase(mockRepo.Comments, mockRepo.Posts, mockRepo.Users)
+		repos := repository.NewMemoryRepositories(memory.New())
+		userUseCase := setupUserUseCase(repos)
+		commentUseCase := usecase.NewCommentsUseCase(repos.Comments, repos.Posts, repos.Users, repos.Reactions, repos.Revisions,
+			repos.UnitOfWork, entity.DefaultReactionKinds, commentsMaxDepth)
 
-		if err := userUseCase.SignUp(user1); err != nil {
+		if err := userUseCase.SignUp(ctx, user1); err != nil {
 			t.Fatal(err)
 		}
 
-		if err := userUseCase.SignUp(user4); err != nil {
+		if err := userUseCase.SignUp(ctx, user4); err != nil {
 			t.Fatal(err)
 		}
 
-		if err := commentUseCase.WriteComment(comment1); err != nil {
+		if err := commentUseCase.WriteComment(ctx, comment1); err != nil {
 			t.Fatal(err)
 		}
 
-		if err := commentUseCase.WriteComment(comment2); err != nil {
+		if err := commentUseCase.WriteComment(ctx, comment2); err != nil {
 			t.Fatal(err)
 		}
 
-		if found, err := commentUseCase.GetAllComments(post1.Id); err != nil {
+		if found, err := commentUseCase.GetAllComments(ctx, post1.Id); err != nil {
 			t.Fatal(err)
 		} else if len(found) != 2 {
 			t.Fatalf("want: %d, got: %d", 2, len(found))
 		}
 	})
+	t.Run("OK threads", func(t *testing.T) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		userUseCase := setupUserUseCase(repos)
+		commentUseCase := usecase.NewCommentsUseCase(repos.Comments, repos.Posts, repos.Users, repos.Reactions, repos.Revisions,
+			repos.UnitOfWork, entity.DefaultReactionKinds, commentsMaxDepth)
+
+		if err := userUseCase.SignUp(ctx, user1); err != nil {
+			t.Fatal(err)
+		}
+
+		// Every comment replies to the previous one, the fifth goes past
+		// the maximum depth.
+		for parentId := int64(0); parentId < 5; parentId++ {
+			reply := comment1
+			reply.ParentId = parentId
+			if err := commentUseCase.WriteComment(ctx, reply); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		found, err := commentUseCase.GetAllComments(ctx, post1.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(found) != 1 || len(found[0].Replies) != 1 || len(found[0].Replies[0].Replies) != 1 {
+			t.Fatalf("want a chain of replies, got: %v", found)
+		}
+		last := found[0].Replies[0].Replies[0]
+		if last.Id != 3 || last.Depth != 2 {
+			t.Fatalf("want: comment %d at depth %d, got: comment %d at depth %d", 3, 2, last.Id, last.Depth)
+		}
+		if len(last.Replies) != 2 || last.Replies[0].Id != 4 || last.Replies[1].Id != 5 ||
+			last.Replies[1].Depth != commentsMaxDepth || len(last.Replies[0].Replies) != 0 {
+			t.Fatalf("want comments 4 and 5 at depth %d, got: %v", commentsMaxDepth, last.Replies)
+		}
+	})
+}
+
+func TestGetCommentsPage(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories(memory.New())
+	userUseCase := setupUserUseCase(repos)
+	commentUseCase := usecase.NewCommentsUseCase(repos.Comments, repos.Posts, repos.Users, repos.Reactions, repos.Revisions,
+		repos.UnitOfWork, entity.DefaultReactionKinds, commentsMaxDepth)
+
+	if err := userUseCase.SignUp(ctx, user1); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < usecase.CommentsPerPage+1; i++ {
+		if err := commentUseCase.WriteComment(ctx, entity.Comment{PostId: 1, User: user1,
+			Content: "Lorem"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("OK page", func(t *testing.T) {
+		if found, page, err := commentUseCase.GetCommentsPage(ctx, 1, 2); err != nil {
+			t.Fatal(err)
+		} else if len(found) != 1 {
+			t.Fatalf("want: %d, got: %d", 1, len(found))
+		} else if found[0].User.Name != user1.Name {
+			t.Fatalf("want: %v, got: %v", user1.Name, found[0].User.Name)
+		} else if page.Pages() != 2 {
+			t.Fatalf("want: %d, got: %d", 2, page.Pages())
+		}
+	})
+
+	t.Run("OK cursor", func(t *testing.T) {
+		found, page, err := commentUseCase.GetCommentsAfter(ctx, 1, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(found) != usecase.CommentsPerPage || page.NextCursor != usecase.CommentsPerPage {
+			t.Fatalf("unexpected listing: %d comments, page %+v", len(found), page)
+		}
+
+		if found, page, err = commentUseCase.GetCommentsAfter(ctx, 1, page.NextCursor); err != nil {
+			t.Fatal(err)
+		} else if len(found) != 1 || page.HasNext() {
+			t.Fatalf("unexpected listing: %d comments, page %+v", len(found), page)
+		}
+	})
+	t.Run("OK replies", func(t *testing.T) {
+		reply := entity.Comment{PostId: 1, ParentId: 1, User: user1, Content: "Lorem"}
+		if err := commentUseCase.WriteComment(ctx, reply); err != nil {
+			t.Fatal(err)
+		}
+
+		found, page, err := commentUseCase.GetCommentsPage(ctx, 1, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.Pages() != 2 || len(found) != usecase.CommentsPerPage {
+			t.Fatalf("unexpected listing: %d comments, page %+v", len(found), page)
+		}
+		if len(found[0].Replies) != 1 || found[0].Replies[0].User.Name != user1.Name {
+			t.Fatalf("want one reply with its author, got: %v", found[0].Replies)
+		}
+	})
+
+	t.Run("OK threads", func(t *testing.T) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		commentUseCase := usecase.NewCommentsUseCase(repos.Comments, repos.Posts, repos.Users, repos.Reactions,
+			repos.Revisions, repos.UnitOfWork, entity.DefaultReactionKinds, commentsMaxDepth)
+		if err := setupUserUseCase(repos).SignUp(ctx, user1); err != nil {
+			t.Fatal(err)
+		}
+
+		// Comments 2 to 5 reply to the previous one, a page of top level
+		// comments follows and the reply to the last of them is written
+		// last.
+		for _, parentId := range []int64{0, 1, 2, 3, 4} {
+			reply := entity.Comment{PostId: 1, ParentId: parentId, User: user1, Content: "Lorem"}
+			if err := commentUseCase.WriteComment(ctx, reply); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for i := 0; i < usecase.CommentsPerPage; i++ {
+			if err := commentUseCase.WriteComment(ctx, entity.Comment{PostId: 1, User: user1,
+				Content: "Lorem"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		reply := entity.Comment{PostId: 1, ParentId: int64(5 + usecase.CommentsPerPage), User: user1, Content: "Lorem"}
+		if err := commentUseCase.WriteComment(ctx, reply); err != nil {
+			t.Fatal(err)
+		}
+
+		found, _, err := commentUseCase.GetCommentsPage(ctx, 1, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(found) != usecase.CommentsPerPage || len(found[0].Replies) != 1 {
+			t.Fatalf("want the first page with one thread, got: %v", found)
+		}
+		deepest := found[0].Replies[0].Replies[0]
+		if deepest.Id != 3 || len(deepest.Replies) != 2 || deepest.Replies[1].Id != 5 {
+			t.Fatalf("want comments 4 and 5 under comment 3, got: %v", deepest)
+		}
+		for _, comment := range found[1:] {
+			if len(comment.Replies) != 0 {
+				t.Fatalf("want no replies under comment %d, got: %v", comment.Id, comment.Replies)
+			}
+		}
+
+		found, _, err = commentUseCase.GetCommentsPage(ctx, 1, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lastId := int64(5 + usecase.CommentsPerPage)
+		if len(found) != 1 || found[0].Id != lastId || len(found[0].Replies) != 1 ||
+			found[0].Replies[0].Id != lastId+1 {
+			t.Fatalf("want comment %d with its reply, got: %v", lastId, found)
+		}
+	})
 }
 
 func TestUpdateComment(t *testing.T) {
-	mockRepo := m.NewMockRepos()
-	commentUseCase := usecase.NewCommentsUseCase(mockRepo.Comments, mockRepo.Posts, mockRepo.Users)
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories(memory.New())
+	commentUseCase := usecase.NewCommentsUseCase(repos.Comments, repos.Posts, repos.Users, repos.Reactions, repos.Revisions,
+		repos.UnitOfWork, entity.DefaultReactionKinds, commentsMaxDepth)
 
 	t.Run("OK", func(t *testing.T) {
-		if err := commentUseCase.WriteComment(comment1); err != nil {
+		if err := commentUseCase.WriteComment(ctx, comment1); err != nil {
 			t.Fatal(err)
 		}
 
 		newContent := "New content abc"
 		comment1.Content = newContent
 
-		if err := commentUseCase.UpdateComment(comment1); err != nil {
+		if err := commentUseCase.UpdateComment(ctx, comment1); err != nil {
+			t.Fatal(err)
+		}
+		if found, err := repos.Comments.GetById(ctx, comment1.Id); err != nil {
+			t.Fatal(err)
+		} else if found.Content != newContent {
+			t.Fatalf("want: %v, got: %v", newContent, found.Content)
+		}
+
+		if found, err := commentUseCase.GetRevisions(ctx, comment1.Id); err != nil {
 			t.Fatal(err)
+		} else if len(found) != 2 || found[1].Content != newContent {
+			t.Fatalf("want original and edited revisions, got: %v", found)
 		}
-		if mockRepo.Comments.Comments[0].Content != newContent {
-			t.Fatalf("want: %v, got: %v", newContent, mockRepo.Comments.Comments[0].Content)
+		if diff, err := commentUseCase.DiffRevisions(ctx, comment1.Id, 1, 2); err != nil {
+			t.Fatal(err)
+		} else if len(diff.Lines) != 2 || !diff.Lines[0].Removed() || !diff.Lines[1].Added() {
+			t.Fatalf("want one line replaced, got: %v", diff.Lines)
 		}
 	})
 
@@ -90,83 +294,137 @@ func TestUpdateComment(t *testing.T) {
 			User:    user1,
 		}
 
-		if err := commentUseCase.UpdateComment(notExisted); err == nil {
-			t.Fatal("Epected error")
+		if err := commentUseCase.UpdateComment(ctx, notExisted); !errors.Is(err, entity.ErrCommentNotFound) {
+			t.Fatalf("want: %v, got: %v", entity.ErrCommentNotFound, err)
 		}
 	})
 }
 
 func TestDeleteComment(t *testing.T) {
-	mockRepo := m.NewMockRepos()
-	commentUseCase := usecase.NewCommentsUseCase(mockRepo.Comments, mockRepo.Posts, mockRepo.Users)
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories(memory.New())
+	commentUseCase := usecase.NewCommentsUseCase(repos.Comments, repos.Posts, repos.Users, repos.Reactions, repos.Revisions,
+		repos.UnitOfWork, entity.DefaultReactionKinds, commentsMaxDepth)
 
 	t.Run("OK", func(t *testing.T) {
-		if err := commentUseCase.WriteComment(comment1); err != nil {
+		if err := commentUseCase.WriteComment(ctx, comment1); err != nil {
 			t.Fatal(err)
 		}
 
-		if len(mockRepo.Comments.Comments) != 1 {
-			t.Fatalf("want: %d, got: %d", 1, len(mockRepo.Comments.Comments))
+		if found, err := repos.Comments.Fetch(ctx, comment1.PostId); err != nil {
+			t.Fatal(err)
+		} else if len(found) != 1 {
+			t.Fatalf("want: %d, got: %d", 1, len(found))
 		}
 
-		if err := commentUseCase.DeleteComment(comment1); err != nil {
+		deleted := comment1
+		deleted.DeletedBy = 1
+		deleted.DeleteReason = "spam"
+		if err := commentUseCase.DeleteComment(ctx, deleted); err != nil {
 			t.Fatal(err)
 		}
 
-		if len(mockRepo.Comments.Comments) != 0 {
-			t.Fatalf("want: %d, got: %d", 0, len(mockRepo.Comments.Comments))
+		if found, err := repos.Comments.Fetch(ctx, comment1.PostId); err != nil {
+			t.Fatal(err)
+		} else if len(found) != 1 || !found[0].IsDeleted() || found[0].DeleteReason != "spam" {
+			t.Fatalf("want deleted comment, got: %v", found)
 		}
 	})
 
 	t.Run("err not exist", func(t *testing.T) {
-		if err := commentUseCase.DeleteComment(comment1); err == nil {
-			t.Fatal("Expected error")
+		if err := commentUseCase.DeleteComment(ctx, comment1); !errors.Is(err, entity.ErrCommentNotFound) {
+			t.Fatalf("want: %v, got: %v", entity.ErrCommentNotFound, err)
+		}
+	})
+}
+
+func TestRestoreComment(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories(memory.New())
+	userUseCase := setupUserUseCase(repos)
+	commentUseCase := usecase.NewCommentsUseCase(repos.Comments, repos.Posts, repos.Users, repos.Reactions, repos.Revisions,
+		repos.UnitOfWork, entity.DefaultReactionKinds, commentsMaxDepth)
+
+	if err := userUseCase.SignUp(ctx, user1); err != nil {
+		t.Fatal(err)
+	}
+	if err := commentUseCase.WriteComment(ctx, comment1); err != nil {
+		t.Fatal(err)
+	}
+	if err := commentUseCase.DeleteComment(ctx, comment1); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		if found, err := commentUseCase.GetDeletedComments(ctx); err != nil {
+			t.Fatal(err)
+		} else if len(found) != 1 || found[0].User.Name != user1.Name {
+			t.Fatalf("want comment of %s, got: %v", user1.Name, found)
+		}
+
+		if err := commentUseCase.RestoreComment(ctx, 1); err != nil {
+			t.Fatal(err)
+		}
+
+		if found, err := commentUseCase.GetDeletedComments(ctx); err != nil {
+			t.Fatal(err)
+		} else if len(found) != 0 {
+			t.Fatalf("want: %d, got: %d", 0, len(found))
+		}
+	})
+
+	t.Run("err not deleted", func(t *testing.T) {
+		if err := commentUseCase.RestoreComment(ctx, 1); !errors.Is(err, entity.ErrCommentNotFound) {
+			t.Fatalf("want: %v, got: %v", entity.ErrCommentNotFound, err)
 		}
 	})
 }
 
 func TestCommentMakeReaction(t *testing.T) {
+	ctx := context.Background()
+
 	t.Run("OK", func(t *testing.T) {
-		mockRepo := m.NewMockRepos()
-		userUseCase := setupUserUseCase(mockRepo)
-		commentUseCase := usecase.NewCommentsUseCase(mockRepo.Comments, mockRepo.Posts, mockRepo.Users)
+		repos := repository.NewMemoryRepositories(memory.New())
+		userUseCase := setupUserUseCase(repos)
+		commentUseCase := usecase.NewCommentsUseCase(repos.Comments, repos.Posts, repos.Users, repos.Reactions, repos.Revisions,
+			repos.UnitOfWork, entity.DefaultReactionKinds, commentsMaxDepth)
 
-		if err := userUseCase.SignUp(user1); err != nil {
+		if err := userUseCase.SignUp(ctx, user1); err != nil {
 			t.Fatal(err)
 		}
 
-		if err := userUseCase.SignUp(user4); err != nil {
+		if err := userUseCase.SignUp(ctx, user4); err != nil {
 			t.Fatal(err)
 		}
 
-		if err := userUseCase.SignUp(user5); err != nil {
+		if err := userUseCase.SignUp(ctx, user5); err != nil {
 			t.Fatal(err)
 		}
 
-		if err := commentUseCase.WriteComment(comment1); err != nil {
+		if err := commentUseCase.WriteComment(ctx, comment1); err != nil {
 			t.Fatal(err)
 		}
 
-		if err := commentUseCase.MakeReaction(comment1, usecase.ReactionLike); err != nil {
+		if err := commentUseCase.MakeReaction(ctx, comment1, "like"); err != nil {
 			t.Fatal(err)
 		}
 
 		comment1.User = user4
-		if err := commentUseCase.MakeReaction(comment1, usecase.ReactionLike); err != nil {
+		if err := commentUseCase.MakeReaction(ctx, comment1, "like"); err != nil {
 			t.Fatal(err)
 		}
 
-		if found, err := commentUseCase.GetReactions(comment1.Id, usecase.PostLikedQuery); err != nil {
+		if found, err := commentUseCase.GetReactions(ctx, comment1.Id, "like"); err != nil {
 			t.Fatal(err)
 		} else if len(found) != 2 {
 			t.Fatalf("want: %d, got: %d", 2, len(found))
 		}
 
-		if err := commentUseCase.MakeReaction(comment1, usecase.ReactionLike); err != nil {
+		if err := commentUseCase.MakeReaction(ctx, comment1, "like"); err != nil {
 			t.Fatal(err)
 		}
 
-		if found, err := commentUseCase.GetReactions(comment1.Id, usecase.PostLikedQuery); err != nil {
+		if found, err := commentUseCase.GetReactions(ctx, comment1.Id, "like"); err != nil {
 			t.Fatal(err)
 		} else if len(found) != 1 {
 			t.Fatalf("want: %d, got: %d", 1, len(found))
@@ -174,24 +432,49 @@ func TestCommentMakeReaction(t *testing.T) {
 
 		comment1.User = user5
 
-		if err := commentUseCase.MakeReaction(comment1, usecase.ReactionDislike); err != nil {
+		if err := commentUseCase.MakeReaction(ctx, comment1, "dislike"); err != nil {
 			t.Fatal(err)
 		}
 
-		if found, err := commentUseCase.GetReactions(comment1.Id, usecase.PostDislikedQuery); err != nil {
+		if found, err := commentUseCase.GetReactions(ctx, comment1.Id, "dislike"); err != nil {
 			t.Fatal(err)
 		} else if len(found) != 1 {
 			t.Fatalf("want: %d, got: %d", 1, len(found))
 		}
 
-		if err := commentUseCase.MakeReaction(comment1, usecase.ReactionDislike); err != nil {
+		if err := commentUseCase.MakeReaction(ctx, comment1, "dislike"); err != nil {
 			t.Fatal(err)
 		}
 
-		if found, err := commentUseCase.GetReactions(comment1.Id, usecase.PostDislikedQuery); err != nil {
+		if found, err := commentUseCase.GetReactions(ctx, comment1.Id, "dislike"); err != nil {
 			t.Fatal(err)
 		} else if len(found) != 0 {
 			t.Fatalf("want: %d, got: %d", 0, len(found))
 		}
 	})
+
+	t.Run("err not found", func(t *testing.T) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		userUseCase := setupUserUseCase(repos)
+		commentUseCase := usecase.NewCommentsUseCase(repos.Comments, repos.Posts, repos.Users, repos.Reactions, repos.Revisions,
+			repos.UnitOfWork, entity.DefaultReactionKinds, commentsMaxDepth)
+
+		if err := commentUseCase.MakeReaction(ctx, comment1, "like"); !errors.Is(err, entity.ErrCommentNotFound) {
+			t.Fatalf("want: %v, got: %v", entity.ErrCommentNotFound, err)
+		}
+
+		if err := userUseCase.SignUp(ctx, user1); err != nil {
+			t.Fatal(err)
+		}
+		if err := commentUseCase.WriteComment(ctx, comment1); err != nil {
+			t.Fatal(err)
+		}
+		if err := commentUseCase.DeleteComment(ctx, comment1); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := commentUseCase.MakeReaction(ctx, comment1, "like"); !errors.Is(err, entity.ErrCommentNotFound) {
+			t.Fatalf("want: %v, got: %v", entity.ErrCommentNotFound, err)
+		}
+	})
 }
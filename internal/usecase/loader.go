@@ -0,0 +1,119 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"forum/internal/entity"
+	"forum/internal/repository"
+)
+
+// loader fetches details of listed posts and comments in batches, so a
+// listing runs the same number of queries whatever its length. It serves
+// one request and keeps the users it has loaded.
+type loader struct {
+	posts    repository.Posts
+	comments repository.Comments
+	users    repository.Users
+	known    map[int64]entity.User
+}
+
+func newLoader(posts repository.Posts, comments repository.Comments, users repository.Users) *loader {
+	return &loader{
+		posts:    posts,
+		comments: comments,
+		users:    users,
+		known:    make(map[int64]entity.User),
+	}
+}
+
+func (l *loader) categories(ctx context.Context, postIds []int64) (map[int64][]entity.Category, error) {
+	categories, err := l.posts.FetchCategories(ctx, postIds)
+	if err != nil {
+		return nil, fmt.Errorf("categories - %w", err)
+	}
+	return categories, nil
+}
+
+// postComments returns comments of the posts keyed by post id, with their
+// authors.
+func (l *loader) postComments(ctx context.Context, postIds []int64) (map[int64][]entity.Comment, error) {
+	byPost, err := l.comments.FetchByPosts(ctx, postIds)
+	if err != nil {
+		return nil, fmt.Errorf("postComments #1 - %w", err)
+	}
+
+	var all []entity.Comment
+	for _, comments := range byPost {
+		all = append(all, comments...)
+	}
+	err = l.loadUsers(ctx, authorIds(all))
+	if err != nil {
+		return nil, fmt.Errorf("postComments #2 - %w", err)
+	}
+	for _, comments := range byPost {
+		l.fillAuthors(comments)
+	}
+	return byPost, nil
+}
+
+// authors sets the authors and the web content of the comments.
+func (l *loader) authors(ctx context.Context, comments []entity.Comment) error {
+	err := l.loadUsers(ctx, authorIds(comments))
+	if err != nil {
+		return fmt.Errorf("authors - %w", err)
+	}
+	l.fillAuthors(comments)
+	return nil
+}
+
+// fillAuthors expects authors to be loaded already, comments of users
+// that are gone keep the bare author id.
+func (l *loader) fillAuthors(comments []entity.Comment) {
+	for i := range comments {
+		if user, ok := l.known[comments[i].User.Id]; ok {
+			comments[i].User = user
+		}
+		comments[i].ContentWeb = strings.Split(comments[i].Content, "\\n")
+	}
+}
+
+// loadUsers fetches the users not loaded yet in one query.
+func (l *loader) loadUsers(ctx context.Context, ids []int64) error {
+	var missing []int64
+	for _, id := range ids {
+		if _, ok := l.known[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	users, err := l.users.FetchByIds(ctx, missing)
+	if err != nil {
+		return fmt.Errorf("loadUsers - %w", err)
+	}
+	for _, user := range users {
+		if user.Gender == UserGenderMale {
+			user.Male = true
+		} else if user.Gender == UserGenderFemale {
+			user.Female = true
+		}
+		l.known[user.Id] = user
+	}
+	return nil
+}
+
+func authorIds(comments []entity.Comment) []int64 {
+	seen := make(map[int64]bool, len(comments))
+	var ids []int64
+	for _, comment := range comments {
+		if !seen[comment.User.Id] {
+			seen[comment.User.Id] = true
+			ids = append(ids, comment.User.Id)
+		}
+	}
+	return ids
+}
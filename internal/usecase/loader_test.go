@@ -0,0 +1,134 @@
+package usecase_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"forum/internal/entity"
+	"forum/internal/repository"
+	"forum/internal/repository/sqlite"
+	"forum/internal/usecase"
+	"forum/pkg/sqlconn"
+	"forum/pkg/sqlite3"
+)
+
+// countingConn counts statements run outside of transactions.
+type countingConn struct {
+	sqlconn.Conn
+	queries *int64
+}
+
+func (c countingConn) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	atomic.AddInt64(c.queries, 1)
+	return c.Conn.ExecContext(ctx, query, args...)
+}
+
+func (c countingConn) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	atomic.AddInt64(c.queries, 1)
+	return c.Conn.QueryContext(ctx, query, args...)
+}
+
+func (c countingConn) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	atomic.AddInt64(c.queries, 1)
+	return c.Conn.QueryRowContext(ctx, query, args...)
+}
+
+func (c countingConn) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	atomic.AddInt64(c.queries, 1)
+	return c.Conn.PrepareContext(ctx, query)
+}
+
+// setupListing stores posts with a category and three comments by
+// different users each on a fresh sqlite database and returns the posts
+// usecase with the counter of its queries.
+func setupListing(tb testing.TB, posts int) (*usecase.PostsUseCase, *int64) {
+	ctx := context.Background()
+	db, err := sqlite3.New(fmt.Sprintf("file:listing%d?mode=memory&cache=shared", posts), sqlite3.Options{})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(db.Close)
+	if err = sqlite.CreateDB(db); err != nil {
+		tb.Fatal(err)
+	}
+
+	repos := repository.NewRepositories(db)
+	for i := 1; i <= 3; i++ {
+		user := entity.User{Name: fmt.Sprint("user", i), Email: fmt.Sprint("user", i, "@mail.ru")}
+		if err = repos.Users.Store(ctx, user); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	cars := entity.Category{Name: "Cars", Slug: "cars"}
+	if err = repos.Categories.Store(ctx, &cars); err != nil {
+		tb.Fatal(err)
+	}
+	for i := 1; i <= posts; i++ {
+		post := entity.Post{User: entity.User{Id: 1}, Title: "Audi", Content: "Lorem ipsum.",
+			Categories: []entity.Category{cars}}
+		if err = repos.Posts.Store(ctx, &post); err != nil {
+			tb.Fatal(err)
+		}
+		if err = repos.Posts.StoreTopicReference(ctx, post); err != nil {
+			tb.Fatal(err)
+		}
+		for userId := int64(1); userId <= 3; userId++ {
+			comment := entity.Comment{PostId: post.Id, User: entity.User{Id: userId}, Content: "Lorem ipsum."}
+			if err = repos.Comments.Store(ctx, &comment); err != nil {
+				tb.Fatal(err)
+			}
+		}
+	}
+
+	var queries int64
+	counted := repository.NewRepositories(&sqlite3.Sqlite{DB: db.DB, Conn: countingConn{db.Conn, &queries}})
+	postUseCase := usecase.NewPostsUseCase(counted.Posts, counted.Users, counted.Comments, counted.Reactions,
+		counted.Revisions, counted.UnitOfWork, entity.DefaultReactionKinds)
+	return postUseCase, &queries
+}
+
+func TestGetAllPostsQueries(t *testing.T) {
+	ctx := context.Background()
+	var counts []int64
+	for _, posts := range []int{2, 30} {
+		postUseCase, queries := setupListing(t, posts)
+
+		found, err := postUseCase.GetAllPosts(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(found) != posts {
+			t.Fatalf("want: %d, got: %d", posts, len(found))
+		}
+		for _, post := range found {
+			if len(post.Categories) != 1 || len(post.Comments) != 3 || post.Comments[2].User.Name != "user3" {
+				t.Fatalf("want filled post, got: %+v", post)
+			}
+		}
+		counts = append(counts, *queries)
+	}
+
+	if counts[0] != counts[1] {
+		t.Fatalf("want the same number of queries, got: %v", counts)
+	}
+}
+
+func BenchmarkGetAllPosts(b *testing.B) {
+	ctx := context.Background()
+	for _, posts := range []int{10, 100} {
+		b.Run(fmt.Sprint(posts, " posts"), func(b *testing.B) {
+			postUseCase, queries := setupListing(b, posts)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := postUseCase.GetAllPosts(ctx); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(atomic.LoadInt64(queries))/float64(b.N), "queries/op")
+		})
+	}
+}
@@ -1,37 +1,73 @@
 package mock_usecase
 
 import (
+	"context"
+	"io"
+	"strings"
+	"time"
+
 	"forum/internal/entity"
 )
 
+// UsersMockUseCase signs the users in with a verified email unless
+// Unverified is set, the password is all it asks for unless TwoFactor is
+// set. The user of id 1 has the admin role, others the default one.
 type UsersMockUseCase struct {
-	Users []entity.User
+	Users      []entity.User
+	Sessions   []entity.Session
+	Unverified bool
+	TwoFactor  bool
+	// Banned lists the ids of banned users.
+	Banned []int64
 }
 
 func NewUsersMockUseCase() *UsersMockUseCase {
 	return &UsersMockUseCase{}
 }
 
-func (um *UsersMockUseCase) SignUp(u entity.User) error {
+func (um *UsersMockUseCase) SignUp(ctx context.Context, u entity.User) error {
 	um.Users = append(um.Users, u)
 	return nil
 }
 
-func (um *UsersMockUseCase) SignIn(u entity.User) error {
-	return nil
+func (um *UsersMockUseCase) SignIn(ctx context.Context, u entity.User, session entity.Session) (entity.Session,
+	error) {
+	id, _ := um.GetIdBy(ctx, u)
+	session.User = entity.User{Id: id}
+	for _, bannedId := range um.Banned {
+		if bannedId == id {
+			return session, entity.ErrUserBanned
+		}
+	}
+	if um.TwoFactor {
+		return session, entity.ErrTwoFactorRequired
+	}
+	session.Token = "token"
+	session.ExpiresAt = time.Now().Add(time.Hour)
+	return session, nil
 }
 
-func (um *UsersMockUseCase) GetAllUsers() ([]entity.User, error) {
+func (um *UsersMockUseCase) GetAllUsers(ctx context.Context) ([]entity.User, error) {
 	return []entity.User{}, nil
 }
 
-func (um *UsersMockUseCase) GetById(id int64) (entity.User, error) {
-	return entity.User{}, nil
+func (um *UsersMockUseCase) GetUsersPage(ctx context.Context, page int) ([]entity.User, entity.Page, error) {
+	return []entity.User{}, entity.Page{Number: page}, nil
+}
+
+func (um *UsersMockUseCase) GetUsersAfter(ctx context.Context, cursor int64) ([]entity.User, entity.Page, error) {
+	return []entity.User{}, entity.Page{}, nil
 }
 
-func (um *UsersMockUseCase) GetIdBy(user entity.User) (int64, error) {
+func (um *UsersMockUseCase) GetById(ctx context.Context, id int64) (entity.User, error) {
+	return entity.User{EmailVerified: !um.Unverified}, nil
+}
+
+func (um *UsersMockUseCase) GetIdBy(ctx context.Context, user entity.User) (int64, error) {
 	var id int64
-	if len(um.Users) > 1 {
+	if len(um.Users) == 0 {
+		return id, entity.ErrUserNotFound
+	} else if len(um.Users) > 1 {
 		id = 5
 	} else if len(um.Users) == 1 {
 		id = 1
@@ -40,104 +76,542 @@ func (um *UsersMockUseCase) GetIdBy(user entity.User) (int64, error) {
 	return id, nil
 }
 
-func (um *UsersMockUseCase) GetSession(id int64) (entity.User, error) {
-	return entity.User{}, nil
-}
-
-func (um *UsersMockUseCase) CheckSession(u entity.User) (bool, error) {
+func (um *UsersMockUseCase) CheckSession(ctx context.Context, session entity.Session) (entity.Session, bool,
+	error) {
 	if len(um.Users) != 0 {
-		return true, nil
+		id, _ := um.GetIdBy(ctx, entity.User{})
+		session.User = entity.User{Id: id, EmailVerified: !um.Unverified, Role: mockRole(id)}
+		return session, true, nil
 	}
-	return false, nil
+	return session, false, nil
 }
 
-func (um *UsersMockUseCase) UpdateUserInfo(u entity.User, query string) error {
+func (um *UsersMockUseCase) UpdateUserInfo(ctx context.Context, u entity.User, query string) error {
 	return nil
 }
 
-func (um *UsersMockUseCase) UpdateSession(u entity.User) error {
+func (um *UsersMockUseCase) UpdateSession(ctx context.Context, session entity.Session) error {
 	return nil
 }
 
-func (um *UsersMockUseCase) DeleteSession(user entity.User) error {
+func (um *UsersMockUseCase) DeleteSession(ctx context.Context, session entity.Session) error {
 	return nil
 }
 
-func (um *UsersMockUseCase) DeleteUser(u entity.User) error {
+func (um *UsersMockUseCase) GetSessions(ctx context.Context, current entity.Session) ([]entity.Session, error) {
+	var sessions []entity.Session
+	for _, session := range um.Sessions {
+		if session.User.Id == current.User.Id {
+			session.Current = session.Id == current.Id
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions, nil
+}
+
+func (um *UsersMockUseCase) RevokeSession(ctx context.Context, userId, id int64) error {
+	for i, session := range um.Sessions {
+		if session.Id == id && session.User.Id == userId {
+			um.Sessions = append(um.Sessions[:i], um.Sessions[i+1:]...)
+			return nil
+		}
+	}
+	return entity.ErrSessionNotFound
+}
+
+func (um *UsersMockUseCase) RevokeAllSessions(ctx context.Context, userId int64) error {
+	var sessions []entity.Session
+	for _, session := range um.Sessions {
+		if session.User.Id != userId {
+			sessions = append(sessions, session)
+		}
+	}
+	um.Sessions = sessions
+	return nil
+}
+
+func (um *UsersMockUseCase) DeleteUser(ctx context.Context, u entity.User) error {
 	um.Users = um.Users[:len(um.Users)-1]
 	return nil
 }
 
+func (um *UsersMockUseCase) BanUser(ctx context.Context, id int64) error {
+	if id == 1 {
+		return entity.ErrBanNotAllowed
+	}
+	um.Banned = append(um.Banned, id)
+	return nil
+}
+
+func (um *UsersMockUseCase) UnbanUser(ctx context.Context, id int64) error {
+	banned := um.Banned[:0]
+	for _, bannedId := range um.Banned {
+		if bannedId != id {
+			banned = append(banned, bannedId)
+		}
+	}
+	um.Banned = banned
+	return nil
+}
+
+// mockRole is the role of the user as UsersMockUseCase sees it.
+func mockRole(id int64) entity.Role {
+	if id == 1 {
+		return entity.Role{Id: entity.RoleAdminId, Name: "Администратор", Permissions: entity.Permissions}
+	}
+	return entity.Role{Id: entity.RoleUserId, Name: "Пользователь"}
+}
+
+// RolesMockUseCase starts with the built-in roles and keeps the roles
+// given to users in Assigned.
+type RolesMockUseCase struct {
+	Roles    []entity.Role
+	Assigned map[int64]int64
+}
+
+func NewRolesMockUseCase() *RolesMockUseCase {
+	return &RolesMockUseCase{
+		Roles: []entity.Role{
+			mockRole(2),
+			{Id: entity.RoleModeratorId, Name: "Модератор", Permissions: []entity.Permission{
+				entity.PermEditAnyPost, entity.PermDeleteAnyPost, entity.PermBanUsers,
+			}},
+			mockRole(1),
+		},
+		Assigned: make(map[int64]int64),
+	}
+}
+
+func (rm *RolesMockUseCase) GetRoles(ctx context.Context) ([]entity.Role, error) {
+	return rm.Roles, nil
+}
+
+func (rm *RolesMockUseCase) GetRole(ctx context.Context, id int64) (entity.Role, error) {
+	for _, role := range rm.Roles {
+		if role.Id == id {
+			return role, nil
+		}
+	}
+	return entity.Role{}, entity.ErrRoleNotFound
+}
+
+func (rm *RolesMockUseCase) CreateRole(ctx context.Context, role entity.Role) error {
+	if strings.TrimSpace(role.Name) == "" {
+		return entity.ErrRoleNameEmpty
+	}
+	for _, existed := range rm.Roles {
+		if existed.Name == role.Name {
+			return entity.ErrRoleExists
+		}
+	}
+	role.Id = rm.Roles[len(rm.Roles)-1].Id + 1
+	rm.Roles = append(rm.Roles, role)
+	return nil
+}
+
+func (rm *RolesMockUseCase) UpdateRole(ctx context.Context, role entity.Role) error {
+	if role.Id == entity.RoleAdminId {
+		return entity.ErrRoleBuiltin
+	}
+	for i := range rm.Roles {
+		if rm.Roles[i].Id == role.Id {
+			rm.Roles[i] = role
+			return nil
+		}
+	}
+	return entity.ErrRoleNotFound
+}
+
+func (rm *RolesMockUseCase) DeleteRole(ctx context.Context, id int64) error {
+	if (entity.Role{Id: id}).Builtin() {
+		return entity.ErrRoleBuiltin
+	}
+	for i := range rm.Roles {
+		if rm.Roles[i].Id == id {
+			rm.Roles = append(rm.Roles[:i], rm.Roles[i+1:]...)
+			return nil
+		}
+	}
+	return entity.ErrRoleNotFound
+}
+
+func (rm *RolesMockUseCase) AssignRole(ctx context.Context, userId, roleId int64) error {
+	if _, err := rm.GetRole(ctx, roleId); err != nil {
+		return err
+	}
+	if userId == 1 && roleId != entity.RoleAdminId {
+		return entity.ErrLastAdmin
+	}
+	rm.Assigned[userId] = roleId
+	return nil
+}
+
+// PasswordResetsMockUseCase takes ValidToken as the only valid link and
+// keeps the emails links were asked for.
+type PasswordResetsMockUseCase struct {
+	Emails []string
+	Reset  bool
+}
+
+const ValidToken = "valid"
+
+func NewPasswordResetsMockUseCase() *PasswordResetsMockUseCase {
+	return &PasswordResetsMockUseCase{}
+}
+
+func (rm *PasswordResetsMockUseCase) RequestReset(ctx context.Context, email string) error {
+	rm.Emails = append(rm.Emails, email)
+	return nil
+}
+
+func (rm *PasswordResetsMockUseCase) CheckToken(ctx context.Context, token string) error {
+	if token != ValidToken {
+		return entity.ErrResetTokenInvalid
+	}
+	return nil
+}
+
+func (rm *PasswordResetsMockUseCase) ResetPassword(ctx context.Context, token, password string) error {
+	if token != ValidToken {
+		return entity.ErrResetTokenInvalid
+	}
+	rm.Reset = true
+	return nil
+}
+
+// VerificationMockUseCase takes ValidToken as the only valid link, keeps
+// the users letters were sent to and the emails asked for.
+type VerificationMockUseCase struct {
+	Sent      []int64
+	Emails    []string
+	Verified  bool
+	Throttled bool
+}
+
+func NewVerificationMockUseCase() *VerificationMockUseCase {
+	return &VerificationMockUseCase{}
+}
+
+func (vm *VerificationMockUseCase) SendVerification(ctx context.Context, userId int64) error {
+	if vm.Throttled {
+		return entity.ErrVerificationThrottled
+	}
+	vm.Sent = append(vm.Sent, userId)
+	return nil
+}
+
+func (vm *VerificationMockUseCase) ChangeEmail(ctx context.Context, userId int64, email string) error {
+	if vm.Throttled {
+		return entity.ErrVerificationThrottled
+	}
+	vm.Emails = append(vm.Emails, email)
+	return nil
+}
+
+func (vm *VerificationMockUseCase) Verify(ctx context.Context, token string) error {
+	if token != ValidToken {
+		return entity.ErrVerificationInvalid
+	}
+	vm.Verified = true
+	return nil
+}
+
+// ValidCode is the only code TwoFactorMockUseCase takes.
+const ValidCode = "123456"
+
+// TwoFactorMockUseCase takes ValidToken as the only challenge and
+// ValidCode as the only code, it keeps the users reset by admins in Resets.
+type TwoFactorMockUseCase struct {
+	Enabled bool
+	Resets  []int64
+}
+
+func NewTwoFactorMockUseCase() *TwoFactorMockUseCase {
+	return &TwoFactorMockUseCase{}
+}
+
+func (tm *TwoFactorMockUseCase) Status(ctx context.Context, userId int64) (entity.TwoFactor, error) {
+	tf := entity.TwoFactor{UserId: userId, Enabled: tm.Enabled}
+	if tm.Enabled {
+		tf.RecoveryCodes = 10
+	}
+	return tf, nil
+}
+
+func (tm *TwoFactorMockUseCase) BeginEnrollment(ctx context.Context, userId int64) (entity.TwoFactorEnrollment,
+	error) {
+	if tm.Enabled {
+		return entity.TwoFactorEnrollment{}, entity.ErrTwoFactorEnabled
+	}
+	return entity.TwoFactorEnrollment{Secret: "SECRET", URI: "otpauth://totp/Forum:user?secret=SECRET"}, nil
+}
+
+func (tm *TwoFactorMockUseCase) Enable(ctx context.Context, userId int64, code string) ([]string, error) {
+	if tm.Enabled {
+		return nil, entity.ErrTwoFactorEnabled
+	}
+	if code != ValidCode {
+		return nil, entity.ErrTwoFactorCodeInvalid
+	}
+	tm.Enabled = true
+	return []string{"aaaaa-bbbbb", "ccccc-ddddd"}, nil
+}
+
+func (tm *TwoFactorMockUseCase) Disable(ctx context.Context, userId int64, code string) error {
+	if !tm.Enabled {
+		return entity.ErrTwoFactorDisabled
+	}
+	if code != ValidCode {
+		return entity.ErrTwoFactorCodeInvalid
+	}
+	tm.Enabled = false
+	return nil
+}
+
+func (tm *TwoFactorMockUseCase) Reset(ctx context.Context, userId int64) error {
+	tm.Resets = append(tm.Resets, userId)
+	tm.Enabled = false
+	return nil
+}
+
+func (tm *TwoFactorMockUseCase) Challenge(ctx context.Context, userId int64) (string, error) {
+	return ValidToken, nil
+}
+
+func (tm *TwoFactorMockUseCase) CompleteSignIn(ctx context.Context, token, code string,
+	session entity.Session,
+) (entity.Session, error) {
+	if token != ValidToken {
+		return session, entity.ErrChallengeInvalid
+	}
+	if code != ValidCode {
+		return session, entity.ErrTwoFactorCodeInvalid
+	}
+	session.User = entity.User{Id: 1}
+	session.Token = "token"
+	session.ExpiresAt = time.Now().Add(time.Hour)
+	return session, nil
+}
+
 type PostsMockUseCase struct {
-	Posts      []entity.Post
-	Categories []string
+	Posts     []entity.Post
+	Deleted   []entity.Post
+	Revisions []entity.Revision
 }
 
 func NewPostsMockUseCase() *PostsMockUseCase {
 	return &PostsMockUseCase{}
 }
 
-func (pm *PostsMockUseCase) CreatePost(p entity.Post) error {
+func (pm *PostsMockUseCase) CreatePost(ctx context.Context, p entity.Post) error {
 	pm.Posts = append(pm.Posts, p)
 	return nil
 }
 
-func (pm *PostsMockUseCase) GetAllPosts() ([]entity.Post, error) {
+func (pm *PostsMockUseCase) GetAllPosts(ctx context.Context) ([]entity.Post, error) {
 	return pm.Posts, nil
 }
 
-func (pm *PostsMockUseCase) GetPostsByQuery(user entity.User, query string) ([]entity.Post, error) {
+func (pm *PostsMockUseCase) GetPostsPage(ctx context.Context, page int) ([]entity.Post, entity.Page, error) {
+	return pm.Posts, entity.Page{Number: page, Total: int64(len(pm.Posts))}, nil
+}
+
+func (pm *PostsMockUseCase) GetPostsAfter(ctx context.Context, cursor int64) ([]entity.Post, entity.Page, error) {
+	return pm.Posts, entity.Page{Total: int64(len(pm.Posts))}, nil
+}
+
+func (pm *PostsMockUseCase) GetPostsByQuery(ctx context.Context, user entity.User,
+	query string) ([]entity.Post, error) {
 	return []entity.Post{}, nil
 }
 
-func (pm *PostsMockUseCase) GetById(id int64) (entity.Post, error) {
+func (pm *PostsMockUseCase) GetById(ctx context.Context, id int64) (entity.Post, error) {
 	return entity.Post{}, nil
 }
 
-func (pm *PostsMockUseCase) GetAllByCategory(category string) ([]entity.Post, error) {
+func (pm *PostsMockUseCase) GetAllByCategory(ctx context.Context, categoryId int64) ([]entity.Post, error) {
 	posts := []entity.Post{}
-	found := false
-
-	for _, v := range pm.Categories {
-		if v == category {
-			found = true
-			break
+	for _, post := range pm.Posts {
+		for _, category := range post.Categories {
+			if category.Id == categoryId {
+				posts = append(posts, post)
+				break
+			}
 		}
 	}
 
-	if !found {
+	if len(posts) == 0 {
 		return posts, entity.ErrPostNotFound
 	}
 	return posts, nil
 }
 
-func (pm *PostsMockUseCase) UpdatePost(post entity.Post) error {
+func (pm *PostsMockUseCase) GetByCategoryPage(ctx context.Context, categoryId int64,
+	page int) ([]entity.Post, entity.Page, error) {
+	posts, err := pm.GetAllByCategory(ctx, categoryId)
+	return posts, entity.Page{Number: page}, err
+}
+
+func (pm *PostsMockUseCase) GetByCategoryAfter(ctx context.Context, categoryId,
+	cursor int64) ([]entity.Post, entity.Page, error) {
+	posts, err := pm.GetAllByCategory(ctx, categoryId)
+	return posts, entity.Page{Total: int64(len(posts))}, err
+}
+
+func (pm *PostsMockUseCase) UpdatePost(ctx context.Context, post entity.Post) error {
+	pm.Revisions = append(pm.Revisions, entity.Revision{
+		Target:   entity.RevisionTargetPost,
+		TargetId: post.Id,
+		Number:   len(pm.Revisions) + 1,
+		User:     post.User,
+		Title:    post.Title,
+		Content:  post.Content,
+	})
 	return nil
 }
 
-func (pm *PostsMockUseCase) DeletePost(p entity.Post) error {
+func (pm *PostsMockUseCase) GetRevisions(ctx context.Context, id int64) ([]entity.Revision, error) {
+	return pm.Revisions, nil
+}
+
+func (pm *PostsMockUseCase) DiffRevisions(ctx context.Context, id int64, from, to int) (entity.Diff, error) {
+	if from < 1 || from > len(pm.Revisions) || to < 1 || to > len(pm.Revisions) {
+		return entity.Diff{}, entity.ErrRevisionNotFound
+	}
+	return entity.Diff{From: pm.Revisions[from-1], To: pm.Revisions[to-1]}, nil
+}
+
+func (pm *PostsMockUseCase) DeletePost(ctx context.Context, p entity.Post) error {
+	pm.Deleted = append(pm.Deleted, p)
 	return nil
 }
 
-func (pm *PostsMockUseCase) MakeReaction(p entity.Post, command string) error {
+func (pm *PostsMockUseCase) RestorePost(ctx context.Context, id int64) error {
+	for i, v := range pm.Deleted {
+		if v.Id == id {
+			pm.Deleted = append(pm.Deleted[:i], pm.Deleted[i+1:]...)
+			return nil
+		}
+	}
+	return entity.ErrPostNotFound
+}
+
+func (pm *PostsMockUseCase) GetDeletedPosts(ctx context.Context) ([]entity.Post, error) {
+	return pm.Deleted, nil
+}
+
+func (pm *PostsMockUseCase) PurgeDeleted(ctx context.Context, retention time.Duration) (int64, error) {
+	return 0, nil
+}
+
+func (pm *PostsMockUseCase) MakeReaction(ctx context.Context, p entity.Post, kind string) error {
+	if _, ok := entity.DefaultReactionKinds.Find(kind); !ok {
+		return entity.ErrUnknownReaction
+	}
 	return nil
 }
 
-func (pm *PostsMockUseCase) DeleteReaction(post entity.Post, command string) error {
+func (pm *PostsMockUseCase) DeleteReaction(ctx context.Context, post entity.Post, kind string) error {
 	return nil
 }
 
-func (pm *PostsMockUseCase) CreateCategories(categories []string) error {
-	pm.Categories = append(pm.Categories, categories...)
+func (pm *PostsMockUseCase) GetReactions(ctx context.Context, id int64, kind string) ([]entity.User, error) {
+	return []entity.User{}, nil
+}
+
+func (pm *PostsMockUseCase) Search(ctx context.Context, query string) ([]entity.SearchResult, error) {
+	results := []entity.SearchResult{}
+	for _, v := range pm.Posts {
+		if strings.Contains(strings.ToLower(v.Title), strings.ToLower(query)) {
+			results = append(results, entity.SearchResult{Post: v, Snippet: v.Title})
+		}
+	}
+	return results, nil
+}
+
+type CategoriesMockUseCase struct {
+	Categories []entity.Category
+}
+
+func NewCategoriesMockUseCase() *CategoriesMockUseCase {
+	return &CategoriesMockUseCase{}
+}
+
+func (cm *CategoriesMockUseCase) CreateCategories(ctx context.Context, categories []entity.Category) error {
+	for _, category := range categories {
+		if category.ParentId > int64(len(cm.Categories)) {
+			return entity.ErrCategoryNotFound
+		}
+		category.Id = int64(len(cm.Categories) + 1)
+		category.Slug = strings.ToLower(category.Name)
+		cm.Categories = append(cm.Categories, category)
+	}
 	return nil
 }
 
-func (pm *PostsMockUseCase) GetAllCategories() ([]string, error) {
-	return []string{}, nil
+func (cm *CategoriesMockUseCase) GetCategories(ctx context.Context) ([]entity.Category, error) {
+	return cm.Categories, nil
 }
 
-func (pm *PostsMockUseCase) GetReactions(id int64, query string) ([]entity.User, error) {
-	return []entity.User{}, nil
+func (cm *CategoriesMockUseCase) GetBySlug(ctx context.Context, slug string) (entity.Category, error) {
+	for _, category := range cm.Categories {
+		if category.Slug == slug {
+			return category, nil
+		}
+	}
+	return entity.Category{}, entity.ErrCategoryNotFound
+}
+
+func (cm *CategoriesMockUseCase) RenameCategory(ctx context.Context, id int64, name string) error {
+	if name == "" {
+		return entity.ErrCategoryNameEmpty
+	}
+	i := cm.find(id)
+	if i < 0 {
+		return entity.ErrCategoryNotFound
+	}
+	cm.Categories[i].Name = name
+	cm.Categories[i].Slug = strings.ToLower(name)
+	return nil
+}
+
+func (cm *CategoriesMockUseCase) MergeCategories(ctx context.Context, fromId, intoId int64) error {
+	if intoId == 0 {
+		return entity.ErrCategoryNotFound
+	}
+	return cm.DeleteCategory(ctx, fromId, intoId)
+}
+
+func (cm *CategoriesMockUseCase) DeleteCategory(ctx context.Context, id, targetId int64) error {
+	if id == targetId {
+		return entity.ErrCategoryMergeSelf
+	}
+	i := cm.find(id)
+	if i < 0 || targetId != 0 && cm.find(targetId) < 0 {
+		return entity.ErrCategoryNotFound
+	}
+	cm.Categories = append(cm.Categories[:i], cm.Categories[i+1:]...)
+	return nil
+}
+
+func (cm *CategoriesMockUseCase) ReorderCategories(ctx context.Context, positions map[int64]int) error {
+	for id, position := range positions {
+		i := cm.find(id)
+		if i < 0 {
+			return entity.ErrCategoryNotFound
+		}
+		cm.Categories[i].Position = position
+	}
+	return nil
+}
+
+func (cm *CategoriesMockUseCase) find(id int64) int {
+	for i, category := range cm.Categories {
+		if category.Id == id {
+			return i
+		}
+	}
+	return -1
 }
 
 type CommentsMockUseCase struct{}
@@ -146,30 +620,116 @@ func NewCommentsMockUseCase() *CommentsMockUseCase {
 	return &CommentsMockUseCase{}
 }
 
-func (cm *CommentsMockUseCase) WriteComment(c entity.Comment) error {
+func (cm *CommentsMockUseCase) WriteComment(ctx context.Context, c entity.Comment) error {
 	return nil
 }
 
-func (cm *CommentsMockUseCase) GetAllComments(postId int64) ([]entity.Comment, error) {
+func (cm *CommentsMockUseCase) GetAllComments(ctx context.Context, postId int64) ([]entity.Comment, error) {
 	return []entity.Comment{}, nil
 }
 
-func (cm *CommentsMockUseCase) UpdateComment(c entity.Comment) error {
+func (cm *CommentsMockUseCase) GetCommentsPage(ctx context.Context, postId int64,
+	page int) ([]entity.Comment, entity.Page, error) {
+	return []entity.Comment{}, entity.Page{Number: page}, nil
+}
+
+func (cm *CommentsMockUseCase) GetCommentsAfter(ctx context.Context,
+	postId, cursor int64) ([]entity.Comment, entity.Page, error) {
+	return []entity.Comment{}, entity.Page{}, nil
+}
+
+func (cm *CommentsMockUseCase) GetById(ctx context.Context, id int64) (entity.Comment, error) {
+	return entity.Comment{Id: id, PostId: 1}, nil
+}
+
+func (cm *CommentsMockUseCase) UpdateComment(ctx context.Context, c entity.Comment) error {
 	return nil
 }
 
-func (cm *CommentsMockUseCase) DeleteComment(c entity.Comment) error {
+func (cm *CommentsMockUseCase) GetRevisions(ctx context.Context, id int64) ([]entity.Revision, error) {
+	return []entity.Revision{}, nil
+}
+
+func (cm *CommentsMockUseCase) DiffRevisions(ctx context.Context, id int64, from, to int) (entity.Diff, error) {
+	return entity.Diff{}, entity.ErrRevisionNotFound
+}
+
+func (cm *CommentsMockUseCase) DeleteComment(ctx context.Context, c entity.Comment) error {
 	return nil
 }
 
-func (cm *CommentsMockUseCase) MakeReaction(c entity.Comment, command string) error {
+func (cm *CommentsMockUseCase) RestoreComment(ctx context.Context, id int64) error {
 	return nil
 }
 
-func (cm *CommentsMockUseCase) DeleteReaction(c entity.Comment, command string) error {
+func (cm *CommentsMockUseCase) GetDeletedComments(ctx context.Context) ([]entity.Comment, error) {
+	return []entity.Comment{}, nil
+}
+
+func (cm *CommentsMockUseCase) PurgeDeleted(ctx context.Context, retention time.Duration) (int64, error) {
+	return 0, nil
+}
+
+func (cm *CommentsMockUseCase) MakeReaction(ctx context.Context, c entity.Comment, kind string) error {
+	if _, ok := entity.DefaultReactionKinds.Find(kind); !ok {
+		return entity.ErrUnknownReaction
+	}
 	return nil
 }
 
-func (cm *CommentsMockUseCase) GetReactions(id int64, query string) ([]entity.User, error) {
+func (cm *CommentsMockUseCase) DeleteReaction(ctx context.Context, c entity.Comment, kind string) error {
+	return nil
+}
+
+func (cm *CommentsMockUseCase) GetReactions(ctx context.Context, id int64, kind string) ([]entity.User, error) {
 	return []entity.User{}, nil
 }
+
+type BackupsMockUseCase struct {
+	Backups []entity.Backup
+}
+
+func NewBackupsMockUseCase() *BackupsMockUseCase {
+	return &BackupsMockUseCase{}
+}
+
+func (bm *BackupsMockUseCase) CreateBackup(ctx context.Context) (entity.Backup, error) {
+	backup := entity.Backup{Name: "forum-20221019-120000.000.db"}
+	for _, stored := range bm.Backups {
+		if stored.Name == backup.Name {
+			return entity.Backup{}, entity.ErrBackupExists
+		}
+	}
+	bm.Backups = append(bm.Backups, backup)
+	return backup, nil
+}
+
+func (bm *BackupsMockUseCase) GetBackups() ([]entity.Backup, error) {
+	return bm.Backups, nil
+}
+
+func (bm *BackupsMockUseCase) GetBackupPath(name string) (string, error) {
+	return "", entity.ErrBackupNotFound
+}
+
+func (bm *BackupsMockUseCase) RestoreBackup(ctx context.Context, path string) error {
+	return nil
+}
+
+type ArchiveMockUseCase struct{}
+
+func NewArchiveMockUseCase() *ArchiveMockUseCase {
+	return &ArchiveMockUseCase{}
+}
+
+func (am *ArchiveMockUseCase) Export(ctx context.Context, w io.Writer, withPasswords bool) error {
+	_, err := w.Write([]byte("PK"))
+	return err
+}
+
+func (am *ArchiveMockUseCase) Import(ctx context.Context, r io.ReaderAt, size int64) (entity.ImportSummary, error) {
+	if size == 0 {
+		return entity.ImportSummary{}, entity.ErrArchiveInvalid
+	}
+	return entity.ImportSummary{Users: 1}, nil
+}
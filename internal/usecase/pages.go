@@ -0,0 +1,28 @@
+package usecase
+
+import "forum/internal/entity"
+
+// pageWindow normalizes a 1-based page number and returns the page
+// together with the offset of its first row. A number past the last page
+// is capped to it, so the offset never overflows or passes total.
+func pageWindow(number, size int, total int64) (entity.Page, int) {
+	page := entity.Page{Number: number, Size: size, Total: total}
+	if page.Number > page.Pages() {
+		page.Number = page.Pages()
+	}
+	if page.Number < 1 {
+		page.Number = 1
+	}
+	return page, (page.Number - 1) * size
+}
+
+// cursorPage describes a keyset page. The repository is asked for one row
+// more than size, fetched is the number of rows it returned and lastId the
+// id of the last row kept on the page.
+func cursorPage(size, fetched int, lastId int64, total int64) entity.Page {
+	page := entity.Page{Size: size, Total: total}
+	if fetched > size {
+		page.NextCursor = lastId
+	}
+	return page
+}
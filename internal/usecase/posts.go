@@ -1,9 +1,13 @@
 package usecase
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
-	"sync"
+	"time"
+	"unicode"
 
 	"forum/internal/entity"
 	"forum/internal/repository"
@@ -14,104 +18,164 @@ type PostsUseCase struct {
 
 	userRepo    repository.Users
 	commentRepo repository.Comments
+	reactions   reactions
+	revisions   revisions
+	uow         repository.UnitOfWork
 }
 
 const (
 	PostCommentedQuery = "commented"
 	PostAuthorQuery    = "author"
-	PostLikedQuery     = "liked"
-	PostDislikedQuery  = "disliked"
-	ReactionLike       = "like"
-	ReactionDislike    = "dislike"
-	UniqueReactionErr  = "UNIQUE constraint failed"
 	NoRowsResultErr    = "no rows in result set"
+	SearchLimit        = 50
 )
 
-func NewPostsUseCase(repo repository.Posts, usersRepo repository.Users, commentsRepo repository.Comments) *PostsUseCase {
+func NewPostsUseCase(repo repository.Posts, usersRepo repository.Users, commentsRepo repository.Comments,
+	reactionsRepo repository.Reactions, revisionsRepo repository.Revisions, uow repository.UnitOfWork,
+	kinds entity.ReactionKinds,
+) *PostsUseCase {
 	return &PostsUseCase{
 		repo:        repo,
 		userRepo:    usersRepo,
 		commentRepo: commentsRepo,
+		reactions:   reactions{repo: reactionsRepo, kinds: kinds, target: entity.ReactionTargetPost},
+		revisions:   revisions{repo: revisionsRepo, target: entity.RevisionTargetPost},
+		uow:         uow,
 	}
 }
 
-func (pu *PostsUseCase) CreatePost(post entity.Post) error {
-	post.Date = getRegTime(DateAndTimeFormat)
-	err := pu.repo.Store(&post)
+// CreatePost stores the post in its categories, which are given by id.
+func (pu *PostsUseCase) CreatePost(ctx context.Context, post entity.Post) error {
+	post.Date = time.Now()
+	return pu.uow.Do(ctx, func(repos *repository.Repositories) error {
+		_, err := loadCategories(ctx, repos.Categories, post.Categories)
+		if err != nil {
+			return fmt.Errorf("PostsUseCase - CreatePost #1 - %w", err)
+		}
+		err = repos.Posts.Store(ctx, &post)
+		if err != nil {
+			return fmt.Errorf("PostsUseCase - CreatePost #2 - %w", err)
+		}
+		err = repos.Posts.StoreTopicReference(ctx, post)
+		if err != nil {
+			return fmt.Errorf("PostsUseCase - CreatePost #3 - %w", err)
+		}
+		return nil
+	})
+}
+
+func (pu *PostsUseCase) GetAllPosts(ctx context.Context) ([]entity.Post, error) {
+	posts, err := pu.repo.Fetch(ctx)
 	if err != nil {
-		return fmt.Errorf("PostsUseCase - CreatePost #1 - %w", err)
+		return posts, fmt.Errorf("PostsUseCase - GetAllPosts #1 - %w", err)
+	}
+
+	if len(posts) != 0 {
+		err = pu.fillPostDetails(ctx, &posts)
+		if err != nil {
+			return posts, fmt.Errorf("PostsUseCase - GetAllPosts #2 - %w", err)
+		}
 	}
-	err = pu.repo.StoreTopicReference(post)
+	return posts, nil
+}
+
+func (pu *PostsUseCase) GetPostsPage(ctx context.Context, number int) ([]entity.Post, entity.Page, error) {
+	total, err := pu.repo.Count(ctx)
 	if err != nil {
-		return fmt.Errorf("PostsUseCase - CreatePost #2 - %w", err)
+		return nil, entity.Page{}, fmt.Errorf("PostsUseCase - GetPostsPage #1 - %w", err)
 	}
-	return nil
+	page, offset := pageWindow(number, PostsPerPage, total)
+
+	posts, err := pu.repo.FetchPage(ctx, PostsPerPage, offset)
+	if err != nil {
+		return nil, page, fmt.Errorf("PostsUseCase - GetPostsPage #2 - %w", err)
+	}
+
+	if len(posts) != 0 {
+		err = pu.fillPostDetails(ctx, &posts)
+		if err != nil {
+			return posts, page, fmt.Errorf("PostsUseCase - GetPostsPage #3 - %w", err)
+		}
+	}
+	return posts, page, nil
 }
 
-func (pu *PostsUseCase) GetAllPosts() ([]entity.Post, error) {
-	posts, err := pu.repo.Fetch()
+func (pu *PostsUseCase) GetPostsAfter(ctx context.Context, cursor int64) ([]entity.Post, entity.Page, error) {
+	total, err := pu.repo.Count(ctx)
 	if err != nil {
-		return posts, fmt.Errorf("PostsUseCase - GetAllPosts #1 - %w", err)
+		return nil, entity.Page{}, fmt.Errorf("PostsUseCase - GetPostsAfter #1 - %w", err)
+	}
+
+	posts, err := pu.repo.FetchAfter(ctx, cursor, PostsPerPage+1)
+	if err != nil {
+		return nil, entity.Page{}, fmt.Errorf("PostsUseCase - GetPostsAfter #2 - %w", err)
+	}
+	fetched := len(posts)
+	if fetched > PostsPerPage {
+		posts = posts[:PostsPerPage]
 	}
+	var lastId int64
+	if len(posts) != 0 {
+		lastId = posts[len(posts)-1].Id
+	}
+	page := cursorPage(PostsPerPage, fetched, lastId, total)
 
 	if len(posts) != 0 {
-		err = pu.fillPostDetails(&posts)
+		err = pu.fillPostDetails(ctx, &posts)
 		if err != nil {
-			return posts, fmt.Errorf("PostsUseCase - GetAllPosts #2 - %w", err)
+			return posts, page, fmt.Errorf("PostsUseCase - GetPostsAfter #3 - %w", err)
 		}
 	}
-	return posts, nil
+	return posts, page, nil
 }
 
-func (pu *PostsUseCase) GetPostsByQuery(user entity.User, query string) ([]entity.Post, error) {
+func (pu *PostsUseCase) GetPostsByQuery(ctx context.Context, user entity.User, query string) ([]entity.Post, error) {
 	var posts []entity.Post
 	var err error
 	switch query {
 	case PostAuthorQuery:
-		posts, err = pu.repo.FetchByAuthor(user)
+		posts, err = pu.repo.FetchByAuthor(ctx, user)
 		if err != nil {
 			return posts, fmt.Errorf("PostsUseCase - GetPostsByQuery #1 - %w", err)
 		}
-	case PostLikedQuery:
-		ids, err := pu.repo.FetchIdsByReaction(user, PostLikedQuery)
+	case PostCommentedQuery:
+		ids, err := pu.commentRepo.GetPostIds(ctx, user)
 		if err != nil {
-			return posts, fmt.Errorf("PostsUseCase - GetPostsByQuery #2 - %w", err)
+			return posts, fmt.Errorf("PostsUseCase - GetPostsByQuery #5 - %w", err)
 		}
 		for i := 0; i < len(ids); i++ {
-			post, err := pu.repo.GetById(ids[i])
+			post, err := pu.repo.GetById(ctx, ids[i])
 			if err != nil {
-				return posts, fmt.Errorf("PostsUseCase - GetPostsByQuery #3 - %w", err)
+				return posts, fmt.Errorf("PostsUseCase - GetPostsByQuery #6 - %w", err)
 			}
-			posts = append(posts, post)
-		}
-	case PostDislikedQuery:
-		ids, err := pu.repo.FetchIdsByReaction(user, PostDislikedQuery)
-		if err != nil {
-			return posts, fmt.Errorf("PostsUseCase - GetPostsByQuery #4 - %w", err)
-		}
-		for i := 0; i < len(ids); i++ {
-			post, err := pu.repo.GetById(ids[i])
-			if err != nil {
-				return posts, fmt.Errorf("PostsUseCase - GetPostsByQuery #5 - %w", err)
+			if post.IsDeleted() {
+				continue
 			}
 			posts = append(posts, post)
 		}
-	case PostCommentedQuery:
-		ids, err := pu.commentRepo.GetPostIds(user)
+	default:
+		// any other query is a reaction kind the user put on posts
+		if _, ok := pu.reactions.kinds.Find(query); !ok {
+			return posts, entity.ErrUnknownReaction
+		}
+		ids, err := pu.reactions.repo.FetchTargetIds(ctx, entity.ReactionTargetPost, user.Id, query)
 		if err != nil {
-			return posts, fmt.Errorf("PostsUseCase - GetPostsByQuery #5 - %w", err)
+			return posts, fmt.Errorf("PostsUseCase - GetPostsByQuery #2 - %w", err)
 		}
 		for i := 0; i < len(ids); i++ {
-			post, err := pu.repo.GetById(ids[i])
+			post, err := pu.repo.GetById(ctx, ids[i])
 			if err != nil {
-				return posts, fmt.Errorf("PostsUseCase - GetPostsByQuery #6 - %w", err)
+				return posts, fmt.Errorf("PostsUseCase - GetPostsByQuery #4 - %w", err)
+			}
+			if post.IsDeleted() {
+				continue
 			}
 			posts = append(posts, post)
 		}
 	}
 
 	if len(posts) != 0 {
-		err = pu.fillPostDetails(&posts)
+		err = pu.fillPostDetails(ctx, &posts)
 		if err != nil {
 			return posts, fmt.Errorf("PostsUseCase - GetPostsByQuery #3 - %w", err)
 		}
@@ -119,8 +183,8 @@ func (pu *PostsUseCase) GetPostsByQuery(user entity.User, query string) ([]entit
 	return posts, nil
 }
 
-func (pu *PostsUseCase) GetById(id int64) (entity.Post, error) {
-	post, err := pu.repo.GetById(id)
+func (pu *PostsUseCase) GetById(ctx context.Context, id int64) (entity.Post, error) {
+	post, err := pu.repo.GetById(ctx, id)
 	if err != nil {
 		if strings.Contains(err.Error(), NoRowsResultErr) {
 			return post, entity.ErrPostNotFound
@@ -128,7 +192,7 @@ func (pu *PostsUseCase) GetById(id int64) (entity.Post, error) {
 		return post, fmt.Errorf("PostsUseCase - GetById #1 - %w", err)
 	}
 
-	user, err := pu.userRepo.GetById(post.User.Id)
+	user, err := pu.userRepo.GetById(ctx, post.User.Id)
 	if err != nil {
 		return post, fmt.Errorf("PostsUseCase - GetById #2 - %w", err)
 	}
@@ -141,16 +205,16 @@ func (pu *PostsUseCase) GetById(id int64) (entity.Post, error) {
 
 	post.Id = id
 	posts := []entity.Post{post}
-	err = pu.fillPostDetails(&posts)
+	err = pu.fillPostDetails(ctx, &posts)
 	if err != nil {
 		return post, fmt.Errorf("PostsUseCase - GetById #3 - %w", err)
 	}
 	return posts[0], nil
 }
 
-func (pu *PostsUseCase) GetAllByCategory(category string) ([]entity.Post, error) {
+func (pu *PostsUseCase) GetAllByCategory(ctx context.Context, categoryId int64) ([]entity.Post, error) {
 	var posts []entity.Post
-	ids, err := pu.repo.GetIdsByCategory(category)
+	ids, err := pu.repo.GetIdsByCategory(ctx, categoryId)
 	if err != nil {
 		return posts, fmt.Errorf("PostsUseCase - GetAllByCategory #1 - %w", err)
 	}
@@ -159,7 +223,7 @@ func (pu *PostsUseCase) GetAllByCategory(category string) ([]entity.Post, error)
 	}
 
 	for i := 0; i < len(ids); i++ {
-		post, err := pu.GetById(ids[i])
+		post, err := pu.GetById(ctx, ids[i])
 		if err != nil {
 			return posts, fmt.Errorf("PostsUseCase - GetAllByCategory #2 - %w", err)
 		}
@@ -168,230 +232,354 @@ func (pu *PostsUseCase) GetAllByCategory(category string) ([]entity.Post, error)
 	return posts, nil
 }
 
-func (pu *PostsUseCase) GetAllCategories() ([]string, error) {
-	categories, err := pu.repo.GetExistedCategories()
+func (pu *PostsUseCase) GetByCategoryPage(ctx context.Context, categoryId int64,
+	number int) ([]entity.Post, entity.Page, error) {
+	var posts []entity.Post
+	ids, err := pu.repo.GetIdsByCategory(ctx, categoryId)
 	if err != nil {
-		return categories, fmt.Errorf("PostsUseCase - GetAllCategories - %w", err)
+		return posts, entity.Page{}, fmt.Errorf("PostsUseCase - GetByCategoryPage #1 - %w", err)
+	}
+	if len(ids) == 0 {
+		return posts, entity.Page{}, entity.ErrPostNotFound
 	}
-	return categories, nil
-}
 
-func (pu *PostsUseCase) UpdatePost(post entity.Post) error {
-	err := pu.repo.Update(post)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	page, offset := pageWindow(number, PostsPerPage, int64(len(ids)))
+	if offset > len(ids) {
+		offset = len(ids)
+	}
+	end := offset + PostsPerPage
+	if end > len(ids) {
+		end = len(ids)
+	}
+
+	posts, err = pu.getByIds(ctx, ids[offset:end])
 	if err != nil {
-		return fmt.Errorf("PostsUseCase - UpdatePost #1 - %w", err)
+		return posts, page, fmt.Errorf("PostsUseCase - GetByCategoryPage #2 - %w", err)
 	}
-	return nil
+	return posts, page, nil
 }
 
-func (pu *PostsUseCase) DeletePost(post entity.Post) error {
-	err := pu.repo.Delete(post)
+// GetByCategoryAfter lists the posts of the category with ids above the
+// cursor, as GetPostsAfter does for all posts.
+func (pu *PostsUseCase) GetByCategoryAfter(ctx context.Context, categoryId,
+	cursor int64) ([]entity.Post, entity.Page, error) {
+	var posts []entity.Post
+	ids, err := pu.repo.GetIdsByCategory(ctx, categoryId)
 	if err != nil {
-		return fmt.Errorf("PostsUseCase - UpdatePost #2 - %w", err)
+		return posts, entity.Page{}, fmt.Errorf("PostsUseCase - GetByCategoryAfter #1 - %w", err)
 	}
-	return nil
+	if len(ids) == 0 {
+		return posts, entity.Page{}, entity.ErrPostNotFound
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	start := sort.Search(len(ids), func(i int) bool { return ids[i] > cursor })
+	end := start + PostsPerPage + 1
+	if end > len(ids) {
+		end = len(ids)
+	}
+	fetched := end - start
+	if fetched > PostsPerPage {
+		end = start + PostsPerPage
+	}
+	var lastId int64
+	if end > start {
+		lastId = ids[end-1]
+	}
+	page := cursorPage(PostsPerPage, fetched, lastId, int64(len(ids)))
+
+	posts, err = pu.getByIds(ctx, ids[start:end])
+	if err != nil {
+		return posts, page, fmt.Errorf("PostsUseCase - GetByCategoryAfter #2 - %w", err)
+	}
+	return posts, page, nil
 }
 
-func (pu *PostsUseCase) MakeReaction(post entity.Post, command string) error {
-	switch command {
-	case ReactionLike:
-		err := pu.repo.StoreLike(post)
+// getByIds reads the posts of the ids in their order.
+func (pu *PostsUseCase) getByIds(ctx context.Context, ids []int64) ([]entity.Post, error) {
+	var posts []entity.Post
+	for _, id := range ids {
+		post, err := pu.GetById(ctx, id)
 		if err != nil {
-			if strings.Contains(err.Error(), UniqueReactionErr) {
-				err = pu.repo.DeleteLike(post)
-				if err != nil {
-					return fmt.Errorf("PostsUseCase - MakeReaction #1 - %w", err)
-				}
-				return nil
-			}
-			return fmt.Errorf("PostsUseCase - MakeReaction #2 - %w", err)
+			return posts, err
 		}
-		err = pu.repo.DeleteDislike(post)
+		posts = append(posts, post)
+	}
+	return posts, nil
+}
+
+// UpdatePost overwrites the post and keeps both the previous and the new
+// version as revisions, post.User is the editor. Nil categories are kept.
+// Images list the ones to keep, nil keeps all of them, new ones can't be
+// attached.
+func (pu *PostsUseCase) UpdatePost(ctx context.Context, post entity.Post) error {
+	return pu.uow.Do(ctx, func(repos *repository.Repositories) error {
+		old, err := repos.Posts.GetById(ctx, post.Id)
 		if err != nil {
-			return fmt.Errorf("PostsUseCase - MakeReaction #3 - %w", err)
+			if strings.Contains(err.Error(), NoRowsResultErr) {
+				return entity.ErrPostNotFound
+			}
+			return fmt.Errorf("PostsUseCase - UpdatePost #1 - %w", err)
+		}
+		if old.IsDeleted() {
+			return entity.ErrPostNotFound
 		}
-	case ReactionDislike:
-		err := pu.repo.StoreDislike(post)
+		old.Categories, err = repos.Posts.GetRelatedCategories(ctx, old)
 		if err != nil {
-			if strings.Contains(err.Error(), UniqueReactionErr) {
-				err = pu.repo.DeleteDislike(post)
-				if err != nil {
-					return fmt.Errorf("PostsUseCase - MakeReaction #4 - %w", err)
-				}
-				return nil
+			return fmt.Errorf("PostsUseCase - UpdatePost #2 - %w", err)
+		}
+
+		categories := old.Categories
+		if post.Categories != nil {
+			categories, err = loadCategories(ctx, repos.Categories, post.Categories)
+			if err != nil {
+				return fmt.Errorf("PostsUseCase - UpdatePost #3 - %w", err)
 			}
-			return fmt.Errorf("PostsUseCase - MakeReaction #5 - %w", err)
 		}
-		err = pu.repo.DeleteLike(post)
+
+		post.Images = keptImages(old.Images, post.Images)
+		post.EditedAt = time.Now()
+		err = repos.Posts.Update(ctx, post)
+		if err != nil {
+			return fmt.Errorf("PostsUseCase - UpdatePost #4 - %w", err)
+		}
+
+		err = pu.revisions.in(repos).record(ctx,
+			entity.Revision{TargetId: old.Id, User: old.User, Date: old.Date,
+				Title: old.Title, Content: old.Content, Categories: categoryNames(old.Categories)},
+			entity.Revision{TargetId: post.Id, User: post.User, Date: post.EditedAt,
+				Title: post.Title, Content: post.Content, Categories: categoryNames(categories)},
+		)
 		if err != nil {
-			return fmt.Errorf("PostsUseCase - MakeReaction #6 - %w", err)
+			return fmt.Errorf("PostsUseCase - UpdatePost #5 - %w", err)
+		}
+		return nil
+	})
+}
+
+// GetRevisions lists versions of the post oldest first, posts never
+// edited have none.
+func (pu *PostsUseCase) GetRevisions(ctx context.Context, id int64) ([]entity.Revision, error) {
+	list, err := pu.revisions.list(ctx, id)
+	if err != nil {
+		return list, fmt.Errorf("PostsUseCase - GetRevisions - %w", err)
+	}
+	return list, nil
+}
+
+// DiffRevisions compares versions number from and to of the post.
+func (pu *PostsUseCase) DiffRevisions(ctx context.Context, id int64, from, to int) (entity.Diff, error) {
+	diff, err := pu.revisions.diff(ctx, id, from, to)
+	if err != nil {
+		if errors.Is(err, entity.ErrRevisionNotFound) {
+			return diff, err
+		}
+		return diff, fmt.Errorf("PostsUseCase - DiffRevisions - %w", err)
+	}
+	return diff, nil
+}
+
+// DeletePost moves the post to the trash, DeletedBy and DeleteReason of
+// the post tell who deleted it and why.
+func (pu *PostsUseCase) DeletePost(ctx context.Context, post entity.Post) error {
+	post.DeletedAt = time.Now()
+	err := pu.repo.Delete(ctx, post)
+	if err != nil {
+		if strings.Contains(err.Error(), NoRowsResultErr) {
+			return entity.ErrPostNotFound
 		}
+		return fmt.Errorf("PostsUseCase - DeletePost - %w", err)
 	}
 	return nil
 }
 
-func (pu *PostsUseCase) DeleteReaction(post entity.Post, command string) error {
-	switch command {
-	case ReactionLike:
-		err := pu.repo.DeleteLike(post)
+// MakeReaction toggles the reaction of post.User on the post, it fails
+// with entity.ErrPostNotFound when the post is missing or in the trash.
+func (pu *PostsUseCase) MakeReaction(ctx context.Context, post entity.Post, kind string) error {
+	if _, ok := pu.reactions.kinds.Find(kind); !ok {
+		return entity.ErrUnknownReaction
+	}
+	err := pu.uow.Do(ctx, func(repos *repository.Repositories) error {
+		found, err := repos.Posts.GetById(ctx, post.Id)
 		if err != nil {
-			return fmt.Errorf("PostsUseCase - DeleteReaction #1 - %w", err)
+			if strings.Contains(err.Error(), NoRowsResultErr) {
+				return entity.ErrPostNotFound
+			}
+			return fmt.Errorf("GetById - %w", err)
 		}
-	case ReactionDislike:
-		err := pu.repo.DeleteDislike(post)
-		if err != nil {
-			return fmt.Errorf("PostsUseCase - DeleteReaction #2 - %w", err)
+		if found.IsDeleted() {
+			return entity.ErrPostNotFound
 		}
+		return pu.reactions.in(repos).toggle(ctx, post.Id, post.User.Id, kind)
+	})
+	if err != nil {
+		return fmt.Errorf("PostsUseCase - MakeReaction - %w", err)
 	}
 	return nil
 }
 
-func (pu *PostsUseCase) fillPostDetails(posts *[]entity.Post) error {
-	wgCategory := sync.WaitGroup{}
-	wgComments := sync.WaitGroup{}
-
-	categorySlice := make([][]string, len(*posts))
-	commentsSlice := make([][]entity.Comment, len(*posts))
-
-	errChan := make(chan error, 1)
-	categoryDone := make(chan interface{})
-	commentsDone := make(chan interface{})
-
-	// collecting categories data
-	for i := 0; i < len(*posts); i++ {
-		wgCategory.Add(1)
-		go func(n int) {
-			defer wgCategory.Done()
-			categories, err := pu.repo.GetRelatedCategories((*posts)[n])
-			if err != nil {
-				errChan <- fmt.Errorf("PostsUseCase - fillPostDetails #1 - %w", err)
-			}
-			categorySlice[n] = categories
-		}(i)
+func (pu *PostsUseCase) DeleteReaction(ctx context.Context, post entity.Post, kind string) error {
+	err := pu.reactions.remove(ctx, post.Id, post.User.Id, kind)
+	if err != nil {
+		return fmt.Errorf("PostsUseCase - DeleteReaction - %w", err)
 	}
+	return nil
+}
 
-	// collecting comments data
-	for i := 0; i < len(*posts); i++ {
-		wgComments.Add(1)
-		go func(n int) {
-			defer wgComments.Done()
-			comments, err := pu.commentRepo.Fetch((*posts)[n].Id)
-			if err != nil {
-				errChan <- fmt.Errorf("PostsUseCase - fillPostDetails #3 - %w", err)
-			}
-			for j := 0; j < len(comments); j++ {
-				comments[j].ContentWeb = strings.Split(comments[j].Content, "\\n")
-				comments[j].User, err = pu.userRepo.GetById(comments[j].User.Id)
-				if comments[j].User.Gender == UserGenderMale {
-					comments[j].User.Male = true
-				} else if comments[j].User.Gender == UserGenderFemale {
-					comments[j].User.Female = true
-				}
-				if err != nil {
-					continue
-				}
-			}
-			commentsSlice[n] = comments
-		}(i)
-	}
-
-	// filling categories data
-	go func() {
-		wgCategory.Wait()
-		for i := 0; i < len(*posts); i++ {
-			(*posts)[i].Categories = append((*posts)[i].Categories, categorySlice[i]...)
-		}
-		close(categoryDone)
-	}()
-
-	// filling comments data
-	go func() {
-		wgComments.Wait()
-		for i := 0; i < len(*posts); i++ {
-			(*posts)[i].Comments = append((*posts)[i].Comments, commentsSlice[i]...)
-			(*posts)[i].TotalComments = int64(len((*posts)[i].Comments))
-			if len(commentsSlice[i]) != 0 {
-				(*posts)[i].LastComment = commentsSlice[i][len(commentsSlice[i])-1]
-				(*posts)[i].LastCommentExist = true
+// loadCategories reads the categories of the ids given, it fails with
+// entity.ErrCategoryNotFound when one of them doesn't exist.
+func loadCategories(ctx context.Context, repo repository.Categories,
+	categories []entity.Category) ([]entity.Category, error) {
+	loaded := make([]entity.Category, 0, len(categories))
+	for _, category := range categories {
+		found, err := repo.GetById(ctx, category.Id)
+		if err != nil {
+			if strings.Contains(err.Error(), NoRowsResultErr) {
+				return nil, entity.ErrCategoryNotFound
 			}
-
+			return nil, fmt.Errorf("loadCategories - %w", err)
 		}
-		close(commentsDone)
-	}()
+		loaded = append(loaded, found)
+	}
+	return loaded, nil
+}
 
-	// if error occurs returning it
-	for {
-		select {
-		case <-errChan:
-			return <-errChan
-		case _, ok := <-categoryDone:
-			if !ok {
-				categoryDone = nil
-			}
-		case _, ok := <-commentsDone:
-			if !ok {
-				commentsDone = nil
+// categoryNames lists the names revisions keep categories by.
+func categoryNames(categories []entity.Category) []string {
+	var names []string
+	for _, category := range categories {
+		names = append(names, category.Name)
+	}
+	return names
+}
+
+// keptImages lists the images of attached that are in kept, in the order
+// of kept. Nil kept keeps all of them and is returned as is.
+func keptImages(attached, kept []string) []string {
+	if kept == nil {
+		return nil
+	}
+	images := make([]string, 0, len(kept))
+	for _, path := range kept {
+		for _, existed := range attached {
+			if path == existed {
+				images = append(images, path)
+				break
 			}
 		}
-		if commentsDone == nil && categoryDone == nil {
-			break
-		}
 	}
-
-	return nil
+	return images
 }
 
-func (pu *PostsUseCase) GetReactions(id int64, query string) ([]entity.User, error) {
-	var users []entity.User
-	post, err := pu.repo.FetchReactions(id)
+// fillPostDetails adds reactions, categories and comments to the posts,
+// each of them loaded for all posts at once.
+func (pu *PostsUseCase) fillPostDetails(ctx context.Context, posts *[]entity.Post) error {
+	ids := make([]int64, len(*posts))
+	for i := range *posts {
+		ids[i] = (*posts)[i].Id
+	}
+	reactionCounts, err := pu.reactions.counts(ctx, ids)
 	if err != nil {
-		return users, fmt.Errorf("PostsUseCase - GetReactions #1 - %w", err)
+		return fmt.Errorf("PostsUseCase - fillPostDetails #2 - %w", err)
 	}
-	switch query {
-	case PostLikedQuery:
-		for i := 0; i < len(post.Likes); i++ {
-			user, err := pu.userRepo.GetById(post.Likes[i].UserId)
-			if err != nil {
-				return users, fmt.Errorf("PostsUseCase - GetReactions #2 - %w", err)
-			}
-			users = append(users, user)
-		}
-	case PostDislikedQuery:
-		for i := 0; i < len(post.Dislikes); i++ {
-			user, err := pu.userRepo.GetById(post.Dislikes[i].UserId)
-			if err != nil {
-				return users, fmt.Errorf("PostsUseCase - GetReactions #3 - %w", err)
-			}
-			users = append(users, user)
+
+	load := newLoader(pu.repo, pu.commentRepo, pu.userRepo)
+	categories, err := load.categories(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("PostsUseCase - fillPostDetails #1 - %w", err)
+	}
+	comments, err := load.postComments(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("PostsUseCase - fillPostDetails #3 - %w", err)
+	}
+
+	for i := range *posts {
+		post := &(*posts)[i]
+		post.Reactions = reactionCounts[post.Id]
+		post.Categories = append(post.Categories, categories[post.Id]...)
+		post.Comments = append(post.Comments, comments[post.Id]...)
+		if found := comments[post.Id]; len(found) != 0 {
+			post.LastComment = found[len(found)-1]
+			post.LastCommentExist = true
 		}
 	}
+	return nil
+}
 
+func (pu *PostsUseCase) GetReactions(ctx context.Context, id int64, kind string) ([]entity.User, error) {
+	users, err := pu.reactions.users(ctx, id, kind, pu.userRepo)
+	if err != nil {
+		return users, fmt.Errorf("PostsUseCase - GetReactions - %w", err)
+	}
 	return users, nil
 }
 
-func (pu *PostsUseCase) CreateCategories(categories []string) error {
-	existed, err := pu.repo.GetExistedCategories()
+// Search returns posts matching every word of the query, best match first.
+// Each post comes once with the snippet of its best matching document.
+func (pu *PostsUseCase) Search(ctx context.Context, query string) ([]entity.SearchResult, error) {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	found, err := pu.repo.Search(ctx, terms, SearchLimit)
 	if err != nil {
-		return fmt.Errorf("PostsUseCase - CreateCategories #1 - %w", err)
+		return nil, fmt.Errorf("PostsUseCase - Search #1 - %w", err)
 	}
 
-	var categoriesToAdd []string
-	for i := 0; i < len(categories); i++ {
-		exist := false
-		for j := 0; j < len(existed); j++ {
-			if categories[i] == existed[j] {
-				exist = true
+	var results []entity.SearchResult
+	seen := make(map[int64]int)
+	for _, result := range found {
+		if i, ok := seen[result.Post.Id]; ok {
+			if result.Rank > results[i].Rank {
+				results[i] = result
 			}
+			continue
 		}
-		if !exist {
-			categoriesToAdd = append(categoriesToAdd, categories[i])
-		}
+		seen[result.Post.Id] = len(results)
+		results = append(results, result)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Rank > results[j].Rank
+	})
+	if len(results) > SearchLimit {
+		results = results[:SearchLimit]
 	}
 
-	err = pu.repo.StoreCategories(categoriesToAdd)
-	if err != nil {
-		return fmt.Errorf("PostsUseCase - CreateCategories #2 - %w", err)
+	filled := results[:0]
+	for _, result := range results {
+		post, err := pu.GetById(ctx, result.Post.Id)
+		if err != nil {
+			// comments of purged posts can still be indexed
+			if errors.Is(err, entity.ErrPostNotFound) {
+				continue
+			}
+			return nil, fmt.Errorf("PostsUseCase - Search #2 - %w", err)
+		}
+		if post.IsDeleted() {
+			continue
+		}
+		result.Post = post
+		filled = append(filled, result)
 	}
 
-	return nil
+	return filled, nil
+}
+
+// searchTerms splits the query into distinct lowercase words.
+func searchTerms(query string) []string {
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var terms []string
+	seen := make(map[string]bool)
+	for _, word := range words {
+		if !seen[word] {
+			seen[word] = true
+			terms = append(terms, word)
+		}
+	}
+	return terms
 }
@@ -1,20 +1,32 @@
 package usecase_test
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"forum/internal/entity"
-	m "forum/internal/repository/sqlite/mock"
+	"forum/internal/repository"
+	"forum/internal/repository/memory"
 	"forum/internal/usecase"
 )
 
 var (
+	cars      = entity.Category{Id: 1, Name: "Cars", Slug: "cars"}
+	sports    = entity.Category{Id: 2, Name: "Sports", Slug: "sports"}
+	guns      = entity.Category{Id: 3, Name: "Guns", Slug: "guns"}
+	computers = entity.Category{Id: 4, Name: "Computers", Slug: "computers"}
+
 	post1 = entity.Post{
 		Id:         1,
 		User:       user1,
-		Categories: []string{"Cars"},
-		Date:       "2022-05-02",
+		Categories: []entity.Category{cars},
+		Date:       time.Date(2022, 5, 2, 0, 0, 0, 0, time.UTC),
 		Title:      "Audi",
 		Content:    "Lorem ipsum, dolor sit amet consectetur adipisicing.",
 	}
@@ -22,8 +34,8 @@ var (
 	post2 = entity.Post{
 		Id:         2,
 		User:       user4,
-		Date:       "2022-01-03",
-		Categories: []string{"Sports"},
+		Date:       time.Date(2022, 1, 3, 0, 0, 0, 0, time.UTC),
+		Categories: []entity.Category{sports},
 		Title:      "Footbal",
 		Content:    "Lorem ipsum, dolor sit amet.",
 	}
@@ -31,47 +43,114 @@ var (
 	post3 = entity.Post{
 		Id:         3,
 		User:       user1,
-		Date:       "2022-04-02",
+		Date:       time.Date(2022, 4, 2, 0, 0, 0, 0, time.UTC),
 		Title:      "BMW",
-		Categories: []string{"Cars"},
+		Categories: []entity.Category{cars},
 		Content:    "Lorem ipsum, dolor sit.",
 	}
 
 	post4 = entity.Post{
 		Id:         4,
 		User:       user1,
-		Date:       "2022-04-02",
+		Date:       time.Date(2022, 4, 2, 0, 0, 0, 0, time.UTC),
 		Title:      "Bdrg",
-		Categories: []string{"Guns", "Computers"},
+		Categories: []entity.Category{guns, computers},
 		Content:    "Lorem ipsum, dolor sit.",
 	}
 )
 
+// storeCategories stores the categories posts of the fixtures are in.
+func storeCategories(t *testing.T, repos *repository.Repositories) {
+	t.Helper()
+	for _, category := range []entity.Category{cars, sports, guns, computers} {
+		category.Id = 0
+		if err := repos.Categories.Store(context.Background(), &category); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
 func TestCreatePost(t *testing.T) {
+	ctx := context.Background()
+
 	t.Run("OK", func(t *testing.T) {
-		mockRepo := m.NewMockRepos()
-		postUseCase := usecase.NewPostsUseCase(mockRepo.Posts, mockRepo.Users, mockRepo.Comments)
+		repos := repository.NewMemoryRepositories(memory.New())
+		storeCategories(t, repos)
+		postUseCase := usecase.NewPostsUseCase(repos.Posts, repos.Users, repos.Comments, repos.Reactions, repos.Revisions,
+			repos.UnitOfWork, entity.DefaultReactionKinds)
 
-		if err := postUseCase.CreatePost(post1); err != nil {
+		if err := postUseCase.CreatePost(ctx, post1); err != nil {
 			t.Fatal(err)
 		}
 	})
+
+	t.Run("err rolled back", func(t *testing.T) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		storeCategories(t, repos)
+		postUseCase := usecase.NewPostsUseCase(repos.Posts, repos.Users, repos.Comments, repos.Reactions, repos.Revisions,
+			repos.UnitOfWork, entity.DefaultReactionKinds)
+
+		post := post1
+		post.Categories = []entity.Category{cars, cars}
+		if err := postUseCase.CreatePost(ctx, post); err == nil {
+			t.Fatal("expected error")
+		}
+
+		if found, err := postUseCase.GetAllPosts(ctx); err != nil {
+			t.Fatal(err)
+		} else if len(found) != 0 {
+			t.Fatalf("want: %d, got: %d", 0, len(found))
+		}
+	})
+
+	t.Run("err category not found", func(t *testing.T) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		postUseCase := usecase.NewPostsUseCase(repos.Posts, repos.Users, repos.Comments, repos.Reactions, repos.Revisions,
+			repos.UnitOfWork, entity.DefaultReactionKinds)
+
+		if err := postUseCase.CreatePost(ctx, post1); !errors.Is(err, entity.ErrCategoryNotFound) {
+			t.Fatalf("want: %v, got: %v", entity.ErrCategoryNotFound, err)
+		}
+	})
+
+	t.Run("err canceled", func(t *testing.T) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		storeCategories(t, repos)
+		postUseCase := usecase.NewPostsUseCase(repos.Posts, repos.Users, repos.Comments, repos.Reactions, repos.Revisions,
+			repos.UnitOfWork, entity.DefaultReactionKinds)
+
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+		if err := postUseCase.CreatePost(canceled, post1); !errors.Is(err, context.Canceled) {
+			t.Fatalf("want: %v, got: %v", context.Canceled, err)
+		}
+
+		if found, err := postUseCase.GetAllPosts(ctx); err != nil {
+			t.Fatal(err)
+		} else if len(found) != 0 {
+			t.Fatalf("want: %d, got: %d", 0, len(found))
+		}
+	})
 }
 
 func TestGetAllPost(t *testing.T) {
+	ctx := context.Background()
+
 	t.Run("OK", func(t *testing.T) {
-		mockRepo := m.NewMockRepos()
-		postUseCase := usecase.NewPostsUseCase(mockRepo.Posts, mockRepo.Users, mockRepo.Comments)
+		repos := repository.NewMemoryRepositories(memory.New())
+		storeCategories(t, repos)
+		postUseCase := usecase.NewPostsUseCase(repos.Posts, repos.Users, repos.Comments, repos.Reactions, repos.Revisions,
+			repos.UnitOfWork, entity.DefaultReactionKinds)
 
-		if err := postUseCase.CreatePost(post1); err != nil {
+		if err := postUseCase.CreatePost(ctx, post1); err != nil {
 			t.Fatal(err)
 		}
 
-		if err := postUseCase.CreatePost(post2); err != nil {
+		if err := postUseCase.CreatePost(ctx, post2); err != nil {
 			t.Fatal(err)
 		}
 
-		if found, err := postUseCase.GetAllPosts(); err != nil {
+		if found, err := postUseCase.GetAllPosts(ctx); err != nil {
 			t.Fatal(err)
 		} else if len(found) != 2 {
 			t.Fatalf("want: %d, got: %d", 2, len(found))
@@ -79,42 +158,118 @@ func TestGetAllPost(t *testing.T) {
 	})
 }
 
+func TestGetPostsPage(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories(memory.New())
+	storeCategories(t, repos)
+	postUseCase := usecase.NewPostsUseCase(repos.Posts, repos.Users, repos.Comments, repos.Reactions, repos.Revisions,
+		repos.UnitOfWork, entity.DefaultReactionKinds)
+
+	for i := 0; i < usecase.PostsPerPage+3; i++ {
+		if err := postUseCase.CreatePost(ctx, post1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("OK page", func(t *testing.T) {
+		found, page, err := postUseCase.GetPostsPage(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(found) != usecase.PostsPerPage {
+			t.Fatalf("want: %d, got: %d", usecase.PostsPerPage, len(found))
+		}
+		if page.Pages() != 2 || !page.HasNext() || page.HasPrev() {
+			t.Fatalf("unexpected page: %+v", page)
+		}
+
+		found, page, err = postUseCase.GetPostsPage(ctx, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(found) != 3 {
+			t.Fatalf("want: %d, got: %d", 3, len(found))
+		}
+		if page.HasNext() || !page.HasPrev() {
+			t.Fatalf("unexpected page: %+v", page)
+		}
+	})
+
+	t.Run("OK page past the last", func(t *testing.T) {
+		found, page, err := postUseCase.GetPostsPage(ctx, math.MaxInt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(found) != 3 || page.Number != 2 {
+			t.Fatalf("want: last page of 3 posts, got: %d posts, %+v", len(found), page)
+		}
+	})
+
+	t.Run("OK cursor", func(t *testing.T) {
+		found, page, err := postUseCase.GetPostsAfter(ctx, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(found) != usecase.PostsPerPage {
+			t.Fatalf("want: %d, got: %d", usecase.PostsPerPage, len(found))
+		}
+		if page.NextCursor != found[len(found)-1].Id {
+			t.Fatalf("want cursor: %d, got: %d", found[len(found)-1].Id, page.NextCursor)
+		}
+
+		found, page, err = postUseCase.GetPostsAfter(ctx, page.NextCursor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(found) != 3 {
+			t.Fatalf("want: %d, got: %d", 3, len(found))
+		}
+		if page.HasNext() {
+			t.Fatalf("unexpected next cursor: %d", page.NextCursor)
+		}
+	})
+}
+
 func TestGetPostsByQuery(t *testing.T) {
+	ctx := context.Background()
+
 	t.Run("OK", func(t *testing.T) {
-		mockRepo := m.NewMockRepos()
-		postUseCase := usecase.NewPostsUseCase(mockRepo.Posts, mockRepo.Users, mockRepo.Comments)
+		repos := repository.NewMemoryRepositories(memory.New())
+		storeCategories(t, repos)
+		postUseCase := usecase.NewPostsUseCase(repos.Posts, repos.Users, repos.Comments, repos.Reactions, repos.Revisions,
+			repos.UnitOfWork, entity.DefaultReactionKinds)
 
-		if err := postUseCase.CreatePost(post1); err != nil {
+		if err := postUseCase.CreatePost(ctx, post1); err != nil {
 			t.Fatal(err)
 		}
 
-		if err := postUseCase.CreatePost(post2); err != nil {
+		if err := postUseCase.CreatePost(ctx, post2); err != nil {
 			t.Fatal(err)
 		}
 
-		if err := postUseCase.CreatePost(post3); err != nil {
+		if err := postUseCase.CreatePost(ctx, post3); err != nil {
 			t.Fatal(err)
 		}
 
-		if found, err := postUseCase.GetPostsByQuery(user1, usecase.PostAuthorQuery); err != nil {
+		if found, err := postUseCase.GetPostsByQuery(ctx, user1, usecase.PostAuthorQuery); err != nil {
 			t.Fatal(err)
 		} else if len(found) != 2 {
 			t.Fatalf("want: %d, got: %d", 2, len(found))
 		}
 
-		if found, err := postUseCase.GetPostsByQuery(user1, usecase.PostLikedQuery); err != nil {
+		if found, err := postUseCase.GetPostsByQuery(ctx, user1, "like"); err != nil {
 			t.Fatal(err)
 		} else if len(found) != 0 {
 			t.Fatalf("want: %d, got: %d", 0, len(found))
 		}
 
-		if found, err := postUseCase.GetPostsByQuery(user1, usecase.PostDislikedQuery); err != nil {
+		if found, err := postUseCase.GetPostsByQuery(ctx, user1, "dislike"); err != nil {
 			t.Fatal(err)
 		} else if len(found) != 0 {
 			t.Fatalf("want: %d, got: %d", 0, len(found))
 		}
 
-		if found, err := postUseCase.GetPostsByQuery(user1, usecase.PostCommentedQuery); err != nil {
+		if found, err := postUseCase.GetPostsByQuery(ctx, user1, usecase.PostCommentedQuery); err != nil {
 			t.Fatal(err)
 		} else if len(found) != 0 {
 			t.Fatalf("want: %d, got: %d", 0, len(found))
@@ -123,21 +278,32 @@ func TestGetPostsByQuery(t *testing.T) {
 }
 
 func TestPostGetById(t *testing.T) {
-	mockRepo := m.NewMockRepos()
-	userUseCase := setupUserUseCase(mockRepo)
-	postUseCase := usecase.NewPostsUseCase(mockRepo.Posts, mockRepo.Users, mockRepo.Comments)
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories(memory.New())
+	storeCategories(t, repos)
+	userUseCase := setupUserUseCase(repos)
+	postUseCase := usecase.NewPostsUseCase(repos.Posts, repos.Users, repos.Comments, repos.Reactions, repos.Revisions,
+		repos.UnitOfWork, entity.DefaultReactionKinds)
 
 	t.Run("OK", func(t *testing.T) {
-		if err := userUseCase.SignUp(user4); err != nil {
+		if err := userUseCase.SignUp(ctx, user1); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := userUseCase.SignUp(ctx, user4); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := postUseCase.CreatePost(ctx, post1); err != nil {
 			t.Fatal(err)
 		}
 
-		if err := postUseCase.CreatePost(post2); err != nil {
+		if err := postUseCase.CreatePost(ctx, post2); err != nil {
 			t.Fatal(err)
 		}
 
 		id := post2.Id
-		if found, err := postUseCase.GetById(id); err != nil {
+		if found, err := postUseCase.GetById(ctx, id); err != nil {
 			t.Fatal(err)
 		} else if found.Id != id {
 			t.Fatalf("want: %d, got: %d", id, found.Id)
@@ -146,7 +312,7 @@ func TestPostGetById(t *testing.T) {
 
 	t.Run("err not found", func(t *testing.T) {
 		id := int64(14)
-		if _, err := postUseCase.GetById(id); err == nil {
+		if _, err := postUseCase.GetById(ctx, id); err == nil {
 			t.Fatal("Expected error")
 		} else if !errors.Is(err, entity.ErrPostNotFound) {
 			t.Fatalf("want: %v, got: %v", entity.ErrPostNotFound, err)
@@ -155,51 +321,49 @@ func TestPostGetById(t *testing.T) {
 }
 
 func TestGetAllByCategory(t *testing.T) {
-	mockRepo := m.NewMockRepos()
-	userUseCase := setupUserUseCase(mockRepo)
-	postUseCase := usecase.NewPostsUseCase(mockRepo.Posts, mockRepo.Users, mockRepo.Comments)
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories(memory.New())
+	storeCategories(t, repos)
+	userUseCase := setupUserUseCase(repos)
+	postUseCase := usecase.NewPostsUseCase(repos.Posts, repos.Users, repos.Comments, repos.Reactions, repos.Revisions,
+		repos.UnitOfWork, entity.DefaultReactionKinds)
 
 	t.Run("OK", func(t *testing.T) {
-		if err := userUseCase.SignUp(user1); err != nil {
+		if err := userUseCase.SignUp(ctx, user1); err != nil {
 			t.Fatal(err)
 		}
 
-		if err := userUseCase.SignUp(user4); err != nil {
+		if err := userUseCase.SignUp(ctx, user4); err != nil {
 			t.Fatal(err)
 		}
 
-		if err := postUseCase.CreatePost(post1); err != nil {
+		if err := postUseCase.CreatePost(ctx, post1); err != nil {
 			t.Fatal(err)
 		}
 
-		if err := postUseCase.CreatePost(post2); err != nil {
+		if err := postUseCase.CreatePost(ctx, post2); err != nil {
 			t.Fatal(err)
 		}
 
-		if err := postUseCase.CreatePost(post3); err != nil {
+		if err := postUseCase.CreatePost(ctx, post3); err != nil {
 			t.Fatal(err)
 		}
 
-		category1 := post1.Categories[0]
-		category2 := post2.Categories[0]
-
-		if found, err := postUseCase.GetAllByCategory(category1); err != nil {
+		if found, err := postUseCase.GetAllByCategory(ctx, cars.Id); err != nil {
 			t.Fatal(err)
 		} else if len(found) != 2 {
 			t.Fatalf("want: %d, got: %d", 2, len(found))
 		}
 
-		if found, err := postUseCase.GetAllByCategory(category2); err != nil {
+		if found, err := postUseCase.GetAllByCategory(ctx, sports.Id); err != nil {
 			t.Fatal(err)
-		} else if found[0].Categories[0] != category2 {
-			t.Fatalf("want: %v, got: %v", category2, found[0].Categories[0])
+		} else if found[0].Categories[0] != sports {
+			t.Fatalf("want: %v, got: %v", sports, found[0].Categories[0])
 		}
 	})
 
 	t.Run("err not found", func(t *testing.T) {
-		category := "Weather"
-
-		if _, err := postUseCase.GetAllByCategory(category); err == nil {
+		if _, err := postUseCase.GetAllByCategory(ctx, guns.Id); err == nil {
 			t.Fatal("Expected error")
 		} else if !errors.Is(err, entity.ErrPostNotFound) {
 			t.Fatalf("want: %v, got: %v", entity.ErrPostNotFound, err)
@@ -207,177 +371,672 @@ func TestGetAllByCategory(t *testing.T) {
 	})
 }
 
-func TestGetAllCategories(t *testing.T) {
-	t.Run("OK", func(t *testing.T) {
-		mockRepo := m.NewMockRepos()
-		mockRepo.Posts.AllTopics = map[string]bool{}
-		postUseCase := usecase.NewPostsUseCase(mockRepo.Posts, mockRepo.Users, mockRepo.Comments)
+func TestGetByCategoryPage(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories(memory.New())
+	storeCategories(t, repos)
+	userUseCase := setupUserUseCase(repos)
+	postUseCase := usecase.NewPostsUseCase(repos.Posts, repos.Users, repos.Comments, repos.Reactions, repos.Revisions,
+		repos.UnitOfWork, entity.DefaultReactionKinds)
 
-		categories := []string{}
-		if err := postUseCase.CreatePost(post1); err != nil {
+	if err := userUseCase.SignUp(ctx, user1); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < usecase.PostsPerPage+1; i++ {
+		if err := postUseCase.CreatePost(ctx, post1); err != nil {
 			t.Fatal(err)
 		}
+	}
 
-		if err := mockRepo.Posts.StoreCategories(post1.Categories); err != nil {
+	t.Run("OK", func(t *testing.T) {
+		if found, page, err := postUseCase.GetByCategoryPage(ctx, cars.Id, 2); err != nil {
 			t.Fatal(err)
+		} else if len(found) != 1 || found[0].Id != usecase.PostsPerPage+1 {
+			t.Fatalf("unexpected posts: %+v", found)
+		} else if page.Total != usecase.PostsPerPage+1 {
+			t.Fatalf("want: %d, got: %d", usecase.PostsPerPage+1, page.Total)
 		}
-		categories = append(categories, post1.Categories...)
+	})
 
-		if err := postUseCase.CreatePost(post4); err != nil {
+	t.Run("OK cursor", func(t *testing.T) {
+		found, page, err := postUseCase.GetByCategoryAfter(ctx, cars.Id, 0)
+		if err != nil {
 			t.Fatal(err)
+		} else if len(found) != usecase.PostsPerPage || page.NextCursor != usecase.PostsPerPage {
+			t.Fatalf("unexpected page: %d posts, %+v", len(found), page)
 		}
 
-		if err := mockRepo.Posts.StoreCategories(post4.Categories); err != nil {
+		found, page, err = postUseCase.GetByCategoryAfter(ctx, cars.Id, page.NextCursor)
+		if err != nil {
 			t.Fatal(err)
+		} else if len(found) != 1 || found[0].Id != usecase.PostsPerPage+1 {
+			t.Fatalf("unexpected posts: %+v", found)
+		} else if page.NextCursor != 0 {
+			t.Fatalf("want no next page, got: %+v", page)
 		}
-		categories = append(categories, post4.Categories...)
+	})
 
-		if found, err := postUseCase.GetAllCategories(); err != nil {
-			t.Fatal(err)
-		} else if len(found) != len(categories) {
-			t.Fatalf("want: %d, got: %d", len(categories), len(found))
+	t.Run("err not found", func(t *testing.T) {
+		if _, _, err := postUseCase.GetByCategoryAfter(ctx, guns.Id, 0); !errors.Is(err, entity.ErrPostNotFound) {
+			t.Fatalf("want: %v, got: %v", entity.ErrPostNotFound, err)
+		}
+		if _, _, err := postUseCase.GetByCategoryPage(ctx, guns.Id, 1); !errors.Is(err, entity.ErrPostNotFound) {
+			t.Fatalf("want: %v, got: %v", entity.ErrPostNotFound, err)
 		}
 	})
 }
 
 func TestUpdatePost(t *testing.T) {
+	ctx := context.Background()
+
 	t.Run("OK", func(t *testing.T) {
-		mockRepo := m.NewMockRepos()
-		userUseCase := setupUserUseCase(mockRepo)
-		postUseCase := usecase.NewPostsUseCase(mockRepo.Posts, mockRepo.Users, mockRepo.Comments)
+		repos := repository.NewMemoryRepositories(memory.New())
+		storeCategories(t, repos)
+		userUseCase := setupUserUseCase(repos)
+		postUseCase := usecase.NewPostsUseCase(repos.Posts, repos.Users, repos.Comments, repos.Reactions, repos.Revisions,
+			repos.UnitOfWork, entity.DefaultReactionKinds)
 
-		if err := userUseCase.SignUp(user1); err != nil {
+		if err := userUseCase.SignUp(ctx, user1); err != nil {
 			t.Fatal(err)
 		}
 
-		if err := postUseCase.CreatePost(post1); err != nil {
+		if err := postUseCase.CreatePost(ctx, post1); err != nil {
 			t.Fatal(err)
 		}
 
 		content := "New content"
 
-		if err := postUseCase.UpdatePost(entity.Post{Id: 1, Content: content, User: user1}); err != nil {
+		if err := postUseCase.UpdatePost(ctx, entity.Post{Id: 1, Content: content, User: user1}); err != nil {
 			t.Fatal(err)
 		}
 
-		if found, err := postUseCase.GetById(1); err != nil {
+		if found, err := postUseCase.GetById(ctx, 1); err != nil {
 			t.Fatal(err)
 		} else if found.Content != content {
 			t.Fatal("Could not update")
+		} else if !found.IsEdited() {
+			t.Fatal("want post marked edited")
+		}
+	})
+
+	t.Run("OK revisions", func(t *testing.T) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		storeCategories(t, repos)
+		postUseCase := usecase.NewPostsUseCase(repos.Posts, repos.Users, repos.Comments, repos.Reactions, repos.Revisions,
+			repos.UnitOfWork, entity.DefaultReactionKinds)
+
+		if err := postUseCase.CreatePost(ctx, post1); err != nil {
+			t.Fatal(err)
+		}
+
+		if found, err := postUseCase.GetRevisions(ctx, 1); err != nil {
+			t.Fatal(err)
+		} else if len(found) != 0 {
+			t.Fatalf("want: %d, got: %d", 0, len(found))
+		}
+
+		edits := []entity.Post{
+			{Id: 1, User: user4, Title: "Audi A4", Content: post1.Content},
+			{Id: 1, User: user1, Title: "Audi A4", Content: "New content", Categories: []entity.Category{{Id: sports.Id}}},
+		}
+		for _, edit := range edits {
+			if err := postUseCase.UpdatePost(ctx, edit); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		found, err := postUseCase.GetRevisions(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var titles []string
+		for _, revision := range found {
+			titles = append(titles, revision.Title)
+		}
+		if want := []string{"Audi", "Audi A4", "Audi A4"}; !reflect.DeepEqual(titles, want) {
+			t.Fatalf("want: %v, got: %v", want, titles)
+		}
+		if found[0].User.Id != post1.User.Id || found[1].User.Id != user4.Id {
+			t.Fatalf("want authors %d and %d, got: %v", post1.User.Id, user4.Id, found)
+		}
+		if !reflect.DeepEqual(found[1].Categories, []string{"Cars"}) ||
+			!reflect.DeepEqual(found[2].Categories, []string{"Sports"}) {
+			t.Fatalf("want categories kept and then replaced, got: %v", found)
+		}
+	})
+
+	t.Run("OK images", func(t *testing.T) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		storeCategories(t, repos)
+		postUseCase := usecase.NewPostsUseCase(repos.Posts, repos.Users, repos.Comments, repos.Reactions, repos.Revisions,
+			repos.UnitOfWork, entity.DefaultReactionKinds)
+
+		if err := setupUserUseCase(repos).SignUp(ctx, user1); err != nil {
+			t.Fatal(err)
+		}
+
+		post := post1
+		post.Images = []string{"/a.png", "/b.png", "/c.png"}
+		if err := postUseCase.CreatePost(ctx, post); err != nil {
+			t.Fatal(err)
+		}
+
+		// nil keeps all, images not attached can not sneak in, kept ones take
+		// the given order
+		edits := []struct {
+			images []string
+			want   []string
+		}{
+			{nil, []string{"/a.png", "/b.png", "/c.png"}},
+			{[]string{"/c.png", "/a.png", "/d.png"}, []string{"/c.png", "/a.png"}},
+			{[]string{}, nil},
+		}
+		for _, edit := range edits {
+			err := postUseCase.UpdatePost(ctx, entity.Post{Id: 1, User: user1, Title: post.Title, Content: post.Content,
+				Images: edit.images})
+			if err != nil {
+				t.Fatal(err)
+			}
+			found, err := postUseCase.GetById(ctx, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(found.Images) != len(edit.want) || (len(edit.want) != 0 && !reflect.DeepEqual(found.Images, edit.want)) {
+				t.Fatalf("want: %v, got: %v", edit.want, found.Images)
+			}
+		}
+	})
+
+	t.Run("err not found", func(t *testing.T) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		storeCategories(t, repos)
+		postUseCase := usecase.NewPostsUseCase(repos.Posts, repos.Users, repos.Comments, repos.Reactions, repos.Revisions,
+			repos.UnitOfWork, entity.DefaultReactionKinds)
+
+		if err := postUseCase.UpdatePost(ctx, entity.Post{Id: 1, User: user1}); !errors.Is(err, entity.ErrPostNotFound) {
+			t.Fatalf("want: %v, got: %v", entity.ErrPostNotFound, err)
+		}
+	})
+}
+
+func TestDiffRevisions(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories(memory.New())
+	storeCategories(t, repos)
+	postUseCase := usecase.NewPostsUseCase(repos.Posts, repos.Users, repos.Comments, repos.Reactions, repos.Revisions,
+		repos.UnitOfWork, entity.DefaultReactionKinds)
+
+	post := post1
+	post.Content = "one\\ntwo\\nthree"
+	if err := postUseCase.CreatePost(ctx, post); err != nil {
+		t.Fatal(err)
+	}
+	edit := entity.Post{Id: 1, User: user1, Title: "Audi", Content: "one\\nthree\\nfour"}
+	if err := postUseCase.UpdatePost(ctx, edit); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		diff, err := postUseCase.DiffRevisions(ctx, 1, 1, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []entity.DiffLine{
+			{Op: entity.DiffSame, Text: "Audi"},
+			{Op: entity.DiffSame, Text: "Cars"},
+			{Op: entity.DiffSame, Text: ""},
+			{Op: entity.DiffSame, Text: "one"},
+			{Op: entity.DiffRemoved, Text: "two"},
+			{Op: entity.DiffSame, Text: "three"},
+			{Op: entity.DiffAdded, Text: "four"},
+		}
+		if !reflect.DeepEqual(diff.Lines, want) {
+			t.Fatalf("want: %v, got: %v", want, diff.Lines)
+		}
+		if diff.From.Number != 1 || diff.To.Number != 2 {
+			t.Fatalf("want revisions 1 and 2, got: %d and %d", diff.From.Number, diff.To.Number)
+		}
+	})
+
+	t.Run("OK backwards", func(t *testing.T) {
+		diff, err := postUseCase.DiffRevisions(ctx, 1, 2, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var added, removed int
+		for _, line := range diff.Lines {
+			if line.Added() {
+				added++
+			} else if line.Removed() {
+				removed++
+			}
+		}
+		if added != 1 || removed != 1 {
+			t.Fatalf("want 1 added and 1 removed, got: %v", diff.Lines)
+		}
+	})
+
+	t.Run("OK too large", func(t *testing.T) {
+		var before, after []string
+		for i := 0; i < 1500; i++ {
+			before = append(before, fmt.Sprintf("line %d", i))
+			after = append(after, fmt.Sprintf("edited line %d", i))
+		}
+		large := post1
+		large.Content = strings.Join(before, "\\n")
+		if err := postUseCase.CreatePost(ctx, large); err != nil {
+			t.Fatal(err)
+		}
+		edit := entity.Post{Id: 2, User: user1, Title: "Audi", Content: strings.Join(after, "\\n")}
+		if err := postUseCase.UpdatePost(ctx, edit); err != nil {
+			t.Fatal(err)
+		}
+
+		diff, err := postUseCase.DiffRevisions(ctx, 2, 1, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !diff.TooLarge || len(diff.Lines) != 0 {
+			t.Fatalf("want too large to diff, got: %d lines", len(diff.Lines))
+		}
+	})
+
+	t.Run("OK long with few changes", func(t *testing.T) {
+		var before []string
+		for i := 0; i < 1500; i++ {
+			before = append(before, fmt.Sprintf("line %d", i))
+		}
+		after := append([]string{}, before...)
+		after[700] = "edited line 700"
+		long := post1
+		long.Content = strings.Join(before, "\\n")
+		if err := postUseCase.CreatePost(ctx, long); err != nil {
+			t.Fatal(err)
+		}
+		edit := entity.Post{Id: 3, User: user1, Title: "Audi", Content: strings.Join(after, "\\n")}
+		if err := postUseCase.UpdatePost(ctx, edit); err != nil {
+			t.Fatal(err)
+		}
+
+		diff, err := postUseCase.DiffRevisions(ctx, 3, 1, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var added, removed int
+		for _, line := range diff.Lines {
+			if line.Added() {
+				added++
+			} else if line.Removed() {
+				removed++
+			}
+		}
+		if diff.TooLarge || added != 1 || removed != 1 || len(diff.Lines) != 1500+3+1 {
+			t.Fatalf("want one line replaced, got: %d added, %d removed of %d", added, removed, len(diff.Lines))
+		}
+	})
+
+	t.Run("err revision not found", func(t *testing.T) {
+		if _, err := postUseCase.DiffRevisions(ctx, 1, 1, 3); !errors.Is(err, entity.ErrRevisionNotFound) {
+			t.Fatalf("want: %v, got: %v", entity.ErrRevisionNotFound, err)
 		}
 	})
 }
 
 func TestDeletePost(t *testing.T) {
+	ctx := context.Background()
+
 	t.Run("OK", func(t *testing.T) {
-		mockRepo := m.NewMockRepos()
-		userUseCase := setupUserUseCase(mockRepo)
-		postUseCase := usecase.NewPostsUseCase(mockRepo.Posts, mockRepo.Users, mockRepo.Comments)
+		repos := repository.NewMemoryRepositories(memory.New())
+		storeCategories(t, repos)
+		userUseCase := setupUserUseCase(repos)
+		postUseCase := usecase.NewPostsUseCase(repos.Posts, repos.Users, repos.Comments, repos.Reactions, repos.Revisions,
+			repos.UnitOfWork, entity.DefaultReactionKinds)
 
-		if err := userUseCase.SignUp(user1); err != nil {
+		if err := userUseCase.SignUp(ctx, user1); err != nil {
 			t.Fatal(err)
 		}
 
-		if err := postUseCase.CreatePost(post1); err != nil {
+		if err := postUseCase.CreatePost(ctx, post1); err != nil {
 			t.Fatal(err)
 		}
 
-		if _, err := postUseCase.GetById(1); err != nil {
+		if _, err := postUseCase.GetById(ctx, 1); err != nil {
 			t.Fatal(err)
 		}
 
-		if err := postUseCase.DeletePost(post1); err != nil {
+		deleted := post1
+		deleted.DeletedBy = 1
+		deleted.DeleteReason = "spam"
+		if err := postUseCase.DeletePost(ctx, deleted); err != nil {
 			t.Fatal(err)
 		}
 
-		if _, err := postUseCase.GetById(1); err == nil {
-			t.Fatal("Expected error")
-		} else if !errors.Is(err, entity.ErrPostNotFound) {
+		if found, err := postUseCase.GetById(ctx, 1); err != nil {
+			t.Fatal(err)
+		} else if !found.IsDeleted() || found.DeletedBy != 1 || found.DeleteReason != "spam" {
+			t.Fatalf("want deleted post, got: %v", found)
+		}
+
+		if found, err := postUseCase.GetAllPosts(ctx); err != nil {
+			t.Fatal(err)
+		} else if len(found) != 0 {
+			t.Fatalf("want: %d, got: %d", 0, len(found))
+		}
+
+		if err := postUseCase.DeletePost(ctx, deleted); !errors.Is(err, entity.ErrPostNotFound) {
+			t.Fatalf("want: %v, got: %v", entity.ErrPostNotFound, err)
+		}
+	})
+}
+
+func TestRestorePost(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		storeCategories(t, repos)
+		userUseCase := setupUserUseCase(repos)
+		postUseCase := usecase.NewPostsUseCase(repos.Posts, repos.Users, repos.Comments, repos.Reactions, repos.Revisions,
+			repos.UnitOfWork, entity.DefaultReactionKinds)
+
+		if err := userUseCase.SignUp(ctx, user1); err != nil {
+			t.Fatal(err)
+		}
+		if err := postUseCase.CreatePost(ctx, post1); err != nil {
+			t.Fatal(err)
+		}
+		if err := postUseCase.DeletePost(ctx, post1); err != nil {
+			t.Fatal(err)
+		}
+
+		if found, err := postUseCase.GetDeletedPosts(ctx); err != nil {
+			t.Fatal(err)
+		} else if len(found) != 1 {
+			t.Fatalf("want: %d, got: %d", 1, len(found))
+		}
+
+		if err := postUseCase.RestorePost(ctx, 1); err != nil {
+			t.Fatal(err)
+		}
+
+		if found, err := postUseCase.GetAllPosts(ctx); err != nil {
+			t.Fatal(err)
+		} else if len(found) != 1 {
+			t.Fatalf("want: %d, got: %d", 1, len(found))
+		}
+
+		if err := postUseCase.RestorePost(ctx, 1); !errors.Is(err, entity.ErrPostNotFound) {
+			t.Fatalf("want: %v, got: %v", entity.ErrPostNotFound, err)
+		}
+	})
+}
+
+func TestPurgeDeletedPosts(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		storeCategories(t, repos)
+		userUseCase := setupUserUseCase(repos)
+		postUseCase := usecase.NewPostsUseCase(repos.Posts, repos.Users, repos.Comments, repos.Reactions, repos.Revisions,
+			repos.UnitOfWork, entity.DefaultReactionKinds)
+
+		if err := userUseCase.SignUp(ctx, user1); err != nil {
+			t.Fatal(err)
+		}
+		if err := postUseCase.CreatePost(ctx, post1); err != nil {
+			t.Fatal(err)
+		}
+		if err := postUseCase.DeletePost(ctx, post1); err != nil {
+			t.Fatal(err)
+		}
+
+		if purged, err := postUseCase.PurgeDeleted(ctx, time.Hour); err != nil {
+			t.Fatal(err)
+		} else if purged != 0 {
+			t.Fatalf("want: %d, got: %d", 0, purged)
+		}
+
+		// a negative retention reaches posts deleted just now
+		if purged, err := postUseCase.PurgeDeleted(ctx, -time.Hour); err != nil {
+			t.Fatal(err)
+		} else if purged != 1 {
+			t.Fatalf("want: %d, got: %d", 1, purged)
+		}
+
+		if _, err := postUseCase.GetById(ctx, 1); !errors.Is(err, entity.ErrPostNotFound) {
 			t.Fatalf("want: %v, got: %v", entity.ErrPostNotFound, err)
 		}
 	})
 }
 
 func TestPostMakeReaction(t *testing.T) {
+	ctx := context.Background()
+
 	t.Run("OK", func(t *testing.T) {
-		mockRepo := m.NewMockRepos()
-		userUseCase := setupUserUseCase(mockRepo)
-		postUseCase := usecase.NewPostsUseCase(mockRepo.Posts, mockRepo.Users, mockRepo.Comments)
+		repos := repository.NewMemoryRepositories(memory.New())
+		storeCategories(t, repos)
+		userUseCase := setupUserUseCase(repos)
+		postUseCase := usecase.NewPostsUseCase(repos.Posts, repos.Users, repos.Comments, repos.Reactions, repos.Revisions,
+			repos.UnitOfWork, entity.DefaultReactionKinds)
 
-		if err := userUseCase.SignUp(user1); err != nil {
+		if err := userUseCase.SignUp(ctx, user1); err != nil {
 			t.Fatal(err)
 		}
 
-		if err := postUseCase.CreatePost(post1); err != nil {
+		if err := postUseCase.CreatePost(ctx, post1); err != nil {
 			t.Fatal(err)
 		}
 
-		if err := postUseCase.CreatePost(post2); err != nil {
+		if err := postUseCase.CreatePost(ctx, post2); err != nil {
 			t.Fatal(err)
 		}
 
-		if err := postUseCase.MakeReaction(post1, usecase.ReactionLike); err != nil {
+		if err := postUseCase.MakeReaction(ctx, post1, "like"); err != nil {
 			t.Fatal(err)
 		}
 
-		if found, err := postUseCase.GetPostsByQuery(user1, usecase.PostLikedQuery); err != nil {
+		if found, err := postUseCase.GetPostsByQuery(ctx, user1, "like"); err != nil {
 			t.Fatal(err)
 		} else if found[0].Id != post1.Id {
 			t.Fatalf("want: %d, got: %d", post1.Id, found[0].Id)
 		}
 
-		if err := postUseCase.MakeReaction(post2, usecase.ReactionDislike); err != nil {
+		if err := postUseCase.MakeReaction(ctx, post2, "dislike"); err != nil {
 			t.Fatal(err)
 		}
 
-		if found, err := postUseCase.GetPostsByQuery(user4, usecase.PostDislikedQuery); err != nil {
+		if found, err := postUseCase.GetPostsByQuery(ctx, user4, "dislike"); err != nil {
 			t.Fatal(err)
 		} else if found[0].Id != post2.Id {
 			t.Fatalf("want: %d, got: %d", post2.Id, found[0].Id)
 		}
 
 		// second time reaction should delete reaction
-		if err := postUseCase.MakeReaction(post2, usecase.ReactionDislike); err != nil {
+		if err := postUseCase.MakeReaction(ctx, post2, "dislike"); err != nil {
 			t.Fatal(err)
 		}
 
-		if found, err := postUseCase.GetPostsByQuery(user4, usecase.PostDislikedQuery); err != nil {
+		if found, err := postUseCase.GetPostsByQuery(ctx, user4, "dislike"); err != nil {
 			t.Fatal(err)
 		} else if len(found) != 0 {
 			t.Fatalf("want: %d, got: %d", 0, len(found))
 		}
 	})
+
+	t.Run("exclusive kinds", func(t *testing.T) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		storeCategories(t, repos)
+		userUseCase := setupUserUseCase(repos)
+		postUseCase := usecase.NewPostsUseCase(repos.Posts, repos.Users, repos.Comments, repos.Reactions, repos.Revisions,
+			repos.UnitOfWork, entity.DefaultReactionKinds)
+
+		if err := userUseCase.SignUp(ctx, user1); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := postUseCase.CreatePost(ctx, post1); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, kind := range []string{"like", "heart", "dislike"} {
+			if err := postUseCase.MakeReaction(ctx, post1, kind); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		found, err := postUseCase.GetById(ctx, post1.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts := map[string]int64{}
+		for _, reaction := range found.Reactions {
+			counts[reaction.Kind.Name] = reaction.Count
+		}
+		want := map[string]int64{"like": 0, "dislike": 1, "heart": 1, "laugh": 0, "wow": 0, "sad": 0}
+		if !reflect.DeepEqual(counts, want) {
+			t.Fatalf("want: %v, got: %v", want, counts)
+		}
+	})
+
+	t.Run("err unknown kind", func(t *testing.T) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		storeCategories(t, repos)
+		postUseCase := usecase.NewPostsUseCase(repos.Posts, repos.Users, repos.Comments, repos.Reactions, repos.Revisions,
+			repos.UnitOfWork, entity.DefaultReactionKinds)
+
+		if err := postUseCase.MakeReaction(ctx, post1, "angry"); !errors.Is(err, entity.ErrUnknownReaction) {
+			t.Fatalf("want: %v, got: %v", entity.ErrUnknownReaction, err)
+		}
+	})
+
+	t.Run("err not found", func(t *testing.T) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		storeCategories(t, repos)
+		userUseCase := setupUserUseCase(repos)
+		postUseCase := usecase.NewPostsUseCase(repos.Posts, repos.Users, repos.Comments, repos.Reactions, repos.Revisions,
+			repos.UnitOfWork, entity.DefaultReactionKinds)
+
+		if err := postUseCase.MakeReaction(ctx, post1, "like"); !errors.Is(err, entity.ErrPostNotFound) {
+			t.Fatalf("want: %v, got: %v", entity.ErrPostNotFound, err)
+		}
+
+		if err := userUseCase.SignUp(ctx, user1); err != nil {
+			t.Fatal(err)
+		}
+		if err := postUseCase.CreatePost(ctx, post1); err != nil {
+			t.Fatal(err)
+		}
+		if err := postUseCase.DeletePost(ctx, post1); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := postUseCase.MakeReaction(ctx, post1, "like"); !errors.Is(err, entity.ErrPostNotFound) {
+			t.Fatalf("want: %v, got: %v", entity.ErrPostNotFound, err)
+		}
+		if ids, err := repos.Reactions.FetchTargetIds(ctx, entity.ReactionTargetPost, post1.User.Id, "like"); err != nil {
+			t.Fatal(err)
+		} else if len(ids) != 0 {
+			t.Fatalf("want no reactions, got: %v", ids)
+		}
+	})
 }
 
 func TestPostDeleteReaction(t *testing.T) {
+	ctx := context.Background()
+
 	t.Run("OK", func(t *testing.T) {
-		mockRepo := m.NewMockRepos()
-		userUseCase := setupUserUseCase(mockRepo)
-		postUseCase := usecase.NewPostsUseCase(mockRepo.Posts, mockRepo.Users, mockRepo.Comments)
+		repos := repository.NewMemoryRepositories(memory.New())
+		storeCategories(t, repos)
+		userUseCase := setupUserUseCase(repos)
+		postUseCase := usecase.NewPostsUseCase(repos.Posts, repos.Users, repos.Comments, repos.Reactions, repos.Revisions,
+			repos.UnitOfWork, entity.DefaultReactionKinds)
 
-		if err := userUseCase.SignUp(user1); err != nil {
+		if err := userUseCase.SignUp(ctx, user1); err != nil {
 			t.Fatal(err)
 		}
 
-		if err := postUseCase.CreatePost(post1); err != nil {
+		if err := postUseCase.CreatePost(ctx, post1); err != nil {
 			t.Fatal(err)
 		}
 
-		if err := postUseCase.MakeReaction(post1, usecase.ReactionLike); err != nil {
+		if err := postUseCase.MakeReaction(ctx, post1, "like"); err != nil {
 			t.Fatal(err)
 		}
 
-		if found, err := postUseCase.GetPostsByQuery(user1, usecase.PostLikedQuery); err != nil {
+		if found, err := postUseCase.GetPostsByQuery(ctx, user1, "like"); err != nil {
 			t.Fatal(err)
 		} else if len(found) != 1 {
 			t.Fatalf("want: %d, got: %d", 1, len(found))
 		}
 
-		if err := postUseCase.DeleteReaction(post1, usecase.ReactionLike); err != nil {
+		if err := postUseCase.DeleteReaction(ctx, post1, "like"); err != nil {
 			t.Fatal(err)
 		}
 
-		if found, err := postUseCase.GetPostsByQuery(user1, usecase.PostLikedQuery); err != nil {
+		if found, err := postUseCase.GetPostsByQuery(ctx, user1, "like"); err != nil {
+			t.Fatal(err)
+		} else if len(found) != 0 {
+			t.Fatalf("want: %d, got: %d", 0, len(found))
+		}
+	})
+}
+
+func TestSearch(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories(memory.New())
+	storeCategories(t, repos)
+	userUseCase := setupUserUseCase(repos)
+	postUseCase := usecase.NewPostsUseCase(repos.Posts, repos.Users, repos.Comments, repos.Reactions, repos.Revisions,
+		repos.UnitOfWork, entity.DefaultReactionKinds)
+	commentUseCase := usecase.NewCommentsUseCase(repos.Comments, repos.Posts, repos.Users, repos.Reactions, repos.Revisions,
+		repos.UnitOfWork, entity.DefaultReactionKinds, commentsMaxDepth)
+
+	t.Run("OK", func(t *testing.T) {
+		if err := userUseCase.SignUp(ctx, user1); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := postUseCase.CreatePost(ctx, post1); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := postUseCase.CreatePost(ctx, post3); err != nil {
+			t.Fatal(err)
+		}
+
+		comment := entity.Comment{PostId: 2, User: user1, Content: "Audi is better than BMW"}
+		if err := commentUseCase.WriteComment(ctx, comment); err != nil {
+			t.Fatal(err)
+		}
+
+		found, err := postUseCase.Search(ctx, "AUDI!")
+		if err != nil {
+			t.Fatal(err)
+		} else if len(found) != 2 {
+			t.Fatalf("want: %d, got: %d", 2, len(found))
+		}
+
+		if found[0].Post.Id != 1 {
+			t.Fatalf("want: %d, got: %d", 1, found[0].Post.Id)
+		} else if found[0].Post.User.Name != user1.Name {
+			t.Fatalf("want: %v, got: %v", user1.Name, found[0].Post.User.Name)
+		}
+
+		parts := found[1].Highlighted()
+		matched := false
+		for _, part := range parts {
+			if part.Match && part.Text == "Audi" {
+				matched = true
+			}
+		}
+		if !matched {
+			t.Fatalf("want highlighted match, got: %v", parts)
+		}
+	})
+
+	t.Run("empty query", func(t *testing.T) {
+		if found, err := postUseCase.Search(ctx, " ?! "); err != nil {
 			t.Fatal(err)
 		} else if len(found) != 0 {
 			t.Fatalf("want: %d, got: %d", 0, len(found))
@@ -0,0 +1,129 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"forum/internal/entity"
+	"forum/internal/repository"
+)
+
+// reactions holds the reaction logic shared by posts and comments, target
+// tells which of them the reactions belong to.
+type reactions struct {
+	repo   repository.Reactions
+	kinds  entity.ReactionKinds
+	target string
+}
+
+// toggle puts a reaction of the user on the target, or takes it back when
+// it is already there. Putting a reaction removes the ones excluded by it,
+// so toggle should run inside a unit of work.
+func (rs reactions) toggle(ctx context.Context, targetId, userId int64, kind string) error {
+	found, ok := rs.kinds.Find(kind)
+	if !ok {
+		return entity.ErrUnknownReaction
+	}
+
+	reaction := entity.Reaction{
+		Target:   rs.target,
+		TargetId: targetId,
+		UserId:   userId,
+		Kind:     kind,
+		Date:     time.Now(),
+	}
+	has, err := rs.repo.Has(ctx, reaction)
+	if err != nil {
+		return fmt.Errorf("toggle #1 - %w", err)
+	}
+	if has {
+		err = rs.repo.Delete(ctx, reaction)
+		if err != nil {
+			return fmt.Errorf("toggle #2 - %w", err)
+		}
+		return nil
+	}
+
+	err = rs.repo.Store(ctx, reaction)
+	if err != nil {
+		return fmt.Errorf("toggle #3 - %w", err)
+	}
+	for _, excluded := range rs.kinds.Excluded(found) {
+		reaction.Kind = excluded
+		err = rs.repo.Delete(ctx, reaction)
+		if err != nil {
+			return fmt.Errorf("toggle #4 - %w", err)
+		}
+	}
+	return nil
+}
+
+// in returns the reactions working through repos of a unit of work.
+func (rs reactions) in(repos *repository.Repositories) reactions {
+	rs.repo = repos.Reactions
+	return rs
+}
+
+func (rs reactions) remove(ctx context.Context, targetId, userId int64, kind string) error {
+	if _, ok := rs.kinds.Find(kind); !ok {
+		return entity.ErrUnknownReaction
+	}
+
+	err := rs.repo.Delete(ctx, entity.Reaction{
+		Target:   rs.target,
+		TargetId: targetId,
+		UserId:   userId,
+		Kind:     kind,
+	})
+	if err != nil {
+		return fmt.Errorf("remove - %w", err)
+	}
+	return nil
+}
+
+// counts returns per-kind counts for every id, kinds nobody used are
+// listed with zero.
+func (rs reactions) counts(ctx context.Context, ids []int64) (map[int64][]entity.ReactionCount, error) {
+	byId, err := rs.repo.Count(ctx, rs.target, ids)
+	if err != nil {
+		return nil, fmt.Errorf("counts - %w", err)
+	}
+
+	counts := make(map[int64][]entity.ReactionCount, len(ids))
+	for _, id := range ids {
+		counts[id] = rs.kinds.Counts(byId[id])
+	}
+	return counts, nil
+}
+
+func (rs reactions) users(ctx context.Context, targetId int64, kind string,
+	userRepo repository.Users) ([]entity.User, error) {
+	var users []entity.User
+	if _, ok := rs.kinds.Find(kind); !ok {
+		return users, entity.ErrUnknownReaction
+	}
+
+	found, err := rs.repo.Fetch(ctx, rs.target, targetId, kind)
+	if err != nil {
+		return users, fmt.Errorf("users #1 - %w", err)
+	}
+	ids := make([]int64, len(found))
+	for i := range found {
+		ids[i] = found[i].UserId
+	}
+	fetched, err := userRepo.FetchByIds(ctx, ids)
+	if err != nil {
+		return users, fmt.Errorf("users #2 - %w", err)
+	}
+	byId := make(map[int64]entity.User, len(fetched))
+	for _, user := range fetched {
+		byId[user.Id] = user
+	}
+	for _, reaction := range found {
+		if user, ok := byId[reaction.UserId]; ok {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
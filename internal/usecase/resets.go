@@ -0,0 +1,172 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"forum/internal/entity"
+	"forum/internal/repository"
+	"forum/pkg/auth"
+	"forum/pkg/hasher"
+	"forum/pkg/mailer"
+)
+
+// Letter with a password reset link.
+const (
+	resetSubject = "Восстановление пароля"
+	resetBody    = "Чтобы задать новый пароль, перейдите по ссылке:\n%s\n\n" +
+		"Ссылка действует %d мин. и только один раз. Если вы не просили сменить пароль, " +
+		"просто не обращайте внимания на это письмо."
+)
+
+type PasswordResetsUseCase struct {
+	repo         repository.PasswordResets
+	userRepo     repository.Users
+	hasher       hasher.PasswordHasher
+	tokenManager auth.TokenManager
+	mailer       mailer.Mailer
+	uow          repository.UnitOfWork
+	siteURL      string
+	ttl          time.Duration
+}
+
+// NewPasswordResetsUseCase mails links starting with siteURL, they are
+// valid for ttl.
+func NewPasswordResetsUseCase(repo repository.PasswordResets, userRepo repository.Users,
+	hasher hasher.PasswordHasher, tokenManager auth.TokenManager, mailer mailer.Mailer,
+	uow repository.UnitOfWork, siteURL string, ttl time.Duration,
+) *PasswordResetsUseCase {
+	return &PasswordResetsUseCase{
+		repo:         repo,
+		userRepo:     userRepo,
+		hasher:       hasher,
+		tokenManager: tokenManager,
+		mailer:       mailer,
+		uow:          uow,
+		siteURL:      siteURL,
+		ttl:          ttl,
+	}
+}
+
+// RequestReset mails a reset link to the user with the email, the links
+// mailed before stop working. Unknown emails get no letter and no error,
+// so the form doesn't tell who is registered.
+func (ru *PasswordResetsUseCase) RequestReset(ctx context.Context, email string) error {
+	id, err := ru.userRepo.GetId(ctx, entity.User{Email: email})
+	if err != nil && !strings.Contains(err.Error(), NoRowsResultErr) {
+		return fmt.Errorf("PasswordResetsUseCase - RequestReset #1 - %w", err)
+	}
+	if id == 0 {
+		return nil
+	}
+
+	token, err := ru.tokenManager.NewToken()
+	if err != nil {
+		return fmt.Errorf("PasswordResetsUseCase - RequestReset #2 - %w", err)
+	}
+	now := time.Now()
+	reset := entity.PasswordReset{
+		User:      entity.User{Id: id},
+		TokenHash: hashToken(token),
+		CreatedAt: now,
+		ExpiresAt: now.Add(ru.ttl),
+	}
+
+	err = ru.uow.Do(ctx, func(repos *repository.Repositories) error {
+		_, err := repos.Resets.DeleteExpired(ctx, now)
+		if err != nil {
+			return fmt.Errorf("PasswordResetsUseCase - RequestReset #3 - %w", err)
+		}
+		err = repos.Resets.DeleteByUser(ctx, id)
+		if err != nil {
+			return fmt.Errorf("PasswordResetsUseCase - RequestReset #4 - %w", err)
+		}
+		err = repos.Resets.Store(ctx, &reset)
+		if err != nil {
+			return fmt.Errorf("PasswordResetsUseCase - RequestReset #5 - %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	link := ru.siteURL + "/reset_password_page?token=" + url.QueryEscape(token)
+	err = ru.mailer.Send(email, resetSubject, fmt.Sprintf(resetBody, link, int(ru.ttl.Minutes())))
+	if err != nil {
+		return fmt.Errorf("PasswordResetsUseCase - RequestReset #6 - %w", err)
+	}
+	return nil
+}
+
+// CheckToken tells whether the reset link is still valid, before the new
+// password is asked for.
+func (ru *PasswordResetsUseCase) CheckToken(ctx context.Context, token string) error {
+	_, err := ru.getReset(ctx, ru.repo, token)
+	if err != nil {
+		return fmt.Errorf("PasswordResetsUseCase - CheckToken - %w", err)
+	}
+	return nil
+}
+
+// ResetPassword sets the new password of the user the link was mailed to.
+// The link works once, every session of the user is signed out.
+func (ru *PasswordResetsUseCase) ResetPassword(ctx context.Context, token, password string) error {
+	hashed, err := ru.hasher.Hash(password)
+	if err != nil {
+		return fmt.Errorf("PasswordResetsUseCase - ResetPassword #1 - %w", err)
+	}
+
+	return ru.uow.Do(ctx, func(repos *repository.Repositories) error {
+		reset, err := ru.getReset(ctx, repos.Resets, token)
+		if err != nil {
+			return fmt.Errorf("PasswordResetsUseCase - ResetPassword #2 - %w", err)
+		}
+		err = repos.Users.UpdatePassword(ctx, entity.User{Id: reset.User.Id, Password: hashed})
+		if err != nil {
+			return fmt.Errorf("PasswordResetsUseCase - ResetPassword #3 - %w", err)
+		}
+		err = repos.Resets.DeleteByUser(ctx, reset.User.Id)
+		if err != nil {
+			return fmt.Errorf("PasswordResetsUseCase - ResetPassword #4 - %w", err)
+		}
+		err = repos.Sessions.DeleteByUser(ctx, reset.User.Id)
+		if err != nil {
+			return fmt.Errorf("PasswordResetsUseCase - ResetPassword #5 - %w", err)
+		}
+		return nil
+	})
+}
+
+// getReset finds the reset of the token, unknown and expired tokens are
+// entity.ErrResetTokenInvalid.
+func (ru *PasswordResetsUseCase) getReset(ctx context.Context, repo repository.PasswordResets,
+	token string,
+) (entity.PasswordReset, error) {
+	if token == "" {
+		return entity.PasswordReset{}, entity.ErrResetTokenInvalid
+	}
+	reset, err := repo.GetByHash(ctx, hashToken(token))
+	if err != nil {
+		if strings.Contains(err.Error(), NoRowsResultErr) {
+			return reset, entity.ErrResetTokenInvalid
+		}
+		return reset, err
+	}
+	if ru.tokenManager.CheckTTLExpired(reset.ExpiresAt) {
+		return reset, entity.ErrResetTokenInvalid
+	}
+	return reset, nil
+}
+
+// hashToken is what is stored of a mailed token. Tokens are random, a
+// plain hash is enough to keep a leaked table from opening accounts.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,154 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"forum/internal/config"
+	"forum/internal/entity"
+	"forum/internal/repository"
+	"forum/internal/repository/memory"
+	"forum/internal/usecase"
+	"forum/pkg/auth"
+	"forum/pkg/hasher"
+	"forum/pkg/mailer"
+)
+
+var resetLink = regexp.MustCompile(`http://forum\.test/reset_password_page\?token=(\S+)`)
+
+// setupResetsUseCase mails the letters into a file of the test, the
+// returned func reads the token of the last one.
+func setupResetsUseCase(t *testing.T, repos *repository.Repositories, ttl time.Duration) (
+	*usecase.PasswordResetsUseCase, func() string,
+) {
+	cfg, err := config.LoadConfig("../../config.json")
+	if err != nil {
+		log.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "mail.log")
+	resetsUseCase := usecase.NewPasswordResetsUseCase(repos.Resets, repos.Users, hasher.NewBcryptHasher(),
+		auth.NewManager(cfg), mailer.NewFileMailer(path, "forum@forum.test"), repos.UnitOfWork,
+		"http://forum.test", ttl)
+
+	lastToken := func() string {
+		t.Helper()
+		letters, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := resetLink.FindAllStringSubmatch(string(letters), -1)
+		if len(found) == 0 {
+			t.Fatal("no reset link mailed")
+		}
+		return found[len(found)-1][1]
+	}
+	return resetsUseCase, lastToken
+}
+
+func TestRequestReset(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		userUseCase := setupUserUseCase(repos)
+		resetsUseCase, lastToken := setupResetsUseCase(t, repos, time.Hour)
+
+		if err := userUseCase.SignUp(ctx, user1); err != nil {
+			t.Fatal(err)
+		}
+		if err := resetsUseCase.RequestReset(ctx, user1.Email); err != nil {
+			t.Fatal(err)
+		}
+		first := lastToken()
+		if err := resetsUseCase.RequestReset(ctx, user1.Email); err != nil {
+			t.Fatal(err)
+		}
+		second := lastToken()
+
+		if err := resetsUseCase.CheckToken(ctx, second); err != nil {
+			t.Fatal(err)
+		}
+		if err := resetsUseCase.CheckToken(ctx, first); !errors.Is(err, entity.ErrResetTokenInvalid) {
+			t.Fatalf("want: %v, got: %v", entity.ErrResetTokenInvalid, err)
+		}
+		if _, err := repos.Resets.GetByHash(ctx, second); err == nil {
+			t.Fatal("want token stored hashed")
+		}
+	})
+
+	t.Run("OK unknown email", func(t *testing.T) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		resetsUseCase, _ := setupResetsUseCase(t, repos, time.Hour)
+
+		if err := resetsUseCase.RequestReset(ctx, "nobody@mail.ru"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("err expired", func(t *testing.T) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		userUseCase := setupUserUseCase(repos)
+		resetsUseCase, lastToken := setupResetsUseCase(t, repos, -time.Minute)
+
+		if err := userUseCase.SignUp(ctx, user1); err != nil {
+			t.Fatal(err)
+		}
+		if err := resetsUseCase.RequestReset(ctx, user1.Email); err != nil {
+			t.Fatal(err)
+		}
+		if err := resetsUseCase.CheckToken(ctx, lastToken()); !errors.Is(err, entity.ErrResetTokenInvalid) {
+			t.Fatalf("want: %v, got: %v", entity.ErrResetTokenInvalid, err)
+		}
+	})
+}
+
+func TestResetPassword(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories(memory.New())
+	userUseCase := setupUserUseCase(repos)
+	resetsUseCase, lastToken := setupResetsUseCase(t, repos, time.Hour)
+
+	if err := userUseCase.SignUp(ctx, user1); err != nil {
+		t.Fatal(err)
+	}
+	signIn(t, userUseCase, "Firefox")
+	signIn(t, userUseCase, "Safari")
+	if err := resetsUseCase.RequestReset(ctx, user1.Email); err != nil {
+		t.Fatal(err)
+	}
+	token := lastToken()
+
+	t.Run("err unknown token", func(t *testing.T) {
+		if err := resetsUseCase.ResetPassword(ctx, "unknown", "Reset"); !errors.Is(err,
+			entity.ErrResetTokenInvalid) {
+			t.Fatalf("want: %v, got: %v", entity.ErrResetTokenInvalid, err)
+		}
+	})
+
+	t.Run("OK", func(t *testing.T) {
+		if err := resetsUseCase.ResetPassword(ctx, token, "Reset"); err != nil {
+			t.Fatal(err)
+		}
+
+		user := user1
+		user.Password = "Reset"
+		if _, err := userUseCase.SignIn(ctx, user, entity.Session{}); err != nil {
+			t.Fatal(err)
+		}
+		if found := getSessions(t, repos, user1.Id); len(found) != 1 {
+			t.Fatalf("want only the new session, got: %d", len(found))
+		}
+	})
+
+	t.Run("err used twice", func(t *testing.T) {
+		if err := resetsUseCase.ResetPassword(ctx, token, "Again"); !errors.Is(err, entity.ErrResetTokenInvalid) {
+			t.Fatalf("want: %v, got: %v", entity.ErrResetTokenInvalid, err)
+		}
+	})
+}
@@ -0,0 +1,164 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"forum/internal/entity"
+	"forum/internal/repository"
+)
+
+// maxDiffCells caps the table diffLines fills for the changed lines of
+// two revisions, about 8 MB. Bigger changes are too large to diff.
+const maxDiffCells = 1 << 20
+
+// revisions holds the edit history shared by posts and comments, target
+// tells which of them the revisions belong to.
+type revisions struct {
+	repo   repository.Revisions
+	target string
+}
+
+// record stores the edit turning old into edited. Targets written before
+// revisions were kept get their original version stored first.
+func (rs revisions) record(ctx context.Context, old, edited entity.Revision) error {
+	stored, err := rs.repo.Fetch(ctx, rs.target, edited.TargetId)
+	if err != nil {
+		return fmt.Errorf("record #1 - %w", err)
+	}
+	if len(stored) == 0 {
+		old.Target = rs.target
+		err = rs.repo.Store(ctx, old)
+		if err != nil {
+			return fmt.Errorf("record #2 - %w", err)
+		}
+	}
+
+	edited.Target = rs.target
+	err = rs.repo.Store(ctx, edited)
+	if err != nil {
+		return fmt.Errorf("record #3 - %w", err)
+	}
+	return nil
+}
+
+// in returns the revisions working through repos of a unit of work.
+func (rs revisions) in(repos *repository.Repositories) revisions {
+	rs.repo = repos.Revisions
+	return rs
+}
+
+func (rs revisions) list(ctx context.Context, targetId int64) ([]entity.Revision, error) {
+	list, err := rs.repo.Fetch(ctx, rs.target, targetId)
+	if err != nil {
+		return nil, fmt.Errorf("list - %w", err)
+	}
+	return list, nil
+}
+
+// diff compares revisions number from and to of the target.
+func (rs revisions) diff(ctx context.Context, targetId int64, from, to int) (entity.Diff, error) {
+	list, err := rs.repo.Fetch(ctx, rs.target, targetId)
+	if err != nil {
+		return entity.Diff{}, fmt.Errorf("diff - %w", err)
+	}
+	if from < 1 || from > len(list) || to < 1 || to > len(list) {
+		return entity.Diff{}, entity.ErrRevisionNotFound
+	}
+
+	diff := entity.Diff{From: list[from-1], To: list[to-1]}
+	lines, ok := diffLines(revisionLines(diff.From), revisionLines(diff.To))
+	if !ok {
+		diff.TooLarge = true
+		return diff, nil
+	}
+	diff.Lines = lines
+	return diff, nil
+}
+
+// revisionLines splits a revision into the lines a diff compares: the
+// title and the categories of posts, then the content line by line.
+func revisionLines(revision entity.Revision) []string {
+	var lines []string
+	if revision.Target == entity.RevisionTargetPost {
+		lines = append(lines, revision.Title, strings.Join(revision.Categories, ", "), "")
+	}
+	return append(lines, strings.Split(revision.Content, "\\n")...)
+}
+
+// diffLines turns a into b keeping their longest common subsequence of
+// lines, everything else is removed from a or added from b. Lines the
+// revisions start and end with are kept without comparing them, ok is
+// false when the lines between are more than maxDiffCells can diff.
+func diffLines(a, b []string) (lines []entity.DiffLine, ok bool) {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	changedA, changedB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if int64(len(changedA)+1)*int64(len(changedB)+1) > maxDiffCells {
+		return nil, false
+	}
+
+	lines = make([]entity.DiffLine, 0, len(a)+len(b)-prefix-suffix)
+	for _, line := range a[:prefix] {
+		lines = append(lines, entity.DiffLine{Op: entity.DiffSame, Text: line})
+	}
+	lines = append(lines, diffChanged(changedA, changedB)...)
+	for _, line := range a[len(a)-suffix:] {
+		lines = append(lines, entity.DiffLine{Op: entity.DiffSame, Text: line})
+	}
+	return lines, true
+}
+
+// diffChanged diffs a and b through the table of their common
+// subsequences.
+func diffChanged(a, b []string) []entity.DiffLine {
+	// common[i][j] is the length of the subsequence of a[i:] and b[j:].
+	common := make([][]int, len(a)+1)
+	for i := range common {
+		common[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				common[i][j] = common[i+1][j+1] + 1
+			case common[i+1][j] >= common[i][j+1]:
+				common[i][j] = common[i+1][j]
+			default:
+				common[i][j] = common[i][j+1]
+			}
+		}
+	}
+
+	lines := make([]entity.DiffLine, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, entity.DiffLine{Op: entity.DiffSame, Text: a[i]})
+			i++
+			j++
+		case common[i+1][j] >= common[i][j+1]:
+			lines = append(lines, entity.DiffLine{Op: entity.DiffRemoved, Text: a[i]})
+			i++
+		default:
+			lines = append(lines, entity.DiffLine{Op: entity.DiffAdded, Text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, entity.DiffLine{Op: entity.DiffRemoved, Text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, entity.DiffLine{Op: entity.DiffAdded, Text: b[j]})
+	}
+	return lines
+}
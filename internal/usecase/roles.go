@@ -0,0 +1,181 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"forum/internal/entity"
+	"forum/internal/repository"
+)
+
+type RolesUseCase struct {
+	repo repository.Roles
+	uow  repository.UnitOfWork
+}
+
+func NewRolesUseCase(repo repository.Roles, uow repository.UnitOfWork) *RolesUseCase {
+	return &RolesUseCase{
+		repo: repo,
+		uow:  uow,
+	}
+}
+
+// GetRoles lists every role with its permissions, the built-in ones
+// first.
+func (ru *RolesUseCase) GetRoles(ctx context.Context) ([]entity.Role, error) {
+	roles, err := ru.repo.Fetch(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("RolesUseCase - GetRoles - %w", err)
+	}
+	return roles, nil
+}
+
+func (ru *RolesUseCase) GetRole(ctx context.Context, id int64) (entity.Role, error) {
+	role, err := ru.repo.GetById(ctx, id)
+	if err != nil {
+		if strings.Contains(err.Error(), NoRowsResultErr) {
+			return role, entity.ErrRoleNotFound
+		}
+		return role, fmt.Errorf("RolesUseCase - GetRole - %w", err)
+	}
+	return role, nil
+}
+
+// CreateRole stores a new role of the name and the permissions.
+func (ru *RolesUseCase) CreateRole(ctx context.Context, role entity.Role) error {
+	role, err := checkRole(role)
+	if err != nil {
+		return err
+	}
+	err = ru.repo.Store(ctx, &role)
+	if err != nil {
+		if strings.Contains(err.Error(), UniqueRoleErr) {
+			return entity.ErrRoleExists
+		}
+		return fmt.Errorf("RolesUseCase - CreateRole - %w", err)
+	}
+	return nil
+}
+
+// UpdateRole renames the role and overwrites its permissions. The admin
+// role can't be changed, so the forum is never left without someone
+// holding every permission.
+func (ru *RolesUseCase) UpdateRole(ctx context.Context, role entity.Role) error {
+	if role.Id == entity.RoleAdminId {
+		return entity.ErrRoleBuiltin
+	}
+	role, err := checkRole(role)
+	if err != nil {
+		return err
+	}
+	_, err = ru.GetRole(ctx, role.Id)
+	if err != nil {
+		return err
+	}
+	err = ru.repo.Update(ctx, role)
+	if err != nil {
+		if strings.Contains(err.Error(), UniqueRoleErr) {
+			return entity.ErrRoleExists
+		}
+		return fmt.Errorf("RolesUseCase - UpdateRole - %w", err)
+	}
+	return nil
+}
+
+// DeleteRole removes a role that is not built in, its users get the
+// default role.
+func (ru *RolesUseCase) DeleteRole(ctx context.Context, id int64) error {
+	if (entity.Role{Id: id}).Builtin() {
+		return entity.ErrRoleBuiltin
+	}
+	return ru.uow.Do(ctx, func(repos *repository.Repositories) error {
+		_, err := repos.Roles.GetById(ctx, id)
+		if err != nil {
+			if strings.Contains(err.Error(), NoRowsResultErr) {
+				return entity.ErrRoleNotFound
+			}
+			return fmt.Errorf("RolesUseCase - DeleteRole #1 - %w", err)
+		}
+		err = repos.Roles.MoveUsers(ctx, id, entity.RoleUserId)
+		if err != nil {
+			return fmt.Errorf("RolesUseCase - DeleteRole #2 - %w", err)
+		}
+		err = repos.Roles.Delete(ctx, id)
+		if err != nil {
+			return fmt.Errorf("RolesUseCase - DeleteRole #3 - %w", err)
+		}
+		return nil
+	})
+}
+
+// AssignRole gives the user the role. The last admin can't be given
+// another one.
+func (ru *RolesUseCase) AssignRole(ctx context.Context, userId, roleId int64) error {
+	return ru.uow.Do(ctx, func(repos *repository.Repositories) error {
+		_, err := repos.Roles.GetById(ctx, roleId)
+		if err != nil {
+			if strings.Contains(err.Error(), NoRowsResultErr) {
+				return entity.ErrRoleNotFound
+			}
+			return fmt.Errorf("RolesUseCase - AssignRole #1 - %w", err)
+		}
+		if roleId != entity.RoleAdminId {
+			err = keepAdmin(ctx, repos, userId)
+			if err != nil {
+				return err
+			}
+		}
+		err = repos.Users.SetRole(ctx, userId, roleId)
+		if err != nil {
+			return fmt.Errorf("RolesUseCase - AssignRole #2 - %w", err)
+		}
+		return nil
+	})
+}
+
+// keepAdmin returns entity.ErrLastAdmin when the user is the only one
+// with the admin role and entity.ErrUserNotFound when there is no such
+// user.
+func keepAdmin(ctx context.Context, repos *repository.Repositories, userId int64) error {
+	user, err := repos.Users.GetById(ctx, userId)
+	if err != nil {
+		if strings.Contains(err.Error(), NoRowsResultErr) {
+			return entity.ErrUserNotFound
+		}
+		return fmt.Errorf("keepAdmin #1 - %w", err)
+	}
+	if user.Role.Id != entity.RoleAdminId {
+		return nil
+	}
+	admins, err := repos.Roles.CountUsers(ctx, entity.RoleAdminId)
+	if err != nil {
+		return fmt.Errorf("keepAdmin #2 - %w", err)
+	}
+	if admins <= 1 {
+		return entity.ErrLastAdmin
+	}
+	return nil
+}
+
+// checkRole trims the name of the role and lists its permissions once
+// in the order of entity.Permissions.
+func checkRole(role entity.Role) (entity.Role, error) {
+	role.Name = strings.TrimSpace(role.Name)
+	if role.Name == "" {
+		return role, entity.ErrRoleNameEmpty
+	}
+	for _, permission := range role.Permissions {
+		if !entity.KnownPermission(permission) {
+			return role, fmt.Errorf("%w: %s", entity.ErrUnknownPermission, permission)
+		}
+	}
+	var permissions []entity.Permission
+	for _, permission := range entity.Permissions {
+		if role.Can(permission) {
+			permissions = append(permissions, permission)
+		}
+	}
+	role.Permissions = permissions
+	return role, nil
+}
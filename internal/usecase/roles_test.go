@@ -0,0 +1,203 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"forum/internal/entity"
+	"forum/internal/repository"
+	"forum/internal/repository/memory"
+	"forum/internal/usecase"
+)
+
+func setupRolesUseCase(repos *repository.Repositories) *usecase.RolesUseCase {
+	return usecase.NewRolesUseCase(repos.Roles, repos.UnitOfWork)
+}
+
+func TestCreateRole(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		roleUseCase := setupRolesUseCase(repos)
+
+		role := entity.Role{Name: " Curator ", Permissions: []entity.Permission{
+			entity.PermEditAnyPost, entity.PermManageCategories, entity.PermEditAnyPost,
+		}}
+		if err := roleUseCase.CreateRole(ctx, role); err != nil {
+			t.Fatal(err)
+		}
+
+		found, err := roleUseCase.GetRole(ctx, 4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found.Name != "Curator" || len(found.Permissions) != 2 ||
+			!found.Can(entity.PermEditAnyPost) || !found.Can(entity.PermManageCategories) {
+			t.Fatalf("want Curator editing posts and managing categories, got: %+v", found)
+		}
+	})
+
+	t.Run("err", func(t *testing.T) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		roleUseCase := setupRolesUseCase(repos)
+
+		for _, tc := range []struct {
+			role entity.Role
+			want error
+		}{
+			{entity.Role{Name: " "}, entity.ErrRoleNameEmpty},
+			{entity.Role{Name: "Модератор"}, entity.ErrRoleExists},
+			{entity.Role{Name: "Curator", Permissions: []entity.Permission{"fly"}}, entity.ErrUnknownPermission},
+		} {
+			if err := roleUseCase.CreateRole(ctx, tc.role); !errors.Is(err, tc.want) {
+				t.Fatalf("%q: want: %v, got: %v", tc.role.Name, tc.want, err)
+			}
+		}
+	})
+}
+
+func TestUpdateRole(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		roleUseCase := setupRolesUseCase(repos)
+
+		role := entity.Role{Id: entity.RoleModeratorId, Name: "Редактор", Permissions: []entity.Permission{
+			entity.PermEditAnyPost,
+		}}
+		if err := roleUseCase.UpdateRole(ctx, role); err != nil {
+			t.Fatal(err)
+		}
+		if found, err := roleUseCase.GetRole(ctx, role.Id); err != nil {
+			t.Fatal(err)
+		} else if !reflect.DeepEqual(found, role) {
+			t.Fatalf("want: %+v, got: %+v", role, found)
+		}
+	})
+
+	t.Run("err", func(t *testing.T) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		roleUseCase := setupRolesUseCase(repos)
+
+		for _, tc := range []struct {
+			role entity.Role
+			want error
+		}{
+			{entity.Role{Id: entity.RoleAdminId, Name: "Boss"}, entity.ErrRoleBuiltin},
+			{entity.Role{Id: entity.RoleModeratorId, Name: "Администратор"}, entity.ErrRoleExists},
+			{entity.Role{Id: 10, Name: "Curator"}, entity.ErrRoleNotFound},
+		} {
+			if err := roleUseCase.UpdateRole(ctx, tc.role); !errors.Is(err, tc.want) {
+				t.Fatalf("%q: want: %v, got: %v", tc.role.Name, tc.want, err)
+			}
+		}
+	})
+}
+
+func TestDeleteRole(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("OK users get the default role", func(t *testing.T) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		roleUseCase := setupRolesUseCase(repos)
+		userUseCase := setupUserUseCase(repos)
+
+		if err := roleUseCase.CreateRole(ctx, entity.Role{Name: "Curator"}); err != nil {
+			t.Fatal(err)
+		}
+		for _, user := range []entity.User{user1, user4} {
+			if err := userUseCase.SignUp(ctx, user); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := roleUseCase.AssignRole(ctx, user4.Id, 4); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := roleUseCase.DeleteRole(ctx, 4); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := roleUseCase.GetRole(ctx, 4); !errors.Is(err, entity.ErrRoleNotFound) {
+			t.Fatalf("want: %v, got: %v", entity.ErrRoleNotFound, err)
+		}
+		if found, err := userUseCase.GetById(ctx, user4.Id); err != nil {
+			t.Fatal(err)
+		} else if found.Role.Id != entity.RoleUserId {
+			t.Fatalf("want role %d, got: %d", entity.RoleUserId, found.Role.Id)
+		}
+	})
+
+	t.Run("err", func(t *testing.T) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		roleUseCase := setupRolesUseCase(repos)
+
+		for id, want := range map[int64]error{
+			entity.RoleUserId:      entity.ErrRoleBuiltin,
+			entity.RoleModeratorId: entity.ErrRoleBuiltin,
+			entity.RoleAdminId:     entity.ErrRoleBuiltin,
+			10:                     entity.ErrRoleNotFound,
+		} {
+			if err := roleUseCase.DeleteRole(ctx, id); !errors.Is(err, want) {
+				t.Fatalf("role %d: want: %v, got: %v", id, want, err)
+			}
+		}
+	})
+}
+
+func TestAssignRole(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(t *testing.T) (*usecase.RolesUseCase, *usecase.UsersUseCase) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		roleUseCase := setupRolesUseCase(repos)
+		userUseCase := setupUserUseCase(repos)
+		for _, user := range []entity.User{user1, user4} {
+			if err := userUseCase.SignUp(ctx, user); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return roleUseCase, userUseCase
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		roleUseCase, userUseCase := setup(t)
+
+		// a second admin lets the first one step down
+		if err := roleUseCase.AssignRole(ctx, user4.Id, entity.RoleAdminId); err != nil {
+			t.Fatal(err)
+		}
+		if err := roleUseCase.AssignRole(ctx, user1.Id, entity.RoleModeratorId); err != nil {
+			t.Fatal(err)
+		}
+
+		for id, want := range map[int64]int64{user1.Id: entity.RoleModeratorId, user4.Id: entity.RoleAdminId} {
+			if found, err := userUseCase.GetById(ctx, id); err != nil {
+				t.Fatal(err)
+			} else if found.Role.Id != want {
+				t.Fatalf("user %d: want role %d, got: %d", id, want, found.Role.Id)
+			}
+		}
+	})
+
+	t.Run("err", func(t *testing.T) {
+		roleUseCase, _ := setup(t)
+
+		for _, tc := range []struct {
+			userId, roleId int64
+			want           error
+		}{
+			{user1.Id, entity.RoleUserId, entity.ErrLastAdmin},
+			{user4.Id, 10, entity.ErrRoleNotFound},
+			{10, entity.RoleModeratorId, entity.ErrUserNotFound},
+		} {
+			if err := roleUseCase.AssignRole(ctx, tc.userId, tc.roleId); !errors.Is(err, tc.want) {
+				t.Fatalf("user %d, role %d: want: %v, got: %v", tc.userId, tc.roleId, tc.want, err)
+			}
+		}
+	})
+}
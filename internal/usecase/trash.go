@@ -0,0 +1,77 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"forum/internal/entity"
+)
+
+func (pu *PostsUseCase) RestorePost(ctx context.Context, id int64) error {
+	err := pu.repo.Restore(ctx, id)
+	if err != nil {
+		if strings.Contains(err.Error(), NoRowsResultErr) {
+			return entity.ErrPostNotFound
+		}
+		return fmt.Errorf("PostsUseCase - RestorePost - %w", err)
+	}
+	return nil
+}
+
+// GetDeletedPosts lists the trash, the latest deleted first.
+func (pu *PostsUseCase) GetDeletedPosts(ctx context.Context) ([]entity.Post, error) {
+	posts, err := pu.repo.FetchDeleted(ctx)
+	if err != nil {
+		return posts, fmt.Errorf("PostsUseCase - GetDeletedPosts - %w", err)
+	}
+	return posts, nil
+}
+
+// PurgeDeleted removes posts that stayed in the trash longer than
+// retention and returns how many there were.
+func (pu *PostsUseCase) PurgeDeleted(ctx context.Context, retention time.Duration) (int64, error) {
+	before := time.Now().Add(-retention)
+	purged, err := pu.repo.Purge(ctx, before)
+	if err != nil {
+		return purged, fmt.Errorf("PostsUseCase - PurgeDeleted - %w", err)
+	}
+	return purged, nil
+}
+
+func (cu *CommentsUseCase) RestoreComment(ctx context.Context, id int64) error {
+	err := cu.repo.Restore(ctx, id)
+	if err != nil {
+		if strings.Contains(err.Error(), NoRowsResultErr) {
+			return entity.ErrCommentNotFound
+		}
+		return fmt.Errorf("CommentsUseCase - RestoreComment - %w", err)
+	}
+	return nil
+}
+
+// GetDeletedComments lists the trash, the latest deleted first.
+func (cu *CommentsUseCase) GetDeletedComments(ctx context.Context) ([]entity.Comment, error) {
+	comments, err := cu.repo.FetchDeleted(ctx)
+	if err != nil {
+		return comments, fmt.Errorf("CommentsUseCase - GetDeletedComments #1 - %w", err)
+	}
+
+	err = cu.fillAuthors(ctx, comments)
+	if err != nil {
+		return comments, fmt.Errorf("CommentsUseCase - GetDeletedComments #2 - %w", err)
+	}
+	return comments, nil
+}
+
+// PurgeDeleted removes comments that stayed in the trash longer than
+// retention and returns how many there were.
+func (cu *CommentsUseCase) PurgeDeleted(ctx context.Context, retention time.Duration) (int64, error) {
+	before := time.Now().Add(-retention)
+	purged, err := cu.repo.Purge(ctx, before)
+	if err != nil {
+		return purged, fmt.Errorf("CommentsUseCase - PurgeDeleted - %w", err)
+	}
+	return purged, nil
+}
@@ -0,0 +1,319 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"forum/internal/entity"
+	"forum/internal/repository"
+	"forum/pkg/auth"
+	"forum/pkg/totp"
+)
+
+const (
+	// totpSkew is how many periods a code may be early or late.
+	totpSkew = 1
+	// recoveryCodes are given out at once, each works one time.
+	recoveryCodes = 10
+	// maxChallengeAttempts wrong codes end a sign-in, the password has to
+	// be typed again.
+	maxChallengeAttempts = 5
+)
+
+type TwoFactorUseCase struct {
+	repo          repository.TwoFactor
+	challengeRepo repository.LoginChallenges
+	userRepo      repository.Users
+	tokenManager  auth.TokenManager
+	uow           repository.UnitOfWork
+	issuer        string
+	challengeTTL  time.Duration
+}
+
+// NewTwoFactorUseCase adds accounts to authenticator apps under issuer,
+// a sign-in waits challengeTTL for the code after the password.
+func NewTwoFactorUseCase(repo repository.TwoFactor, challengeRepo repository.LoginChallenges,
+	userRepo repository.Users, tokenManager auth.TokenManager, uow repository.UnitOfWork,
+	issuer string, challengeTTL time.Duration,
+) *TwoFactorUseCase {
+	return &TwoFactorUseCase{
+		repo:          repo,
+		challengeRepo: challengeRepo,
+		userRepo:      userRepo,
+		tokenManager:  tokenManager,
+		uow:           uow,
+		issuer:        issuer,
+		challengeTTL:  challengeTTL,
+	}
+}
+
+// Status tells whether the user has two-factor enabled and how many
+// recovery codes are left, the secret is not returned.
+func (tu *TwoFactorUseCase) Status(ctx context.Context, userId int64) (entity.TwoFactor, error) {
+	tf, err := tu.repo.Get(ctx, userId)
+	if err != nil {
+		return tf, fmt.Errorf("TwoFactorUseCase - Status - %w", err)
+	}
+	tf.Secret = ""
+	return tf, nil
+}
+
+// BeginEnrollment gives the secret for the authenticator app and its
+// provisioning URI. The secret is kept until Enable, so showing the page
+// again gives the same one.
+func (tu *TwoFactorUseCase) BeginEnrollment(ctx context.Context, userId int64) (entity.TwoFactorEnrollment,
+	error) {
+	tf, err := tu.repo.Get(ctx, userId)
+	if err != nil {
+		return entity.TwoFactorEnrollment{}, fmt.Errorf("TwoFactorUseCase - BeginEnrollment #1 - %w", err)
+	}
+	if tf.Enabled {
+		return entity.TwoFactorEnrollment{}, entity.ErrTwoFactorEnabled
+	}
+	user, err := tu.userRepo.GetById(ctx, userId)
+	if err != nil {
+		return entity.TwoFactorEnrollment{}, fmt.Errorf("TwoFactorUseCase - BeginEnrollment #2 - %w", err)
+	}
+
+	if tf.Secret == "" {
+		tf.Secret, err = totp.GenerateSecret()
+		if err != nil {
+			return entity.TwoFactorEnrollment{}, fmt.Errorf("TwoFactorUseCase - BeginEnrollment #3 - %w", err)
+		}
+		if err = tu.repo.Update(ctx, tf); err != nil {
+			return entity.TwoFactorEnrollment{}, fmt.Errorf("TwoFactorUseCase - BeginEnrollment #4 - %w", err)
+		}
+	}
+
+	return entity.TwoFactorEnrollment{
+		Secret: tf.Secret,
+		URI:    totp.URI(tu.issuer, user.Name, tf.Secret),
+	}, nil
+}
+
+// Enable turns two-factor on once the code from the app matches the
+// secret of BeginEnrollment. The recovery codes are returned in plain
+// text this one time, only their hashes are stored.
+func (tu *TwoFactorUseCase) Enable(ctx context.Context, userId int64, code string) ([]string, error) {
+	tf, err := tu.repo.Get(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("TwoFactorUseCase - Enable #1 - %w", err)
+	}
+	if tf.Enabled {
+		return nil, entity.ErrTwoFactorEnabled
+	}
+	if tf.Secret == "" {
+		return nil, entity.ErrTwoFactorCodeInvalid
+	}
+	step, ok := totp.Validate(tf.Secret, code, time.Now(), totpSkew)
+	if !ok {
+		return nil, entity.ErrTwoFactorCodeInvalid
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, fmt.Errorf("TwoFactorUseCase - Enable #2 - %w", err)
+	}
+	tf.Enabled = true
+	tf.LastStep = step
+
+	err = tu.uow.Do(ctx, func(repos *repository.Repositories) error {
+		if err := repos.TwoFactor.Update(ctx, tf); err != nil {
+			return fmt.Errorf("TwoFactorUseCase - Enable #3 - %w", err)
+		}
+		if err := repos.TwoFactor.StoreRecoveryCodes(ctx, userId, hashes); err != nil {
+			return fmt.Errorf("TwoFactorUseCase - Enable #4 - %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Disable turns two-factor off for the user who proves they still have
+// it with a code from the app or a recovery code.
+func (tu *TwoFactorUseCase) Disable(ctx context.Context, userId int64, code string) error {
+	tf, err := tu.repo.Get(ctx, userId)
+	if err != nil {
+		return fmt.Errorf("TwoFactorUseCase - Disable #1 - %w", err)
+	}
+	if !tf.Enabled {
+		return entity.ErrTwoFactorDisabled
+	}
+	ok, err := tu.checkCode(ctx, tf, code)
+	if err != nil {
+		return fmt.Errorf("TwoFactorUseCase - Disable #2 - %w", err)
+	}
+	if !ok {
+		return entity.ErrTwoFactorCodeInvalid
+	}
+
+	if err = tu.Reset(ctx, userId); err != nil {
+		return fmt.Errorf("TwoFactorUseCase - Disable #3 - %w", err)
+	}
+	return nil
+}
+
+// Reset turns two-factor off without a code, for admins helping users
+// who lost both the app and the recovery codes. Sign-ins waiting for a
+// code are dropped.
+func (tu *TwoFactorUseCase) Reset(ctx context.Context, userId int64) error {
+	_, err := tu.repo.Get(ctx, userId)
+	if err != nil {
+		if strings.Contains(err.Error(), NoRowsResultErr) {
+			return entity.ErrUserNotFound
+		}
+		return fmt.Errorf("TwoFactorUseCase - Reset #1 - %w", err)
+	}
+
+	return tu.uow.Do(ctx, func(repos *repository.Repositories) error {
+		if err := repos.TwoFactor.Update(ctx, entity.TwoFactor{UserId: userId}); err != nil {
+			return fmt.Errorf("TwoFactorUseCase - Reset #2 - %w", err)
+		}
+		if err := repos.TwoFactor.DeleteRecoveryCodes(ctx, userId); err != nil {
+			return fmt.Errorf("TwoFactorUseCase - Reset #3 - %w", err)
+		}
+		if err := repos.Challenges.DeleteByUser(ctx, userId); err != nil {
+			return fmt.Errorf("TwoFactorUseCase - Reset #4 - %w", err)
+		}
+		return nil
+	})
+}
+
+// Challenge starts the second step of a sign-in of the user whose
+// password was right, the returned token names it in CompleteSignIn.
+// Expired challenges of every user are dropped on the way.
+func (tu *TwoFactorUseCase) Challenge(ctx context.Context, userId int64) (string, error) {
+	token, err := tu.tokenManager.NewToken()
+	if err != nil {
+		return "", fmt.Errorf("TwoFactorUseCase - Challenge #1 - %w", err)
+	}
+	now := time.Now()
+	challenge := entity.LoginChallenge{
+		User:      entity.User{Id: userId},
+		TokenHash: hashToken(token),
+		CreatedAt: now,
+		ExpiresAt: now.Add(tu.challengeTTL),
+	}
+
+	err = tu.uow.Do(ctx, func(repos *repository.Repositories) error {
+		if _, err := repos.Challenges.DeleteExpired(ctx, now); err != nil {
+			return fmt.Errorf("TwoFactorUseCase - Challenge #2 - %w", err)
+		}
+		if err := repos.Challenges.Store(ctx, &challenge); err != nil {
+			return fmt.Errorf("TwoFactorUseCase - Challenge #3 - %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// CompleteSignIn opens the session of the challenge once the code from
+// the app or a recovery code is right. Wrong codes give
+// entity.ErrTwoFactorCodeInvalid, after maxChallengeAttempts of them the
+// challenge is dropped and entity.ErrChallengeInvalid is given as for
+// unknown and expired ones.
+func (tu *TwoFactorUseCase) CompleteSignIn(ctx context.Context, token, code string,
+	session entity.Session,
+) (entity.Session, error) {
+	if token == "" {
+		return session, entity.ErrChallengeInvalid
+	}
+	challenge, err := tu.challengeRepo.GetByHash(ctx, hashToken(token))
+	if err != nil {
+		if strings.Contains(err.Error(), NoRowsResultErr) {
+			return session, entity.ErrChallengeInvalid
+		}
+		return session, fmt.Errorf("TwoFactorUseCase - CompleteSignIn #1 - %w", err)
+	}
+	if tu.tokenManager.CheckTTLExpired(challenge.ExpiresAt) {
+		return session, entity.ErrChallengeInvalid
+	}
+	// the attempt is counted before the code is checked, so requests
+	// racing on one challenge can't try more than maxChallengeAttempts
+	added, err := tu.challengeRepo.AddAttempt(ctx, challenge.Id, maxChallengeAttempts)
+	if err != nil {
+		return session, fmt.Errorf("TwoFactorUseCase - CompleteSignIn #2 - %w", err)
+	}
+	if !added {
+		if err = tu.challengeRepo.Delete(ctx, challenge.Id); err != nil {
+			return session, fmt.Errorf("TwoFactorUseCase - CompleteSignIn #3 - %w", err)
+		}
+		return session, entity.ErrChallengeInvalid
+	}
+
+	tf, err := tu.repo.Get(ctx, challenge.User.Id)
+	if err != nil {
+		return session, fmt.Errorf("TwoFactorUseCase - CompleteSignIn #4 - %w", err)
+	}
+	ok, err := tu.checkCode(ctx, tf, code)
+	if err != nil {
+		return session, fmt.Errorf("TwoFactorUseCase - CompleteSignIn #5 - %w", err)
+	}
+	if !ok {
+		if challenge.Attempts+1 >= maxChallengeAttempts {
+			if err = tu.challengeRepo.Delete(ctx, challenge.Id); err != nil {
+				return session, fmt.Errorf("TwoFactorUseCase - CompleteSignIn #6 - %w", err)
+			}
+			return session, entity.ErrChallengeInvalid
+		}
+		return session, entity.ErrTwoFactorCodeInvalid
+	}
+
+	if err = tu.challengeRepo.Delete(ctx, challenge.Id); err != nil {
+		return session, fmt.Errorf("TwoFactorUseCase - CompleteSignIn #7 - %w", err)
+	}
+	session, err = openSession(ctx, tu.tokenManager, tu.uow, challenge.User.Id, session)
+	if err != nil {
+		return session, fmt.Errorf("TwoFactorUseCase - CompleteSignIn #8 - %w", err)
+	}
+	return session, nil
+}
+
+// checkCode takes a code from the app of the step not used yet, so a
+// code seen over the shoulder doesn't work twice, or a recovery code,
+// which is used up.
+func (tu *TwoFactorUseCase) checkCode(ctx context.Context, tf entity.TwoFactor, code string) (bool, error) {
+	if !tf.Enabled {
+		return false, nil
+	}
+	if step, ok := totp.Validate(tf.Secret, code, time.Now(), totpSkew); ok {
+		return tu.repo.UseStep(ctx, tf.UserId, step)
+	}
+	code = normalizeRecoveryCode(code)
+	if code == "" {
+		return false, nil
+	}
+	return tu.repo.UseRecoveryCode(ctx, tf.UserId, hashToken(code))
+}
+
+// newRecoveryCodes makes codes like "3f9a1-c07d2" and the hashes to
+// store of them.
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodes)
+	hashes := make([]string, recoveryCodes)
+	for i := range codes {
+		raw := make([]byte, 5)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, err
+		}
+		code := hex.EncodeToString(raw)
+		codes[i] = code[:5] + "-" + code[5:]
+		hashes[i] = hashToken(code)
+	}
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode drops the dash and spaces people type or not.
+func normalizeRecoveryCode(code string) string {
+	return strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(code))
+}
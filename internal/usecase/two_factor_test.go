@@ -0,0 +1,343 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"testing"
+	"time"
+
+	"forum/internal/config"
+	"forum/internal/entity"
+	"forum/internal/repository"
+	"forum/internal/repository/memory"
+	"forum/internal/usecase"
+	"forum/pkg/auth"
+	"forum/pkg/totp"
+)
+
+func setupTwoFactorUseCase(repos *repository.Repositories, challengeTTL time.Duration) *usecase.TwoFactorUseCase {
+	cfg, err := config.LoadConfig("../../config.json")
+	if err != nil {
+		log.Fatal(err)
+	}
+	return usecase.NewTwoFactorUseCase(repos.TwoFactor, repos.Challenges, repos.Users, auth.NewManager(cfg),
+		repos.UnitOfWork, "Forum", challengeTTL)
+}
+
+// enableTwoFactor signs user1 up with two-factor on and returns the secret
+// with the recovery codes. The code of the current step is taken.
+func enableTwoFactor(t *testing.T, repos *repository.Repositories, twoFactorUseCase *usecase.TwoFactorUseCase,
+) (string, []string) {
+	t.Helper()
+	ctx := context.Background()
+	if err := setupUserUseCase(repos).SignUp(ctx, user1); err != nil {
+		t.Fatal(err)
+	}
+	enrollment, err := twoFactorUseCase.BeginEnrollment(ctx, user1.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := totp.Code(enrollment.Secret, totp.Step(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	codes, err := twoFactorUseCase.Enable(ctx, user1.Id, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return enrollment.Secret, codes
+}
+
+// nextCode is the code of the step after the current one, it is still
+// taken and not used yet.
+func nextCode(t *testing.T, secret string) string {
+	t.Helper()
+	code, err := totp.Code(secret, totp.Step(time.Now())+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func TestTwoFactorEnable(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		twoFactorUseCase := setupTwoFactorUseCase(repos, time.Minute)
+		secret, codes := enableTwoFactor(t, repos, twoFactorUseCase)
+
+		if len(codes) != 10 {
+			t.Fatalf("want %d recovery codes, got: %v", 10, codes)
+		}
+		tf, err := twoFactorUseCase.Status(ctx, user1.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !tf.Enabled || tf.RecoveryCodes != 10 || tf.Secret != "" {
+			t.Fatalf("want enabled with 10 codes and no secret, got: %+v", tf)
+		}
+		if _, err := twoFactorUseCase.BeginEnrollment(ctx, user1.Id); !errors.Is(err, entity.ErrTwoFactorEnabled) {
+			t.Fatalf("want: %v, got: %v", entity.ErrTwoFactorEnabled, err)
+		}
+		if _, err := twoFactorUseCase.Enable(ctx, user1.Id, nextCode(t, secret)); !errors.Is(err,
+			entity.ErrTwoFactorEnabled) {
+			t.Fatalf("want: %v, got: %v", entity.ErrTwoFactorEnabled, err)
+		}
+	})
+
+	t.Run("OK same secret", func(t *testing.T) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		twoFactorUseCase := setupTwoFactorUseCase(repos, time.Minute)
+		if err := setupUserUseCase(repos).SignUp(ctx, user1); err != nil {
+			t.Fatal(err)
+		}
+
+		first, err := twoFactorUseCase.BeginEnrollment(ctx, user1.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, err := twoFactorUseCase.BeginEnrollment(ctx, user1.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first != second || first.URI != totp.URI("Forum", user1.Name, first.Secret) {
+			t.Fatalf("want the same enrollment, got: %+v, %+v", first, second)
+		}
+	})
+
+	t.Run("err wrong code", func(t *testing.T) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		twoFactorUseCase := setupTwoFactorUseCase(repos, time.Minute)
+		if err := setupUserUseCase(repos).SignUp(ctx, user1); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := twoFactorUseCase.Enable(ctx, user1.Id, "123456"); !errors.Is(err,
+			entity.ErrTwoFactorCodeInvalid) {
+			t.Fatalf("want no enrollment refused, got: %v", err)
+		}
+		enrollment, err := twoFactorUseCase.BeginEnrollment(ctx, user1.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		code, err := totp.Code(enrollment.Secret, totp.Step(time.Now())-5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := twoFactorUseCase.Enable(ctx, user1.Id, code); !errors.Is(err, entity.ErrTwoFactorCodeInvalid) {
+			t.Fatalf("want old code refused, got: %v", err)
+		}
+		if tf, _ := twoFactorUseCase.Status(ctx, user1.Id); tf.Enabled {
+			t.Fatal("want two-factor disabled")
+		}
+	})
+}
+
+func TestTwoFactorSignIn(t *testing.T) {
+	ctx := context.Background()
+	password := entity.User{Email: user1.Email, Password: user1.Password}
+
+	t.Run("OK", func(t *testing.T) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		twoFactorUseCase := setupTwoFactorUseCase(repos, time.Minute)
+		userUseCase := setupUserUseCase(repos)
+		secret, codes := enableTwoFactor(t, repos, twoFactorUseCase)
+
+		session, err := userUseCase.SignIn(ctx, password, entity.Session{UserAgent: "Firefox"})
+		if !errors.Is(err, entity.ErrTwoFactorRequired) {
+			t.Fatalf("want: %v, got: %v", entity.ErrTwoFactorRequired, err)
+		}
+		if session.Token != "" || session.User.Id != user1.Id {
+			t.Fatalf("want no session of user %d yet, got: %+v", user1.Id, session)
+		}
+
+		token, err := twoFactorUseCase.Challenge(ctx, session.User.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		code := nextCode(t, secret)
+		session, err = twoFactorUseCase.CompleteSignIn(ctx, token, code, session)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, auth, err := userUseCase.CheckSession(ctx, session); err != nil || !auth {
+			t.Fatalf("want signed in, got: %v, %v", auth, err)
+		}
+		if _, err := twoFactorUseCase.CompleteSignIn(ctx, token, code, session); !errors.Is(err,
+			entity.ErrChallengeInvalid) {
+			t.Fatalf("want used challenge refused, got: %v", err)
+		}
+
+		token, err = twoFactorUseCase.Challenge(ctx, user1.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := twoFactorUseCase.CompleteSignIn(ctx, token, code, session); !errors.Is(err,
+			entity.ErrTwoFactorCodeInvalid) {
+			t.Fatalf("want used code refused, got: %v", err)
+		}
+		if _, err := twoFactorUseCase.CompleteSignIn(ctx, token, codes[0], session); err != nil {
+			t.Fatal(err)
+		}
+		if tf, _ := twoFactorUseCase.Status(ctx, user1.Id); tf.RecoveryCodes != 9 {
+			t.Fatalf("want %d recovery codes left, got: %d", 9, tf.RecoveryCodes)
+		}
+	})
+
+	t.Run("err recovery code used", func(t *testing.T) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		twoFactorUseCase := setupTwoFactorUseCase(repos, time.Minute)
+		_, codes := enableTwoFactor(t, repos, twoFactorUseCase)
+
+		for i, want := range []error{nil, entity.ErrTwoFactorCodeInvalid} {
+			token, err := twoFactorUseCase.Challenge(ctx, user1.Id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := twoFactorUseCase.CompleteSignIn(ctx, token, codes[1], entity.Session{}); !errors.Is(err,
+				want) {
+				t.Fatalf("attempt %d: want: %v, got: %v", i, want, err)
+			}
+		}
+	})
+
+	t.Run("err too many attempts", func(t *testing.T) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		twoFactorUseCase := setupTwoFactorUseCase(repos, time.Minute)
+		secret, _ := enableTwoFactor(t, repos, twoFactorUseCase)
+
+		token, err := twoFactorUseCase.Challenge(ctx, user1.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 1; i < 5; i++ {
+			if _, err := twoFactorUseCase.CompleteSignIn(ctx, token, "000000", entity.Session{}); !errors.Is(err,
+				entity.ErrTwoFactorCodeInvalid) {
+				t.Fatalf("attempt %d: want: %v, got: %v", i, entity.ErrTwoFactorCodeInvalid, err)
+			}
+		}
+		if _, err := twoFactorUseCase.CompleteSignIn(ctx, token, "000000", entity.Session{}); !errors.Is(err,
+			entity.ErrChallengeInvalid) {
+			t.Fatalf("want: %v, got: %v", entity.ErrChallengeInvalid, err)
+		}
+		if _, err := twoFactorUseCase.CompleteSignIn(ctx, token, nextCode(t, secret), entity.Session{}); !errors.Is(
+			err, entity.ErrChallengeInvalid) {
+			t.Fatalf("want dropped challenge refused, got: %v", err)
+		}
+	})
+
+	t.Run("err too many parallel attempts", func(t *testing.T) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		twoFactorUseCase := setupTwoFactorUseCase(repos, time.Minute)
+		secret, _ := enableTwoFactor(t, repos, twoFactorUseCase)
+
+		token, err := twoFactorUseCase.Challenge(ctx, user1.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		const guesses = 20
+		var wg sync.WaitGroup
+		errs := make(chan error, guesses)
+		for i := 0; i < guesses; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := twoFactorUseCase.CompleteSignIn(ctx, token, "000000", entity.Session{})
+				errs <- err
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		checked := 0
+		for err := range errs {
+			switch {
+			case errors.Is(err, entity.ErrTwoFactorCodeInvalid):
+				checked++
+			case !errors.Is(err, entity.ErrChallengeInvalid):
+				t.Fatalf("want: %v or %v, got: %v", entity.ErrTwoFactorCodeInvalid, entity.ErrChallengeInvalid, err)
+			}
+		}
+		if checked > 5 {
+			t.Fatalf("want at most %d codes checked, got: %d", 5, checked)
+		}
+		if _, err := twoFactorUseCase.CompleteSignIn(ctx, token, nextCode(t, secret), entity.Session{}); !errors.Is(
+			err, entity.ErrChallengeInvalid) {
+			t.Fatalf("want dropped challenge refused, got: %v", err)
+		}
+	})
+
+	t.Run("err expired", func(t *testing.T) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		twoFactorUseCase := setupTwoFactorUseCase(repos, -time.Minute)
+		secret, _ := enableTwoFactor(t, repos, twoFactorUseCase)
+
+		token, err := twoFactorUseCase.Challenge(ctx, user1.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := twoFactorUseCase.CompleteSignIn(ctx, token, nextCode(t, secret), entity.Session{}); !errors.Is(
+			err, entity.ErrChallengeInvalid) {
+			t.Fatalf("want: %v, got: %v", entity.ErrChallengeInvalid, err)
+		}
+	})
+}
+
+func TestTwoFactorDisable(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("OK recovery code", func(t *testing.T) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		twoFactorUseCase := setupTwoFactorUseCase(repos, time.Minute)
+		_, codes := enableTwoFactor(t, repos, twoFactorUseCase)
+
+		if err := twoFactorUseCase.Disable(ctx, user1.Id, "000000"); !errors.Is(err,
+			entity.ErrTwoFactorCodeInvalid) {
+			t.Fatalf("want: %v, got: %v", entity.ErrTwoFactorCodeInvalid, err)
+		}
+		if err := twoFactorUseCase.Disable(ctx, user1.Id, codes[0]); err != nil {
+			t.Fatal(err)
+		}
+		if tf, _ := twoFactorUseCase.Status(ctx, user1.Id); tf.Enabled || tf.RecoveryCodes != 0 {
+			t.Fatalf("want disabled without codes, got: %+v", tf)
+		}
+		if err := twoFactorUseCase.Disable(ctx, user1.Id, codes[1]); !errors.Is(err, entity.ErrTwoFactorDisabled) {
+			t.Fatalf("want: %v, got: %v", entity.ErrTwoFactorDisabled, err)
+		}
+	})
+
+	t.Run("OK admin reset", func(t *testing.T) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		twoFactorUseCase := setupTwoFactorUseCase(repos, time.Minute)
+		userUseCase := setupUserUseCase(repos)
+		enableTwoFactor(t, repos, twoFactorUseCase)
+		token, err := twoFactorUseCase.Challenge(ctx, user1.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := twoFactorUseCase.Reset(ctx, user1.Id); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := twoFactorUseCase.CompleteSignIn(ctx, token, "000000", entity.Session{}); !errors.Is(err,
+			entity.ErrChallengeInvalid) {
+			t.Fatalf("want waiting sign-in dropped, got: %v", err)
+		}
+		if _, err := userUseCase.SignIn(ctx, entity.User{Email: user1.Email, Password: user1.Password},
+			entity.Session{}); err != nil {
+			t.Fatalf("want password enough, got: %v", err)
+		}
+	})
+
+	t.Run("err reset unknown user", func(t *testing.T) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		twoFactorUseCase := setupTwoFactorUseCase(repos, time.Minute)
+
+		if err := twoFactorUseCase.Reset(ctx, 7); !errors.Is(err, entity.ErrUserNotFound) {
+			t.Fatalf("want: %v, got: %v", entity.ErrUserNotFound, err)
+		}
+	})
+}
@@ -1,58 +1,153 @@
 package usecase
 
 import (
+	"context"
+	"io"
+	"time"
+
 	"forum/internal/entity"
 )
 
 type Posts interface {
-	CreatePost(p entity.Post) error
-	GetAllPosts() ([]entity.Post, error)
-	GetPostsByQuery(user entity.User, query string) ([]entity.Post, error)
-	GetById(id int64) (entity.Post, error)
-	GetAllByCategory(category string) ([]entity.Post, error)
-	UpdatePost(post entity.Post) error
-	DeletePost(p entity.Post) error
-	MakeReaction(p entity.Post, command string) error
-	DeleteReaction(post entity.Post, command string) error
-	CreateCategories(categories []string) error
-	GetAllCategories() ([]string, error)
-	GetReactions(id int64, query string) ([]entity.User, error)
+	CreatePost(ctx context.Context, p entity.Post) error
+	GetAllPosts(ctx context.Context) ([]entity.Post, error)
+	GetPostsPage(ctx context.Context, page int) ([]entity.Post, entity.Page, error)
+	GetPostsAfter(ctx context.Context, cursor int64) ([]entity.Post, entity.Page, error)
+	GetPostsByQuery(ctx context.Context, user entity.User, query string) ([]entity.Post, error)
+	GetById(ctx context.Context, id int64) (entity.Post, error)
+	GetAllByCategory(ctx context.Context, categoryId int64) ([]entity.Post, error)
+	GetByCategoryPage(ctx context.Context, categoryId int64, page int) ([]entity.Post, entity.Page, error)
+	GetByCategoryAfter(ctx context.Context, categoryId, cursor int64) ([]entity.Post, entity.Page, error)
+	UpdatePost(ctx context.Context, post entity.Post) error
+	GetRevisions(ctx context.Context, id int64) ([]entity.Revision, error)
+	DiffRevisions(ctx context.Context, id int64, from, to int) (entity.Diff, error)
+	DeletePost(ctx context.Context, p entity.Post) error
+	RestorePost(ctx context.Context, id int64) error
+	GetDeletedPosts(ctx context.Context) ([]entity.Post, error)
+	PurgeDeleted(ctx context.Context, retention time.Duration) (int64, error)
+	MakeReaction(ctx context.Context, p entity.Post, kind string) error
+	DeleteReaction(ctx context.Context, post entity.Post, kind string) error
+	GetReactions(ctx context.Context, id int64, kind string) ([]entity.User, error)
+	Search(ctx context.Context, query string) ([]entity.SearchResult, error)
+}
+
+type Categories interface {
+	CreateCategories(ctx context.Context, categories []entity.Category) error
+	GetCategories(ctx context.Context) ([]entity.Category, error)
+	GetBySlug(ctx context.Context, slug string) (entity.Category, error)
+	RenameCategory(ctx context.Context, id int64, name string) error
+	MergeCategories(ctx context.Context, fromId, intoId int64) error
+	DeleteCategory(ctx context.Context, id, targetId int64) error
+	ReorderCategories(ctx context.Context, positions map[int64]int) error
 }
 
 type Users interface {
-	SignUp(u entity.User) error
-	SignIn(u entity.User) error
-	GetAllUsers() ([]entity.User, error)
-	GetById(id int64) (entity.User, error)
-	GetIdBy(user entity.User) (int64, error)
-	GetSession(id int64) (entity.User, error)
-	CheckSession(u entity.User) (bool, error)
-	UpdateUserInfo(u entity.User, query string) error
-	UpdateSession(u entity.User) error
-	DeleteSession(user entity.User) error
-	DeleteUser(u entity.User) error
+	SignUp(ctx context.Context, u entity.User) error
+	SignIn(ctx context.Context, u entity.User, session entity.Session) (entity.Session, error)
+	GetAllUsers(ctx context.Context) ([]entity.User, error)
+	GetUsersPage(ctx context.Context, page int) ([]entity.User, entity.Page, error)
+	GetUsersAfter(ctx context.Context, cursor int64) ([]entity.User, entity.Page, error)
+	GetById(ctx context.Context, id int64) (entity.User, error)
+	GetIdBy(ctx context.Context, user entity.User) (int64, error)
+	CheckSession(ctx context.Context, session entity.Session) (entity.Session, bool, error)
+	UpdateUserInfo(ctx context.Context, u entity.User, query string) error
+	UpdateSession(ctx context.Context, session entity.Session) error
+	DeleteSession(ctx context.Context, session entity.Session) error
+	GetSessions(ctx context.Context, current entity.Session) ([]entity.Session, error)
+	RevokeSession(ctx context.Context, userId, id int64) error
+	RevokeAllSessions(ctx context.Context, userId int64) error
+	DeleteUser(ctx context.Context, u entity.User) error
+	BanUser(ctx context.Context, id int64) error
+	UnbanUser(ctx context.Context, id int64) error
+}
+
+type Roles interface {
+	GetRoles(ctx context.Context) ([]entity.Role, error)
+	GetRole(ctx context.Context, id int64) (entity.Role, error)
+	CreateRole(ctx context.Context, role entity.Role) error
+	UpdateRole(ctx context.Context, role entity.Role) error
+	DeleteRole(ctx context.Context, id int64) error
+	AssignRole(ctx context.Context, userId, roleId int64) error
+}
+
+type PasswordResets interface {
+	RequestReset(ctx context.Context, email string) error
+	CheckToken(ctx context.Context, token string) error
+	ResetPassword(ctx context.Context, token, password string) error
+}
+
+type Verification interface {
+	SendVerification(ctx context.Context, userId int64) error
+	ChangeEmail(ctx context.Context, userId int64, email string) error
+	Verify(ctx context.Context, token string) error
+}
+
+type TwoFactor interface {
+	Status(ctx context.Context, userId int64) (entity.TwoFactor, error)
+	BeginEnrollment(ctx context.Context, userId int64) (entity.TwoFactorEnrollment, error)
+	Enable(ctx context.Context, userId int64, code string) ([]string, error)
+	Disable(ctx context.Context, userId int64, code string) error
+	Reset(ctx context.Context, userId int64) error
+	Challenge(ctx context.Context, userId int64) (string, error)
+	CompleteSignIn(ctx context.Context, token, code string, session entity.Session) (entity.Session, error)
 }
 
 type Comments interface {
-	WriteComment(c entity.Comment) error
-	GetAllComments(postId int64) ([]entity.Comment, error)
-	UpdateComment(c entity.Comment) error
-	DeleteComment(c entity.Comment) error
-	MakeReaction(c entity.Comment, command string) error
-	DeleteReaction(c entity.Comment, command string) error
-	GetReactions(id int64, query string) ([]entity.User, error)
+	WriteComment(ctx context.Context, c entity.Comment) error
+	GetAllComments(ctx context.Context, postId int64) ([]entity.Comment, error)
+	GetCommentsPage(ctx context.Context, postId int64, page int) ([]entity.Comment, entity.Page, error)
+	GetCommentsAfter(ctx context.Context, postId, cursor int64) ([]entity.Comment, entity.Page, error)
+	GetById(ctx context.Context, id int64) (entity.Comment, error)
+	UpdateComment(ctx context.Context, c entity.Comment) error
+	GetRevisions(ctx context.Context, id int64) ([]entity.Revision, error)
+	DiffRevisions(ctx context.Context, id int64, from, to int) (entity.Diff, error)
+	DeleteComment(ctx context.Context, c entity.Comment) error
+	RestoreComment(ctx context.Context, id int64) error
+	GetDeletedComments(ctx context.Context) ([]entity.Comment, error)
+	PurgeDeleted(ctx context.Context, retention time.Duration) (int64, error)
+	MakeReaction(ctx context.Context, c entity.Comment, kind string) error
+	DeleteReaction(ctx context.Context, c entity.Comment, kind string) error
+	GetReactions(ctx context.Context, id int64, kind string) ([]entity.User, error)
+}
+
+type Backups interface {
+	CreateBackup(ctx context.Context) (entity.Backup, error)
+	GetBackups() ([]entity.Backup, error)
+	GetBackupPath(name string) (string, error)
+	RestoreBackup(ctx context.Context, path string) error
+}
+
+type Archive interface {
+	Export(ctx context.Context, w io.Writer, withPasswords bool) error
+	Import(ctx context.Context, r io.ReaderAt, size int64) (entity.ImportSummary, error)
 }
 
 type UseCases struct {
-	Posts    Posts
-	Users    Users
-	Comments Comments
+	Posts        Posts
+	Categories   Categories
+	Users        Users
+	Roles        Roles
+	Resets       PasswordResets
+	Verification Verification
+	TwoFactor    TwoFactor
+	Comments     Comments
+	Backups      Backups
+	Archive      Archive
 }
 
-func NewUseCases(posts Posts, users Users, comments Comments) *UseCases {
+func NewUseCases(posts Posts, categories Categories, users Users, roles Roles, resets PasswordResets,
+	verification Verification, twoFactor TwoFactor, comments Comments, backups Backups, archive Archive,
+) *UseCases {
 	return &UseCases{
-		Posts:    posts,
-		Users:    users,
-		Comments: comments,
+		Posts:        posts,
+		Categories:   categories,
+		Users:        users,
+		Roles:        roles,
+		Resets:       resets,
+		Verification: verification,
+		TwoFactor:    twoFactor,
+		Comments:     comments,
+		Backups:      backups,
+		Archive:      archive,
 	}
 }
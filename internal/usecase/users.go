@@ -1,6 +1,7 @@
 package usecase
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
@@ -17,11 +18,18 @@ type UsersUseCase struct {
 	tokenManager auth.TokenManager
 	postRepo     repository.Posts
 	commentRepo  repository.Comments
+	reactionRepo repository.Reactions
+	sessionRepo  repository.Sessions
+	roleRepo     repository.Roles
+	uow          repository.UnitOfWork
+	kinds        entity.ReactionKinds
 }
 
 func NewUsersUseCase(repo repository.Users, hasher hasher.PasswordHasher,
 	tokenManager auth.TokenManager, postsRepo repository.Posts,
-	commentsRepo repository.Comments,
+	commentsRepo repository.Comments, reactionsRepo repository.Reactions,
+	sessionsRepo repository.Sessions, rolesRepo repository.Roles, uow repository.UnitOfWork,
+	kinds entity.ReactionKinds,
 ) *UsersUseCase {
 	return &UsersUseCase{
 		repo:         repo,
@@ -29,19 +37,40 @@ func NewUsersUseCase(repo repository.Users, hasher hasher.PasswordHasher,
 		tokenManager: tokenManager,
 		postRepo:     postsRepo,
 		commentRepo:  commentsRepo,
+		reactionRepo: reactionsRepo,
+		sessionRepo:  sessionsRepo,
+		roleRepo:     rolesRepo,
+		uow:          uow,
+		kinds:        kinds,
 	}
 }
 
-func (uu *UsersUseCase) SignUp(user entity.User) error {
+// SignUp stores a new user with the default role. The first user of a
+// forum without admins gets the admin role instead.
+func (uu *UsersUseCase) SignUp(ctx context.Context, user entity.User) error {
 	hashed, err := uu.hasher.Hash(user.Password)
 	if err != nil {
 		return fmt.Errorf("UsersUseCase - SignUp #1 - %w", err)
 	}
 	user.Password = hashed
 
-	user.RegDate = getRegTime(DateFormat)
+	user.RegDate = time.Now()
 
-	err = uu.repo.Store(user)
+	err = uu.uow.Do(ctx, func(repos *repository.Repositories) error {
+		err := repos.Users.Store(ctx, user)
+		if err != nil {
+			return err
+		}
+		admins, err := repos.Roles.CountUsers(ctx, entity.RoleAdminId)
+		if err != nil || admins > 0 {
+			return err
+		}
+		id, err := repos.Users.GetId(ctx, user)
+		if err != nil {
+			return err
+		}
+		return repos.Users.SetRole(ctx, id, entity.RoleAdminId)
+	})
 	if err != nil {
 		if strings.Contains(err.Error(), UniqueEmailErr) {
 			return entity.ErrUserEmailAlreadyExists
@@ -55,45 +84,77 @@ func (uu *UsersUseCase) SignUp(user entity.User) error {
 	return nil
 }
 
-func (uu *UsersUseCase) SignIn(user entity.User) error {
-	id, err := uu.repo.GetId(user)
+// SignIn checks the password and opens a new session for the browser the
+// session describes, sessions of other devices stay signed in. Expired
+// sessions of every user are dropped on the way. Users with two-factor
+// enabled get entity.ErrTwoFactorRequired and no session yet, only the
+// User.Id of the returned session is set. Banned users get
+// entity.ErrUserBanned.
+func (uu *UsersUseCase) SignIn(ctx context.Context, user entity.User, session entity.Session) (entity.Session,
+	error) {
+	id, err := uu.repo.GetId(ctx, user)
 
 	if id == 0 {
-		return entity.ErrUserNotFound
+		return session, entity.ErrUserNotFound
 	}
 
 	if err != nil {
-		return fmt.Errorf("UsersUseCase - SignIn #1 - %w", err)
+		return session, fmt.Errorf("UsersUseCase - SignIn #1 - %w", err)
 	}
 
-	existUserInfo, err := uu.GetById(id)
+	existUserInfo, err := uu.GetById(ctx, id)
 	if err != nil {
-		return fmt.Errorf("UsersUseCase - SignIn #2 - %w", err)
+		return session, fmt.Errorf("UsersUseCase - SignIn #2 - %w", err)
 	}
 
 	err = uu.hasher.CheckPassword(existUserInfo.Password, user.Password)
 	if err != nil {
-		return entity.ErrUserPasswordIncorrect
+		return session, entity.ErrUserPasswordIncorrect
 	}
-	token, err := uu.tokenManager.NewToken()
-	if err != nil {
-		return fmt.Errorf("UsersUseCase - SignIn #3 - %w", err)
+	if existUserInfo.Banned {
+		return session, entity.ErrUserBanned
+	}
+	if existUserInfo.TwoFactor {
+		session.User = entity.User{Id: id}
+		return session, entity.ErrTwoFactorRequired
 	}
 
-	user.SessionToken = token
-	TTL := uu.tokenManager.UpdateTTL()
-	user.SessionTTL = TTL
-	user.Id = id
+	session, err = openSession(ctx, uu.tokenManager, uu.uow, id, session)
+	if err != nil {
+		return session, fmt.Errorf("UsersUseCase - SignIn #3 - %w", err)
+	}
+	return session, nil
+}
 
-	err = uu.repo.NewSession(user)
+// openSession stores a new session of the user for the browser the
+// session describes and drops expired sessions of every user.
+func openSession(ctx context.Context, tokenManager auth.TokenManager, uow repository.UnitOfWork, userId int64,
+	session entity.Session,
+) (entity.Session, error) {
+	token, err := tokenManager.NewToken()
 	if err != nil {
-		return fmt.Errorf("UsersUseCase - SignIn #4 - %w", err)
+		return session, err
 	}
-	return nil
+
+	now := time.Now()
+	session.User = entity.User{Id: userId}
+	session.Token = token
+	session.CreatedAt = now
+	session.LastSeen = now
+	session.ExpiresAt = tokenManager.UpdateTTL()
+
+	err = uow.Do(ctx, func(repos *repository.Repositories) error {
+		_, err := repos.Sessions.DeleteExpired(ctx, now)
+		if err != nil {
+			return err
+		}
+		return repos.Sessions.Store(ctx, &session)
+	})
+	return session, err
 }
 
-func (uu *UsersUseCase) GetIdBy(user entity.User) (int64, error) {
-	id, err := uu.repo.GetId(user)
+func (uu *UsersUseCase) GetIdBy(ctx context.Context, user entity.User) (int64, error) {
+	id, err := uu.repo.GetId(ctx, user)
 	if id == 0 {
 		return 0, entity.ErrUserNotFound
 	}
@@ -105,10 +166,40 @@ func (uu *UsersUseCase) GetIdBy(user entity.User) (int64, error) {
 	return id, nil
 }
 
-func (uu *UsersUseCase) UpdateSession(user entity.User) error {
-	TTL := uu.tokenManager.UpdateTTL()
-	user.SessionTTL = TTL
-	err := uu.UpdateUserInfo(user, UpdateSessionQuery)
+// CheckSession looks the session up by its token and tells whether it is
+// still alive. The stored session is returned with the address of the
+// session asked about and the role of its user with the permissions,
+// unknown tokens and sessions of banned users are not alive.
+func (uu *UsersUseCase) CheckSession(ctx context.Context, session entity.Session) (entity.Session, bool, error) {
+	if session.Token == "" {
+		return session, false, nil
+	}
+	stored, err := uu.sessionRepo.GetByToken(ctx, session.Token)
+	if err != nil {
+		if strings.Contains(err.Error(), NoRowsResultErr) {
+			return session, false, nil
+		}
+		return session, false, fmt.Errorf("UsersUseCase - CheckSession #1 - %w", err)
+	}
+	if session.IP != "" {
+		stored.IP = session.IP
+	}
+	if stored.User.Banned {
+		return stored, false, nil
+	}
+	stored.User.Role, err = uu.roleRepo.GetById(ctx, stored.User.Role.Id)
+	if err != nil {
+		return session, false, fmt.Errorf("UsersUseCase - CheckSession #2 - %w", err)
+	}
+
+	return stored, !uu.tokenManager.CheckTTLExpired(stored.ExpiresAt), nil
+}
+
+// UpdateSession marks the session seen now and moves its expiry on.
+func (uu *UsersUseCase) UpdateSession(ctx context.Context, session entity.Session) error {
+	session.LastSeen = time.Now()
+	session.ExpiresAt = uu.tokenManager.UpdateTTL()
+	err := uu.sessionRepo.Update(ctx, session)
 	if err != nil {
 		return fmt.Errorf("UsersUseCase - UpdateSession - %w", err)
 	}
@@ -116,63 +207,111 @@ func (uu *UsersUseCase) UpdateSession(user entity.User) error {
 	return nil
 }
 
-func (uu *UsersUseCase) DeleteSession(user entity.User) error {
-	timeNow := time.Now()
-	formatted := timeNow.Format(DateAndTimeFormat)
-	parsed, err := time.Parse(DateAndTimeFormat, formatted)
+// DeleteSession signs the browser of the session out.
+func (uu *UsersUseCase) DeleteSession(ctx context.Context, session entity.Session) error {
+	err := uu.sessionRepo.Delete(ctx, session.Id)
 	if err != nil {
-		return fmt.Errorf("UsersUseCase - DeleteSession #1 - %w", err)
+		return fmt.Errorf("UsersUseCase - DeleteSession - %w", err)
 	}
-	user.SessionTTL = parsed
-	err = uu.UpdateUserInfo(user, UpdateSessionQuery)
+
+	return nil
+}
+
+// GetSessions lists the sessions of the user of the current session, the
+// last seen first, and marks the current one.
+func (uu *UsersUseCase) GetSessions(ctx context.Context, current entity.Session) ([]entity.Session, error) {
+	sessions, err := uu.sessionRepo.FetchByUser(ctx, current.User.Id)
 	if err != nil {
-		return fmt.Errorf("UsersUseCase - DeleteSession #2 - %w", err)
+		return nil, fmt.Errorf("UsersUseCase - GetSessions - %w", err)
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].Id == current.Id
 	}
 
-	return nil
+	return sessions, nil
 }
 
-func (uu *UsersUseCase) GetSession(id int64) (entity.User, error) {
-	var user entity.User
-	user, err := uu.repo.GetSession(id)
+// RevokeSession signs one of the devices of the user out, sessions of
+// other users are not found.
+func (uu *UsersUseCase) RevokeSession(ctx context.Context, userId, id int64) error {
+	sessions, err := uu.sessionRepo.FetchByUser(ctx, userId)
 	if err != nil {
-		if strings.Contains(err.Error(), NoRowsResultErr) {
-			return user, entity.ErrUserNotFound
+		return fmt.Errorf("UsersUseCase - RevokeSession #1 - %w", err)
+	}
+	for _, session := range sessions {
+		if session.Id != id {
+			continue
 		}
-		return user, fmt.Errorf("UsersUseCase - GetSession - %w", err)
+		err = uu.sessionRepo.Delete(ctx, id)
+		if err != nil {
+			return fmt.Errorf("UsersUseCase - RevokeSession #2 - %w", err)
+		}
+		return nil
 	}
-	user.Id = id
-	return user, nil
+
+	return entity.ErrSessionNotFound
 }
 
-func (uu *UsersUseCase) CheckSession(user entity.User) (bool, error) {
-	if user.Id == 0 {
-		return false, nil
+// RevokeAllSessions signs the user out everywhere.
+func (uu *UsersUseCase) RevokeAllSessions(ctx context.Context, userId int64) error {
+	err := uu.sessionRepo.DeleteByUser(ctx, userId)
+	if err != nil {
+		return fmt.Errorf("UsersUseCase - RevokeAllSessions - %w", err)
 	}
-	existUserInfo, err := uu.GetSession(user.Id)
+
+	return nil
+}
+
+func (uu *UsersUseCase) GetAllUsers(ctx context.Context) ([]entity.User, error) {
+	users, err := uu.repo.Fetch(ctx)
+	markGenders(users)
 	if err != nil {
-		return false, fmt.Errorf("UsersUseCase - CheckSession #1 - %w", err)
+		return nil, fmt.Errorf("UsersUseCase - GetAllUsers - %w", err)
 	}
 
-	// check token
-	if existUserInfo.SessionToken != user.SessionToken {
-		return false, nil
+	return users, nil
+}
+
+func (uu *UsersUseCase) GetUsersPage(ctx context.Context, number int) ([]entity.User, entity.Page, error) {
+	total, err := uu.repo.Count(ctx)
+	if err != nil {
+		return nil, entity.Page{}, fmt.Errorf("UsersUseCase - GetUsersPage #1 - %w", err)
 	}
+	page, offset := pageWindow(number, UsersPerPage, total)
 
-	// check token life time
-	expired, err := uu.tokenManager.CheckTTLExpired(existUserInfo.SessionTTL)
+	users, err := uu.repo.FetchPage(ctx, UsersPerPage, offset)
 	if err != nil {
-		if strings.Contains(err.Error(), NoRowsResultErr) {
-			return false, entity.ErrUserNotFound
-		}
-		return false, fmt.Errorf("UsersUseCase - CheckSession #2 - %w", err)
+		return nil, page, fmt.Errorf("UsersUseCase - GetUsersPage #2 - %w", err)
 	}
+	markGenders(users)
 
-	return !expired, nil
+	return users, page, nil
 }
 
-func (uu *UsersUseCase) GetAllUsers() ([]entity.User, error) {
-	users, err := uu.repo.Fetch()
+func (uu *UsersUseCase) GetUsersAfter(ctx context.Context, cursor int64) ([]entity.User, entity.Page, error) {
+	total, err := uu.repo.Count(ctx)
+	if err != nil {
+		return nil, entity.Page{}, fmt.Errorf("UsersUseCase - GetUsersAfter #1 - %w", err)
+	}
+
+	users, err := uu.repo.FetchAfter(ctx, cursor, UsersPerPage+1)
+	if err != nil {
+		return nil, entity.Page{}, fmt.Errorf("UsersUseCase - GetUsersAfter #2 - %w", err)
+	}
+	fetched := len(users)
+	if fetched > UsersPerPage {
+		users = users[:UsersPerPage]
+	}
+	var lastId int64
+	if len(users) != 0 {
+		lastId = users[len(users)-1].Id
+	}
+	markGenders(users)
+
+	return users, cursorPage(UsersPerPage, fetched, lastId, total), nil
+}
+
+func markGenders(users []entity.User) {
 	for i := 0; i < len(users); i++ {
 		if users[i].Gender == UserGenderMale {
 			users[i].Male = true
@@ -180,21 +319,16 @@ func (uu *UsersUseCase) GetAllUsers() ([]entity.User, error) {
 			users[i].Female = true
 		}
 	}
-	if err != nil {
-		return nil, fmt.Errorf("UsersUseCase - GetAllUsers - %w", err)
-	}
-
-	return users, nil
 }
 
-func (uu *UsersUseCase) GetById(id int64) (entity.User, error) {
+func (uu *UsersUseCase) GetById(ctx context.Context, id int64) (entity.User, error) {
 	var user entity.User
-	user, err := uu.repo.GetById(id)
+	user, err := uu.repo.GetById(ctx, id)
 	if err != nil {
 		if strings.Contains(err.Error(), NoRowsResultErr) {
 			return user, entity.ErrUserNotFound
 		}
-		return user, fmt.Errorf("UsersUseCase - GetById - %w", err)
+		return user, fmt.Errorf("UsersUseCase - GetById #1 - %w", err)
 	}
 	user.Id = id
 	if user.Gender == UserGenderMale {
@@ -202,13 +336,28 @@ func (uu *UsersUseCase) GetById(id int64) (entity.User, error) {
 	} else if user.Gender == UserGenderFemale {
 		user.Female = true
 	}
+
+	postReactions, err := uu.reactionRepo.CountByUser(ctx, entity.ReactionTargetPost, id)
+	if err != nil {
+		return user, fmt.Errorf("UsersUseCase - GetById #2 - %w", err)
+	}
+	user.PostReactions = uu.kinds.Counts(postReactions)
+	commentReactions, err := uu.reactionRepo.CountByUser(ctx, entity.ReactionTargetComment, id)
+	if err != nil {
+		return user, fmt.Errorf("UsersUseCase - GetById #3 - %w", err)
+	}
+	user.CommentReactions = uu.kinds.Counts(commentReactions)
 	return user, nil
 }
 
-func (uu *UsersUseCase) UpdateUserInfo(user entity.User, query string) error {
+func (uu *UsersUseCase) UpdateUserInfo(ctx context.Context, user entity.User, query string) error {
 	switch query {
 	case UpdateInfoQuery:
-		err := uu.repo.UpdateInfo(user)
+		// an empty timezone leaves dates in the forum default
+		if _, err := time.LoadLocation(user.Timezone); err != nil {
+			return fmt.Errorf("UsersUseCase - UpdateUserInfo - %w", entity.ErrUnknownTimezone)
+		}
+		err := uu.repo.UpdateInfo(ctx, user)
 		if err != nil {
 			return fmt.Errorf("UsersUseCase - UpdateUserInfo #1 - %w", err)
 		}
@@ -218,29 +367,112 @@ func (uu *UsersUseCase) UpdateUserInfo(user entity.User, query string) error {
 			return fmt.Errorf("UsersUseCase - UpdateUserInfo #2 - %w", err)
 		}
 		user.Password = hashed
-		err = uu.repo.UpdatePassword(user)
+		err = uu.repo.UpdatePassword(ctx, user)
 		if err != nil {
 			return fmt.Errorf("UsersUseCase - UpdateUserInfo #3 - %w", err)
 		}
-	case UpdateSessionQuery:
-		err := uu.repo.UpdateSession(user)
-		if err != nil {
-			return fmt.Errorf("UsersUseCase - UpdateUserInfo #4 - %w", err)
-		}
 	}
 
 	return nil
 }
 
-func (uu *UsersUseCase) DeleteUser(u entity.User) error {
-	err := uu.repo.Delete(u)
+// DeleteUser removes the user together with everything they left: posts
+// with the discussions under them, comments, revisions, reactions,
+// sessions, password resets and two-factor recovery codes and sign-ins.
+// Replies to their comments stay as top level comments. The last admin
+// can't be deleted.
+func (uu *UsersUseCase) DeleteUser(ctx context.Context, u entity.User) error {
+	return uu.uow.Do(ctx, func(repos *repository.Repositories) error {
+		err := keepAdmin(ctx, repos, u.Id)
+		if err != nil {
+			return err
+		}
+		err = repos.Reactions.DeleteByUser(ctx, u.Id)
+		if err != nil {
+			return fmt.Errorf("UsersUseCase - DeleteUser #1 - %w", err)
+		}
+		err = repos.Sessions.DeleteByUser(ctx, u.Id)
+		if err != nil {
+			return fmt.Errorf("UsersUseCase - DeleteUser #2 - %w", err)
+		}
+		err = repos.TwoFactor.DeleteRecoveryCodes(ctx, u.Id)
+		if err != nil {
+			return fmt.Errorf("UsersUseCase - DeleteUser #3 - %w", err)
+		}
+		err = repos.Challenges.DeleteByUser(ctx, u.Id)
+		if err != nil {
+			return fmt.Errorf("UsersUseCase - DeleteUser #4 - %w", err)
+		}
+		err = repos.Resets.DeleteByUser(ctx, u.Id)
+		if err != nil {
+			return fmt.Errorf("UsersUseCase - DeleteUser #5 - %w", err)
+		}
+		err = repos.Posts.PurgeByUser(ctx, u.Id)
+		if err != nil {
+			return fmt.Errorf("UsersUseCase - DeleteUser #6 - %w", err)
+		}
+		err = repos.Comments.PurgeByUser(ctx, u.Id)
+		if err != nil {
+			return fmt.Errorf("UsersUseCase - DeleteUser #7 - %w", err)
+		}
+		err = repos.Revisions.DeleteByUser(ctx, u.Id)
+		if err != nil {
+			return fmt.Errorf("UsersUseCase - DeleteUser #8 - %w", err)
+		}
+		err = repos.Users.Delete(ctx, u)
+		if err != nil {
+			return fmt.Errorf("UsersUseCase - DeleteUser #9 - %w", err)
+		}
+		return nil
+	})
+}
+
+// BanUser signs the user out everywhere and keeps them from signing in
+// again. Users who may ban others can't be banned.
+func (uu *UsersUseCase) BanUser(ctx context.Context, id int64) error {
+	return uu.uow.Do(ctx, func(repos *repository.Repositories) error {
+		user, err := repos.Users.GetById(ctx, id)
+		if err != nil {
+			if strings.Contains(err.Error(), NoRowsResultErr) {
+				return entity.ErrUserNotFound
+			}
+			return fmt.Errorf("UsersUseCase - BanUser #1 - %w", err)
+		}
+		role, err := repos.Roles.GetById(ctx, user.Role.Id)
+		if err != nil {
+			return fmt.Errorf("UsersUseCase - BanUser #2 - %w", err)
+		}
+		if role.Can(entity.PermBanUsers) {
+			return entity.ErrBanNotAllowed
+		}
+		err = repos.Users.SetBanned(ctx, id, true)
+		if err != nil {
+			return fmt.Errorf("UsersUseCase - BanUser #3 - %w", err)
+		}
+		err = repos.Sessions.DeleteByUser(ctx, id)
+		if err != nil {
+			return fmt.Errorf("UsersUseCase - BanUser #4 - %w", err)
+		}
+		err = repos.Challenges.DeleteByUser(ctx, id)
+		if err != nil {
+			return fmt.Errorf("UsersUseCase - BanUser #5 - %w", err)
+		}
+		return nil
+	})
+}
+
+// UnbanUser lets a banned user sign in again.
+func (uu *UsersUseCase) UnbanUser(ctx context.Context, id int64) error {
+	_, err := uu.repo.GetById(ctx, id)
 	if err != nil {
-		return fmt.Errorf("UsersUseCase - DeleteUser - %w", err)
+		if strings.Contains(err.Error(), NoRowsResultErr) {
+			return entity.ErrUserNotFound
+		}
+		return fmt.Errorf("UsersUseCase - UnbanUser #1 - %w", err)
+	}
+	err = uu.repo.SetBanned(ctx, id, false)
+	if err != nil {
+		return fmt.Errorf("UsersUseCase - UnbanUser #2 - %w", err)
 	}
 	return nil
 }
-
-func getRegTime(format string) string {
-	timeNow := time.Now()
-	return timeNow.Format(format)
-}
@@ -1,14 +1,17 @@
 package usecase_test
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"log"
 	"testing"
 	"time"
 
 	"forum/internal/config"
 	"forum/internal/entity"
-	m "forum/internal/repository/sqlite/mock"
+	"forum/internal/repository"
+	"forum/internal/repository/memory"
 	"forum/internal/usecase"
 	"forum/pkg/auth"
 	"forum/pkg/hasher"
@@ -16,6 +19,9 @@ import (
 
 var errNoRows = "no rows in result set"
 
+// Ids follow the order users are signed up in: user1, user4, user5.
+// user2 and user3 clash with user1 and are never stored. user1 is the
+// first user and gets the admin role.
 var (
 	user1 = entity.User{
 		Id:       1,
@@ -23,43 +29,43 @@ var (
 		Email:    "Riddle@mail.ru",
 		Password: "Vivse",
 		City:     "Astana",
-		Role:     "Пользователь",
+		Role:     entity.Role{Id: entity.RoleAdminId, Name: "Администратор"},
 	}
 	user2 = entity.User{
-		Id:       2,
+		Id:       4,
 		Name:     "Riddle",
 		Email:    "Subi@mail.ru",
 		City:     "Karaganda",
 		Password: "azh",
-		Role:     "Пользователь",
+		Role:     entity.Role{Id: entity.RoleUserId, Name: "Пользователь"},
 	}
 	user3 = entity.User{
-		Id:       3,
+		Id:       5,
 		Name:     "Isa",
 		Email:    "Riddle@mail.ru",
 		Password: "Mimi",
 		City:     "Karaganda",
-		Role:     "Пользователь",
+		Role:     entity.Role{Id: entity.RoleUserId, Name: "Пользователь"},
 	}
 
 	user4 = entity.User{
-		Id:       4,
+		Id:       2,
 		Name:     "Makaron",
 		Email:    "Makaron@mail.ru",
 		Password: "Mimi",
-		Role:     "Пользователь",
+		Role:     entity.Role{Id: entity.RoleUserId, Name: "Пользователь"},
 	}
 
 	user5 = entity.User{
-		Id:       5,
+		Id:       3,
 		Name:     "Spaget",
 		Email:    "Spaget@mail.ru",
 		Password: "Mimi",
-		Role:     "Пользователь",
+		Role:     entity.Role{Id: entity.RoleUserId, Name: "Пользователь"},
 	}
 )
 
-func setupUserUseCase(mockRepo *m.MockRepos) *usecase.UsersUseCase {
+func setupUserUseCase(repos *repository.Repositories) *usecase.UsersUseCase {
 	cfg, err := config.LoadConfig("../../config.json")
 	if err != nil {
 		log.Fatal(err)
@@ -67,23 +73,44 @@ func setupUserUseCase(mockRepo *m.MockRepos) *usecase.UsersUseCase {
 	hasher := hasher.NewBcryptHasher()
 	tokenManager := auth.NewManager(cfg)
 
-	userUseCase := usecase.NewUsersUseCase(mockRepo.Users, hasher, tokenManager,
-		mockRepo.Posts, mockRepo.Comments)
+	userUseCase := usecase.NewUsersUseCase(repos.Users, hasher, tokenManager,
+		repos.Posts, repos.Comments, repos.Reactions, repos.Sessions, repos.Roles, repos.UnitOfWork,
+		entity.DefaultReactionKinds)
 	return userUseCase
 }
 
+// signIn signs user1 in from the device named by agent.
+func signIn(t *testing.T, userUseCase *usecase.UsersUseCase, agent string) entity.Session {
+	t.Helper()
+	session, err := userUseCase.SignIn(context.Background(), user1, entity.Session{UserAgent: agent, IP: "10.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return session
+}
+
+func getSessions(t *testing.T, repos *repository.Repositories, id int64) []entity.Session {
+	t.Helper()
+	sessions, err := repos.Sessions.FetchByUser(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sessions
+}
+
 func TestSignUp(t *testing.T) {
-	mockRepo := m.NewMockRepos()
-	userUseCase := setupUserUseCase(mockRepo)
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories(memory.New())
+	userUseCase := setupUserUseCase(repos)
 
 	t.Run("OK", func(t *testing.T) {
-		if err := userUseCase.SignUp(user1); err != nil {
+		if err := userUseCase.SignUp(ctx, user1); err != nil {
 			t.Fatal(err)
 		}
 	})
 
 	t.Run("err name already exist", func(t *testing.T) {
-		if err := userUseCase.SignUp(user2); err == nil {
+		if err := userUseCase.SignUp(ctx, user2); err == nil {
 			t.Fatal("Expected error")
 		} else if !errors.Is(err, entity.ErrUserNameAlreadyExists) {
 			t.Fatalf("want: %v, got: %v", entity.ErrUserNameAlreadyExists, err)
@@ -91,7 +118,7 @@ func TestSignUp(t *testing.T) {
 	})
 
 	t.Run("err email already exist", func(t *testing.T) {
-		if err := userUseCase.SignUp(user3); err == nil {
+		if err := userUseCase.SignUp(ctx, user3); err == nil {
 			t.Fatal("Expected error")
 		} else if !errors.Is(err, entity.ErrUserEmailAlreadyExists) {
 			t.Fatalf("want: %v, got: %v", entity.ErrUserEmailAlreadyExists, err)
@@ -99,25 +126,76 @@ func TestSignUp(t *testing.T) {
 	})
 }
 
+func TestSignUpFirstAdmin(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories(memory.New())
+	userUseCase := setupUserUseCase(repos)
+
+	for _, user := range []entity.User{user1, user4, user5} {
+		if err := userUseCase.SignUp(ctx, user); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for id, want := range map[int64]int64{1: entity.RoleAdminId, 2: entity.RoleUserId, 3: entity.RoleUserId} {
+		if found, err := userUseCase.GetById(ctx, id); err != nil {
+			t.Fatal(err)
+		} else if found.Role.Id != want {
+			t.Fatalf("user %d: want role %d, got: %d", id, want, found.Role.Id)
+		}
+	}
+}
+
 func TestSignIn(t *testing.T) {
-	mockRepo := m.NewMockRepos()
-	userUseCase := setupUserUseCase(mockRepo)
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories(memory.New())
+	userUseCase := setupUserUseCase(repos)
 
 	t.Run("OK", func(t *testing.T) {
-		if err := userUseCase.SignUp(user1); err != nil {
+		if err := userUseCase.SignUp(ctx, user1); err != nil {
 			t.Fatal(err)
 		}
 
-		if err := userUseCase.SignIn(entity.User{Name: "Riddle", Password: "Vivse"}); err != nil {
+		laptop, err := userUseCase.SignIn(ctx, entity.User{Name: "Riddle", Password: "Vivse"},
+			entity.Session{UserAgent: "Firefox"})
+		if err != nil {
 			t.Fatal(err)
 		}
-		if err := userUseCase.SignIn(entity.User{Email: "Riddle@mail.ru", Password: "Vivse"}); err != nil {
+		phone, err := userUseCase.SignIn(ctx, entity.User{Email: "Riddle@mail.ru", Password: "Vivse"},
+			entity.Session{UserAgent: "Safari"})
+		if err != nil {
 			t.Fatal(err)
 		}
+		if laptop.Token == phone.Token || phone.User.Id != user1.Id {
+			t.Fatalf("want two sessions of user %d, got: %+v, %+v", user1.Id, laptop, phone)
+		}
+
+		for _, session := range []entity.Session{laptop, phone} {
+			if _, auth, err := userUseCase.CheckSession(ctx, session); err != nil {
+				t.Fatal(err)
+			} else if !auth {
+				t.Fatalf("want %s signed in", session.UserAgent)
+			}
+		}
+	})
+
+	t.Run("OK expired dropped", func(t *testing.T) {
+		expired := entity.Session{User: entity.User{Id: user1.Id}, Token: "expired",
+			ExpiresAt: time.Now().Add(-time.Hour)}
+		if err := repos.Sessions.Store(ctx, &expired); err != nil {
+			t.Fatal(err)
+		}
+		signIn(t, userUseCase, "Chrome")
+
+		if _, err := repos.Sessions.GetByToken(ctx, "expired"); err == nil {
+			t.Fatal("want expired session dropped")
+		}
+		if found := getSessions(t, repos, user1.Id); len(found) != 3 {
+			t.Fatalf("want: %d, got: %d", 3, len(found))
+		}
 	})
 
 	t.Run("err user not exist", func(t *testing.T) {
-		if err := userUseCase.SignIn(user3); err == nil {
+		if _, err := userUseCase.SignIn(ctx, user3, entity.Session{}); err == nil {
 			t.Fatal("Expected error")
 		} else if !errors.Is(err, entity.ErrUserNotFound) {
 			t.Fatalf("want err: %v, got: %v", entity.ErrUserNotFound, err)
@@ -125,7 +203,8 @@ func TestSignIn(t *testing.T) {
 	})
 
 	t.Run("err wrong password", func(t *testing.T) {
-		if err := userUseCase.SignIn(entity.User{Name: "Riddle", Password: "abc"}); err == nil {
+		if _, err := userUseCase.SignIn(ctx, entity.User{Name: "Riddle", Password: "abc"},
+			entity.Session{}); err == nil {
 			t.Fatal("Expected error")
 		} else if !errors.Is(err, entity.ErrUserPasswordIncorrect) {
 			t.Fatalf("want err: %v, got: %v", entity.ErrUserPasswordIncorrect, err)
@@ -134,33 +213,22 @@ func TestSignIn(t *testing.T) {
 }
 
 func TestUsersGetIdBy(t *testing.T) {
-	mockRepo := m.NewMockRepos()
-	userUseCase := setupUserUseCase(mockRepo)
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories(memory.New())
+	userUseCase := setupUserUseCase(repos)
 
 	t.Run("OK", func(t *testing.T) {
-		if err := userUseCase.SignUp(user1); err != nil {
-			t.Fatal(err)
-		}
-
-		if id, err := userUseCase.GetIdBy(entity.User{Name: "Riddle"}); err != nil {
+		if err := userUseCase.SignUp(ctx, user1); err != nil {
 			t.Fatal(err)
-		} else if id != user1.Id {
-			t.Fatalf("want id: %d, got: %d", user1.Id, id)
 		}
 
-		if id, err := userUseCase.GetIdBy(entity.User{Email: "Riddle@mail.ru"}); err != nil {
+		if id, err := userUseCase.GetIdBy(ctx, entity.User{Name: "Riddle"}); err != nil {
 			t.Fatal(err)
 		} else if id != user1.Id {
 			t.Fatalf("want id: %d, got: %d", user1.Id, id)
 		}
 
-		if err := userUseCase.SignIn(entity.User{Name: "Riddle", Password: "Vivse"}); err != nil {
-			t.Fatal(err)
-		}
-
-		token := mockRepo.Users.Users[0].SessionToken
-
-		if id, err := userUseCase.GetIdBy(entity.User{SessionToken: token}); err != nil {
+		if id, err := userUseCase.GetIdBy(ctx, entity.User{Email: "Riddle@mail.ru"}); err != nil {
 			t.Fatal(err)
 		} else if id != user1.Id {
 			t.Fatalf("want id: %d, got: %d", user1.Id, id)
@@ -168,7 +236,7 @@ func TestUsersGetIdBy(t *testing.T) {
 	})
 
 	t.Run("err user not found", func(t *testing.T) {
-		if _, err := userUseCase.GetIdBy(entity.User{Name: "Pup"}); err == nil {
+		if _, err := userUseCase.GetIdBy(ctx, entity.User{Name: "Pup"}); err == nil {
 			t.Fatal("Expected error")
 		} else if !errors.Is(err, entity.ErrUserNotFound) {
 			t.Fatalf("want err: %v, got: %v", errNoRows, err)
@@ -177,105 +245,176 @@ func TestUsersGetIdBy(t *testing.T) {
 }
 
 func TestUpdateSession(t *testing.T) {
+	ctx := context.Background()
+
 	t.Run("OK", func(t *testing.T) {
-		mockRepo := m.NewMockRepos()
-		userUseCase := setupUserUseCase(mockRepo)
+		repos := repository.NewMemoryRepositories(memory.New())
+		userUseCase := setupUserUseCase(repos)
 
-		if err := userUseCase.SignUp(user1); err != nil {
+		if err := userUseCase.SignUp(ctx, user1); err != nil {
 			t.Fatal(err)
 		}
-
-		if err := userUseCase.SignIn(user1); err != nil {
+		session := signIn(t, userUseCase, "Firefox")
+		session.IP = "10.0.0.9"
+		session.ExpiresAt = session.ExpiresAt.Add(-time.Hour * 10)
+		if err := repos.Sessions.Update(ctx, session); err != nil {
 			t.Fatal(err)
 		}
 
-		TTL := mockRepo.Users.Users[0].SessionTTL.Add(-time.Hour * 10)
-
-		if err := userUseCase.UpdateSession(user1); err != nil {
+		if err := userUseCase.UpdateSession(ctx, session); err != nil {
 			t.Fatal(err)
-		} else if !mockRepo.Users.Users[0].SessionTTL.After(TTL) {
-			t.Fatal("could not update TTL")
+		}
+		found := getSessions(t, repos, user1.Id)[0]
+		if !found.ExpiresAt.After(session.ExpiresAt) || found.IP != "10.0.0.9" {
+			t.Fatalf("could not update session: %+v", found)
 		}
 	})
 }
 
 func TestDeleteSession(t *testing.T) {
+	ctx := context.Background()
+
 	t.Run("OK", func(t *testing.T) {
-		mockRepo := m.NewMockRepos()
-		userUseCase := setupUserUseCase(mockRepo)
+		repos := repository.NewMemoryRepositories(memory.New())
+		userUseCase := setupUserUseCase(repos)
 
-		if err := userUseCase.SignUp(user1); err != nil {
+		if err := userUseCase.SignUp(ctx, user1); err != nil {
 			t.Fatal(err)
 		}
+		laptop := signIn(t, userUseCase, "Firefox")
+		phone := signIn(t, userUseCase, "Safari")
 
-		if err := userUseCase.SignIn(user1); err != nil {
+		if err := userUseCase.DeleteSession(ctx, laptop); err != nil {
 			t.Fatal(err)
 		}
-
-		TTL := mockRepo.Users.Users[0].SessionTTL
-		if err := userUseCase.DeleteSession(user1); err != nil {
-			t.Fatal(err)
-		} else if !TTL.After(mockRepo.Users.Users[0].SessionTTL) {
-			t.Fatal("Could not delete session")
+		if found := getSessions(t, repos, user1.Id); len(found) != 1 || found[0].Token != phone.Token {
+			t.Fatalf("want only the phone signed in, got: %+v", found)
 		}
 	})
 }
 
-func TestGetSession(t *testing.T) {
+func TestGetSessions(t *testing.T) {
+	ctx := context.Background()
+
 	t.Run("OK", func(t *testing.T) {
-		mockRepo := m.NewMockRepos()
-		userUseCase := setupUserUseCase(mockRepo)
+		repos := repository.NewMemoryRepositories(memory.New())
+		userUseCase := setupUserUseCase(repos)
 
-		if err := userUseCase.SignUp(user1); err != nil {
+		if err := userUseCase.SignUp(ctx, user1); err != nil {
 			t.Fatal(err)
 		}
+		laptop := signIn(t, userUseCase, "Firefox")
+		signIn(t, userUseCase, "Safari")
 
-		if err := userUseCase.SignIn(user1); err != nil {
+		found, err := userUseCase.GetSessions(ctx, laptop)
+		if err != nil {
 			t.Fatal(err)
 		}
-
-		token := mockRepo.Users.Users[0].SessionToken
-
-		if found, err := userUseCase.GetSession(user1.Id); err != nil {
-			t.Fatal(err)
-		} else if found.SessionToken != token {
-			t.Fatalf("want : %v, got: %v", token, found.SessionToken)
+		if len(found) != 2 {
+			t.Fatalf("want: %d, got: %d", 2, len(found))
+		}
+		for _, session := range found {
+			if session.Current != (session.Id == laptop.Id) {
+				t.Fatalf("want only the laptop current, got: %+v", found)
+			}
 		}
 	})
 }
 
-func TestCheckSession(t *testing.T) {
-	mockRepo := m.NewMockRepos()
-	userUseCase := setupUserUseCase(mockRepo)
+func TestRevokeSession(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories(memory.New())
+	userUseCase := setupUserUseCase(repos)
+
+	if err := userUseCase.SignUp(ctx, user1); err != nil {
+		t.Fatal(err)
+	}
+	laptop := signIn(t, userUseCase, "Firefox")
+	phone := signIn(t, userUseCase, "Safari")
+
+	t.Run("err session of other user", func(t *testing.T) {
+		if err := userUseCase.RevokeSession(ctx, user4.Id, phone.Id); !errors.Is(err, entity.ErrSessionNotFound) {
+			t.Fatalf("want: %v, got: %v", entity.ErrSessionNotFound, err)
+		}
+	})
 
 	t.Run("OK", func(t *testing.T) {
-		if err := userUseCase.SignUp(user1); err != nil {
+		if err := userUseCase.RevokeSession(ctx, user1.Id, phone.Id); err != nil {
+			t.Fatal(err)
+		}
+		if _, auth, err := userUseCase.CheckSession(ctx, phone); err != nil {
+			t.Fatal(err)
+		} else if auth {
+			t.Fatal("want phone signed out")
+		}
+		if _, auth, err := userUseCase.CheckSession(ctx, laptop); err != nil {
 			t.Fatal(err)
+		} else if !auth {
+			t.Fatal("want laptop signed in")
 		}
+	})
 
-		if err := userUseCase.SignIn(user1); err != nil {
+	t.Run("OK everywhere", func(t *testing.T) {
+		signIn(t, userUseCase, "Safari")
+		if err := userUseCase.RevokeAllSessions(ctx, user1.Id); err != nil {
 			t.Fatal(err)
 		}
+		if found := getSessions(t, repos, user1.Id); len(found) != 0 {
+			t.Fatalf("want: %d, got: %d", 0, len(found))
+		}
+	})
+}
+
+func TestCheckSession(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories(memory.New())
+	userUseCase := setupUserUseCase(repos)
+
+	if err := userUseCase.SignUp(ctx, user1); err != nil {
+		t.Fatal(err)
+	}
+	session := signIn(t, userUseCase, "Firefox")
 
-		if auth, err := userUseCase.CheckSession(mockRepo.Users.Users[0]); err != nil {
+	t.Run("OK", func(t *testing.T) {
+		found, auth, err := userUseCase.CheckSession(ctx, entity.Session{Token: session.Token, IP: "10.0.0.9"})
+		if err != nil {
 			t.Fatal(err)
 		} else if !auth {
 			t.Fatal("expected true")
 		}
+		if found.Id != session.Id || found.User.Id != user1.Id || found.UserAgent != "Firefox" ||
+			found.IP != "10.0.0.9" {
+			t.Fatalf("want session %d of user %d from the new address, got: %+v", session.Id, user1.Id, found)
+		}
 	})
 
-	t.Run("err did not find session", func(t *testing.T) {
-		if _, err := userUseCase.CheckSession(user2); err == nil {
-			t.Fatal("expected error")
-		} else if !errors.Is(err, entity.ErrUserNotFound) {
-			t.Fatalf("want : %v, got: %v", entity.ErrUserNotFound, err)
+	t.Run("OK role with permissions", func(t *testing.T) {
+		found, _, err := userUseCase.CheckSession(ctx, entity.Session{Token: session.Token})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found.User.Role.Id != entity.RoleAdminId || !found.User.Role.Can(entity.PermManageRoles) {
+			t.Fatalf("want the admin role with every permission, got: %+v", found.User.Role)
+		}
+	})
+
+	t.Run("OK unknown token", func(t *testing.T) {
+		for _, token := range []string{"", "unknown"} {
+			if _, auth, err := userUseCase.CheckSession(ctx, entity.Session{Token: token}); err != nil {
+				t.Fatal(err)
+			} else if auth {
+				t.Fatal("expected false")
+			}
 		}
 	})
 
 	t.Run("err expired session", func(t *testing.T) {
-		mockRepo.Users.Users[0].SessionTTL = mockRepo.Users.Users[0].SessionTTL.Add(-time.Hour * 25)
+		session.ExpiresAt = session.ExpiresAt.Add(-time.Hour * 25)
+		if err := repos.Sessions.Update(ctx, session); err != nil {
+			t.Fatal(err)
+		}
 
-		if auth, err := userUseCase.CheckSession(mockRepo.Users.Users[0]); err != nil {
+		if _, auth, err := userUseCase.CheckSession(ctx, session); err != nil {
 			t.Fatal(err)
 		} else if auth {
 			t.Fatal("expected false")
@@ -284,22 +423,24 @@ func TestCheckSession(t *testing.T) {
 }
 
 func TestGetAllUsers(t *testing.T) {
+	ctx := context.Background()
+
 	t.Run("OK", func(t *testing.T) {
-		mockRepo := m.NewMockRepos()
-		userUseCase := setupUserUseCase(mockRepo)
+		repos := repository.NewMemoryRepositories(memory.New())
+		userUseCase := setupUserUseCase(repos)
 
-		if err := userUseCase.SignUp(user1); err != nil {
+		if err := userUseCase.SignUp(ctx, user1); err != nil {
 			t.Fatal(err)
 		}
 
-		if err := userUseCase.SignUp(user4); err != nil {
+		if err := userUseCase.SignUp(ctx, user4); err != nil {
 			t.Fatal(err)
 		}
-		if err := userUseCase.SignUp(user5); err != nil {
+		if err := userUseCase.SignUp(ctx, user5); err != nil {
 			t.Fatal(err)
 		}
 
-		if users, err := userUseCase.GetAllUsers(); err != nil {
+		if users, err := userUseCase.GetAllUsers(ctx); err != nil {
 			t.Fatal(err)
 		} else if len(users) != 3 {
 			t.Fatalf("want: %d, got: %d", 3, len(users))
@@ -307,28 +448,58 @@ func TestGetAllUsers(t *testing.T) {
 	})
 }
 
+func TestGetUsersPage(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories(memory.New())
+	userUseCase := setupUserUseCase(repos)
+
+	for i := 0; i < usecase.UsersPerPage+1; i++ {
+		user := entity.User{Name: fmt.Sprintf("user%d", i), Email: fmt.Sprintf("user%d@mail.ru", i)}
+		if err := repos.Users.Store(ctx, user); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("OK page", func(t *testing.T) {
+		if users, page, err := userUseCase.GetUsersPage(ctx, 1); err != nil {
+			t.Fatal(err)
+		} else if len(users) != usecase.UsersPerPage || !page.HasNext() {
+			t.Fatalf("unexpected listing: %d users, page %+v", len(users), page)
+		}
+	})
+
+	t.Run("OK cursor", func(t *testing.T) {
+		if users, page, err := userUseCase.GetUsersAfter(ctx, usecase.UsersPerPage); err != nil {
+			t.Fatal(err)
+		} else if len(users) != 1 || page.HasNext() {
+			t.Fatalf("unexpected listing: %d users, page %+v", len(users), page)
+		}
+	})
+}
+
 func TestUserGetById(t *testing.T) {
-	mockRepo := m.NewMockRepos()
-	userUseCase := setupUserUseCase(mockRepo)
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories(memory.New())
+	userUseCase := setupUserUseCase(repos)
 
 	t.Run("OK", func(t *testing.T) {
-		if err := userUseCase.SignUp(user1); err != nil {
+		if err := userUseCase.SignUp(ctx, user1); err != nil {
 			t.Fatal(err)
 		}
 
-		if err := userUseCase.SignUp(user4); err != nil {
+		if err := userUseCase.SignUp(ctx, user4); err != nil {
 			t.Fatal(err)
 		}
 
-		if found, err := userUseCase.GetById(4); err != nil {
+		if found, err := userUseCase.GetById(ctx, user4.Id); err != nil {
 			t.Fatal(err)
-		} else if found.Id != 4 {
-			t.Fatalf("want: %d, got: %d", 4, found.Id)
+		} else if found.Id != user4.Id {
+			t.Fatalf("want: %d, got: %d", user4.Id, found.Id)
 		}
 	})
 
 	t.Run("err not found", func(t *testing.T) {
-		if _, err := userUseCase.GetById(3); err == nil {
+		if _, err := userUseCase.GetById(ctx, 3); err == nil {
 			t.Fatal("Expected error")
 		} else if !errors.Is(err, entity.ErrUserNotFound) {
 			t.Fatalf("want: %v, got: %v", entity.ErrUserNotFound, err)
@@ -337,20 +508,22 @@ func TestUserGetById(t *testing.T) {
 }
 
 func TestUpdateUserInfo(t *testing.T) {
+	ctx := context.Background()
+
 	t.Run("OK", func(t *testing.T) {
-		mockRepo := m.NewMockRepos()
-		userUseCase := setupUserUseCase(mockRepo)
+		repos := repository.NewMemoryRepositories(memory.New())
+		userUseCase := setupUserUseCase(repos)
 
-		if err := userUseCase.SignUp(user1); err != nil {
+		if err := userUseCase.SignUp(ctx, user1); err != nil {
 			t.Fatal(err)
 		}
 
 		city := "Updated"
 		user1.City = city
-		if err := userUseCase.UpdateUserInfo(user1, "info"); err != nil {
+		if err := userUseCase.UpdateUserInfo(ctx, user1, "info"); err != nil {
 			t.Fatal(err)
 		}
-		if found, err := userUseCase.GetById(1); err != nil {
+		if found, err := userUseCase.GetById(ctx, 1); err != nil {
 			t.Fatal(err)
 		} else if found.City != city {
 			t.Fatalf("want: %v, got: %v", city, found.City)
@@ -358,34 +531,221 @@ func TestUpdateUserInfo(t *testing.T) {
 
 		password := "NewPassword"
 		user1.Password = password
-		if err := userUseCase.UpdateUserInfo(user1, "password"); err != nil {
+		if err := userUseCase.UpdateUserInfo(ctx, user1, "password"); err != nil {
 			t.Fatal(err)
 		}
-		if err := userUseCase.SignIn(user1); err != nil {
+		if _, err := userUseCase.SignIn(ctx, user1, entity.Session{}); err != nil {
 			t.Fatal("Could not update password")
 		}
 	})
+
+	t.Run("err unknown timezone", func(t *testing.T) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		userUseCase := setupUserUseCase(repos)
+
+		if err := userUseCase.SignUp(ctx, user1); err != nil {
+			t.Fatal(err)
+		}
+
+		user := user1
+		user.Timezone = "Mars/Olympus"
+		if err := userUseCase.UpdateUserInfo(ctx, user, "info"); !errors.Is(err, entity.ErrUnknownTimezone) {
+			t.Fatalf("want: %v, got: %v", entity.ErrUnknownTimezone, err)
+		}
+	})
 }
 
 func TestDeleteUser(t *testing.T) {
+	ctx := context.Background()
+
 	t.Run("OK", func(t *testing.T) {
-		mockRepo := m.NewMockRepos()
-		userUseCase := setupUserUseCase(mockRepo)
+		repos := repository.NewMemoryRepositories(memory.New())
+		userUseCase := setupUserUseCase(repos)
 
-		if err := userUseCase.SignUp(user1); err != nil {
+		if err := userUseCase.SignUp(ctx, user1); err != nil {
 			t.Fatal(err)
 		}
+		demote(t, repos, user1.Id)
+
+		signIn(t, userUseCase, "Firefox")
 
-		if err := userUseCase.SignIn(user1); err != nil {
+		if err := userUseCase.DeleteUser(ctx, user1); err != nil {
 			t.Fatal(err)
 		}
 
-		if err := userUseCase.DeleteUser(user1); err != nil {
+		if _, err := userUseCase.SignIn(ctx, user1, entity.Session{}); err == nil {
+			t.Fatal("Could not delete user")
+		}
+		if found := getSessions(t, repos, user1.Id); len(found) != 0 {
+			t.Fatalf("want: %d, got: %d", 0, len(found))
+		}
+	})
+	t.Run("OK reactions removed", func(t *testing.T) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		storeCategories(t, repos)
+		userUseCase := setupUserUseCase(repos)
+		postUseCase := usecase.NewPostsUseCase(repos.Posts, repos.Users, repos.Comments, repos.Reactions, repos.Revisions,
+			repos.UnitOfWork, entity.DefaultReactionKinds)
+
+		if err := userUseCase.SignUp(ctx, user1); err != nil {
+			t.Fatal(err)
+		}
+		demote(t, repos, user1.Id)
+		if err := postUseCase.CreatePost(ctx, post1); err != nil {
+			t.Fatal(err)
+		}
+		if err := postUseCase.MakeReaction(ctx, post1, "like"); err != nil {
 			t.Fatal(err)
 		}
 
-		if err := userUseCase.SignIn(user1); err == nil {
-			t.Fatal("Could not delete user")
+		if err := userUseCase.DeleteUser(ctx, user1); err != nil {
+			t.Fatal(err)
+		}
+
+		if found, err := repos.Reactions.CountByUser(ctx, entity.ReactionTargetPost, user1.Id); err != nil {
+			t.Fatal(err)
+		} else if len(found) != 0 {
+			t.Fatalf("want: %d, got: %d", 0, len(found))
+		}
+	})
+	t.Run("OK content removed", func(t *testing.T) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		userUseCase := setupUserUseCase(repos)
+
+		if err := userUseCase.SignUp(ctx, user1); err != nil {
+			t.Fatal(err)
+		}
+		demote(t, repos, user1.Id)
+		if err := repos.Users.Store(ctx, entity.User{Name: "Subi", Email: "Subi@mail.ru"}); err != nil {
+			t.Fatal(err)
+		}
+		for _, userId := range []int64{1, 2} {
+			post := entity.Post{User: entity.User{Id: userId}, Title: "Audi", Content: "Lorem ipsum."}
+			if err := repos.Posts.Store(ctx, &post); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, comment := range []entity.Comment{
+			{PostId: 1, User: entity.User{Id: 2}, Content: "Lorem ipsum."},
+			{PostId: 2, User: entity.User{Id: 1}, Content: "Lorem ipsum."},
+			{PostId: 2, ParentId: 2, User: entity.User{Id: 2}, Content: "Lorem ipsum."},
+		} {
+			if err := repos.Comments.Store(ctx, &comment); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := repos.Resets.Store(ctx, &entity.PasswordReset{User: entity.User{Id: 1}, TokenHash: "hash",
+			ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := userUseCase.DeleteUser(ctx, user1); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := repos.Posts.GetById(ctx, 1); err == nil {
+			t.Fatal("Could not delete post")
+		}
+		if found, err := repos.Comments.Fetch(ctx, 2); err != nil {
+			t.Fatal(err)
+		} else if len(found) != 1 || found[0].Id != 3 || found[0].ParentId != 0 {
+			t.Fatalf("want top level reply 3, got: %v", found)
+		}
+		if _, err := repos.Resets.GetByHash(ctx, "hash"); err == nil {
+			t.Fatal("Could not delete password reset")
+		}
+	})
+	t.Run("err last admin", func(t *testing.T) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		userUseCase := setupUserUseCase(repos)
+
+		if err := userUseCase.SignUp(ctx, user1); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := userUseCase.DeleteUser(ctx, user1); !errors.Is(err, entity.ErrLastAdmin) {
+			t.Fatalf("want: %v, got: %v", entity.ErrLastAdmin, err)
+		}
+		if _, err := userUseCase.GetById(ctx, user1.Id); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+// demote gives the user the default role, so they are not the last admin.
+func demote(t *testing.T, repos *repository.Repositories, id int64) {
+	t.Helper()
+	if err := repos.Users.SetRole(context.Background(), id, entity.RoleUserId); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBanUser(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(t *testing.T) (*repository.Repositories, *usecase.UsersUseCase) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		userUseCase := setupUserUseCase(repos)
+		for _, user := range []entity.User{user1, user4} {
+			if err := userUseCase.SignUp(ctx, user); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return repos, userUseCase
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		repos, userUseCase := setup(t)
+		session, err := userUseCase.SignIn(ctx, user4, entity.Session{UserAgent: "Firefox"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := userUseCase.BanUser(ctx, user4.Id); err != nil {
+			t.Fatal(err)
+		}
+
+		if found := getSessions(t, repos, user4.Id); len(found) != 0 {
+			t.Fatalf("want banned user signed out, got %d sessions", len(found))
+		}
+		if _, auth, err := userUseCase.CheckSession(ctx, session); err != nil {
+			t.Fatal(err)
+		} else if auth {
+			t.Fatal("expected false")
+		}
+		if _, err := userUseCase.SignIn(ctx, user4, entity.Session{}); !errors.Is(err, entity.ErrUserBanned) {
+			t.Fatalf("want: %v, got: %v", entity.ErrUserBanned, err)
+		}
+
+		if err := userUseCase.UnbanUser(ctx, user4.Id); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := userUseCase.SignIn(ctx, user4, entity.Session{}); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("err not allowed", func(t *testing.T) {
+		repos, userUseCase := setup(t)
+		if err := repos.Users.SetRole(ctx, user4.Id, entity.RoleModeratorId); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, id := range []int64{user1.Id, user4.Id} {
+			if err := userUseCase.BanUser(ctx, id); !errors.Is(err, entity.ErrBanNotAllowed) {
+				t.Fatalf("user %d: want: %v, got: %v", id, entity.ErrBanNotAllowed, err)
+			}
+		}
+	})
+
+	t.Run("err not found", func(t *testing.T) {
+		_, userUseCase := setup(t)
+
+		if err := userUseCase.BanUser(ctx, 10); !errors.Is(err, entity.ErrUserNotFound) {
+			t.Fatalf("want: %v, got: %v", entity.ErrUserNotFound, err)
+		}
+		if err := userUseCase.UnbanUser(ctx, 10); !errors.Is(err, entity.ErrUserNotFound) {
+			t.Fatalf("want: %v, got: %v", entity.ErrUserNotFound, err)
 		}
 	})
 }
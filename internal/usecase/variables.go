@@ -3,11 +3,16 @@ package usecase
 const (
 	UpdateInfoQuery     = "info"
 	UpdatePasswordQuery = "password"
-	UpdateSessionQuery  = "session"
 	UniqueEmailErr      = "UNIQUE constraint failed: users.email"
 	UniqueNameErr       = "UNIQUE constraint failed: users.name"
-	DateAndTimeFormat   = "2006-01-02 15:04:05"
-	DateFormat          = "2006-01-02"
+	UniqueRoleErr       = "UNIQUE constraint failed: roles.name"
 	UserGenderMale      = "Male"
 	UserGenderFemale    = "Female"
 )
+
+// Listing page sizes.
+const (
+	PostsPerPage    = 10
+	CommentsPerPage = 20
+	UsersPerPage    = 20
+)
@@ -0,0 +1,182 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"forum/internal/entity"
+	"forum/internal/repository"
+	"forum/pkg/mailer"
+	"forum/pkg/signer"
+)
+
+// Letter with an email verification link.
+const (
+	verifySubject = "Подтверждение почты"
+	verifyBody    = "Чтобы подтвердить почту на форуме, перейдите по ссылке:\n%s\n\n" +
+		"Ссылка действует %d ч. Если вы не регистрировались на форуме, " +
+		"просто не обращайте внимания на это письмо."
+)
+
+type VerificationUseCase struct {
+	repo     repository.Users
+	signer   signer.Signer
+	mailer   mailer.Mailer
+	siteURL  string
+	ttl      time.Duration
+	interval time.Duration
+}
+
+// NewVerificationUseCase mails links starting with siteURL, they are valid
+// for ttl. A user gets at most one letter per interval.
+func NewVerificationUseCase(repo repository.Users, signer signer.Signer, mailer mailer.Mailer,
+	siteURL string, ttl, interval time.Duration,
+) *VerificationUseCase {
+	return &VerificationUseCase{
+		repo:     repo,
+		signer:   signer,
+		mailer:   mailer,
+		siteURL:  siteURL,
+		ttl:      ttl,
+		interval: interval,
+	}
+}
+
+// SendVerification mails a verification link to the pending email of the
+// user, or to their email while it is unverified. Users with nothing to
+// verify get entity.ErrEmailAlreadyVerified.
+func (vu *VerificationUseCase) SendVerification(ctx context.Context, userId int64) error {
+	user, err := vu.repo.GetById(ctx, userId)
+	if err != nil {
+		return fmt.Errorf("VerificationUseCase - SendVerification #1 - %w", err)
+	}
+	email := user.PendingEmail
+	if email == "" {
+		if user.EmailVerified {
+			return entity.ErrEmailAlreadyVerified
+		}
+		email = user.Email
+	}
+
+	err = vu.send(ctx, user, email)
+	if err != nil {
+		return fmt.Errorf("VerificationUseCase - SendVerification #2 - %w", err)
+	}
+	return nil
+}
+
+// ChangeEmail keeps email as pending and mails the link to it, the current
+// email stays until the link is followed. Emails of other users are
+// entity.ErrUserEmailAlreadyExists, going back to the verified email drops
+// the pending one and is entity.ErrEmailAlreadyVerified.
+func (vu *VerificationUseCase) ChangeEmail(ctx context.Context, userId int64, email string) error {
+	id, err := vu.repo.GetId(ctx, entity.User{Email: email})
+	if err != nil && !strings.Contains(err.Error(), NoRowsResultErr) {
+		return fmt.Errorf("VerificationUseCase - ChangeEmail #1 - %w", err)
+	}
+	if id != 0 && id != userId {
+		return entity.ErrUserEmailAlreadyExists
+	}
+
+	user, err := vu.repo.GetById(ctx, userId)
+	if err != nil {
+		return fmt.Errorf("VerificationUseCase - ChangeEmail #2 - %w", err)
+	}
+	if email == user.Email && user.EmailVerified {
+		user.PendingEmail = ""
+		err = vu.repo.UpdateVerification(ctx, user)
+		if err != nil {
+			return fmt.Errorf("VerificationUseCase - ChangeEmail #3 - %w", err)
+		}
+		return entity.ErrEmailAlreadyVerified
+	}
+
+	err = vu.send(ctx, user, email)
+	if err != nil {
+		return fmt.Errorf("VerificationUseCase - ChangeEmail #4 - %w", err)
+	}
+	return nil
+}
+
+// Verify marks the email of the link verified. The link works while it is
+// not expired and the email is still the one waiting for it.
+func (vu *VerificationUseCase) Verify(ctx context.Context, token string) error {
+	id, email, err := vu.parseToken(token)
+	if err != nil {
+		return err
+	}
+
+	user, err := vu.repo.GetById(ctx, id)
+	if err != nil {
+		if strings.Contains(err.Error(), NoRowsResultErr) {
+			return entity.ErrVerificationInvalid
+		}
+		return fmt.Errorf("VerificationUseCase - Verify #1 - %w", err)
+	}
+	if email != user.PendingEmail && (email != user.Email || user.EmailVerified) {
+		return entity.ErrVerificationInvalid
+	}
+
+	err = vu.repo.VerifyEmail(ctx, id, email)
+	if err != nil {
+		if strings.Contains(err.Error(), UniqueEmailErr) {
+			return entity.ErrUserEmailAlreadyExists
+		}
+		return fmt.Errorf("VerificationUseCase - Verify #2 - %w", err)
+	}
+	return nil
+}
+
+// send mails the link for email unless the user got one less than interval
+// ago, then keeps email as pending unless it is the current one.
+func (vu *VerificationUseCase) send(ctx context.Context, user entity.User, email string) error {
+	now := time.Now()
+	if now.Sub(user.VerificationSentAt) < vu.interval {
+		return entity.ErrVerificationThrottled
+	}
+
+	payload := strconv.FormatInt(user.Id, 10) + ":" + strconv.FormatInt(now.Add(vu.ttl).Unix(), 10) +
+		":" + email
+	link := vu.siteURL + "/verify_email?token=" + url.QueryEscape(vu.signer.Sign(payload))
+	err := vu.mailer.Send(email, verifySubject, fmt.Sprintf(verifyBody, link, int(vu.ttl.Hours())))
+	if err != nil {
+		return fmt.Errorf("send - %w", err)
+	}
+
+	user.PendingEmail = ""
+	if email != user.Email {
+		user.PendingEmail = email
+	}
+	user.VerificationSentAt = now
+	err = vu.repo.UpdateVerification(ctx, user)
+	if err != nil {
+		return fmt.Errorf("send - %w", err)
+	}
+	return nil
+}
+
+// parseToken reads the user id and the email of a link signed by send,
+// forged and expired links are entity.ErrVerificationInvalid.
+func (vu *VerificationUseCase) parseToken(token string) (int64, string, error) {
+	payload, err := vu.signer.Verify(token)
+	if err != nil {
+		return 0, "", entity.ErrVerificationInvalid
+	}
+	parts := strings.SplitN(payload, ":", 3)
+	if len(parts) != 3 {
+		return 0, "", entity.ErrVerificationInvalid
+	}
+	id, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, "", entity.ErrVerificationInvalid
+	}
+	expiry, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || time.Now().Unix() > expiry {
+		return 0, "", entity.ErrVerificationInvalid
+	}
+	return id, parts[2], nil
+}
@@ -0,0 +1,203 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"forum/internal/entity"
+	"forum/internal/repository"
+	"forum/internal/repository/memory"
+	"forum/internal/usecase"
+	"forum/pkg/mailer"
+	"forum/pkg/signer"
+)
+
+var verifyLink = regexp.MustCompile(`http://forum\.test/verify_email\?token=(\S+)`)
+
+// setupVerificationUseCase mails the letters into a file of the test, the
+// returned func reads the token of the last one.
+func setupVerificationUseCase(t *testing.T, repos *repository.Repositories, ttl, interval time.Duration) (
+	*usecase.VerificationUseCase, func() string,
+) {
+	path := filepath.Join(t.TempDir(), "mail.log")
+	verificationUseCase := usecase.NewVerificationUseCase(repos.Users, signer.NewHMACSigner([]byte("secret")),
+		mailer.NewFileMailer(path, "forum@forum.test"), "http://forum.test", ttl, interval)
+
+	lastToken := func() string {
+		t.Helper()
+		letters, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := verifyLink.FindAllStringSubmatch(string(letters), -1)
+		if len(found) == 0 {
+			t.Fatal("no verification link mailed")
+		}
+		return found[len(found)-1][1]
+	}
+	return verificationUseCase, lastToken
+}
+
+// signUpUnverified registers user1 and returns their id.
+func signUpUnverified(t *testing.T, repos *repository.Repositories) int64 {
+	t.Helper()
+	ctx := context.Background()
+	if err := setupUserUseCase(repos).SignUp(ctx, user1); err != nil {
+		t.Fatal(err)
+	}
+	id, err := repos.Users.GetId(ctx, entity.User{Email: user1.Email})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestVerify(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("OK", func(t *testing.T) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		verificationUseCase, lastToken := setupVerificationUseCase(t, repos, time.Hour, time.Minute)
+		id := signUpUnverified(t, repos)
+
+		if err := verificationUseCase.SendVerification(ctx, id); err != nil {
+			t.Fatal(err)
+		}
+		if err := verificationUseCase.Verify(ctx, lastToken()); err != nil {
+			t.Fatal(err)
+		}
+		user, err := repos.Users.GetById(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !user.EmailVerified {
+			t.Fatal("want email verified")
+		}
+		if err := verificationUseCase.Verify(ctx, lastToken()); !errors.Is(err, entity.ErrVerificationInvalid) {
+			t.Fatalf("want used link refused, got: %v", err)
+		}
+		if err := verificationUseCase.SendVerification(ctx, id); !errors.Is(err, entity.ErrEmailAlreadyVerified) {
+			t.Fatalf("want: %v, got: %v", entity.ErrEmailAlreadyVerified, err)
+		}
+	})
+
+	t.Run("err forged", func(t *testing.T) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		verificationUseCase, lastToken := setupVerificationUseCase(t, repos, time.Hour, time.Minute)
+		id := signUpUnverified(t, repos)
+
+		if err := verificationUseCase.SendVerification(ctx, id); err != nil {
+			t.Fatal(err)
+		}
+		forged := signer.NewHMACSigner([]byte("guess")).Sign("1:9999999999:" + user1.Email)
+		for _, token := range []string{"", "garbage", forged, lastToken() + "x"} {
+			if err := verificationUseCase.Verify(ctx, token); !errors.Is(err, entity.ErrVerificationInvalid) {
+				t.Fatalf("token %q: want: %v, got: %v", token, entity.ErrVerificationInvalid, err)
+			}
+		}
+	})
+
+	t.Run("err expired", func(t *testing.T) {
+		repos := repository.NewMemoryRepositories(memory.New())
+		verificationUseCase, lastToken := setupVerificationUseCase(t, repos, -time.Minute, time.Minute)
+		id := signUpUnverified(t, repos)
+
+		if err := verificationUseCase.SendVerification(ctx, id); err != nil {
+			t.Fatal(err)
+		}
+		if err := verificationUseCase.Verify(ctx, lastToken()); !errors.Is(err, entity.ErrVerificationInvalid) {
+			t.Fatalf("want: %v, got: %v", entity.ErrVerificationInvalid, err)
+		}
+	})
+}
+
+func TestSendVerificationThrottled(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories(memory.New())
+	verificationUseCase, _ := setupVerificationUseCase(t, repos, time.Hour, time.Hour)
+	id := signUpUnverified(t, repos)
+
+	if err := verificationUseCase.SendVerification(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if err := verificationUseCase.SendVerification(ctx, id); !errors.Is(err, entity.ErrVerificationThrottled) {
+		t.Fatalf("want: %v, got: %v", entity.ErrVerificationThrottled, err)
+	}
+}
+
+func TestChangeEmail(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories(memory.New())
+	verificationUseCase, lastToken := setupVerificationUseCase(t, repos, time.Hour, 0)
+	id := signUpUnverified(t, repos)
+	if err := verificationUseCase.SendVerification(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if err := verificationUseCase.Verify(ctx, lastToken()); err != nil {
+		t.Fatal(err)
+	}
+	other := user3
+	other.Email = "isa@mail.ru"
+	if err := setupUserUseCase(repos).SignUp(ctx, other); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("err taken", func(t *testing.T) {
+		err := verificationUseCase.ChangeEmail(ctx, id, other.Email)
+		if !errors.Is(err, entity.ErrUserEmailAlreadyExists) {
+			t.Fatalf("want: %v, got: %v", entity.ErrUserEmailAlreadyExists, err)
+		}
+	})
+
+	t.Run("OK", func(t *testing.T) {
+		if err := verificationUseCase.ChangeEmail(ctx, id, "first@mail.ru"); err != nil {
+			t.Fatal(err)
+		}
+		first := lastToken()
+		if err := verificationUseCase.ChangeEmail(ctx, id, "second@mail.ru"); err != nil {
+			t.Fatal(err)
+		}
+
+		user, err := repos.Users.GetById(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if user.Email != user1.Email || user.PendingEmail != "second@mail.ru" {
+			t.Fatalf("want email kept until verified, got: %v, pending: %v", user.Email, user.PendingEmail)
+		}
+		if err := verificationUseCase.Verify(ctx, first); !errors.Is(err, entity.ErrVerificationInvalid) {
+			t.Fatalf("want replaced link refused, got: %v", err)
+		}
+		if err := verificationUseCase.Verify(ctx, lastToken()); err != nil {
+			t.Fatal(err)
+		}
+
+		user, err = repos.Users.GetById(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if user.Email != "second@mail.ru" || user.PendingEmail != "" || !user.EmailVerified {
+			t.Fatalf("want the new email verified, got: %#v", user)
+		}
+	})
+
+	t.Run("OK back to verified", func(t *testing.T) {
+		if err := verificationUseCase.ChangeEmail(ctx, id, "third@mail.ru"); err != nil {
+			t.Fatal(err)
+		}
+		err := verificationUseCase.ChangeEmail(ctx, id, "second@mail.ru")
+		if !errors.Is(err, entity.ErrEmailAlreadyVerified) {
+			t.Fatalf("want: %v, got: %v", entity.ErrEmailAlreadyVerified, err)
+		}
+		if user, err := repos.Users.GetById(ctx, id); err != nil {
+			t.Fatal(err)
+		} else if user.PendingEmail != "" {
+			t.Fatalf("want pending email dropped, got: %v", user.PendingEmail)
+		}
+	})
+}
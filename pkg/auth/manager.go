@@ -12,15 +12,13 @@ import (
 type TokenManager interface {
 	NewToken() (string, error)
 	UpdateTTL() time.Time
-	CheckTTLExpired(TTL time.Time) (bool, error)
+	CheckTTLExpired(TTL time.Time) bool
 }
 
 type Manager struct {
 	Cfg config.Config
 }
 
-const TimeFormat = "2006-01-02 15:04:05"
-
 func NewManager(cfg config.Config) *Manager {
 	return &Manager{
 		Cfg: cfg,
@@ -40,12 +38,6 @@ func (m *Manager) UpdateTTL() time.Time {
 	return TTL
 }
 
-func (m *Manager) CheckTTLExpired(TTL time.Time) (bool, error) {
-	now := time.Now()
-	formatted := now.Format(TimeFormat)
-	timeNow, err := time.Parse(TimeFormat, formatted)
-	if err != nil {
-		return false, fmt.Errorf("auth - CheckTTLExpired - Parse: %w", err)
-	}
-	return TTL.Before(timeNow), nil
+func (m *Manager) CheckTTLExpired(TTL time.Time) bool {
+	return TTL.Before(time.Now())
 }
@@ -0,0 +1,108 @@
+package mailer
+
+import (
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Mailer sends plain text letters.
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// SMTPMailer sends letters through an SMTP server, with PLAIN auth when a
+// username is given.
+type SMTPMailer struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+func NewSMTPMailer(host string, port int, username, password, from string) *SMTPMailer {
+	m := &SMTPMailer{
+		addr: net.JoinHostPort(host, strconv.Itoa(port)),
+		from: from,
+	}
+	if username != "" {
+		m.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return m
+}
+
+func (m *SMTPMailer) Send(to, subject, body string) error {
+	msg, err := message(m.from, to, subject, body)
+	if err != nil {
+		return fmt.Errorf("mailer - Send - %w", err)
+	}
+	err = smtp.SendMail(m.addr, m.auth, m.from, []string{to}, msg)
+	if err != nil {
+		return fmt.Errorf("mailer - Send - SendMail: %w", err)
+	}
+	return nil
+}
+
+// FileMailer appends letters to a file instead of sending them, for local
+// runs and tests.
+type FileMailer struct {
+	mu   sync.Mutex
+	from string
+	path string
+}
+
+func NewFileMailer(path, from string) *FileMailer {
+	return &FileMailer{
+		from: from,
+		path: path,
+	}
+}
+
+func (m *FileMailer) Send(to, subject, body string) error {
+	msg, err := message(m.from, to, subject, body)
+	if err != nil {
+		return fmt.Errorf("mailer - Send - %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err = os.MkdirAll(filepath.Dir(m.path), 0o755); err != nil {
+		return fmt.Errorf("mailer - Send - MkdirAll: %w", err)
+	}
+	file, err := os.OpenFile(m.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("mailer - Send - OpenFile: %w", err)
+	}
+	defer file.Close()
+
+	if _, err = file.Write(append(msg, "\r\n"...)); err != nil {
+		return fmt.Errorf("mailer - Send - Write: %w", err)
+	}
+	return nil
+}
+
+// message formats the letter with its headers, addresses with line breaks
+// would add headers of their own and are refused.
+func message(from, to, subject, body string) ([]byte, error) {
+	if strings.ContainsAny(from+to, "\r\n") {
+		return nil, fmt.Errorf("message: address with a line break")
+	}
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + to + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return []byte(b.String()), nil
+}
@@ -0,0 +1,266 @@
+package migrate
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Migration is a single numbered schema change. Up moves the schema from
+// Version-1 to Version, Down reverts it.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+var (
+	ErrSchemaTooNew    = errors.New("database schema is newer than the application supports")
+	ErrUnknownVersion  = errors.New("unknown migration version")
+	ErrNoDownMigration = errors.New("migration has no down step")
+)
+
+const TimeFormat = "2006-01-02 15:04:05"
+
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+	repair     func(db *sql.DB) error
+}
+
+func New(db *sql.DB, migrations []Migration) *Migrator {
+	sorted := make([]Migration, len(migrations))
+	copy(sorted, migrations)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+	return &Migrator{
+		db:         db,
+		migrations: sorted,
+	}
+}
+
+// WithRepair sets a step Check runs on migrated databases, for parts of
+// the schema that depend on the build rather than on the version.
+func (m *Migrator) WithRepair(repair func(db *sql.DB) error) *Migrator {
+	m.repair = repair
+	return m
+}
+
+func (m *Migrator) init() error {
+	_, err := m.db.Exec(`
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TEXT NOT NULL
+		);
+	`)
+	if err != nil {
+		return fmt.Errorf("Migrator - init - Exec: %w", err)
+	}
+	return nil
+}
+
+// Latest returns the highest version known to the application.
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version returns the version the database is currently migrated to.
+func (m *Migrator) Version() (int, error) {
+	if err := m.init(); err != nil {
+		return 0, fmt.Errorf("Migrator - Version - %w", err)
+	}
+
+	var version sql.NullInt64
+	err := m.db.QueryRow(`
+	SELECT MAX(version)
+	FROM schema_migrations
+	`).Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("Migrator - Version - Scan: %w", err)
+	}
+
+	return int(version.Int64), nil
+}
+
+// Check refuses to work with a database migrated by a newer build and
+// runs the repair step, if any, on the others.
+func (m *Migrator) Check() error {
+	version, err := m.Version()
+	if err != nil {
+		return fmt.Errorf("Migrator - Check - %w", err)
+	}
+	if version > m.Latest() {
+		return fmt.Errorf("Migrator - Check - version %d, latest known %d: %w",
+			version, m.Latest(), ErrSchemaTooNew)
+	}
+	if m.repair != nil && version > 0 {
+		if err = m.repair(m.db); err != nil {
+			return fmt.Errorf("Migrator - Check - repair: %w", err)
+		}
+	}
+	return nil
+}
+
+// Pending returns migrations which are not applied yet.
+func (m *Migrator) Pending() ([]Migration, error) {
+	version, err := m.Version()
+	if err != nil {
+		return nil, fmt.Errorf("Migrator - Pending - %w", err)
+	}
+
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if migration.Version > version {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies all pending migrations.
+func (m *Migrator) Up() error {
+	if err := m.To(m.Latest()); err != nil {
+		return fmt.Errorf("Migrator - Up - %w", err)
+	}
+	return nil
+}
+
+// Down reverts the last applied migration.
+func (m *Migrator) Down() error {
+	version, err := m.Version()
+	if err != nil {
+		return fmt.Errorf("Migrator - Down - %w", err)
+	}
+	if version == 0 {
+		return nil
+	}
+
+	target := 0
+	for _, migration := range m.migrations {
+		if migration.Version < version {
+			target = migration.Version
+		}
+	}
+
+	if err = m.To(target); err != nil {
+		return fmt.Errorf("Migrator - Down - %w", err)
+	}
+	return nil
+}
+
+// To migrates the database up or down to the given version.
+func (m *Migrator) To(target int) error {
+	if err := m.Check(); err != nil {
+		return fmt.Errorf("Migrator - To - %w", err)
+	}
+	if target != 0 && m.find(target) == nil {
+		return fmt.Errorf("Migrator - To - %d: %w", target, ErrUnknownVersion)
+	}
+
+	version, err := m.Version()
+	if err != nil {
+		return fmt.Errorf("Migrator - To - %w", err)
+	}
+
+	if target >= version {
+		for _, migration := range m.migrations {
+			if migration.Version <= version || migration.Version > target {
+				continue
+			}
+			if err = m.apply(migration); err != nil {
+				return fmt.Errorf("Migrator - To - %w", err)
+			}
+		}
+		return nil
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if migration.Version > version || migration.Version <= target {
+			continue
+		}
+		if err = m.revert(migration); err != nil {
+			return fmt.Errorf("Migrator - To - %w", err)
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) find(version int) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) apply(migration Migration) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return fmt.Errorf("Migrator - apply - Begin: %w", err)
+	}
+	defer func() {
+		err = tx.Rollback()
+	}()
+
+	if _, err = tx.Exec(migration.Up); err != nil {
+		return fmt.Errorf("Migrator - apply - %d %s - Exec #1: %w", migration.Version, migration.Name, err)
+	}
+
+	_, err = tx.Exec(`
+	INSERT INTO schema_migrations(version, name, applied_at)
+		values($1, $2, $3)
+	`, migration.Version, migration.Name, time.Now().Format(TimeFormat))
+	if err != nil {
+		return fmt.Errorf("Migrator - apply - %d %s - Exec #2: %w", migration.Version, migration.Name, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("Migrator - apply - Commit: %w", err)
+	}
+
+	return nil
+}
+
+func (m *Migrator) revert(migration Migration) error {
+	if migration.Down == "" {
+		return fmt.Errorf("Migrator - revert - %d %s: %w", migration.Version, migration.Name, ErrNoDownMigration)
+	}
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return fmt.Errorf("Migrator - revert - Begin: %w", err)
+	}
+	defer func() {
+		err = tx.Rollback()
+	}()
+
+	if _, err = tx.Exec(migration.Down); err != nil {
+		return fmt.Errorf("Migrator - revert - %d %s - Exec #1: %w", migration.Version, migration.Name, err)
+	}
+
+	_, err = tx.Exec(`
+	DELETE FROM schema_migrations
+	WHERE version = $1
+	`, migration.Version)
+	if err != nil {
+		return fmt.Errorf("Migrator - revert - %d %s - Exec #2: %w", migration.Version, migration.Name, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("Migrator - revert - Commit: %w", err)
+	}
+
+	return nil
+}
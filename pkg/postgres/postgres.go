@@ -0,0 +1,31 @@
+package postgres
+
+import (
+	"database/sql"
+
+	"forum/pkg/sqlconn"
+
+	_ "github.com/lib/pq"
+)
+
+type Postgres struct {
+	DB *sql.DB
+	// Conn is what repositories query through, the pool DB or a
+	// transaction of it.
+	Conn sqlconn.Conn
+}
+
+func New(dsn string) (*Postgres, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &Postgres{
+		DB:   db,
+		Conn: sqlconn.Pool(db),
+	}, nil
+}
+
+func (p *Postgres) Close() {
+	p.DB.Close()
+}
@@ -0,0 +1,88 @@
+// Package sqlconn lets repositories run their queries either on the
+// connection pool or inside a transaction shared with other repositories.
+package sqlconn
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Conn is the part of *sql.DB repositories query through.
+type Conn interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	Begin(ctx context.Context) (Tx, error)
+}
+
+// Tx is the part of *sql.Tx repositories use.
+type Tx interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	Commit() error
+	Rollback() error
+}
+
+// Pool is a Conn on the pool itself, Begin starts a new transaction.
+func Pool(db *sql.DB) Conn {
+	return pool{db}
+}
+
+type pool struct {
+	*sql.DB
+}
+
+func (p pool) Begin(ctx context.Context) (Tx, error) {
+	return p.DB.BeginTx(ctx, nil)
+}
+
+// joined is a transaction started by RunInTx. Begin hands out the same
+// transaction and Commit and Rollback are left to RunInTx, so repository
+// methods opening their own transactions become a part of it.
+type joined struct {
+	*sql.Tx
+}
+
+func (j joined) Begin(ctx context.Context) (Tx, error) {
+	return j, nil
+}
+
+func (j joined) Commit() error {
+	return nil
+}
+
+func (j joined) Rollback() error {
+	return nil
+}
+
+// RunInTx runs fn on a transaction of db. The transaction is committed
+// when fn returns nil and rolled back otherwise, or when ctx is done by
+// then. Queries fn runs with ctx stop once it is done, the transaction
+// itself is not bound to ctx so it is always over when RunInTx returns.
+func RunInTx(ctx context.Context, db *sql.DB, fn func(conn Conn) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("RunInTx - Begin: %w", err)
+	}
+
+	err = fn(joined{tx})
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return fmt.Errorf("RunInTx - Rollback: %v: %w", rollbackErr, err)
+		}
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("RunInTx - Commit: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package sqlite3
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"time"
+
+	gosqlite "github.com/mattn/go-sqlite3"
+)
+
+const (
+	// backupPages is copied in one step, writers may go on between steps.
+	backupPages = 256
+	backupPause = 10 * time.Millisecond
+)
+
+// Backup copies the database into the file at dest with the online backup
+// API while the database stays in use. An existing file is overwritten.
+// The copy stops between steps once ctx is done.
+func (s *Sqlite) Backup(ctx context.Context, dest string) error {
+	db, err := sql.Open("sqlite3", dest)
+	if err != nil {
+		return fmt.Errorf("Sqlite - Backup - Open: %w", err)
+	}
+	defer db.Close()
+
+	if err = copyDatabase(ctx, db, s.DB); err != nil {
+		return fmt.Errorf("Sqlite - Backup - %w", err)
+	}
+	return nil
+}
+
+// Restore replaces the content of the database with the file at src
+// through the online backup API, open connections see the restored data.
+func (s *Sqlite) Restore(ctx context.Context, src string) error {
+	if _, err := os.Stat(src); err != nil {
+		return fmt.Errorf("Sqlite - Restore - Stat: %w", err)
+	}
+	db, err := sql.Open("sqlite3", "file:"+src+"?mode=ro")
+	if err != nil {
+		return fmt.Errorf("Sqlite - Restore - Open: %w", err)
+	}
+	defer db.Close()
+
+	if err = copyDatabase(ctx, s.DB, db); err != nil {
+		return fmt.Errorf("Sqlite - Restore - %w", err)
+	}
+	return nil
+}
+
+// copyDatabase copies the main database of src into dst page by page.
+func copyDatabase(ctx context.Context, dst, src *sql.DB) error {
+	dstConn, err := dst.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("copyDatabase - Conn: %w", err)
+	}
+	defer dstConn.Close()
+	srcConn, err := src.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("copyDatabase - Conn: %w", err)
+	}
+	defer srcConn.Close()
+
+	return dstConn.Raw(func(dstDriver any) error {
+		return srcConn.Raw(func(srcDriver any) error {
+			to, ok := dstDriver.(*gosqlite.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("copyDatabase - got connection of type %T", dstDriver)
+			}
+			from, ok := srcDriver.(*gosqlite.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("copyDatabase - got connection of type %T", srcDriver)
+			}
+
+			backup, err := to.Backup("main", from, "main")
+			if err != nil {
+				return fmt.Errorf("copyDatabase - Backup: %w", err)
+			}
+			for {
+				done, err := backup.Step(backupPages)
+				if err != nil {
+					backup.Close()
+					return fmt.Errorf("copyDatabase - Step: %w", err)
+				}
+				if done {
+					break
+				}
+				if err = ctx.Err(); err != nil {
+					backup.Close()
+					return fmt.Errorf("copyDatabase - Step: %w", err)
+				}
+				time.Sleep(backupPause)
+			}
+			if err = backup.Finish(); err != nil {
+				return fmt.Errorf("copyDatabase - Finish: %w", err)
+			}
+			return nil
+		})
+	})
+}
@@ -2,24 +2,91 @@ package sqlite3
 
 import (
 	"database/sql"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"forum/pkg/sqlconn"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
 type Sqlite struct {
 	DB *sql.DB
+	// Conn is what repositories query through, the pool DB or a
+	// transaction of it.
+	Conn sqlconn.Conn
+}
+
+// Options tune the connections to the database, zero values keep the
+// defaults of SQLite and database/sql.
+type Options struct {
+	// JournalMode is one of DELETE, TRUNCATE, PERSIST, MEMORY, WAL and OFF.
+	// In WAL mode readers don't block the writer and the other way round.
+	JournalMode string
+	// Synchronous is one of OFF, NORMAL, FULL and EXTRA.
+	Synchronous string
+	// BusyTimeout is how long a connection waits for a lock held by
+	// another one before it fails with "database is locked".
+	BusyTimeout time.Duration
+	// ForeignKeys makes SQLite enforce FOREIGN KEY constraints.
+	ForeignKeys bool
+
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
 }
 
-func New(path string) (*Sqlite, error) {
-	db, err := sql.Open("sqlite3", path)
+// New opens the database at path and applies opts to every connection.
+// Transactions take the write lock as they begin, so concurrent writers
+// wait for each other for BusyTimeout instead of failing to upgrade their
+// read locks halfway.
+func New(path string, opts Options) (*Sqlite, error) {
+	db, err := sql.Open("sqlite3", dsn(path, opts))
 	if err != nil {
 		return nil, err
 	}
+	db.SetMaxOpenConns(opts.MaxOpenConns)
+	// zero would close every released connection
+	if opts.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(opts.MaxIdleConns)
+	}
+	db.SetConnMaxLifetime(opts.ConnMaxLifetime)
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
 	return &Sqlite{
-		DB: db,
+		DB:   db,
+		Conn: sqlconn.Pool(db),
 	}, nil
 }
 
 func (s *Sqlite) Close() {
 	s.DB.Close()
 }
+
+// dsn appends opts to path as the connection parameters of the driver.
+func dsn(path string, opts Options) string {
+	params := url.Values{}
+	params.Set("_txlock", "immediate")
+	if opts.JournalMode != "" {
+		params.Set("_journal_mode", opts.JournalMode)
+	}
+	if opts.Synchronous != "" {
+		params.Set("_synchronous", opts.Synchronous)
+	}
+	if opts.BusyTimeout > 0 {
+		params.Set("_busy_timeout", strconv.FormatInt(opts.BusyTimeout.Milliseconds(), 10))
+	}
+	if opts.ForeignKeys {
+		params.Set("_foreign_keys", "1")
+	}
+
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	return path + separator + params.Encode()
+}
@@ -0,0 +1,109 @@
+package totp_test
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"forum/pkg/totp"
+)
+
+// rfcSecret is the SHA-1 key of the RFC 6238 test vectors,
+// "12345678901234567890" in base32.
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCode(t *testing.T) {
+	// the RFC lists 8 digit codes, 6 digit ones are their last digits
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			code, err := totp.Code(rfcSecret, totp.Step(time.Unix(tt.unix, 0)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if code != tt.want {
+				t.Fatalf("want: %s, got: %s", tt.want, code)
+			}
+		})
+	}
+
+	t.Run("OK lower case secret", func(t *testing.T) {
+		code, err := totp.Code(strings.ToLower(rfcSecret), totp.Step(time.Unix(59, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != "287082" {
+			t.Fatalf("want: %s, got: %s", "287082", code)
+		}
+	})
+
+	t.Run("err secret", func(t *testing.T) {
+		if _, err := totp.Code("not base32!", 1); err == nil {
+			t.Fatal("want error")
+		}
+	})
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := totp.Step(now)
+	codeAt := func(t *testing.T, step int64) string {
+		t.Helper()
+		code, err := totp.Code(rfcSecret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+
+	t.Run("OK within skew", func(t *testing.T) {
+		for offset := int64(-2); offset <= 2; offset++ {
+			found, ok := totp.Validate(rfcSecret, codeAt(t, step+offset), now, 2)
+			if !ok || found != step+offset {
+				t.Fatalf("offset %d: want step %d, got: %d, %v", offset, step+offset, found, ok)
+			}
+		}
+	})
+
+	t.Run("OK spaces", func(t *testing.T) {
+		code := codeAt(t, step)
+		if found, ok := totp.Validate(rfcSecret, code[:3]+" "+code[3:], now, 0); !ok || found != step {
+			t.Fatalf("want step %d, got: %d, %v", step, found, ok)
+		}
+	})
+
+	t.Run("err outside skew", func(t *testing.T) {
+		for _, offset := range []int64{-3, 3, -10, 10} {
+			if found, ok := totp.Validate(rfcSecret, codeAt(t, step+offset), now, 2); ok {
+				t.Fatalf("offset %d: want refused, got step %d", offset, found)
+			}
+		}
+		if _, ok := totp.Validate(rfcSecret, codeAt(t, step+1), now, 0); ok {
+			t.Fatal("want the next step refused without skew")
+		}
+	})
+
+	t.Run("err malformed", func(t *testing.T) {
+		code := codeAt(t, step)
+		for _, bad := range []string{"", code[:5], code + "0", "abcdef"} {
+			if _, ok := totp.Validate(rfcSecret, bad, now, 2); ok {
+				t.Fatalf("want %q refused", bad)
+			}
+		}
+		if _, ok := totp.Validate("not base32!", code, now, 2); ok {
+			t.Fatal("want bad secret refused")
+		}
+	})
+}